@@ -20,6 +20,7 @@ import (
 
 	"github.com/joho/godotenv"
 	configaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/config_access"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/cmd"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
@@ -186,6 +187,12 @@ func main() {
 	var projectID string
 	var vertexImport string
 	var vertexImportPrefix string
+	var rotateAuthKey bool
+	var migrateStore string
+	var replayRequestID string
+	var replayAuthID string
+	var replayModel string
+	var migrateDryRun bool
 	var configPath string
 	var password string
 	var homeAddr string
@@ -210,6 +217,12 @@ func main() {
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
 	flag.StringVar(&vertexImportPrefix, "vertex-import-prefix", "", "Prefix for Vertex model namespacing (use with -vertex-import)")
+	flag.BoolVar(&rotateAuthKey, "rotate-auth-key", false, "Re-encrypt all auth files with the current auth-encryption key")
+	flag.StringVar(&migrateStore, "migrate-store", "", "Copy auth files and config to another store: file, git, postgres or object (target settings from MIGRATE_* env vars)")
+	flag.BoolVar(&migrateDryRun, "migrate-dry-run", false, "Print the -migrate-store diff without writing to the target")
+	flag.StringVar(&replayRequestID, "replay", "", "Replay a logged request by ID through the running server and print the upstream diff")
+	flag.StringVar(&replayAuthID, "replay-auth", "", "Pin the -replay request to this auth ID")
+	flag.StringVar(&replayModel, "replay-model", "", "Replay the -replay request with this model instead")
 	flag.StringVar(&password, "password", "", "")
 	flag.StringVar(&homeAddr, "home", "", "Home control plane address in host:port, redis://host:port, or rediss://host:port format (loads config from home and skips local config file)")
 	flag.StringVar(&homePassword, "home-password", "", "Home control plane password (Redis AUTH)")
@@ -516,33 +529,11 @@ func main() {
 			}
 		}
 		objectStoreRoot := filepath.Join(objectStoreLocalPath, "objectstore")
-		resolvedEndpoint := strings.TrimSpace(objectStoreEndpoint)
-		useSSL := true
-		if strings.Contains(resolvedEndpoint, "://") {
-			parsed, errParse := url.Parse(resolvedEndpoint)
-			if errParse != nil {
-				log.Errorf("failed to parse object store endpoint %q: %v", objectStoreEndpoint, errParse)
-				return
-			}
-			switch strings.ToLower(parsed.Scheme) {
-			case "http":
-				useSSL = false
-			case "https":
-				useSSL = true
-			default:
-				log.Errorf("unsupported object store scheme %q (only http and https are allowed)", parsed.Scheme)
-				return
-			}
-			if parsed.Host == "" {
-				log.Errorf("object store endpoint %q is missing host information", objectStoreEndpoint)
-				return
-			}
-			resolvedEndpoint = parsed.Host
-			if parsed.Path != "" && parsed.Path != "/" {
-				resolvedEndpoint = strings.TrimSuffix(parsed.Host+parsed.Path, "/")
-			}
+		resolvedEndpoint, useSSL, errEndpoint := store.ParseObjectEndpoint(objectStoreEndpoint)
+		if errEndpoint != nil {
+			log.Error(errEndpoint)
+			return
 		}
-		resolvedEndpoint = strings.TrimRight(resolvedEndpoint, "/")
 		objCfg := store.ObjectStoreConfig{
 			Endpoint:  resolvedEndpoint,
 			Bucket:    objectStoreBucket,
@@ -684,6 +675,11 @@ func main() {
 	}
 	managementasset.SetCurrentConfig(cfg)
 
+	if errAuthEncryption := authcrypt.Configure(cfg.AuthEncryption); errAuthEncryption != nil {
+		log.Errorf("failed to configure auth encryption: %v", errAuthEncryption)
+		return
+	}
+
 	// Create login options to be used in authentication flows.
 	options := &cmd.LoginOptions{
 		NoBrowser:    noBrowser,
@@ -709,6 +705,15 @@ func main() {
 	if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport, vertexImportPrefix)
+	} else if strings.TrimSpace(migrateStore) != "" {
+		// Copy auth records and config to another store backend
+		cmd.DoMigrateStore(cfg, configFilePath, store.MigrationTargetFromEnv(migrateStore), migrateDryRun)
+	} else if strings.TrimSpace(replayRequestID) != "" {
+		// Replay a logged request against the running server
+		cmd.DoReplayRequest(cfg, replayRequestID, replayAuthID, replayModel, password)
+	} else if rotateAuthKey {
+		// Re-encrypt stored auth files with the current key
+		cmd.DoRotateAuthKey(cfg)
 	} else if login {
 		// Handle Google/Gemini login
 		cmd.DoLogin(cfg, projectID, options)
@@ -783,7 +788,7 @@ func main() {
 				ready := false
 				backoff := 100 * time.Millisecond
 				for i := 0; i < 30; i++ {
-					if _, errGetConfig := client.GetConfig(); errGetConfig == nil {
+					if session, errSession := client.LoadSession(); errSession == nil && session != nil {
 						ready = true
 						break
 					}
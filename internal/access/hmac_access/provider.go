@@ -0,0 +1,223 @@
+// Package hmacaccess implements the "hmac" access provider for service-to-service calls.
+// Callers sign each request with a shared secret instead of sending the secret itself.
+//
+// The signature is the lower-case hex HMAC-SHA256 of
+//
+//	METHOD "\n" REQUEST-URI "\n" TIMESTAMP "\n" hex(SHA256(body))
+//
+// where REQUEST-URI is the path plus raw query exactly as sent, and TIMESTAMP is the Unix
+// time in seconds. The key ID, timestamp and signature travel in the KeyIDHeader,
+// TimestampHeader and SignatureHeader headers.
+package hmacaccess
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// KeyIDHeader names the signing key.
+	KeyIDHeader = "X-CLIProxy-Key-Id"
+	// TimestampHeader carries the signing time in Unix seconds.
+	TimestampHeader = "X-CLIProxy-Timestamp"
+	// SignatureHeader carries the hex-encoded HMAC-SHA256 signature.
+	SignatureHeader = "X-CLIProxy-Signature"
+
+	defaultMaxSkew  = 5 * time.Minute
+	maxSignedBodyMB = 64
+)
+
+// Key is one shared secret accepted by the provider.
+type Key struct {
+	ID            string   `json:"id"`
+	Secret        string   `json:"secret"`
+	AllowedModels []string `json:"allowed-models"`
+}
+
+type options struct {
+	Keys           []Key `json:"keys"`
+	MaxSkewSeconds int   `json:"max-skew-seconds"`
+}
+
+// Provider validates HMAC-signed requests.
+type Provider struct {
+	name    string
+	keys    map[string]Key
+	maxSkew time.Duration
+	now     func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// New builds an hmac provider from its configuration entry.
+func New(cfg sdkaccess.AccessProvider) (*Provider, error) {
+	var opts options
+	if err := cfg.DecodeConfig(&opts); err != nil {
+		return nil, err
+	}
+	p := &Provider{
+		name:    cfg.Name,
+		keys:    make(map[string]Key, len(opts.Keys)),
+		maxSkew: defaultMaxSkew,
+		now:     time.Now,
+		seen:    make(map[string]time.Time),
+	}
+	if opts.MaxSkewSeconds > 0 {
+		p.maxSkew = time.Duration(opts.MaxSkewSeconds) * time.Second
+	}
+	for _, key := range opts.Keys {
+		key.ID = strings.TrimSpace(key.ID)
+		if key.ID == "" || key.Secret == "" {
+			return nil, fmt.Errorf("access provider %s: every key needs an id and a secret", cfg.Name)
+		}
+		if _, exists := p.keys[key.ID]; exists {
+			return nil, fmt.Errorf("access provider %s: duplicate key id %q", cfg.Name, key.ID)
+		}
+		p.keys[key.ID] = key
+	}
+	if len(p.keys) == 0 {
+		return nil, fmt.Errorf("access provider %s: no keys configured", cfg.Name)
+	}
+	return p, nil
+}
+
+// Identifier returns the configured provider name.
+func (p *Provider) Identifier() string {
+	if p == nil {
+		return sdkaccess.AccessProviderTypeHMAC
+	}
+	return p.name
+}
+
+// Status reports the configured key IDs.
+func (p *Provider) Status() map[string]any {
+	if p == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(p.keys))
+	for id := range p.keys {
+		ids = append(ids, id)
+	}
+	return map[string]any{"key-ids": ids}
+}
+
+// Authenticate verifies the request signature. Requests without the signature headers are
+// left to other providers.
+func (p *Provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if p == nil || r == nil {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	keyID := strings.TrimSpace(r.Header.Get(KeyIDHeader))
+	signature := strings.ToLower(strings.TrimSpace(r.Header.Get(SignatureHeader)))
+	timestamp := strings.TrimSpace(r.Header.Get(TimestampHeader))
+	if keyID == "" && signature == "" {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	key, ok := p.keys[keyID]
+	if !ok || signature == "" || timestamp == "" {
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	now := p.now()
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-p.maxSkew)) || signedAt.After(now.Add(p.maxSkew)) {
+		log.Debugf("access provider %s: rejected request from %s: timestamp outside allowed skew", p.name, keyID)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+
+	body, err := readBody(r)
+	if errors.Is(err, errBodyTooLarge) {
+		return nil, sdkaccess.NewRequestTooLargeError(fmt.Sprintf("Signed request body exceeds %d MB", maxSignedBodyMB))
+	}
+	if err != nil {
+		return nil, sdkaccess.NewInternalAuthError("Failed to read request body", err)
+	}
+	expected := Sign(key.Secret, r.Method, requestURI(r), timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		log.Debugf("access provider %s: rejected request from %s: signature mismatch", p.name, keyID)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	if !p.remember(signature, signedAt, now) {
+		log.Debugf("access provider %s: rejected replayed request from %s", p.name, keyID)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+
+	metadata := map[string]string{
+		"source":                   "hmac-signature",
+		"key-id":                   keyID,
+		sdkaccess.MetadataAuthType: sdkaccess.AccessProviderTypeHMAC,
+	}
+	if len(key.AllowedModels) > 0 {
+		metadata[sdkaccess.MetadataAllowedModels] = strings.Join(key.AllowedModels, ",")
+	}
+	return &sdkaccess.Result{Provider: p.Identifier(), Principal: keyID, Metadata: metadata}, nil
+}
+
+// Sign returns the hex signature for a request. Clients can use it to build SignatureHeader.
+func Sign(secret, method, requestURI, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.ToUpper(method) + "\n" + requestURI + "\n" + timestamp + "\n" + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// remember records signature and reports false when it was already used within the skew
+// window, so a captured request cannot be replayed.
+func (p *Provider) remember(signature string, signedAt, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for sig, at := range p.seen {
+		if now.Sub(at) > p.maxSkew {
+			delete(p.seen, sig)
+		}
+	}
+	if _, used := p.seen[signature]; used {
+		return false
+	}
+	p.seen[signature] = signedAt
+	return true
+}
+
+// errBodyTooLarge reports a signed body over maxSignedBodyMB.
+var errBodyTooLarge = errors.New("request body too large")
+
+// readBody reads the request body and restores it for the handlers. Bodies over the limit
+// are rejected rather than truncated, since a truncated body is not what was signed.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	const limit = maxSignedBodyMB << 20
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	_ = r.Body.Close()
+	if err == nil && len(body) > limit {
+		return nil, errBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+func requestURI(r *http.Request) string {
+	if r.URL == nil {
+		return r.RequestURI
+	}
+	return r.URL.RequestURI()
+}
@@ -0,0 +1,103 @@
+package hmacaccess
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+)
+
+func newTestProvider(t *testing.T) *Provider {
+	t.Helper()
+	p, err := New(sdkaccess.AccessProvider{
+		Name: "services",
+		Type: sdkaccess.AccessProviderTypeHMAC,
+		Config: map[string]any{
+			"keys": []map[string]any{{"id": "billing", "secret": "s3cret", "allowed-models": []string{"gemini-*"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+func signedRequest(body, secret string, at time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions?alt=sse", strings.NewReader(body))
+	ts := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set(KeyIDHeader, "billing")
+	req.Header.Set(TimestampHeader, ts)
+	req.Header.Set(SignatureHeader, Sign(secret, http.MethodPost, "/v1/chat/completions?alt=sse", ts, []byte(body)))
+	return req
+}
+
+func TestProviderAuthenticate_ValidSignature(t *testing.T) {
+	p := newTestProvider(t)
+	req := signedRequest(`{"model":"gemini-2.5-pro"}`, "s3cret", time.Now())
+
+	result, authErr := p.Authenticate(context.Background(), req)
+	if authErr != nil {
+		t.Fatalf("Authenticate error = %v", authErr)
+	}
+	if result.Principal != "billing" || result.Metadata[sdkaccess.MetadataAllowedModels] != "gemini-*" {
+		t.Fatalf("result = %+v", result)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != `{"model":"gemini-2.5-pro"}` {
+		t.Fatalf("body not restored: %q", body)
+	}
+}
+
+func TestProviderAuthenticate_RejectsTamperedReplayedAndStale(t *testing.T) {
+	p := newTestProvider(t)
+
+	tampered := signedRequest(`{"model":"gemini-2.5-pro"}`, "s3cret", time.Now())
+	tampered.Body = io.NopCloser(strings.NewReader(`{"model":"gpt-5"}`))
+	if _, authErr := p.Authenticate(context.Background(), tampered); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+		t.Fatalf("tampered: error = %v", authErr)
+	}
+
+	wrongSecret := signedRequest(`{}`, "other", time.Now())
+	if _, authErr := p.Authenticate(context.Background(), wrongSecret); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+		t.Fatalf("wrong secret: error = %v", authErr)
+	}
+
+	stale := signedRequest(`{}`, "s3cret", time.Now().Add(-10*time.Minute))
+	if _, authErr := p.Authenticate(context.Background(), stale); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+		t.Fatalf("stale: error = %v", authErr)
+	}
+
+	at := time.Now()
+	if _, authErr := p.Authenticate(context.Background(), signedRequest(`{}`, "s3cret", at)); authErr != nil {
+		t.Fatalf("first use: error = %v", authErr)
+	}
+	if _, authErr := p.Authenticate(context.Background(), signedRequest(`{}`, "s3cret", at)); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+		t.Fatalf("replay: error = %v", authErr)
+	}
+}
+
+func TestProviderAuthenticate_UnsignedRequestNotHandled(t *testing.T) {
+	p := newTestProvider(t)
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer sk-key")
+	if _, authErr := p.Authenticate(context.Background(), req); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeNotHandled) {
+		t.Fatalf("error = %v, want not handled", authErr)
+	}
+}
+
+func TestProviderAuthenticate_RejectsOversizedBody(t *testing.T) {
+	p := newTestProvider(t)
+	body := strings.Repeat("a", maxSignedBodyMB<<20+1)
+	req := signedRequest(body, "s3cret", time.Now())
+
+	_, authErr := p.Authenticate(context.Background(), req)
+	if authErr == nil || authErr.HTTPStatusCode() != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Authenticate error = %v, want 413", authErr)
+	}
+}
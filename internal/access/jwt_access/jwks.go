@@ -0,0 +1,269 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultJWKSRefresh = time.Hour
+	// minJWKSReload bounds how often an unknown kid may trigger an early reload.
+	minJWKSReload    = 30 * time.Second
+	jwksFetchTimeout = 10 * time.Second
+	maxJWKSBodySize  = 1 << 20
+)
+
+// jsonWebKey is a verified public key from a JWKS document.
+type jsonWebKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// keySet loads and caches the JWKS from a URL or a file.
+type keySet struct {
+	url     string
+	file    string
+	refresh time.Duration
+	client  *http.Client
+
+	loadMu sync.Mutex
+	// refreshing is set while a background reload of stale keys runs.
+	refreshing atomic.Bool
+	mu         sync.RWMutex
+	keys       []jsonWebKey
+	loaded     time.Time
+	tried      time.Time
+	err        error
+}
+
+func newKeySet(url, file string, refresh time.Duration) *keySet {
+	if refresh <= 0 {
+		refresh = defaultJWKSRefresh
+	}
+	return &keySet{url: url, file: file, refresh: refresh, client: &http.Client{Timeout: jwksFetchTimeout}}
+}
+
+// lookup returns the candidate keys for kid, reloading the set when it is stale or when
+// kid is unknown. Reloads are attempted at most every minJWKSReload, so an unreachable
+// JWKS endpoint does not stall every request; stale keys keep serving meanwhile and are
+// refreshed in the background when they still match kid.
+func (s *keySet) lookup(kid string) ([]jsonWebKey, error) {
+	now := time.Now()
+	s.mu.RLock()
+	keys, loaded, tried := s.keys, s.loaded, s.tried
+	s.mu.RUnlock()
+
+	matched := matchKeys(keys, kid)
+	stale := loaded.IsZero() || now.Sub(loaded) >= s.refresh
+	if (stale || matched == nil) && now.Sub(tried) >= minJWKSReload {
+		if matched != nil {
+			if s.refreshing.CompareAndSwap(false, true) {
+				go func() {
+					defer s.refreshing.Store(false)
+					s.reload(tried)
+				}()
+			}
+			return matched, nil
+		}
+		s.reload(tried)
+	}
+	s.mu.RLock()
+	keys = s.keys
+	err := s.err
+	s.mu.RUnlock()
+	if len(keys) == 0 && err != nil {
+		return nil, err
+	}
+	return matchKeys(keys, kid), nil
+}
+
+// reload fetches the key set unless another caller already did so after seen. The fetch
+// runs on its own context so a cancelled request does not fail the load for everyone.
+func (s *keySet) reload(seen time.Time) {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	s.mu.RLock()
+	alreadyTried := s.tried.After(seen)
+	s.mu.RUnlock()
+	if alreadyTried {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+	keys, err := s.fetch(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tried = time.Now()
+	s.err = err
+	if err == nil {
+		s.keys = keys
+		s.loaded = s.tried
+	}
+}
+
+func (s *keySet) fetch(ctx context.Context) ([]jsonWebKey, error) {
+	var data []byte
+	if s.file != "" {
+		raw, err := os.ReadFile(s.file)
+		if err != nil {
+			return nil, fmt.Errorf("read jwks file: %w", err)
+		}
+		data = raw
+	} else {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("build jwks request: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("fetch jwks: %w", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+		}
+		raw, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSBodySize))
+		if err != nil {
+			return nil, fmt.Errorf("read jwks: %w", err)
+		}
+		data = raw
+	}
+	return parseJWKS(data)
+}
+
+// status reports the cached key count and the outcome of the last load.
+func (s *keySet) status() map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := map[string]any{"keys": len(s.keys)}
+	if !s.loaded.IsZero() {
+		out["loaded-at"] = s.loaded.UTC()
+	}
+	if s.err != nil {
+		out["last-error"] = s.err.Error()
+	}
+	return out
+}
+
+func matchKeys(keys []jsonWebKey, kid string) []jsonWebKey {
+	if kid == "" {
+		return keys
+	}
+	for _, key := range keys {
+		if key.kid == kid {
+			return []jsonWebKey{key}
+		}
+	}
+	return nil
+}
+
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS decodes the public signing keys of a JWKS document. Keys of unsupported types
+// are skipped; a document without any usable key is an error.
+func parseJWKS(data []byte) ([]jsonWebKey, error) {
+	var doc struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+	keys := make([]jsonWebKey, 0, len(doc.Keys))
+	for _, raw := range doc.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.publicKey()
+		if err != nil {
+			continue
+		}
+		keys = append(keys, jsonWebKey{kid: raw.Kid, alg: raw.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k rawJWK) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, errN := decodeBigInt(k.N)
+		e, errE := decodeBigInt(k.E)
+		if errN != nil || errE != nil || !e.IsInt64() {
+			return nil, errors.New("invalid rsa key")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) > size || len(y) > size {
+			return nil, errors.New("invalid ec key")
+		}
+		point := make([]byte, 1+2*size)
+		point[0] = 4
+		copy(point[1+size-len(x):1+size], x)
+		copy(point[1+2*size-len(y):], y)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid integer")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,390 @@
+// Package jwtaccess implements the "jwt" access provider, which authenticates clients with
+// short-lived JSON Web Tokens issued by an external identity provider and verified against
+// its JWKS.
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const defaultLeeway = 60 * time.Second
+
+// options is the provider-specific configuration under access.providers[].config.
+type options struct {
+	JWKSURL            string            `json:"jwks-url"`
+	JWKSFile           string            `json:"jwks-file"`
+	JWKSRefreshSeconds int               `json:"jwks-refresh-seconds"`
+	Issuer             string            `json:"issuer"`
+	Audience           stringList        `json:"audience"`
+	Algorithms         stringList        `json:"algorithms"`
+	PrincipalClaim     string            `json:"principal-claim"`
+	ModelsClaim        string            `json:"models-claim"`
+	MetadataClaims     map[string]string `json:"metadata-claims"`
+	LeewaySeconds      *int              `json:"leeway-seconds"`
+}
+
+// stringList accepts either a single string or a list of strings.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = splitList(single)
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Provider validates bearer JWTs.
+type Provider struct {
+	name           string
+	keys           *keySet
+	issuer         string
+	audience       []string
+	algorithms     map[string]struct{}
+	principalClaim string
+	modelsClaim    string
+	metadataClaims map[string]string
+	leeway         time.Duration
+	now            func() time.Time
+}
+
+// New builds a jwt provider from its configuration entry. The JWKS is loaded lazily on the
+// first request.
+func New(cfg sdkaccess.AccessProvider) (*Provider, error) {
+	var opts options
+	if err := cfg.DecodeConfig(&opts); err != nil {
+		return nil, err
+	}
+	opts.JWKSURL = strings.TrimSpace(opts.JWKSURL)
+	opts.JWKSFile = strings.TrimSpace(opts.JWKSFile)
+	if (opts.JWKSURL == "") == (opts.JWKSFile == "") {
+		return nil, fmt.Errorf("access provider %s: exactly one of jwks-url or jwks-file is required", cfg.Name)
+	}
+	p := &Provider{
+		name:           cfg.Name,
+		keys:           newKeySet(opts.JWKSURL, opts.JWKSFile, time.Duration(opts.JWKSRefreshSeconds)*time.Second),
+		issuer:         strings.TrimSpace(opts.Issuer),
+		principalClaim: strings.TrimSpace(opts.PrincipalClaim),
+		modelsClaim:    strings.TrimSpace(opts.ModelsClaim),
+		metadataClaims: opts.MetadataClaims,
+		leeway:         defaultLeeway,
+		now:            time.Now,
+	}
+	if p.principalClaim == "" {
+		p.principalClaim = "sub"
+	}
+	if opts.LeewaySeconds != nil && *opts.LeewaySeconds >= 0 {
+		p.leeway = time.Duration(*opts.LeewaySeconds) * time.Second
+	}
+	for _, aud := range opts.Audience {
+		if aud = strings.TrimSpace(aud); aud != "" {
+			p.audience = append(p.audience, aud)
+		}
+	}
+	if len(opts.Algorithms) > 0 {
+		p.algorithms = make(map[string]struct{}, len(opts.Algorithms))
+		for _, alg := range opts.Algorithms {
+			alg = strings.ToUpper(strings.TrimSpace(alg))
+			if _, ok := hashFor(alg); !ok && alg != "EDDSA" {
+				return nil, fmt.Errorf("access provider %s: unsupported algorithm %q", cfg.Name, alg)
+			}
+			p.algorithms[alg] = struct{}{}
+		}
+	}
+	return p, nil
+}
+
+// Identifier returns the configured provider name.
+func (p *Provider) Identifier() string {
+	if p == nil {
+		return sdkaccess.AccessProviderTypeJWT
+	}
+	return p.name
+}
+
+// Status reports the state of the cached key set.
+func (p *Provider) Status() map[string]any {
+	if p == nil {
+		return nil
+	}
+	return p.keys.status()
+}
+
+// Authenticate verifies the JWT presented as a bearer token (or x-api-key / x-goog-api-key
+// for clients that can only send API keys). Credentials that are not JWTs are left to
+// other providers.
+func (p *Provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if p == nil {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	token, source := extractToken(r)
+	if token == "" {
+		return nil, sdkaccess.NewNoCredentialsError()
+	}
+	parsed, ok := parseToken(token)
+	if !ok {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	keys, err := p.keys.lookup(parsed.header.Kid)
+	if err != nil {
+		log.Errorf("access provider %s: %v", p.name, err)
+		return nil, sdkaccess.NewInternalAuthError("Authentication service unavailable", err)
+	}
+	if errVerify := p.verify(parsed, keys); errVerify != nil {
+		log.Debugf("access provider %s: rejected token: %v", p.name, errVerify)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	claims := gjson.ParseBytes(parsed.claims)
+	if errClaims := p.validateClaims(claims); errClaims != nil {
+		log.Debugf("access provider %s: rejected token: %v", p.name, errClaims)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	principal := strings.TrimSpace(claims.Get(p.principalClaim).String())
+	if principal == "" {
+		log.Debugf("access provider %s: rejected token: claim %s is empty", p.name, p.principalClaim)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+
+	metadata := map[string]string{
+		"source":                   source,
+		sdkaccess.MetadataAuthType: sdkaccess.AccessProviderTypeJWT,
+	}
+	if iss := claims.Get("iss").String(); iss != "" {
+		metadata["issuer"] = iss
+	}
+	for key, path := range p.metadataClaims {
+		if value := claims.Get(path); value.Exists() {
+			metadata[key] = value.String()
+		}
+	}
+	if p.modelsClaim != "" {
+		// A configured but missing or empty claim grants no models rather than all of them.
+		models := claimList(claims.Get(p.modelsClaim))
+		if len(models) == 0 {
+			log.Debugf("access provider %s: rejected token: claim %s grants no models", p.name, p.modelsClaim)
+			return nil, sdkaccess.NewInvalidCredentialError()
+		}
+		metadata[sdkaccess.MetadataAllowedModels] = strings.Join(models, ",")
+	}
+	return &sdkaccess.Result{Provider: p.Identifier(), Principal: principal, Metadata: metadata}, nil
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type parsedToken struct {
+	header       tokenHeader
+	claims       []byte
+	signingInput []byte
+	signature    []byte
+}
+
+// parseToken decodes a compact JWS. It reports false for values that are not JWTs.
+func parseToken(token string) (parsedToken, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return parsedToken{}, false
+	}
+	headerJSON, errHeader := base64.RawURLEncoding.DecodeString(parts[0])
+	claims, errClaims := base64.RawURLEncoding.DecodeString(parts[1])
+	signature, errSig := base64.RawURLEncoding.DecodeString(parts[2])
+	if errHeader != nil || errClaims != nil || errSig != nil {
+		return parsedToken{}, false
+	}
+	var header tokenHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg == "" {
+		return parsedToken{}, false
+	}
+	if !json.Valid(claims) {
+		return parsedToken{}, false
+	}
+	return parsedToken{
+		header:       header,
+		claims:       claims,
+		signingInput: []byte(parts[0] + "." + parts[1]),
+		signature:    signature,
+	}, true
+}
+
+func (p *Provider) verify(token parsedToken, keys []jsonWebKey) error {
+	alg := strings.ToUpper(token.header.Alg)
+	if p.algorithms != nil {
+		if _, ok := p.algorithms[alg]; !ok {
+			return fmt.Errorf("algorithm %s not allowed", token.header.Alg)
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no key matches kid %q", token.header.Kid)
+	}
+	for _, key := range keys {
+		if key.alg != "" && !strings.EqualFold(key.alg, alg) {
+			continue
+		}
+		if verifySignature(alg, key.key, token.signingInput, token.signature) {
+			return nil
+		}
+	}
+	return errors.New("signature verification failed")
+}
+
+// verifySignature checks sig for the supported asymmetric algorithms. Symmetric and "none"
+// algorithms are rejected so a public key can never be used as an HMAC secret.
+func verifySignature(alg string, key crypto.PublicKey, input, sig []byte) bool {
+	if alg == "EDDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, input, sig)
+	}
+	hash, ok := hashFor(alg)
+	if !ok {
+		return false
+	}
+	h := hash.New()
+	h.Write(input)
+	digest := h.Sum(nil)
+	switch alg[:2] {
+	case "RS":
+		pub, okKey := key.(*rsa.PublicKey)
+		return okKey && rsa.VerifyPKCS1v15(pub, hash, digest, sig) == nil
+	case "PS":
+		pub, okKey := key.(*rsa.PublicKey)
+		return okKey && rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+	case "ES":
+		pub, okKey := key.(*ecdsa.PublicKey)
+		if !okKey || pub.Curve.Params().BitSize != curveBits(hash) {
+			return false
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	return false
+}
+
+func hashFor(alg string) (crypto.Hash, bool) {
+	if len(alg) != 5 {
+		return 0, false
+	}
+	switch alg[:2] {
+	case "RS", "PS", "ES":
+	default:
+		return 0, false
+	}
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, true
+	case "384":
+		return crypto.SHA384, true
+	case "512":
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+// curveBits returns the curve size paired with hash by the ES* algorithms.
+func curveBits(hash crypto.Hash) int {
+	switch hash {
+	case crypto.SHA256:
+		return 256
+	case crypto.SHA384:
+		return 384
+	default:
+		return 521
+	}
+}
+
+func (p *Provider) validateClaims(claims gjson.Result) error {
+	now := p.now()
+	exp := claims.Get("exp")
+	if exp.Type != gjson.Number {
+		return errors.New("missing exp claim")
+	}
+	if now.After(time.Unix(exp.Int(), 0).Add(p.leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf := claims.Get("nbf"); nbf.Type == gjson.Number && now.Add(p.leeway).Before(time.Unix(nbf.Int(), 0)) {
+		return errors.New("token not yet valid")
+	}
+	if p.issuer != "" && claims.Get("iss").String() != p.issuer {
+		return fmt.Errorf("unexpected issuer %q", claims.Get("iss").String())
+	}
+	if len(p.audience) > 0 {
+		matched := false
+		for _, aud := range claimList(claims.Get("aud")) {
+			for _, want := range p.audience {
+				if aud == want {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return errors.New("audience mismatch")
+		}
+	}
+	return nil
+}
+
+// claimList reads a claim holding either an array or a space/comma separated string.
+func claimList(value gjson.Result) []string {
+	if value.IsArray() {
+		var out []string
+		for _, item := range value.Array() {
+			if s := strings.TrimSpace(item.String()); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return splitList(value.String())
+}
+
+func splitList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+func extractToken(r *http.Request) (string, string) {
+	if r == nil {
+		return "", ""
+	}
+	if header := strings.TrimSpace(r.Header.Get("Authorization")); header != "" {
+		if scheme, value, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "bearer") {
+			return strings.TrimSpace(value), "authorization"
+		}
+	}
+	if value := strings.TrimSpace(r.Header.Get("X-Api-Key")); value != "" {
+		return value, "x-api-key"
+	}
+	if value := strings.TrimSpace(r.Header.Get("X-Goog-Api-Key")); value != "" {
+		return value, "x-goog-api-key"
+	}
+	return "", ""
+}
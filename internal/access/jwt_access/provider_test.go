@@ -0,0 +1,195 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+)
+
+func newTestProvider(t *testing.T, extra map[string]any) (*Provider, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	jwks := map[string]any{"keys": []map[string]any{{
+		"kty": "RSA",
+		"kid": "k1",
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, _ := json.Marshal(jwks)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+	cfg := map[string]any{"jwks-file": path, "issuer": "https://idp.test/", "audience": "proxy"}
+	for k, v := range extra {
+		cfg[k] = v
+	}
+	p, err := New(sdkaccess.AccessProvider{Name: "sso", Type: sdkaccess.AccessProviderTypeJWT, Config: cfg})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p, key
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func authenticate(p *Provider, token string) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return p.Authenticate(context.Background(), req)
+}
+
+func TestProviderAuthenticate_ValidToken(t *testing.T) {
+	p, key := newTestProvider(t, map[string]any{
+		"models-claim":    "models",
+		"metadata-claims": map[string]string{"team": "team"},
+	})
+	token := signToken(t, key, map[string]any{
+		"sub":    "alice",
+		"iss":    "https://idp.test/",
+		"aud":    []string{"other", "proxy"},
+		"exp":    time.Now().Add(time.Minute).Unix(),
+		"models": []string{"gemini-*", "gpt-5"},
+		"team":   "research",
+	})
+
+	result, authErr := authenticate(p, token)
+	if authErr != nil {
+		t.Fatalf("Authenticate error = %v", authErr)
+	}
+	if result.Principal != "alice" || result.Provider != "sso" {
+		t.Fatalf("result = %+v", result)
+	}
+	if got := result.Metadata[sdkaccess.MetadataAllowedModels]; got != "gemini-*,gpt-5" {
+		t.Fatalf("allowed models = %q", got)
+	}
+	if got := result.Metadata["team"]; got != "research" {
+		t.Fatalf("team metadata = %q", got)
+	}
+}
+
+func TestProviderAuthenticate_RejectsInvalidTokens(t *testing.T) {
+	p, key := newTestProvider(t, map[string]any{"models-claim": "models"})
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	valid := func() map[string]any {
+		return map[string]any{
+			"sub":    "alice",
+			"iss":    "https://idp.test/",
+			"aud":    "proxy",
+			"exp":    time.Now().Add(time.Minute).Unix(),
+			"models": "gemini-*",
+		}
+	}
+	cases := map[string]string{}
+	expired := valid()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	cases["expired"] = signToken(t, key, expired)
+	wrongAudience := valid()
+	wrongAudience["aud"] = "someone-else"
+	cases["audience"] = signToken(t, key, wrongAudience)
+	wrongIssuer := valid()
+	wrongIssuer["iss"] = "https://evil.test/"
+	cases["issuer"] = signToken(t, key, wrongIssuer)
+	noModels := valid()
+	delete(noModels, "models")
+	cases["no models"] = signToken(t, key, noModels)
+	cases["foreign key"] = signToken(t, otherKey, valid())
+
+	for name, token := range cases {
+		if _, authErr := authenticate(p, token); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+			t.Errorf("%s: error = %v, want invalid credential", name, authErr)
+		}
+	}
+}
+
+func TestProviderAuthenticate_IgnoresPlainAPIKeys(t *testing.T) {
+	p, _ := newTestProvider(t, nil)
+	if _, authErr := authenticate(p, "sk-plain-key"); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeNotHandled) {
+		t.Fatalf("error = %v, want not handled", authErr)
+	}
+}
+
+func TestKeySetServesStaleKeysWhileJWKSIsDown(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	data, _ := json.Marshal(map[string]any{"keys": []map[string]any{{
+		"kty": "RSA",
+		"kid": "k1",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if hits.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	set := newKeySet(server.URL, "", time.Millisecond)
+	if keys, errLookup := set.lookup("k1"); errLookup != nil || len(keys) != 1 {
+		t.Fatalf("initial lookup = %d keys, %v", len(keys), errLookup)
+	}
+	// Force the next lookup past the reload backoff.
+	set.mu.Lock()
+	set.tried = time.Now().Add(-minJWKSReload)
+	set.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+
+	for index := 0; index < 20; index++ {
+		keys, errLookup := set.lookup("k1")
+		if errLookup != nil || len(keys) != 1 {
+			t.Fatalf("lookup #%d with the endpoint down = %d keys, %v; want the stale key", index, len(keys), errLookup)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for hits.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	for set.refreshing.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, errLookup := set.lookup("unknown"); errLookup != nil {
+		t.Fatalf("lookup of an unknown kid during backoff = %v", errLookup)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("JWKS fetches = %d, want 2 while backing off", got)
+	}
+}
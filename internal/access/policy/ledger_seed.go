@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"context"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	internallogging "github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageledger"
+)
+
+// SeedFromLedger restores the daily and monthly token counters of every policy with a token
+// budget from the usage recorded in the ledger this month, so budgets survive restarts.
+// It does nothing when the ledger is disabled.
+func (l *Limiter) SeedFromLedger(ctx context.Context, ledger *usageledger.Ledger, policies []config.APIKeyPolicy) error {
+	if l == nil || ledger == nil || !ledger.Enabled() {
+		return nil
+	}
+	keysByID := make(map[string]string, len(policies))
+	for _, policy := range policies {
+		if policy.APIKey == "" || (policy.TokensPerDay <= 0 && policy.MonthlyTokenBudget <= 0) {
+			continue
+		}
+		keysByID[internallogging.ClientKeyID(policy.APIKey)] = policy.APIKey
+	}
+	if len(keysByID) == 0 {
+		return nil
+	}
+
+	now := l.now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	entries, err := ledger.Query(ctx, usageledger.Filter{From: monthStart})
+	if err != nil {
+		return err
+	}
+
+	type totals struct{ day, month int64 }
+	byKey := make(map[string]*totals, len(keysByID))
+	for _, entry := range entries {
+		apiKey, ok := keysByID[entry.ClientKeyID]
+		if !ok {
+			continue
+		}
+		sum := byKey[apiKey]
+		if sum == nil {
+			sum = &totals{}
+			byKey[apiKey] = sum
+		}
+		sum.month += entry.TotalTokens
+		if !entry.Timestamp.Before(dayStart) {
+			sum.day += entry.TotalTokens
+		}
+	}
+	for apiKey, sum := range byKey {
+		l.Seed(apiKey, sum.day, sum.month)
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+// Package policy enforces per-client API key policies (model allowlists,
+// request rate limits and token budgets) ahead of request execution.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+const requestWindow = time.Minute
+
+// Error reports a policy violation along with the HTTP status and retry hint.
+type Error struct {
+	status     int
+	message    string
+	retryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e == nil {
+		return ""
+	}
+	return e.message
+}
+
+// StatusCode returns the HTTP status that should be reported to the client.
+func (e *Error) StatusCode() int {
+	if e == nil {
+		return 0
+	}
+	return e.status
+}
+
+// RetryAfter returns how long the client should wait before retrying.
+// Zero means retrying will not help (e.g. a disallowed model).
+func (e *Error) RetryAfter() time.Duration {
+	if e == nil {
+		return 0
+	}
+	return e.retryAfter
+}
+
+// Headers returns the response headers describing the violation.
+func (e *Error) Headers() http.Header {
+	if e == nil || e.retryAfter <= 0 {
+		return nil
+	}
+	header := make(http.Header)
+	header.Set("Retry-After", strconv.FormatInt(RetryAfterSeconds(e.retryAfter), 10))
+	return header
+}
+
+// RetryAfterSeconds rounds a wait duration up to whole seconds (minimum 1).
+func RetryAfterSeconds(wait time.Duration) int64 {
+	seconds := int64(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// Usage is a point-in-time view of the counters tracked for a client key.
+type Usage struct {
+	RequestsLastMinute int   `json:"requests-last-minute"`
+	TokensToday        int64 `json:"tokens-today"`
+	TokensThisMonth    int64 `json:"tokens-this-month"`
+}
+
+type keyState struct {
+	requests    []time.Time
+	day         string
+	dayTokens   int64
+	month       string
+	monthTokens int64
+}
+
+// Limiter tracks per-key request and token counters.
+type Limiter struct {
+	mu   sync.Mutex
+	keys map[string]*keyState
+	now  func() time.Time
+}
+
+// NewLimiter constructs an empty limiter.
+func NewLimiter() *Limiter {
+	return &Limiter{keys: make(map[string]*keyState), now: time.Now}
+}
+
+// CheckModel verifies the model against the policy allowlist without consuming quota.
+func (l *Limiter) CheckModel(policy config.APIKeyPolicy, model string) *Error {
+	if len(policy.AllowedModels) == 0 {
+		return nil
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range policy.AllowedModels {
+		if matchWildcard(pattern, model) {
+			return nil
+		}
+	}
+	return &Error{
+		status:  http.StatusForbidden,
+		message: fmt.Sprintf("model %s is not allowed for this api key", model),
+	}
+}
+
+// Allow checks the model allowlist, the request rate and the token budgets.
+// When the request is accepted it is counted against the per-minute limit.
+func (l *Limiter) Allow(policy config.APIKeyPolicy, model string) *Error {
+	if errModel := l.CheckModel(policy, model); errModel != nil {
+		return errModel
+	}
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now().UTC()
+	state := l.stateLocked(policy.APIKey, now)
+
+	if policy.RequestsPerMinute > 0 {
+		state.pruneRequests(now)
+		if len(state.requests) >= policy.RequestsPerMinute {
+			wait := state.requests[0].Add(requestWindow).Sub(now)
+			return &Error{
+				status:     http.StatusTooManyRequests,
+				message:    fmt.Sprintf("api key rate limit exceeded: %d requests per minute", policy.RequestsPerMinute),
+				retryAfter: wait,
+			}
+		}
+	}
+	if policy.TokensPerDay > 0 && state.dayTokens >= policy.TokensPerDay {
+		nextDay := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		return &Error{
+			status:     http.StatusTooManyRequests,
+			message:    fmt.Sprintf("api key daily token limit exceeded: %d tokens per day", policy.TokensPerDay),
+			retryAfter: nextDay.Sub(now),
+		}
+	}
+	if policy.MonthlyTokenBudget > 0 && state.monthTokens >= policy.MonthlyTokenBudget {
+		nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		return &Error{
+			status:     http.StatusTooManyRequests,
+			message:    fmt.Sprintf("api key monthly token budget exhausted: %d tokens per month", policy.MonthlyTokenBudget),
+			retryAfter: nextMonth.Sub(now),
+		}
+	}
+
+	if policy.RequestsPerMinute > 0 {
+		state.requests = append(state.requests, now)
+	}
+	return nil
+}
+
+// AddTokens charges consumed tokens to the given client key.
+func (l *Limiter) AddTokens(apiKey string, tokens int64, at time.Time) {
+	apiKey = strings.TrimSpace(apiKey)
+	if l == nil || apiKey == "" || tokens <= 0 {
+		return
+	}
+	if at.IsZero() {
+		at = l.now()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now().UTC()
+	state := l.stateLocked(apiKey, now)
+	at = at.UTC()
+	if at.Format(time.DateOnly) == state.day {
+		state.dayTokens += tokens
+	}
+	if at.Format("2006-01") == state.month {
+		state.monthTokens += tokens
+	}
+}
+
+// Seed raises the daily and monthly token counters of a client key to at least the given
+// totals. It restores budgets recorded before a restart; taking the maximum keeps tokens
+// charged since startup from being counted twice.
+func (l *Limiter) Seed(apiKey string, dayTokens, monthTokens int64) {
+	apiKey = strings.TrimSpace(apiKey)
+	if l == nil || apiKey == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := l.stateLocked(apiKey, l.now().UTC())
+	state.dayTokens = max(state.dayTokens, dayTokens)
+	state.monthTokens = max(state.monthTokens, monthTokens)
+}
+
+// Snapshot returns the current counters for a client key.
+func (l *Limiter) Snapshot(apiKey string) Usage {
+	apiKey = strings.TrimSpace(apiKey)
+	if l == nil || apiKey == "" {
+		return Usage{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now().UTC()
+	state := l.stateLocked(apiKey, now)
+	state.pruneRequests(now)
+	return Usage{
+		RequestsLastMinute: len(state.requests),
+		TokensToday:        state.dayTokens,
+		TokensThisMonth:    state.monthTokens,
+	}
+}
+
+// Reset clears all counters tracked for a client key.
+func (l *Limiter) Reset(apiKey string) {
+	apiKey = strings.TrimSpace(apiKey)
+	if l == nil || apiKey == "" {
+		return
+	}
+	l.mu.Lock()
+	delete(l.keys, apiKey)
+	l.mu.Unlock()
+}
+
+// HandleUsage implements coreusage.Plugin by charging reported tokens to the client key.
+func (l *Limiter) HandleUsage(_ context.Context, record coreusage.Record) {
+	tokens := record.Detail.TotalTokens
+	if tokens == 0 {
+		tokens = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+	l.AddTokens(record.APIKey, tokens, record.RequestedAt)
+}
+
+func (l *Limiter) stateLocked(apiKey string, now time.Time) *keyState {
+	state, ok := l.keys[apiKey]
+	if !ok {
+		state = &keyState{}
+		l.keys[apiKey] = state
+	}
+	day := now.Format(time.DateOnly)
+	if state.day != day {
+		state.day = day
+		state.dayTokens = 0
+	}
+	month := now.Format("2006-01")
+	if state.month != month {
+		state.month = month
+		state.monthTokens = 0
+	}
+	return state
+}
+
+func (s *keyState) pruneRequests(now time.Time) {
+	cutoff := now.Add(-requestWindow)
+	idx := 0
+	for idx < len(s.requests) && !s.requests[idx].After(cutoff) {
+		idx++
+	}
+	if idx > 0 {
+		s.requests = append(s.requests[:0], s.requests[idx:]...)
+	}
+}
+
+// matchWildcard performs wildcard matching where '*' matches any substring.
+func matchWildcard(pattern, value string) bool {
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+	parts := strings.Split(pattern, "*")
+	if prefix := parts[0]; prefix != "" {
+		if !strings.HasPrefix(value, prefix) {
+			return false
+		}
+		value = value[len(prefix):]
+	}
+	if suffix := parts[len(parts)-1]; suffix != "" {
+		if !strings.HasSuffix(value, suffix) {
+			return false
+		}
+		value = value[:len(value)-len(suffix)]
+	}
+	for i := 1; i < len(parts)-1; i++ {
+		segment := parts[i]
+		if segment == "" {
+			continue
+		}
+		idx := strings.Index(value, segment)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(segment):]
+	}
+	return true
+}
+
+var defaultLimiter = NewLimiter()
+
+func init() {
+	coreusage.RegisterPlugin(defaultLimiter)
+}
+
+// Default returns the process-wide limiter fed by the usage pipeline.
+func Default() *Limiter { return defaultLimiter }
@@ -0,0 +1,147 @@
+package policy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	internallogging "github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageledger"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func newTestLimiter(now *time.Time) *Limiter {
+	limiter := NewLimiter()
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestLimiterAllowedModels(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	policy := config.NormalizeAPIKeyPolicy(config.APIKeyPolicy{
+		APIKey:        "k1",
+		AllowedModels: []string{"Claude-*", "gpt-5"},
+	})
+
+	if err := limiter.Allow(policy, "claude-sonnet-4-5"); err != nil {
+		t.Fatalf("expected claude model to be allowed, got %v", err)
+	}
+	if err := limiter.Allow(policy, "GPT-5"); err != nil {
+		t.Fatalf("expected gpt-5 to be allowed, got %v", err)
+	}
+	err := limiter.Allow(policy, "gemini-2.5-pro")
+	if err == nil {
+		t.Fatal("expected gemini model to be rejected")
+	}
+	if err.StatusCode() != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", err.StatusCode(), http.StatusForbidden)
+	}
+	if err.Headers() != nil {
+		t.Fatalf("expected no Retry-After for disallowed model, got %v", err.Headers())
+	}
+}
+
+func TestLimiterRequestsPerMinute(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	policy := config.APIKeyPolicy{APIKey: "k1", RequestsPerMinute: 2}
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Allow(policy, "m"); err != nil {
+			t.Fatalf("request %d rejected: %v", i, err)
+		}
+		now = now.Add(10 * time.Second)
+	}
+	err := limiter.Allow(policy, "m")
+	if err == nil {
+		t.Fatal("expected third request to be rate limited")
+	}
+	if err.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", err.StatusCode(), http.StatusTooManyRequests)
+	}
+	if got := err.Headers().Get("Retry-After"); got != "40" {
+		t.Fatalf("Retry-After = %q, want %q", got, "40")
+	}
+
+	now = now.Add(41 * time.Second)
+	if errAllow := limiter.Allow(policy, "m"); errAllow != nil {
+		t.Fatalf("expected request after window to pass, got %v", errAllow)
+	}
+}
+
+func TestLimiterTokenBudgets(t *testing.T) {
+	now := time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	policy := config.APIKeyPolicy{APIKey: "k1", TokensPerDay: 100, MonthlyTokenBudget: 1000}
+
+	limiter.HandleUsage(context.Background(), coreusage.Record{
+		APIKey:      "k1",
+		RequestedAt: now,
+		Detail:      coreusage.Detail{InputTokens: 60, OutputTokens: 40},
+	})
+	err := limiter.Allow(policy, "m")
+	if err == nil {
+		t.Fatal("expected daily token limit to reject request")
+	}
+	if got := err.Headers().Get("Retry-After"); got != "3600" {
+		t.Fatalf("Retry-After = %q, want %q", got, "3600")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if errAllow := limiter.Allow(policy, "m"); errAllow != nil {
+		t.Fatalf("expected new day to reset daily counter, got %v", errAllow)
+	}
+	usage := limiter.Snapshot("k1")
+	if usage.TokensToday != 0 || usage.TokensThisMonth != 0 {
+		t.Fatalf("unexpected counters after month rollover: %+v", usage)
+	}
+
+	limiter.AddTokens("k1", 1000, now)
+	err = limiter.Allow(policy, "m")
+	if err == nil || err.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("expected monthly budget rejection, got %v", err)
+	}
+}
+
+func TestLimiterSeedFromLedger(t *testing.T) {
+	backend, err := usageledger.NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileBackend: %v", err)
+	}
+	t.Cleanup(func() { _ = backend.Close() })
+	ledger := usageledger.Default()
+	previous := ledger.Configure(backend, 0)
+	t.Cleanup(func() { ledger.Configure(previous, 0) })
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	keyID := internallogging.ClientKeyID("k1")
+	if err = backend.AppendUsage(context.Background(), []usageledger.Entry{
+		{Timestamp: now.Add(-time.Hour), ClientKeyID: keyID, TotalTokens: 40},
+		{Timestamp: now.AddDate(0, 0, -3), ClientKeyID: keyID, TotalTokens: 100},
+		{Timestamp: now.AddDate(0, -1, 0), ClientKeyID: keyID, TotalTokens: 1000},
+		{Timestamp: now.Add(-time.Hour), ClientKeyID: internallogging.ClientKeyID("other"), TotalTokens: 7},
+	}); err != nil {
+		t.Fatalf("AppendUsage: %v", err)
+	}
+
+	limiter := newTestLimiter(&now)
+	limiter.AddTokens("k1", 50, now)
+	policies := []config.APIKeyPolicy{{APIKey: "k1", TokensPerDay: 45, MonthlyTokenBudget: 1000}, {APIKey: "other"}}
+	if err = limiter.SeedFromLedger(context.Background(), ledger, policies); err != nil {
+		t.Fatalf("SeedFromLedger: %v", err)
+	}
+
+	usage := limiter.Snapshot("k1")
+	if usage.TokensToday != 50 || usage.TokensThisMonth != 140 {
+		t.Fatalf("usage = %+v, want today 50 (kept) and month 140 (seeded)", usage)
+	}
+	if got := limiter.Snapshot("other"); got.TokensThisMonth != 0 {
+		t.Fatalf("key without a budget was seeded: %+v", got)
+	}
+	if errAllow := limiter.Allow(config.NormalizeAPIKeyPolicy(policies[0]), "gpt-5"); errAllow == nil {
+		t.Fatal("expected the seeded daily budget to be exhausted")
+	}
+}
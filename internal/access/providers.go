@@ -0,0 +1,113 @@
+package access
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	hmacaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/hmac_access"
+	jwtaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/jwt_access"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	log "github.com/sirupsen/logrus"
+)
+
+// configuredProvider is a provider built from an access.providers entry.
+type configuredProvider struct {
+	entry    sdkaccess.AccessProvider
+	provider sdkaccess.Provider
+}
+
+var (
+	configuredMu sync.Mutex
+	configured   = make(map[string]configuredProvider)
+)
+
+// BuildProvider constructs the built-in provider for a configuration entry.
+func BuildProvider(entry sdkaccess.AccessProvider) (sdkaccess.Provider, error) {
+	entry = config.NormalizeAccessProvider(entry)
+	switch entry.Type {
+	case sdkaccess.AccessProviderTypeJWT:
+		return jwtaccess.New(entry)
+	case sdkaccess.AccessProviderTypeHMAC:
+		return hmacaccess.New(entry)
+	default:
+		return nil, fmt.Errorf("access provider %s: unsupported type %q", entry.Name, entry.Type)
+	}
+}
+
+// IsBuiltInProviderType reports whether typ is built by BuildProvider.
+func IsBuiltInProviderType(typ string) bool {
+	switch strings.ToLower(strings.TrimSpace(typ)) {
+	case sdkaccess.AccessProviderTypeJWT, sdkaccess.AccessProviderTypeHMAC:
+		return true
+	default:
+		return false
+	}
+}
+
+// ProviderStatus returns the runtime status of the configured provider called name, or nil
+// when it is not active.
+func ProviderStatus(name string) map[string]any {
+	configuredMu.Lock()
+	current, ok := configured[strings.ToLower(name)]
+	configuredMu.Unlock()
+	if !ok {
+		return nil
+	}
+	if reporter, okStatus := current.provider.(interface{ Status() map[string]any }); okStatus {
+		return reporter.Status()
+	}
+	return map[string]any{}
+}
+
+// RegisterConfiguredProviders registers the built-in providers listed under
+// access.providers. Providers keep their instance across reloads while their entry is
+// unchanged, so JWKS caches and replay windows survive unrelated config edits. Entries
+// naming an external SDK are expected to be registered by the embedding application.
+func RegisterConfiguredProviders(cfg *config.SDKConfig) {
+	configuredMu.Lock()
+	defer configuredMu.Unlock()
+
+	desired := make(map[string]struct{})
+	if cfg != nil {
+		for _, entry := range cfg.Access.Providers {
+			entry = config.NormalizeAccessProvider(entry)
+			if entry.SDK != "" || entry.Type == sdkaccess.AccessProviderTypeConfigAPIKey {
+				continue
+			}
+			if !IsBuiltInProviderType(entry.Type) {
+				log.Warnf("access provider %s: unsupported type %q", entry.Name, entry.Type)
+				continue
+			}
+			key := strings.ToLower(entry.Name)
+			if current, ok := configured[key]; ok && reflect.DeepEqual(current.entry, entry) {
+				desired[key] = struct{}{}
+				continue
+			}
+			provider, err := BuildProvider(entry)
+			if err != nil {
+				log.Errorf("%v", err)
+				continue
+			}
+			if current, ok := configured[key]; ok {
+				sdkaccess.UnregisterProvider(registryKey(current.entry))
+			}
+			configured[key] = configuredProvider{entry: entry, provider: provider}
+			sdkaccess.RegisterProvider(registryKey(entry), provider)
+			desired[key] = struct{}{}
+		}
+	}
+	for key, current := range configured {
+		if _, keep := desired[key]; keep {
+			continue
+		}
+		sdkaccess.UnregisterProvider(registryKey(current.entry))
+		delete(configured, key)
+	}
+}
+
+func registryKey(entry sdkaccess.AccessProvider) string {
+	return entry.Type + ":" + strings.ToLower(entry.Name)
+}
@@ -0,0 +1,169 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/access"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/audit"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+)
+
+// GetAccessProviders lists the configured access providers and whether each one is active.
+// Inline API keys, HMAC secrets and other secret-looking config values are redacted; writing
+// a listed entry back unchanged keeps the stored secrets.
+func (h *Handler) GetAccessProviders(c *gin.Context) {
+	h.mu.Lock()
+	entries := append([]sdkaccess.AccessProvider(nil), h.cfg.Access.Providers...)
+	h.mu.Unlock()
+
+	out := make([]map[string]any, 0, len(entries))
+	for _, entry := range entries {
+		view, _ := audit.Redact(audit.Normalize(entry)).(map[string]any)
+		if view == nil {
+			view = map[string]any{"name": entry.Name, "type": entry.Type}
+		}
+		view["active"] = false
+		if status := access.ProviderStatus(entry.Name); status != nil {
+			view["active"] = true
+			view["status"] = status
+		}
+		out = append(out, view)
+	}
+	c.JSON(http.StatusOK, gin.H{"access-providers": out})
+}
+
+// restoreAccessProviderSecrets puts back the stored secrets of entry that arrive in the
+// redacted form returned by GetAccessProviders. It fails when a redacted value does not match
+// the stored one, so a placeholder is never saved as a secret.
+func restoreAccessProviderSecrets(entry sdkaccess.AccessProvider, existing []sdkaccess.AccessProvider) (sdkaccess.AccessProvider, error) {
+	var original any
+	for _, candidate := range existing {
+		if strings.EqualFold(candidate.Name, entry.Name) {
+			original = audit.Normalize(candidate)
+			break
+		}
+	}
+	raw, err := json.Marshal(audit.Unredact(audit.Normalize(entry), original))
+	if err != nil {
+		return entry, err
+	}
+	if audit.IsRedacted(string(raw)) {
+		return entry, fmt.Errorf("access provider %s: redacted value does not match the stored secret; send the secret in full", entry.Name)
+	}
+	var restored sdkaccess.AccessProvider
+	if err = json.Unmarshal(raw, &restored); err != nil {
+		return entry, err
+	}
+	return restored, nil
+}
+
+// PutAccessProviders replaces the access provider list.
+func (h *Handler) PutAccessProviders(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+	var entries []sdkaccess.AccessProvider
+	if err = json.Unmarshal(data, &entries); err != nil {
+		var wrapper struct {
+			Items []sdkaccess.AccessProvider `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &wrapper); err2 != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+		entries = wrapper.Items
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range entries {
+		entries[i] = config.NormalizeAccessProvider(entries[i])
+		restored, errRestore := restoreAccessProviderSecrets(entries[i], h.cfg.Access.Providers)
+		if errRestore != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errRestore.Error()})
+			return
+		}
+		entries[i] = restored
+		if errValidate := validateAccessProvider(entries[i]); errValidate != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errValidate.Error()})
+			return
+		}
+	}
+	h.cfg.Access.Providers = entries
+	h.cfg.SanitizeAccessProviders()
+	h.persistLocked(c)
+}
+
+// PatchAccessProvider adds or replaces a single access provider matched by name.
+func (h *Handler) PatchAccessProvider(c *gin.Context) {
+	var body sdkaccess.AccessProvider
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	body = config.NormalizeAccessProvider(body)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	body, errRestore := restoreAccessProviderSecrets(body, h.cfg.Access.Providers)
+	if errRestore != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errRestore.Error()})
+		return
+	}
+	if errValidate := validateAccessProvider(body); errValidate != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errValidate.Error()})
+		return
+	}
+	for i := range h.cfg.Access.Providers {
+		if strings.EqualFold(h.cfg.Access.Providers[i].Name, body.Name) {
+			h.cfg.Access.Providers[i] = body
+			h.persistLocked(c)
+			return
+		}
+	}
+	h.cfg.Access.Providers = append(h.cfg.Access.Providers, body)
+	h.persistLocked(c)
+}
+
+// DeleteAccessProvider removes the access provider named by the name query parameter.
+func (h *Handler) DeleteAccessProvider(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing name"})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range h.cfg.Access.Providers {
+		if strings.EqualFold(h.cfg.Access.Providers[i].Name, name) {
+			h.cfg.Access.Providers = append(h.cfg.Access.Providers[:i], h.cfg.Access.Providers[i+1:]...)
+			h.persistLocked(c)
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "access provider not found"})
+}
+
+// validateAccessProvider rejects entries that would fail to load. Providers supplied by an
+// external SDK cannot be checked here and are accepted as-is.
+func validateAccessProvider(entry sdkaccess.AccessProvider) error {
+	if entry.Type == "" {
+		return fmt.Errorf("access provider %s: missing type", entry.Name)
+	}
+	if entry.SDK != "" || !access.IsBuiltInProviderType(entry.Type) {
+		if entry.SDK == "" {
+			return fmt.Errorf("access provider %s: unsupported type %q", entry.Name, entry.Type)
+		}
+		return nil
+	}
+	_, err := access.BuildProvider(entry)
+	return err
+}
@@ -0,0 +1,63 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+)
+
+func TestAccessProviders_RedactsSecretsAndKeepsThemOnWriteBack(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	h := &Handler{
+		cfg: &config.Config{SDKConfig: config.SDKConfig{Access: sdkaccess.AccessConfig{Providers: []sdkaccess.AccessProvider{{
+			Name:   "services",
+			Type:   sdkaccess.AccessProviderTypeHMAC,
+			Config: map[string]any{"keys": []any{map[string]any{"id": "billing", "secret": "s3cret-value"}}},
+		}}}}},
+		configFilePath: writeTestConfigFile(t),
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/access-providers", nil)
+	h.GetAccessProviders(c)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "s3cret-value") {
+		t.Fatalf("GetAccessProviders() = %d %s, want the secret redacted", rec.Code, rec.Body.String())
+	}
+	var listing struct {
+		Providers []map[string]any `json:"access-providers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listing); err != nil || len(listing.Providers) != 1 {
+		t.Fatalf("decode listing: %v, %s", err, rec.Body.String())
+	}
+	listed, _ := json.Marshal(listing.Providers[0])
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPatch, "/v0/management/access-providers", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PatchAccessProvider(c)
+		return rec
+	}
+	if rec = patch(string(listed)); rec.Code != http.StatusOK {
+		t.Fatalf("write back of the listing = %d %s", rec.Code, rec.Body.String())
+	}
+	keys, _ := h.cfg.Access.Providers[0].Config["keys"].([]any)
+	if len(keys) != 1 || keys[0].(map[string]any)["secret"] != "s3cret-value" {
+		t.Fatalf("stored keys after write back = %v", h.cfg.Access.Providers[0].Config)
+	}
+
+	stale := `{"name":"services","type":"hmac","config":{"keys":[{"id":"billing","secret":"[redacted:00000000]"}]}}`
+	if rec = patch(stale); rec.Code != http.StatusBadRequest {
+		t.Fatalf("write with a foreign redacted secret = %d %s, want 400", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,139 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	accesspolicy "github.com/router-for-me/CLIProxyAPI/v7/internal/access/policy"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// hasClientAPIKey reports whether key is listed in the top-level api-keys.
+// It expects the caller to hold h.mu.
+func (h *Handler) hasClientAPIKey(key string) bool {
+	for _, existing := range h.cfg.APIKeys {
+		if strings.TrimSpace(existing) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// findAPIKeyPolicyIndex returns the index of the policy for key or -1.
+// It expects the caller to hold h.mu.
+func (h *Handler) findAPIKeyPolicyIndex(key string) int {
+	for i := range h.cfg.APIKeyPolicies {
+		if h.cfg.APIKeyPolicies[i].APIKey == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetAPIKeyPolicy returns the policy and live counters for a client API key.
+func (h *Handler) GetAPIKeyPolicy(c *gin.Context) {
+	key := strings.TrimSpace(c.Param("key"))
+	h.mu.Lock()
+	known := h.hasClientAPIKey(key)
+	policy, hasPolicy := h.cfg.APIKeyPolicyFor(key)
+	h.mu.Unlock()
+	if !known && !hasPolicy {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+	if !hasPolicy {
+		policy = config.APIKeyPolicy{APIKey: key}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"policy": policy,
+		"usage":  accesspolicy.Default().Snapshot(key),
+	})
+}
+
+// PutAPIKeyPolicy replaces the policy for a client API key.
+func (h *Handler) PutAPIKeyPolicy(c *gin.Context) {
+	key := strings.TrimSpace(c.Param("key"))
+	var body config.APIKeyPolicy
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	body.APIKey = key
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.hasClientAPIKey(key) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+	h.setAPIKeyPolicyLocked(config.NormalizeAPIKeyPolicy(body))
+	h.persistLocked(c)
+}
+
+// PatchAPIKeyPolicy updates individual fields of a client API key policy.
+func (h *Handler) PatchAPIKeyPolicy(c *gin.Context) {
+	key := strings.TrimSpace(c.Param("key"))
+	var body struct {
+		AllowedModels      *[]string `json:"allowed-models"`
+		RequestsPerMinute  *int      `json:"requests-per-minute"`
+		TokensPerDay       *int64    `json:"tokens-per-day"`
+		MonthlyTokenBudget *int64    `json:"monthly-token-budget"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.hasClientAPIKey(key) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+	policy, _ := h.cfg.APIKeyPolicyFor(key)
+	policy.APIKey = key
+	if body.AllowedModels != nil {
+		policy.AllowedModels = append([]string(nil), (*body.AllowedModels)...)
+	}
+	if body.RequestsPerMinute != nil {
+		policy.RequestsPerMinute = *body.RequestsPerMinute
+	}
+	if body.TokensPerDay != nil {
+		policy.TokensPerDay = *body.TokensPerDay
+	}
+	if body.MonthlyTokenBudget != nil {
+		policy.MonthlyTokenBudget = *body.MonthlyTokenBudget
+	}
+	h.setAPIKeyPolicyLocked(config.NormalizeAPIKeyPolicy(policy))
+	h.persistLocked(c)
+}
+
+// DeleteAPIKeyPolicy removes the policy for a client API key.
+func (h *Handler) DeleteAPIKeyPolicy(c *gin.Context) {
+	key := strings.TrimSpace(c.Param("key"))
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	idx := h.findAPIKeyPolicyIndex(key)
+	if idx < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "policy not found"})
+		return
+	}
+	h.cfg.APIKeyPolicies = append(h.cfg.APIKeyPolicies[:idx], h.cfg.APIKeyPolicies[idx+1:]...)
+	h.persistLocked(c)
+}
+
+// setAPIKeyPolicyLocked inserts or replaces a policy, dropping policies that impose no limits.
+// It expects the caller to hold h.mu.
+func (h *Handler) setAPIKeyPolicyLocked(policy config.APIKeyPolicy) {
+	idx := h.findAPIKeyPolicyIndex(policy.APIKey)
+	switch {
+	case policy.IsEmpty() && idx >= 0:
+		h.cfg.APIKeyPolicies = append(h.cfg.APIKeyPolicies[:idx], h.cfg.APIKeyPolicies[idx+1:]...)
+	case policy.IsEmpty():
+	case idx >= 0:
+		h.cfg.APIKeyPolicies[idx] = policy
+	default:
+		h.cfg.APIKeyPolicies = append(h.cfg.APIKeyPolicies, policy)
+	}
+}
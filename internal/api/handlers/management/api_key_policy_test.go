@@ -0,0 +1,78 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestPutAPIKeyPolicy_PersistsNormalizedPolicy(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	configPath := writeTestConfigFile(t)
+	h := &Handler{
+		cfg:            &config.Config{SDKConfig: config.SDKConfig{APIKeys: []string{"client-key"}}},
+		configFilePath: configPath,
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Params = gin.Params{{Key: "key", Value: "client-key"}}
+	c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/api-keys/client-key/policy", strings.NewReader(`{"allowed-models":[" Claude-* "],"requests-per-minute":30,"tokens-per-day":-5}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	h.PutAPIKeyPolicy(c)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	policy, ok := h.cfg.APIKeyPolicyFor("client-key")
+	if !ok {
+		t.Fatal("expected policy to be stored")
+	}
+	if len(policy.AllowedModels) != 1 || policy.AllowedModels[0] != "claude-*" {
+		t.Fatalf("allowed models = %v, want [claude-*]", policy.AllowedModels)
+	}
+	if policy.RequestsPerMinute != 30 || policy.TokensPerDay != 0 {
+		t.Fatalf("unexpected limits: %+v", policy)
+	}
+
+	data, errRead := os.ReadFile(configPath)
+	if errRead != nil {
+		t.Fatalf("read config: %v", errRead)
+	}
+	if !strings.Contains(string(data), "api-key-policies") {
+		t.Fatalf("expected persisted config to contain api-key-policies, got:\n%s", data)
+	}
+}
+
+func TestPutAPIKeyPolicy_UnknownKey(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	h := &Handler{
+		cfg:            &config.Config{SDKConfig: config.SDKConfig{APIKeys: []string{"client-key"}}},
+		configFilePath: writeTestConfigFile(t),
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Params = gin.Params{{Key: "key", Value: "other-key"}}
+	c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/api-keys/other-key/policy", strings.NewReader(`{"requests-per-minute":1}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	h.PutAPIKeyPolicy(c)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if len(h.cfg.APIKeyPolicies) != 0 {
+		t.Fatalf("expected no policies, got %v", h.cfg.APIKeyPolicies)
+	}
+}
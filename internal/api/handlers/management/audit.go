@@ -0,0 +1,307 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/audit"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// Credential kinds recorded in the audit log.
+const (
+	auditCredentialLocalPassword = "local-password"
+	auditCredentialEnvSecret     = "env-secret"
+	auditCredentialSecretKey     = "secret-key"
+	// auditCredentialTokenPrefix is followed by the management token name.
+	auditCredentialTokenPrefix = "token:"
+)
+
+// managementIdentityKey stores the managementIdentity on the gin context.
+const managementIdentityKey = "managementIdentity"
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// auditState is the config and auth state captured around an audited request. Only the
+// parts the route can change are captured: config holds the touched sections, and auths holds
+// the auth records, which are normalized only when they differ afterwards.
+type auditState struct {
+	config map[string]any
+	auths  map[string]*coreauth.Auth
+}
+
+// auditScope names what an audited route can change.
+type auditScope struct {
+	// wholeConfig is set for routes that replace the config file.
+	wholeConfig bool
+	// sections are the config sections, by JSON name, the route edits.
+	sections []string
+	// auths is set for routes that add, change or remove auth records.
+	auths bool
+}
+
+// auditScopeFor maps a management route to the state it can change. Routes whose first path
+// segment names no config section, such as cache flushes, change neither.
+func auditScopeFor(route string) auditScope {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/v0/management/"), "/")
+	switch segment {
+	case "config.yaml", "config":
+		return auditScope{wholeConfig: true}
+	case "auth-files", "vertex", "oauth-callback":
+		return auditScope{auths: true}
+	case "api-keys":
+		return auditScope{sections: []string{"api-keys", "api-key-policies"}}
+	case "access-providers":
+		return auditScope{sections: []string{"access"}}
+	default:
+		return auditScope{sections: []string{segment}}
+	}
+}
+
+// auditedMethod reports whether requests with method change state and must be audited.
+func auditedMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// auditSnapshot captures the state an audited request may change. It returns nil when the
+// request is not audited.
+func (h *Handler) auditSnapshot(c *gin.Context) *auditState {
+	if !audit.Default().Enabled() || !auditedMethod(c.Request.Method) {
+		return nil
+	}
+	scope := auditScopeFor(c.FullPath())
+	state := &auditState{config: make(map[string]any)}
+	h.mu.Lock()
+	cfg := h.cfg
+	manager := h.authManager
+	if cfg != nil {
+		if scope.wholeConfig {
+			state.config, _ = audit.Normalize(cfg).(map[string]any)
+		}
+		for _, name := range scope.sections {
+			if section, ok := configSection(cfg, name); ok {
+				state.config[name] = audit.Normalize(section)
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	if scope.auths && manager != nil {
+		state.auths = make(map[string]*coreauth.Auth)
+		for _, auth := range manager.List() {
+			if auth != nil && auth.ID != "" {
+				state.auths[auth.ID] = auth
+			}
+		}
+	}
+	return state
+}
+
+// configSection returns the field of cfg whose JSON name is name, looking through inlined structs.
+func configSection(cfg *config.Config, name string) (any, bool) {
+	if name == "" {
+		return nil, false
+	}
+	var find func(value reflect.Value) (any, bool)
+	find = func(value reflect.Value) (any, bool) {
+		valueType := value.Type()
+		for i := 0; i < valueType.NumField(); i++ {
+			field := valueType.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+				if section, ok := find(value.Field(i)); ok {
+					return section, true
+				}
+				continue
+			}
+			if tag == name {
+				return value.Field(i).Interface(), true
+			}
+		}
+		return nil, false
+	}
+	return find(reflect.ValueOf(cfg).Elem())
+}
+
+// auditAuthFields returns the audited fields of auth.
+func auditAuthFields(auth *coreauth.Auth) map[string]any {
+	return map[string]any{
+		"provider":   auth.Provider,
+		"prefix":     auth.Prefix,
+		"label":      auth.Label,
+		"disabled":   auth.Disabled,
+		"proxy_url":  auth.ProxyURL,
+		"attributes": auth.Attributes,
+		"metadata":   auth.Metadata,
+	}
+}
+
+// diffAuths returns one change per auth added, removed or edited between before and after.
+// Only those auths are normalized.
+func diffAuths(before, after map[string]*coreauth.Auth) []audit.Change {
+	ids := make([]string, 0, len(before)+len(after))
+	for id := range before {
+		ids = append(ids, id)
+	}
+	for id := range after {
+		if _, ok := before[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var changes []audit.Change
+	for _, id := range ids {
+		var oldState, newState map[string]any
+		if auth := before[id]; auth != nil {
+			oldState = auditAuthFields(auth)
+		}
+		if auth := after[id]; auth != nil {
+			newState = auditAuthFields(auth)
+		}
+		if oldState != nil && newState != nil && reflect.DeepEqual(oldState, newState) {
+			continue
+		}
+		var oldValue, newValue any
+		if oldState != nil {
+			oldValue = audit.Normalize(oldState)
+		}
+		if newState != nil {
+			newValue = audit.Normalize(newState)
+		}
+		changes = append(changes, audit.Diff("auth", id, oldValue, newValue))
+	}
+	return changes
+}
+
+// recordAudit appends the outcome of an audited request. before is nil for requests rejected
+// by authentication.
+func (h *Handler) recordAudit(c *gin.Context, credential string, before *auditState) {
+	auditLog := audit.Default()
+	if !auditLog.Enabled() || !auditedMethod(c.Request.Method) {
+		return
+	}
+	status := c.Writer.Status()
+	entry := audit.Entry{
+		RemoteIP:   c.ClientIP(),
+		Credential: credential,
+		Method:     c.Request.Method,
+		Route:      c.FullPath(),
+		Path:       c.Request.URL.Path,
+		Status:     status,
+		Result:     audit.ResultSuccess,
+	}
+	if status >= http.StatusBadRequest {
+		entry.Result = audit.ResultFailure
+	}
+	if before != nil {
+		if after := h.auditSnapshot(c); after != nil {
+			entry.Changes = append(audit.DiffSections("config", before.config, after.config),
+				diffAuths(before.auths, after.auths)...)
+		}
+	}
+	auditLog.Record(entry)
+}
+
+// GetAuditLog returns management audit entries, newest first.
+//
+// Query parameters: from and to (RFC3339 or YYYY-MM-DD), method, route (substring), credential,
+// result (success or failure), remote_ip, target (config section or auth ID), limit (default 100,
+// max 1000) and offset.
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	auditLog := audit.Default()
+	if !auditLog.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "audit log disabled"})
+		return
+	}
+	filter, limit, offset, err := parseAuditQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entries, err := auditLog.Query(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	total := len(entries)
+	start := min(offset, total)
+	end := min(start+limit, total)
+	page := entries[start:end]
+	if page == nil {
+		page = []audit.Entry{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries": page,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+func parseAuditQuery(c *gin.Context) (audit.Filter, int, int, error) {
+	filter := audit.Filter{
+		Method:     strings.TrimSpace(c.Query("method")),
+		Route:      strings.TrimSpace(c.Query("route")),
+		Credential: strings.TrimSpace(c.Query("credential")),
+		Result:     strings.TrimSpace(c.Query("result")),
+		RemoteIP:   strings.TrimSpace(c.Query("remote_ip")),
+		Target:     strings.TrimSpace(c.Query("target")),
+	}
+	if raw := strings.TrimSpace(c.Query("from")); raw != "" {
+		parsed, _, err := parseUsageHistoryTime(raw)
+		if err != nil {
+			return filter, 0, 0, fmt.Errorf("invalid from: %w", err)
+		}
+		filter.From = parsed
+	}
+	if raw := strings.TrimSpace(c.Query("to")); raw != "" {
+		parsed, dateOnly, err := parseUsageHistoryTime(raw)
+		if err != nil {
+			return filter, 0, 0, fmt.Errorf("invalid to: %w", err)
+		}
+		if dateOnly {
+			// A bare date covers the whole day.
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		filter.To = parsed
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, 0, 0, fmt.Errorf("from must be before to")
+	}
+
+	limit := defaultAuditLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			return filter, 0, 0, fmt.Errorf("invalid limit")
+		}
+		limit = min(value, maxAuditLimit)
+	}
+	offset := 0
+	if raw := strings.TrimSpace(c.Query("offset")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return filter, 0, 0, fmt.Errorf("invalid offset")
+		}
+		offset = value
+	}
+	return filter, limit, offset, nil
+}
@@ -0,0 +1,151 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/audit"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestMiddlewareRecordsAuditEntries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	backend, err := audit.NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileBackend: %v", err)
+	}
+	previous := audit.Default().Configure(backend, nil)
+	t.Cleanup(func() {
+		audit.Default().Configure(previous, nil)
+		_ = backend.Close()
+	})
+
+	h := &Handler{
+		cfg:            &config.Config{},
+		failedAttempts: make(map[string]*attemptInfo),
+		envSecret:      "management-secret",
+	}
+	h.cfg.APIKeys = []string{"sk-old"}
+	router := gin.New()
+	mgmt := router.Group("/v0/management")
+	mgmt.Use(h.Middleware())
+	mgmt.PUT("/api-keys", func(c *gin.Context) {
+		h.mu.Lock()
+		h.cfg.APIKeys = []string{"sk-new"}
+		h.mu.Unlock()
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	mgmt.GET("/audit", h.GetAuditLog)
+
+	send := func(method, target, key string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = "127.0.0.1:5000"
+		req.Header.Set("Authorization", "Bearer "+key)
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send(http.MethodPut, "/v0/management/api-keys", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := send(http.MethodPut, "/v0/management/api-keys", "management-secret"); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec := send(http.MethodGet, "/v0/management/audit?result=success", "management-secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("audit status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Entries []audit.Entry `json:"entries"`
+		Total   int           `json:"total"`
+	}
+	if err = json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Total != 1 || len(resp.Entries) != 1 {
+		t.Fatalf("expected one successful entry, got %+v", resp)
+	}
+	entry := resp.Entries[0]
+	if entry.Credential != auditCredentialEnvSecret || entry.Route != "/v0/management/api-keys" || entry.RemoteIP != "127.0.0.1" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if len(entry.Changes) != 1 || entry.Changes[0].Name != "api-keys" {
+		t.Fatalf("unexpected changes: %+v", entry.Changes)
+	}
+	if raw, _ := json.Marshal(entry.Changes); strings.Contains(string(raw), "sk-old") || strings.Contains(string(raw), "sk-new") {
+		t.Fatalf("changes leak api keys: %s", raw)
+	}
+
+	rec = send(http.MethodGet, "/v0/management/audit?result=failure", "management-secret")
+	if err = json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Total != 1 || resp.Entries[0].Status != http.StatusUnauthorized || resp.Entries[0].Credential != "" {
+		t.Fatalf("expected the rejected request to be recorded, got %+v", resp)
+	}
+}
+
+func TestAuditSnapshotCapturesOnlyTouchedState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	backend, err := audit.NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileBackend: %v", err)
+	}
+	previous := audit.Default().Configure(backend, nil)
+	t.Cleanup(func() {
+		audit.Default().Configure(previous, nil)
+		_ = backend.Close()
+	})
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	for _, id := range []string{"a.json", "b.json"} {
+		if _, errRegister := manager.Register(context.Background(), &coreauth.Auth{ID: id, Provider: "codex", Metadata: map[string]any{"type": "codex"}}); errRegister != nil {
+			t.Fatalf("Register(%s): %v", id, errRegister)
+		}
+	}
+	h := &Handler{cfg: &config.Config{}, authManager: manager}
+	h.cfg.APIKeys = []string{"sk-old"}
+
+	state := h.auditSnapshot(routedContext(http.MethodPatch, "/v0/management/debug"))
+	if state == nil || len(state.config) != 1 || state.auths != nil {
+		t.Fatalf("debug snapshot = %+v, want only the debug section", state)
+	}
+	if _, ok := state.config["debug"]; !ok {
+		t.Fatalf("debug snapshot = %+v, want the debug section", state.config)
+	}
+
+	c := routedContext(http.MethodPatch, "/v0/management/auth-files/fields")
+	before := h.auditSnapshot(c)
+	if before == nil || len(before.config) != 0 || len(before.auths) != 2 {
+		t.Fatalf("auth snapshot = %+v, want the auths only", before)
+	}
+	auth, _ := manager.GetByID("b.json")
+	auth.ProxyURL = "user:pass@proxy.example:1080"
+	if _, errUpdate := manager.Update(context.Background(), auth); errUpdate != nil {
+		t.Fatalf("Update: %v", errUpdate)
+	}
+	changes := diffAuths(before.auths, h.auditSnapshot(c).auths)
+	if len(changes) != 1 || changes[0].Name != "b.json" {
+		t.Fatalf("changes = %+v, want b.json only", changes)
+	}
+	if raw, _ := json.Marshal(changes); strings.Contains(string(raw), "pass") {
+		t.Fatalf("changes leak the proxy credentials: %s", raw)
+	}
+}
+
+// routedContext returns the gin context of a request routed to route, so FullPath is set.
+func routedContext(method, route string) *gin.Context {
+	var routed *gin.Context
+	router := gin.New()
+	router.Handle(method, route, func(c *gin.Context) { routed = c.Copy() })
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, route, nil))
+	return routed
+}
@@ -0,0 +1,60 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestListAuthFiles_IncludesQuotaSnapshot(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	gin.SetMode(gin.TestMode)
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	record := &coreauth.Auth{
+		ID:         "runtime-only-auth-1",
+		Provider:   "codex",
+		Attributes: map[string]string{"runtime_only": "true"},
+		Metadata:   map[string]any{"type": "codex"},
+	}
+	if _, errRegister := manager.Register(context.Background(), record); errRegister != nil {
+		t.Fatalf("failed to register auth record: %v", errRegister)
+	}
+	quota := &coreauth.QuotaSnapshot{Source: "codex-headers", PlanTier: "plus", UpdatedAt: time.Now()}
+	quota.AddWindow(coreauth.QuotaWindow{Name: "primary", UsedPercent: 82, ResetAt: time.Now().Add(time.Hour)})
+	manager.MarkResult(context.Background(), coreauth.Result{AuthID: record.ID, Provider: "codex", Model: "gpt-5", Success: true, Quota: quota})
+
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: t.TempDir()}, manager)
+	h.tokenStore = &memoryAuthStore{}
+
+	rec := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(rec)
+	ginCtx.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files", nil)
+	h.ListAuthFiles(ginCtx)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected list status %d, got %d with body %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Files []struct {
+			QuotaSnapshot *coreauth.QuotaSnapshot `json:"quota_snapshot"`
+		} `json:"files"`
+	}
+	if errUnmarshal := json.Unmarshal(rec.Body.Bytes(), &payload); errUnmarshal != nil {
+		t.Fatalf("failed to decode list payload: %v", errUnmarshal)
+	}
+	if len(payload.Files) != 1 || payload.Files[0].QuotaSnapshot == nil {
+		t.Fatalf("expected one entry with a quota snapshot, got %s", rec.Body.String())
+	}
+	snapshot := payload.Files[0].QuotaSnapshot
+	if snapshot.PlanTier != "plus" || len(snapshot.Windows) != 1 || snapshot.Windows[0].UsedPercent != 82 {
+		t.Fatalf("unexpected quota snapshot %+v", snapshot)
+	}
+}
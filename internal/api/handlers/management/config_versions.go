@@ -0,0 +1,222 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/configversion"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher/diff"
+	log "github.com/sirupsen/logrus"
+)
+
+// recordConfigVersion adds the config file as it is on disk to the version history.
+// Callers hold h.mu so the file cannot change underneath.
+func (h *Handler) recordConfigVersion(source, note string) {
+	history := configversion.Default()
+	if !history.Enabled() || h.configFilePath == "" {
+		return
+	}
+	data, err := os.ReadFile(h.configFilePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("config versions: failed to read config: %v", err)
+		}
+		return
+	}
+	if _, _, err = history.Record(context.Background(), data, source, note); err != nil {
+		log.Warnf("config versions: failed to record version: %v", err)
+	}
+}
+
+// ListConfigVersions returns the recorded config versions, newest first, and the ID of the
+// version matching the file on disk.
+func (h *Handler) ListConfigVersions(c *gin.Context) {
+	history := configversion.Default()
+	if !history.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config versions disabled"})
+		return
+	}
+	versions, err := history.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if versions == nil {
+		versions = []configversion.Version{}
+	}
+	var current any
+	if data, errRead := os.ReadFile(h.configFilePath); errRead == nil {
+		sum := configversion.Checksum(data)
+		for _, version := range versions {
+			if version.SHA256 == sum {
+				current = version.ID
+				break
+			}
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": versions, "current": current})
+}
+
+// GetConfigVersion returns the raw YAML of one recorded version.
+func (h *Handler) GetConfigVersion(c *gin.Context) {
+	version, ok := h.loadConfigVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+	c.Header("Content-Type", "application/yaml; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	_, _ = c.Writer.Write(version.Content)
+}
+
+// DiffConfigVersions lists the changes between two versions. "from" is required; "to" defaults to
+// "current", the config file on disk.
+func (h *Handler) DiffConfigVersions(c *gin.Context) {
+	from := strings.TrimSpace(c.Query("from"))
+	if from == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from is required"})
+		return
+	}
+	to := strings.TrimSpace(c.Query("to"))
+	if to == "" {
+		to = "current"
+	}
+	oldData, ok := h.configVersionContent(c, from)
+	if !ok {
+		return
+	}
+	newData, ok := h.configVersionContent(c, to)
+	if !ok {
+		return
+	}
+	oldCfg, err := config.ParseConfigBytes(oldData)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("version %s: %v", from, err)})
+		return
+	}
+	newCfg, err := config.ParseConfigBytes(newData)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("version %s: %v", to, err)})
+		return
+	}
+	changes := diff.BuildConfigChangeDetails(oldCfg, newCfg)
+	if changes == nil {
+		changes = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "changes": changes})
+}
+
+// RollbackConfigVersion validates a recorded version and atomically restores it as the config
+// file. The watcher then reloads it and mirrors it to the token store like any other edit.
+func (h *Handler) RollbackConfigVersion(c *gin.Context) {
+	version, ok := h.loadConfigVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+	if _, err := config.ValidateConfigBytes(version.Content); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "message": err.Error()})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.recordConfigVersion(configversion.SourceSnapshot, "")
+	if err := writeConfigAtomic(h.configFilePath, version.Content); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": err.Error()})
+		return
+	}
+	newCfg, err := config.LoadConfig(h.configFilePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "reload_failed", "message": err.Error()})
+		return
+	}
+	h.cfg = newCfg
+	h.recordConfigVersion(configversion.SourceRollback, fmt.Sprintf("restored version %d", version.ID))
+	c.JSON(http.StatusOK, gin.H{"ok": true, "restored": version.ID})
+}
+
+// configVersionContent returns the content of a version ID, or of the config file for "current".
+func (h *Handler) configVersionContent(c *gin.Context, ref string) ([]byte, bool) {
+	if ref == "current" {
+		data, err := os.ReadFile(h.configFilePath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "read_failed", "message": err.Error()})
+			return nil, false
+		}
+		return data, true
+	}
+	version, ok := h.loadConfigVersion(c, ref)
+	return version.Content, ok
+}
+
+func (h *Handler) loadConfigVersion(c *gin.Context, ref string) (configversion.Version, bool) {
+	history := configversion.Default()
+	if !history.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config versions disabled"})
+		return configversion.Version{}, false
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(ref), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid version %q", ref)})
+		return configversion.Version{}, false
+	}
+	version, err := history.Get(c.Request.Context(), id)
+	if errors.Is(err, configversion.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return configversion.Version{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return configversion.Version{}, false
+	}
+	return version, true
+}
+
+// writeConfigAtomic replaces the config file through a temporary file and a rename so readers
+// never see a partial file. Single-file bind mounts cannot be renamed over; those fall back to
+// an in-place write.
+func writeConfigAtomic(path string, data []byte) error {
+	data = config.NormalizeCommentIndentation(data)
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-rollback-*.yaml")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	cleanup := func() { _ = os.Remove(tmpName) }
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		cleanup()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		cleanup()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		cleanup()
+		return err
+	}
+	if err = os.Chmod(tmpName, mode); err != nil {
+		cleanup()
+		return err
+	}
+	if err = os.Rename(tmpName, path); err != nil {
+		cleanup()
+		log.Warnf("config versions: atomic replace failed (%v), writing in place", err)
+		return WriteConfig(path, data)
+	}
+	return nil
+}
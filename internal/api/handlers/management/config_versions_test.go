@@ -0,0 +1,123 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/configversion"
+)
+
+func TestConfigVersionsRecordDiffAndRollback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	backend, err := configversion.NewFileBackend(filepath.Join(dir, "versions"))
+	if err != nil {
+		t.Fatalf("NewFileBackend: %v", err)
+	}
+	previous := configversion.Default().Configure(backend, 10)
+	t.Cleanup(func() { configversion.Default().Configure(previous, 0) })
+
+	configPath := filepath.Join(dir, "config.yaml")
+	if err = os.WriteFile(configPath, []byte("port: 8317\ndebug: false\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{}, configFilePath: configPath}
+	router := gin.New()
+	router.PUT("/config.yaml", h.PutConfigYAML)
+	router.GET("/config/versions", h.ListConfigVersions)
+	router.GET("/config/versions/diff", h.DiffConfigVersions)
+	router.GET("/config/versions/:version", h.GetConfigVersion)
+	router.POST("/config/versions/rollback/:version", h.RollbackConfigVersion)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := send(http.MethodPut, "/config.yaml", "port: 8317\ndebug: true\n"); rec.Code != http.StatusOK {
+		t.Fatalf("put status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := send(http.MethodPut, "/config.yaml", "port: 8317\nrouting:\n  strategy: bogus\n"); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("invalid config status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+
+	rec := send(http.MethodGet, "/config/versions", "")
+	var list struct {
+		Versions []configversion.Version `json:"versions"`
+		Current  int64                   `json:"current"`
+	}
+	if err = json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list.Versions) != 2 || list.Current != 2 || list.Versions[1].Source != configversion.SourceSnapshot {
+		t.Fatalf("unexpected versions: %s", rec.Body.String())
+	}
+
+	rec = send(http.MethodGet, "/config/versions/diff?from=1", "")
+	var diffResp struct {
+		Changes []string `json:"changes"`
+	}
+	if err = json.Unmarshal(rec.Body.Bytes(), &diffResp); err != nil {
+		t.Fatalf("decode diff: %v", err)
+	}
+	if len(diffResp.Changes) != 1 || diffResp.Changes[0] != "debug: false -> true" {
+		t.Fatalf("unexpected diff: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec = send(http.MethodPost, "/config/versions/rollback/1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("rollback status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil || string(data) != "port: 8317\ndebug: false\n" {
+		t.Fatalf("config not restored: %q %v", data, err)
+	}
+	if h.cfg.Debug {
+		t.Fatal("handler config not reloaded")
+	}
+	versions, _ := configversion.Default().List(t.Context())
+	if len(versions) != 3 || versions[0].Source != configversion.SourceRollback {
+		t.Fatalf("rollback not recorded: %+v", versions)
+	}
+}
+
+func TestPersistRejectsInvalidConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	h := &Handler{cfg: cfg, configFilePath: configPath}
+	router := gin.New()
+	router.PATCH("/quota-avoid-percent", func(c *gin.Context) {
+		h.updateIntField(c, func(v int) { h.cfg.Routing.QuotaAvoidPercent = v })
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/quota-avoid-percent", strings.NewReader(`{"value":150}`)))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusUnprocessableEntity, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != "port: 8317\n" {
+		t.Fatalf("invalid config was written: %q", data)
+	}
+	if h.cfg.Routing.QuotaAvoidPercent != 0 {
+		t.Fatalf("in-memory config kept the rejected edit: %d", h.cfg.Routing.QuotaAvoidPercent)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/quota-avoid-percent", strings.NewReader(`{"value":90}`)))
+	if rec.Code != http.StatusOK || h.cfg.Routing.QuotaAvoidPercent != 90 {
+		t.Fatalf("valid edit status = %d, value %d; body=%s", rec.Code, h.cfg.Routing.QuotaAvoidPercent, rec.Body.String())
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,9 +16,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/configversion"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
 )
 
 type attemptInfo struct {
@@ -46,6 +49,8 @@ type Handler struct {
 	envSecret           string
 	logDir              string
 	postAuthHook        coreauth.PostAuthHook
+	replayHandler       http.Handler
+	tokenMatches        sync.Map // verified bcrypt token matches
 }
 
 // NewHandler creates a new management handler instance.
@@ -168,33 +173,55 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			provided = c.GetHeader("X-Management-Key")
 		}
 
-		allowed, statusCode, errMsg := h.AuthenticateManagementKey(clientIP, localClient, provided)
+		identity, allowed, statusCode, errMsg := h.authenticateManagementKey(clientIP, localClient, provided)
 		if !allowed {
 			c.AbortWithStatusJSON(statusCode, gin.H{"error": errMsg})
+			h.recordAudit(c, identity.Credential, nil)
 			return
 		}
+		if ok, required := identity.allows(c.Request.Method, c.FullPath(), c.Request.URL.Query()); !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient scope", "scope": required})
+			h.recordAudit(c, identity.Credential, nil)
+			return
+		}
+		c.Set(managementIdentityKey, identity)
+		before := h.auditSnapshot(c)
 		c.Next()
+		h.recordAudit(c, identity.Credential, before)
 	}
 }
 
 // AuthenticateManagementKey verifies the provided management key for the given client.
 // It mirrors the behaviour of Middleware() so non-HTTP callers can reuse the same logic.
+// Non-HTTP callers have no route to check scopes against, so scoped tokens need the admin scope.
 func (h *Handler) AuthenticateManagementKey(clientIP string, localClient bool, provided string) (bool, int, string) {
+	identity, allowed, statusCode, errMsg := h.authenticateManagementKey(clientIP, localClient, provided)
+	if allowed && identity.Token != nil && !slices.Contains(identity.Token.Scopes, config.ManagementScopeAdmin) {
+		return false, http.StatusForbidden, "insufficient scope"
+	}
+	return allowed, statusCode, errMsg
+}
+
+// authenticateManagementKey is AuthenticateManagementKey that also reports which credential
+// accepted the key, for scope checks and the audit log.
+func (h *Handler) authenticateManagementKey(clientIP string, localClient bool, provided string) (managementIdentity, bool, int, string) {
 	const maxFailures = 5
 	const banDuration = 30 * time.Minute
 
 	if h == nil {
-		return false, http.StatusForbidden, "remote management disabled"
+		return managementIdentity{}, false, http.StatusForbidden, "remote management disabled"
 	}
 
 	cfg := h.cfg
 	var (
 		allowRemote bool
 		secretHash  string
+		tokens      []config.ManagementToken
 	)
 	if cfg != nil {
 		allowRemote = cfg.RemoteManagement.AllowRemote
 		secretHash = cfg.RemoteManagement.SecretKey
+		tokens = cfg.RemoteManagement.Tokens
 	}
 	if h.allowRemoteOverride {
 		allowRemote = true
@@ -208,7 +235,7 @@ func (h *Handler) AuthenticateManagementKey(clientIP string, localClient bool, p
 		if now.Before(ai.blockedUntil) {
 			remaining := ai.blockedUntil.Sub(now).Round(time.Second)
 			h.attemptsMu.Unlock()
-			return false, http.StatusForbidden, fmt.Sprintf("IP banned due to too many failed attempts. Try again in %s", remaining)
+			return managementIdentity{}, false, http.StatusForbidden, fmt.Sprintf("IP banned due to too many failed attempts. Try again in %s", remaining)
 		}
 		// Ban expired, reset state
 		ai.blockedUntil = time.Time{}
@@ -217,7 +244,7 @@ func (h *Handler) AuthenticateManagementKey(clientIP string, localClient bool, p
 	h.attemptsMu.Unlock()
 
 	if !localClient && !allowRemote {
-		return false, http.StatusForbidden, "remote management disabled"
+		return managementIdentity{}, false, http.StatusForbidden, "remote management disabled"
 	}
 
 	fail := func() {
@@ -245,37 +272,45 @@ func (h *Handler) AuthenticateManagementKey(clientIP string, localClient bool, p
 		h.attemptsMu.Unlock()
 	}
 
-	if secretHash == "" && envSecret == "" {
-		return false, http.StatusForbidden, "remote management key not set"
+	if secretHash == "" && envSecret == "" && len(tokens) == 0 {
+		return managementIdentity{}, false, http.StatusForbidden, "remote management key not set"
 	}
 
 	if provided == "" {
 		fail()
-		return false, http.StatusUnauthorized, "missing management key"
+		return managementIdentity{}, false, http.StatusUnauthorized, "missing management key"
 	}
 
 	if localClient {
 		if lp := h.localPassword; lp != "" {
 			if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
 				reset()
-				return true, 0, ""
+				return managementIdentity{Credential: auditCredentialLocalPassword}, true, 0, ""
 			}
 		}
 	}
 
 	if envSecret != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(envSecret)) == 1 {
 		reset()
-		return true, 0, ""
+		return managementIdentity{Credential: auditCredentialEnvSecret}, true, 0, ""
+	}
+
+	if token := h.matchManagementToken(tokens, provided); token != nil {
+		if expiry, err := token.Expiry(); err != nil || (!expiry.IsZero() && !now.Before(expiry)) {
+			return managementIdentity{}, false, http.StatusUnauthorized, "management token expired"
+		}
+		reset()
+		return managementIdentity{Credential: auditCredentialTokenPrefix + token.Name, Token: token}, true, 0, ""
 	}
 
 	if secretHash == "" || bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(provided)) != nil {
 		fail()
-		return false, http.StatusUnauthorized, "invalid management key"
+		return managementIdentity{}, false, http.StatusUnauthorized, "invalid management key"
 	}
 
 	reset()
 
-	return true, 0, ""
+	return managementIdentity{Credential: auditCredentialSecretKey}, true, 0, ""
 }
 
 // persist saves the current in-memory config to disk.
@@ -285,14 +320,29 @@ func (h *Handler) persist(c *gin.Context) bool {
 	return h.persistLocked(c)
 }
 
-// persistLocked saves the current in-memory config to disk.
+// persistLocked validates the current in-memory config and saves it to disk. An invalid config
+// is not written, and the in-memory copy is reloaded from the file so the rejected edit is dropped.
 // It expects the caller to hold h.mu.
 func (h *Handler) persistLocked(c *gin.Context) bool {
+	rendered, err := yaml.Marshal(h.cfg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to render config: %v", err)})
+		return false
+	}
+	if _, err = config.ValidateConfigBytes(rendered); err != nil {
+		if reloaded, errLoad := config.LoadConfig(h.configFilePath); errLoad == nil {
+			h.cfg = reloaded
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "message": err.Error()})
+		return false
+	}
+	h.recordConfigVersion(configversion.SourceSnapshot, "")
 	// Preserve comments when writing
 	if err := config.SaveConfigPreserveComments(h.configFilePath, h.cfg); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err)})
 		return false
 	}
+	h.recordConfigVersion(configversion.SourceManagement, "")
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	return true
 }
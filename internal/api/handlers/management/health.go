@@ -0,0 +1,64 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// GetCredentialHealth returns the routing state and latest probe results of every auth,
+// optionally filtered by ?provider=.
+func (h *Handler) GetCredentialHealth(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	provider := strings.ToLower(strings.TrimSpace(c.Query("provider")))
+	credentials := make([]coreauth.CredentialHealth, 0)
+	for _, health := range h.authManager.CredentialHealthReport() {
+		if provider != "" && strings.ToLower(health.Provider) != provider {
+			continue
+		}
+		credentials = append(credentials, health)
+	}
+	h.mu.Lock()
+	enabled := h.cfg != nil && h.cfg.HealthProbe.Enable
+	h.mu.Unlock()
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":     enabled,
+		"credentials": credentials,
+	})
+}
+
+// ProbeCredential probes the auth named by ?name= (auth ID or file name) now and returns its
+// updated health.
+func (h *Handler) ProbeCredential(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	auth := h.findAuthForDelete(name)
+	if auth == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth not found"})
+		return
+	}
+	health, err := h.authManager.ProbeAuth(c.Request.Context(), auth.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var authErr *coreauth.Error
+		if errors.As(err, &authErr) && authErr.HTTPStatus > 0 {
+			status = authErr.HTTPStatus
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, health)
+}
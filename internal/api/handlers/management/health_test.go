@@ -0,0 +1,48 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestCredentialHealthEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	for _, auth := range []*coreauth.Auth{{ID: "claude-a.json", Provider: "claude"}, {ID: "gemini-a.json", Provider: "gemini"}} {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+	h := &Handler{cfg: &config.Config{HealthProbe: config.HealthProbeConfig{Enable: true}}, authManager: manager}
+	router := gin.New()
+	router.GET("/health/credentials", h.GetCredentialHealth)
+	router.POST("/health/credentials/probe", h.ProbeCredential)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/credentials?provider=gemini", nil))
+	var report struct {
+		Enabled     bool                        `json:"enabled"`
+		Credentials []coreauth.CredentialHealth `json:"credentials"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil || !report.Enabled || len(report.Credentials) != 1 || report.Credentials[0].AuthID != "gemini-a.json" {
+		t.Fatalf("GET /health/credentials = %d %s", rec.Code, rec.Body.String())
+	}
+
+	for target, want := range map[string]int{
+		"/health/credentials/probe":                   http.StatusBadRequest,
+		"/health/credentials/probe?name=missing.json": http.StatusNotFound,
+	} {
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+		if rec.Code != want {
+			t.Fatalf("POST %s = %d, want %d", target, rec.Code, want)
+		}
+	}
+}
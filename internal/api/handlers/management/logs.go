@@ -2,6 +2,7 @@ package management
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -264,7 +265,7 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 	}
 
 	if matchedFile == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "log file not found for the given request ID"})
+		h.writeStructuredRequestLog(c, dir, requestID)
 		return
 	}
 
@@ -297,6 +298,120 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 	c.FileAttachment(fullPath, matchedFile)
 }
 
+// writeStructuredRequestLog responds with the JSONL entry recorded for requestID and the
+// contents of the bodies it references.
+func (h *Handler) writeStructuredRequestLog(c *gin.Context, dir, requestID string) {
+	entry, err := logging.FindRequestLog(dir, requestID)
+	if errors.Is(err, logging.ErrRequestLogNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "log file not found for the given request ID"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to search request logs: %v", err)})
+		return
+	}
+	bodies := make(map[string]string, len(entry.Bodies))
+	for name, ref := range entry.Bodies {
+		content, errRead := logging.ReadRequestLogBody(dir, ref)
+		if errRead != nil {
+			continue
+		}
+		bodies[name] = string(content)
+	}
+	c.JSON(http.StatusOK, gin.H{"entry": entry, "bodies": bodies})
+}
+
+// SearchRequestLogs filters structured (JSONL) request logs by time range, model, status,
+// auth and client key.
+func (h *Handler) SearchRequestLogs(c *gin.Context) {
+	dir := h.logDirectory()
+	if strings.TrimSpace(dir) == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "log directory not configured"})
+		return
+	}
+
+	var filter logging.RequestLogFilter
+	for _, bound := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		raw := strings.TrimSpace(c.Query(bound.name))
+		if raw == "" {
+			continue
+		}
+		parsed, dateOnly, errParse := parseUsageHistoryTime(raw)
+		if errParse != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s: %v", bound.name, errParse)})
+			return
+		}
+		if dateOnly && bound.name == "to" {
+			// A bare date covers the whole day.
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		*bound.dst = parsed
+	}
+	minStatus, maxStatus, errStatus := parseRequestLogStatus(c.Query("status"))
+	if errStatus != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errStatus.Error()})
+		return
+	}
+	filter.MinStatus, filter.MaxStatus = minStatus, maxStatus
+	filter.Model = strings.TrimSpace(c.Query("model"))
+	filter.Auth = strings.TrimSpace(c.Query("auth"))
+	if filter.Auth == "" {
+		filter.Auth = strings.TrimSpace(c.Query("auth_index"))
+	}
+	filter.ClientKey = strings.TrimSpace(c.Query("key"))
+
+	limit := 100
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		parsed, errParse := strconv.Atoi(raw)
+		if errParse != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = min(parsed, 1000)
+	}
+	offset := 0
+	if raw := strings.TrimSpace(c.Query("offset")); raw != "" {
+		parsed, errParse := strconv.Atoi(raw)
+		if errParse != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+			return
+		}
+		offset = parsed
+	}
+
+	entries, total, err := logging.SearchRequestLogs(dir, filter, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to search request logs: %v", err)})
+		return
+	}
+	if entries == nil {
+		entries = []logging.RequestLogEntry{}
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "total": total, "limit": limit, "offset": offset})
+}
+
+// parseRequestLogStatus accepts an exact status ("404"), a class ("5xx") or "error" (>= 400).
+func parseRequestLogStatus(raw string) (int, int, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case raw == "":
+		return 0, 0, nil
+	case raw == "error":
+		return http.StatusBadRequest, 0, nil
+	case len(raw) == 3 && strings.HasSuffix(raw, "xx") && raw[0] >= '1' && raw[0] <= '5':
+		base := int(raw[0]-'0') * 100
+		return base, base + 99, nil
+	}
+	status, err := strconv.Atoi(raw)
+	if err != nil || status < 100 || status > 599 {
+		return 0, 0, fmt.Errorf("invalid status %q", raw)
+	}
+	return status, status, nil
+}
+
 // DownloadRequestErrorLog downloads a specific error request log file by name.
 func (h *Handler) DownloadRequestErrorLog(c *gin.Context) {
 	if h == nil {
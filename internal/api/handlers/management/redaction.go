@@ -0,0 +1,47 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+)
+
+// TestRequestLogRedaction shows what the request log redaction rules would replace in a
+// sample request without writing anything. The configured rules are used unless the body
+// carries its own, so new rules can be tried before they are saved.
+func (h *Handler) TestRequestLogRedaction(c *gin.Context) {
+	var body struct {
+		Headers map[string][]string               `json:"headers"`
+		Body    string                            `json:"body"`
+		Rules   *config.RequestLogRedactionConfig `json:"rules"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	var rules config.RequestLogRedactionConfig
+	if body.Rules != nil {
+		rules = *body.Rules
+	} else {
+		h.mu.Lock()
+		if h.cfg != nil {
+			rules = h.cfg.RequestLogRedaction
+		}
+		h.mu.Unlock()
+	}
+	redactor, err := logging.NewRedactor(rules)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_rules", "message": err.Error()})
+		return
+	}
+
+	headers, redacted, findings := redactor.Test(body.Headers, []byte(body.Body))
+	c.JSON(http.StatusOK, gin.H{
+		"headers":  headers,
+		"body":     string(redacted),
+		"findings": findings,
+	})
+}
@@ -0,0 +1,411 @@
+package management
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxReplayDiffLines bounds the line-level diff; longer sections are compared line by line
+// without alignment.
+const maxReplayDiffLines = 4000
+
+// replayDroppedHeaders are inbound headers that are not reissued: credentials (the replay
+// is already authenticated), hop-by-hop headers, and encodings the log no longer matches.
+var replayDroppedHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"x-api-key":           {},
+	"x-goog-api-key":      {},
+	"cookie":              {},
+	"host":                {},
+	"connection":          {},
+	"keep-alive":          {},
+	"upgrade":             {},
+	"te":                  {},
+	"trailer":             {},
+	"transfer-encoding":   {},
+	"content-length":      {},
+	"content-encoding":    {},
+	"accept-encoding":     {},
+}
+
+// SetReplayHandler installs the HTTP handler replayed requests are dispatched to, normally
+// the server's own engine.
+func (h *Handler) SetReplayHandler(handler http.Handler) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.replayHandler = handler
+	h.mu.Unlock()
+}
+
+type replaySnapshot struct {
+	Status      int    `json:"status"`
+	APIRequest  string `json:"api_request"`
+	APIResponse string `json:"api_response"`
+	Response    string `json:"response"`
+}
+
+// DiffLine is one row of a side-by-side diff. Op is "equal", "changed", "removed" or
+// "added"; Old or New is empty when the row exists on one side only.
+type DiffLine struct {
+	Op  string `json:"op"`
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// ReplayRequestLog reissues a logged inbound request through the running server and returns
+// the original and new upstream payloads and responses with a side-by-side diff. The body
+// may pin the replay to an auth ("auth_id" or "auth_index") or override the model.
+func (h *Handler) ReplayRequestLog(c *gin.Context) {
+	var body struct {
+		AuthID    string `json:"auth_id"`
+		AuthIndex string `json:"auth_index"`
+		Model     string `json:"model"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+
+	h.mu.Lock()
+	replayHandler := h.replayHandler
+	h.mu.Unlock()
+	if replayHandler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "request replay unavailable"})
+		return
+	}
+	dir := h.logDirectory()
+	if strings.TrimSpace(dir) == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "log directory not configured"})
+		return
+	}
+
+	captured, err := logging.LoadCapturedRequest(dir, c.Param("id"))
+	if errors.Is(err, logging.ErrRequestLogNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "log file not found for the given request ID"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read request log: %v", err)})
+		return
+	}
+
+	authID := strings.TrimSpace(body.AuthID)
+	if index := strings.TrimSpace(body.AuthIndex); index != "" {
+		auth := h.authByIndex(index)
+		if auth == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "auth not found"})
+			return
+		}
+		authID = auth.ID
+	} else if authID != "" && h.authManager != nil {
+		if _, ok := h.authManager.GetByID(authID); !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "auth not found"})
+			return
+		}
+	}
+
+	req, err := buildReplayRequest(c, captured, strings.TrimSpace(body.Model))
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "not_replayable", "message": err.Error()})
+		return
+	}
+	capture := &logging.ReplayCapture{}
+	ctx := logging.WithReplayCapture(req.Context(), capture)
+	ctx = handlers.WithPinnedAuthID(ctx, authID)
+	recorder := &replayRecorder{header: make(http.Header)}
+	replayHandler.ServeHTTP(recorder, req.WithContext(ctx))
+
+	replayID, apiRequest, apiResponse := capture.Result()
+	original := replaySnapshot{
+		Status:      captured.Status,
+		APIRequest:  string(captured.APIRequest),
+		APIResponse: string(captured.APIResponse),
+		Response:    string(captured.Response),
+	}
+	replayed := replaySnapshot{
+		Status:      recorder.statusCode(),
+		APIRequest:  string(apiRequest),
+		APIResponse: string(apiResponse),
+		Response:    recorder.body.String(),
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"request_id":        captured.ID,
+		"replay_request_id": replayID,
+		"method":            req.Method,
+		"url":               req.URL.RequestURI(),
+		"auth_id":           authID,
+		"model":             strings.TrimSpace(body.Model),
+		"original":          original,
+		"replay":            replayed,
+		"diff": gin.H{
+			"api_request":  diffReplayText(original.APIRequest, replayed.APIRequest),
+			"api_response": diffReplayText(original.APIResponse, replayed.APIResponse),
+			"response":     diffReplayText(original.Response, replayed.Response),
+		},
+	})
+}
+
+// buildReplayRequest recreates the inbound request from the log. Masked credentials in the
+// query string and headers are dropped, a body with redacted fields is refused, and model, when set, replaces the model in the
+// body or in a Gemini-style "/models/<model>:<action>" path.
+func buildReplayRequest(c *gin.Context, captured *logging.CapturedRequest, model string) (*http.Request, error) {
+	method := strings.ToUpper(strings.TrimSpace(captured.Method))
+	if method == "" {
+		method = http.MethodPost
+	}
+	for key, values := range captured.Headers {
+		if strings.EqualFold(key, "Upgrade") && len(values) > 0 && strings.EqualFold(strings.TrimSpace(values[0]), "websocket") {
+			return nil, errors.New("websocket sessions cannot be replayed")
+		}
+	}
+	target, err := url.Parse(strings.TrimSpace(captured.URL))
+	if err != nil || target.Path == "" {
+		return nil, fmt.Errorf("invalid logged URL %q", captured.URL)
+	}
+	target.RawQuery = util.StripSensitiveQuery(target.RawQuery)
+
+	payload := captured.Body
+	if bytes.Contains(payload, []byte("[REDACTED")) {
+		return nil, errors.New("the logged body was redacted; replaying it would send the placeholders upstream")
+	}
+	if model != "" {
+		if gjson.GetBytes(payload, "model").Exists() {
+			if payload, err = sjson.SetBytes(payload, "model", model); err != nil {
+				return nil, fmt.Errorf("set model: %w", err)
+			}
+		} else if prefix, rest, ok := strings.Cut(target.Path, "/models/"); ok {
+			_, action, _ := strings.Cut(rest, ":")
+			target.Path = prefix + "/models/" + model
+			if action != "" {
+				target.Path += ":" + action
+			}
+		} else {
+			return nil, errors.New("the logged request does not name a model to override")
+		}
+	}
+
+	req, err := http.NewRequestWithContext(c.Request.Context(), method, target.RequestURI(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range captured.Headers {
+		if _, drop := replayDroppedHeaders[strings.ToLower(key)]; drop {
+			continue
+		}
+		for _, value := range values {
+			if strings.Contains(value, "[REDACTED") {
+				continue
+			}
+			req.Header.Add(key, value)
+		}
+	}
+	if req.Header.Get("Content-Type") == "" && len(payload) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.RemoteAddr = c.Request.RemoteAddr
+	return req, nil
+}
+
+// replayRecorder buffers the response of a replayed request.
+type replayRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *replayRecorder) Header() http.Header { return r.header }
+
+func (r *replayRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *replayRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+func (r *replayRecorder) Flush() {}
+
+func (r *replayRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// diffReplayText compares two logged sections line by line after dropping timestamps and
+// indenting single-line JSON, so the rows line up on the fields that actually changed.
+func diffReplayText(oldText, newText string) []DiffLine {
+	oldLines := normalizeReplayLines(oldText)
+	newLines := normalizeReplayLines(newText)
+	if len(oldLines) > maxReplayDiffLines || len(newLines) > maxReplayDiffLines {
+		return pairDiffLines(oldLines, newLines)
+	}
+
+	rows := make([]DiffLine, 0, max(len(oldLines), len(newLines)))
+	var removed, added []string
+	flush := func() {
+		rows = append(rows, pairDiffLines(removed, added)...)
+		removed, added = removed[:0], added[:0]
+	}
+	myersDiff(oldLines, newLines, func(op string, line string) {
+		switch op {
+		case "removed":
+			removed = append(removed, line)
+		case "added":
+			added = append(added, line)
+		default:
+			flush()
+			rows = append(rows, DiffLine{Op: "equal", Old: line, New: line})
+		}
+	})
+	flush()
+	return rows
+}
+
+// myersDiff reports the shortest edit script from a to b as "equal", "removed" and "added"
+// lines in order. It splits the problem at the middle snake (Myers 1986, section 4b), so
+// memory stays linear in the input.
+func myersDiff(a, b []string, emit func(op, line string)) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		emit("equal", a[prefix])
+		prefix++
+	}
+	a, b = a[prefix:], b[prefix:]
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	tail := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch x, y, u, v := middleSnake(a, b); {
+	case len(a) == 0 || len(b) == 0 || (x == len(a) && y == len(b)) || (u == 0 && v == 0):
+		for _, line := range a {
+			emit("removed", line)
+		}
+		for _, line := range b {
+			emit("added", line)
+		}
+	default:
+		myersDiff(a[:x], b[:y], emit)
+		for _, line := range a[x:u] {
+			emit("equal", line)
+		}
+		myersDiff(a[u:], b[v:], emit)
+	}
+	for _, line := range tail {
+		emit("equal", line)
+	}
+}
+
+// middleSnake returns the snake from (x, y) to (u, v) in the middle of a shortest edit path
+// from a to b, searching forward from the start and backward from the end at the same time.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return 0, 0, 0, 0
+	}
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	forward := make([]int, 2*maxD+3)
+	backward := make([]int, 2*maxD+3)
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			start := forward[offset+k-1] + 1
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				start = forward[offset+k+1]
+			}
+			end := start
+			for end < n && end-k < m && a[end] == b[end-k] {
+				end++
+			}
+			forward[offset+k] = end
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && end+backward[offset+c] >= n {
+				return start, start - k, end, end - k
+			}
+		}
+		for c := -d; c <= d; c += 2 {
+			start := backward[offset+c-1] + 1
+			if c == -d || (c != d && backward[offset+c-1] < backward[offset+c+1]) {
+				start = backward[offset+c+1]
+			}
+			end := start
+			for end < n && end-c < m && a[n-1-end] == b[m-1-(end-c)] {
+				end++
+			}
+			backward[offset+c] = end
+			if k := delta - c; !odd && k >= -d && k <= d && end+forward[offset+k] >= n {
+				return n - end, m - (end - c), n - start, m - (start - c)
+			}
+		}
+	}
+	return n, m, n, m
+}
+
+// pairDiffLines lays out a removed block next to an added block.
+func pairDiffLines(removed, added []string) []DiffLine {
+	rows := make([]DiffLine, 0, max(len(removed), len(added)))
+	for k := 0; k < len(removed) || k < len(added); k++ {
+		switch {
+		case k < len(removed) && k < len(added):
+			op := "changed"
+			if removed[k] == added[k] {
+				op = "equal"
+			}
+			rows = append(rows, DiffLine{Op: op, Old: removed[k], New: added[k]})
+		case k < len(removed):
+			rows = append(rows, DiffLine{Op: "removed", Old: removed[k]})
+		default:
+			rows = append(rows, DiffLine{Op: "added", New: added[k]})
+		}
+	}
+	return rows
+}
+
+func normalizeReplayLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "Timestamp: ") {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+			var indented bytes.Buffer
+			if json.Indent(&indented, []byte(trimmed), "", "  ") == nil {
+				lines = append(lines, strings.Split(indented.String(), "\n")...)
+				continue
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,158 @@
+package management
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/tidwall/gjson"
+)
+
+func TestReplayRequestLogReissuesRequestAndDiffs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logsDir := t.TempDir()
+	logger := logging.NewFileRequestLogger(true, logsDir, "", 0)
+	errLog := logger.LogRequest("/v1/chat/completions?key=sk-secret&debug=1", http.MethodPost,
+		map[string][]string{"Authorization": {"Bearer sk-client"}, "Content-Type": {"application/json"}, "X-Trace": {"t-1"}},
+		[]byte(`{"model":"gpt-5","messages":[]}`), http.StatusOK, map[string][]string{"Content-Type": {"application/json"}},
+		[]byte(`{"id":"old"}`), nil,
+		[]byte("=== API REQUEST 1 ===\nTimestamp: x\nUpstream URL: https://upstream/v1\n\nBody:\n{\"model\":\"gpt-5\",\"n\":1}\n\n"),
+		[]byte("=== API RESPONSE 1 ===\nStatus: 200\n\n{\"id\":\"old\"}\n"), nil, nil, "req-1", time.Now(), time.Time{})
+	if errLog != nil {
+		t.Fatalf("LogRequest: %v", errLog)
+	}
+
+	var forwarded *http.Request
+	engine := gin.New()
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		forwarded = c.Request
+		capture := logging.ReplayCaptureFrom(c.Request.Context())
+		if capture == nil {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(c.Request.Body)
+		model := gjson.GetBytes(body, "model").String()
+		c.Set("API_REQUEST", []byte("=== API REQUEST 1 ===\nTimestamp: y\nUpstream URL: https://upstream/v1\n\nBody:\n{\"model\":\""+model+"\",\"n\":1}\n"))
+		c.Set("API_RESPONSE", []byte("=== API RESPONSE 1 ===\nStatus: 200\n\n{\"id\":\"new\"}\n"))
+		c.JSON(http.StatusOK, gin.H{"id": "new"})
+		capture.Collect(c)
+	})
+
+	h := &Handler{cfg: &config.Config{}, logDir: logsDir}
+	h.SetReplayHandler(engine)
+	router := gin.New()
+	router.POST("/request-log-by-id/:id/replay", h.ReplayRequestLog)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/request-log-by-id/req-1/replay", strings.NewReader(`{"model":"gpt-5-mini"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("replay status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	if forwarded.Header.Get("Authorization") != "" || forwarded.Header.Get("X-Trace") != "t-1" || forwarded.URL.RawQuery != "debug=1" {
+		t.Fatalf("unexpected forwarded request: headers=%v query=%q", forwarded.Header, forwarded.URL.RawQuery)
+	}
+
+	var result struct {
+		Original replaySnapshot        `json:"original"`
+		Replay   replaySnapshot        `json:"replay"`
+		Diff     map[string][]DiffLine `json:"diff"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if result.Original.Response != `{"id":"old"}` || result.Replay.Response != `{"id":"new"}` {
+		t.Fatalf("unexpected responses: %+v / %+v", result.Original, result.Replay)
+	}
+	var changed []DiffLine
+	for _, row := range result.Diff["api_request"] {
+		if row.Op != "equal" {
+			changed = append(changed, row)
+		}
+	}
+	if len(changed) != 1 || !strings.Contains(changed[0].Old, `"gpt-5"`) || !strings.Contains(changed[0].New, `"gpt-5-mini"`) {
+		t.Fatalf("unexpected api_request diff: %+v", result.Diff["api_request"])
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/request-log-by-id/missing/replay", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("missing log returned %d", rec.Code)
+	}
+}
+
+func TestBuildReplayRequestRefusesRedactedBody(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/request-log-by-id/req-1/replay", nil)
+	captured := &logging.CapturedRequest{
+		Method: http.MethodPost,
+		URL:    "/v1/chat/completions",
+		Body:   []byte(`{"model":"gpt-5","user":"[REDACTED:email]"}`),
+	}
+	if _, err := buildReplayRequest(c, captured, ""); err == nil {
+		t.Fatal("buildReplayRequest() accepted a body with redaction markers")
+	}
+}
+
+func TestDiffReplayTextFindsShortestEditScript(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	randomLines := func() []string {
+		lines := make([]string, rng.IntN(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.IntN(4)))
+		}
+		return lines
+	}
+	for round := 0; round < 500; round++ {
+		oldLines, newLines := randomLines(), randomLines()
+		var gotOld, gotNew []string
+		edits := 0
+		myersDiff(oldLines, newLines, func(op, line string) {
+			if op != "added" {
+				gotOld = append(gotOld, line)
+			}
+			if op != "removed" {
+				gotNew = append(gotNew, line)
+			}
+			if op != "equal" {
+				edits++
+			}
+		})
+		if strings.Join(gotOld, "") != strings.Join(oldLines, "") || strings.Join(gotNew, "") != strings.Join(newLines, "") {
+			t.Fatalf("script does not rebuild %v -> %v: %v / %v", oldLines, newLines, gotOld, gotNew)
+		}
+		if want := len(oldLines) + len(newLines) - 2*longestCommonSubsequence(oldLines, newLines); edits != want {
+			t.Fatalf("%v -> %v: %d edits, want %d", oldLines, newLines, edits, want)
+		}
+	}
+
+	rows := diffReplayText("a\nb\nc\n", "a\nx\nc\n")
+	if len(rows) != 3 || rows[1].Op != "changed" || rows[1].Old != "b" || rows[1].New != "x" {
+		t.Fatalf("rows = %+v", rows)
+	}
+}
+
+func longestCommonSubsequence(a, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	return table[0][0]
+}
@@ -0,0 +1,74 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+)
+
+func TestSearchAndFetchStructuredRequestLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logsDir := t.TempDir()
+	logger := logging.NewFileRequestLogger(true, logsDir, "", 0)
+	logger.SetFormat(logging.RequestLogFormatJSONL, 7)
+	for _, tc := range []struct {
+		id     string
+		model  string
+		status int
+	}{{"req-ok", "gpt-5", http.StatusOK}, {"req-fail", "gpt-5", http.StatusTooManyRequests}, {"req-other", "claude-sonnet-4", http.StatusOK}} {
+		err := logger.LogRequestWithMeta(logging.RequestLogMeta{Model: tc.model, AuthIndex: "2"}, "/v1/responses", http.MethodPost,
+			nil, []byte(`{"input":"hi"}`), tc.status, nil, []byte(`{"ok":true}`), nil, nil, nil, nil, nil, false, tc.id, time.Now(), time.Time{})
+		if err != nil {
+			t.Fatalf("LogRequestWithMeta: %v", err)
+		}
+	}
+
+	h := &Handler{cfg: &config.Config{}, logDir: logsDir}
+	router := gin.New()
+	router.GET("/request-logs/search", h.SearchRequestLogs)
+	router.GET("/request-log-by-id/:id", h.GetRequestLogByID)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/request-logs/search?model=gpt-5&status=2xx&auth=2", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("search status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	var search struct {
+		Entries []logging.RequestLogEntry `json:"entries"`
+		Total   int                       `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &search); err != nil {
+		t.Fatalf("decode search: %v", err)
+	}
+	if search.Total != 1 || search.Entries[0].ID != "req-ok" {
+		t.Fatalf("unexpected search result: %+v", search)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/request-logs/search?status=abc", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid status filter returned %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/request-log-by-id/req-fail", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("by-id status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	var byID struct {
+		Entry  logging.RequestLogEntry `json:"entry"`
+		Bodies map[string]string       `json:"bodies"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &byID); err != nil {
+		t.Fatalf("decode by-id: %v", err)
+	}
+	if byID.Entry.Status != http.StatusTooManyRequests || byID.Bodies[logging.RequestLogBodyRequest] != `{"input":"hi"}` {
+		t.Fatalf("unexpected by-id response: %+v", byID)
+	}
+}
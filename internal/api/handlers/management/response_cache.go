@@ -0,0 +1,40 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsecache"
+)
+
+// GetResponseCache returns response cache counters and the metadata of cached entries.
+func (h *Handler) GetResponseCache(c *gin.Context) {
+	cache := responsecache.Default()
+	entries := cache.Entries()
+	if entries == nil {
+		entries = []responsecache.Entry{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"stats":   cache.Stats(),
+		"entries": entries,
+	})
+}
+
+// DeleteResponseCache purges the response cache, or a single entry when ?key= is given.
+func (h *Handler) DeleteResponseCache(c *gin.Context) {
+	cache := responsecache.Default()
+	if !cache.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "response cache disabled"})
+		return
+	}
+	if key := strings.TrimSpace(c.Query("key")); key != "" {
+		if !cache.Delete(key) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "entry not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "removed": 1})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "removed": cache.Purge()})
+}
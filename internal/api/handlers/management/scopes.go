@@ -0,0 +1,206 @@
+package management
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageledger"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const managementRoutePrefix = "/v0/management"
+
+// managementIdentity is the credential that authenticated a management request.
+type managementIdentity struct {
+	// Credential is recorded in the audit log, e.g. "secret-key" or "token:oncall".
+	Credential string
+	// Token is set when a scoped management token was used.
+	Token *config.ManagementToken
+}
+
+// allows reports whether the identity may call route with method and query. Only scoped tokens
+// are restricted.
+func (id managementIdentity) allows(method, route string, query url.Values) (bool, string) {
+	if id.Token == nil {
+		return true, ""
+	}
+	scopes := id.Token.Scopes
+	if slices.Contains(scopes, config.ManagementScopeAdmin) {
+		return true, ""
+	}
+	route = strings.TrimPrefix(route, managementRoutePrefix)
+	rule := managementRouteRule(route)
+	if route == "/usage/history" && usageQueryNamesClientKey(query) {
+		// Grouping or filtering by client key reveals the keys themselves.
+		rule = routeRule{scope: config.ManagementScopeKeys, guardReads: true}
+	}
+	if rule.open {
+		return true, ""
+	}
+	if rule.scope != "" && slices.Contains(scopes, rule.scope) {
+		return true, ""
+	}
+	if isReadMethod(method) && !rule.guardReads && slices.Contains(scopes, config.ManagementScopeReadOnly) {
+		return true, ""
+	}
+	required := rule.scope
+	if required == "" {
+		required = config.ManagementScopeAdmin
+	}
+	return false, required
+}
+
+// routeRule describes which scope guards a management route.
+type routeRule struct {
+	// scope grants every method on the route. Empty means only admin may change it.
+	scope string
+	// guardReads requires scope for GET too, because the response reveals secrets or the
+	// GET itself has side effects.
+	guardReads bool
+	// open routes are available to every authenticated credential.
+	open bool
+}
+
+// managementRouteRules match a route equal to the prefix or below it, in order. Routes without
+// a match are guarded by the config scope, reads included, so a new route never leaks to
+// read-only tokens by omission.
+var managementRouteRules = []struct {
+	prefix string
+	rule   routeRule
+}{
+	{"/session", routeRule{open: true}},
+	{"/auth-files/download", routeRule{scope: config.ManagementScopeAuthFiles, guardReads: true}},
+	{"/auth-files", routeRule{scope: config.ManagementScopeAuthFiles}},
+	{"/get-auth-status", routeRule{scope: config.ManagementScopeAuthFiles, guardReads: true}},
+	{"/oauth-callback", routeRule{scope: config.ManagementScopeAuthFiles, guardReads: true}},
+	{"/vertex/import", routeRule{scope: config.ManagementScopeAuthFiles, guardReads: true}},
+	{"/api-keys", routeRule{scope: config.ManagementScopeKeys, guardReads: true}},
+	{"/api-key-usage", routeRule{scope: config.ManagementScopeKeys, guardReads: true}},
+	{"/gemini-api-key", routeRule{scope: config.ManagementScopeKeys, guardReads: true}},
+	{"/claude-api-key", routeRule{scope: config.ManagementScopeKeys, guardReads: true}},
+	{"/codex-api-key", routeRule{scope: config.ManagementScopeKeys, guardReads: true}},
+	{"/vertex-api-key", routeRule{scope: config.ManagementScopeKeys, guardReads: true}},
+	{"/openai-compatibility", routeRule{scope: config.ManagementScopeKeys, guardReads: true}},
+	{"/ampcode", routeRule{scope: config.ManagementScopeKeys, guardReads: true}},
+	{"/access-providers", routeRule{scope: config.ManagementScopeKeys, guardReads: true}},
+	{"/config", routeRule{scope: config.ManagementScopeConfig, guardReads: true}},
+	{"/config.yaml", routeRule{scope: config.ManagementScopeConfig, guardReads: true}},
+	{"/proxy-url", routeRule{scope: config.ManagementScopeConfig, guardReads: true}},
+	{"/logs", routeRule{scope: config.ManagementScopeLogs, guardReads: true}},
+	{"/request-error-logs", routeRule{scope: config.ManagementScopeLogs, guardReads: true}},
+	// Replays send real upstream requests, which the logs scope alone must not allow.
+	{"/request-log-by-id/:id/replay", routeRule{scope: config.ManagementScopeConfig, guardReads: true}},
+	{"/request-log-by-id", routeRule{scope: config.ManagementScopeLogs, guardReads: true}},
+	{"/request-logs", routeRule{scope: config.ManagementScopeLogs, guardReads: true}},
+	{"/request-log-redaction/test", routeRule{scope: config.ManagementScopeLogs, guardReads: true}},
+	{"/api-call", routeRule{guardReads: true}},
+	{"/store/migrate", routeRule{guardReads: true}},
+	{"/usage-queue", routeRule{guardReads: true}},
+	{"/usage/history/export", routeRule{scope: config.ManagementScopeKeys, guardReads: true}},
+	{"/usage", routeRule{}},
+	{"/audit", routeRule{}},
+	{"/latest-version", routeRule{}},
+	{"/model-definitions", routeRule{}},
+	{"/response-cache", routeRule{scope: config.ManagementScopeConfig, guardReads: true}},
+	{"/session-bindings", routeRule{scope: config.ManagementScopeConfig, guardReads: true}},
+	{"/signature-cache", routeRule{scope: config.ManagementScopeConfig, guardReads: true}},
+	// Plain settings that read-only tokens may view.
+	{"/debug", routeRule{scope: config.ManagementScopeConfig}},
+	{"/logging-to-file", routeRule{scope: config.ManagementScopeConfig}},
+	{"/logs-max-total-size-mb", routeRule{scope: config.ManagementScopeConfig}},
+	{"/error-logs-max-files", routeRule{scope: config.ManagementScopeConfig}},
+	{"/usage-statistics-enabled", routeRule{scope: config.ManagementScopeConfig}},
+	{"/quota-exceeded", routeRule{scope: config.ManagementScopeConfig}},
+	{"/request-log", routeRule{scope: config.ManagementScopeConfig}},
+	{"/ws-auth", routeRule{scope: config.ManagementScopeConfig}},
+	{"/request-retry", routeRule{scope: config.ManagementScopeConfig}},
+	{"/max-retry-interval", routeRule{scope: config.ManagementScopeConfig}},
+	{"/force-model-prefix", routeRule{scope: config.ManagementScopeConfig}},
+	{"/routing", routeRule{scope: config.ManagementScopeConfig}},
+	{"/oauth-excluded-models", routeRule{scope: config.ManagementScopeConfig}},
+	{"/oauth-model-alias", routeRule{scope: config.ManagementScopeConfig}},
+	{"/health", routeRule{scope: config.ManagementScopeConfig}},
+}
+
+func managementRouteRule(route string) routeRule {
+	// OAuth login URLs start a login flow that writes a new auth file.
+	if strings.HasSuffix(route, "-auth-url") {
+		return routeRule{scope: config.ManagementScopeAuthFiles, guardReads: true}
+	}
+	for _, entry := range managementRouteRules {
+		if route == entry.prefix || strings.HasPrefix(route, entry.prefix+"/") {
+			return entry.rule
+		}
+	}
+	return routeRule{scope: config.ManagementScopeConfig, guardReads: true}
+}
+
+// usageQueryNamesClientKey reports whether a usage history query groups or filters by client key.
+func usageQueryNamesClientKey(query url.Values) bool {
+	if strings.TrimSpace(query.Get("client_key")) != "" {
+		return true
+	}
+	dimensions, _ := usageledger.ParseDimensions(query.Get("group_by"))
+	return slices.Contains(dimensions, usageledger.DimensionClientKey)
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// matchManagementToken returns the configured token matching provided. Verified bcrypt matches
+// are cached so a token does not pay the hashing cost on every request.
+func (h *Handler) matchManagementToken(tokens []config.ManagementToken, provided string) *config.ManagementToken {
+	digest := sha256.Sum256([]byte(provided))
+	for i := range tokens {
+		token := &tokens[i]
+		if token.Key == "" {
+			continue
+		}
+		if !looksLikeBcryptHash(token.Key) {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token.Key)) == 1 {
+				return token
+			}
+			continue
+		}
+		cacheKey := token.Key + "\x00" + string(digest[:])
+		if _, ok := h.tokenMatches.Load(cacheKey); ok {
+			return token
+		}
+		if bcrypt.CompareHashAndPassword([]byte(token.Key), []byte(provided)) == nil {
+			h.tokenMatches.Store(cacheKey, struct{}{})
+			return token
+		}
+	}
+	return nil
+}
+
+func looksLikeBcryptHash(value string) bool {
+	return strings.HasPrefix(value, "$2a$") || strings.HasPrefix(value, "$2b$") || strings.HasPrefix(value, "$2y$")
+}
+
+// GetSession describes the credential used for the request so clients can adapt to reduced
+// permissions.
+func (h *Handler) GetSession(c *gin.Context) {
+	identity, _ := c.Get(managementIdentityKey)
+	id, _ := identity.(managementIdentity)
+	resp := gin.H{
+		"credential": id.Credential,
+		"scopes":     []string{config.ManagementScopeAdmin},
+	}
+	if id.Token != nil {
+		resp["name"] = id.Token.Name
+		resp["scopes"] = id.Token.Scopes
+		if expiry, err := id.Token.Expiry(); err == nil && !expiry.IsZero() {
+			resp["expires-at"] = expiry.UTC().Format(time.RFC3339)
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
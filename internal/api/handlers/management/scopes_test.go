@@ -0,0 +1,105 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestMiddlewareEnforcesTokenScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.RemoteManagement.Tokens = []config.ManagementToken{
+		{Name: "viewer", Key: "viewer-key", Scopes: []string{config.ManagementScopeReadOnly, config.ManagementScopeLogs}},
+		{Name: "stale", Key: "stale-key", Scopes: []string{config.ManagementScopeAdmin}, ExpiresAt: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+	}
+	h := &Handler{cfg: cfg, failedAttempts: make(map[string]*attemptInfo)}
+
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) }
+	router := gin.New()
+	mgmt := router.Group("/v0/management")
+	mgmt.Use(h.Middleware())
+	mgmt.GET("/session", h.GetSession)
+	mgmt.GET("/usage/history", ok)
+	mgmt.GET("/logs", ok)
+	mgmt.GET("/auth-files/download", ok)
+	mgmt.GET("/debug", ok)
+	mgmt.PUT("/debug", ok)
+	mgmt.GET("/ampcode", ok)
+	mgmt.GET("/usage/history/export", ok)
+	mgmt.POST("/request-log-by-id/:id/replay", ok)
+	mgmt.GET("/response-cache", ok)
+	mgmt.GET("/unlisted-setting", ok)
+
+	send := func(method, target, key string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = "127.0.0.1:5000"
+		req.Header.Set("Authorization", "Bearer "+key)
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, target := range []string{"/v0/management/usage/history", "/v0/management/usage/history?group_by=model", "/v0/management/logs", "/v0/management/debug"} {
+		if rec := send(http.MethodGet, target, "viewer-key"); rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d; body=%s", target, rec.Code, rec.Body.String())
+		}
+	}
+
+	denied := []struct {
+		method string
+		target string
+		scope  string
+	}{
+		{http.MethodGet, "/v0/management/auth-files/download", config.ManagementScopeAuthFiles},
+		{http.MethodPut, "/v0/management/debug", config.ManagementScopeConfig},
+		{http.MethodGet, "/v0/management/ampcode", config.ManagementScopeKeys},
+		{http.MethodGet, "/v0/management/usage/history?group_by=model,client_key", config.ManagementScopeKeys},
+		{http.MethodGet, "/v0/management/usage/history?client_key=sk-guess", config.ManagementScopeKeys},
+		{http.MethodGet, "/v0/management/usage/history/export", config.ManagementScopeKeys},
+		{http.MethodPost, "/v0/management/request-log-by-id/req-1/replay", config.ManagementScopeConfig},
+		{http.MethodGet, "/v0/management/response-cache", config.ManagementScopeConfig},
+		{http.MethodGet, "/v0/management/unlisted-setting", config.ManagementScopeConfig},
+	}
+	for _, tc := range denied {
+		rec := send(tc.method, tc.target, "viewer-key")
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("%s %s status = %d, want %d", tc.method, tc.target, rec.Code, http.StatusForbidden)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if body["scope"] != tc.scope {
+			t.Fatalf("%s %s scope = %q, want %q", tc.method, tc.target, body["scope"], tc.scope)
+		}
+	}
+
+	if rec := send(http.MethodGet, "/v0/management/logs", "stale-key"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expired token status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	rec := send(http.MethodGet, "/v0/management/session", "viewer-key")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("session status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	var session struct {
+		Credential string   `json:"credential"`
+		Scopes     []string `json:"scopes"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &session); err != nil {
+		t.Fatalf("decode session: %v", err)
+	}
+	if session.Credential != "token:viewer" || len(session.Scopes) != 2 {
+		t.Fatalf("unexpected session: %+v", session)
+	}
+
+	if allowed, status, _ := h.AuthenticateManagementKey("127.0.0.1", true, "viewer-key"); allowed || status != http.StatusForbidden {
+		t.Fatalf("AuthenticateManagementKey allowed=%v status=%d, want forbidden", allowed, status)
+	}
+}
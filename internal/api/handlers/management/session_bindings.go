@@ -0,0 +1,59 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// sessionBindingView is a session-affinity binding with its key split into parts.
+type sessionBindingView struct {
+	coreauth.SessionBinding
+	SessionID string `json:"session_id"`
+}
+
+// GetSessionBindings lists the session-affinity bindings, optionally filtered by ?session=.
+func (h *Handler) GetSessionBindings(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	session := strings.TrimSpace(c.Query("session"))
+	bindings := make([]sessionBindingView, 0)
+	for _, binding := range h.authManager.SessionBindings() {
+		sessionID := coreauth.SessionBindingSessionID(binding.Key)
+		if session != "" && sessionID != session {
+			continue
+		}
+		bindings = append(bindings, sessionBindingView{SessionBinding: binding, SessionID: sessionID})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  h.authManager.SessionAffinityEnabled(),
+		"bindings": bindings,
+	})
+}
+
+// DeleteSessionBindings removes the bindings of ?session=, or every binding when ?all=true.
+func (h *Handler) DeleteSessionBindings(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	if !h.authManager.SessionAffinityEnabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "session affinity disabled"})
+		return
+	}
+	session := strings.TrimSpace(c.Query("session"))
+	if session == "" && !strings.EqualFold(strings.TrimSpace(c.Query("all")), "true") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "session is required (or all=true)"})
+		return
+	}
+	removed := h.authManager.InvalidateSession(session)
+	if session != "" && removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "removed": removed})
+}
@@ -0,0 +1,46 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
+)
+
+// GetSignatureCache summarizes the cached thinking signatures per model group.
+func (h *Handler) GetSignatureCache(c *gin.Context) {
+	groups := cache.SignatureGroups()
+	if groups == nil {
+		groups = []cache.SignatureGroupInfo{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": cache.SignatureCacheEnabled(),
+		"ttl":     cache.SignatureCacheTTL.String(),
+		"groups":  groups,
+	})
+}
+
+// DeleteSignatureCache clears the model group named by ?group=, or every group when ?all=true.
+func (h *Handler) DeleteSignatureCache(c *gin.Context) {
+	group := strings.TrimSpace(c.Query("group"))
+	if group == "" {
+		if !strings.EqualFold(strings.TrimSpace(c.Query("all")), "true") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "group is required (or all=true)"})
+			return
+		}
+		removed := 0
+		for _, info := range cache.SignatureGroups() {
+			removed += cache.ClearSignatureGroup(info.Group)
+		}
+		cache.ClearSignatureCache("")
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "removed": removed})
+		return
+	}
+	removed := cache.ClearSignatureGroup(group)
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "removed": removed})
+}
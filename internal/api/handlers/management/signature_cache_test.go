@@ -0,0 +1,68 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestSessionBindingsAndSignatureCacheEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache.ClearSignatureCache("")
+	t.Cleanup(func() { cache.ClearSignatureCache("") })
+	cache.CacheSignature("claude-sonnet-4-5", "thinking", "endpoint_signature_1234567890123456789012345678901234567890")
+
+	manager := coreauth.NewManager(nil, coreauth.NewSessionAffinitySelector(nil), nil)
+	t.Cleanup(manager.StopAutoRefresh)
+	manager.RestoreSessionBindings([]coreauth.SessionBinding{
+		{Key: "claude::session-1::claude-sonnet-4-5", AuthID: "auth-a", ExpiresAt: time.Now().Add(time.Hour)},
+		{Key: "claude::session-2::claude-sonnet-4-5", AuthID: "auth-b", ExpiresAt: time.Now().Add(time.Hour)},
+	})
+	h := &Handler{authManager: manager}
+	router := gin.New()
+	router.GET("/session-bindings", h.GetSessionBindings)
+	router.DELETE("/session-bindings", h.DeleteSessionBindings)
+	router.GET("/signature-cache", h.GetSignatureCache)
+	router.DELETE("/signature-cache", h.DeleteSignatureCache)
+	send := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	rec := send(http.MethodGet, "/session-bindings?session=session-1")
+	var listed struct {
+		Bindings []sessionBindingView `json:"bindings"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil || len(listed.Bindings) != 1 || listed.Bindings[0].AuthID != "auth-a" {
+		t.Fatalf("GET /session-bindings = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec = send(http.MethodDelete, "/session-bindings"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("DELETE without session = %d, want 400", rec.Code)
+	}
+	if rec = send(http.MethodDelete, "/session-bindings?session=session-1"); rec.Code != http.StatusOK {
+		t.Fatalf("DELETE session = %d %s", rec.Code, rec.Body.String())
+	}
+	if bindings := manager.SessionBindings(); len(bindings) != 1 || bindings[0].AuthID != "auth-b" {
+		t.Fatalf("bindings after delete = %+v", bindings)
+	}
+
+	if rec = send(http.MethodGet, "/signature-cache"); rec.Code != http.StatusOK {
+		t.Fatalf("GET /signature-cache = %d", rec.Code)
+	}
+	if rec = send(http.MethodDelete, "/signature-cache?group=gemini"); rec.Code != http.StatusNotFound {
+		t.Fatalf("DELETE unknown group = %d, want 404", rec.Code)
+	}
+	if rec = send(http.MethodDelete, "/signature-cache?group=claude"); rec.Code != http.StatusOK {
+		t.Fatalf("DELETE group = %d %s", rec.Code, rec.Body.String())
+	}
+	if groups := cache.SignatureGroups(); len(groups) != 0 {
+		t.Fatalf("groups after delete = %+v", groups)
+	}
+}
@@ -0,0 +1,63 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/store"
+)
+
+// migrateStoreTimeout bounds a migration started from the management API.
+const migrateStoreTimeout = 10 * time.Minute
+
+// MigrateStore copies every auth record and the config file from the active token store to the
+// target of the requested type configured through the MIGRATE_* environment variables. The
+// request only picks the type; connection details are never taken from the body. With
+// "dry-run" the target is opened read-only and the diff is returned without writing.
+func (h *Handler) MigrateStore(c *gin.Context) {
+	var body struct {
+		Target store.MigrationTarget `json:"target"`
+		DryRun bool                  `json:"dry-run"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if body.Target != (store.MigrationTarget{Type: body.Target.Type}) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only target.type may be set; configure the target through MIGRATE_* environment variables"})
+		return
+	}
+	targetCfg := store.MigrationTargetFromEnv(body.Target.Type)
+	if !targetCfg.Configured() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "migration target not configured"})
+		return
+	}
+	if h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config unavailable"})
+		return
+	}
+	tokenStore := h.tokenStoreWithBaseDir()
+	if tokenStore == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "token store unavailable"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), migrateStoreTimeout)
+	defer cancel()
+	target, closeTarget, err := store.OpenMigrationTarget(ctx, targetCfg, body.DryRun)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer closeTarget()
+
+	source := store.MigrationSource(tokenStore, h.cfg.AuthDir, h.configFilePath)
+	report, err := store.Migrate(ctx, source, target, body.DryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": report})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
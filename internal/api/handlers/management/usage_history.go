@@ -0,0 +1,135 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageledger"
+)
+
+const defaultUsageHistoryRange = 7 * 24 * time.Hour
+
+type usageHistoryQuery struct {
+	filter     usageledger.Filter
+	interval   usageledger.Interval
+	dimensions []string
+}
+
+// GetUsageHistory returns aggregated usage from the persistent ledger.
+// Supports from/to (RFC3339 or YYYY-MM-DD), interval (hour|day), group_by
+// (provider,model,alias,auth,client_key), provider/model/auth_index/client_key filters
+// and format=csv.
+func (h *Handler) GetUsageHistory(c *gin.Context) {
+	if strings.EqualFold(strings.TrimSpace(c.Query("format")), "csv") {
+		h.ExportUsageHistory(c)
+		return
+	}
+	query, rows, ok := h.loadUsageHistory(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":     query.filter.From,
+		"to":       query.filter.To,
+		"interval": string(query.interval),
+		"group_by": query.dimensions,
+		"rows":     rows,
+	})
+}
+
+// ExportUsageHistory streams aggregated usage from the persistent ledger as CSV.
+func (h *Handler) ExportUsageHistory(c *gin.Context) {
+	query, rows, ok := h.loadUsageHistory(c)
+	if !ok {
+		return
+	}
+	filename := fmt.Sprintf("usage-%s-%s.csv", query.filter.From.Format("20060102"), query.filter.To.Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	if err := usageledger.WriteCSV(c.Writer, rows, query.interval, query.dimensions); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+func (h *Handler) loadUsageHistory(c *gin.Context) (usageHistoryQuery, []usageledger.Row, bool) {
+	ledger := usageledger.Default()
+	if !ledger.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage ledger disabled"})
+		return usageHistoryQuery{}, nil, false
+	}
+	query, err := parseUsageHistoryQuery(c, time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return usageHistoryQuery{}, nil, false
+	}
+	entries, err := ledger.Query(c.Request.Context(), query.filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return usageHistoryQuery{}, nil, false
+	}
+	return query, usageledger.Aggregate(entries, query.interval, query.dimensions), true
+}
+
+func parseUsageHistoryQuery(c *gin.Context, now time.Time) (usageHistoryQuery, error) {
+	var query usageHistoryQuery
+
+	to := now
+	if raw := strings.TrimSpace(c.Query("to")); raw != "" {
+		parsed, dateOnly, err := parseUsageHistoryTime(raw)
+		if err != nil {
+			return query, fmt.Errorf("invalid to: %w", err)
+		}
+		if dateOnly {
+			// A bare date covers the whole day.
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultUsageHistoryRange)
+	if raw := strings.TrimSpace(c.Query("from")); raw != "" {
+		parsed, _, err := parseUsageHistoryTime(raw)
+		if err != nil {
+			return query, fmt.Errorf("invalid from: %w", err)
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return query, fmt.Errorf("from must be before to")
+	}
+
+	interval, err := usageledger.ParseInterval(c.Query("interval"))
+	if err != nil {
+		return query, err
+	}
+	dimensions, err := usageledger.ParseDimensions(c.Query("group_by"))
+	if err != nil {
+		return query, err
+	}
+
+	query.filter = usageledger.Filter{
+		From:      from,
+		To:        to,
+		Provider:  strings.TrimSpace(c.Query("provider")),
+		Model:     strings.TrimSpace(c.Query("model")),
+		AuthIndex: strings.TrimSpace(c.Query("auth_index")),
+		ClientKey: strings.TrimSpace(c.Query("client_key")),
+	}
+	query.interval = interval
+	query.dimensions = dimensions
+	return query, nil
+}
+
+func parseUsageHistoryTime(raw string) (time.Time, bool, error) {
+	if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+		return parsed.UTC(), false, nil
+	}
+	parsed, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected RFC3339 timestamp or YYYY-MM-DD date")
+	}
+	return parsed, true, nil
+}
@@ -0,0 +1,109 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageledger"
+)
+
+func setupUsageLedger(t *testing.T) {
+	t.Helper()
+	backend, err := usageledger.NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileBackend: %v", err)
+	}
+	previous := usageledger.Default().Configure(backend, 0)
+	t.Cleanup(func() {
+		usageledger.Default().Configure(previous, 0)
+		_ = backend.Close()
+	})
+
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err = backend.AppendUsage(context.Background(), []usageledger.Entry{
+		{Timestamp: base, Provider: "claude", Model: "claude-sonnet-4-5", ClientKey: "k1", InputTokens: 10, TotalTokens: 10},
+		{Timestamp: base.Add(time.Hour), Provider: "claude", Model: "claude-sonnet-4-5", ClientKey: "k2", InputTokens: 5, TotalTokens: 5},
+		{Timestamp: base.AddDate(0, 0, 1), Provider: "gemini", Model: "gemini-2.5-pro", ClientKey: "k1", OutputTokens: 7, TotalTokens: 7},
+	}); err != nil {
+		t.Fatalf("AppendUsage: %v", err)
+	}
+}
+
+func TestGetUsageHistory_GroupsByDayAndProvider(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupUsageLedger(t)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/usage/history?from=2026-03-01&to=2026-03-02&interval=day&group_by=provider", nil)
+
+	h := &Handler{}
+	h.GetUsageHistory(c)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var resp struct {
+		Rows []usageledger.Row `json:"rows"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", resp.Rows)
+	}
+	if resp.Rows[0].Bucket != "2026-03-01" || resp.Rows[0].Provider != "claude" || resp.Rows[0].Requests != 2 || resp.Rows[0].TotalTokens != 15 {
+		t.Fatalf("unexpected first row: %+v", resp.Rows[0])
+	}
+	if resp.Rows[1].Bucket != "2026-03-02" || resp.Rows[1].Provider != "gemini" {
+		t.Fatalf("unexpected second row: %+v", resp.Rows[1])
+	}
+}
+
+func TestExportUsageHistory_CSVWithClientKeyFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupUsageLedger(t)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/usage/history/export?from=2026-03-01&to=2026-03-02&group_by=model&client_key=k1", nil)
+
+	h := &Handler{}
+	h.ExportUsageHistory(c)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("content type = %q", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got %q", rec.Body.String())
+	}
+	if !strings.HasPrefix(lines[1], "claude-sonnet-4-5,1,0,10,") || !strings.HasPrefix(lines[2], "gemini-2.5-pro,1,0,0,7,") {
+		t.Fatalf("unexpected csv rows: %q", lines[1:])
+	}
+}
+
+func TestGetUsageHistory_RejectsInvalidRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupUsageLedger(t)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/usage/history?from=2026-03-05&to=2026-03-01", nil)
+
+	h := &Handler{}
+	h.GetUsageHistory(c)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -52,6 +52,10 @@ func RequestLoggingMiddleware(logger logging.RequestLogger) gin.HandlerFunc {
 			return
 		}
 
+		if loggerEnabled {
+			logging.WithRequestLogMeta(c)
+		}
+
 		// Create response writer wrapper
 		wrapper := NewResponseWriterWrapper(c.Writer, logger, requestInfo)
 		if !loggerEnabled {
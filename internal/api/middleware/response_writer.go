@@ -296,6 +296,9 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 		}
 
 		w.streamWriter.SetFirstChunkTimestamp(w.firstChunkTimestamp)
+		if setter, ok := w.streamWriter.(interface{ SetMeta(logging.RequestLogMeta) }); ok {
+			setter.SetMeta(w.requestLogMeta(c))
+		}
 
 		// Write API Request and Response to the streaming log before closing
 		apiRequest := w.extractAPIRequest(c)
@@ -318,7 +321,20 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 		return nil
 	}
 
-	return w.logRequest(w.extractRequestBody(c), finalStatusCode, w.cloneHeaders(), w.extractResponseBody(c), w.extractWebsocketTimeline(c), w.extractAPIRequest(c), w.extractAPIResponse(c), w.extractAPIWebsocketTimeline(c), w.extractAPIResponseTimestamp(c), slicesAPIResponseError, forceLog)
+	return w.logRequest(c, w.extractRequestBody(c), finalStatusCode, w.cloneHeaders(), w.extractResponseBody(c), w.extractWebsocketTimeline(c), w.extractAPIRequest(c), w.extractAPIResponse(c), w.extractAPIWebsocketTimeline(c), w.extractAPIResponseTimestamp(c), slicesAPIResponseError, forceLog)
+}
+
+// requestLogMeta collects the routing details executors reported for c and adds the
+// client principal and timing known to the wrapper.
+func (w *ResponseWriterWrapper) requestLogMeta(c *gin.Context) logging.RequestLogMeta {
+	meta := logging.RequestLogMetaFrom(c)
+	if c != nil {
+		meta.ClientKey = c.GetString("userApiKey")
+	}
+	meta.Stream = w.isStreaming
+	meta.FirstByteAt = w.firstChunkTimestamp
+	meta.CompletedAt = time.Now()
+	return meta
 }
 
 func (w *ResponseWriterWrapper) cloneHeaders() map[string][]string {
@@ -426,11 +442,35 @@ func extractBodyOverride(c *gin.Context, key string) []byte {
 	return nil
 }
 
-func (w *ResponseWriterWrapper) logRequest(requestBody []byte, statusCode int, headers map[string][]string, body, websocketTimeline, apiRequestBody, apiResponseBody, apiWebsocketTimeline []byte, apiResponseTimestamp time.Time, apiResponseErrors []*interfaces.ErrorMessage, forceLog bool) error {
+func (w *ResponseWriterWrapper) logRequest(c *gin.Context, requestBody []byte, statusCode int, headers map[string][]string, body, websocketTimeline, apiRequestBody, apiResponseBody, apiWebsocketTimeline []byte, apiResponseTimestamp time.Time, apiResponseErrors []*interfaces.ErrorMessage, forceLog bool) error {
 	if w.requestInfo == nil {
 		return nil
 	}
 
+	if loggerWithMeta, ok := w.logger.(interface {
+		LogRequestWithMeta(logging.RequestLogMeta, string, string, map[string][]string, []byte, int, map[string][]string, []byte, []byte, []byte, []byte, []byte, []*interfaces.ErrorMessage, bool, string, time.Time, time.Time) error
+	}); ok {
+		return loggerWithMeta.LogRequestWithMeta(
+			w.requestLogMeta(c),
+			w.requestInfo.URL,
+			w.requestInfo.Method,
+			w.requestInfo.Headers,
+			requestBody,
+			statusCode,
+			headers,
+			body,
+			websocketTimeline,
+			apiRequestBody,
+			apiResponseBody,
+			apiWebsocketTimeline,
+			apiResponseErrors,
+			forceLog,
+			w.requestInfo.RequestID,
+			w.requestInfo.Timestamp,
+			apiResponseTimestamp,
+		)
+	}
+
 	if loggerWithOptions, ok := w.logger.(interface {
 		LogRequestWithOptions(string, string, map[string][]string, []byte, int, map[string][]string, []byte, []byte, []byte, []byte, []byte, []*interfaces.ErrorMessage, bool, string, time.Time, time.Time) error
 	}); ok {
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware opens a server span for every request and stores it in the request
+// context, so downstream spans (authentication, credential selection, translation and the
+// upstream call) nest under it. An incoming traceparent header continues the caller's trace.
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !tracing.Default().Enabled() {
+			c.Next()
+			return
+		}
+		ctx := tracing.ExtractHeaders(c.Request.Context(), c.Request.Header)
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		// Unsampled spans are kept in the context too, so the trace context still reaches
+		// upstreams with the sampled flag cleared.
+		ctx, span := tracing.Start(ctx, c.Request.Method+" "+route, trace.SpanKindServer,
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", c.Request.URL.Path),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if !span.IsRecording() {
+			return
+		}
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if requestID := logging.GetGinRequestID(c); requestID != "" {
+			span.SetAttributes(attribute.String("request.id", requestID))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+	}
+}
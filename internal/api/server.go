@@ -28,12 +28,15 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/api/modules"
 	ampmodule "github.com/router-for-me/CLIProxyAPI/v7/internal/api/modules/amp"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/batch"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/home"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisqueue"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
@@ -43,6 +46,9 @@ import (
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/http2"
 	"gopkg.in/yaml.v3"
 )
@@ -69,6 +75,10 @@ func defaultRequestLoggerFactory(cfg *config.Config, configPath string) logging.
 	logsDir := logging.ResolveLogDirectory(cfg)
 	logger := logging.NewFileRequestLogger(cfg.RequestLog, logsDir, configDir, cfg.ErrorLogsMaxFiles)
 	logger.SetHomeEnabled(cfg != nil && cfg.Home.Enabled)
+	if err := logger.SetRedaction(cfg.RequestLogRedaction); err != nil {
+		log.Errorf("invalid request-log-redaction, using built-in rules: %v", err)
+	}
+	logger.SetFormat(cfg.RequestLogFormat, cfg.RequestLogRetentionDays)
 	return logger
 }
 
@@ -224,6 +234,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Add middleware
 	engine.Use(logging.GinLogrusLogger())
 	engine.Use(logging.GinLogrusRecovery())
+	engine.Use(middleware.TracingMiddleware())
 	for _, mw := range optionState.extraMiddleware {
 		engine.Use(mw)
 	}
@@ -274,6 +285,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	if authManager != nil {
 		authManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second, cfg.MaxRetryCredentials)
 	}
+	batch.Default().SetExecutor(s.handlers)
 	managementasset.SetCurrentConfig(cfg)
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	applySignatureCacheConfig(nil, cfg)
@@ -284,6 +296,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 	logDir := logging.ResolveLogDirectory(cfg)
 	s.mgmt.SetLogDirectory(logDir)
+	s.mgmt.SetReplayHandler(engine)
 	if optionState.postAuthHook != nil {
 		s.mgmt.SetPostAuthHook(optionState.postAuthHook)
 	}
@@ -315,9 +328,10 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 
 	// Register management routes when configuration or environment secrets are available,
 	// or when a local management password is provided (e.g. TUI mode).
-	hasManagementSecret := cfg.RemoteManagement.SecretKey != "" || envManagementSecret || s.localPassword != ""
+	hasManagementSecret := cfg.RemoteManagement.HasCredentials() || envManagementSecret || s.localPassword != ""
 	s.managementRoutesEnabled.Store(hasManagementSecret)
 	redisqueue.SetEnabled(hasManagementSecret || (cfg != nil && cfg.Home.Enabled))
+	metrics.SetEnabled(cfg.Metrics.Enable)
 	if hasManagementSecret {
 		s.registerManagementRoutes()
 	}
@@ -370,6 +384,7 @@ func (s *Server) setupRoutes() {
 	}
 	s.engine.GET("/healthz", healthzHandler)
 	s.engine.HEAD("/healthz", healthzHandler)
+	s.engine.GET("/metrics", AuthMiddleware(s.accessManager), gin.WrapH(metrics.Handler()))
 
 	s.engine.GET("/management.html", s.serveManagementControlPanel)
 	openaiHandlers := openai.NewOpenAIAPIHandler(s.handlers)
@@ -385,6 +400,10 @@ func (s *Server) setupRoutes() {
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
+		v1.POST("/embeddings", openaiHandlers.Embeddings)
+		v1.POST("/audio/transcriptions", openaiHandlers.AudioTranscriptions)
+		v1.POST("/audio/translations", openaiHandlers.AudioTranslations)
+		v1.POST("/audio/speech", openaiHandlers.AudioSpeech)
 		v1.POST("/images/generations", openaiHandlers.ImagesGenerations)
 		v1.POST("/images/edits", openaiHandlers.ImagesEdits)
 		v1.POST("/videos", openaiHandlers.VideosCreate)
@@ -394,9 +413,27 @@ func (s *Server) setupRoutes() {
 		v1.GET("/videos/:request_id", openaiHandlers.XAIVideosRetrieve)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
+		v1.POST("/messages/batches", claudeCodeHandlers.MessageBatchesCreate)
+		v1.GET("/messages/batches", claudeCodeHandlers.MessageBatchesList)
+		v1.GET("/messages/batches/:id", claudeCodeHandlers.MessageBatchesRetrieve)
+		v1.DELETE("/messages/batches/:id", claudeCodeHandlers.MessageBatchesDelete)
+		v1.POST("/messages/batches/:id/cancel", claudeCodeHandlers.MessageBatchesCancel)
+		v1.GET("/messages/batches/:id/results", claudeCodeHandlers.MessageBatchesResults)
+		v1.POST("/files", openaiHandlers.FilesUpload)
+		v1.GET("/files", openaiHandlers.FilesList)
+		v1.GET("/files/:id", openaiHandlers.FilesRetrieve)
+		v1.DELETE("/files/:id", openaiHandlers.FilesDelete)
+		v1.GET("/files/:id/content", openaiHandlers.FilesContent)
+		v1.POST("/batches", openaiHandlers.BatchesCreate)
+		v1.GET("/batches", openaiHandlers.BatchesList)
+		v1.GET("/batches/:id", openaiHandlers.BatchesRetrieve)
+		v1.POST("/batches/:id/cancel", openaiHandlers.BatchesCancel)
 		v1.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
+		v1.GET("/responses/:id", openaiResponsesHandlers.GetResponse)
+		v1.DELETE("/responses/:id", openaiResponsesHandlers.DeleteResponse)
+		v1.GET("/responses/:id/input_items", openaiResponsesHandlers.ListResponseInputItems)
 	}
 
 	// Codex CLI direct route aliases (chatgpt_base_url compatible)
@@ -556,9 +593,14 @@ func (s *Server) registerManagementRoutes() {
 	mgmt := s.engine.Group("/v0/management")
 	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
 	{
+		mgmt.GET("/session", s.mgmt.GetSession)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
+		mgmt.GET("/config/versions", s.mgmt.ListConfigVersions)
+		mgmt.GET("/config/versions/diff", s.mgmt.DiffConfigVersions)
+		mgmt.GET("/config/versions/:version", s.mgmt.GetConfigVersion)
+		mgmt.POST("/config/versions/rollback/:version", s.mgmt.RollbackConfigVersion)
 		mgmt.GET("/latest-version", s.mgmt.GetLatestVersion)
 
 		mgmt.GET("/debug", s.mgmt.GetDebug)
@@ -600,8 +642,27 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/api-keys", s.mgmt.PutAPIKeys)
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
 		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
+		mgmt.GET("/api-keys/:key/policy", s.mgmt.GetAPIKeyPolicy)
+		mgmt.PUT("/api-keys/:key/policy", s.mgmt.PutAPIKeyPolicy)
+		mgmt.PATCH("/api-keys/:key/policy", s.mgmt.PatchAPIKeyPolicy)
+		mgmt.DELETE("/api-keys/:key/policy", s.mgmt.DeleteAPIKeyPolicy)
 		mgmt.GET("/api-key-usage", s.mgmt.GetAPIKeyUsage)
+		mgmt.GET("/access-providers", s.mgmt.GetAccessProviders)
+		mgmt.PUT("/access-providers", s.mgmt.PutAccessProviders)
+		mgmt.PATCH("/access-providers", s.mgmt.PatchAccessProvider)
+		mgmt.DELETE("/access-providers", s.mgmt.DeleteAccessProvider)
 		mgmt.GET("/usage-queue", s.mgmt.GetUsageQueue)
+		mgmt.GET("/usage/history", s.mgmt.GetUsageHistory)
+		mgmt.GET("/usage/history/export", s.mgmt.ExportUsageHistory)
+		mgmt.GET("/audit", s.mgmt.GetAuditLog)
+		mgmt.GET("/response-cache", s.mgmt.GetResponseCache)
+		mgmt.DELETE("/response-cache", s.mgmt.DeleteResponseCache)
+		mgmt.GET("/session-bindings", s.mgmt.GetSessionBindings)
+		mgmt.DELETE("/session-bindings", s.mgmt.DeleteSessionBindings)
+		mgmt.GET("/signature-cache", s.mgmt.GetSignatureCache)
+		mgmt.DELETE("/signature-cache", s.mgmt.DeleteSignatureCache)
+		mgmt.GET("/health/credentials", s.mgmt.GetCredentialHealth)
+		mgmt.POST("/health/credentials/probe", s.mgmt.ProbeCredential)
 
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
 		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
@@ -613,9 +674,12 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/request-error-logs", s.mgmt.GetRequestErrorLogs)
 		mgmt.GET("/request-error-logs/:name", s.mgmt.DownloadRequestErrorLog)
 		mgmt.GET("/request-log-by-id/:id", s.mgmt.GetRequestLogByID)
+		mgmt.GET("/request-logs/search", s.mgmt.SearchRequestLogs)
+		mgmt.POST("/request-log-by-id/:id/replay", s.mgmt.ReplayRequestLog)
 		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
 		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
 		mgmt.PATCH("/request-log", s.mgmt.PutRequestLog)
+		mgmt.POST("/request-log-redaction/test", s.mgmt.TestRequestLogRedaction)
 		mgmt.GET("/ws-auth", s.mgmt.GetWebsocketAuth)
 		mgmt.PUT("/ws-auth", s.mgmt.PutWebsocketAuth)
 		mgmt.PATCH("/ws-auth", s.mgmt.PutWebsocketAuth)
@@ -698,6 +762,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
 		mgmt.PATCH("/auth-files/fields", s.mgmt.PatchAuthFileFields)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
+		mgmt.POST("/store/migrate", s.mgmt.MigrateStore)
 
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
 		mgmt.GET("/codex-auth-url", s.mgmt.RequestCodexToken)
@@ -1381,6 +1446,10 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		redisqueue.SetUsageStatisticsEnabled(cfg.UsageStatisticsEnabled)
 	}
 
+	if oldCfg == nil || oldCfg.Metrics.Enable != cfg.Metrics.Enable {
+		metrics.SetEnabled(cfg.Metrics.Enable)
+	}
+
 	if oldCfg == nil || oldCfg.RedisUsageQueueRetentionSeconds != cfg.RedisUsageQueueRetentionSeconds {
 		redisqueue.SetRetentionSeconds(cfg.RedisUsageQueueRetentionSeconds)
 	}
@@ -1391,6 +1460,22 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
+	if s.requestLogger != nil && (oldCfg == nil || oldCfg.RequestLogFormat != cfg.RequestLogFormat || oldCfg.RequestLogRetentionDays != cfg.RequestLogRetentionDays) {
+		if setter, ok := s.requestLogger.(interface{ SetFormat(string, int) }); ok {
+			setter.SetFormat(cfg.RequestLogFormat, cfg.RequestLogRetentionDays)
+		}
+	}
+
+	if s.requestLogger != nil && (oldCfg == nil || !reflect.DeepEqual(oldCfg.RequestLogRedaction, cfg.RequestLogRedaction)) {
+		if setter, ok := s.requestLogger.(interface {
+			SetRedaction(config.RequestLogRedactionConfig) error
+		}); ok {
+			if err := setter.SetRedaction(cfg.RequestLogRedaction); err != nil {
+				log.Errorf("failed to apply request-log-redaction, keeping previous rules: %v", err)
+			}
+		}
+	}
+
 	if oldCfg == nil || oldCfg.DisableCooling != cfg.DisableCooling {
 		auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	}
@@ -1412,9 +1497,9 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 
 	prevSecretEmpty := true
 	if oldCfg != nil {
-		prevSecretEmpty = oldCfg.RemoteManagement.SecretKey == ""
+		prevSecretEmpty = !oldCfg.RemoteManagement.HasCredentials()
 	}
-	newSecretEmpty := cfg.RemoteManagement.SecretKey == ""
+	newSecretEmpty := !cfg.RemoteManagement.HasCredentials()
 	if s.envManagementSecret {
 		s.registerManagementRoutes()
 		if s.managementRoutesEnabled.CompareAndSwap(false, true) {
@@ -1521,12 +1606,29 @@ func (s *Server) SetWebsocketAuthChangeHandler(fn func(bool, bool)) {
 // it allows all requests (legacy behaviour).
 func AuthMiddleware(manager *sdkaccess.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Replays are dispatched in-process by the management API, which has already
+		// authenticated the caller; the capture only exists on such requests.
+		if capture := logging.ReplayCaptureFrom(c.Request.Context()); capture != nil {
+			c.Set("userApiKey", "replay")
+			c.Set("accessProvider", "replay")
+			c.Next()
+			capture.Collect(c)
+			return
+		}
 		if manager == nil {
 			c.Next()
 			return
 		}
 
-		result, err := manager.Authenticate(c.Request.Context(), c.Request)
+		authCtx, span := tracing.Start(c.Request.Context(), "access.authenticate", trace.SpanKindInternal)
+		result, err := manager.Authenticate(authCtx, c.Request)
+		if result != nil {
+			span.SetAttributes(attribute.String("access.provider", result.Provider))
+		}
+		if err != nil {
+			span.SetStatus(codes.Error, err.Message)
+		}
+		span.End()
 		if err == nil {
 			if result != nil {
 				c.Set("userApiKey", result.Principal)
@@ -0,0 +1,203 @@
+// Package audit records mutating management API requests in an append-only log so operators
+// can tell who changed which config section or auth file, and with what result.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const writeTimeout = 5 * time.Second
+
+// Result values recorded on entries.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Entry is one audited management request.
+type Entry struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	RemoteIP  string    `json:"remote_ip"`
+	// Credential names the management credential that authenticated the request,
+	// e.g. "secret-key", "env-secret", "local-password" or "token:<name>".
+	Credential string   `json:"credential"`
+	Method     string   `json:"method"`
+	Route      string   `json:"route"`
+	Path       string   `json:"path"`
+	Status     int      `json:"status"`
+	Result     string   `json:"result"`
+	Changes    []Change `json:"changes,omitempty"`
+}
+
+// Change is the redacted before/after state of one config section or auth file.
+type Change struct {
+	// Target is "config" or "auth".
+	Target string `json:"target"`
+	// Name is the config section or the auth file ID.
+	Name   string `json:"name"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// Filter narrows an audit query. Zero values match everything.
+type Filter struct {
+	// From is the inclusive lower bound of the query range.
+	From time.Time
+	// To is the exclusive upper bound of the query range.
+	To         time.Time
+	Method     string
+	Route      string
+	Credential string
+	Result     string
+	RemoteIP   string
+	// Target matches entries that changed the named config section or auth file.
+	Target string
+}
+
+// Match reports whether entry satisfies the filter.
+func (f Filter) Match(entry Entry) bool {
+	if !f.From.IsZero() && entry.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !entry.Timestamp.Before(f.To) {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(f.Method, entry.Method) {
+		return false
+	}
+	if f.Route != "" && !strings.Contains(entry.Route, f.Route) {
+		return false
+	}
+	if f.Credential != "" && f.Credential != entry.Credential {
+		return false
+	}
+	if f.Result != "" && !strings.EqualFold(f.Result, entry.Result) {
+		return false
+	}
+	if f.RemoteIP != "" && f.RemoteIP != entry.RemoteIP {
+		return false
+	}
+	if f.Target != "" {
+		for _, change := range entry.Changes {
+			if change.Name == f.Target {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Backend stores and retrieves audit entries. Entries are never updated or deleted.
+type Backend interface {
+	AppendAudit(ctx context.Context, entry Entry) error
+	// QueryAudit returns matching entries, newest first.
+	QueryAudit(ctx context.Context, filter Filter) ([]Entry, error)
+}
+
+// Log writes entries to the JSONL file and, when configured, mirrors them to a shared store.
+type Log struct {
+	mu     sync.RWMutex
+	file   Backend
+	mirror Backend
+}
+
+var defaultLog = &Log{}
+
+// Default returns the process-wide audit log.
+func Default() *Log { return defaultLog }
+
+// Configure swaps the backends. A nil file backend disables auditing. Queries use the mirror
+// when set because it holds the entries of every instance sharing it. The previous file
+// backend is returned so callers can release it.
+func (l *Log) Configure(file, mirror Backend) Backend {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	previous := l.file
+	l.file = file
+	l.mirror = mirror
+	if file == nil {
+		l.mirror = nil
+	}
+	l.mu.Unlock()
+	return previous
+}
+
+// File returns the active file backend or nil when auditing is disabled.
+func (l *Log) File() Backend {
+	if l == nil {
+		return nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.file
+}
+
+// Enabled reports whether auditing is on.
+func (l *Log) Enabled() bool { return l.File() != nil }
+
+// Record appends entry to every backend, filling in the ID and timestamp when missing.
+// Failures are logged; auditing never fails the audited request.
+func (l *Log) Record(entry Entry) {
+	if l == nil {
+		return
+	}
+	l.mu.RLock()
+	file, mirror := l.file, l.mirror
+	l.mu.RUnlock()
+	if file == nil {
+		return
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	entry.Timestamp = entry.Timestamp.UTC()
+	if entry.ID == "" {
+		entry.ID = newEntryID(entry.Timestamp)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if err := file.AppendAudit(ctx, entry); err != nil {
+		log.Errorf("audit log: failed to append entry: %v", err)
+	}
+	if mirror != nil {
+		if err := mirror.AppendAudit(ctx, entry); err != nil {
+			log.Errorf("audit log: failed to mirror entry: %v", err)
+		}
+	}
+}
+
+// Query returns entries matching filter, newest first.
+func (l *Log) Query(ctx context.Context, filter Filter) ([]Entry, error) {
+	if l == nil {
+		return nil, nil
+	}
+	l.mu.RLock()
+	backend := l.mirror
+	if backend == nil {
+		backend = l.file
+	}
+	l.mu.RUnlock()
+	if backend == nil {
+		return nil, nil
+	}
+	return backend.QueryAudit(ctx, filter)
+}
+
+// newEntryID returns a time-ordered identifier.
+func newEntryID(ts time.Time) string {
+	var suffix [4]byte
+	_, _ = rand.Read(suffix[:])
+	return "audit_" + ts.Format("20060102T150405.000000000") + "_" + hex.EncodeToString(suffix[:])
+}
@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+const (
+	fileName     = "audit.jsonl"
+	maxLineBytes = 4 << 20
+)
+
+// FileBackend appends entries as JSON lines to a single file.
+type FileBackend struct {
+	dir  string
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileBackend creates dir when needed and returns a backend appending to dir/audit.jsonl.
+func NewFileBackend(dir string) (*FileBackend, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("audit log: directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("audit log: create directory: %w", err)
+	}
+	return &FileBackend{dir: dir, path: filepath.Join(dir, fileName)}, nil
+}
+
+// Dir returns the directory holding the audit file.
+func (b *FileBackend) Dir() string { return b.dir }
+
+// Path returns the audit file path.
+func (b *FileBackend) Path() string { return b.path }
+
+// Close releases the open file.
+func (b *FileBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	b.file = nil
+	return err
+}
+
+// AppendAudit writes entry as one line and syncs it to disk.
+func (b *FileBackend) AppendAudit(_ context.Context, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("audit log: encode entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.file == nil {
+		f, errOpen := os.OpenFile(b.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if errOpen != nil {
+			return fmt.Errorf("audit log: open file: %w", errOpen)
+		}
+		b.file = f
+	}
+	if _, err = b.file.Write(line); err != nil {
+		return fmt.Errorf("audit log: write entry: %w", err)
+	}
+	if err = b.file.Sync(); err != nil {
+		return fmt.Errorf("audit log: sync file: %w", err)
+	}
+	return nil
+}
+
+// QueryAudit scans the file and returns matching entries, newest first.
+func (b *FileBackend) QueryAudit(ctx context.Context, filter Filter) ([]Entry, error) {
+	f, err := os.Open(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("audit log: open file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var out []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	for scanner.Scan() {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		var entry Entry
+		if errUnmarshal := json.Unmarshal(scanner.Bytes(), &entry); errUnmarshal != nil {
+			continue
+		}
+		if filter.Match(entry) {
+			out = append(out, entry)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("audit log: read file: %w", err)
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// ResolveDirectory returns the directory holding the audit file.
+func ResolveDirectory(cfg *config.Config) string {
+	if cfg != nil {
+		if dir := strings.TrimSpace(cfg.AuditLog.Dir); dir != "" {
+			if resolved, err := util.ResolveAuthDir(dir); err == nil && resolved != "" {
+				return resolved
+			}
+			return dir
+		}
+	}
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "audit")
+	}
+	if cfg != nil {
+		if authDir, err := util.ResolveAuthDir(cfg.AuthDir); err == nil && authDir != "" {
+			return filepath.Join(authDir, "audit")
+		}
+	}
+	return "audit"
+}
@@ -0,0 +1,181 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// sensitiveKeyMarkers flag map keys whose string values must never reach the audit log.
+// Proxy URLs are included because they often carry user:pass credentials, with or without a scheme.
+var sensitiveKeyMarkers = []string{
+	"key", "secret", "password", "token", "authorization", "cookie", "dsn", "credential", "private",
+	"proxy-url", "proxy_url", "proxyurl",
+}
+
+// redactedPrefix starts every fingerprint that replaces a secret.
+const redactedPrefix = "[redacted:"
+
+// Redact returns a copy of value with sensitive strings replaced by a short fingerprint, so an
+// entry still shows that a secret changed without revealing it. A string is sensitive when its
+// own key, or the key of the list holding it, looks like a secret. Credentials embedded in
+// URLs are replaced wherever they appear.
+func Redact(value any) any {
+	return redact(value, false)
+}
+
+func redact(value any, sensitive bool) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(typed))
+		for key, item := range typed {
+			out[key] = redact(item, isSensitiveKey(key))
+		}
+		return out
+	case []any:
+		out := make([]any, len(typed))
+		for i, item := range typed {
+			out[i] = redact(item, sensitive)
+		}
+		return out
+	case string:
+		if sensitive && typed != "" {
+			return fingerprint(typed)
+		}
+		return redactURLUserinfo(typed)
+	default:
+		return value
+	}
+}
+
+// Unredact returns a copy of value in which every string equal to the redacted form of the
+// string at the same place in original is replaced by that original string, so a listing
+// produced by Redact can be sent back without overwriting the secrets in it.
+func Unredact(value, original any) any {
+	return unredact(value, original, false)
+}
+
+func unredact(value, original any, sensitive bool) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		originalMap, _ := original.(map[string]any)
+		out := make(map[string]any, len(typed))
+		for key, item := range typed {
+			out[key] = unredact(item, originalMap[key], isSensitiveKey(key))
+		}
+		return out
+	case []any:
+		originalList, _ := original.([]any)
+		out := make([]any, len(typed))
+		for i, item := range typed {
+			var originalItem any
+			if i < len(originalList) {
+				originalItem = originalList[i]
+			}
+			out[i] = unredact(item, originalItem, sensitive)
+		}
+		return out
+	case string:
+		if originalString, ok := original.(string); ok && originalString != typed && redact(originalString, sensitive) == any(typed) {
+			return originalString
+		}
+		return typed
+	default:
+		return value
+	}
+}
+
+// IsRedacted reports whether value contains a fingerprint produced by Redact.
+func IsRedacted(value string) bool {
+	return strings.Contains(value, redactedPrefix)
+}
+
+// redactURLUserinfo replaces the user:password part of a URL with a fingerprint.
+func redactURLUserinfo(value string) string {
+	schemeEnd := strings.Index(value, "://")
+	if schemeEnd < 0 {
+		return value
+	}
+	authorityStart := schemeEnd + len("://")
+	authority := value[authorityStart:]
+	if end := strings.IndexAny(authority, "/?#"); end >= 0 {
+		authority = authority[:end]
+	}
+	at := strings.LastIndex(authority, "@")
+	if at < 0 {
+		return value
+	}
+	return value[:authorityStart] + fingerprint(authority[:at]) + value[authorityStart+at:]
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, marker := range sensitiveKeyMarkers {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return redactedPrefix + hex.EncodeToString(sum[:4]) + "]"
+}
+
+// Normalize converts value into its generic JSON form (maps, slices, strings, numbers).
+func Normalize(value any) any {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var out any
+	if err = json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// DiffSections compares the top-level keys of two JSON objects and returns one redacted change
+// per section that differs.
+func DiffSections(target string, before, after map[string]any) []Change {
+	names := make(map[string]struct{}, len(before)+len(after))
+	for name := range before {
+		names[name] = struct{}{}
+	}
+	for name := range after {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var changes []Change
+	for _, name := range sorted {
+		oldValue, newValue := before[name], after[name]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, Diff(target, name, oldValue, newValue))
+	}
+	return changes
+}
+
+// Diff returns the redacted change for one named item. The item name is treated as the key of
+// the value, so a section such as "api-keys" redacts its entries.
+func Diff(target, name string, before, after any) Change {
+	sensitive := isSensitiveKey(name)
+	change := Change{Target: target, Name: name}
+	if before != nil {
+		change.Before = redact(before, sensitive)
+	}
+	if after != nil {
+		change.After = redact(after, sensitive)
+	}
+	return change
+}
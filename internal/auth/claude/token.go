@@ -59,31 +59,33 @@ func (ts *ClaudeTokenStorage) SetMetadata(meta map[string]any) {
 //   - error: An error if the operation fails, nil otherwise
 func (ts *ClaudeTokenStorage) SaveTokenToFile(authFilePath string) error {
 	misc.LogSavingCredentials(authFilePath)
-	ts.Type = "claude"
+	data, err := ts.MarshalToken()
+	if err != nil {
+		return err
+	}
 
 	// Create directory structure if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
+	if err = os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
 		return fmt.Errorf("failed to create directory: %v", err)
 	}
-
-	// Create the token file
-	f, err := os.Create(authFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to create token file: %w", err)
+	if err = os.WriteFile(authFilePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write token to file: %w", err)
 	}
-	defer func() {
-		_ = f.Close()
-	}()
+	return nil
+}
 
+// MarshalToken returns the JSON content of the Claude token file, merging any injected
+// metadata into the top-level object.
+func (ts *ClaudeTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "claude"
 	// Merge metadata using helper
 	data, errMerge := misc.MergeMetadata(ts, ts.Metadata)
 	if errMerge != nil {
-		return fmt.Errorf("failed to merge metadata: %w", errMerge)
+		return nil, fmt.Errorf("failed to merge metadata: %w", errMerge)
 	}
-
-	// Encode and write the token data as JSON
-	if err = json.NewEncoder(f).Encode(data); err != nil {
-		return fmt.Errorf("failed to write token to file: %w", err)
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token: %w", err)
 	}
-	return nil
+	return raw, nil
 }
@@ -55,28 +55,33 @@ func (ts *CodexTokenStorage) SetMetadata(meta map[string]any) {
 //   - error: An error if the operation fails, nil otherwise
 func (ts *CodexTokenStorage) SaveTokenToFile(authFilePath string) error {
 	misc.LogSavingCredentials(authFilePath)
-	ts.Type = "codex"
-	if err := os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
-		return fmt.Errorf("failed to create directory: %v", err)
+	data, err := ts.MarshalToken()
+	if err != nil {
+		return err
 	}
 
-	f, err := os.Create(authFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to create token file: %w", err)
+	// Create directory structure if it doesn't exist
+	if err = os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	if err = os.WriteFile(authFilePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write token to file: %w", err)
 	}
-	defer func() {
-		_ = f.Close()
-	}()
+	return nil
+}
 
+// MarshalToken returns the JSON content of the Codex token file, merging any injected
+// metadata into the top-level object.
+func (ts *CodexTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "codex"
 	// Merge metadata using helper
 	data, errMerge := misc.MergeMetadata(ts, ts.Metadata)
 	if errMerge != nil {
-		return fmt.Errorf("failed to merge metadata: %w", errMerge)
+		return nil, fmt.Errorf("failed to merge metadata: %w", errMerge)
 	}
-
-	if err = json.NewEncoder(f).Encode(data); err != nil {
-		return fmt.Errorf("failed to write token to file: %w", err)
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token: %w", err)
 	}
-	return nil
-
+	return raw, nil
 }
@@ -81,32 +81,35 @@ type DeviceCodeResponse struct {
 // SaveTokenToFile serializes the Kimi token storage to a JSON file.
 func (ts *KimiTokenStorage) SaveTokenToFile(authFilePath string) error {
 	misc.LogSavingCredentials(authFilePath)
-	ts.Type = "kimi"
+	data, err := ts.MarshalToken()
+	if err != nil {
+		return err
+	}
 
-	if err := os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
+	// Create directory structure if it doesn't exist
+	if err = os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
 		return fmt.Errorf("failed to create directory: %v", err)
 	}
-
-	f, err := os.Create(authFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to create token file: %w", err)
+	if err = os.WriteFile(authFilePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write token to file: %w", err)
 	}
-	defer func() {
-		_ = f.Close()
-	}()
+	return nil
+}
 
+// MarshalToken returns the JSON content of the Kimi token file, merging any injected
+// metadata into the top-level object.
+func (ts *KimiTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "kimi"
 	// Merge metadata using helper
 	data, errMerge := misc.MergeMetadata(ts, ts.Metadata)
 	if errMerge != nil {
-		return fmt.Errorf("failed to merge metadata: %w", errMerge)
+		return nil, fmt.Errorf("failed to merge metadata: %w", errMerge)
 	}
-
-	encoder := json.NewEncoder(f)
-	encoder.SetIndent("", "  ")
-	if err = encoder.Encode(data); err != nil {
-		return fmt.Errorf("failed to write token to file: %w", err)
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token: %w", err)
 	}
-	return nil
+	return raw, nil
 }
 
 // IsExpired checks if the token has expired.
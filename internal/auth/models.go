@@ -15,3 +15,10 @@ type TokenStorage interface {
 	//   - error: An error if the save operation fails, nil otherwise
 	SaveTokenToFile(authFilePath string) error
 }
+
+// TokenMarshaler is implemented by token storages that can serialize themselves without
+// touching the disk, so stores can encrypt the content before writing it.
+type TokenMarshaler interface {
+	// MarshalToken returns the JSON content SaveTokenToFile writes.
+	MarshalToken() ([]byte, error)
+}
@@ -9,7 +9,6 @@ import (
 	"path/filepath"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
-	log "github.com/sirupsen/logrus"
 )
 
 // VertexCredentialStorage stores the service account JSON for Vertex AI access.
@@ -40,31 +39,32 @@ type VertexCredentialStorage struct {
 // It ensures the parent directory exists and logs the operation for transparency.
 func (s *VertexCredentialStorage) SaveTokenToFile(authFilePath string) error {
 	misc.LogSavingCredentials(authFilePath)
+	data, err := s.MarshalToken()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(authFilePath), 0o700); err != nil {
+		return fmt.Errorf("vertex credential: create directory failed: %w", err)
+	}
+	if err = os.WriteFile(authFilePath, data, 0o600); err != nil {
+		return fmt.Errorf("vertex credential: write file failed: %w", err)
+	}
+	return nil
+}
+
+// MarshalToken returns the JSON content of the credential file.
+func (s *VertexCredentialStorage) MarshalToken() ([]byte, error) {
 	if s == nil {
-		return fmt.Errorf("vertex credential: storage is nil")
+		return nil, fmt.Errorf("vertex credential: storage is nil")
 	}
 	if s.ServiceAccount == nil {
-		return fmt.Errorf("vertex credential: service account content is empty")
+		return nil, fmt.Errorf("vertex credential: service account content is empty")
 	}
 	// Ensure we tag the file with the provider type.
 	s.Type = "vertex"
-
-	if err := os.MkdirAll(filepath.Dir(authFilePath), 0o700); err != nil {
-		return fmt.Errorf("vertex credential: create directory failed: %w", err)
-	}
-	f, err := os.Create(authFilePath)
+	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
-		return fmt.Errorf("vertex credential: create file failed: %w", err)
-	}
-	defer func() {
-		if errClose := f.Close(); errClose != nil {
-			log.Errorf("vertex credential: failed to close file: %v", errClose)
-		}
-	}()
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	if err = enc.Encode(s); err != nil {
-		return fmt.Errorf("vertex credential: encode failed: %w", err)
+		return nil, fmt.Errorf("vertex credential: encode failed: %w", err)
 	}
-	return nil
+	return data, nil
 }
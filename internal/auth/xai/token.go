@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
-	log "github.com/sirupsen/logrus"
 )
 
 // TokenStorage stores xAI OAuth credentials on disk.
@@ -40,31 +39,32 @@ func (ts *TokenStorage) SetMetadata(meta map[string]any) {
 // SaveTokenToFile writes xAI credentials to a JSON auth file.
 func (ts *TokenStorage) SaveTokenToFile(authFilePath string) error {
 	misc.LogSavingCredentials(authFilePath)
-	ts.Type = "xai"
-	ts.AuthKind = "oauth"
+	data, err := ts.MarshalToken()
+	if err != nil {
+		return err
+	}
 	if errMkdirAll := os.MkdirAll(filepath.Dir(authFilePath), 0o700); errMkdirAll != nil {
 		return fmt.Errorf("xai token storage: create directory: %w", errMkdirAll)
 	}
-	file, err := os.Create(authFilePath)
-	if err != nil {
-		return fmt.Errorf("xai token storage: create token file: %w", err)
+	if err = os.WriteFile(authFilePath, data, 0o600); err != nil {
+		return fmt.Errorf("xai token storage: write token file: %w", err)
 	}
-	defer func() {
-		if errClose := file.Close(); errClose != nil {
-			log.Errorf("xai token storage: close token file error: %v", errClose)
-		}
-	}()
+	return nil
+}
 
+// MarshalToken returns the JSON content of the xAI auth file.
+func (ts *TokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "xai"
+	ts.AuthKind = "oauth"
 	data, errMerge := misc.MergeMetadata(ts, ts.Metadata)
 	if errMerge != nil {
-		return fmt.Errorf("xai token storage: merge metadata: %w", errMerge)
+		return nil, fmt.Errorf("xai token storage: merge metadata: %w", errMerge)
 	}
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err = encoder.Encode(data); err != nil {
-		return fmt.Errorf("xai token storage: write token file: %w", err)
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("xai token storage: encode token: %w", err)
 	}
-	return nil
+	return raw, nil
 }
 
 // CredentialFileName returns the filename used for xAI credentials.
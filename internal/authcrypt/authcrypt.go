@@ -0,0 +1,228 @@
+// Package authcrypt encrypts auth files at rest. Files are sealed in an envelope: the JSON
+// payload is encrypted with AES-256-GCM under a random per-file data key, and the data key is
+// wrapped with the configured master key. Payloads without an envelope are legacy plaintext
+// files and are returned unchanged, so enabling encryption never breaks existing auth dirs.
+package authcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	envelopeField   = "cliproxy_envelope"
+	envelopeVersion = 1
+	algorithm       = "AES-256-GCM"
+)
+
+// ErrUnknownKey is returned when an envelope was sealed with a key that is not configured.
+var ErrUnknownKey = errors.New("authcrypt: no key configured for envelope")
+
+// envelope is the on-disk form of an encrypted auth file.
+type envelope struct {
+	Version    int    `json:"cliproxy_envelope"`
+	Algorithm  string `json:"alg"`
+	KeyID      string `json:"kid"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// IsSealed reports whether data is an encrypted envelope.
+func IsSealed(data []byte) bool {
+	if !bytes.Contains(data, []byte(envelopeField)) {
+		return false
+	}
+	return gjson.GetBytes(data, envelopeField).Int() > 0
+}
+
+// Seal encrypts plain with the current key. When encryption is disabled plain is returned as is.
+func Seal(plain []byte) ([]byte, error) {
+	ring := currentRing()
+	if !ring.enable {
+		return plain, nil
+	}
+	master := ring.keys[ring.currentID]
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	wrapNonce := make([]byte, master.NonceSize())
+	nonce := make([]byte, dataAEAD.NonceSize())
+	if _, err = rand.Read(wrapNonce); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate nonce: %w", err)
+	}
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate nonce: %w", err)
+	}
+
+	env := envelope{
+		Version:    envelopeVersion,
+		Algorithm:  algorithm,
+		KeyID:      ring.currentID,
+		WrappedKey: master.Seal(wrapNonce, wrapNonce, dataKey, []byte(ring.currentID)),
+		Nonce:      nonce,
+		Ciphertext: dataAEAD.Seal(nil, nonce, plain, []byte(algorithm)),
+	}
+	return json.Marshal(env)
+}
+
+// Open returns the plaintext of data. Data that is not an envelope is returned unchanged.
+func Open(data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("authcrypt: decode envelope: %w", err)
+	}
+	if env.Version != envelopeVersion || env.Algorithm != algorithm {
+		return nil, fmt.Errorf("authcrypt: unsupported envelope version %d (%s)", env.Version, env.Algorithm)
+	}
+	master, ok := currentRing().keys[env.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: key id %s", ErrUnknownKey, env.KeyID)
+	}
+	nonceSize := master.NonceSize()
+	if len(env.WrappedKey) < nonceSize {
+		return nil, fmt.Errorf("authcrypt: wrapped key is truncated")
+	}
+	dataKey, err := master.Open(nil, env.WrappedKey[:nonceSize], env.WrappedKey[nonceSize:], []byte(env.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: unwrap data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != dataAEAD.NonceSize() {
+		return nil, fmt.Errorf("authcrypt: invalid nonce")
+	}
+	plain, err := dataAEAD.Open(nil, env.Nonce, env.Ciphertext, []byte(algorithm))
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: decrypt payload: %w", err)
+	}
+	return plain, nil
+}
+
+// Current reports whether data is stored the way Seal would store it now: sealed with the
+// current key when encryption is enabled, plaintext otherwise.
+func Current(data []byte) bool {
+	ring := currentRing()
+	if !IsSealed(data) {
+		return !ring.enable
+	}
+	return ring.enable && gjson.GetBytes(data, "kid").String() == ring.currentID
+}
+
+// ReadFile reads the auth file at path and returns its plaintext.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(data)
+}
+
+// WriteFile stores the JSON plain at path in the current at-rest format with one atomic
+// write, so a plaintext token never reaches the disk while encryption is enabled. It reports
+// false without writing when the file already holds the same JSON in that format.
+func WriteFile(path string, plain []byte) (bool, error) {
+	if existing, err := os.ReadFile(path); err == nil {
+		if opened, errOpen := Open(existing); errOpen == nil && Current(existing) && jsonEqual(opened, plain) {
+			return false, nil
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("authcrypt: read auth file: %w", err)
+	}
+	sealed, err := Seal(plain)
+	if err != nil {
+		return false, err
+	}
+	if err = writeFileAtomic(path, sealed); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MarshalStorage returns the JSON content of a token storage. Storages that can only write
+// themselves to a file are written to a private temporary directory outside the auth dir, so
+// the plaintext copy is never seen by the auth watcher.
+func MarshalStorage(storage auth.TokenStorage) ([]byte, error) {
+	if marshaler, ok := storage.(auth.TokenMarshaler); ok {
+		return marshaler.MarshalToken()
+	}
+	dir, err := os.MkdirTemp("", "cliproxy-auth-")
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "auth.json")
+	if err = storage.SaveTokenToFile(path); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func jsonEqual(a, b []byte) bool {
+	var valueA, valueB any
+	if json.Unmarshal(a, &valueA) != nil || json.Unmarshal(b, &valueB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(valueA, valueB)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("authcrypt: create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("authcrypt: write temp file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("authcrypt: close temp file: %w", err)
+	}
+	if err = os.Chmod(tmpName, 0o600); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("authcrypt: chmod temp file: %w", err)
+	}
+	if err = os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("authcrypt: rename temp file: %w", err)
+	}
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: %w", err)
+	}
+	return aead, nil
+}
@@ -0,0 +1,135 @@
+package authcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func configureForTest(t *testing.T, cfg config.AuthEncryptionConfig) {
+	t.Helper()
+	if err := Configure(cfg); err != nil {
+		t.Fatalf("Configure() error: %v", err)
+	}
+	t.Cleanup(func() { _ = Configure(config.AuthEncryptionConfig{}) })
+}
+
+func TestSealOpenAndRotation(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, keySize)
+	newKey := bytes.Repeat([]byte{2}, keySize)
+	t.Setenv("AUTH_KEY_OLD", base64.StdEncoding.EncodeToString(oldKey))
+	t.Setenv("AUTH_KEY_NEW", hex.EncodeToString(newKey))
+	plain := []byte(`{"type":"codex","refresh_token":"secret"}`)
+
+	configureForTest(t, config.AuthEncryptionConfig{Enable: true, Key: config.AuthKeySource{Env: "AUTH_KEY_OLD"}})
+	if opened, err := Open(plain); err != nil || !bytes.Equal(opened, plain) {
+		t.Fatalf("Open(plaintext) = %q, %v; want passthrough", opened, err)
+	}
+	if Current(plain) {
+		t.Fatal("plaintext should not be current while encryption is enabled")
+	}
+	sealed, err := Seal(plain)
+	if err != nil {
+		t.Fatalf("Seal() error: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("secret")) || !IsSealed(sealed) || !Current(sealed) {
+		t.Fatalf("unexpected envelope %s", sealed)
+	}
+	if opened, errOpen := Open(sealed); errOpen != nil || !bytes.Equal(opened, plain) {
+		t.Fatalf("Open(sealed) = %q, %v", opened, errOpen)
+	}
+
+	configureForTest(t, config.AuthEncryptionConfig{
+		Enable:       true,
+		Key:          config.AuthKeySource{Env: "AUTH_KEY_NEW"},
+		PreviousKeys: []config.AuthKeySource{{Env: "AUTH_KEY_OLD"}},
+	})
+	if Current(sealed) {
+		t.Fatal("envelope sealed with the previous key should not be current")
+	}
+	if opened, errOpen := Open(sealed); errOpen != nil || !bytes.Equal(opened, plain) {
+		t.Fatalf("Open() with previous key = %q, %v", opened, errOpen)
+	}
+
+	configureForTest(t, config.AuthEncryptionConfig{Enable: true, Key: config.AuthKeySource{Env: "AUTH_KEY_NEW"}})
+	if _, errOpen := Open(sealed); !errors.Is(errOpen, ErrUnknownKey) {
+		t.Fatalf("Open() without the old key error = %v, want ErrUnknownKey", errOpen)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte(base64.RawURLEncoding.EncodeToString(bytes.Repeat([]byte{3}, keySize))+"\n"), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	path := filepath.Join(dir, "auth.json")
+	plain := []byte(`{"type":"claude"}`)
+	if err := os.WriteFile(path, plain, 0o600); err != nil {
+		t.Fatalf("write auth: %v", err)
+	}
+
+	configureForTest(t, config.AuthEncryptionConfig{Enable: true, Key: config.AuthKeySource{File: keyFile}})
+	if written, err := WriteFile(path, plain); err != nil || !written {
+		t.Fatalf("WriteFile() = %v, %v; want the plaintext file sealed", written, err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil || !IsSealed(raw) {
+		t.Fatalf("auth file was not sealed: %s, %v", raw, err)
+	}
+	if opened, errRead := ReadFile(path); errRead != nil || !bytes.Equal(opened, plain) {
+		t.Fatalf("ReadFile() = %q, %v", opened, errRead)
+	}
+	if written, errWrite := WriteFile(path, []byte(`{ "type": "claude" }`)); errWrite != nil || written {
+		t.Fatalf("WriteFile() with the same JSON = %v, %v; want no write", written, errWrite)
+	}
+
+	configureForTest(t, config.AuthEncryptionConfig{Key: config.AuthKeySource{File: keyFile}})
+	if _, err = WriteFile(path, plain); err != nil {
+		t.Fatalf("WriteFile() with encryption disabled error: %v", err)
+	}
+	if raw, err = os.ReadFile(path); err != nil || !bytes.Equal(raw, plain) {
+		t.Fatalf("auth file = %s, %v; want plaintext", raw, err)
+	}
+}
+
+// fileOnlyStorage is a token storage that can only write itself to a file.
+type fileOnlyStorage struct{ paths []string }
+
+func (s *fileOnlyStorage) SaveTokenToFile(path string) error {
+	s.paths = append(s.paths, path)
+	return os.WriteFile(path, []byte(`{"refresh_token":"secret"}`), 0o600)
+}
+
+func TestMarshalStorageKeepsPlaintextOutOfTheAuthDir(t *testing.T) {
+	storage := &fileOnlyStorage{}
+	raw, err := MarshalStorage(storage)
+	if err != nil || string(raw) != `{"refresh_token":"secret"}` {
+		t.Fatalf("MarshalStorage() = %s, %v", raw, err)
+	}
+	if len(storage.paths) != 1 {
+		t.Fatalf("SaveTokenToFile() calls = %v, want one", storage.paths)
+	}
+	if _, errStat := os.Stat(filepath.Dir(storage.paths[0])); !errors.Is(errStat, os.ErrNotExist) {
+		t.Fatalf("temporary directory %s was not removed: %v", filepath.Dir(storage.paths[0]), errStat)
+	}
+}
+
+func TestConfigureRejectsInvalidKeys(t *testing.T) {
+	t.Setenv("AUTH_KEY_SHORT", "c2hvcnQ=")
+	if err := Configure(config.AuthEncryptionConfig{Enable: true}); err == nil {
+		t.Fatal("Configure() without a key should fail when enabled")
+	}
+	if err := Configure(config.AuthEncryptionConfig{Enable: true, Key: config.AuthKeySource{Env: "AUTH_KEY_SHORT"}}); err == nil {
+		t.Fatal("Configure() with a short key should fail")
+	}
+	if Enabled() {
+		t.Fatal("failed Configure() calls should keep the previous keys")
+	}
+}
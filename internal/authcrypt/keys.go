@@ -0,0 +1,168 @@
+package authcrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+const (
+	keySize        = 32
+	commandTimeout = 30 * time.Second
+)
+
+// keyring holds the loaded master keys by id.
+type keyring struct {
+	enable    bool
+	currentID string
+	keys      map[string]cipher.AEAD
+}
+
+var (
+	stateMu    sync.RWMutex
+	state      = &keyring{keys: map[string]cipher.AEAD{}}
+	configured config.AuthEncryptionConfig
+)
+
+func currentRing() *keyring {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return state
+}
+
+// Enabled reports whether new writes are encrypted.
+func Enabled() bool {
+	return currentRing().enable
+}
+
+// Configure loads the keys named by cfg. Keys are loaded even when encryption is disabled so
+// that existing envelopes stay readable. Reconfiguring with unchanged settings keeps the
+// loaded keys without re-running key commands; on error the previous keys stay active.
+func Configure(cfg config.AuthEncryptionConfig) error {
+	stateMu.RLock()
+	unchanged := cfg.Enable == configured.Enable && cfg.Key == configured.Key && slices.Equal(cfg.PreviousKeys, configured.PreviousKeys)
+	stateMu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	ring := &keyring{keys: make(map[string]cipher.AEAD)}
+	if !cfg.Key.IsZero() {
+		id, aead, err := loadKey(cfg.Key)
+		if err != nil {
+			return fmt.Errorf("auth-encryption.key: %w", err)
+		}
+		ring.currentID = id
+		ring.keys[id] = aead
+	} else if cfg.Enable {
+		return fmt.Errorf("auth-encryption.enable requires auth-encryption.key")
+	}
+	for i, source := range cfg.PreviousKeys {
+		id, aead, err := loadKey(source)
+		if err != nil {
+			return fmt.Errorf("auth-encryption.previous-keys[%d]: %w", i, err)
+		}
+		ring.keys[id] = aead
+	}
+	ring.enable = cfg.Enable
+
+	stateMu.Lock()
+	state = ring
+	configured = cfg
+	stateMu.Unlock()
+	return nil
+}
+
+// KeyID returns the identifier recorded in envelopes sealed with the current key.
+func KeyID() string {
+	return currentRing().currentID
+}
+
+func loadKey(source config.AuthKeySource) (string, cipher.AEAD, error) {
+	raw, err := readKeySource(source)
+	if err != nil {
+		return "", nil, err
+	}
+	key, err := decodeKey(raw)
+	if err != nil {
+		return "", nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8]), aead, nil
+}
+
+func readKeySource(source config.AuthKeySource) (string, error) {
+	switch {
+	case source.Env != "":
+		value := os.Getenv(source.Env)
+		if strings.TrimSpace(value) == "" {
+			return "", fmt.Errorf("environment variable %s is empty", source.Env)
+		}
+		return value, nil
+	case source.File != "":
+		data, err := os.ReadFile(source.File)
+		if err != nil {
+			return "", fmt.Errorf("read key file: %w", err)
+		}
+		return string(data), nil
+	case source.Command != "":
+		return runKeyCommand(source.Command)
+	default:
+		return "", fmt.Errorf("no key source configured")
+	}
+}
+
+// runKeyCommand runs command through the platform shell and returns its standard output.
+func runKeyCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("key command failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("key command failed: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+// decodeKey accepts a 32-byte key encoded as hex or standard/URL base64, padded or not.
+func decodeKey(raw string) ([]byte, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) == hex.EncodedLen(keySize) {
+		if key, err := hex.DecodeString(raw); err == nil {
+			return key, nil
+		}
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := encoding.DecodeString(raw); err == nil && len(key) == keySize {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key must be %d bytes encoded as base64 or hex", keySize)
+}
@@ -0,0 +1,256 @@
+package batch
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authcrypt"
+)
+
+// Kinds of batch jobs. Both run on the same runner and differ only in input and result shape.
+const (
+	KindOpenAI    = "openai"
+	KindAnthropic = "anthropic"
+)
+
+// Job statuses follow the OpenAI batch lifecycle; Anthropic views map them onto
+// processing_status.
+const (
+	StatusValidating = "validating"
+	StatusInProgress = "in_progress"
+	StatusFinalizing = "finalizing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusExpired    = "expired"
+	StatusCancelling = "cancelling"
+	StatusCancelled  = "cancelled"
+)
+
+// Line outcomes recorded for every input request.
+const (
+	outcomeSucceeded = "succeeded"
+	outcomeErrored   = "errored"
+	outcomeCanceled  = "canceled"
+	outcomeExpired   = "expired"
+)
+
+// Job is a persisted batch job.
+type Job struct {
+	ID               string            `json:"id"`
+	Kind             string            `json:"kind"`
+	Owner            string            `json:"owner,omitempty"`
+	Endpoint         string            `json:"endpoint"`
+	InputFileID      string            `json:"input_file_id"`
+	CompletionWindow string            `json:"completion_window"`
+	Status           string            `json:"status"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	OutputFileID     string            `json:"output_file_id,omitempty"`
+	ErrorFileID      string            `json:"error_file_id,omitempty"`
+	FailureMessage   string            `json:"failure_message,omitempty"`
+	// SealedPrincipal is the principal sealed with the auth encryption key, so jobs resumed
+	// after a restart still run as their client. It is only kept when encryption is enabled.
+	SealedPrincipal []byte `json:"sealed_principal,omitempty"`
+
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Errored   int `json:"errored"`
+	Canceled  int `json:"canceled"`
+	Expired   int `json:"expired"`
+
+	CreatedAt    int64 `json:"created_at"`
+	InProgressAt int64 `json:"in_progress_at,omitempty"`
+	ExpiresAt    int64 `json:"expires_at"`
+	FinalizingAt int64 `json:"finalizing_at,omitempty"`
+	CompletedAt  int64 `json:"completed_at,omitempty"`
+	FailedAt     int64 `json:"failed_at,omitempty"`
+	ExpiredAt    int64 `json:"expired_at,omitempty"`
+	CancellingAt int64 `json:"cancelling_at,omitempty"`
+	CancelledAt  int64 `json:"cancelled_at,omitempty"`
+
+	// principal is the client the job runs as while this process knows it.
+	principal *Principal
+}
+
+// Principal is the authenticated client a job was created by. Every request line runs as
+// this client, so key policies, rate limits and usage attribution apply as for live traffic.
+type Principal struct {
+	APIKey   string            `json:"api_key"`
+	Provider string            `json:"provider,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// PrincipalFromContext returns the client authenticated by the access middleware for c.
+func PrincipalFromContext(c *gin.Context) *Principal {
+	if c == nil {
+		return nil
+	}
+	principal := &Principal{APIKey: c.GetString("userApiKey"), Provider: c.GetString("accessProvider")}
+	if metadata, ok := c.Get("accessMetadata"); ok {
+		principal.Metadata, _ = metadata.(map[string]string)
+	}
+	return principal
+}
+
+// sealPrincipal stores p on the job, sealing a copy when auth encryption is enabled.
+func (j *Job) sealPrincipal(p *Principal) error {
+	j.principal = p
+	if p == nil || !authcrypt.Enabled() {
+		return nil
+	}
+	plain, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	j.SealedPrincipal, err = authcrypt.Seal(plain)
+	return err
+}
+
+// openPrincipal returns the principal of the job, unsealing the persisted copy when the
+// job was loaded from disk. It returns nil when the principal is not known.
+func (j *Job) openPrincipal() (*Principal, error) {
+	if j.principal != nil || len(j.SealedPrincipal) == 0 {
+		return j.principal, nil
+	}
+	plain, err := authcrypt.Open(j.SealedPrincipal)
+	if err != nil {
+		return nil, err
+	}
+	var p Principal
+	if err = json.Unmarshal(plain, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Active reports whether the job still has work or finalization pending.
+func (j *Job) Active() bool {
+	switch j.Status {
+	case StatusValidating, StatusInProgress, StatusFinalizing, StatusCancelling:
+		return true
+	default:
+		return false
+	}
+}
+
+// processed returns the number of requests with a recorded outcome.
+func (j *Job) processed() int {
+	return j.Succeeded + j.Errored + j.Canceled + j.Expired
+}
+
+// OpenAIView renders the job as an OpenAI batch object.
+func (j *Job) OpenAIView() map[string]any {
+	var errors any
+	if j.FailureMessage != "" {
+		errors = map[string]any{
+			"object": "list",
+			"data":   []map[string]any{{"code": "invalid_request", "message": j.FailureMessage}},
+		}
+	}
+	metadata := any(j.Metadata)
+	if len(j.Metadata) == 0 {
+		metadata = nil
+	}
+	return map[string]any{
+		"id":                j.ID,
+		"object":            "batch",
+		"endpoint":          j.Endpoint,
+		"errors":            errors,
+		"input_file_id":     j.InputFileID,
+		"completion_window": j.CompletionWindow,
+		"status":            j.Status,
+		"output_file_id":    nullableString(j.OutputFileID),
+		"error_file_id":     nullableString(j.ErrorFileID),
+		"created_at":        j.CreatedAt,
+		"in_progress_at":    nullableTime(j.InProgressAt),
+		"expires_at":        j.ExpiresAt,
+		"finalizing_at":     nullableTime(j.FinalizingAt),
+		"completed_at":      nullableTime(j.CompletedAt),
+		"failed_at":         nullableTime(j.FailedAt),
+		"expired_at":        nullableTime(j.ExpiredAt),
+		"cancelling_at":     nullableTime(j.CancellingAt),
+		"cancelled_at":      nullableTime(j.CancelledAt),
+		"request_counts": map[string]int{
+			"total":     j.Total,
+			"completed": j.Succeeded,
+			"failed":    j.Errored,
+		},
+		"metadata": metadata,
+	}
+}
+
+// AnthropicView renders the job as an Anthropic message batch object. resultsURL is
+// reported once the batch has ended.
+func (j *Job) AnthropicView(resultsURL string) map[string]any {
+	status := "in_progress"
+	var endedAt any
+	switch j.Status {
+	case StatusCancelling:
+		status = "canceling"
+	case StatusCompleted, StatusFailed, StatusExpired, StatusCancelled:
+		status = "ended"
+		endedAt = formatTime(max(j.CompletedAt, j.FailedAt, j.ExpiredAt, j.CancelledAt))
+	}
+	var results any
+	if status == "ended" && j.OutputFileID != "" {
+		results = resultsURL
+	}
+	var cancelInitiated any
+	if j.CancellingAt > 0 {
+		cancelInitiated = formatTime(j.CancellingAt)
+	}
+	return map[string]any{
+		"id":                  j.ID,
+		"type":                "message_batch",
+		"processing_status":   status,
+		"request_counts":      j.anthropicCounts(),
+		"ended_at":            endedAt,
+		"created_at":          formatTime(j.CreatedAt),
+		"expires_at":          formatTime(j.ExpiresAt),
+		"archived_at":         nil,
+		"cancel_initiated_at": cancelInitiated,
+		"results_url":         results,
+	}
+}
+
+func (j *Job) anthropicCounts() map[string]int {
+	processing := j.Total - j.processed()
+	if processing < 0 || !j.Active() {
+		processing = 0
+	}
+	return map[string]int{
+		"processing": processing,
+		"succeeded":  j.Succeeded,
+		"errored":    j.Errored,
+		"canceled":   j.Canceled,
+		"expired":    j.Expired,
+	}
+}
+
+// lineResult is the recorded outcome of one input request.
+type lineResult struct {
+	Index        int             `json:"index"`
+	CustomID     string          `json:"custom_id"`
+	Outcome      string          `json:"outcome"`
+	StatusCode   int             `json:"status_code,omitempty"`
+	Body         json.RawMessage `json:"body,omitempty"`
+	ErrorMessage string          `json:"error_message,omitempty"`
+}
+
+func nullableString(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func nullableTime(value int64) any {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+func formatTime(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,481 @@
+// Package batch emulates the OpenAI Batch API and Anthropic message batches. Input files
+// and jobs are persisted locally and every request line is executed through the regular
+// handler path, so batches share the credential pool, retries and cooldowns with live
+// traffic while a bounded number of workers keeps their pace under control.
+package batch
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultConcurrency is used when the configured worker count is not positive.
+	DefaultConcurrency = 4
+	// DefaultCompletionWindow is the only completion window accepted by OpenAI.
+	DefaultCompletionWindow = "24h"
+)
+
+var (
+	// ErrDisabled is returned while the batch runner is not configured.
+	ErrDisabled = errors.New("batch API is not enabled")
+	// ErrNotFound is returned for unknown files and jobs.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a job is not in a state that allows the operation.
+	ErrConflict = errors.New("batch is not in a state that allows this operation")
+)
+
+// Executor runs a single request through the handler execution path.
+type Executor interface {
+	ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage)
+}
+
+// JobOptions describes a batch to create.
+type JobOptions struct {
+	Kind  string
+	Owner string
+	// Principal is the client the request lines run as; nil runs them as the Owner key.
+	Principal *Principal
+	Endpoint  string
+	// InputFileID names an uploaded file. When Input is set instead, it is stored as an
+	// internal file first; Anthropic batches carry their requests inline.
+	InputFileID      string
+	Input            io.Reader
+	CompletionWindow string
+	Metadata         map[string]string
+	// CheckModel rejects models the client may not use. It runs once per distinct model.
+	CheckModel func(model string) error
+}
+
+// Manager owns the batch store and runs jobs.
+type Manager struct {
+	mu           sync.Mutex
+	store        *store
+	executor     Executor
+	slots        chan struct{}
+	maxFileBytes int64
+	jobs         map[string]*Job
+	running      map[string]context.CancelFunc
+	stopCtx      context.Context
+	stop         context.CancelFunc
+	now          func() time.Time
+}
+
+var defaultManager = &Manager{now: time.Now}
+
+// Default returns the process-wide batch manager.
+func Default() *Manager { return defaultManager }
+
+// Configure opens the store in dir and resumes unfinished jobs. Reconfiguring the same
+// directory only updates the limits, so running jobs keep going.
+func (m *Manager) Configure(dir string, concurrency int, maxFileBytes int64) error {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store == nil || m.store.dir != dir {
+		st, err := newStore(dir)
+		if err != nil {
+			return err
+		}
+		m.stopLocked()
+		m.store = st
+		m.jobs = make(map[string]*Job)
+		for _, job := range st.loadJobs() {
+			m.jobs[job.ID] = job
+		}
+		m.stopCtx, m.stop = context.WithCancel(context.Background())
+	}
+	if cap(m.slots) != concurrency {
+		m.slots = make(chan struct{}, concurrency)
+	}
+	m.maxFileBytes = maxFileBytes
+	m.resumeLocked()
+	return nil
+}
+
+// Disable stops the runner. Unfinished jobs stay on disk and resume once re-enabled.
+func (m *Manager) Disable() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopLocked()
+	m.store = nil
+	m.jobs = nil
+}
+
+// SetExecutor installs the executor used for request lines and starts pending jobs.
+func (m *Manager) SetExecutor(executor Executor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executor = executor
+	m.resumeLocked()
+}
+
+// Enabled reports whether a store is configured.
+func (m *Manager) Enabled() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.store != nil
+}
+
+func (m *Manager) stopLocked() {
+	if m.stop != nil {
+		m.stop()
+	}
+	m.stop = nil
+	m.stopCtx = nil
+	m.running = nil
+}
+
+func (m *Manager) currentStore() (*store, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store == nil {
+		return nil, ErrDisabled
+	}
+	return m.store, nil
+}
+
+// CreateFile stores an uploaded file for owner.
+func (m *Manager) CreateFile(owner, filename, purpose string, content io.Reader) (File, error) {
+	st, err := m.currentStore()
+	if err != nil {
+		return File{}, err
+	}
+	m.mu.Lock()
+	limit := m.maxFileBytes
+	m.mu.Unlock()
+	meta := File{
+		ID:        newID("file-"),
+		CreatedAt: m.now().Unix(),
+		Filename:  filename,
+		Purpose:   purpose,
+		Owner:     ownerKey(owner),
+	}
+	return st.writeFile(meta, content, limit)
+}
+
+// GetFile returns the file id when owner may see it.
+func (m *Manager) GetFile(id, owner string) (File, error) {
+	st, err := m.currentStore()
+	if err != nil {
+		return File{}, err
+	}
+	meta, ok := st.getFile(id)
+	if !ok || meta.Internal || !ownedBy(meta.Owner, owner) {
+		return File{}, ErrNotFound
+	}
+	return meta, nil
+}
+
+// ListFiles returns the files visible to owner, newest first, optionally filtered by purpose.
+func (m *Manager) ListFiles(owner, purpose string) ([]File, error) {
+	st, err := m.currentStore()
+	if err != nil {
+		return nil, err
+	}
+	var out []File
+	for _, meta := range st.listFiles() {
+		if meta.Internal || !ownedBy(meta.Owner, owner) || (purpose != "" && meta.Purpose != purpose) {
+			continue
+		}
+		out = append(out, meta)
+	}
+	return out, nil
+}
+
+// DeleteFile removes the file id when owner may see it.
+func (m *Manager) DeleteFile(id, owner string) error {
+	if _, err := m.GetFile(id, owner); err != nil {
+		return err
+	}
+	st, err := m.currentStore()
+	if err != nil {
+		return err
+	}
+	if !st.deleteFile(id) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// OpenFile opens the content of file id for owner.
+func (m *Manager) OpenFile(id, owner string) (*os.File, File, error) {
+	meta, err := m.GetFile(id, owner)
+	if err != nil {
+		return nil, File{}, err
+	}
+	st, err := m.currentStore()
+	if err != nil {
+		return nil, File{}, err
+	}
+	f, err := st.openFile(id)
+	if err != nil {
+		return nil, File{}, ErrNotFound
+	}
+	return f, meta, nil
+}
+
+// CreateJob validates the input and queues a new job.
+func (m *Manager) CreateJob(opts JobOptions) (*Job, error) {
+	st, err := m.currentStore()
+	if err != nil {
+		return nil, err
+	}
+	if opts.CompletionWindow == "" {
+		opts.CompletionWindow = DefaultCompletionWindow
+	}
+	window, err := time.ParseDuration(opts.CompletionWindow)
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("%w: invalid completion_window %q", ErrInvalidRequest, opts.CompletionWindow)
+	}
+
+	if opts.Input != nil {
+		input, errWrite := st.writeFile(File{
+			ID:        newID("file-"),
+			CreatedAt: m.now().Unix(),
+			Filename:  "message_batch_requests.jsonl",
+			Purpose:   "batch",
+			Owner:     ownerKey(opts.Owner),
+			Internal:  true,
+		}, opts.Input, 0)
+		if errWrite != nil {
+			return nil, errWrite
+		}
+		opts.InputFileID = input.ID
+	} else {
+		meta, ok := st.getFile(opts.InputFileID)
+		if !ok || meta.Internal || !ownedBy(meta.Owner, opts.Owner) {
+			return nil, fmt.Errorf("%w: input file %q not found", ErrInvalidRequest, opts.InputFileID)
+		}
+		if meta.Purpose != "batch" {
+			return nil, fmt.Errorf("%w: input file %q must have purpose \"batch\"", ErrInvalidRequest, opts.InputFileID)
+		}
+	}
+
+	requests, err := readRequests(st, opts.Kind, opts.Endpoint, opts.InputFileID)
+	if err == nil && opts.CheckModel != nil {
+		checked := make(map[string]struct{})
+		for _, request := range requests {
+			if _, done := checked[request.Model]; done {
+				continue
+			}
+			checked[request.Model] = struct{}{}
+			if errCheck := opts.CheckModel(request.Model); errCheck != nil {
+				err = fmt.Errorf("%w: custom_id %q: %v", ErrInvalidRequest, request.CustomID, errCheck)
+				break
+			}
+		}
+	}
+	if err != nil {
+		if opts.Input != nil {
+			st.deleteFile(opts.InputFileID)
+		}
+		return nil, err
+	}
+
+	now := m.now()
+	prefix := "batch_"
+	if opts.Kind == KindAnthropic {
+		prefix = "msgbatch_"
+	}
+	job := &Job{
+		ID:               newID(prefix),
+		Kind:             opts.Kind,
+		Owner:            ownerKey(opts.Owner),
+		Endpoint:         opts.Endpoint,
+		InputFileID:      opts.InputFileID,
+		CompletionWindow: opts.CompletionWindow,
+		Status:           StatusInProgress,
+		Metadata:         opts.Metadata,
+		Total:            len(requests),
+		CreatedAt:        now.Unix(),
+		InProgressAt:     now.Unix(),
+		ExpiresAt:        now.Add(window).Unix(),
+	}
+	principal := opts.Principal
+	if principal == nil && strings.TrimSpace(opts.Owner) != "" {
+		principal = &Principal{APIKey: opts.Owner}
+	}
+	if err = job.sealPrincipal(principal); err != nil {
+		return nil, fmt.Errorf("batch: seal principal: %w", err)
+	}
+	if err = st.saveJob(job); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store != st {
+		return nil, ErrDisabled
+	}
+	m.jobs[job.ID] = job
+	m.resumeLocked()
+	copied := *job
+	return &copied, nil
+}
+
+// GetJob returns a copy of job id of kind when owner may see it.
+func (m *Manager) GetJob(id, owner, kind string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, err := m.jobLocked(id, owner, kind)
+	if err != nil {
+		return nil, err
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// ListJobs returns copies of the jobs of kind visible to owner, newest first.
+func (m *Manager) ListJobs(owner, kind string) ([]*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store == nil {
+		return nil, ErrDisabled
+	}
+	var out []*Job
+	for _, job := range m.jobs {
+		if job.Kind != kind || !ownedBy(job.Owner, owner) {
+			continue
+		}
+		copied := *job
+		out = append(out, &copied)
+	}
+	sortJobs(out)
+	return out, nil
+}
+
+// CancelJob stops a running job. Requests already finished keep their results.
+func (m *Manager) CancelJob(id, owner, kind string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, err := m.jobLocked(id, owner, kind)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == StatusInProgress || job.Status == StatusValidating {
+		job.Status = StatusCancelling
+		job.CancellingAt = m.now().Unix()
+		if errSave := m.store.saveJob(job); errSave != nil {
+			log.Warnf("batch %s: %v", job.ID, errSave)
+		}
+		if cancel, ok := m.running[job.ID]; ok {
+			cancel()
+		} else {
+			m.resumeLocked()
+		}
+	} else if job.Status != StatusCancelling {
+		return nil, ErrConflict
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// DeleteJob removes an ended job together with its input and result files.
+func (m *Manager) DeleteJob(id, owner, kind string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, err := m.jobLocked(id, owner, kind)
+	if err != nil {
+		return nil, err
+	}
+	if job.Active() {
+		return nil, ErrConflict
+	}
+	for _, fileID := range []string{job.OutputFileID, job.ErrorFileID} {
+		if fileID != "" {
+			m.store.deleteFile(fileID)
+		}
+	}
+	if meta, ok := m.store.getFile(job.InputFileID); ok && meta.Internal {
+		m.store.deleteFile(job.InputFileID)
+	}
+	m.store.deleteJob(job.ID)
+	delete(m.jobs, job.ID)
+	copied := *job
+	return &copied, nil
+}
+
+// OpenResults opens the results of an ended Anthropic batch.
+func (m *Manager) OpenResults(id, owner string) (*os.File, error) {
+	m.mu.Lock()
+	job, err := m.jobLocked(id, owner, KindAnthropic)
+	st := m.store
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if job.Active() || job.OutputFileID == "" {
+		return nil, ErrConflict
+	}
+	f, errOpen := st.openFile(job.OutputFileID)
+	if errOpen != nil {
+		return nil, ErrNotFound
+	}
+	return f, nil
+}
+
+func (m *Manager) jobLocked(id, owner, kind string) (*Job, error) {
+	if m.store == nil {
+		return nil, ErrDisabled
+	}
+	job, ok := m.jobs[id]
+	if !ok || job.Kind != kind || !ownedBy(job.Owner, owner) {
+		return nil, ErrNotFound
+	}
+	return job, nil
+}
+
+// resumeLocked starts every active job that is not running yet.
+func (m *Manager) resumeLocked() {
+	if m.store == nil || m.executor == nil || m.stopCtx == nil {
+		return
+	}
+	if m.running == nil {
+		m.running = make(map[string]context.CancelFunc)
+	}
+	for id, job := range m.jobs {
+		if _, running := m.running[id]; running || !job.Active() {
+			continue
+		}
+		ctx, cancel := context.WithCancel(m.stopCtx)
+		m.running[id] = cancel
+		go m.run(ctx, m.store, m.executor, id)
+	}
+}
+
+func ownerKey(principal string) string {
+	principal = strings.TrimSpace(principal)
+	if principal == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(principal))
+	return hex.EncodeToString(sum[:16])
+}
+
+// ownedBy reports whether the stored owner key matches principal. Objects created without
+// a client key are shared by every client.
+func ownedBy(stored, principal string) bool {
+	return stored == "" || stored == ownerKey(principal)
+}
+
+func newID(prefix string) string {
+	var buf [12]byte
+	_, _ = rand.Read(buf[:])
+	return prefix + hex.EncodeToString(buf[:])
+}
@@ -0,0 +1,237 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/tidwall/gjson"
+)
+
+type fakeExecutor struct {
+	mu      sync.Mutex
+	calls   []string
+	keys    []string
+	block   chan struct{}
+	started chan struct{}
+}
+
+func (e *fakeExecutor) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	e.mu.Lock()
+	e.calls = append(e.calls, handlerType+":"+modelName)
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok {
+		e.keys = append(e.keys, ginCtx.GetString("userApiKey"))
+	}
+	e.mu.Unlock()
+	if e.started != nil {
+		e.started <- struct{}{}
+	}
+	if e.block != nil {
+		select {
+		case <-e.block:
+		case <-ctx.Done():
+			return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusRequestTimeout, Error: ctx.Err()}
+		}
+	}
+	if modelName == "bad-model" {
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New("unknown model")}
+	}
+	return []byte(`{"id":"out","model":"` + modelName + `"}`), nil, nil
+}
+
+func newTestManager(t *testing.T, executor Executor) *Manager {
+	t.Helper()
+	return newTestManagerAt(t, t.TempDir(), executor)
+}
+
+func newTestManagerAt(t *testing.T, dir string, executor Executor) *Manager {
+	t.Helper()
+	m := &Manager{now: time.Now}
+	if err := m.Configure(dir, 2, 1<<20); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	m.SetExecutor(executor)
+	t.Cleanup(m.Disable)
+	return m
+}
+
+func waitForJob(t *testing.T, m *Manager, id, owner, kind string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.GetJob(id, owner, kind)
+		if err != nil {
+			t.Fatalf("GetJob: %v", err)
+		}
+		if !job.Active() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func readFile(t *testing.T, m *Manager, id, owner string) []string {
+	t.Helper()
+	f, _, err := m.OpenFile(id, owner)
+	if err != nil {
+		t.Fatalf("OpenFile(%s): %v", id, err)
+	}
+	defer func() { _ = f.Close() }()
+	data, _ := io.ReadAll(f)
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestOpenAIBatchWritesOutputAndErrorFiles(t *testing.T) {
+	executor := &fakeExecutor{}
+	m := newTestManager(t, executor)
+
+	input := strings.Join([]string{
+		`{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"gpt-5","stream":true,"messages":[]}}`,
+		`{"custom_id":"b","method":"POST","url":"/v1/chat/completions","body":{"model":"bad-model","messages":[]}}`,
+	}, "\n")
+	file, err := m.CreateFile("key-a", "input.jsonl", "batch", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+	if _, err = m.GetFile(file.ID, "key-b"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected another client key to be denied, got %v", err)
+	}
+
+	job, err := m.CreateJob(JobOptions{Kind: KindOpenAI, Owner: "key-a", Endpoint: "/v1/chat/completions", InputFileID: file.ID})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	job = waitForJob(t, m, job.ID, "key-a", KindOpenAI)
+	if job.Status != StatusCompleted || job.Succeeded != 1 || job.Errored != 1 {
+		t.Fatalf("unexpected job state: %+v", job)
+	}
+
+	output := readFile(t, m, job.OutputFileID, "key-a")
+	if len(output) != 1 || gjson.Get(output[0], "custom_id").String() != "a" || gjson.Get(output[0], "response.body.model").String() != "gpt-5" {
+		t.Fatalf("unexpected output file: %v", output)
+	}
+	failures := readFile(t, m, job.ErrorFileID, "key-a")
+	if len(failures) != 1 || gjson.Get(failures[0], "response.status_code").Int() != http.StatusBadRequest {
+		t.Fatalf("unexpected error file: %v", failures)
+	}
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	if len(executor.keys) != 2 || executor.keys[0] != "key-a" || executor.keys[1] != "key-a" {
+		t.Fatalf("lines ran as %v, want the job's client key", executor.keys)
+	}
+}
+
+func TestResumedJobWithoutPrincipalFails(t *testing.T) {
+	dir := t.TempDir()
+	executor := &fakeExecutor{block: make(chan struct{}), started: make(chan struct{}, 10)}
+	first := &Manager{now: time.Now}
+	if err := first.Configure(dir, 1, 1<<20); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	first.SetExecutor(executor)
+	input := `{"custom_id":"r1","params":{"model":"claude-sonnet","max_tokens":10,"messages":[]}}`
+	job, err := first.CreateJob(JobOptions{Kind: KindAnthropic, Owner: "key-a", Endpoint: AnthropicEndpoint, Input: strings.NewReader(input)})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	<-executor.started
+	first.Disable()
+
+	second := newTestManagerAt(t, dir, &fakeExecutor{})
+	job = waitForJob(t, second, job.ID, "key-a", KindAnthropic)
+	if job.Status != StatusFailed || job.FailureMessage != principalMessage {
+		t.Fatalf("resumed job = %+v, want it failed instead of running without its client", job)
+	}
+}
+
+func TestCreateJobRejectsInvalidInput(t *testing.T) {
+	m := newTestManager(t, &fakeExecutor{})
+
+	cases := map[string]string{
+		"url mismatch":  `{"custom_id":"a","method":"POST","url":"/v1/embeddings","body":{"model":"m"}}`,
+		"duplicate id":  `{"custom_id":"a","url":"/v1/chat/completions","body":{"model":"m"}}` + "\n" + `{"custom_id":"a","url":"/v1/chat/completions","body":{"model":"m"}}`,
+		"missing model": `{"custom_id":"a","url":"/v1/chat/completions","body":{}}`,
+		"denied model":  `{"custom_id":"a","url":"/v1/chat/completions","body":{"model":"blocked"}}`,
+	}
+	for name, input := range cases {
+		file, err := m.CreateFile("", "input.jsonl", "batch", strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("%s: CreateFile: %v", name, err)
+		}
+		_, err = m.CreateJob(JobOptions{
+			Kind:        KindOpenAI,
+			Endpoint:    "/v1/chat/completions",
+			InputFileID: file.ID,
+			CheckModel: func(model string) error {
+				if model == "blocked" {
+					return errors.New("model not allowed")
+				}
+				return nil
+			},
+		})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Fatalf("%s: expected ErrInvalidRequest, got %v", name, err)
+		}
+	}
+
+	if _, err := m.CreateFile("", "big.jsonl", "batch", strings.NewReader(strings.Repeat("x", 2<<20))); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("expected ErrFileTooLarge, got %v", err)
+	}
+}
+
+func TestAnthropicBatchCancelMarksPendingRequests(t *testing.T) {
+	executor := &fakeExecutor{block: make(chan struct{}), started: make(chan struct{}, 10)}
+	m := newTestManager(t, executor)
+
+	var input strings.Builder
+	for _, id := range []string{"r1", "r2", "r3", "r4"} {
+		input.WriteString(`{"custom_id":"` + id + `","params":{"model":"claude-sonnet","max_tokens":10,"messages":[]}}` + "\n")
+	}
+	job, err := m.CreateJob(JobOptions{Kind: KindAnthropic, Owner: "key-a", Endpoint: AnthropicEndpoint, Input: strings.NewReader(input.String())})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	<-executor.started
+	<-executor.started
+
+	if _, err = m.CancelJob(job.ID, "key-a", KindAnthropic); err != nil {
+		t.Fatalf("CancelJob: %v", err)
+	}
+	job = waitForJob(t, m, job.ID, "key-a", KindAnthropic)
+	if job.Status != StatusCancelled || job.Canceled != 4 {
+		t.Fatalf("unexpected job state: %+v", job)
+	}
+	if view := job.AnthropicView("url"); view["processing_status"] != "ended" || view["results_url"] != "url" {
+		t.Fatalf("unexpected anthropic view: %v", view)
+	}
+
+	results, err := m.OpenResults(job.ID, "key-a")
+	if err != nil {
+		t.Fatalf("OpenResults: %v", err)
+	}
+	data, _ := io.ReadAll(results)
+	_ = results.Close()
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 || gjson.Get(lines[0], "result.type").String() != "canceled" {
+		t.Fatalf("unexpected results: %s", data)
+	}
+	if files, _ := m.ListFiles("key-a", ""); len(files) != 0 {
+		t.Fatalf("expected internal files to stay hidden, got %d", len(files))
+	}
+
+	if _, err = m.DeleteJob(job.ID, "key-a", KindAnthropic); err != nil {
+		t.Fatalf("DeleteJob: %v", err)
+	}
+	if _, err = m.GetJob(job.ID, "key-a", KindAnthropic); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected deleted job to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// MaxRequestsPerBatch mirrors the upstream OpenAI and Anthropic limits.
+	MaxRequestsPerBatch = 50000
+	// AnthropicEndpoint is the endpoint recorded for Anthropic message batches.
+	AnthropicEndpoint = "/v1/messages"
+)
+
+// ErrInvalidRequest marks errors caused by the client's batch input.
+var ErrInvalidRequest = errors.New("invalid batch request")
+
+// openAIEndpoints maps the batch endpoints to the handler type used to execute their lines.
+var openAIEndpoints = map[string]string{
+	"/v1/chat/completions": constant.OpenAI,
+	"/v1/responses":        constant.OpenaiResponse,
+	"/v1/embeddings":       constant.OpenAIEmbedding,
+}
+
+// SupportedEndpoint reports whether OpenAI batches may target endpoint.
+func SupportedEndpoint(endpoint string) bool {
+	_, ok := openAIEndpoints[endpoint]
+	return ok
+}
+
+// lineRequest is one parsed input line.
+type lineRequest struct {
+	Index       int
+	CustomID    string
+	HandlerType string
+	Model       string
+	Body        []byte
+}
+
+// parseRequests reads a JSONL input file. OpenAI lines carry custom_id, method, url and body;
+// Anthropic lines carry custom_id and params. Streaming is always disabled because results
+// are collected into files.
+func parseRequests(kind, endpoint string, r io.Reader) ([]lineRequest, error) {
+	handlerType := constant.Claude
+	if kind == KindOpenAI {
+		var ok bool
+		if handlerType, ok = openAIEndpoints[endpoint]; !ok {
+			return nil, fmt.Errorf("%w: unsupported endpoint %q", ErrInvalidRequest, endpoint)
+		}
+	}
+
+	reader := bufio.NewReader(r)
+	seen := make(map[string]struct{})
+	var requests []lineRequest
+	for lineNo := 1; ; lineNo++ {
+		line, errRead := reader.ReadBytes('\n')
+		if errRead != nil && !errors.Is(errRead, io.EOF) {
+			return nil, fmt.Errorf("batch: read input: %w", errRead)
+		}
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			request, err := parseLine(kind, endpoint, handlerType, trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidRequest, lineNo, err)
+			}
+			if _, dup := seen[request.CustomID]; dup {
+				return nil, fmt.Errorf("%w: line %d: duplicate custom_id %q", ErrInvalidRequest, lineNo, request.CustomID)
+			}
+			seen[request.CustomID] = struct{}{}
+			request.Index = len(requests)
+			requests = append(requests, request)
+			if len(requests) > MaxRequestsPerBatch {
+				return nil, fmt.Errorf("%w: more than %d requests", ErrInvalidRequest, MaxRequestsPerBatch)
+			}
+		}
+		if errRead != nil {
+			break
+		}
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%w: input file contains no requests", ErrInvalidRequest)
+	}
+	return requests, nil
+}
+
+func parseLine(kind, endpoint, handlerType string, line []byte) (lineRequest, error) {
+	if !gjson.ValidBytes(line) {
+		return lineRequest{}, fmt.Errorf("not valid JSON")
+	}
+	root := gjson.ParseBytes(line)
+	customID := strings.TrimSpace(root.Get("custom_id").String())
+	if customID == "" {
+		return lineRequest{}, fmt.Errorf("custom_id is required")
+	}
+
+	bodyPath := "params"
+	if kind == KindOpenAI {
+		bodyPath = "body"
+		if method := root.Get("method").String(); method != "" && !strings.EqualFold(method, http.MethodPost) {
+			return lineRequest{}, fmt.Errorf("method must be POST")
+		}
+		if url := root.Get("url").String(); url != endpoint {
+			return lineRequest{}, fmt.Errorf("url %q does not match the batch endpoint %q", url, endpoint)
+		}
+	}
+	body := root.Get(bodyPath)
+	if !body.IsObject() {
+		return lineRequest{}, fmt.Errorf("%s must be an object", bodyPath)
+	}
+	model := strings.TrimSpace(body.Get("model").String())
+	if model == "" {
+		return lineRequest{}, fmt.Errorf("%s.model is required", bodyPath)
+	}
+	payload := []byte(body.Raw)
+	if body.Get("stream").Exists() {
+		payload, _ = sjson.DeleteBytes(payload, "stream")
+	}
+	return lineRequest{CustomID: customID, HandlerType: handlerType, Model: model, Body: payload}, nil
+}
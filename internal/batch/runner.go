@@ -0,0 +1,492 @@
+package batch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// lineAttempts bounds how often a request is retried after the credential pool reports
+	// rate limiting or an upstream failure; the auth manager already rotates credentials
+	// within each attempt.
+	lineAttempts     = 3
+	lineRetryBase    = 5 * time.Second
+	lineRetryMax     = time.Minute
+	jobSaveInterval  = time.Second
+	expiredMessage   = "This request could not be executed before the completion window expired."
+	principalMessage = "The client that created this batch is unknown after a restart; enable auth encryption to resume batches."
+	cancelledMessage = "This request was not executed because the batch was cancelled."
+)
+
+// run executes the pending requests of job id and finalizes it. When ctx ends because the
+// manager stopped, the job is left as-is so it resumes later.
+func (m *Manager) run(ctx context.Context, st *store, executor Executor, id string) {
+	defer func() {
+		m.mu.Lock()
+		if m.store == st && m.running != nil {
+			delete(m.running, id)
+		}
+		m.mu.Unlock()
+	}()
+
+	job, ok := m.snapshot(st, id)
+	if !ok {
+		return
+	}
+	principal, err := job.openPrincipal()
+	if err != nil {
+		m.fail(st, id, fmt.Errorf("batch: open principal: %w", err))
+		return
+	}
+	if principal == nil && job.Owner != "" && (job.Status == StatusInProgress || job.Status == StatusValidating) {
+		// Running the lines without their client would bypass its key policy.
+		m.fail(st, id, errors.New(principalMessage))
+		return
+	}
+	job.principal = principal
+	requests, err := readRequests(st, job.Kind, job.Endpoint, job.InputFileID)
+	if err != nil {
+		m.fail(st, id, err)
+		return
+	}
+	done := st.readResults(id)
+	m.update(st, id, true, func(job *Job) { recount(job, done) })
+
+	if job.Status == StatusInProgress || job.Status == StatusValidating {
+		m.execute(ctx, st, executor, job, requests, done)
+	}
+	if ctx.Err() != nil && !m.cancelling(st, id) {
+		return
+	}
+	m.finalize(st, id, requests)
+}
+
+func (m *Manager) execute(ctx context.Context, st *store, executor Executor, job Job, requests []lineRequest, done map[int]lineResult) {
+	results := make(chan lineResult)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		lastSave := time.Now()
+		for result := range results {
+			if err := st.appendResult(job.ID, result); err != nil {
+				log.Warnf("batch %s: record result: %v", job.ID, err)
+				continue
+			}
+			save := time.Since(lastSave) >= jobSaveInterval
+			if save {
+				lastSave = time.Now()
+			}
+			m.update(st, job.ID, save, func(job *Job) { count(job, result.Outcome) })
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, request := range requests {
+		if _, ok := done[request.Index]; ok {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if m.now().Unix() >= job.ExpiresAt {
+			results <- lineResult{Index: request.Index, CustomID: request.CustomID, Outcome: outcomeExpired}
+			continue
+		}
+		slots, ok := m.acquire(ctx)
+		if !ok {
+			break
+		}
+		wg.Add(1)
+		go func(request lineRequest) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if result, okRun := executeLine(lineContext(ctx, job), executor, job.Kind, request); okRun {
+				results <- result
+			}
+		}(request)
+	}
+	wg.Wait()
+	close(results)
+	<-finished
+}
+
+// acquire takes a worker slot from the current pool. The pool is looked up on every call so
+// concurrency changes apply without restarting jobs.
+func (m *Manager) acquire(ctx context.Context) (chan struct{}, bool) {
+	m.mu.Lock()
+	slots := m.slots
+	m.mu.Unlock()
+	select {
+	case slots <- struct{}{}:
+		return slots, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// lineContext returns the context a request line of job runs in. The handler path reads the
+// client from the gin context, so each line gets a detached one carrying the job's principal;
+// its writer only collects headers set along the way and is discarded.
+func lineContext(ctx context.Context, job Job) context.Context {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request, _ = http.NewRequestWithContext(ctx, http.MethodPost, job.Endpoint, nil)
+	if principal := job.principal; principal != nil {
+		ginCtx.Set("userApiKey", principal.APIKey)
+		ginCtx.Set("accessProvider", principal.Provider)
+		if principal.Metadata != nil {
+			ginCtx.Set("accessMetadata", principal.Metadata)
+		}
+	}
+	return context.WithValue(ctx, "gin", ginCtx)
+}
+
+// executeLine runs one request, retrying while the pool is rate limited. It returns false when
+// ctx ended before an outcome was known.
+func executeLine(ctx context.Context, executor Executor, kind string, request lineRequest) (lineResult, bool) {
+	result := lineResult{Index: request.Index, CustomID: request.CustomID}
+	for attempt := 0; ; attempt++ {
+		resp, _, errMsg := executor.ExecuteWithAuthManager(ctx, request.HandlerType, request.Model, request.Body, "")
+		if ctx.Err() != nil {
+			return lineResult{}, false
+		}
+		if errMsg == nil {
+			result.Outcome = outcomeSucceeded
+			result.StatusCode = http.StatusOK
+			result.Body = jsonBody(resp)
+			return result, true
+		}
+		status := errMsg.StatusCode
+		if status <= 0 {
+			status = http.StatusInternalServerError
+		}
+		if (status == http.StatusTooManyRequests || status >= http.StatusInternalServerError) && attempt+1 < lineAttempts {
+			select {
+			case <-time.After(retryDelay(errMsg, attempt)):
+				continue
+			case <-ctx.Done():
+				return lineResult{}, false
+			}
+		}
+		message := http.StatusText(status)
+		if errMsg.Error != nil && strings.TrimSpace(errMsg.Error.Error()) != "" {
+			message = strings.TrimSpace(errMsg.Error.Error())
+		}
+		result.Outcome = outcomeErrored
+		result.StatusCode = status
+		result.ErrorMessage = message
+		result.Body = errorBody(kind, status, message)
+		return result, true
+	}
+}
+
+func retryDelay(errMsg *interfaces.ErrorMessage, attempt int) time.Duration {
+	if errMsg != nil && errMsg.Addon != nil {
+		if seconds, err := strconv.Atoi(strings.TrimSpace(errMsg.Addon.Get("Retry-After"))); err == nil && seconds > 0 {
+			return min(time.Duration(seconds)*time.Second, lineRetryMax)
+		}
+	}
+	return min(lineRetryBase<<attempt, lineRetryMax)
+}
+
+// jsonBody returns resp as JSON, undoing gzip bodies some upstreams send without a header.
+func jsonBody(resp []byte) json.RawMessage {
+	if len(resp) >= 2 && resp[0] == 0x1f && resp[1] == 0x8b {
+		if reader, err := gzip.NewReader(bytes.NewReader(resp)); err == nil {
+			if decompressed, errRead := io.ReadAll(reader); errRead == nil {
+				resp = decompressed
+			}
+			_ = reader.Close()
+		}
+	}
+	resp = bytes.TrimSpace(resp)
+	if json.Valid(resp) {
+		return json.RawMessage(resp)
+	}
+	encoded, _ := json.Marshal(string(resp))
+	return encoded
+}
+
+// errorBody renders an error in the shape of the batch's API.
+func errorBody(kind string, status int, message string) json.RawMessage {
+	if json.Valid([]byte(message)) {
+		return json.RawMessage(message)
+	}
+	if kind == KindAnthropic {
+		body := []byte(`{"type":"error","error":{"type":"","message":""}}`)
+		body, _ = sjson.SetBytes(body, "error.type", anthropicErrorType(status))
+		body, _ = sjson.SetBytes(body, "error.message", message)
+		return body
+	}
+	errType := "invalid_request_error"
+	switch {
+	case status == http.StatusUnauthorized:
+		errType = "authentication_error"
+	case status == http.StatusForbidden:
+		errType = "permission_error"
+	case status == http.StatusTooManyRequests:
+		errType = "rate_limit_error"
+	case status >= http.StatusInternalServerError:
+		errType = "server_error"
+	}
+	body := []byte(`{"error":{"message":"","type":""}}`)
+	body, _ = sjson.SetBytes(body, "error.message", message)
+	body, _ = sjson.SetBytes(body, "error.type", errType)
+	return body
+}
+
+func anthropicErrorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case 529:
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
+
+// finalize writes the result files and settles the final status.
+func (m *Manager) finalize(st *store, id string, requests []lineRequest) {
+	job, ok := m.update(st, id, true, func(job *Job) {
+		if job.Status != StatusCancelling {
+			job.Status = StatusFinalizing
+			job.FinalizingAt = m.now().Unix()
+		}
+	})
+	if !ok {
+		return
+	}
+	cancelled := job.Status == StatusCancelling
+	results := st.readResults(id)
+	for _, request := range requests {
+		if _, done := results[request.Index]; done {
+			continue
+		}
+		outcome := outcomeExpired
+		if cancelled {
+			outcome = outcomeCanceled
+		}
+		results[request.Index] = lineResult{Index: request.Index, CustomID: request.CustomID, Outcome: outcome}
+	}
+	ordered := make([]lineResult, 0, len(results))
+	for _, result := range results {
+		ordered = append(ordered, result)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Index < ordered[j].Index })
+
+	outputID, errorID, err := m.writeResults(st, job, ordered)
+	if err != nil {
+		m.fail(st, id, err)
+		return
+	}
+	now := m.now().Unix()
+	job, _ = m.update(st, id, true, func(job *Job) {
+		recount(job, results)
+		job.OutputFileID = outputID
+		job.ErrorFileID = errorID
+		switch {
+		case cancelled:
+			job.Status = StatusCancelled
+			job.CancelledAt = now
+		case job.Expired > 0:
+			job.Status = StatusExpired
+			job.ExpiredAt = now
+		default:
+			job.Status = StatusCompleted
+			job.CompletedAt = now
+		}
+	})
+	st.deleteResults(id)
+	log.Infof("batch %s finished: %d succeeded, %d errored, %d canceled, %d expired", id, job.Succeeded, job.Errored, job.Canceled, job.Expired)
+}
+
+// writeResults stores the output and error files of an OpenAI batch, or the single results
+// file of an Anthropic batch.
+func (m *Manager) writeResults(st *store, job Job, results []lineResult) (string, string, error) {
+	var output, failures bytes.Buffer
+	for _, result := range results {
+		var line []byte
+		var err error
+		if job.Kind == KindAnthropic {
+			line, err = anthropicResultLine(result)
+			output.Write(line)
+			output.WriteByte('\n')
+		} else {
+			line, err = openAIResultLine(job.ID, result)
+			target := &failures
+			if result.Outcome == outcomeSucceeded {
+				target = &output
+			}
+			target.Write(line)
+			target.WriteByte('\n')
+		}
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	save := func(name, purpose string, content *bytes.Buffer, internal bool) (string, error) {
+		if content.Len() == 0 && !internal {
+			return "", nil
+		}
+		meta, err := st.writeFile(File{
+			ID:        newID("file-"),
+			CreatedAt: m.now().Unix(),
+			Filename:  name,
+			Purpose:   purpose,
+			Owner:     job.Owner,
+			Internal:  internal,
+		}, content, 0)
+		return meta.ID, err
+	}
+	if job.Kind == KindAnthropic {
+		outputID, err := save(job.ID+"_results.jsonl", "batch_output", &output, true)
+		return outputID, "", err
+	}
+	outputID, err := save(job.ID+"_output.jsonl", "batch_output", &output, false)
+	if err != nil {
+		return "", "", err
+	}
+	errorID, err := save(job.ID+"_error.jsonl", "batch_output", &failures, false)
+	return outputID, errorID, err
+}
+
+func openAIResultLine(jobID string, result lineResult) ([]byte, error) {
+	line := map[string]any{
+		"id":        fmt.Sprintf("batch_req_%s_%d", strings.TrimPrefix(jobID, "batch_"), result.Index),
+		"custom_id": result.CustomID,
+		"response":  nil,
+		"error":     nil,
+	}
+	switch result.Outcome {
+	case outcomeSucceeded, outcomeErrored:
+		line["response"] = map[string]any{
+			"status_code": result.StatusCode,
+			"request_id":  line["id"],
+			"body":        result.Body,
+		}
+	case outcomeExpired:
+		line["error"] = map[string]string{"code": "batch_expired", "message": expiredMessage}
+	case outcomeCanceled:
+		line["error"] = map[string]string{"code": "batch_cancelled", "message": cancelledMessage}
+	}
+	return json.Marshal(line)
+}
+
+func anthropicResultLine(result lineResult) ([]byte, error) {
+	outcome := map[string]any{"type": result.Outcome}
+	switch result.Outcome {
+	case outcomeSucceeded:
+		outcome["message"] = result.Body
+	case outcomeErrored:
+		outcome["error"] = result.Body
+	}
+	return json.Marshal(map[string]any{"custom_id": result.CustomID, "result": outcome})
+}
+
+func (m *Manager) fail(st *store, id string, err error) {
+	log.Warnf("batch %s failed: %v", id, err)
+	m.update(st, id, true, func(job *Job) {
+		job.Status = StatusFailed
+		job.FailedAt = m.now().Unix()
+		job.FailureMessage = err.Error()
+	})
+	st.deleteResults(id)
+}
+
+// update applies fn to the live job and optionally persists it. It returns a copy of the
+// updated job, or false when the job is gone or the manager moved to another store.
+func (m *Manager) update(st *store, id string, save bool, fn func(job *Job)) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store != st {
+		return Job{}, false
+	}
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	fn(job)
+	if save {
+		if err := st.saveJob(job); err != nil {
+			log.Warnf("batch %s: %v", id, err)
+		}
+	}
+	return *job, true
+}
+
+func (m *Manager) snapshot(st *store, id string) (Job, bool) {
+	return m.update(st, id, false, func(*Job) {})
+}
+
+func (m *Manager) cancelling(st *store, id string) bool {
+	job, ok := m.snapshot(st, id)
+	return ok && job.Status == StatusCancelling
+}
+
+func readRequests(st *store, kind, endpoint, fileID string) ([]lineRequest, error) {
+	f, err := st.openFile(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: input file %q not found", ErrInvalidRequest, fileID)
+	}
+	defer func() { _ = f.Close() }()
+	return parseRequests(kind, endpoint, f)
+}
+
+func recount(job *Job, results map[int]lineResult) {
+	job.Succeeded, job.Errored, job.Canceled, job.Expired = 0, 0, 0, 0
+	for _, result := range results {
+		count(job, result.Outcome)
+	}
+}
+
+func count(job *Job, outcome string) {
+	switch outcome {
+	case outcomeSucceeded:
+		job.Succeeded++
+	case outcomeErrored:
+		job.Errored++
+	case outcomeCanceled:
+		job.Canceled++
+	case outcomeExpired:
+		job.Expired++
+	}
+}
+
+func sortJobs(jobs []*Job) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if jobs[i].CreatedAt != jobs[j].CreatedAt {
+			return jobs[i].CreatedAt > jobs[j].CreatedAt
+		}
+		return jobs[i].ID > jobs[j].ID
+	})
+}
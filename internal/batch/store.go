@@ -0,0 +1,338 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const maxIDLen = 128
+
+// ErrFileTooLarge is returned when an upload exceeds the configured size limit.
+var ErrFileTooLarge = errors.New("file exceeds the maximum allowed size")
+
+// File is an uploaded input file or a generated output file.
+type File struct {
+	ID        string `json:"id"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+	Owner     string `json:"owner,omitempty"`
+	// Internal files back Anthropic batches and are hidden from the files API.
+	Internal bool `json:"internal,omitempty"`
+}
+
+// OpenAIView renders the file as an OpenAI file object.
+func (f *File) OpenAIView() map[string]any {
+	return map[string]any{
+		"id":         f.ID,
+		"object":     "file",
+		"bytes":      f.Bytes,
+		"created_at": f.CreatedAt,
+		"filename":   f.Filename,
+		"purpose":    f.Purpose,
+		"status":     "processed",
+	}
+}
+
+// store keeps files and jobs under one directory:
+//
+//	files/<id>.json     file metadata
+//	files/<id>.jsonl    file content
+//	jobs/<id>.json      job state
+//	jobs/<id>.results   outcomes recorded while the job runs, one JSON line each
+type store struct {
+	dir string
+
+	mu sync.Mutex
+}
+
+func newStore(dir string) (*store, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("batch: directory is required")
+	}
+	for _, sub := range []string{"files", "jobs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("batch: create directory: %w", err)
+		}
+	}
+	return &store{dir: dir}, nil
+}
+
+func (s *store) path(kind, id, suffix string) (string, bool) {
+	if id == "" || len(id) > maxIDLen {
+		return "", false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return "", false
+		}
+	}
+	return filepath.Join(s.dir, kind, id+suffix), true
+}
+
+// writeFile stores content read from r and its metadata. Content beyond limit bytes is
+// rejected with ErrFileTooLarge when limit is positive.
+func (s *store) writeFile(meta File, r io.Reader, limit int64) (File, error) {
+	contentPath, ok := s.path("files", meta.ID, ".jsonl")
+	if !ok {
+		return File{}, fmt.Errorf("batch: invalid file id %q", meta.ID)
+	}
+	tmp := contentPath + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return File{}, fmt.Errorf("batch: create file: %w", err)
+	}
+	reader := r
+	if limit > 0 {
+		reader = io.LimitReader(r, limit+1)
+	}
+	written, errCopy := io.Copy(out, reader)
+	errClose := out.Close()
+	if errCopy == nil {
+		errCopy = errClose
+	}
+	if errCopy == nil && limit > 0 && written > limit {
+		errCopy = ErrFileTooLarge
+	}
+	if errCopy != nil {
+		_ = os.Remove(tmp)
+		if errors.Is(errCopy, ErrFileTooLarge) {
+			return File{}, errCopy
+		}
+		return File{}, fmt.Errorf("batch: write file: %w", errCopy)
+	}
+	if err = os.Rename(tmp, contentPath); err != nil {
+		_ = os.Remove(tmp)
+		return File{}, fmt.Errorf("batch: write file: %w", err)
+	}
+	meta.Bytes = written
+	if err = s.writeJSON("files", meta.ID, meta); err != nil {
+		_ = os.Remove(contentPath)
+		return File{}, err
+	}
+	return meta, nil
+}
+
+func (s *store) getFile(id string) (File, bool) {
+	var meta File
+	if !s.readJSON("files", id, &meta) {
+		return File{}, false
+	}
+	return meta, true
+}
+
+// listFiles returns every file, newest first.
+func (s *store) listFiles() []File {
+	var files []File
+	for _, id := range s.ids("files") {
+		if meta, ok := s.getFile(id); ok {
+			files = append(files, meta)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].CreatedAt != files[j].CreatedAt {
+			return files[i].CreatedAt > files[j].CreatedAt
+		}
+		return files[i].ID > files[j].ID
+	})
+	return files
+}
+
+func (s *store) deleteFile(id string) bool {
+	metaPath, ok := s.path("files", id, ".json")
+	if !ok {
+		return false
+	}
+	contentPath, _ := s.path("files", id, ".jsonl")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(metaPath); err != nil {
+		return false
+	}
+	_ = os.Remove(contentPath)
+	return true
+}
+
+func (s *store) openFile(id string) (*os.File, error) {
+	contentPath, ok := s.path("files", id, ".jsonl")
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(contentPath)
+}
+
+func (s *store) saveJob(job *Job) error {
+	return s.writeJSON("jobs", job.ID, job)
+}
+
+// loadJobs returns every persisted job, newest first.
+func (s *store) loadJobs() []*Job {
+	var jobs []*Job
+	for _, id := range s.ids("jobs") {
+		job := &Job{}
+		if s.readJSON("jobs", id, job) {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if jobs[i].CreatedAt != jobs[j].CreatedAt {
+			return jobs[i].CreatedAt > jobs[j].CreatedAt
+		}
+		return jobs[i].ID > jobs[j].ID
+	})
+	return jobs
+}
+
+func (s *store) deleteJob(id string) {
+	if jobPath, ok := s.path("jobs", id, ".json"); ok {
+		_ = os.Remove(jobPath)
+	}
+	s.deleteResults(id)
+}
+
+func (s *store) appendResult(jobID string, result lineResult) error {
+	resultsPath, ok := s.path("jobs", jobID, ".results")
+	if !ok {
+		return fmt.Errorf("batch: invalid job id %q", jobID)
+	}
+	line, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out, err := os.OpenFile(resultsPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(line, '\n'))
+	if errClose := out.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// readResults returns the recorded outcomes keyed by input index. A torn last line from an
+// interrupted write is ignored so the request is simply run again.
+func (s *store) readResults(jobID string) map[int]lineResult {
+	results := make(map[int]lineResult)
+	resultsPath, ok := s.path("jobs", jobID, ".results")
+	if !ok {
+		return results
+	}
+	s.mu.Lock()
+	data, err := os.ReadFile(resultsPath)
+	s.mu.Unlock()
+	if err != nil {
+		return results
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		var result lineResult
+		if json.Unmarshal(scanner.Bytes(), &result) == nil {
+			results[result.Index] = result
+		}
+	}
+	return results
+}
+
+func (s *store) deleteResults(jobID string) {
+	if resultsPath, ok := s.path("jobs", jobID, ".results"); ok {
+		_ = os.Remove(resultsPath)
+	}
+}
+
+func (s *store) writeJSON(kind, id string, value any) error {
+	target, ok := s.path(kind, id, ".json")
+	if !ok {
+		return fmt.Errorf("batch: invalid id %q", id)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("batch: encode %s: %w", kind, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := target + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("batch: write %s: %w", kind, err)
+	}
+	if err = os.Rename(tmp, target); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("batch: write %s: %w", kind, err)
+	}
+	return nil
+}
+
+func (s *store) readJSON(kind, id string, value any) bool {
+	target, ok := s.path(kind, id, ".json")
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	data, err := os.ReadFile(target)
+	s.mu.Unlock()
+	if err != nil {
+		return false
+	}
+	if err = json.Unmarshal(data, value); err != nil {
+		log.Warnf("batch: decode %s %s: %v", kind, id, err)
+		return false
+	}
+	return true
+}
+
+func (s *store) ids(kind string) []string {
+	entries, err := os.ReadDir(filepath.Join(s.dir, kind))
+	if err != nil {
+		return nil
+	}
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, ".json"))
+	}
+	return ids
+}
+
+// ResolveDirectory returns the batch storage directory for cfg.
+func ResolveDirectory(cfg *config.Config) string {
+	if cfg != nil {
+		if dir := strings.TrimSpace(cfg.Batch.Dir); dir != "" {
+			if resolved, err := util.ResolveAuthDir(dir); err == nil && resolved != "" {
+				return resolved
+			}
+			return dir
+		}
+	}
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "batches")
+	}
+	if cfg != nil {
+		if authDir, err := util.ResolveAuthDir(cfg.AuthDir); err == nil && authDir != "" {
+			return filepath.Join(authDir, "batches")
+		}
+	}
+	return "batches"
+}
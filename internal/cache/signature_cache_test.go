@@ -299,3 +299,45 @@ func TestSignatureBypassStrictMode_LogsAtDebugLevel(t *testing.T) {
 		t.Fatalf("expected debug output for basic bypass mode, got: %q", output)
 	}
 }
+
+func TestExportRestoreSignatures_SkipsExpiredAndKeepsNewer(t *testing.T) {
+	ClearSignatureCache("")
+	t.Cleanup(func() { ClearSignatureCache("") })
+
+	signature := "exported_signature_1234567890123456789012345678901234567890"
+	CacheSignature(testModelName, "exported text", signature)
+	records := ExportSignatures()
+	if len(records) != 1 || records[0].Group != "claude" || records[0].Signature != signature {
+		t.Fatalf("ExportSignatures() = %+v", records)
+	}
+
+	expired := records[0]
+	expired.TextHash = hashText("expired text")
+	expired.Timestamp = time.Now().Add(-SignatureCacheTTL - time.Minute)
+	ClearSignatureCache("")
+	if restored := RestoreSignatures(append(records, expired)); restored != 1 {
+		t.Fatalf("RestoreSignatures() = %d, want 1", restored)
+	}
+	if got := GetCachedSignature(testModelName, "exported text"); got != signature {
+		t.Fatalf("restored signature = %q, want %q", got, signature)
+	}
+	if got := GetCachedSignature(testModelName, "expired text"); got != "" {
+		t.Fatalf("expired signature was restored: %q", got)
+	}
+
+	// The lookup above refreshed the entry, so the older export must not replace it.
+	if restored := RestoreSignatures(records); restored != 0 {
+		t.Fatalf("RestoreSignatures() over a newer entry = %d, want 0", restored)
+	}
+
+	groups := SignatureGroups()
+	if len(groups) != 1 || groups[0].Group != "claude" || groups[0].Entries != 1 {
+		t.Fatalf("SignatureGroups() = %+v", groups)
+	}
+	if removed := ClearSignatureGroup("claude"); removed != 1 {
+		t.Fatalf("ClearSignatureGroup() = %d, want 1", removed)
+	}
+	if groups = SignatureGroups(); len(groups) != 0 {
+		t.Fatalf("SignatureGroups() after clear = %+v", groups)
+	}
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+// SignatureRecord is one cached signature as written to snapshots.
+type SignatureRecord struct {
+	Group     string    `json:"group"`
+	TextHash  string    `json:"text_hash"`
+	Signature string    `json:"signature"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SignatureGroupInfo summarizes the cached signatures of one model group.
+type SignatureGroupInfo struct {
+	Group   string    `json:"group"`
+	Entries int       `json:"entries"`
+	Oldest  time.Time `json:"oldest"`
+	Newest  time.Time `json:"newest"`
+	// ExpiresAt is when the most recently used entry expires unless it is used again.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExportSignatures returns every unexpired cached signature, ordered by group and hash.
+func ExportSignatures() []SignatureRecord {
+	now := time.Now()
+	var records []SignatureRecord
+	signatureCache.Range(func(key, value any) bool {
+		group := key.(string)
+		sc := value.(*groupCache)
+		sc.mu.RLock()
+		for textHash, entry := range sc.entries {
+			if now.Sub(entry.Timestamp) > SignatureCacheTTL {
+				continue
+			}
+			records = append(records, SignatureRecord{
+				Group:     group,
+				TextHash:  textHash,
+				Signature: entry.Signature,
+				Timestamp: entry.Timestamp,
+			})
+		}
+		sc.mu.RUnlock()
+		return true
+	})
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Group != records[j].Group {
+			return records[i].Group < records[j].Group
+		}
+		return records[i].TextHash < records[j].TextHash
+	})
+	return records
+}
+
+// RestoreSignatures loads records exported by ExportSignatures and returns how many were
+// added. Expired records are skipped, and entries cached since the export are kept when
+// they are newer.
+func RestoreSignatures(records []SignatureRecord) int {
+	now := time.Now()
+	restored := 0
+	for _, record := range records {
+		if record.Group == "" || len(record.TextHash) != SignatureTextHashLen || len(record.Signature) < MinValidSignatureLen {
+			continue
+		}
+		if now.Sub(record.Timestamp) > SignatureCacheTTL {
+			continue
+		}
+		sc := getOrCreateGroupCache(record.Group)
+		sc.mu.Lock()
+		if existing, ok := sc.entries[record.TextHash]; !ok || existing.Timestamp.Before(record.Timestamp) {
+			sc.entries[record.TextHash] = SignatureEntry{Signature: record.Signature, Timestamp: record.Timestamp}
+			restored++
+		}
+		sc.mu.Unlock()
+	}
+	return restored
+}
+
+// SignatureGroups summarizes the cached model groups, ordered by name. Groups without
+// unexpired entries are omitted.
+func SignatureGroups() []SignatureGroupInfo {
+	now := time.Now()
+	var groups []SignatureGroupInfo
+	signatureCache.Range(func(key, value any) bool {
+		info := SignatureGroupInfo{Group: key.(string)}
+		sc := value.(*groupCache)
+		sc.mu.RLock()
+		for _, entry := range sc.entries {
+			if now.Sub(entry.Timestamp) > SignatureCacheTTL {
+				continue
+			}
+			info.Entries++
+			if info.Oldest.IsZero() || entry.Timestamp.Before(info.Oldest) {
+				info.Oldest = entry.Timestamp
+			}
+			if entry.Timestamp.After(info.Newest) {
+				info.Newest = entry.Timestamp
+			}
+		}
+		sc.mu.RUnlock()
+		if info.Entries > 0 {
+			info.ExpiresAt = info.Newest.Add(SignatureCacheTTL)
+			groups = append(groups, info)
+		}
+		return true
+	})
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Group < groups[j].Group })
+	return groups
+}
+
+// ClearSignatureGroup drops the model group named group and returns how many entries it held.
+// Unlike ClearSignatureCache, group is matched exactly rather than derived from a model name.
+func ClearSignatureGroup(group string) int {
+	value, ok := signatureCache.LoadAndDelete(group)
+	if !ok {
+		return 0
+	}
+	sc := value.(*groupCache)
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return len(sc.entries)
+}
@@ -0,0 +1,143 @@
+// Package cachesnapshot saves the session-affinity bindings and the thinking signature cache
+// so a restart does not rebind running sessions or drop signatures still in use.
+package cachesnapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// Snapshot names.
+const (
+	SessionBindings = "session-bindings"
+	Signatures      = "signatures"
+)
+
+// Backend stores snapshots by name. Implementations must be safe for concurrent use.
+type Backend interface {
+	// SaveCacheSnapshot replaces the snapshot stored under name.
+	SaveCacheSnapshot(ctx context.Context, name string, data []byte) error
+	// LoadCacheSnapshot returns the snapshot stored under name, or nil when there is none.
+	LoadCacheSnapshot(ctx context.Context, name string) ([]byte, error)
+}
+
+// SessionSource holds the session-affinity bindings; *coreauth.Manager implements it.
+type SessionSource interface {
+	SessionBindings() []coreauth.SessionBinding
+	RestoreSessionBindings(bindings []coreauth.SessionBinding) int
+}
+
+type sessionSnapshot struct {
+	SavedAt  time.Time                 `json:"saved_at"`
+	Bindings []coreauth.SessionBinding `json:"bindings"`
+}
+
+type signatureSnapshot struct {
+	SavedAt    time.Time               `json:"saved_at"`
+	Signatures []cache.SignatureRecord `json:"signatures"`
+}
+
+// Restored counts the entries a restore added.
+type Restored struct {
+	SessionBindings int
+	Signatures      int
+}
+
+// Snapshotter saves and restores both caches through a backend.
+type Snapshotter struct {
+	backend  Backend
+	sessions SessionSource
+
+	mu sync.Mutex
+	// saved holds the digest of the entries last written under each name, so idle caches
+	// are not rewritten on every tick.
+	saved map[string][sha256.Size]byte
+}
+
+// New returns a snapshotter writing to backend. sessions may be nil, in which case only the
+// signature cache is kept.
+func New(backend Backend, sessions SessionSource) *Snapshotter {
+	return &Snapshotter{backend: backend, sessions: sessions, saved: make(map[string][sha256.Size]byte)}
+}
+
+// Backend returns the backend snapshots are written to.
+func (s *Snapshotter) Backend() Backend { return s.backend }
+
+// Save writes the current contents of both caches. Expired entries are left out.
+func (s *Snapshotter) Save(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	if s.sessions != nil {
+		bindings := s.sessions.SessionBindings()
+		if bindings == nil {
+			bindings = []coreauth.SessionBinding{}
+		}
+		if err := s.save(ctx, SessionBindings, bindings, sessionSnapshot{SavedAt: now, Bindings: bindings}); err != nil {
+			return err
+		}
+	}
+	signatures := cache.ExportSignatures()
+	if signatures == nil {
+		signatures = []cache.SignatureRecord{}
+	}
+	return s.save(ctx, Signatures, signatures, signatureSnapshot{SavedAt: now, Signatures: signatures})
+}
+
+func (s *Snapshotter) save(ctx context.Context, name string, entries, snapshot any) error {
+	encoded, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("cache snapshot: encode %s: %w", name, err)
+	}
+	digest := sha256.Sum256(encoded)
+	if previous, ok := s.saved[name]; ok && previous == digest {
+		return nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("cache snapshot: encode %s: %w", name, err)
+	}
+	if err = s.backend.SaveCacheSnapshot(ctx, name, data); err != nil {
+		return fmt.Errorf("cache snapshot: save %s: %w", name, err)
+	}
+	s.saved[name] = digest
+	return nil
+}
+
+// Restore loads both snapshots into the caches. Entries that expired while the process was
+// down are dropped and entries created since startup are kept.
+func (s *Snapshotter) Restore(ctx context.Context) (Restored, error) {
+	var restored Restored
+	if s.sessions != nil {
+		data, err := s.backend.LoadCacheSnapshot(ctx, SessionBindings)
+		if err != nil {
+			return restored, fmt.Errorf("cache snapshot: load %s: %w", SessionBindings, err)
+		}
+		if len(data) > 0 {
+			var snapshot sessionSnapshot
+			if err = json.Unmarshal(data, &snapshot); err != nil {
+				return restored, fmt.Errorf("cache snapshot: decode %s: %w", SessionBindings, err)
+			}
+			restored.SessionBindings = s.sessions.RestoreSessionBindings(snapshot.Bindings)
+		}
+	}
+	data, err := s.backend.LoadCacheSnapshot(ctx, Signatures)
+	if err != nil {
+		return restored, fmt.Errorf("cache snapshot: load %s: %w", Signatures, err)
+	}
+	if len(data) > 0 {
+		var snapshot signatureSnapshot
+		if err = json.Unmarshal(data, &snapshot); err != nil {
+			return restored, fmt.Errorf("cache snapshot: decode %s: %w", Signatures, err)
+		}
+		restored.Signatures = cache.RestoreSignatures(snapshot.Signatures)
+	}
+	return restored, nil
+}
@@ -0,0 +1,85 @@
+package cachesnapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+type countingBackend struct {
+	*FileBackend
+	saves int
+}
+
+func (b *countingBackend) SaveCacheSnapshot(ctx context.Context, name string, data []byte) error {
+	b.saves++
+	return b.FileBackend.SaveCacheSnapshot(ctx, name, data)
+}
+
+func TestSnapshotterRoundTrip(t *testing.T) {
+	cache.ClearSignatureCache("")
+	t.Cleanup(func() { cache.ClearSignatureCache("") })
+
+	fileBackend, err := NewFileBackend(filepath.Join(t.TempDir(), "snapshots"))
+	if err != nil {
+		t.Fatalf("NewFileBackend() error = %v", err)
+	}
+	backend := &countingBackend{FileBackend: fileBackend}
+	ctx := context.Background()
+
+	before := coreauth.NewManager(nil, coreauth.NewSessionAffinitySelector(nil), nil)
+	t.Cleanup(before.StopAutoRefresh)
+	before.RestoreSessionBindings([]coreauth.SessionBinding{{Key: "claude::session-1::claude-sonnet-4-5", AuthID: "auth-a", ExpiresAt: time.Now().Add(time.Hour)}})
+	signature := "snapshot_signature_123456789012345678901234567890123456789"
+	cache.CacheSignature("claude-sonnet-4-5", "thinking text", signature)
+
+	snapshotter := New(backend, before)
+	if err = snapshotter.Save(ctx); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if backend.saves != 2 {
+		t.Fatalf("saves = %d, want 2", backend.saves)
+	}
+	if err = snapshotter.Save(ctx); err != nil || backend.saves != 2 {
+		t.Fatalf("unchanged caches were written again: saves=%d err=%v", backend.saves, err)
+	}
+	for _, name := range []string{SessionBindings, Signatures} {
+		if _, errStat := os.Stat(filepath.Join(fileBackend.Dir(), name+".json")); errStat != nil {
+			t.Fatalf("snapshot %s missing: %v", name, errStat)
+		}
+	}
+
+	// A restarted process starts with empty caches.
+	cache.ClearSignatureCache("")
+	after := coreauth.NewManager(nil, coreauth.NewSessionAffinitySelector(nil), nil)
+	t.Cleanup(after.StopAutoRefresh)
+	restored, err := New(backend, after).Restore(ctx)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if restored.SessionBindings != 1 || restored.Signatures != 1 {
+		t.Fatalf("Restore() = %+v", restored)
+	}
+	if bindings := after.SessionBindings(); len(bindings) != 1 || bindings[0].AuthID != "auth-a" {
+		t.Fatalf("restored bindings = %+v", bindings)
+	}
+	if got := cache.GetCachedSignature("claude-sonnet-4-5", "thinking text"); got != signature {
+		t.Fatalf("restored signature = %q", got)
+	}
+}
+
+func TestSnapshotterRestoreWithoutSnapshots(t *testing.T) {
+	backend, err := NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileBackend() error = %v", err)
+	}
+	restored, err := New(backend, nil).Restore(context.Background())
+	if err != nil || restored != (Restored{}) {
+		t.Fatalf("Restore() = %+v, %v", restored, err)
+	}
+}
@@ -0,0 +1,85 @@
+package cachesnapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+const fileSuffix = ".json"
+
+// FileBackend keeps each snapshot in a JSON file named after the snapshot.
+type FileBackend struct {
+	dir string
+}
+
+var _ Backend = (*FileBackend)(nil)
+
+// NewFileBackend creates dir when needed and returns a backend storing snapshots in it.
+func NewFileBackend(dir string) (*FileBackend, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("cache snapshot: directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("cache snapshot: create directory: %w", err)
+	}
+	return &FileBackend{dir: dir}, nil
+}
+
+// Dir returns the directory holding the snapshots.
+func (b *FileBackend) Dir() string { return b.dir }
+
+// SaveCacheSnapshot atomically replaces the file for name.
+func (b *FileBackend) SaveCacheSnapshot(_ context.Context, name string, data []byte) error {
+	path := b.path(name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// LoadCacheSnapshot reads the file for name.
+func (b *FileBackend) LoadCacheSnapshot(_ context.Context, name string) ([]byte, error) {
+	data, err := os.ReadFile(b.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (b *FileBackend) path(name string) string {
+	return filepath.Join(b.dir, name+fileSuffix)
+}
+
+// ResolveDirectory returns the snapshot directory for cfg and the config file path. It returns
+// "" when neither a directory, a writable path nor a config path is known, so snapshots are not
+// written to the working directory.
+func ResolveDirectory(cfg *config.Config, configPath string) string {
+	if cfg != nil {
+		if dir := strings.TrimSpace(cfg.CacheSnapshot.Dir); dir != "" {
+			if resolved, err := util.ResolveAuthDir(dir); err == nil && resolved != "" {
+				return resolved
+			}
+			return dir
+		}
+	}
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "cache-snapshots")
+	}
+	if configPath = strings.TrimSpace(configPath); configPath != "" {
+		return filepath.Join(filepath.Dir(configPath), "cache-snapshots")
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+// Package cmd contains CLI helpers. This file implements copying auth records and the
+// config from the active token store to another backend.
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/store"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoMigrateStore copies all auth records and the config file from the registered token store
+// to target and prints the resulting diff. With dryRun nothing is written.
+func DoMigrateStore(cfg *config.Config, configFilePath string, target store.MigrationTarget, dryRun bool) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	ctx := context.Background()
+	tokenStore := sdkAuth.GetTokenStore()
+	if setter, ok := tokenStore.(interface{ SetBaseDir(string) }); ok {
+		setter.SetBaseDir(cfg.AuthDir)
+	}
+	source := store.MigrationSource(tokenStore, cfg.AuthDir, configFilePath)
+
+	destination, closeTarget, errOpen := store.OpenMigrationTarget(ctx, target, dryRun)
+	if errOpen != nil {
+		log.Errorf("migrate-store: open target failed: %v", errOpen)
+		return
+	}
+	defer closeTarget()
+
+	report, errMigrate := store.Migrate(ctx, source, destination, dryRun)
+	if report != nil {
+		report.WriteText(os.Stdout)
+	}
+	if errMigrate != nil {
+		log.Errorf("migrate-store: %v", errMigrate)
+	}
+}
@@ -0,0 +1,150 @@
+// Package cmd contains CLI helpers. This file implements replaying a logged request against
+// the running server and printing how the upstream traffic changed.
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// replayColumnWidth is the width of each side of the printed diff.
+const replayColumnWidth = 72
+
+type replayDiffLine struct {
+	Op  string `json:"op"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+type replaySide struct {
+	Status int `json:"status"`
+}
+
+type replayResult struct {
+	RequestID       string                      `json:"request_id"`
+	ReplayRequestID string                      `json:"replay_request_id"`
+	Method          string                      `json:"method"`
+	URL             string                      `json:"url"`
+	AuthID          string                      `json:"auth_id"`
+	Model           string                      `json:"model"`
+	Original        replaySide                  `json:"original"`
+	Replay          replaySide                  `json:"replay"`
+	Diff            map[string][]replayDiffLine `json:"diff"`
+}
+
+// DoReplayRequest asks the server running with cfg to replay the logged request requestID,
+// optionally pinned to authID or with model overridden, and prints a side-by-side diff of the
+// original and new upstream payloads and responses. The management key comes from
+// managementKey or the MANAGEMENT_PASSWORD environment variable.
+func DoReplayRequest(cfg *config.Config, requestID, authID, model, managementKey string) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if strings.TrimSpace(managementKey) == "" {
+		managementKey = strings.TrimSpace(os.Getenv("MANAGEMENT_PASSWORD"))
+	}
+	if managementKey == "" {
+		log.Error("replay: a management key is required (-password or MANAGEMENT_PASSWORD)")
+		return
+	}
+
+	scheme := "http"
+	client := &http.Client{Timeout: 10 * time.Minute}
+	if cfg.TLS.Enable {
+		// The request goes to this host's own listener, whose certificate rarely names 127.0.0.1.
+		scheme = "https"
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	endpoint := fmt.Sprintf("%s://127.0.0.1:%d/v0/management/request-log-by-id/%s/replay", scheme, cfg.Port, url.PathEscape(strings.TrimSpace(requestID)))
+	payload, _ := json.Marshal(map[string]string{"auth_id": strings.TrimSpace(authID), "model": strings.TrimSpace(model)})
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		log.Errorf("replay: build request failed: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+managementKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Errorf("replay: request to the running server failed: %v", err)
+		return
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("replay: close response body failed: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Errorf("replay: read response failed: %v", err)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Errorf("replay: server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+		return
+	}
+	var result replayResult
+	if err = json.Unmarshal(data, &result); err != nil {
+		log.Errorf("replay: decode response failed: %v", err)
+		return
+	}
+	printReplayResult(os.Stdout, result)
+}
+
+func printReplayResult(w io.Writer, result replayResult) {
+	_, _ = fmt.Fprintf(w, "Replayed %s %s (request %s -> %s)\n", result.Method, result.URL, result.RequestID, result.ReplayRequestID)
+	if result.AuthID != "" {
+		_, _ = fmt.Fprintf(w, "Pinned auth: %s\n", result.AuthID)
+	}
+	if result.Model != "" {
+		_, _ = fmt.Fprintf(w, "Model override: %s\n", result.Model)
+	}
+	_, _ = fmt.Fprintf(w, "Status: %d -> %d\n", result.Original.Status, result.Replay.Status)
+
+	for _, section := range []struct{ key, title string }{
+		{"api_request", "UPSTREAM REQUEST"},
+		{"api_response", "UPSTREAM RESPONSE"},
+		{"response", "RESPONSE"},
+	} {
+		rows := result.Diff[section.key]
+		_, _ = fmt.Fprintf(w, "\n=== %s ===\n", section.title)
+		if len(rows) == 0 {
+			_, _ = fmt.Fprintln(w, "(empty on both sides)")
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "  %-*s   %s\n", replayColumnWidth, "original", "replay")
+		for _, row := range rows {
+			marker := " "
+			switch row.Op {
+			case "changed":
+				marker = "|"
+			case "removed":
+				marker = "<"
+			case "added":
+				marker = ">"
+			}
+			_, _ = fmt.Fprintf(w, "  %-*s %s %s\n", replayColumnWidth, clipReplayColumn(row.Old), marker, clipReplayColumn(row.New))
+		}
+	}
+}
+
+func clipReplayColumn(text string) string {
+	text = strings.ReplaceAll(text, "\t", "    ")
+	runes := []rune(text)
+	if len(runes) <= replayColumnWidth {
+		return text
+	}
+	return string(runes[:replayColumnWidth-3]) + "..."
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strings"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+)
+
+// SanitizeAccessProviders trims provider names and types, defaults empty names to the type,
+// and drops entries without a type or duplicating an earlier name.
+func (cfg *SDKConfig) SanitizeAccessProviders() {
+	if cfg == nil || len(cfg.Access.Providers) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.Access.Providers))
+	out := make([]sdkaccess.AccessProvider, 0, len(cfg.Access.Providers))
+	for _, provider := range cfg.Access.Providers {
+		provider = NormalizeAccessProvider(provider)
+		if provider.Type == "" {
+			continue
+		}
+		key := strings.ToLower(provider.Name)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, provider)
+	}
+	cfg.Access.Providers = out
+}
+
+// NormalizeAccessProvider returns a trimmed copy of the provider entry.
+func NormalizeAccessProvider(provider sdkaccess.AccessProvider) sdkaccess.AccessProvider {
+	provider.Type = strings.ToLower(strings.TrimSpace(provider.Type))
+	provider.Name = strings.TrimSpace(provider.Name)
+	provider.SDK = strings.TrimSpace(provider.SDK)
+	if provider.Name == "" {
+		provider.Name = provider.Type
+	}
+	if len(provider.APIKeys) > 0 {
+		keys := make([]string, 0, len(provider.APIKeys))
+		for _, key := range provider.APIKeys {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		provider.APIKeys = keys
+	}
+	return provider
+}
@@ -0,0 +1,83 @@
+package config
+
+import "strings"
+
+// APIKeyPolicy describes the restrictions enforced for a single client API key.
+// A zero value for any limit disables that limit.
+type APIKeyPolicy struct {
+	// APIKey is the client key (from top-level api-keys) this policy applies to.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// AllowedModels lists model name patterns the key may request.
+	// Uses the same wildcard syntax as excluded-models; empty allows every model.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+
+	// RequestsPerMinute caps the number of requests accepted within a rolling minute.
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+
+	// TokensPerDay caps total tokens consumed per UTC calendar day.
+	TokensPerDay int64 `yaml:"tokens-per-day,omitempty" json:"tokens-per-day,omitempty"`
+
+	// MonthlyTokenBudget caps total tokens consumed per UTC calendar month.
+	MonthlyTokenBudget int64 `yaml:"monthly-token-budget,omitempty" json:"monthly-token-budget,omitempty"`
+}
+
+// IsEmpty reports whether the policy imposes no restriction at all.
+func (p APIKeyPolicy) IsEmpty() bool {
+	return len(p.AllowedModels) == 0 && p.RequestsPerMinute <= 0 && p.TokensPerDay <= 0 && p.MonthlyTokenBudget <= 0
+}
+
+// SanitizeAPIKeyPolicies trims keys and patterns, clamps negative limits,
+// and drops entries without a key or duplicating an earlier key.
+func (cfg *SDKConfig) SanitizeAPIKeyPolicies() {
+	if cfg == nil || len(cfg.APIKeyPolicies) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.APIKeyPolicies))
+	out := make([]APIKeyPolicy, 0, len(cfg.APIKeyPolicies))
+	for i := range cfg.APIKeyPolicies {
+		entry := NormalizeAPIKeyPolicy(cfg.APIKeyPolicies[i])
+		if entry.APIKey == "" {
+			continue
+		}
+		if _, exists := seen[entry.APIKey]; exists {
+			continue
+		}
+		seen[entry.APIKey] = struct{}{}
+		out = append(out, entry)
+	}
+	cfg.APIKeyPolicies = out
+}
+
+// NormalizeAPIKeyPolicy returns a trimmed copy of the policy with negative limits cleared.
+func NormalizeAPIKeyPolicy(policy APIKeyPolicy) APIKeyPolicy {
+	policy.APIKey = strings.TrimSpace(policy.APIKey)
+	policy.AllowedModels = NormalizeExcludedModels(policy.AllowedModels)
+	if policy.RequestsPerMinute < 0 {
+		policy.RequestsPerMinute = 0
+	}
+	if policy.TokensPerDay < 0 {
+		policy.TokensPerDay = 0
+	}
+	if policy.MonthlyTokenBudget < 0 {
+		policy.MonthlyTokenBudget = 0
+	}
+	return policy
+}
+
+// APIKeyPolicyFor returns the policy configured for the given client key, if any.
+func (cfg *SDKConfig) APIKeyPolicyFor(apiKey string) (APIKeyPolicy, bool) {
+	if cfg == nil {
+		return APIKeyPolicy{}, false
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return APIKeyPolicy{}, false
+	}
+	for i := range cfg.APIKeyPolicies {
+		if cfg.APIKeyPolicies[i].APIKey == apiKey {
+			return cfg.APIKeyPolicies[i], true
+		}
+	}
+	return APIKeyPolicy{}, false
+}
@@ -10,8 +10,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	log "github.com/sirupsen/logrus"
@@ -52,6 +54,9 @@ type Config struct {
 	// Pprof config controls the optional pprof HTTP debug server.
 	Pprof PprofConfig `yaml:"pprof" json:"pprof"`
 
+	// Metrics config controls the Prometheus metrics endpoint.
+	Metrics MetricsConfig `yaml:"metrics" json:"metrics"`
+
 	// CommercialMode disables high-overhead HTTP middleware features to minimize per-request memory usage.
 	CommercialMode bool `yaml:"commercial-mode" json:"commercial-mode"`
 
@@ -66,6 +71,16 @@ type Config struct {
 	// When exceeded, the oldest error log files are deleted. Default is 10. Set to 0 to disable cleanup.
 	ErrorLogsMaxFiles int `yaml:"error-logs-max-files" json:"error-logs-max-files"`
 
+	// RequestLogRedaction configures what is masked in request logs before they are written or forwarded.
+	RequestLogRedaction RequestLogRedactionConfig `yaml:"request-log-redaction" json:"request-log-redaction"`
+
+	// RequestLogFormat selects how request logs are written: "text" (default) writes one
+	// human-readable file per request, "jsonl" appends structured entries to a daily index.
+	RequestLogFormat string `yaml:"request-log-format,omitempty" json:"request-log-format,omitempty"`
+
+	// RequestLogRetentionDays limits how many days of JSONL request logs are kept. Default is 7.
+	RequestLogRetentionDays int `yaml:"request-log-retention-days,omitempty" json:"request-log-retention-days,omitempty"`
+
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
@@ -74,6 +89,41 @@ type Config struct {
 	// Default: 60. Max: 3600.
 	RedisUsageQueueRetentionSeconds int `yaml:"redis-usage-queue-retention-seconds" json:"redis-usage-queue-retention-seconds"`
 
+	// UsageLedger configures the persistent usage ledger backing the usage history API.
+	UsageLedger UsageLedgerConfig `yaml:"usage-ledger" json:"usage-ledger"`
+
+	// ResponseCache configures caching of deterministic non-streaming responses.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache" json:"response-cache"`
+
+	// ResponsesStore configures the local store behind previous_response_id and GET /v1/responses/{id}.
+	ResponsesStore ResponsesStoreConfig `yaml:"responses-store" json:"responses-store"`
+
+	// Batch configures the local runner behind /v1/files, /v1/batches and /v1/messages/batches.
+	Batch BatchConfig `yaml:"batch" json:"batch"`
+
+	// AuthEncryption configures encryption at rest for auth files in every token store.
+	AuthEncryption AuthEncryptionConfig `yaml:"auth-encryption" json:"auth-encryption"`
+
+	// AuditLog configures the append-only log of mutating management API requests.
+	AuditLog AuditLogConfig `yaml:"audit-log" json:"audit-log"`
+
+	// ConfigVersions configures the history of config file versions kept for rollback.
+	ConfigVersions ConfigVersionsConfig `yaml:"config-versions" json:"config-versions"`
+
+	// Tracing configures OpenTelemetry span export for the request path.
+	Tracing TracingConfig `yaml:"tracing" json:"tracing"`
+
+	// SharedState configures the Redis-protocol backend replicas use to share cooldowns,
+	// quota state, session-affinity bindings and round-robin cursors.
+	SharedState SharedStateConfig `yaml:"shared-state" json:"-"`
+
+	// CacheSnapshot configures saving session-affinity bindings and cached thinking signatures
+	// so they survive restarts.
+	CacheSnapshot CacheSnapshotConfig `yaml:"cache-snapshot" json:"cache-snapshot"`
+
+	// HealthProbe configures background probing of credentials outside user traffic.
+	HealthProbe HealthProbeConfig `yaml:"health-probe" json:"health-probe"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -143,6 +193,11 @@ type Config struct {
 	// gemini-api-key, codex-api-key, claude-api-key, openai-compatibility, vertex-api-key, and ampcode.
 	OAuthModelAlias map[string][]OAuthModelAlias `yaml:"oauth-model-alias,omitempty" json:"oauth-model-alias,omitempty"`
 
+	// ModelFallbacks maps client-visible model aliases to ordered provider chains.
+	// Each hop is tried in order until one starts producing output; unlike
+	// openai-compatibility alias pools, a chain may span providers and auth kinds.
+	ModelFallbacks []ModelFallback `yaml:"model-fallbacks,omitempty" json:"model-fallbacks,omitempty"`
+
 	// Payload defines default and override rules for provider payload parameters.
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
@@ -190,6 +245,275 @@ type PprofConfig struct {
 	Addr string `yaml:"addr" json:"addr"`
 }
 
+// MetricsConfig holds Prometheus metrics settings.
+type MetricsConfig struct {
+	// Enable toggles metric collection and the /metrics route on the API server.
+	Enable bool `yaml:"enable" json:"enable"`
+	// ServeOnPprof additionally exposes /metrics on the pprof listener when pprof is enabled.
+	ServeOnPprof bool `yaml:"serve-on-pprof" json:"serve-on-pprof"`
+}
+
+// UsageLedgerConfig holds persistent usage ledger settings.
+type UsageLedgerConfig struct {
+	// Enable toggles writing usage records to the ledger.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Dir overrides the directory used by the file-backed ledger.
+	// When empty, a "usage" directory next to the logs or auth-dir is used.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// RetentionDays drops ledger entries older than this many days; 0 keeps everything.
+	RetentionDays int `yaml:"retention-days,omitempty" json:"retention-days,omitempty"`
+}
+
+// ResponseCacheConfig holds response cache settings.
+type ResponseCacheConfig struct {
+	// Enable toggles caching of non-streaming responses for requests with temperature 0.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Backend selects the store: "memory" (LRU, default) or "disk".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+	// Dir overrides the directory used by the disk backend.
+	// When empty, a "response-cache" directory next to the logs or auth-dir is used.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// MaxEntries caps the number of cached responses. Default is 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+	// TTLSeconds controls how long a response stays cached. Default is 3600.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+}
+
+// ResponsesStoreConfig holds settings for the stateful Responses API store.
+type ResponsesStoreConfig struct {
+	// Enable stores Responses API results so clients can chain them with previous_response_id.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Backend selects the store: "memory" (LRU, default) or "disk".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+	// Dir overrides the directory used by the disk backend.
+	// When empty, a "responses-store" directory next to the logs or auth-dir is used.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// MaxEntries caps the number of stored responses. Default is 10000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+	// TTLSeconds controls how long a response stays retrievable. Default is 30 days.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+}
+
+// BatchConfig holds settings for the OpenAI and Anthropic batch API emulation.
+type BatchConfig struct {
+	// Enable serves the files and batches endpoints and runs queued jobs.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Dir overrides the directory holding uploaded files, jobs and results.
+	// When empty, a "batches" directory next to the logs or auth-dir is used.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Concurrency caps the number of batch requests in flight across all jobs. Default is 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	// MaxFileSizeMB caps the size of uploaded files. Default is 200.
+	MaxFileSizeMB int `yaml:"max-file-size-mb,omitempty" json:"max-file-size-mb,omitempty"`
+}
+
+// AuthEncryptionConfig holds settings for encrypting auth files at rest.
+type AuthEncryptionConfig struct {
+	// Enable encrypts auth files on write. Existing plaintext files stay readable and are
+	// encrypted the next time they are saved or by -rotate-auth-key.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Key is the source of the 32-byte key used for new writes.
+	Key AuthKeySource `yaml:"key" json:"key"`
+	// PreviousKeys are only used to decrypt files written with retired keys.
+	PreviousKeys []AuthKeySource `yaml:"previous-keys,omitempty" json:"previous-keys,omitempty"`
+}
+
+// RequestLogRedactionConfig holds the rules applied to request logs before they are persisted.
+// The Authorization, X-Api-Key and X-Goog-Api-Key headers are always redacted unless
+// DisableDefaults is set.
+type RequestLogRedactionConfig struct {
+	// DisableDefaults turns off the built-in header rules.
+	DisableDefaults bool `yaml:"disable-defaults,omitempty" json:"disable-defaults,omitempty"`
+	// Headers lists additional header names whose values are redacted, matched case-insensitively.
+	Headers []string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// JSONPaths lists gjson paths redacted in JSON bodies and SSE data lines.
+	// A "#" segment matches every element of an array, e.g. "messages.#.content".
+	JSONPaths []string `yaml:"json-paths,omitempty" json:"json-paths,omitempty"`
+	// Patterns lists regular expressions redacted anywhere in the log. A pattern with only a
+	// name selects a built-in rule: email, api-key or credit-card.
+	Patterns []RedactionPattern `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+}
+
+// RedactionPattern is a named regular expression used by request log redaction.
+type RedactionPattern struct {
+	Name  string `yaml:"name" json:"name"`
+	Regex string `yaml:"regex,omitempty" json:"regex,omitempty"`
+}
+
+// AuditLogConfig holds management API audit log settings.
+type AuditLogConfig struct {
+	// Enable records every mutating management request with a redacted before/after diff.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Dir overrides the directory holding audit.jsonl.
+	// When empty, an "audit" directory next to the logs or auth-dir is used.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Postgres mirrors entries to the PostgreSQL token store when it is in use, so every
+	// instance sharing the database sees the same audit trail.
+	Postgres bool `yaml:"postgres,omitempty" json:"postgres,omitempty"`
+}
+
+// ConfigVersionsConfig holds settings for the config version history.
+type ConfigVersionsConfig struct {
+	// Disable stops recording config versions. History is kept by default.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+	// Dir overrides the directory holding versions when the token store cannot keep them.
+	// When empty, a "config-versions" directory next to the logs or the config file is used.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// MaxVersions caps the number of versions kept. Default is 20.
+	MaxVersions int `yaml:"max-versions,omitempty" json:"max-versions,omitempty"`
+}
+
+// CacheSnapshotConfig holds settings for the session-affinity and signature cache snapshots.
+type CacheSnapshotConfig struct {
+	// Disable stops saving and restoring snapshots. Snapshots are kept by default.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+	// Dir overrides the directory holding snapshots when the token store cannot keep them.
+	// When empty, a "cache-snapshots" directory next to the logs or the config file is used.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// IntervalSeconds is how often snapshots are saved while running. Default is 300.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+}
+
+// HealthProbeConfig holds settings for the background credential prober.
+type HealthProbeConfig struct {
+	// Enable turns on periodic probing.
+	Enable bool `yaml:"enable" json:"enable"`
+	// IntervalSeconds is the pause between probe rounds. Default is 900.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+	// TimeoutSeconds bounds a single probe. Default is 30.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+	// Concurrency caps the probes running at once. Default is 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	// Models pins the models probed per provider. Providers without an entry probe one
+	// registered model per model family.
+	Models map[string][]string `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// SanitizeHealthProbe applies defaults and normalizes the pinned probe models.
+func (cfg *Config) SanitizeHealthProbe() {
+	if cfg == nil {
+		return
+	}
+	probe := &cfg.HealthProbe
+	if probe.IntervalSeconds <= 0 {
+		probe.IntervalSeconds = 900
+	}
+	if probe.TimeoutSeconds <= 0 {
+		probe.TimeoutSeconds = 30
+	}
+	if probe.Concurrency <= 0 {
+		probe.Concurrency = 4
+	}
+	if len(probe.Models) == 0 {
+		probe.Models = nil
+		return
+	}
+	models := make(map[string][]string, len(probe.Models))
+	for provider, list := range probe.Models {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider == "" {
+			continue
+		}
+		for _, model := range list {
+			if model = strings.TrimSpace(model); model != "" && !slices.Contains(models[provider], model) {
+				models[provider] = append(models[provider], model)
+			}
+		}
+	}
+	if len(models) == 0 {
+		models = nil
+	}
+	probe.Models = models
+}
+
+// AuthKeySource locates an auth encryption key. Exactly one field should be set; the key is
+// 32 bytes encoded as base64 or hex.
+type AuthKeySource struct {
+	// Env names an environment variable holding the key.
+	Env string `yaml:"env,omitempty" json:"env,omitempty"`
+	// File is a path to a file holding the key.
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+	// Command is run through the shell and its standard output is used as the key,
+	// e.g. a KMS or secret manager CLI.
+	Command string `yaml:"command,omitempty" json:"command,omitempty"`
+}
+
+// Normalize returns the source with surrounding whitespace removed.
+func (s AuthKeySource) Normalize() AuthKeySource {
+	return AuthKeySource{
+		Env:     strings.TrimSpace(s.Env),
+		File:    strings.TrimSpace(s.File),
+		Command: strings.TrimSpace(s.Command),
+	}
+}
+
+// IsZero reports whether no key source is configured.
+func (s AuthKeySource) IsZero() bool {
+	return s.Env == "" && s.File == "" && s.Command == ""
+}
+
+// TracingConfig holds OpenTelemetry tracing settings.
+type TracingConfig struct {
+	// Enable toggles span recording and export.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Endpoint is the OTLP/HTTP collector URL, e.g. "http://localhost:4318".
+	// "/v1/traces" is appended when the URL has no such path.
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+	// Headers are sent with every export request, e.g. collector authentication.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// SampleRatio is the share of new traces recorded, in (0, 1]. Default is 1.
+	// Requests carrying a traceparent header follow the caller's sampling decision.
+	SampleRatio float64 `yaml:"sample-ratio,omitempty" json:"sample-ratio,omitempty"`
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string `yaml:"service-name,omitempty" json:"service-name,omitempty"`
+	// PropagateUpstream sends traceparent to upstream providers that accept it.
+	PropagateUpstream bool `yaml:"propagate-upstream" json:"propagate-upstream"`
+}
+
+// SanitizeTracing trims tracing settings and clamps the sample ratio.
+func (cfg *Config) SanitizeTracing() {
+	if cfg == nil {
+		return
+	}
+	cfg.Tracing.Endpoint = strings.TrimSpace(cfg.Tracing.Endpoint)
+	cfg.Tracing.ServiceName = strings.TrimSpace(cfg.Tracing.ServiceName)
+	if cfg.Tracing.SampleRatio <= 0 || cfg.Tracing.SampleRatio > 1 {
+		cfg.Tracing.SampleRatio = 1
+	}
+	if cfg.Tracing.Enable && cfg.Tracing.Endpoint == "" {
+		log.Warn("tracing.enable is true but tracing.endpoint is empty; tracing disabled")
+		cfg.Tracing.Enable = false
+	}
+}
+
+// SharedStateConfig holds settings for the state shared between proxy instances.
+type SharedStateConfig struct {
+	// Enable publishes cooldown and quota changes to the backend and applies the ones
+	// published by other instances.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Addr is the backend address: host:port, redis://host:port/db or rediss://host:port/db.
+	// Any server speaking the Redis protocol (RESP2) can be used.
+	Addr string `yaml:"addr,omitempty" json:"addr,omitempty"`
+	// Password authenticates to the backend. It overrides a password given in Addr.
+	Password string `yaml:"password,omitempty" json:"-"`
+	// KeyPrefix namespaces every key and channel, so unrelated clusters can share a server.
+	// Default is "cliproxy:".
+	KeyPrefix string `yaml:"key-prefix,omitempty" json:"key-prefix,omitempty"`
+}
+
+// SanitizeSharedState trims shared-state settings and disables the backend when no address is set.
+func (cfg *Config) SanitizeSharedState() {
+	if cfg == nil {
+		return
+	}
+	cfg.SharedState.Addr = strings.TrimSpace(cfg.SharedState.Addr)
+	cfg.SharedState.KeyPrefix = strings.TrimSpace(cfg.SharedState.KeyPrefix)
+	if cfg.SharedState.Enable && cfg.SharedState.Addr == "" {
+		log.Warn("shared-state.enable is true but shared-state.addr is empty; shared state disabled")
+		cfg.SharedState.Enable = false
+	}
+}
+
 // RemoteManagement holds management API configuration under 'remote-management'.
 type RemoteManagement struct {
 	// AllowRemote toggles remote (non-localhost) access to management API.
@@ -204,6 +528,111 @@ type RemoteManagement struct {
 	// PanelGitHubRepository overrides the GitHub repository used to fetch the management panel asset.
 	// Accepts either a repository URL (https://github.com/org/repo) or an API releases endpoint.
 	PanelGitHubRepository string `yaml:"panel-github-repository"`
+	// Tokens are named management credentials limited to a set of scopes. The secret key keeps
+	// full access.
+	Tokens []ManagementToken `yaml:"tokens,omitempty"`
+}
+
+// Management token scopes.
+const (
+	// ManagementScopeAdmin grants every management route.
+	ManagementScopeAdmin = "admin"
+	// ManagementScopeReadOnly grants GET on routes that do not reveal secrets, such as usage,
+	// auth file listings and plain settings.
+	ManagementScopeReadOnly = "read-only"
+	// ManagementScopeAuthFiles grants auth file management, downloads and OAuth logins.
+	ManagementScopeAuthFiles = "auth-files"
+	// ManagementScopeKeys grants client API keys and provider key lists.
+	ManagementScopeKeys = "keys"
+	// ManagementScopeConfig grants the full config, config.yaml, versions and all settings.
+	ManagementScopeConfig = "config"
+	// ManagementScopeLogs grants the server and request logs.
+	ManagementScopeLogs = "logs"
+)
+
+// ManagementScopes lists the supported management token scopes.
+var ManagementScopes = []string{
+	ManagementScopeAdmin,
+	ManagementScopeReadOnly,
+	ManagementScopeAuthFiles,
+	ManagementScopeKeys,
+	ManagementScopeConfig,
+	ManagementScopeLogs,
+}
+
+// ManagementToken is a named management credential.
+type ManagementToken struct {
+	// Name identifies the token in the audit log and the session endpoint.
+	Name string `yaml:"name"`
+	// Key is the token value, plaintext or bcrypt hashed. Unlike secret-key it is not hashed
+	// on startup, so prefer storing a bcrypt hash.
+	Key string `yaml:"key"`
+	// Scopes lists the granted scopes, see ManagementScopes.
+	Scopes []string `yaml:"scopes"`
+	// ExpiresAt is an optional RFC3339 timestamp after which the token is rejected.
+	ExpiresAt string `yaml:"expires-at,omitempty"`
+}
+
+// Expiry parses ExpiresAt. The zero time means the token never expires.
+func (t ManagementToken) Expiry() (time.Time, error) {
+	if t.ExpiresAt == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, t.ExpiresAt)
+}
+
+// HasCredentials reports whether a secret key or any management token is configured.
+func (r RemoteManagement) HasCredentials() bool {
+	return r.SecretKey != "" || len(r.Tokens) > 0
+}
+
+// sanitizeManagementTokens trims token fields and normalizes scopes.
+func (cfg *Config) sanitizeManagementTokens() {
+	for i := range cfg.RemoteManagement.Tokens {
+		token := &cfg.RemoteManagement.Tokens[i]
+		token.Name = strings.TrimSpace(token.Name)
+		token.Key = strings.TrimSpace(token.Key)
+		token.ExpiresAt = strings.TrimSpace(token.ExpiresAt)
+		scopes := make([]string, 0, len(token.Scopes))
+		for _, scope := range token.Scopes {
+			scope = strings.ToLower(strings.TrimSpace(scope))
+			if scope != "" && !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+		token.Scopes = scopes
+	}
+}
+
+// RedactionBuiltinPatterns names the patterns usable without a regex in request-log-redaction.
+var RedactionBuiltinPatterns = []string{"email", "api-key", "credit-card"}
+
+// sanitizeRequestLogRedaction trims redaction rules and drops empty entries.
+func (cfg *Config) sanitizeRequestLogRedaction() {
+	redaction := &cfg.RequestLogRedaction
+	headers := make([]string, 0, len(redaction.Headers))
+	for _, header := range redaction.Headers {
+		if header = strings.TrimSpace(header); header != "" {
+			headers = append(headers, header)
+		}
+	}
+	redaction.Headers = headers
+	paths := make([]string, 0, len(redaction.JSONPaths))
+	for _, path := range redaction.JSONPaths {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	redaction.JSONPaths = paths
+	patterns := make([]RedactionPattern, 0, len(redaction.Patterns))
+	for _, pattern := range redaction.Patterns {
+		pattern.Name = strings.ToLower(strings.TrimSpace(pattern.Name))
+		if pattern.Name == "" && pattern.Regex == "" {
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	redaction.Patterns = patterns
 }
 
 // QuotaExceeded defines the behavior when API quota limits are exceeded.
@@ -224,7 +653,8 @@ type QuotaExceeded struct {
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
-	// Supported values: "round-robin" (default), "fill-first".
+	// Supported values: "round-robin" (default), "fill-first", "weighted" (share by the
+	// credential "weight"), "least-latency" (lowest rolling latency, penalized by failures).
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
 
 	// SessionAffinity enables universal session-sticky routing for all clients.
@@ -238,6 +668,33 @@ type RoutingConfig struct {
 	// SessionAffinityTTL specifies how long session-to-auth bindings are retained.
 	// Default: 1h. Accepts duration strings like "30m", "1h", "2h30m".
 	SessionAffinityTTL string `yaml:"session-affinity-ttl,omitempty" json:"session-affinity-ttl,omitempty"`
+
+	// ConcurrencyQueue controls how requests wait when every matching credential is at its
+	// max-concurrent limit.
+	ConcurrencyQueue ConcurrencyQueueConfig `yaml:"concurrency-queue,omitempty" json:"concurrency-queue,omitempty"`
+
+	// QuotaAvoidPercent skips credentials whose latest upstream quota reading shows a window
+	// at least this percent used, as long as another credential is available.
+	// Default: 95. A negative value turns the check off.
+	QuotaAvoidPercent int `yaml:"quota-avoid-percent,omitempty" json:"quota-avoid-percent,omitempty"`
+}
+
+// ConcurrencyQueueConfig bounds the queue of requests waiting for a credential below its
+// max-concurrent limit. Waiters are served first-in first-out per client API key, round-robin
+// across clients.
+type ConcurrencyQueueConfig struct {
+	// Disabled fails requests immediately instead of queueing them.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	// MaxWaiting caps the requests waiting at once. Default: 256.
+	MaxWaiting int `yaml:"max-waiting,omitempty" json:"max-waiting,omitempty"`
+
+	// MaxWaitingPerClient caps the waiting requests of a single client API key; 0 means no
+	// per-client cap.
+	MaxWaitingPerClient int `yaml:"max-waiting-per-client,omitempty" json:"max-waiting-per-client,omitempty"`
+
+	// TimeoutSeconds is how long a request waits before failing with 429. Default: 30.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
 }
 
 // OAuthModelAlias defines a model ID alias for a specific channel.
@@ -250,6 +707,25 @@ type OAuthModelAlias struct {
 	Fork  bool   `yaml:"fork,omitempty" json:"fork,omitempty"`
 }
 
+// ModelFallback maps a client-visible alias to an ordered chain of upstream hops.
+type ModelFallback struct {
+	// Alias is the model name clients request.
+	Alias string `yaml:"alias" json:"alias"`
+	// Chain lists the hops tried in order.
+	Chain []ModelFallbackHop `yaml:"chain" json:"chain"`
+}
+
+// ModelFallbackHop is a single provider/model target within a fallback chain.
+type ModelFallbackHop struct {
+	// Provider is the provider key, e.g. "claude", "antigravity", "gemini-cli"
+	// or the name of an openai-compatibility provider.
+	Provider string `yaml:"provider" json:"provider"`
+	// Model is the upstream model requested from the provider.
+	Model string `yaml:"model" json:"model"`
+	// AuthKind restricts the hop to "oauth" or "apikey" credentials. Empty allows both.
+	AuthKind string `yaml:"auth-kind,omitempty" json:"auth-kind,omitempty"`
+}
+
 // AmpModelMapping defines a model name mapping for Amp CLI requests.
 // When Amp requests a model that isn't available locally, this mapping
 // allows routing to an alternative model that IS available.
@@ -391,6 +867,12 @@ type ClaudeKey struct {
 	// Higher values are preferred; defaults to 0.
 	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
 
+	// Weight sets the traffic share under the "weighted" routing strategy. Defaults to 1.
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// MaxConcurrent caps the requests in flight on this credential; 0 means unlimited.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/claude-sonnet-4").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
@@ -447,6 +929,12 @@ type CodexKey struct {
 	// Higher values are preferred; defaults to 0.
 	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
 
+	// Weight sets the traffic share under the "weighted" routing strategy. Defaults to 1.
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// MaxConcurrent caps the requests in flight on this credential; 0 means unlimited.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gpt-5-codex").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
@@ -498,6 +986,12 @@ type GeminiKey struct {
 	// Higher values are preferred; defaults to 0.
 	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
 
+	// Weight sets the traffic share under the "weighted" routing strategy. Defaults to 1.
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// MaxConcurrent caps the requests in flight on this credential; 0 means unlimited.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gemini-3-pro-preview").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
@@ -545,6 +1039,12 @@ type OpenAICompatibility struct {
 	// Higher values are preferred; defaults to 0.
 	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
 
+	// Weight sets the traffic share under the "weighted" routing strategy. Defaults to 1.
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// MaxConcurrent caps the requests in flight on this credential; 0 means unlimited.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
 	// Disabled prevents this provider from being used for routing.
 	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
 
@@ -709,6 +1209,77 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		cfg.RedisUsageQueueRetentionSeconds = 3600
 	}
 
+	cfg.UsageLedger.Dir = strings.TrimSpace(cfg.UsageLedger.Dir)
+	if cfg.UsageLedger.RetentionDays < 0 {
+		cfg.UsageLedger.RetentionDays = 0
+	}
+
+	cfg.ResponseCache.Backend = strings.ToLower(strings.TrimSpace(cfg.ResponseCache.Backend))
+	if cfg.ResponseCache.Backend != "disk" {
+		cfg.ResponseCache.Backend = "memory"
+	}
+	cfg.ResponseCache.Dir = strings.TrimSpace(cfg.ResponseCache.Dir)
+	if cfg.ResponseCache.MaxEntries <= 0 {
+		cfg.ResponseCache.MaxEntries = 1000
+	}
+	if cfg.ResponseCache.TTLSeconds <= 0 {
+		cfg.ResponseCache.TTLSeconds = 3600
+	}
+
+	cfg.ResponsesStore.Backend = strings.ToLower(strings.TrimSpace(cfg.ResponsesStore.Backend))
+	if cfg.ResponsesStore.Backend != "disk" {
+		cfg.ResponsesStore.Backend = "memory"
+	}
+	cfg.ResponsesStore.Dir = strings.TrimSpace(cfg.ResponsesStore.Dir)
+	if cfg.ResponsesStore.MaxEntries <= 0 {
+		cfg.ResponsesStore.MaxEntries = 10000
+	}
+	if cfg.ResponsesStore.TTLSeconds <= 0 {
+		cfg.ResponsesStore.TTLSeconds = 30 * 24 * 3600
+	}
+
+	cfg.Batch.Dir = strings.TrimSpace(cfg.Batch.Dir)
+	if cfg.Batch.Concurrency <= 0 {
+		cfg.Batch.Concurrency = 4
+	}
+	if cfg.Batch.MaxFileSizeMB <= 0 {
+		cfg.Batch.MaxFileSizeMB = 200
+	}
+
+	cfg.AuthEncryption.Key = cfg.AuthEncryption.Key.Normalize()
+	previousKeys := cfg.AuthEncryption.PreviousKeys[:0]
+	for _, source := range cfg.AuthEncryption.PreviousKeys {
+		if source = source.Normalize(); !source.IsZero() {
+			previousKeys = append(previousKeys, source)
+		}
+	}
+	cfg.AuthEncryption.PreviousKeys = previousKeys
+
+	cfg.AuditLog.Dir = strings.TrimSpace(cfg.AuditLog.Dir)
+
+	cfg.sanitizeManagementTokens()
+
+	cfg.sanitizeRequestLogRedaction()
+
+	cfg.RequestLogFormat = strings.ToLower(strings.TrimSpace(cfg.RequestLogFormat))
+	if cfg.RequestLogRetentionDays <= 0 {
+		cfg.RequestLogRetentionDays = 7
+	}
+
+	cfg.ConfigVersions.Dir = strings.TrimSpace(cfg.ConfigVersions.Dir)
+	if cfg.ConfigVersions.MaxVersions <= 0 {
+		cfg.ConfigVersions.MaxVersions = 20
+	}
+
+	cfg.CacheSnapshot.Dir = strings.TrimSpace(cfg.CacheSnapshot.Dir)
+	if cfg.CacheSnapshot.IntervalSeconds <= 0 {
+		cfg.CacheSnapshot.IntervalSeconds = 300
+	}
+
+	cfg.SanitizeTracing()
+	cfg.SanitizeSharedState()
+	cfg.SanitizeHealthProbe()
+
 	if cfg.MaxRetryCredentials < 0 {
 		cfg.MaxRetryCredentials = 0
 	}
@@ -740,9 +1311,16 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Normalize global OAuth model name aliases.
 	cfg.SanitizeOAuthModelAlias()
 
+	// Normalize cross-provider model fallback chains.
+	cfg.SanitizeModelFallbacks()
+
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Normalize per-client API key policies.
+	cfg.SanitizeAPIKeyPolicies()
+	cfg.SanitizeAccessProviders()
+
 	// NOTE: Legacy migration persistence is intentionally disabled together with
 	// startup legacy migration to keep startup read-only for config.yaml.
 	// Re-enable the block below if automatic startup migration is needed again.
@@ -879,6 +1457,50 @@ func (cfg *Config) SanitizeOAuthModelAlias() {
 	cfg.OAuthModelAlias = out
 }
 
+// SanitizeModelFallbacks normalizes model fallback chains. It lower-cases provider keys,
+// maps auth kinds to "oauth" or "apikey", drops incomplete hops and empty chains,
+// and keeps only the first chain declared for an alias.
+func (cfg *Config) SanitizeModelFallbacks() {
+	if cfg == nil || len(cfg.ModelFallbacks) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.ModelFallbacks))
+	out := make([]ModelFallback, 0, len(cfg.ModelFallbacks))
+	for _, fallback := range cfg.ModelFallbacks {
+		alias := strings.TrimSpace(fallback.Alias)
+		if alias == "" {
+			continue
+		}
+		key := strings.ToLower(alias)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		chain := make([]ModelFallbackHop, 0, len(fallback.Chain))
+		for _, hop := range fallback.Chain {
+			provider := strings.ToLower(strings.TrimSpace(hop.Provider))
+			model := strings.TrimSpace(hop.Model)
+			if provider == "" || model == "" {
+				continue
+			}
+			authKind := strings.ToLower(strings.TrimSpace(hop.AuthKind))
+			switch authKind {
+			case "api-key", "api_key", "apikey":
+				authKind = "apikey"
+			case "oauth":
+			default:
+				authKind = ""
+			}
+			chain = append(chain, ModelFallbackHop{Provider: provider, Model: model, AuthKind: authKind})
+		}
+		if len(chain) == 0 {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, ModelFallback{Alias: alias, Chain: chain})
+	}
+	cfg.ModelFallbacks = out
+}
+
 // SanitizeOpenAICompatibility removes OpenAI-compatibility provider entries that are
 // not actionable, specifically those missing a BaseURL. It trims whitespace before
 // evaluation and preserves the relative order of remaining entries.
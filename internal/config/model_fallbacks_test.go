@@ -0,0 +1,38 @@
+package config
+
+import "testing"
+
+func TestSanitizeModelFallbacks_NormalizesHopsAndDropsDuplicates(t *testing.T) {
+	cfg := &Config{
+		ModelFallbacks: []ModelFallback{
+			{Alias: " smart ", Chain: []ModelFallbackHop{
+				{Provider: " Claude ", Model: " claude-sonnet-4-5 ", AuthKind: "OAuth"},
+				{Provider: "claude", Model: "claude-sonnet-4-5", AuthKind: "api-key"},
+				{Provider: "", Model: "missing-provider"},
+				{Provider: "antigravity", Model: "claude-sonnet-4-5", AuthKind: "unknown"},
+			}},
+			{Alias: "SMART", Chain: []ModelFallbackHop{{Provider: "codex", Model: "gpt-5"}}},
+			{Alias: "empty", Chain: []ModelFallbackHop{{Provider: "codex"}}},
+		},
+	}
+
+	cfg.SanitizeModelFallbacks()
+
+	if len(cfg.ModelFallbacks) != 1 {
+		t.Fatalf("expected 1 fallback, got %+v", cfg.ModelFallbacks)
+	}
+	fallback := cfg.ModelFallbacks[0]
+	if fallback.Alias != "smart" || len(fallback.Chain) != 3 {
+		t.Fatalf("unexpected fallback: %+v", fallback)
+	}
+	want := []ModelFallbackHop{
+		{Provider: "claude", Model: "claude-sonnet-4-5", AuthKind: "oauth"},
+		{Provider: "claude", Model: "claude-sonnet-4-5", AuthKind: "apikey"},
+		{Provider: "antigravity", Model: "claude-sonnet-4-5"},
+	}
+	for i := range want {
+		if fallback.Chain[i] != want[i] {
+			t.Fatalf("hop %d = %+v, want %+v", i, fallback.Chain[i], want[i])
+		}
+	}
+}
@@ -69,6 +69,77 @@ func ParseConfigBytes(data []byte) (*Config, error) {
 		cfg.RedisUsageQueueRetentionSeconds = 3600
 	}
 
+	cfg.UsageLedger.Dir = strings.TrimSpace(cfg.UsageLedger.Dir)
+	if cfg.UsageLedger.RetentionDays < 0 {
+		cfg.UsageLedger.RetentionDays = 0
+	}
+
+	cfg.ResponseCache.Backend = strings.ToLower(strings.TrimSpace(cfg.ResponseCache.Backend))
+	if cfg.ResponseCache.Backend != "disk" {
+		cfg.ResponseCache.Backend = "memory"
+	}
+	cfg.ResponseCache.Dir = strings.TrimSpace(cfg.ResponseCache.Dir)
+	if cfg.ResponseCache.MaxEntries <= 0 {
+		cfg.ResponseCache.MaxEntries = 1000
+	}
+	if cfg.ResponseCache.TTLSeconds <= 0 {
+		cfg.ResponseCache.TTLSeconds = 3600
+	}
+
+	cfg.ResponsesStore.Backend = strings.ToLower(strings.TrimSpace(cfg.ResponsesStore.Backend))
+	if cfg.ResponsesStore.Backend != "disk" {
+		cfg.ResponsesStore.Backend = "memory"
+	}
+	cfg.ResponsesStore.Dir = strings.TrimSpace(cfg.ResponsesStore.Dir)
+	if cfg.ResponsesStore.MaxEntries <= 0 {
+		cfg.ResponsesStore.MaxEntries = 10000
+	}
+	if cfg.ResponsesStore.TTLSeconds <= 0 {
+		cfg.ResponsesStore.TTLSeconds = 30 * 24 * 3600
+	}
+
+	cfg.Batch.Dir = strings.TrimSpace(cfg.Batch.Dir)
+	if cfg.Batch.Concurrency <= 0 {
+		cfg.Batch.Concurrency = 4
+	}
+	if cfg.Batch.MaxFileSizeMB <= 0 {
+		cfg.Batch.MaxFileSizeMB = 200
+	}
+
+	cfg.AuthEncryption.Key = cfg.AuthEncryption.Key.Normalize()
+	previousKeys := cfg.AuthEncryption.PreviousKeys[:0]
+	for _, source := range cfg.AuthEncryption.PreviousKeys {
+		if source = source.Normalize(); !source.IsZero() {
+			previousKeys = append(previousKeys, source)
+		}
+	}
+	cfg.AuthEncryption.PreviousKeys = previousKeys
+
+	cfg.AuditLog.Dir = strings.TrimSpace(cfg.AuditLog.Dir)
+
+	cfg.sanitizeManagementTokens()
+
+	cfg.sanitizeRequestLogRedaction()
+
+	cfg.RequestLogFormat = strings.ToLower(strings.TrimSpace(cfg.RequestLogFormat))
+	if cfg.RequestLogRetentionDays <= 0 {
+		cfg.RequestLogRetentionDays = 7
+	}
+
+	cfg.ConfigVersions.Dir = strings.TrimSpace(cfg.ConfigVersions.Dir)
+	if cfg.ConfigVersions.MaxVersions <= 0 {
+		cfg.ConfigVersions.MaxVersions = 20
+	}
+
+	cfg.CacheSnapshot.Dir = strings.TrimSpace(cfg.CacheSnapshot.Dir)
+	if cfg.CacheSnapshot.IntervalSeconds <= 0 {
+		cfg.CacheSnapshot.IntervalSeconds = 300
+	}
+
+	cfg.SanitizeTracing()
+	cfg.SanitizeSharedState()
+	cfg.SanitizeHealthProbe()
+
 	if cfg.MaxRetryCredentials < 0 {
 		cfg.MaxRetryCredentials = 0
 	}
@@ -83,7 +154,10 @@ func ParseConfigBytes(data []byte) (*Config, error) {
 	cfg.SanitizeOpenAICompatibility()
 	cfg.OAuthExcludedModels = NormalizeOAuthExcludedModels(cfg.OAuthExcludedModels)
 	cfg.SanitizeOAuthModelAlias()
+	cfg.SanitizeModelFallbacks()
 	cfg.SanitizePayloadRules()
+	cfg.SanitizeAPIKeyPolicies()
+	cfg.SanitizeAccessProviders()
 
 	return &cfg, nil
 }
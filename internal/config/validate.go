@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Validate reports semantic problems that parsing alone does not catch, such as settings that
+// would otherwise be silently ignored or only fail once the server reloads the file.
+func (cfg *Config) Validate() error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+	var errs []error
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port %d is out of range", cfg.Port))
+	}
+	if cfg.TLS.Enable && (strings.TrimSpace(cfg.TLS.Cert) == "" || strings.TrimSpace(cfg.TLS.Key) == "") {
+		errs = append(errs, fmt.Errorf("tls.enable requires tls.cert and tls.key"))
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Routing.Strategy)) {
+	case "", "round-robin", "roundrobin", "rr",
+		"fill-first", "fillfirst", "ff",
+		"weighted", "weighted-round-robin", "wrr",
+		"least-latency", "leastlatency", "latency":
+	default:
+		errs = append(errs, fmt.Errorf("routing.strategy %q is not supported", cfg.Routing.Strategy))
+	}
+	if ttl := strings.TrimSpace(cfg.Routing.SessionAffinityTTL); ttl != "" {
+		if _, err := time.ParseDuration(ttl); err != nil {
+			errs = append(errs, fmt.Errorf("routing.session-affinity-ttl: %w", err))
+		}
+	}
+	queue := cfg.Routing.ConcurrencyQueue
+	if queue.MaxWaiting < 0 || queue.MaxWaitingPerClient < 0 || queue.TimeoutSeconds < 0 {
+		errs = append(errs, fmt.Errorf("routing.concurrency-queue values must not be negative"))
+	}
+	if cfg.Routing.QuotaAvoidPercent > 100 {
+		errs = append(errs, fmt.Errorf("routing.quota-avoid-percent must not exceed 100"))
+	}
+	errs = append(errs, validateMaxConcurrent(cfg)...)
+	if cfg.AuthEncryption.Enable && cfg.AuthEncryption.Key.IsZero() {
+		errs = append(errs, fmt.Errorf("auth-encryption.enable requires auth-encryption.key"))
+	}
+	names := make(map[string]struct{}, len(cfg.RemoteManagement.Tokens))
+	for i, token := range cfg.RemoteManagement.Tokens {
+		label := fmt.Sprintf("remote-management.tokens[%d]", i)
+		if token.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: name is required", label))
+		} else if _, dup := names[token.Name]; dup {
+			errs = append(errs, fmt.Errorf("%s: duplicate name %q", label, token.Name))
+		}
+		names[token.Name] = struct{}{}
+		if token.Key == "" {
+			errs = append(errs, fmt.Errorf("%s: key is required", label))
+		}
+		if len(token.Scopes) == 0 {
+			errs = append(errs, fmt.Errorf("%s: at least one scope is required", label))
+		}
+		for _, scope := range token.Scopes {
+			if !slices.Contains(ManagementScopes, scope) {
+				errs = append(errs, fmt.Errorf("%s: unknown scope %q", label, scope))
+			}
+		}
+		if _, err := token.Expiry(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: expires-at must be RFC3339: %w", label, err))
+		}
+	}
+	switch cfg.RequestLogFormat {
+	case "", "text", "jsonl":
+	default:
+		errs = append(errs, fmt.Errorf("request-log-format %q must be text or jsonl", cfg.RequestLogFormat))
+	}
+	for i, pattern := range cfg.RequestLogRedaction.Patterns {
+		label := fmt.Sprintf("request-log-redaction.patterns[%d]", i)
+		if pattern.Regex == "" {
+			if !slices.Contains(RedactionBuiltinPatterns, pattern.Name) {
+				errs = append(errs, fmt.Errorf("%s: %q is not a built-in pattern and has no regex", label, pattern.Name))
+			}
+			continue
+		}
+		if _, err := regexp.Compile(pattern.Regex); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", label, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateConfigBytes parses a YAML payload and runs the semantic checks, returning the parsed
+// config when both pass.
+func ValidateConfigBytes(data []byte) (*Config, error) {
+	cfg, err := ParseConfigBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validateMaxConcurrent rejects negative max-concurrent limits on credential entries.
+func validateMaxConcurrent(cfg *Config) []error {
+	var errs []error
+	check := func(label string, value int) {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s: max-concurrent must not be negative", label))
+		}
+	}
+	for i, key := range cfg.GeminiKey {
+		check(fmt.Sprintf("gemini-api-key[%d]", i), key.MaxConcurrent)
+	}
+	for i, key := range cfg.ClaudeKey {
+		check(fmt.Sprintf("claude-api-key[%d]", i), key.MaxConcurrent)
+	}
+	for i, key := range cfg.CodexKey {
+		check(fmt.Sprintf("codex-api-key[%d]", i), key.MaxConcurrent)
+	}
+	for i, key := range cfg.VertexCompatAPIKey {
+		check(fmt.Sprintf("vertex-api-key[%d]", i), key.MaxConcurrent)
+	}
+	for i, compat := range cfg.OpenAICompatibility {
+		check(fmt.Sprintf("openai-compatibility[%d]", i), compat.MaxConcurrent)
+	}
+	return errs
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigBytesRejectsSemanticErrors(t *testing.T) {
+	if _, err := ValidateConfigBytes([]byte("port: 8317\nrouting:\n  strategy: fill-first\n")); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+
+	_, err := ValidateConfigBytes([]byte("port: 70000\ntls:\n  enable: true\nrouting:\n  strategy: random\n"))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"port 70000", "tls.enable", "routing.strategy"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+
+	if _, err = ValidateConfigBytes([]byte("port: [")); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestValidateManagementTokens(t *testing.T) {
+	valid := "remote-management:\n  tokens:\n    - name: ops\n      key: ops-key\n      scopes: [Read-Only, logs]\n      expires-at: 2030-01-01T00:00:00Z\n"
+	cfg, err := ValidateConfigBytes([]byte(valid))
+	if err != nil {
+		t.Fatalf("valid tokens rejected: %v", err)
+	}
+	if got := cfg.RemoteManagement.Tokens[0].Scopes; len(got) != 2 || got[0] != ManagementScopeReadOnly {
+		t.Fatalf("scopes not normalized: %v", got)
+	}
+
+	invalid := "remote-management:\n  tokens:\n    - name: ops\n      key: a\n      scopes: [everything]\n    - name: ops\n      scopes: [logs]\n      expires-at: tomorrow\n"
+	_, err = ValidateConfigBytes([]byte(invalid))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"everything", "duplicate", "key", "expires-at"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+}
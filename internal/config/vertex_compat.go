@@ -17,6 +17,12 @@ type VertexCompatKey struct {
 	// Higher values are preferred; defaults to 0.
 	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
 
+	// Weight sets the traffic share under the "weighted" routing strategy. Defaults to 1.
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// MaxConcurrent caps the requests in flight on this credential; 0 means unlimited.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
 	// Prefix optionally namespaces model aliases for this credential (e.g., "teamA/vertex-pro").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
@@ -0,0 +1,152 @@
+// Package configversion keeps a bounded history of config file versions so a bad edit made
+// through the management API can be inspected and rolled back.
+package configversion
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Sources recorded on versions.
+const (
+	// SourceSnapshot marks the file content found on disk before a write, e.g. after a manual edit.
+	SourceSnapshot = "snapshot"
+	// SourceManagement marks writes made by the individual management setters.
+	SourceManagement = "management"
+	// SourceConfigYAML marks full replacements through PUT /config.yaml.
+	SourceConfigYAML = "config-yaml"
+	// SourceRollback marks restores through the rollback endpoint.
+	SourceRollback = "rollback"
+)
+
+// ErrNotFound is returned when a version does not exist.
+var ErrNotFound = errors.New("config version not found")
+
+// Version is one recorded config file.
+type Version struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Source    string    `json:"source"`
+	// Note carries extra context, such as the version a rollback restored.
+	Note   string `json:"note,omitempty"`
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+	// Content is the raw YAML. List results leave it empty.
+	Content []byte `json:"-"`
+}
+
+// Backend stores config versions. IDs are assigned by the backend and increase monotonically.
+type Backend interface {
+	SaveConfigVersion(ctx context.Context, version Version) (Version, error)
+	// ListConfigVersions returns version metadata, newest first.
+	ListConfigVersions(ctx context.Context) ([]Version, error)
+	GetConfigVersion(ctx context.Context, id int64) (Version, error)
+	// PruneConfigVersions drops all but the newest keep versions.
+	PruneConfigVersions(ctx context.Context, keep int) error
+}
+
+// History records versions into the configured backend.
+type History struct {
+	mu          sync.Mutex
+	backend     Backend
+	maxVersions int
+}
+
+var defaultHistory = &History{}
+
+// Default returns the process-wide config history.
+func Default() *History { return defaultHistory }
+
+// Configure swaps the backend and the number of versions kept. A nil backend disables the
+// history. The previous backend is returned so callers can release it.
+func (h *History) Configure(backend Backend, maxVersions int) Backend {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	previous := h.backend
+	h.backend = backend
+	h.maxVersions = maxVersions
+	return previous
+}
+
+// Backend returns the active backend or nil when the history is disabled.
+func (h *History) Backend() Backend {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.backend
+}
+
+// Enabled reports whether versions are recorded.
+func (h *History) Enabled() bool { return h.Backend() != nil }
+
+// Record stores content as a new version unless it matches the newest one. It reports the
+// newest version and whether a new one was written.
+func (h *History) Record(ctx context.Context, content []byte, source, note string) (Version, bool, error) {
+	if h == nil {
+		return Version{}, false, nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.backend == nil || len(content) == 0 {
+		return Version{}, false, nil
+	}
+
+	sum := Checksum(content)
+	versions, err := h.backend.ListConfigVersions(ctx)
+	if err != nil {
+		return Version{}, false, err
+	}
+	if len(versions) > 0 && versions[0].SHA256 == sum {
+		return versions[0], false, nil
+	}
+	saved, err := h.backend.SaveConfigVersion(ctx, Version{
+		CreatedAt: time.Now().UTC(),
+		Source:    source,
+		Note:      note,
+		SHA256:    sum,
+		Size:      len(content),
+		Content:   append([]byte(nil), content...),
+	})
+	if err != nil {
+		return Version{}, false, err
+	}
+	if h.maxVersions > 0 {
+		if err = h.backend.PruneConfigVersions(ctx, h.maxVersions); err != nil {
+			return saved, true, err
+		}
+	}
+	return saved, true, nil
+}
+
+// List returns version metadata, newest first.
+func (h *History) List(ctx context.Context) ([]Version, error) {
+	backend := h.Backend()
+	if backend == nil {
+		return nil, nil
+	}
+	return backend.ListConfigVersions(ctx)
+}
+
+// Get returns a version with its content.
+func (h *History) Get(ctx context.Context, id int64) (Version, error) {
+	backend := h.Backend()
+	if backend == nil {
+		return Version{}, ErrNotFound
+	}
+	return backend.GetConfigVersion(ctx, id)
+}
+
+// Checksum returns the hex SHA-256 of content.
+func Checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,48 @@
+package configversion
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHistoryRecordDeduplicatesAndPrunes(t *testing.T) {
+	backend, err := NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileBackend: %v", err)
+	}
+	history := &History{}
+	history.Configure(backend, 2)
+	ctx := context.Background()
+
+	first, created, err := history.Record(ctx, []byte("port: 1\n"), SourceSnapshot, "")
+	if err != nil || !created {
+		t.Fatalf("Record first: created=%t err=%v", created, err)
+	}
+	if _, created, err = history.Record(ctx, []byte("port: 1\n"), SourceManagement, ""); err != nil || created {
+		t.Fatalf("identical content should not create a version: created=%t err=%v", created, err)
+	}
+	for _, content := range []string{"port: 2\n", "port: 3\n"} {
+		if _, _, err = history.Record(ctx, []byte(content), SourceManagement, ""); err != nil {
+			t.Fatalf("Record %q: %v", content, err)
+		}
+	}
+
+	versions, err := history.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(versions) != 2 || versions[0].ID != 3 || versions[1].ID != 2 {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+	if versions[0].Content != nil || versions[0].Size != len("port: 3\n") {
+		t.Fatalf("list should carry metadata only: %+v", versions[0])
+	}
+	if _, err = history.Get(ctx, first.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("pruned version should be gone, got %v", err)
+	}
+	latest, err := history.Get(ctx, 3)
+	if err != nil || string(latest.Content) != "port: 3\n" {
+		t.Fatalf("Get latest: %+v %v", latest, err)
+	}
+}
@@ -0,0 +1,184 @@
+package configversion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+const fileSuffix = ".json"
+
+// fileRecord is the on-disk form of a version.
+type fileRecord struct {
+	Version
+	Content string `json:"content"`
+}
+
+// FileBackend keeps each version in its own JSON file named after the version ID.
+type FileBackend struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileBackend creates dir when needed and returns a backend storing versions in it.
+func NewFileBackend(dir string) (*FileBackend, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("config versions: directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("config versions: create directory: %w", err)
+	}
+	return &FileBackend{dir: dir}, nil
+}
+
+// Dir returns the directory holding the versions.
+func (b *FileBackend) Dir() string { return b.dir }
+
+// SaveConfigVersion writes version under the next free ID.
+func (b *FileBackend) SaveConfigVersion(_ context.Context, version Version) (Version, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ids, err := b.ids()
+	if err != nil {
+		return Version{}, err
+	}
+	version.ID = 1
+	if len(ids) > 0 {
+		version.ID = ids[len(ids)-1] + 1
+	}
+	data, err := json.Marshal(fileRecord{Version: version, Content: string(version.Content)})
+	if err != nil {
+		return Version{}, fmt.Errorf("config versions: encode version: %w", err)
+	}
+	path := b.path(version.ID)
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return Version{}, fmt.Errorf("config versions: write version: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return Version{}, fmt.Errorf("config versions: write version: %w", err)
+	}
+	return version, nil
+}
+
+// ListConfigVersions returns version metadata, newest first.
+func (b *FileBackend) ListConfigVersions(_ context.Context) ([]Version, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ids, err := b.ids()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Version, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		version, errRead := b.read(ids[i])
+		if errRead != nil {
+			continue
+		}
+		version.Content = nil
+		out = append(out, version)
+	}
+	return out, nil
+}
+
+// GetConfigVersion loads one version with its content.
+func (b *FileBackend) GetConfigVersion(_ context.Context, id int64) (Version, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.read(id)
+}
+
+// PruneConfigVersions removes all but the newest keep versions.
+func (b *FileBackend) PruneConfigVersions(_ context.Context, keep int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ids, err := b.ids()
+	if err != nil {
+		return err
+	}
+	if keep < 0 || len(ids) <= keep {
+		return nil
+	}
+	for _, id := range ids[:len(ids)-keep] {
+		if errRemove := os.Remove(b.path(id)); errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+			return fmt.Errorf("config versions: remove version %d: %w", id, errRemove)
+		}
+	}
+	return nil
+}
+
+func (b *FileBackend) read(id int64) (Version, error) {
+	data, err := os.ReadFile(b.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return Version{}, ErrNotFound
+	}
+	if err != nil {
+		return Version{}, fmt.Errorf("config versions: read version %d: %w", id, err)
+	}
+	var record fileRecord
+	if err = json.Unmarshal(data, &record); err != nil {
+		return Version{}, fmt.Errorf("config versions: decode version %d: %w", id, err)
+	}
+	version := record.Version
+	version.Content = []byte(record.Content)
+	return version, nil
+}
+
+// ids returns the stored version IDs in ascending order.
+func (b *FileBackend) ids() ([]int64, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, fmt.Errorf("config versions: read directory: %w", err)
+	}
+	var ids []int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		id, errParse := strconv.ParseInt(strings.TrimSuffix(name, fileSuffix), 10, 64)
+		if errParse != nil || id <= 0 {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (b *FileBackend) path(id int64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%010d%s", id, fileSuffix))
+}
+
+// ResolveDirectory returns the file-backed history directory for cfg and the config file path.
+// It returns "" when neither a directory, a writable path nor a config path is known, so history
+// is not written to the working directory.
+func ResolveDirectory(cfg *config.Config, configPath string) string {
+	if cfg != nil {
+		if dir := strings.TrimSpace(cfg.ConfigVersions.Dir); dir != "" {
+			if resolved, err := util.ResolveAuthDir(dir); err == nil && resolved != "" {
+				return resolved
+			}
+			return dir
+		}
+	}
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "config-versions")
+	}
+	if configPath = strings.TrimSpace(configPath); configPath != "" {
+		return filepath.Join(filepath.Dir(configPath), "config-versions")
+	}
+	return ""
+}
@@ -24,4 +24,13 @@ const (
 
 	// Antigravity represents the Antigravity response format identifier.
 	Antigravity = "antigravity"
+
+	// OpenAIEmbedding represents the OpenAI embeddings request format identifier.
+	OpenAIEmbedding = "openai-embedding"
+
+	// GeminiEmbedding represents the Gemini batchEmbedContents request format identifier.
+	GeminiEmbedding = "gemini-embedding"
+
+	// OpenAIAudio represents the OpenAI audio (transcriptions, translations, speech) request format identifier.
+	OpenAIAudio = "openai-audio"
 )
@@ -0,0 +1,313 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+const redactedHeaderValue = "[REDACTED]"
+
+// defaultRedactedHeaders are redacted unless request-log-redaction.disable-defaults is set.
+var defaultRedactedHeaders = []string{"Authorization", "X-Api-Key", "X-Goog-Api-Key"}
+
+var builtinRedactionPatterns = map[string]struct {
+	expr  string
+	valid func(string) bool
+}{
+	"email":       {expr: `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`},
+	"api-key":     {expr: `\b(?:sk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}|AIza[0-9A-Za-z_-]{35}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abposr]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16})\b`},
+	"credit-card": {expr: `\b(?:\d[ -]?){12,18}\d\b`, valid: luhnValid},
+}
+
+// Redactor masks secrets and personal data in request log content. A nil Redactor leaves
+// content unchanged.
+type Redactor struct {
+	headers  map[string]struct{}
+	paths    []string
+	patterns []redactionPattern
+}
+
+type redactionPattern struct {
+	name  string
+	re    *regexp.Regexp
+	valid func(string) bool
+}
+
+// RedactionFinding describes one value a Redactor replaced. Findings are only collected in
+// test mode and never include the original value.
+type RedactionFinding struct {
+	// Kind is "header", "json-path" or "pattern".
+	Kind string `json:"kind"`
+	// Rule is the header name, gjson path or pattern name that matched.
+	Rule string `json:"rule"`
+	// Location is where the value was found, e.g. "headers" or "body line 3".
+	Location string `json:"location"`
+	// Fingerprint is a short hash of the original value so repeated values can be correlated.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// NewRedactor builds a Redactor from the request-log-redaction settings.
+func NewRedactor(cfg config.RequestLogRedactionConfig) (*Redactor, error) {
+	r := &Redactor{headers: make(map[string]struct{})}
+	if !cfg.DisableDefaults {
+		for _, header := range defaultRedactedHeaders {
+			r.headers[strings.ToLower(header)] = struct{}{}
+		}
+	}
+	for _, header := range cfg.Headers {
+		if header = strings.TrimSpace(header); header != "" {
+			r.headers[strings.ToLower(header)] = struct{}{}
+		}
+	}
+	for _, path := range cfg.JSONPaths {
+		if path = strings.TrimSpace(path); path != "" {
+			r.paths = append(r.paths, path)
+		}
+	}
+	for _, pattern := range cfg.Patterns {
+		name := strings.ToLower(strings.TrimSpace(pattern.Name))
+		if pattern.Regex == "" {
+			builtin, ok := builtinRedactionPatterns[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown built-in redaction pattern %q", pattern.Name)
+			}
+			r.patterns = append(r.patterns, redactionPattern{name: name, re: regexp.MustCompile(builtin.expr), valid: builtin.valid})
+			continue
+		}
+		re, err := regexp.Compile(pattern.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q: %w", pattern.Name, err)
+		}
+		if name == "" {
+			name = "custom"
+		}
+		r.patterns = append(r.patterns, redactionPattern{name: name, re: re})
+	}
+	return r, nil
+}
+
+// DefaultRedactor returns a Redactor with only the built-in header rules.
+func DefaultRedactor() *Redactor {
+	r, _ := NewRedactor(config.RequestLogRedactionConfig{})
+	return r
+}
+
+// RedactHeaders returns a copy of headers with configured header values replaced and
+// patterns applied to the remaining values.
+func (r *Redactor) RedactHeaders(headers map[string][]string) map[string][]string {
+	return r.redactHeaders(headers, nil)
+}
+
+// Redact returns payload with JSON paths and patterns applied. A payload that is a single
+// JSON document is redacted as a whole; anything else, such as SSE streams or the formatted
+// upstream request sections, is redacted line by line, which also covers "Name: value"
+// header lines.
+func (r *Redactor) Redact(payload []byte) []byte {
+	return r.redact(payload, "body", nil)
+}
+
+// Test applies the redactor to sample headers and body and reports every replacement,
+// so rules can be checked before they are enabled.
+func (r *Redactor) Test(headers map[string][]string, body []byte) (map[string][]string, []byte, []RedactionFinding) {
+	findings := make([]RedactionFinding, 0)
+	redactedHeaders := r.redactHeaders(headers, &findings)
+	redactedBody := r.redact(body, "body", &findings)
+	return redactedHeaders, redactedBody, findings
+}
+
+func (r *Redactor) redactHeaders(headers map[string][]string, findings *[]RedactionFinding) map[string][]string {
+	if r == nil || headers == nil {
+		return headers
+	}
+	out := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		copied := make([]string, len(values))
+		_, redactKey := r.headers[strings.ToLower(strings.TrimSpace(key))]
+		for i, value := range values {
+			if redactKey {
+				recordFinding(findings, "header", key, "headers", value)
+				copied[i] = redactedHeaderValue
+				continue
+			}
+			copied[i] = string(r.applyPatterns([]byte(value), "header "+key, findings))
+		}
+		out[key] = copied
+	}
+	return out
+}
+
+func (r *Redactor) redact(payload []byte, location string, findings *[]RedactionFinding) []byte {
+	if r == nil || len(payload) == 0 || (len(r.headers) == 0 && len(r.paths) == 0 && len(r.patterns) == 0) {
+		return payload
+	}
+	if trimmed := bytes.TrimSpace(payload); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && gjson.ValidBytes(trimmed) {
+		out := r.applyPaths(bytes.Clone(payload), location, findings)
+		return r.applyPatterns(out, location, findings)
+	}
+	var out bytes.Buffer
+	out.Grow(len(payload))
+	lineNo := 0
+	for len(payload) > 0 {
+		lineNo++
+		line := payload
+		rest := []byte(nil)
+		if idx := bytes.IndexByte(payload, '\n'); idx >= 0 {
+			line, rest = payload[:idx+1], payload[idx+1:]
+		}
+		out.Write(r.redactLine(line, fmt.Sprintf("%s line %d", location, lineNo), findings))
+		payload = rest
+	}
+	return out.Bytes()
+}
+
+// redactLine handles one line of a text payload, keeping its line ending.
+func (r *Redactor) redactLine(line []byte, location string, findings *[]RedactionFinding) []byte {
+	content := bytes.TrimRight(line, "\r\n")
+	ending := line[len(content):]
+
+	if name, _, ok := bytes.Cut(content, []byte(": ")); ok && len(name) > 0 && !bytes.ContainsAny(name, " \t{[\"") {
+		if _, redactKey := r.headers[strings.ToLower(string(name))]; redactKey {
+			recordFinding(findings, "header", string(name), location, string(content[len(name)+2:]))
+			out := make([]byte, 0, len(name)+2+len(redactedHeaderValue)+len(ending))
+			out = append(out, name...)
+			out = append(out, ": "+redactedHeaderValue...)
+			return append(out, ending...)
+		}
+	}
+
+	prefix, document := []byte(nil), content
+	if after, ok := bytes.CutPrefix(content, []byte("data:")); ok {
+		prefix, document = content[:len(content)-len(after)], after
+	}
+	if trimmed := bytes.TrimSpace(document); len(r.paths) > 0 && len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && gjson.ValidBytes(trimmed) {
+		content = append(bytes.Clone(prefix), r.applyPaths(bytes.Clone(document), location, findings)...)
+	}
+	content = r.applyPatterns(content, location, findings)
+	return append(bytes.Clone(content), ending...)
+}
+
+func (r *Redactor) applyPaths(document []byte, location string, findings *[]RedactionFinding) []byte {
+	for _, path := range r.paths {
+		for _, concrete := range expandJSONPath(document, path) {
+			value := gjson.GetBytes(document, concrete)
+			if !value.Exists() || value.Type == gjson.Null {
+				continue
+			}
+			updated, err := sjson.SetBytes(document, concrete, redactionPlaceholder(path))
+			if err != nil {
+				continue
+			}
+			recordFinding(findings, "json-path", path, location+" "+concrete, value.Raw)
+			document = updated
+		}
+	}
+	return document
+}
+
+func (r *Redactor) applyPatterns(payload []byte, location string, findings *[]RedactionFinding) []byte {
+	for _, pattern := range r.patterns {
+		payload = pattern.re.ReplaceAllFunc(payload, func(match []byte) []byte {
+			if pattern.valid != nil && !pattern.valid(string(match)) {
+				return match
+			}
+			recordFinding(findings, "pattern", pattern.name, location, string(match))
+			return []byte(redactionPlaceholder(pattern.name))
+		})
+	}
+	return payload
+}
+
+// expandJSONPath resolves "#" array segments into concrete element paths so sjson can set
+// them; other gjson path syntax is passed through unchanged.
+func expandJSONPath(document []byte, path string) []string {
+	head, tail, found := strings.Cut(path, ".#")
+	if !found || (tail != "" && tail[0] != '.') {
+		return []string{path}
+	}
+	count := gjson.GetBytes(document, head+".#").Int()
+	paths := make([]string, 0, count)
+	for i := int64(0); i < count; i++ {
+		paths = append(paths, expandJSONPath(document, head+"."+strconv.FormatInt(i, 10)+tail)...)
+	}
+	return paths
+}
+
+func redactionPlaceholder(rule string) string {
+	return "[REDACTED:" + rule + "]"
+}
+
+func recordFinding(findings *[]RedactionFinding, kind, rule, location, value string) {
+	if findings == nil {
+		return
+	}
+	sum := sha256.Sum256([]byte(value))
+	*findings = append(*findings, RedactionFinding{
+		Kind:        kind,
+		Rule:        rule,
+		Location:    location,
+		Fingerprint: hex.EncodeToString(sum[:4]),
+	})
+}
+
+// luhnValid reports whether the digits in value pass the Luhn checksum, which keeps the
+// credit-card pattern from redacting arbitrary long numbers such as timestamps.
+func luhnValid(value string) bool {
+	sum, digits := 0, 0
+	for i := len(value) - 1; i >= 0; i-- {
+		c := value[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// newRedactingReader streams src through the redactor line by line, so spooled response
+// bodies can be redacted without loading them into memory. Callers must close the reader.
+func newRedactingReader(r *Redactor, src io.Reader) io.ReadCloser {
+	if r == nil {
+		return io.NopCloser(src)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		reader := bufio.NewReader(src)
+		lineNo := 0
+		for {
+			line, errRead := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				lineNo++
+				if _, errWrite := pw.Write(r.redactLine(line, fmt.Sprintf("response line %d", lineNo), nil)); errWrite != nil {
+					return
+				}
+			}
+			if errRead != nil {
+				if errRead == io.EOF {
+					errRead = nil
+				}
+				pw.CloseWithError(errRead)
+				return
+			}
+		}
+	}()
+	return pr
+}
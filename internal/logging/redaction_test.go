@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestRedactorAppliesHeaderPathAndPatternRules(t *testing.T) {
+	redactor, err := NewRedactor(config.RequestLogRedactionConfig{
+		Headers:   []string{"Cookie"},
+		JSONPaths: []string{"messages.#.content", "metadata.user_id"},
+		Patterns: []config.RedactionPattern{
+			{Name: "email"},
+			{Name: "credit-card"},
+			{Name: "employee-id", Regex: `EMP-[0-9]{6}`},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRedactor: %v", err)
+	}
+
+	headers := redactor.RedactHeaders(map[string][]string{
+		"X-Goog-Api-Key": {"AIza-secret"},
+		"Cookie":         {"session=abc"},
+		"X-Trace":        {"owner jane@example.com"},
+	})
+	if headers["X-Goog-Api-Key"][0] != redactedHeaderValue || headers["Cookie"][0] != redactedHeaderValue {
+		t.Fatalf("headers not redacted: %v", headers)
+	}
+	if headers["X-Trace"][0] != "owner [REDACTED:email]" {
+		t.Fatalf("pattern not applied to header value: %v", headers["X-Trace"])
+	}
+
+	body := redactor.Redact([]byte(`{"metadata":{"user_id":"u-1"},"messages":[{"role":"user","content":"card 4111 1111 1111 1111"},{"role":"user","content":"hi"}],"ts":1234567890123}`))
+	for _, leaked := range []string{"u-1", "4111", `"hi"`} {
+		if strings.Contains(string(body), leaked) {
+			t.Fatalf("body still contains %q: %s", leaked, body)
+		}
+	}
+	if !strings.Contains(string(body), "1234567890123") {
+		t.Fatalf("credit-card pattern redacted a number failing the Luhn check: %s", body)
+	}
+
+	text := redactor.Redact([]byte("Headers:\nAuthorization: Bearer sk-upstream\nBody:\nEMP-123456\ndata: {\"metadata\":{\"user_id\":\"u-2\"}}\n"))
+	for _, leaked := range []string{"sk-upstream", "EMP-123456", "u-2"} {
+		if strings.Contains(string(text), leaked) {
+			t.Fatalf("text still contains %q: %s", leaked, text)
+		}
+	}
+	if !strings.HasPrefix(string(text), "Headers:\nAuthorization: [REDACTED]\n") {
+		t.Fatalf("unexpected text layout: %q", text)
+	}
+
+	_, _, findings := redactor.Test(map[string][]string{"Authorization": {"Bearer x"}}, []byte(`{"metadata":{"user_id":"u-3"}}`))
+	if len(findings) != 2 || findings[0].Kind != "header" || findings[1].Rule != "metadata.user_id" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+
+	if _, err = NewRedactor(config.RequestLogRedactionConfig{Patterns: []config.RedactionPattern{{Name: "phone"}}}); err == nil {
+		t.Fatal("expected error for unknown built-in pattern")
+	}
+}
+
+func TestFileStreamingLogWriterRedactsSpooledResponse(t *testing.T) {
+	logsDir := t.TempDir()
+	logger := NewFileRequestLogger(true, logsDir, "", 0)
+	if err := logger.SetRedaction(config.RequestLogRedactionConfig{Patterns: []config.RedactionPattern{{Name: "email"}}}); err != nil {
+		t.Fatalf("SetRedaction: %v", err)
+	}
+
+	writer, err := logger.LogStreamingRequest("/v1/chat/completions", http.MethodPost, map[string][]string{"X-Api-Key": {"client-key"}}, []byte(`{"user":"a@example.com"}`), "req-stream")
+	if err != nil {
+		t.Fatalf("LogStreamingRequest: %v", err)
+	}
+	writer.WriteChunkAsync([]byte("data: {\"text\":\"mail b@example.com\"}\n\n"))
+	if err = writer.WriteStatus(http.StatusOK, map[string][]string{"Content-Type": {"text/event-stream"}}); err != nil {
+		t.Fatalf("WriteStatus: %v", err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(logsDir, "*req-stream.log"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one log file, got %v", matches)
+	}
+	content, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	for _, leaked := range []string{"client-key", "a@example.com", "b@example.com"} {
+		if strings.Contains(string(content), leaked) {
+			t.Fatalf("log still contains %q:\n%s", leaked, content)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CapturedRequest is an inbound request reconstructed from a request log, together with
+// the upstream traffic and the response it produced.
+type CapturedRequest struct {
+	ID          string              `json:"id"`
+	Method      string              `json:"method"`
+	URL         string              `json:"url"`
+	Headers     map[string][]string `json:"headers,omitempty"`
+	Body        []byte              `json:"-"`
+	APIRequest  []byte              `json:"-"`
+	APIResponse []byte              `json:"-"`
+	Status      int                 `json:"status,omitempty"`
+	Response    []byte              `json:"-"`
+}
+
+// LoadCapturedRequest reads the request logged under requestID from a text log file or,
+// failing that, from the structured (JSONL) log. Structured entries do not keep the
+// inbound headers, so Headers is empty for them.
+func LoadCapturedRequest(logsDir, requestID string) (*CapturedRequest, error) {
+	requestID = strings.TrimSpace(requestID)
+	if requestID == "" || strings.ContainsAny(requestID, "/\\") {
+		return nil, ErrRequestLogNotFound
+	}
+	entries, err := os.ReadDir(logsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	suffix := "-" + requestID + ".log"
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), suffix) {
+			continue
+		}
+		data, errRead := os.ReadFile(filepath.Join(logsDir, entry.Name()))
+		if errRead != nil {
+			return nil, errRead
+		}
+		captured := ParseTextRequestLog(data)
+		captured.ID = requestID
+		return captured, nil
+	}
+
+	logged, err := FindRequestLog(logsDir, requestID)
+	if err != nil {
+		return nil, err
+	}
+	captured := &CapturedRequest{ID: logged.ID, Method: logged.Method, URL: logged.URL, Status: logged.Status}
+	for name, target := range map[string]*[]byte{
+		RequestLogBodyRequest:     &captured.Body,
+		RequestLogBodyResponse:    &captured.Response,
+		RequestLogBodyAPIRequest:  &captured.APIRequest,
+		RequestLogBodyAPIResponse: &captured.APIResponse,
+	} {
+		ref, ok := logged.Bodies[name]
+		if !ok {
+			continue
+		}
+		content, errRead := ReadRequestLogBody(logsDir, ref)
+		if errRead != nil && !errors.Is(errRead, os.ErrNotExist) {
+			return nil, errRead
+		}
+		*target = content
+	}
+	return captured, nil
+}
+
+// ParseTextRequestLog splits a text request log back into its sections. Numbered upstream
+// sections ("=== API REQUEST 2 ===") are kept together with their section.
+func ParseTextRequestLog(data []byte) *CapturedRequest {
+	captured := &CapturedRequest{Headers: make(map[string][]string)}
+	sections := make(map[string]*bytes.Buffer)
+	current := ""
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if name, ok := textLogSectionName(line); ok && (current != "RESPONSE" || name == "RESPONSE") {
+			current = name
+			if sections[current] == nil {
+				sections[current] = &bytes.Buffer{}
+			}
+			if current == "API REQUEST" || current == "API RESPONSE" {
+				sections[current].Write(line)
+			}
+			continue
+		}
+		if current != "" {
+			sections[current].Write(line)
+		}
+	}
+
+	if info := sections["REQUEST INFO"]; info != nil {
+		for _, line := range strings.Split(info.String(), "\n") {
+			if value, ok := strings.CutPrefix(line, "URL: "); ok {
+				captured.URL = value
+			} else if value, ok = strings.CutPrefix(line, "Method: "); ok {
+				captured.Method = value
+			}
+		}
+	}
+	if headers := sections["HEADERS"]; headers != nil {
+		for _, line := range strings.Split(headers.String(), "\n") {
+			key, value, ok := strings.Cut(line, ": ")
+			if !ok || strings.TrimSpace(key) == "" {
+				continue
+			}
+			captured.Headers[key] = append(captured.Headers[key], value)
+		}
+	}
+	if body := sections["REQUEST BODY"]; body != nil {
+		captured.Body = bytes.TrimSuffix(bytes.TrimSuffix(body.Bytes(), []byte("\n")), []byte("\n"))
+	}
+	if apiRequest := sections["API REQUEST"]; apiRequest != nil {
+		captured.APIRequest = bytes.TrimRight(apiRequest.Bytes(), "\n")
+	}
+	if apiResponse := sections["API RESPONSE"]; apiResponse != nil {
+		captured.APIResponse = bytes.TrimRight(apiResponse.Bytes(), "\n")
+	}
+	if response := sections["RESPONSE"]; response != nil {
+		head, body, _ := bytes.Cut(response.Bytes(), []byte("\n\n"))
+		for _, line := range strings.Split(string(head), "\n") {
+			if value, ok := strings.CutPrefix(line, "Status: "); ok {
+				captured.Status, _ = strconv.Atoi(strings.TrimSpace(value))
+			}
+		}
+		captured.Response = bytes.TrimRight(body, "\n")
+	}
+	return captured
+}
+
+// textLogSectionName reports the section a "=== NAME ===" header line opens, with any
+// attempt number removed.
+func textLogSectionName(line []byte) (string, bool) {
+	trimmed := strings.TrimRight(string(line), "\r\n")
+	if !strings.HasPrefix(trimmed, "=== ") || !strings.HasSuffix(trimmed, " ===") || len(trimmed) < 8 {
+		return "", false
+	}
+	name := strings.TrimSpace(trimmed[4 : len(trimmed)-4])
+	if idx := strings.LastIndexByte(name, ' '); idx > 0 {
+		if _, err := strconv.Atoi(name[idx+1:]); err == nil {
+			name = name[:idx]
+		}
+	}
+	switch name {
+	case "REQUEST INFO", "HEADERS", "REQUEST BODY", "WEBSOCKET TIMELINE", "API WEBSOCKET TIMELINE",
+		"API REQUEST", "API ERROR RESPONSE", "API RESPONSE", "RESPONSE":
+		return name, true
+	}
+	return "", false
+}
+
+// ReplayCapture collects the upstream traffic of a replayed request. It rides on the
+// request context, which only in-process callers can set, and also marks the request as a
+// replay for the client authentication middleware.
+type ReplayCapture struct {
+	mu          sync.Mutex
+	requestID   string
+	apiRequest  []byte
+	apiResponse []byte
+}
+
+type replayCaptureContextKey struct{}
+
+// WithReplayCapture returns a child context carrying capture.
+func WithReplayCapture(ctx context.Context, capture *ReplayCapture) context.Context {
+	return context.WithValue(ctx, replayCaptureContextKey{}, capture)
+}
+
+// ReplayCaptureFrom returns the capture attached to ctx, or nil when ctx is not a replay.
+func ReplayCaptureFrom(ctx context.Context) *ReplayCapture {
+	if ctx == nil {
+		return nil
+	}
+	capture, _ := ctx.Value(replayCaptureContextKey{}).(*ReplayCapture)
+	return capture
+}
+
+// Collect copies the upstream request and response recorded on c.
+func (r *ReplayCapture) Collect(c *gin.Context) {
+	if r == nil || c == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requestID = GetGinRequestID(c)
+	if value, ok := c.Get("API_REQUEST"); ok {
+		if data, isBytes := value.([]byte); isBytes {
+			r.apiRequest = bytes.Clone(data)
+		}
+	}
+	if value, ok := c.Get("API_RESPONSE"); ok {
+		if data, isBytes := value.([]byte); isBytes {
+			r.apiResponse = bytes.Clone(data)
+		}
+	}
+}
+
+// Result returns the request ID of the replay and the upstream traffic it produced.
+func (r *ReplayCapture) Result() (requestID string, apiRequest, apiResponse []byte) {
+	if r == nil {
+		return "", nil, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requestID, bytes.TrimRight(r.apiRequest, "\n"), bytes.TrimRight(r.apiResponse, "\n")
+}
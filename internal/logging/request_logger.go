@@ -10,6 +10,7 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -25,6 +26,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/home"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
@@ -162,6 +164,15 @@ type FileRequestLogger struct {
 	errorLogsMaxFiles int
 
 	homeEnabled bool
+
+	// redactor masks secrets and personal data before anything is written or forwarded.
+	redactor atomic.Pointer[Redactor]
+
+	// jsonl switches regular request logs to the structured JSONL format.
+	jsonl atomic.Bool
+
+	// structured appends structured entries and prunes expired days.
+	structured structuredRequestLog
 }
 
 type homeRequestLogPayload struct {
@@ -233,12 +244,14 @@ func NewFileRequestLogger(enabled bool, logsDir string, configDir string, errorL
 			logsDir = filepath.Join(configDir, logsDir)
 		}
 	}
-	return &FileRequestLogger{
+	logger := &FileRequestLogger{
 		enabled:           enabled,
 		logsDir:           logsDir,
 		errorLogsMaxFiles: errorLogsMaxFiles,
 		homeEnabled:       false,
 	}
+	logger.redactor.Store(DefaultRedactor())
+	return logger
 }
 
 // SetHomeEnabled toggles home request-log forwarding.
@@ -272,6 +285,59 @@ func (l *FileRequestLogger) SetErrorLogsMaxFiles(maxFiles int) {
 	l.errorLogsMaxFiles = maxFiles
 }
 
+// SetFormat selects the request log format, RequestLogFormatText or RequestLogFormatJSONL.
+// retentionDays limits how many days of JSONL logs are kept; zero keeps them all.
+// Forced error logs and logs forwarded to home always use the text format.
+func (l *FileRequestLogger) SetFormat(format string, retentionDays int) {
+	l.jsonl.Store(strings.EqualFold(strings.TrimSpace(format), RequestLogFormatJSONL))
+	l.structured.mu.Lock()
+	l.structured.retentionDays = retentionDays
+	l.structured.prunedDay = ""
+	l.structured.mu.Unlock()
+}
+
+// LogsDir returns the directory request logs are written to.
+func (l *FileRequestLogger) LogsDir() string {
+	return l.logsDir
+}
+
+// SetRedaction replaces the redaction rules applied to request logs. On error the previous
+// rules stay in effect.
+func (l *FileRequestLogger) SetRedaction(cfg config.RequestLogRedactionConfig) error {
+	redactor, err := NewRedactor(cfg)
+	if err != nil {
+		return err
+	}
+	l.redactor.Store(redactor)
+	return nil
+}
+
+// Redactor returns the redaction rules currently applied to request logs.
+func (l *FileRequestLogger) Redactor() *Redactor {
+	if l == nil {
+		return nil
+	}
+	return l.redactor.Load()
+}
+
+// redactErrorMessages returns copies of errs whose error text has been redacted.
+func redactErrorMessages(redactor *Redactor, errs []*interfaces.ErrorMessage) []*interfaces.ErrorMessage {
+	if redactor == nil || len(errs) == 0 {
+		return errs
+	}
+	out := make([]*interfaces.ErrorMessage, 0, len(errs))
+	for _, msg := range errs {
+		if msg == nil || msg.Error == nil {
+			out = append(out, msg)
+			continue
+		}
+		copied := *msg
+		copied.Error = errors.New(string(redactor.Redact([]byte(msg.Error.Error()))))
+		out = append(out, &copied)
+	}
+	return out
+}
+
 // LogRequest logs a complete non-streaming request/response cycle to a file.
 //
 // Parameters:
@@ -291,25 +357,42 @@ func (l *FileRequestLogger) SetErrorLogsMaxFiles(maxFiles int) {
 // Returns:
 //   - error: An error if logging fails, nil otherwise
 func (l *FileRequestLogger) LogRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, websocketTimeline, apiRequest, apiResponse, apiWebsocketTimeline []byte, apiResponseErrors []*interfaces.ErrorMessage, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
-	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, websocketTimeline, apiRequest, apiResponse, apiWebsocketTimeline, apiResponseErrors, false, requestID, requestTimestamp, apiResponseTimestamp)
+	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, websocketTimeline, apiRequest, apiResponse, apiWebsocketTimeline, apiResponseErrors, false, requestID, requestTimestamp, apiResponseTimestamp, nil)
 }
 
 // LogRequestWithOptions logs a request with optional forced logging behavior.
 // The force flag allows writing error logs even when regular request logging is disabled.
 func (l *FileRequestLogger) LogRequestWithOptions(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, websocketTimeline, apiRequest, apiResponse, apiWebsocketTimeline []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
-	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, websocketTimeline, apiRequest, apiResponse, apiWebsocketTimeline, apiResponseErrors, force, requestID, requestTimestamp, apiResponseTimestamp)
+	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, websocketTimeline, apiRequest, apiResponse, apiWebsocketTimeline, apiResponseErrors, force, requestID, requestTimestamp, apiResponseTimestamp, nil)
+}
+
+// LogRequestWithMeta is LogRequestWithOptions with the routing details recorded by the
+// structured format.
+func (l *FileRequestLogger) LogRequestWithMeta(meta RequestLogMeta, url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, websocketTimeline, apiRequest, apiResponse, apiWebsocketTimeline []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
+	return l.logRequest(url, method, requestHeaders, body, statusCode, responseHeaders, response, websocketTimeline, apiRequest, apiResponse, apiWebsocketTimeline, apiResponseErrors, force, requestID, requestTimestamp, apiResponseTimestamp, &meta)
 }
 
-func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, websocketTimeline, apiRequest, apiResponse, apiWebsocketTimeline []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time) error {
+func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, websocketTimeline, apiRequest, apiResponse, apiWebsocketTimeline []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string, requestTimestamp, apiResponseTimestamp time.Time, meta *RequestLogMeta) error {
 	if !l.enabled && !force {
 		return nil
 	}
 
+	redactor := l.Redactor()
+	requestHeaders = redactor.RedactHeaders(requestHeaders)
+	body = redactor.Redact(body)
+	responseHeaders = redactor.RedactHeaders(responseHeaders)
+	websocketTimeline = redactor.Redact(websocketTimeline)
+	apiRequest = redactor.Redact(apiRequest)
+	apiResponse = redactor.Redact(apiResponse)
+	apiWebsocketTimeline = redactor.Redact(apiWebsocketTimeline)
+	apiResponseErrors = redactErrorMessages(redactor, apiResponseErrors)
+
 	if l.homeEnabled && l.enabled {
 		responseToWrite, decompressErr := l.decompressResponse(responseHeaders, response)
 		if decompressErr != nil {
 			responseToWrite = response
 		}
+		responseToWrite = redactor.Redact(responseToWrite)
 
 		var buf bytes.Buffer
 		writeErr := l.writeNonStreamingLog(
@@ -342,6 +425,35 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 		return fmt.Errorf("failed to create logs directory: %w", errEnsure)
 	}
 
+	if l.enabled && l.jsonl.Load() {
+		responseToWrite, decompressErr := l.decompressResponse(responseHeaders, response)
+		if decompressErr != nil {
+			responseToWrite = response
+		}
+		var upstreamErrors []int
+		for _, msg := range apiResponseErrors {
+			if msg != nil {
+				upstreamErrors = append(upstreamErrors, msg.StatusCode)
+			}
+		}
+		if meta != nil && meta.FirstByteAt.IsZero() {
+			meta.FirstByteAt = apiResponseTimestamp
+		}
+		entry := newRequestLogEntry(requestID, url, method, statusCode, requestTimestamp, meta, upstreamErrors)
+		sections := []structuredSection{
+			{name: RequestLogBodyRequest, data: body},
+			{name: RequestLogBodyWebsocketTimeline, data: websocketTimeline},
+			{name: RequestLogBodyAPIRequest, data: apiRequest},
+			{name: RequestLogBodyAPIResponse, data: apiResponse},
+			{name: RequestLogBodyAPIWebsocketTimeline, data: apiWebsocketTimeline},
+			{name: RequestLogBodyResponse, data: redactor.Redact(responseToWrite)},
+		}
+		if errWrite := l.structured.write(l.logsDir, entry, sections, nil); errWrite != nil {
+			return fmt.Errorf("failed to write structured request log: %w", errWrite)
+		}
+		return nil
+	}
+
 	// Generate filename with request ID
 	filename := l.generateFilename(url, requestID)
 	if force && !l.enabled {
@@ -366,6 +478,7 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 		// If decompression fails, continue with original response and annotate the log output.
 		responseToWrite = response
 	}
+	responseToWrite = redactor.Redact(responseToWrite)
 
 	logFile, errOpen := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if errOpen != nil {
@@ -427,12 +540,18 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 		return &NoOpStreamingLogWriter{}, nil
 	}
 
+	redactor := l.Redactor()
+	headers = redactor.RedactHeaders(headers)
+	body = redactor.Redact(body)
+
 	if l.homeEnabled {
 		client := home.Current()
 		if client == nil || !client.HeartbeatOK() {
 			return &NoOpStreamingLogWriter{}, nil
 		}
-		return newHomeStreamingLogWriter(url, method, headers, body, requestID), nil
+		writer := newHomeStreamingLogWriter(url, method, headers, body, requestID)
+		writer.redactor = redactor
+		return writer, nil
 	}
 
 	// Ensure logs directory exists
@@ -465,6 +584,7 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 
 	// Create streaming writer
 	writer := &FileStreamingLogWriter{
+		requestID:        requestID,
 		logFilePath:      filePath,
 		url:              url,
 		method:           method,
@@ -473,11 +593,17 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 		requestBodyPath:  requestBodyPath,
 		responseBodyPath: responseBodyPath,
 		responseBodyFile: responseBodyFile,
+		redactor:         redactor,
 		chunkChan:        make(chan []byte, 100), // Buffered channel for async writes
 		closeChan:        make(chan struct{}),
 		errorChan:        make(chan error, 1),
 	}
 
+	if l.jsonl.Load() {
+		writer.structured = &l.structured
+		writer.logsDir = l.logsDir
+	}
+
 	// Start async writer goroutine
 	go writer.asyncWriter()
 
@@ -737,7 +863,7 @@ func writeRequestInfoWithBody(
 	}
 	for key, values := range headers {
 		for _, value := range values {
-			masked := util.MaskSensitiveHeaderValue(key, value)
+			masked := maskHeaderValue(key, value)
 			if _, errWrite := io.WriteString(w, fmt.Sprintf("%s: %s\n", key, masked)); errWrite != nil {
 				return errWrite
 			}
@@ -784,6 +910,15 @@ func writeRequestInfoWithBody(
 	return nil
 }
 
+// maskHeaderValue partially masks sensitive header values, leaving values the redactor has
+// already replaced intact.
+func maskHeaderValue(key, value string) string {
+	if value == redactedHeaderValue {
+		return value
+	}
+	return util.MaskSensitiveHeaderValue(key, value)
+}
+
 func countTrailingNewlinesBytes(payload []byte) int {
 	count := 0
 	for i := len(payload) - 1; i >= 0; i-- {
@@ -994,6 +1129,17 @@ func responseBodyStartsWithLeadingNewline(reader *bufio.Reader) bool {
 // Returns:
 //   - string: The formatted log content
 func (l *FileRequestLogger) formatLogContent(url, method string, headers map[string][]string, body, websocketTimeline, apiRequest, apiResponse, apiWebsocketTimeline, response []byte, status int, responseHeaders map[string][]string, apiResponseErrors []*interfaces.ErrorMessage) string {
+	redactor := l.Redactor()
+	headers = redactor.RedactHeaders(headers)
+	body = redactor.Redact(body)
+	websocketTimeline = redactor.Redact(websocketTimeline)
+	apiRequest = redactor.Redact(apiRequest)
+	apiResponse = redactor.Redact(apiResponse)
+	apiWebsocketTimeline = redactor.Redact(apiWebsocketTimeline)
+	response = redactor.Redact(response)
+	responseHeaders = redactor.RedactHeaders(responseHeaders)
+	apiResponseErrors = redactErrorMessages(redactor, apiResponseErrors)
+
 	var content strings.Builder
 	isWebsocketTranscript := hasSectionPayload(websocketTimeline)
 	downstreamTransport := inferDownstreamTransport(headers, websocketTimeline)
@@ -1250,7 +1396,7 @@ func (l *FileRequestLogger) formatRequestInfo(url, method string, headers map[st
 	content.WriteString("=== HEADERS ===\n")
 	for key, values := range headers {
 		for _, value := range values {
-			masked := util.MaskSensitiveHeaderValue(key, value)
+			masked := maskHeaderValue(key, value)
 			content.WriteString(fmt.Sprintf("%s: %s\n", key, masked))
 		}
 	}
@@ -1271,9 +1417,21 @@ func (l *FileRequestLogger) formatRequestInfo(url, method string, headers map[st
 // It spools streaming response chunks to a temporary file to avoid retaining large responses in memory.
 // The final log file is assembled when Close is called.
 type FileStreamingLogWriter struct {
+	// requestID identifies the request in structured logs.
+	requestID string
+
 	// logFilePath is the final log file path.
 	logFilePath string
 
+	// structured, when set, receives a structured entry instead of the text log file.
+	structured *structuredRequestLog
+
+	// logsDir is the directory structured bodies are written under.
+	logsDir string
+
+	// meta carries routing details for structured logs.
+	meta *RequestLogMeta
+
 	// url is the request URL (masked upstream in middleware).
 	url string
 
@@ -1295,6 +1453,9 @@ type FileStreamingLogWriter struct {
 	// responseBodyFile is the temp file where chunks are appended by the async writer.
 	responseBodyFile *os.File
 
+	// redactor masks buffered sections and the spooled response when the log is assembled.
+	redactor *Redactor
+
 	// chunkChan is a channel for receiving response chunks to spool.
 	chunkChan chan []byte
 
@@ -1368,6 +1529,7 @@ func (w *FileStreamingLogWriter) WriteStatus(status int, headers map[string][]st
 			copy(headerValues, values)
 			w.responseHeaders[key] = headerValues
 		}
+		w.responseHeaders = w.redactor.RedactHeaders(w.responseHeaders)
 	}
 	w.statusWritten = true
 	return nil
@@ -1384,7 +1546,7 @@ func (w *FileStreamingLogWriter) WriteAPIRequest(apiRequest []byte) error {
 	if len(apiRequest) == 0 {
 		return nil
 	}
-	w.apiRequest = bytes.Clone(apiRequest)
+	w.apiRequest = bytes.Clone(w.redactor.Redact(apiRequest))
 	return nil
 }
 
@@ -1399,7 +1561,7 @@ func (w *FileStreamingLogWriter) WriteAPIResponse(apiResponse []byte) error {
 	if len(apiResponse) == 0 {
 		return nil
 	}
-	w.apiResponse = bytes.Clone(apiResponse)
+	w.apiResponse = bytes.Clone(w.redactor.Redact(apiResponse))
 	return nil
 }
 
@@ -1414,10 +1576,15 @@ func (w *FileStreamingLogWriter) WriteAPIWebsocketTimeline(apiWebsocketTimeline
 	if len(apiWebsocketTimeline) == 0 {
 		return nil
 	}
-	w.apiWebsocketTimeline = bytes.Clone(apiWebsocketTimeline)
+	w.apiWebsocketTimeline = bytes.Clone(w.redactor.Redact(apiWebsocketTimeline))
 	return nil
 }
 
+// SetMeta records the routing details written by the structured format.
+func (w *FileStreamingLogWriter) SetMeta(meta RequestLogMeta) {
+	w.meta = &meta
+}
+
 func (w *FileStreamingLogWriter) SetFirstChunkTimestamp(timestamp time.Time) {
 	if !timestamp.IsZero() {
 		w.apiResponseTimestamp = timestamp
@@ -1448,6 +1615,12 @@ func (w *FileStreamingLogWriter) Close() error {
 	default:
 	}
 
+	if w.structured != nil {
+		errWrite := w.writeStructuredLog()
+		w.cleanupTempFiles()
+		return errWrite
+	}
+
 	if w.logFilePath == "" {
 		w.cleanupTempFiles()
 		return nil
@@ -1530,8 +1703,35 @@ func (w *FileStreamingLogWriter) writeFinalLog(logFile *os.File) error {
 			log.WithError(errClose).Warn("failed to close response body temp file")
 		}
 	}()
+	responseReader := newRedactingReader(w.redactor, responseBodyFile)
+	defer func() {
+		_ = responseReader.Close()
+	}()
 
-	return writeResponseSection(logFile, w.responseStatus, w.statusWritten, w.responseHeaders, responseBodyFile, nil, false)
+	return writeResponseSection(logFile, w.responseStatus, w.statusWritten, w.responseHeaders, responseReader, nil, false)
+}
+
+func (w *FileStreamingLogWriter) writeStructuredLog() error {
+	meta := w.meta
+	if meta == nil {
+		meta = &RequestLogMeta{}
+	}
+	meta.Stream = true
+	if meta.FirstByteAt.IsZero() {
+		meta.FirstByteAt = w.apiResponseTimestamp
+	}
+	entry := newRequestLogEntry(w.requestID, w.url, w.method, w.responseStatus, w.timestamp, meta, nil)
+	sections := []structuredSection{
+		{name: RequestLogBodyRequest, path: w.requestBodyPath},
+		{name: RequestLogBodyAPIRequest, data: w.apiRequest},
+		{name: RequestLogBodyAPIResponse, data: w.apiResponse},
+		{name: RequestLogBodyAPIWebsocketTimeline, data: w.apiWebsocketTimeline},
+		{name: RequestLogBodyResponse, path: w.responseBodyPath},
+	}
+	if err := w.structured.write(w.logsDir, entry, sections, w.redactor); err != nil {
+		return fmt.Errorf("failed to write structured request log: %w", err)
+	}
+	return nil
 }
 
 func (w *FileStreamingLogWriter) cleanupTempFiles() {
@@ -1633,6 +1833,8 @@ type homeStreamingLogWriter struct {
 	apiWebsocketTime []byte
 	apiResponseTS    time.Time
 	firstChunkTS     time.Time
+
+	redactor *Redactor
 }
 
 func newHomeStreamingLogWriter(url, method string, headers map[string][]string, body []byte, _ string) *homeStreamingLogWriter {
@@ -1690,6 +1892,7 @@ func (w *homeStreamingLogWriter) WriteStatus(status int, headers map[string][]st
 			copy(copied, values)
 			w.responseHeaders[key] = copied
 		}
+		w.responseHeaders = w.redactor.RedactHeaders(w.responseHeaders)
 	}
 	return nil
 }
@@ -1698,7 +1901,7 @@ func (w *homeStreamingLogWriter) WriteAPIRequest(apiRequest []byte) error {
 	if w == nil || len(apiRequest) == 0 {
 		return nil
 	}
-	w.apiRequest = bytes.Clone(apiRequest)
+	w.apiRequest = bytes.Clone(w.redactor.Redact(apiRequest))
 	return nil
 }
 
@@ -1706,7 +1909,7 @@ func (w *homeStreamingLogWriter) WriteAPIResponse(apiResponse []byte) error {
 	if w == nil || len(apiResponse) == 0 {
 		return nil
 	}
-	w.apiResponse = bytes.Clone(apiResponse)
+	w.apiResponse = bytes.Clone(w.redactor.Redact(apiResponse))
 	return nil
 }
 
@@ -1714,7 +1917,7 @@ func (w *homeStreamingLogWriter) WriteAPIWebsocketTimeline(apiWebsocketTimeline
 	if w == nil || len(apiWebsocketTimeline) == 0 {
 		return nil
 	}
-	w.apiWebsocketTime = bytes.Clone(apiWebsocketTimeline)
+	w.apiWebsocketTime = bytes.Clone(w.redactor.Redact(apiWebsocketTimeline))
 	return nil
 }
 
@@ -1744,7 +1947,7 @@ func (w *homeStreamingLogWriter) Close() error {
 		w.chunkChan = nil
 	}
 
-	responsePayload := w.responseBody.Bytes()
+	responsePayload := w.redactor.Redact(w.responseBody.Bytes())
 
 	var buf bytes.Buffer
 	upstreamTransport := inferUpstreamTransport(w.apiRequest, w.apiResponse, w.apiWebsocketTime, nil)
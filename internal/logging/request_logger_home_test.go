@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -85,12 +86,15 @@ func TestFileRequestLogger_HomeEnabled_ForwardsWhenRequestLogEnabled(t *testing.
 	if got.Headers == nil || got.Headers["Content-Type"][0] != "application/json" {
 		t.Fatalf("headers.content-type = %+v, want application/json", got.Headers["Content-Type"])
 	}
-	if got.Headers == nil || got.Headers["Authorization"][0] != "Bearer secret" {
-		t.Fatalf("headers.authorization = %+v, want Bearer secret", got.Headers["Authorization"])
+	if got.Headers == nil || got.Headers["Authorization"][0] != redactedHeaderValue {
+		t.Fatalf("headers.authorization = %+v, want %s", got.Headers["Authorization"], redactedHeaderValue)
 	}
 	if got.RequestLog == "" {
 		t.Fatalf("request_log empty, want non-empty")
 	}
+	if strings.Contains(got.RequestLog, "secret") {
+		t.Fatalf("request_log leaks the authorization header: %s", got.RequestLog)
+	}
 }
 
 func TestFileRequestLogger_HomeEnabled_DoesNotForwardForcedErrorLogsWhenRequestLogDisabled(t *testing.T) {
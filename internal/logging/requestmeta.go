@@ -5,11 +5,13 @@ import (
 	"net/http"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type endpointKey struct{}
 type responseStatusKey struct{}
 type responseHeadersKey struct{}
+type firstChunkKey struct{}
 
 type responseStatusHolder struct {
 	status atomic.Int32
@@ -105,6 +107,45 @@ func GetResponseHeaders(ctx context.Context) http.Header {
 	return cloneHTTPHeader(holder.headers)
 }
 
+// WithFirstChunkHolder attaches a holder recording when the first response chunk reached the client.
+func WithFirstChunkHolder(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if holder, ok := ctx.Value(firstChunkKey{}).(*atomic.Int64); ok && holder != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, firstChunkKey{}, &atomic.Int64{})
+}
+
+// MarkFirstChunk records the current time as the first chunk time; later calls are ignored.
+func MarkFirstChunk(ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+	holder, ok := ctx.Value(firstChunkKey{}).(*atomic.Int64)
+	if !ok || holder == nil {
+		return
+	}
+	holder.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// GetFirstChunkAt returns when the first chunk was sent, or the zero time if unknown.
+func GetFirstChunkAt(ctx context.Context) time.Time {
+	if ctx == nil {
+		return time.Time{}
+	}
+	holder, ok := ctx.Value(firstChunkKey{}).(*atomic.Int64)
+	if !ok || holder == nil {
+		return time.Time{}
+	}
+	nanos := holder.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
 func cloneHTTPHeader(src http.Header) http.Header {
 	if len(src) == 0 {
 		return nil
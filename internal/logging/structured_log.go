@@ -0,0 +1,526 @@
+package logging
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+const (
+	// RequestLogFormatText writes one human-readable file per request.
+	RequestLogFormatText = "text"
+	// RequestLogFormatJSONL appends one JSON entry per request to a daily index and stores
+	// the bodies as separate files referenced from the entry.
+	RequestLogFormatJSONL = "jsonl"
+
+	requestLogMetaKey       = "REQUEST_LOG_META"
+	structuredLogFilePrefix = "requests-"
+	structuredLogFileSuffix = ".jsonl"
+	structuredBodiesDirName = "request-bodies"
+	structuredDayLayout     = "2006-01-02"
+)
+
+// Body section names used in RequestLogEntry.Bodies.
+const (
+	RequestLogBodyRequest              = "request"
+	RequestLogBodyResponse             = "response"
+	RequestLogBodyWebsocketTimeline    = "websocket-timeline"
+	RequestLogBodyAPIRequest           = "api-request"
+	RequestLogBodyAPIResponse          = "api-response"
+	RequestLogBodyAPIWebsocketTimeline = "api-websocket-timeline"
+)
+
+// ErrRequestLogNotFound is returned when no structured entry matches a request ID.
+var ErrRequestLogNotFound = errors.New("request log not found")
+
+// RequestLogMeta carries the routing details that only structured request logs record.
+// Executors report them per upstream attempt and the logging middleware adds the rest.
+type RequestLogMeta struct {
+	// ClientKey is the authenticated client principal. It is masked before it is written.
+	ClientKey      string
+	SourceFormat   string
+	TargetFormat   string
+	RequestedModel string
+	Model          string
+	AuthID         string
+	AuthIndex      string
+	// Attempts counts upstream attempts, so retries are Attempts-1.
+	Attempts int
+	Stream   bool
+	// FirstByteAt is when the first response byte was available; zero when unknown.
+	FirstByteAt time.Time
+	// CompletedAt is when the response finished.
+	CompletedAt time.Time
+}
+
+type requestLogMetaHolder struct {
+	mu   sync.Mutex
+	meta RequestLogMeta
+}
+
+// WithRequestLogMeta prepares c to collect RequestLogMeta from the executors serving it.
+func WithRequestLogMeta(c *gin.Context) {
+	if c == nil {
+		return
+	}
+	if _, exists := c.Get(requestLogMetaKey); exists {
+		return
+	}
+	c.Set(requestLogMetaKey, &requestLogMetaHolder{})
+}
+
+// ObserveRequestLogAttempt records one upstream attempt for c. Non-empty fields replace the
+// previous values so the entry describes the attempt that finished last.
+func ObserveRequestLogAttempt(c *gin.Context, attempt RequestLogMeta) {
+	holder := requestLogMetaHolderFrom(c)
+	if holder == nil {
+		return
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	meta := &holder.meta
+	meta.Attempts++
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&meta.SourceFormat, attempt.SourceFormat},
+		{&meta.TargetFormat, attempt.TargetFormat},
+		{&meta.RequestedModel, attempt.RequestedModel},
+		{&meta.Model, attempt.Model},
+		{&meta.AuthID, attempt.AuthID},
+		{&meta.AuthIndex, attempt.AuthIndex},
+	} {
+		if src := strings.TrimSpace(field.src); src != "" {
+			*field.dst = src
+		}
+	}
+}
+
+// RequestLogMetaFrom returns the details collected for c so far.
+func RequestLogMetaFrom(c *gin.Context) RequestLogMeta {
+	holder := requestLogMetaHolderFrom(c)
+	if holder == nil {
+		return RequestLogMeta{}
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	return holder.meta
+}
+
+func requestLogMetaHolderFrom(c *gin.Context) *requestLogMetaHolder {
+	if c == nil {
+		return nil
+	}
+	value, exists := c.Get(requestLogMetaKey)
+	if !exists {
+		return nil
+	}
+	holder, _ := value.(*requestLogMetaHolder)
+	return holder
+}
+
+// RequestLogEntry is one line of the structured request log.
+type RequestLogEntry struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Method    string    `json:"method"`
+	URL       string    `json:"url"`
+	// ClientKey is the masked client principal and ClientKeyID a short hash of it, so entries
+	// can be filtered by key without storing the key itself.
+	ClientKey      string                    `json:"client_key,omitempty"`
+	ClientKeyID    string                    `json:"client_key_id,omitempty"`
+	SourceFormat   string                    `json:"source_format,omitempty"`
+	TargetFormat   string                    `json:"target_format,omitempty"`
+	RequestedModel string                    `json:"requested_model,omitempty"`
+	Model          string                    `json:"model,omitempty"`
+	AuthID         string                    `json:"auth_id,omitempty"`
+	AuthIndex      string                    `json:"auth_index,omitempty"`
+	Attempts       int                       `json:"attempts,omitempty"`
+	Retries        int                       `json:"retries,omitempty"`
+	Status         int                       `json:"status"`
+	Stream         bool                      `json:"stream,omitempty"`
+	TTFBMs         int64                     `json:"ttfb_ms,omitempty"`
+	TotalMs        int64                     `json:"total_ms"`
+	UpstreamErrors []int                     `json:"upstream_errors,omitempty"`
+	Bodies         map[string]RequestLogBody `json:"bodies,omitempty"`
+}
+
+// RequestLogBody references a body file relative to the logs directory.
+type RequestLogBody struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// RequestLogFilter selects structured request log entries. Zero fields match everything.
+type RequestLogFilter struct {
+	From time.Time
+	To   time.Time
+	// Model matches the resolved or the requested model.
+	Model string
+	// MinStatus and MaxStatus bound the downstream status code, inclusive.
+	MinStatus int
+	MaxStatus int
+	// Auth matches the auth index or auth ID.
+	Auth string
+	// ClientKey matches the raw key, its masked form or its key ID.
+	ClientKey string
+}
+
+// Match reports whether entry satisfies the filter.
+func (f RequestLogFilter) Match(entry RequestLogEntry) bool {
+	if !f.From.IsZero() && entry.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !entry.Timestamp.Before(f.To) {
+		return false
+	}
+	if f.Model != "" && !strings.EqualFold(f.Model, entry.Model) && !strings.EqualFold(f.Model, entry.RequestedModel) {
+		return false
+	}
+	if f.MinStatus > 0 && entry.Status < f.MinStatus {
+		return false
+	}
+	if f.MaxStatus > 0 && entry.Status > f.MaxStatus {
+		return false
+	}
+	if f.Auth != "" && f.Auth != entry.AuthIndex && f.Auth != entry.AuthID {
+		return false
+	}
+	if f.ClientKey != "" && f.ClientKey != entry.ClientKeyID && f.ClientKey != entry.ClientKey && ClientKeyID(f.ClientKey) != entry.ClientKeyID {
+		return false
+	}
+	return true
+}
+
+// ClientKeyID returns the short hash identifying a client key in logs and usage records.
+func ClientKeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:6])
+}
+
+// structuredSection is a body destined for its own file. Exactly one of data and path is set;
+// path names a spooled temp file that is copied through the redactor.
+type structuredSection struct {
+	name string
+	data []byte
+	path string
+}
+
+// structuredRequestLog appends entries to requests-<day>.jsonl under the logs directory and
+// prunes days older than the retention window when the day changes.
+type structuredRequestLog struct {
+	mu            sync.Mutex
+	retentionDays int
+	prunedDay     string
+}
+
+func newRequestLogEntry(id, url, method string, status int, start time.Time, meta *RequestLogMeta, apiResponseErrors []int) RequestLogEntry {
+	if id == "" {
+		var raw [8]byte
+		_, _ = rand.Read(raw[:])
+		id = hex.EncodeToString(raw[:])
+	}
+	if start.IsZero() {
+		start = time.Now()
+	}
+	entry := RequestLogEntry{
+		ID:             id,
+		Timestamp:      start.UTC(),
+		Method:         method,
+		URL:            url,
+		Status:         status,
+		UpstreamErrors: apiResponseErrors,
+	}
+	if meta == nil {
+		entry.TotalMs = time.Since(start).Milliseconds()
+		return entry
+	}
+	if key := strings.TrimSpace(meta.ClientKey); key != "" {
+		entry.ClientKey = util.HideAPIKey(key)
+		entry.ClientKeyID = ClientKeyID(key)
+	}
+	entry.SourceFormat = meta.SourceFormat
+	entry.TargetFormat = meta.TargetFormat
+	entry.RequestedModel = meta.RequestedModel
+	entry.Model = meta.Model
+	entry.AuthID = meta.AuthID
+	entry.AuthIndex = meta.AuthIndex
+	entry.Attempts = meta.Attempts
+	if meta.Attempts > 1 {
+		entry.Retries = meta.Attempts - 1
+	}
+	entry.Stream = meta.Stream
+	if !meta.FirstByteAt.IsZero() && meta.FirstByteAt.After(start) {
+		entry.TTFBMs = meta.FirstByteAt.Sub(start).Milliseconds()
+	}
+	completed := meta.CompletedAt
+	if completed.IsZero() {
+		completed = time.Now()
+	}
+	entry.TotalMs = completed.Sub(start).Milliseconds()
+	return entry
+}
+
+// write stores the body sections and appends entry to the day's index.
+func (s *structuredRequestLog) write(logsDir string, entry RequestLogEntry, sections []structuredSection, redactor *Redactor) error {
+	day := entry.Timestamp.Format(structuredDayLayout)
+	bodiesDir := filepath.Join(logsDir, structuredBodiesDirName, day)
+	for _, section := range sections {
+		if len(section.data) == 0 && section.path == "" {
+			continue
+		}
+		if err := os.MkdirAll(bodiesDir, 0755); err != nil {
+			return err
+		}
+		name := sanitizeRequestLogID(entry.ID) + "." + section.name
+		size, err := writeStructuredBody(filepath.Join(bodiesDir, name), section, redactor)
+		if err != nil {
+			return fmt.Errorf("write %s body: %w", section.name, err)
+		}
+		if size == 0 {
+			_ = os.Remove(filepath.Join(bodiesDir, name))
+			continue
+		}
+		if entry.Bodies == nil {
+			entry.Bodies = make(map[string]RequestLogBody)
+		}
+		entry.Bodies[section.name] = RequestLogBody{
+			Path: filepath.ToSlash(filepath.Join(structuredBodiesDirName, day, name)),
+			Size: size,
+		}
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(filepath.Join(logsDir, structuredLogFilePrefix+day+structuredLogFileSuffix), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, errWrite := file.Write(line)
+	if errClose := file.Close(); errWrite == nil {
+		errWrite = errClose
+	}
+	if s.prunedDay != day {
+		s.prunedDay = day
+		s.prune(logsDir, entry.Timestamp)
+	}
+	return errWrite
+}
+
+func writeStructuredBody(path string, section structuredSection, redactor *Redactor) (int64, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, err
+	}
+	var written int64
+	if section.path != "" {
+		src, errOpen := os.Open(section.path)
+		if errOpen != nil {
+			_ = file.Close()
+			return 0, errOpen
+		}
+		reader := newRedactingReader(redactor, src)
+		written, err = io.Copy(file, reader)
+		_ = reader.Close()
+		_ = src.Close()
+	} else {
+		var n int
+		n, err = file.Write(section.data)
+		written = int64(n)
+	}
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	return written, err
+}
+
+// prune removes index files and body directories older than the retention window.
+// It expects the caller to hold s.mu.
+func (s *structuredRequestLog) prune(logsDir string, now time.Time) {
+	if s.retentionDays <= 0 {
+		return
+	}
+	cutoff := now.UTC().AddDate(0, 0, -s.retentionDays).Format(structuredDayLayout)
+	if entries, err := os.ReadDir(logsDir); err == nil {
+		for _, entry := range entries {
+			day, ok := structuredLogDay(entry.Name())
+			if ok && !entry.IsDir() && day < cutoff {
+				if errRemove := os.Remove(filepath.Join(logsDir, entry.Name())); errRemove != nil {
+					log.WithError(errRemove).Warn("failed to remove expired structured request log")
+				}
+			}
+		}
+	}
+	bodiesRoot := filepath.Join(logsDir, structuredBodiesDirName)
+	if entries, err := os.ReadDir(bodiesRoot); err == nil {
+		for _, entry := range entries {
+			if _, errParse := time.Parse(structuredDayLayout, entry.Name()); entry.IsDir() && errParse == nil && entry.Name() < cutoff {
+				if errRemove := os.RemoveAll(filepath.Join(bodiesRoot, entry.Name())); errRemove != nil {
+					log.WithError(errRemove).Warn("failed to remove expired request log bodies")
+				}
+			}
+		}
+	}
+}
+
+func structuredLogDay(name string) (string, bool) {
+	if !strings.HasPrefix(name, structuredLogFilePrefix) || !strings.HasSuffix(name, structuredLogFileSuffix) {
+		return "", false
+	}
+	day := strings.TrimSuffix(strings.TrimPrefix(name, structuredLogFilePrefix), structuredLogFileSuffix)
+	if _, err := time.Parse(structuredDayLayout, day); err != nil {
+		return "", false
+	}
+	return day, true
+}
+
+func sanitizeRequestLogID(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, id)
+}
+
+// SearchRequestLogs returns structured entries under logsDir matching filter, newest first,
+// together with the total number of matches before limit and offset are applied.
+func SearchRequestLogs(logsDir string, filter RequestLogFilter, limit, offset int) ([]RequestLogEntry, int, error) {
+	var matches []RequestLogEntry
+	err := scanRequestLogs(logsDir, filter.From, filter.To, func(entry RequestLogEntry) bool {
+		if filter.Match(entry) {
+			matches = append(matches, entry)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Timestamp.After(matches[j].Timestamp) })
+	total := len(matches)
+	if offset > total {
+		offset = total
+	}
+	matches = matches[offset:]
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, total, nil
+}
+
+// FindRequestLog returns the structured entry recorded for requestID.
+func FindRequestLog(logsDir, requestID string) (RequestLogEntry, error) {
+	var found *RequestLogEntry
+	err := scanRequestLogs(logsDir, time.Time{}, time.Time{}, func(entry RequestLogEntry) bool {
+		if entry.ID == requestID {
+			found = &entry
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return RequestLogEntry{}, err
+	}
+	if found == nil {
+		return RequestLogEntry{}, ErrRequestLogNotFound
+	}
+	return *found, nil
+}
+
+// ReadRequestLogBody returns the content of a body referenced by an entry.
+func ReadRequestLogBody(logsDir string, body RequestLogBody) ([]byte, error) {
+	rel := filepath.FromSlash(body.Path)
+	if filepath.IsAbs(rel) || !strings.HasPrefix(filepath.Clean(rel), structuredBodiesDirName+string(os.PathSeparator)) {
+		return nil, fmt.Errorf("invalid body path %q", body.Path)
+	}
+	return os.ReadFile(filepath.Join(logsDir, rel))
+}
+
+// scanRequestLogs visits entries in the index files covering [from, to), newest day first,
+// until visit returns false.
+func scanRequestLogs(logsDir string, from, to time.Time, visit func(RequestLogEntry) bool) error {
+	dirEntries, err := os.ReadDir(logsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var days []string
+	for _, dirEntry := range dirEntries {
+		day, ok := structuredLogDay(dirEntry.Name())
+		if !ok || dirEntry.IsDir() {
+			continue
+		}
+		if !from.IsZero() && day < from.UTC().Format(structuredDayLayout) {
+			continue
+		}
+		if !to.IsZero() && day > to.UTC().Format(structuredDayLayout) {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+	for _, day := range days {
+		keepGoing, errScan := scanRequestLogFile(filepath.Join(logsDir, structuredLogFilePrefix+day+structuredLogFileSuffix), visit)
+		if errScan != nil {
+			return errScan
+		}
+		if !keepGoing {
+			return nil
+		}
+	}
+	return nil
+}
+
+func scanRequestLogFile(path string, visit func(RequestLogEntry) bool) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	defer func() {
+		if errClose := file.Close(); errClose != nil {
+			log.WithError(errClose).Warn("failed to close structured request log")
+		}
+	}()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 8*1024*1024)
+	for scanner.Scan() {
+		var entry RequestLogEntry
+		if errDecode := json.Unmarshal(scanner.Bytes(), &entry); errDecode != nil {
+			continue
+		}
+		if !visit(entry) {
+			return false, nil
+		}
+	}
+	return true, scanner.Err()
+}
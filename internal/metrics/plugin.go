@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	internallogging "github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+var enabled atomic.Bool
+
+// SetEnabled toggles collection and exposition of metrics.
+// This is controlled by the config field `metrics.enable`.
+func SetEnabled(value bool) { enabled.Store(value) }
+
+// Enabled reports whether metrics are being collected.
+func Enabled() bool { return enabled.Load() }
+
+var seriesLabels = []string{"provider", "model", "alias", "auth_index", "client_key"}
+
+var (
+	requestsTotal = newCounterVec("cliproxy_requests_total",
+		"Total upstream requests handled by the proxy.", seriesLabels...)
+	failuresTotal = newCounterVec("cliproxy_request_failures_total",
+		"Total failed upstream requests by HTTP status.", append(append([]string(nil), seriesLabels...), "status")...)
+	tokensTotal = newCounterVec("cliproxy_tokens_total",
+		"Total tokens reported by upstream providers by token type.", append(append([]string(nil), seriesLabels...), "type")...)
+	requestDuration = newHistogramVec("cliproxy_request_duration_seconds",
+		"Upstream request latency in seconds.", defaultDurationBuckets, seriesLabels...)
+	firstChunkDuration = newHistogramVec("cliproxy_time_to_first_chunk_seconds",
+		"Delay until the first streamed chunk reached the client in seconds.", defaultDurationBuckets, seriesLabels...)
+	cacheHitsTotal = newCounterVec("cliproxy_response_cache_hits_total",
+		"Total requests answered from the response cache.", seriesLabels...)
+
+	collectors = []collector{requestsTotal, failuresTotal, tokensTotal, requestDuration, firstChunkDuration, cacheHitsTotal}
+)
+
+func init() {
+	coreusage.RegisterPlugin(&usageMetricsPlugin{})
+}
+
+type usageMetricsPlugin struct{}
+
+func (p *usageMetricsPlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if p == nil || !Enabled() {
+		return
+	}
+	observe(ctx, record)
+}
+
+// observe folds a usage record into the metric series. Response cache hits only count towards
+// the cache series since no upstream request was made.
+func observe(ctx context.Context, record coreusage.Record) {
+	labels := recordLabels(record)
+	if record.CacheHit {
+		cacheHitsTotal.add(1, labels...)
+		return
+	}
+	requestsTotal.add(1, labels...)
+
+	if status, failed := failureStatus(ctx, record); failed {
+		failuresTotal.add(1, append(labels, status)...)
+	}
+
+	detail := record.Detail
+	tokensTotal.add(float64(detail.InputTokens), append(labels, "input")...)
+	tokensTotal.add(float64(detail.OutputTokens), append(labels, "output")...)
+	tokensTotal.add(float64(detail.ReasoningTokens), append(labels, "reasoning")...)
+	cached := detail.CachedTokens
+	if cached == 0 {
+		cached = detail.CacheReadTokens
+	}
+	tokensTotal.add(float64(cached), append(labels, "cached")...)
+
+	if record.Latency > 0 {
+		requestDuration.observe(record.Latency.Seconds(), labels...)
+	}
+	if record.TimeToFirstChunk > 0 {
+		firstChunkDuration.observe(record.TimeToFirstChunk.Seconds(), labels...)
+	}
+}
+
+// Reset drops every collected series.
+func Reset() {
+	for _, c := range collectors {
+		c.reset()
+	}
+}
+
+// Handler serves the collected metrics in the exposition format the scraper negotiates.
+// It responds with 404 while metrics are disabled.
+func Handler() http.Handler {
+	exposition := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !Enabled() {
+			http.NotFound(w, r)
+			return
+		}
+		exposition.ServeHTTP(w, r)
+	})
+}
+
+func recordLabels(record coreusage.Record) []string {
+	model := strings.TrimSpace(record.Model)
+	if model == "" {
+		model = "unknown"
+	}
+	alias := strings.TrimSpace(record.Alias)
+	if alias == "" {
+		alias = model
+	}
+	provider := strings.TrimSpace(record.Provider)
+	if provider == "" {
+		provider = "unknown"
+	}
+	clientKey := strings.TrimSpace(record.APIKey)
+	if clientKey != "" {
+		clientKey = util.HideAPIKey(clientKey)
+	}
+	return []string{provider, model, alias, strings.TrimSpace(record.AuthIndex), clientKey}
+}
+
+func failureStatus(ctx context.Context, record coreusage.Record) (string, bool) {
+	status := record.Fail.StatusCode
+	if status <= 0 {
+		status = internallogging.GetResponseStatus(ctx)
+	}
+	failed := record.Failed || status >= http.StatusBadRequest
+	if !failed {
+		return "", false
+	}
+	if status <= 0 {
+		return "unknown", true
+	}
+	return strconv.Itoa(status), true
+}
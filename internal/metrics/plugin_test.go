@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestHandlerExposesUsageSeries(t *testing.T) {
+	Reset()
+	previous := Enabled()
+	SetEnabled(true)
+	t.Cleanup(func() {
+		SetEnabled(previous)
+		Reset()
+	})
+
+	plugin := &usageMetricsPlugin{}
+	plugin.HandleUsage(context.Background(), coreusage.Record{
+		Provider:         "claude",
+		Model:            "claude-sonnet-4-5",
+		Alias:            "sonnet",
+		APIKey:           "sk-client-secret",
+		AuthIndex:        "3",
+		Latency:          1500 * time.Millisecond,
+		TimeToFirstChunk: 200 * time.Millisecond,
+		Detail:           coreusage.Detail{InputTokens: 10, OutputTokens: 20, CacheReadTokens: 4},
+	})
+	plugin.HandleUsage(context.Background(), coreusage.Record{
+		Provider: "claude",
+		Model:    "claude-sonnet-4-5",
+		Alias:    "sonnet",
+		APIKey:   "sk-client-secret",
+		Failed:   true,
+		Fail:     coreusage.Failure{StatusCode: http.StatusTooManyRequests},
+	})
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Fatalf("content type = %q, want the Prometheus text format", got)
+	}
+
+	body := rec.Body.String()
+	labels := `alias="sonnet",auth_index="3",client_key="sk-c...cret",model="claude-sonnet-4-5",provider="claude"`
+	for _, want := range []string{
+		`cliproxy_requests_total{` + labels + `} 1`,
+		`cliproxy_tokens_total{` + labels + `,type="input"} 10`,
+		`cliproxy_tokens_total{` + labels + `,type="output"} 20`,
+		`cliproxy_tokens_total{` + labels + `,type="cached"} 4`,
+		`cliproxy_request_duration_seconds_bucket{` + labels + `,le="2.5"} 1`,
+		`cliproxy_request_duration_seconds_bucket{` + labels + `,le="1"} 0`,
+		`cliproxy_time_to_first_chunk_seconds_count{` + labels + `} 1`,
+		`cliproxy_request_failures_total{alias="sonnet",auth_index="",client_key="sk-c...cret",model="claude-sonnet-4-5",provider="claude",status="429"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "sk-client-secret") {
+		t.Fatalf("metrics output leaks client key:\n%s", body)
+	}
+}
+
+func TestHandlerDisabled(t *testing.T) {
+	previous := Enabled()
+	SetEnabled(false)
+	t.Cleanup(func() { SetEnabled(previous) })
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandlerOutputParsesAsPrometheusText(t *testing.T) {
+	Reset()
+	previous := Enabled()
+	SetEnabled(true)
+	t.Cleanup(func() {
+		SetEnabled(previous)
+		Reset()
+	})
+
+	plugin := &usageMetricsPlugin{}
+	plugin.HandleUsage(context.Background(), coreusage.Record{
+		Provider: "openai-compat",
+		Model:    "vendor/model \"quoted\"\nline",
+		Latency:  300 * time.Millisecond,
+		Detail:   coreusage.Detail{InputTokens: 5},
+	})
+	plugin.HandleUsage(context.Background(), coreusage.Record{Provider: "claude", Model: "cached", CacheHit: true})
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(rec.Body)
+	if err != nil {
+		t.Fatalf("parse metrics output: %v", err)
+	}
+	requests := families["cliproxy_requests_total"]
+	if requests == nil || len(requests.GetMetric()) != 1 || requests.GetMetric()[0].GetCounter().GetValue() != 1 {
+		t.Fatalf("cliproxy_requests_total = %v, want one series with value 1", requests)
+	}
+	var gotModel string
+	for _, label := range requests.GetMetric()[0].GetLabel() {
+		if label.GetName() == "model" {
+			gotModel = label.GetValue()
+		}
+	}
+	if want := "vendor/model \"quoted\"\nline"; gotModel != want {
+		t.Fatalf("model label = %q, want %q", gotModel, want)
+	}
+	if families["cliproxy_request_duration_seconds"].GetType().String() != "HISTOGRAM" {
+		t.Fatalf("cliproxy_request_duration_seconds type = %v, want histogram", families["cliproxy_request_duration_seconds"].GetType())
+	}
+	if hits := families["cliproxy_response_cache_hits_total"]; hits == nil || len(hits.GetMetric()) != 1 {
+		t.Fatalf("cliproxy_response_cache_hits_total = %v, want one series", hits)
+	}
+}
+
+func TestObserveSkipsRecordsWhileDisabled(t *testing.T) {
+	Reset()
+	previous := Enabled()
+	SetEnabled(false)
+	t.Cleanup(func() {
+		SetEnabled(previous)
+		Reset()
+	})
+
+	(&usageMetricsPlugin{}).HandleUsage(context.Background(), coreusage.Record{Provider: "claude", Model: "m"})
+	SetEnabled(true)
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if strings.Contains(rec.Body.String(), "cliproxy_requests_total{") {
+		t.Fatalf("records handled while disabled were collected:\n%s", rec.Body.String())
+	}
+}
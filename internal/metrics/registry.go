@@ -0,0 +1,59 @@
+// Package metrics maintains Prometheus counters and histograms fed by the usage pipeline and
+// exposes them through the Prometheus client library.
+package metrics
+
+import (
+	"math"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultDurationBuckets are histogram upper bounds in seconds tuned for LLM latencies.
+var defaultDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+// registry holds only the proxy series, so the endpoint does not expose process metrics.
+var registry = prometheus.NewRegistry()
+
+type counterVec struct {
+	labels int
+	vec    *prometheus.CounterVec
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+	registry.MustRegister(vec)
+	return &counterVec{labels: len(labels), vec: vec}
+}
+
+func (c *counterVec) add(delta float64, values ...string) {
+	if c == nil || delta <= 0 || len(values) != c.labels {
+		return
+	}
+	c.vec.WithLabelValues(values...).Add(delta)
+}
+
+func (c *counterVec) reset() { c.vec.Reset() }
+
+type histogramVec struct {
+	labels int
+	vec    *prometheus.HistogramVec
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)
+	registry.MustRegister(vec)
+	return &histogramVec{labels: len(labels), vec: vec}
+}
+
+func (h *histogramVec) observe(value float64, values ...string) {
+	if h == nil || len(values) != h.labels || math.IsNaN(value) || value < 0 {
+		return
+	}
+	h.vec.WithLabelValues(values...).Observe(value)
+}
+
+func (h *histogramVec) reset() { h.vec.Reset() }
+
+type collector interface {
+	reset()
+}
@@ -80,6 +80,8 @@ func (p *usageQueuePlugin) HandleUsage(ctx context.Context, record coreusage.Rec
 		Failed:          failed,
 		Fail:            fail,
 		ResponseHeaders: record.ResponseHeaders,
+		CacheHit:        record.CacheHit,
+		FallbackHop:     record.FallbackHop,
 	}
 
 	payload, err := json.Marshal(queuedUsageDetail{
@@ -118,6 +120,8 @@ type requestDetail struct {
 	Failed          bool        `json:"failed"`
 	Fail            failDetail  `json:"fail"`
 	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	CacheHit        bool        `json:"cache_hit,omitempty"`
+	FallbackHop     int         `json:"fallback_hop,omitempty"`
 }
 
 type tokenStats struct {
@@ -11,6 +11,12 @@ const (
 	xaiBuiltinImageModelID        = "grok-imagine-image"
 	xaiBuiltinImageQualityModelID = "grok-imagine-image-quality"
 	xaiBuiltinVideoModelID        = "grok-imagine-video"
+
+	geminiBuiltinEmbeddingModelID      = "gemini-embedding-001"
+	vertexBuiltinTextEmbeddingModelID  = "text-embedding-005"
+	vertexBuiltinMultilingualModelID   = "text-multilingual-embedding-002"
+	openAIBuiltinEmbeddingSmallModelID = "text-embedding-3-small"
+	openAIBuiltinEmbeddingLargeModelID = "text-embedding-3-large"
 )
 
 // staticModelsJSON mirrors the top-level structure of models.json.
@@ -36,12 +42,12 @@ func GetClaudeModels() []*ModelInfo {
 
 // GetGeminiModels returns the standard Gemini model definitions.
 func GetGeminiModels() []*ModelInfo {
-	return cloneModelInfos(getModels().Gemini)
+	return WithGeminiEmbeddingBuiltins(cloneModelInfos(getModels().Gemini))
 }
 
 // GetGeminiVertexModels returns Gemini model definitions for Vertex AI.
 func GetGeminiVertexModels() []*ModelInfo {
-	return cloneModelInfos(getModels().Vertex)
+	return WithVertexEmbeddingBuiltins(cloneModelInfos(getModels().Vertex))
 }
 
 // GetGeminiCLIModels returns Gemini model definitions for the Gemini CLI.
@@ -102,6 +108,55 @@ func WithXAIBuiltins(models []*ModelInfo) []*ModelInfo {
 	return upsertModelInfos(models, xaiBuiltinImageModelInfo(), xaiBuiltinImageQualityModelInfo(), xaiBuiltinVideoModelInfo())
 }
 
+// WithGeminiEmbeddingBuiltins injects the Gemini API embedding models served through
+// embedContent and batchEmbedContents.
+func WithGeminiEmbeddingBuiltins(models []*ModelInfo) []*ModelInfo {
+	return upsertModelInfos(models, geminiEmbeddingModelInfo(geminiBuiltinEmbeddingModelID, "Gemini Embedding 001"))
+}
+
+// WithVertexEmbeddingBuiltins injects the Vertex AI text embedding models served through predict.
+func WithVertexEmbeddingBuiltins(models []*ModelInfo) []*ModelInfo {
+	return upsertModelInfos(models,
+		geminiEmbeddingModelInfo(geminiBuiltinEmbeddingModelID, "Gemini Embedding 001"),
+		geminiEmbeddingModelInfo(vertexBuiltinTextEmbeddingModelID, "Text Embedding 005"),
+		geminiEmbeddingModelInfo(vertexBuiltinMultilingualModelID, "Text Multilingual Embedding 002"),
+	)
+}
+
+// WithCodexEmbeddingBuiltins injects the OpenAI embedding models available to Codex API-key
+// credentials. OAuth credentials cannot reach the embeddings API and must not advertise them.
+func WithCodexEmbeddingBuiltins(models []*ModelInfo) []*ModelInfo {
+	return upsertModelInfos(models,
+		openAIEmbeddingModelInfo(openAIBuiltinEmbeddingSmallModelID, "Text Embedding 3 Small"),
+		openAIEmbeddingModelInfo(openAIBuiltinEmbeddingLargeModelID, "Text Embedding 3 Large"),
+	)
+}
+
+func geminiEmbeddingModelInfo(id, displayName string) *ModelInfo {
+	return &ModelInfo{
+		ID:                         id,
+		Object:                     "model",
+		Created:                    1735689600, // 2025-01-01
+		OwnedBy:                    "google",
+		Type:                       "gemini",
+		DisplayName:                displayName,
+		Name:                       "models/" + id,
+		SupportedGenerationMethods: []string{"embedContent", "batchEmbedContents"},
+	}
+}
+
+func openAIEmbeddingModelInfo(id, displayName string) *ModelInfo {
+	return &ModelInfo{
+		ID:          id,
+		Object:      "model",
+		Created:     1704067200, // 2024-01-01
+		OwnedBy:     "openai",
+		Type:        "openai",
+		DisplayName: displayName,
+		Version:     id,
+	}
+}
+
 func codexBuiltinImageModelInfo() *ModelInfo {
 	return &ModelInfo{
 		ID:          codexBuiltinImageModelID,
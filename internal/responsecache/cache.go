@@ -0,0 +1,313 @@
+// Package responsecache stores responses of deterministic non-streaming requests so that
+// identical prompts can be answered without contacting an upstream provider.
+package responsecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// DefaultTTL is used when the configured TTL is not positive.
+	DefaultTTL = time.Hour
+	// DefaultMaxEntries is used when the configured entry limit is not positive.
+	DefaultMaxEntries = 1000
+)
+
+// ignoredPayloadFields do not influence the generated response and are dropped before hashing.
+var ignoredPayloadFields = []string{"stream", "stream_options", "user", "metadata"}
+
+// Entry is a cached response.
+type Entry struct {
+	Key       string           `json:"key"`
+	Format    string           `json:"format"`
+	Model     string           `json:"model"`
+	Provider  string           `json:"provider,omitempty"`
+	Payload   []byte           `json:"payload"`
+	Headers   http.Header      `json:"headers,omitempty"`
+	Usage     coreusage.Detail `json:"usage"`
+	CreatedAt time.Time        `json:"created_at"`
+	ExpiresAt time.Time        `json:"expires_at"`
+}
+
+// Expired reports whether the entry is past its expiry at now.
+func (e Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// Backend stores cache entries.
+type Backend interface {
+	Get(key string) (Entry, bool)
+	Set(entry Entry)
+	Delete(key string) bool
+	Purge() int
+	List() []Entry
+}
+
+// Stats summarizes cache activity since the cache was last configured.
+type Stats struct {
+	Enabled bool   `json:"enabled"`
+	Backend string `json:"backend,omitempty"`
+	Entries int    `json:"entries"`
+	Hits    int64  `json:"hits"`
+	Misses  int64  `json:"misses"`
+	Stores  int64  `json:"stores"`
+}
+
+// Cache fronts the configured backend with TTL handling and hit accounting.
+type Cache struct {
+	mu          sync.RWMutex
+	backend     Backend
+	backendName string
+	ttl         time.Duration
+	hits        int64
+	misses      int64
+	stores      int64
+	now         func() time.Time
+}
+
+var defaultCache = &Cache{now: time.Now}
+
+// Default returns the process-wide response cache.
+func Default() *Cache { return defaultCache }
+
+// Configure swaps the backend and TTL. A nil backend disables the cache.
+// The previous backend is returned so callers can release it.
+func (c *Cache) Configure(backend Backend, name string, ttl time.Duration) Backend {
+	if c == nil {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	c.mu.Lock()
+	previous := c.backend
+	if previous != backend {
+		c.hits, c.misses, c.stores = 0, 0, 0
+	}
+	c.backend = backend
+	c.backendName = name
+	c.ttl = ttl
+	c.mu.Unlock()
+	return previous
+}
+
+// Backend returns the active backend or nil when the cache is disabled.
+func (c *Cache) Backend() Backend {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.backend
+}
+
+// Enabled reports whether a backend is configured.
+func (c *Cache) Enabled() bool { return c.Backend() != nil }
+
+// Lookup returns the unexpired entry stored under key. The backend is read without holding
+// the cache lock so a slow disk read does not serialize unrelated requests.
+func (c *Cache) Lookup(key string) (Entry, bool) {
+	if c == nil {
+		return Entry{}, false
+	}
+	c.mu.RLock()
+	backend := c.backend
+	c.mu.RUnlock()
+	if backend == nil {
+		return Entry{}, false
+	}
+	entry, ok := backend.Get(key)
+	if ok && entry.Expired(c.now()) {
+		backend.Delete(key)
+		ok = false
+	}
+	c.count(backend, func() {
+		if ok {
+			c.hits++
+		} else {
+			c.misses++
+		}
+	})
+	if !ok {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Store saves entry, stamping its creation and expiry times.
+func (c *Cache) Store(entry Entry) {
+	if c == nil {
+		return
+	}
+	c.mu.RLock()
+	backend := c.backend
+	ttl := c.ttl
+	c.mu.RUnlock()
+	if backend == nil {
+		return
+	}
+	entry.CreatedAt = c.now().UTC()
+	entry.ExpiresAt = entry.CreatedAt.Add(ttl)
+	backend.Set(entry)
+	c.count(backend, func() { c.stores++ })
+}
+
+// count applies update to the counters unless the backend was swapped in the meantime,
+// which resets them.
+func (c *Cache) count(backend Backend, update func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.backend == backend {
+		update()
+	}
+}
+
+// Delete removes a single entry.
+func (c *Cache) Delete(key string) bool {
+	backend := c.Backend()
+	if backend == nil {
+		return false
+	}
+	return backend.Delete(key)
+}
+
+// Purge removes every entry and returns how many were dropped.
+func (c *Cache) Purge() int {
+	backend := c.Backend()
+	if backend == nil {
+		return 0
+	}
+	return backend.Purge()
+}
+
+// Entries lists the unexpired entries without their payloads.
+func (c *Cache) Entries() []Entry {
+	backend := c.Backend()
+	if backend == nil {
+		return nil
+	}
+	now := c.now()
+	items := backend.List()
+	out := make([]Entry, 0, len(items))
+	for _, item := range items {
+		if item.Expired(now) {
+			continue
+		}
+		item.Payload = nil
+		item.Headers = nil
+		out = append(out, item)
+	}
+	return out
+}
+
+// Stats returns the current counters.
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	c.mu.RLock()
+	stats := Stats{
+		Enabled: c.backend != nil,
+		Backend: c.backendName,
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Stores:  c.stores,
+	}
+	backend := c.backend
+	c.mu.RUnlock()
+	if backend != nil {
+		stats.Entries = len(backend.List())
+	}
+	return stats
+}
+
+// Deterministic reports whether the payload pins sampling to temperature 0, which is the
+// only case where replaying a stored response is equivalent to asking the provider again.
+func Deterministic(payload []byte) bool {
+	for _, path := range []string{"temperature", "generationConfig.temperature"} {
+		if value := gjson.GetBytes(payload, path); value.Exists() {
+			return value.Type == gjson.Number && value.Float() == 0
+		}
+	}
+	return false
+}
+
+// Key returns the cache key for a request, hashing the client format, the client principal,
+// the resolved model and the payload with object keys sorted and non-semantic fields removed.
+// The payload is the raw client request, before translation, so the format is part of the
+// key; the principal keeps one client from being served another client's responses.
+func Key(format, principal, model string, payload []byte) (string, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var body map[string]any
+	if err := decoder.Decode(&body); err != nil || body == nil {
+		return "", false
+	}
+	for _, field := range ignoredPayloadFields {
+		delete(body, field)
+	}
+	canonical, err := json.Marshal(body)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.New()
+	sum.Write([]byte(strings.TrimSpace(format)))
+	sum.Write([]byte{0})
+	sum.Write([]byte(strings.TrimSpace(principal)))
+	sum.Write([]byte{0})
+	sum.Write([]byte(strings.TrimSpace(model)))
+	sum.Write([]byte{0})
+	sum.Write(canonical)
+	return hex.EncodeToString(sum.Sum(nil)), true
+}
+
+// ParseUsage extracts the token usage reported in a client-format response so cache hits can
+// be accounted with the tokens the original request consumed.
+func ParseUsage(payload []byte) coreusage.Detail {
+	root := gjson.ParseBytes(payload)
+	if meta := root.Get("usageMetadata"); meta.Exists() {
+		return coreusage.Detail{
+			InputTokens:     meta.Get("promptTokenCount").Int(),
+			OutputTokens:    meta.Get("candidatesTokenCount").Int(),
+			ReasoningTokens: meta.Get("thoughtsTokenCount").Int(),
+			CachedTokens:    meta.Get("cachedContentTokenCount").Int(),
+			TotalTokens:     meta.Get("totalTokenCount").Int(),
+		}
+	}
+	usage := root.Get("usage")
+	if !usage.Exists() {
+		return coreusage.Detail{}
+	}
+	if usage.Get("prompt_tokens").Exists() {
+		return coreusage.Detail{
+			InputTokens:     usage.Get("prompt_tokens").Int(),
+			OutputTokens:    usage.Get("completion_tokens").Int(),
+			ReasoningTokens: usage.Get("completion_tokens_details.reasoning_tokens").Int(),
+			CachedTokens:    usage.Get("prompt_tokens_details.cached_tokens").Int(),
+			TotalTokens:     usage.Get("total_tokens").Int(),
+		}
+	}
+	detail := coreusage.Detail{
+		InputTokens:         usage.Get("input_tokens").Int(),
+		OutputTokens:        usage.Get("output_tokens").Int(),
+		ReasoningTokens:     usage.Get("output_tokens_details.reasoning_tokens").Int(),
+		CachedTokens:        usage.Get("input_tokens_details.cached_tokens").Int(),
+		CacheReadTokens:     usage.Get("cache_read_input_tokens").Int(),
+		CacheCreationTokens: usage.Get("cache_creation_input_tokens").Int(),
+		TotalTokens:         usage.Get("total_tokens").Int(),
+	}
+	if detail.TotalTokens == 0 {
+		detail.TotalTokens = detail.InputTokens + detail.OutputTokens
+	}
+	return detail
+}
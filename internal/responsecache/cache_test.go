@@ -0,0 +1,130 @@
+package responsecache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKeyIgnoresFieldOrderAndNonSemanticFields(t *testing.T) {
+	a, ok := Key("openai", "k1", "gpt-5", []byte(`{"model":"gpt-5","temperature":0,"messages":[{"role":"user","content":"hi"}],"user":"ci-1"}`))
+	if !ok {
+		t.Fatal("expected payload to be hashable")
+	}
+	c, _ := Key("openai", "k1", "gpt-5", []byte(`{"messages":[{"content":"hi","role":"user"}],"temperature":0,"model":"gpt-5","stream":false,"metadata":{"user_id":"x"}}`))
+	if a != c {
+		t.Fatalf("expected reordered payload to share a key: %s != %s", a, c)
+	}
+	d, _ := Key("claude", "k1", "gpt-5", []byte(`{"model":"gpt-5","temperature":0,"messages":[{"role":"user","content":"hi"}]}`))
+	if a == d {
+		t.Fatal("expected client format to be part of the key")
+	}
+	e, _ := Key("openai", "k2", "gpt-5", []byte(`{"model":"gpt-5","temperature":0,"messages":[{"role":"user","content":"hi"}]}`))
+	if a == e {
+		t.Fatal("expected the client principal to be part of the key")
+	}
+	if _, ok = Key("openai", "k1", "gpt-5", []byte(`not json`)); ok {
+		t.Fatal("expected invalid JSON to be rejected")
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	cases := map[string]bool{
+		`{"temperature":0}`:                      true,
+		`{"generationConfig":{"temperature":0}}`: true,
+		`{"temperature":0.2}`:                    false,
+		`{"messages":[]}`:                        false,
+		`{"generationConfig":{"temperature":1}}`: false,
+		`{"temperature":"0"}`:                    false,
+	}
+	for payload, want := range cases {
+		if got := Deterministic([]byte(payload)); got != want {
+			t.Errorf("Deterministic(%s) = %t, want %t", payload, got, want)
+		}
+	}
+}
+
+func TestMemoryBackendEvictsLeastRecentlyUsed(t *testing.T) {
+	backend := NewMemoryBackend(2)
+	backend.Set(Entry{Key: "a"})
+	backend.Set(Entry{Key: "b"})
+	if _, ok := backend.Get("a"); !ok {
+		t.Fatal("expected a to be present")
+	}
+	backend.Set(Entry{Key: "c"})
+	if _, ok := backend.Get("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	if _, ok := backend.Get("a"); !ok {
+		t.Fatal("expected recently used a to survive")
+	}
+}
+
+func TestCacheExpiresEntriesAndPersistsOnDisk(t *testing.T) {
+	backend, err := NewDiskBackend(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewDiskBackend: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cache := &Cache{now: func() time.Time { return now }}
+	cache.Configure(backend, "disk", time.Minute)
+
+	key, _ := Key("openai", "", "m", []byte(`{"temperature":0}`))
+	cache.Store(Entry{Key: key, Model: "m", Payload: []byte(`{"ok":true}`)})
+	if _, ok := cache.Lookup("../escape"); ok {
+		t.Fatal("expected invalid key to miss")
+	}
+
+	reopened, err := NewDiskBackend(backend.Dir(), 10)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	cache.Configure(reopened, "disk", time.Minute)
+	entry, ok := cache.Lookup(key)
+	if !ok || string(entry.Payload) != `{"ok":true}` {
+		t.Fatalf("expected persisted entry, got %+v ok=%t", entry, ok)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok = cache.Lookup(key); ok {
+		t.Fatal("expected entry to expire")
+	}
+	if len(reopened.List()) != 0 {
+		t.Fatal("expected expired entry to be removed from disk")
+	}
+}
+
+func TestDiskBackendEvictsOldestFiles(t *testing.T) {
+	backend, err := NewDiskBackend(t.TempDir(), 2)
+	if err != nil {
+		t.Fatalf("NewDiskBackend: %v", err)
+	}
+	base := time.Now().Add(-time.Hour)
+	for i, key := range []string{"aa", "bb"} {
+		backend.Set(Entry{Key: key})
+		stamp := base.Add(time.Duration(i) * time.Minute)
+		if errTimes := os.Chtimes(filepath.Join(backend.Dir(), key+diskEntrySuffix), stamp, stamp); errTimes != nil {
+			t.Fatalf("Chtimes: %v", errTimes)
+		}
+	}
+	backend.Set(Entry{Key: "cc"})
+	if _, ok := backend.Get("aa"); ok {
+		t.Fatal("expected the oldest file to be evicted")
+	}
+	if _, ok := backend.Get("bb"); !ok {
+		t.Fatal("expected bb to survive")
+	}
+	if _, ok := backend.Get("cc"); !ok {
+		t.Fatal("expected cc to survive")
+	}
+}
+
+func TestParseUsage(t *testing.T) {
+	if got := ParseUsage([]byte(`{"usage":{"input_tokens":4,"output_tokens":6}}`)); got.TotalTokens != 10 {
+		t.Fatalf("claude usage = %+v", got)
+	}
+	if got := ParseUsage([]byte(`{"usageMetadata":{"promptTokenCount":1,"candidatesTokenCount":2,"totalTokenCount":3}}`)); got.OutputTokens != 2 || got.TotalTokens != 3 {
+		t.Fatalf("gemini usage = %+v", got)
+	}
+}
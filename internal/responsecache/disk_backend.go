@@ -0,0 +1,220 @@
+package responsecache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const diskEntrySuffix = ".json"
+
+// DiskBackend stores one JSON file per entry so cached responses survive restarts.
+type DiskBackend struct {
+	dir        string
+	maxEntries int
+
+	mu sync.Mutex
+}
+
+// NewDiskBackend creates dir when needed and returns a backend writing into it.
+func NewDiskBackend(dir string, maxEntries int) (*DiskBackend, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("response cache: directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("response cache: create directory: %w", err)
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &DiskBackend{dir: dir, maxEntries: maxEntries}, nil
+}
+
+// Dir returns the directory holding the cache files.
+func (b *DiskBackend) Dir() string { return b.dir }
+
+// Get reads the entry stored under key.
+func (b *DiskBackend) Get(key string) (Entry, bool) {
+	path, ok := b.pathForKey(key)
+	if !ok {
+		return Entry{}, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return readDiskEntry(path)
+}
+
+// Set writes entry atomically and drops the oldest entries beyond the limit.
+func (b *DiskBackend) Set(entry Entry) {
+	path, ok := b.pathForKey(entry.Key)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Warnf("response cache: encode entry: %v", err)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		log.Warnf("response cache: write entry: %v", err)
+		return
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		log.Warnf("response cache: rename entry: %v", err)
+		return
+	}
+	b.evictLocked()
+}
+
+// Delete removes key.
+func (b *DiskBackend) Delete(key string) bool {
+	path, ok := b.pathForKey(key)
+	if !ok {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return os.Remove(path) == nil
+}
+
+// Purge removes every entry.
+func (b *DiskBackend) Purge() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	removed := 0
+	for _, path := range b.entryPathsLocked() {
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// List returns every stored entry, newest first.
+func (b *DiskBackend) List() []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.listLocked()
+}
+
+func (b *DiskBackend) listLocked() []Entry {
+	paths := b.entryPathsLocked()
+	out := make([]Entry, 0, len(paths))
+	for _, path := range paths {
+		if entry, ok := readDiskEntry(path); ok {
+			out = append(out, entry)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+type diskEntryFile struct {
+	path    string
+	modTime time.Time
+}
+
+// evictLocked removes the least recently written files beyond the entry limit. File
+// modification times stand in for creation times so eviction does not parse every entry.
+func (b *DiskBackend) evictLocked() {
+	files := b.entryFilesLocked()
+	if len(files) <= b.maxEntries {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	for _, file := range files[b.maxEntries:] {
+		_ = os.Remove(file.path)
+	}
+}
+
+func (b *DiskBackend) entryPathsLocked() []string {
+	files := b.entryFilesLocked()
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.path)
+	}
+	return paths
+}
+
+func (b *DiskBackend) entryFilesLocked() []diskEntryFile {
+	items, err := os.ReadDir(b.dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("response cache: read directory: %v", err)
+		}
+		return nil
+	}
+	files := make([]diskEntryFile, 0, len(items))
+	for _, item := range items {
+		if item.IsDir() || !strings.HasSuffix(item.Name(), diskEntrySuffix) {
+			continue
+		}
+		info, errInfo := item.Info()
+		if errInfo != nil {
+			continue
+		}
+		files = append(files, diskEntryFile{path: filepath.Join(b.dir, item.Name()), modTime: info.ModTime()})
+	}
+	return files
+}
+
+// pathForKey rejects keys that are not plain hex digests so they cannot escape the directory.
+func (b *DiskBackend) pathForKey(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	for _, r := range key {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return "", false
+		}
+	}
+	return filepath.Join(b.dir, key+diskEntrySuffix), true
+}
+
+func readDiskEntry(path string) (Entry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, false
+	}
+	var entry Entry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// ResolveDirectory returns the on-disk cache directory for cfg.
+func ResolveDirectory(cfg *config.Config) string {
+	if cfg != nil {
+		if dir := strings.TrimSpace(cfg.ResponseCache.Dir); dir != "" {
+			if resolved, err := util.ResolveAuthDir(dir); err == nil && resolved != "" {
+				return resolved
+			}
+			return dir
+		}
+	}
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "response-cache")
+	}
+	if cfg != nil {
+		if authDir, err := util.ResolveAuthDir(cfg.AuthDir); err == nil && authDir != "" {
+			return filepath.Join(authDir, "response-cache")
+		}
+	}
+	return "response-cache"
+}
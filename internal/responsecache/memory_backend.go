@@ -0,0 +1,89 @@
+package responsecache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// MemoryBackend is a bounded in-memory LRU store.
+type MemoryBackend struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+// NewMemoryBackend returns an LRU backend holding at most maxEntries responses.
+func NewMemoryBackend(maxEntries int) *MemoryBackend {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &MemoryBackend{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry for key and marks it as most recently used.
+func (b *MemoryBackend) Get(key string) (Entry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	elem, ok := b.items[key]
+	if !ok {
+		return Entry{}, false
+	}
+	b.order.MoveToFront(elem)
+	return elem.Value.(Entry), true
+}
+
+// Set stores entry, evicting the least recently used entries beyond the limit.
+func (b *MemoryBackend) Set(entry Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elem, ok := b.items[entry.Key]; ok {
+		elem.Value = entry
+		b.order.MoveToFront(elem)
+		return
+	}
+	b.items[entry.Key] = b.order.PushFront(entry)
+	for b.order.Len() > b.maxEntries {
+		oldest := b.order.Back()
+		b.order.Remove(oldest)
+		delete(b.items, oldest.Value.(Entry).Key)
+	}
+}
+
+// Delete removes key.
+func (b *MemoryBackend) Delete(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	elem, ok := b.items[key]
+	if !ok {
+		return false
+	}
+	b.order.Remove(elem)
+	delete(b.items, key)
+	return true
+}
+
+// Purge removes every entry.
+func (b *MemoryBackend) Purge() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.order.Len()
+	b.order.Init()
+	b.items = make(map[string]*list.Element)
+	return n
+}
+
+// List returns the entries from most to least recently used.
+func (b *MemoryBackend) List() []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]Entry, 0, b.order.Len())
+	for elem := b.order.Front(); elem != nil; elem = elem.Next() {
+		out = append(out, elem.Value.(Entry))
+	}
+	return out
+}
@@ -0,0 +1,187 @@
+package responsestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	diskRecordSuffix = ".json"
+	maxRecordIDLen   = 200
+)
+
+// DiskBackend stores one JSON file per response so conversations survive restarts.
+type DiskBackend struct {
+	dir        string
+	maxEntries int
+
+	mu sync.Mutex
+}
+
+// NewDiskBackend creates dir when needed and returns a backend writing into it.
+func NewDiskBackend(dir string, maxEntries int) (*DiskBackend, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("response store: directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("response store: create directory: %w", err)
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &DiskBackend{dir: dir, maxEntries: maxEntries}, nil
+}
+
+// Dir returns the directory holding the record files.
+func (b *DiskBackend) Dir() string { return b.dir }
+
+// Get reads the record stored under id.
+func (b *DiskBackend) Get(id string) (Record, bool) {
+	path, ok := b.pathForID(id)
+	if !ok {
+		return Record{}, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Record{}, false
+	}
+	var record Record
+	if err = json.Unmarshal(data, &record); err != nil {
+		return Record{}, false
+	}
+	return record, true
+}
+
+// Set writes record atomically and drops the oldest records beyond the limit.
+func (b *DiskBackend) Set(record Record) {
+	path, ok := b.pathForID(record.ID)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.Warnf("response store: encode record: %v", err)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		log.Warnf("response store: write record: %v", err)
+		return
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		log.Warnf("response store: rename record: %v", err)
+		return
+	}
+	b.evictLocked()
+}
+
+// Delete removes id.
+func (b *DiskBackend) Delete(id string) bool {
+	path, ok := b.pathForID(id)
+	if !ok {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return os.Remove(path) == nil
+}
+
+// Len returns the number of stored records.
+func (b *DiskBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.recordFilesLocked())
+}
+
+type diskRecordFile struct {
+	path    string
+	modTime time.Time
+}
+
+// evictLocked removes the least recently written files beyond the entry limit. File
+// modification times stand in for creation times so eviction does not parse every record.
+func (b *DiskBackend) evictLocked() {
+	files := b.recordFilesLocked()
+	if len(files) <= b.maxEntries {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	for _, file := range files[b.maxEntries:] {
+		_ = os.Remove(file.path)
+	}
+}
+
+func (b *DiskBackend) recordFilesLocked() []diskRecordFile {
+	items, err := os.ReadDir(b.dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("response store: read directory: %v", err)
+		}
+		return nil
+	}
+	files := make([]diskRecordFile, 0, len(items))
+	for _, item := range items {
+		if item.IsDir() || !strings.HasSuffix(item.Name(), diskRecordSuffix) {
+			continue
+		}
+		info, errInfo := item.Info()
+		if errInfo != nil {
+			continue
+		}
+		files = append(files, diskRecordFile{path: filepath.Join(b.dir, item.Name()), modTime: info.ModTime()})
+	}
+	return files
+}
+
+// pathForID rejects IDs outside [A-Za-z0-9_-] so they cannot escape the directory.
+func (b *DiskBackend) pathForID(id string) (string, bool) {
+	if id == "" || len(id) > maxRecordIDLen {
+		return "", false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return "", false
+		}
+	}
+	return filepath.Join(b.dir, id+diskRecordSuffix), true
+}
+
+// ResolveDirectory returns the on-disk store directory for cfg.
+func ResolveDirectory(cfg *config.Config) string {
+	if cfg != nil {
+		if dir := strings.TrimSpace(cfg.ResponsesStore.Dir); dir != "" {
+			if resolved, err := util.ResolveAuthDir(dir); err == nil && resolved != "" {
+				return resolved
+			}
+			return dir
+		}
+	}
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "responses-store")
+	}
+	if cfg != nil {
+		if authDir, err := util.ResolveAuthDir(cfg.AuthDir); err == nil && authDir != "" {
+			return filepath.Join(authDir, "responses-store")
+		}
+	}
+	return "responses-store"
+}
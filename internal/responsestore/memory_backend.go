@@ -0,0 +1,75 @@
+package responsestore
+
+import (
+	"container/list"
+	"sync"
+)
+
+// MemoryBackend is a bounded in-memory LRU store.
+type MemoryBackend struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+// NewMemoryBackend returns an LRU backend holding at most maxEntries records.
+func NewMemoryBackend(maxEntries int) *MemoryBackend {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &MemoryBackend{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get returns the record for id and marks it as most recently used.
+func (b *MemoryBackend) Get(id string) (Record, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	elem, ok := b.items[id]
+	if !ok {
+		return Record{}, false
+	}
+	b.order.MoveToFront(elem)
+	return elem.Value.(Record), true
+}
+
+// Set stores record, evicting the least recently used records beyond the limit.
+func (b *MemoryBackend) Set(record Record) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elem, ok := b.items[record.ID]; ok {
+		elem.Value = record
+		b.order.MoveToFront(elem)
+		return
+	}
+	b.items[record.ID] = b.order.PushFront(record)
+	for b.order.Len() > b.maxEntries {
+		oldest := b.order.Back()
+		b.order.Remove(oldest)
+		delete(b.items, oldest.Value.(Record).ID)
+	}
+}
+
+// Delete removes id.
+func (b *MemoryBackend) Delete(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	elem, ok := b.items[id]
+	if !ok {
+		return false
+	}
+	b.order.Remove(elem)
+	delete(b.items, id)
+	return true
+}
+
+// Len returns the number of stored records.
+func (b *MemoryBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.order.Len()
+}
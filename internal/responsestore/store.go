@@ -0,0 +1,170 @@
+// Package responsestore keeps the input and output items of Responses API calls so that
+// stateless HTTP clients can continue a conversation with previous_response_id and read
+// responses back by ID.
+package responsestore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL is used when the configured TTL is not positive.
+	DefaultTTL = 30 * 24 * time.Hour
+	// DefaultMaxEntries is used when the configured entry limit is not positive.
+	DefaultMaxEntries = 10000
+)
+
+// Record is a stored response together with the input items its request added. The items
+// of earlier turns live in the records reached through PreviousResponseID.
+type Record struct {
+	ID                 string          `json:"id"`
+	Model              string          `json:"model,omitempty"`
+	Owner              string          `json:"owner,omitempty"`
+	PreviousResponseID string          `json:"previous_response_id,omitempty"`
+	Input              json.RawMessage `json:"input"`
+	Output             json.RawMessage `json:"output"`
+	Response           json.RawMessage `json:"response"`
+	CreatedAt          time.Time       `json:"created_at"`
+	ExpiresAt          time.Time       `json:"expires_at"`
+}
+
+// Expired reports whether the record is past its expiry at now.
+func (r Record) Expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && !now.Before(r.ExpiresAt)
+}
+
+// Backend persists records.
+type Backend interface {
+	Get(id string) (Record, bool)
+	Set(record Record)
+	Delete(id string) bool
+	Len() int
+}
+
+// Store fronts the configured backend with TTL and ownership checks.
+type Store struct {
+	mu      sync.RWMutex
+	backend Backend
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+var defaultStore = &Store{now: time.Now}
+
+// Default returns the process-wide response store.
+func Default() *Store { return defaultStore }
+
+// Configure swaps the backend and TTL. A nil backend disables the store.
+// The previous backend is returned so callers can release it.
+func (s *Store) Configure(backend Backend, ttl time.Duration) Backend {
+	if s == nil {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	s.mu.Lock()
+	previous := s.backend
+	s.backend = backend
+	s.ttl = ttl
+	s.mu.Unlock()
+	return previous
+}
+
+// Backend returns the active backend or nil when the store is disabled.
+func (s *Store) Backend() Backend {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.backend
+}
+
+// Enabled reports whether a backend is configured.
+func (s *Store) Enabled() bool { return s.Backend() != nil }
+
+// Get returns the unexpired record for id when it belongs to owner. A record saved without
+// an owner is only visible to requests without one, so authenticated clients never see it.
+func (s *Store) Get(id, owner string) (Record, bool) {
+	backend := s.Backend()
+	if backend == nil || strings.TrimSpace(id) == "" {
+		return Record{}, false
+	}
+	record, ok := backend.Get(id)
+	if !ok {
+		return Record{}, false
+	}
+	if record.Expired(s.now()) {
+		backend.Delete(id)
+		return Record{}, false
+	}
+	if record.Owner != OwnerKey(owner) {
+		return Record{}, false
+	}
+	return record, true
+}
+
+// Conversation returns the record for id and the ancestors reached through
+// PreviousResponseID, oldest first. When a record of the chain is gone, expired or owned by
+// someone else, missing is its ID and records holds only the turns after it.
+func (s *Store) Conversation(id, owner string) (records []Record, missing string) {
+	seen := make(map[string]struct{})
+	for id != "" {
+		if _, loop := seen[id]; loop {
+			break
+		}
+		seen[id] = struct{}{}
+		record, ok := s.Get(id, owner)
+		if !ok {
+			missing = id
+			break
+		}
+		records = append(records, record)
+		id = record.PreviousResponseID
+	}
+	slices.Reverse(records)
+	return records, missing
+}
+
+// Save stores record for owner, stamping its creation and expiry times.
+func (s *Store) Save(record Record, owner string) {
+	if s == nil || strings.TrimSpace(record.ID) == "" {
+		return
+	}
+	s.mu.RLock()
+	backend, ttl := s.backend, s.ttl
+	s.mu.RUnlock()
+	if backend == nil {
+		return
+	}
+	record.Owner = OwnerKey(owner)
+	record.CreatedAt = s.now().UTC()
+	record.ExpiresAt = record.CreatedAt.Add(ttl)
+	backend.Set(record)
+}
+
+// Delete removes the record for id when it belongs to owner.
+func (s *Store) Delete(id, owner string) bool {
+	if _, ok := s.Get(id, owner); !ok {
+		return false
+	}
+	return s.Backend().Delete(id)
+}
+
+// OwnerKey hashes a client credential so records can be scoped to it without persisting the
+// credential itself. An empty credential yields an empty key.
+func OwnerKey(principal string) string {
+	principal = strings.TrimSpace(principal)
+	if principal == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(principal))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,88 @@
+package responsestore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreScopesRecordsToOwnerAndExpires(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := &Store{now: func() time.Time { return now }}
+	store.Configure(NewMemoryBackend(10), time.Hour)
+
+	store.Save(Record{ID: "resp_1", Input: []byte(`[]`), Output: []byte(`[]`), Response: []byte(`{}`)}, "key-a")
+	if _, ok := store.Get("resp_1", "key-a"); !ok {
+		t.Fatal("expected owner to read its record")
+	}
+	if _, ok := store.Get("resp_1", "key-b"); ok {
+		t.Fatal("expected another client key to be denied")
+	}
+	if store.Delete("resp_1", "key-b") {
+		t.Fatal("expected another client key to be unable to delete")
+	}
+	store.Save(Record{ID: "resp_anon", Input: []byte(`[]`), Output: []byte(`[]`), Response: []byte(`{}`)}, "")
+	if _, ok := store.Get("resp_anon", "key-a"); ok {
+		t.Fatal("expected a record without owner to be hidden from client keys")
+	}
+	if !store.Delete("resp_anon", "") {
+		t.Fatal("expected a record without owner to stay reachable without a key")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok := store.Get("resp_1", "key-a"); ok {
+		t.Fatal("expected record to expire")
+	}
+	if store.Backend().Len() != 0 {
+		t.Fatal("expected expired record to be dropped")
+	}
+}
+
+func TestDiskBackendPersistsAndRejectsUnsafeIDs(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := NewDiskBackend(dir, 2)
+	if err != nil {
+		t.Fatalf("NewDiskBackend: %v", err)
+	}
+	backend.Set(Record{ID: "resp_a", Model: "gpt-5", Input: []byte(`[{"type":"message"}]`), Output: []byte(`[]`), Response: []byte(`{"id":"resp_a"}`)})
+
+	reopened, err := NewDiskBackend(dir, 2)
+	if err != nil {
+		t.Fatalf("NewDiskBackend: %v", err)
+	}
+	record, ok := reopened.Get("resp_a")
+	if !ok || record.Model != "gpt-5" || string(record.Input) != `[{"type":"message"}]` {
+		t.Fatalf("unexpected record after reopen: %+v ok=%t", record, ok)
+	}
+
+	reopened.Set(Record{ID: "../escape", Response: []byte(`{}`)})
+	if _, ok = reopened.Get("../escape"); ok {
+		t.Fatal("expected path traversal IDs to be rejected")
+	}
+
+	reopened.Set(Record{ID: "resp_b", Response: []byte(`{}`)})
+	time.Sleep(10 * time.Millisecond)
+	reopened.Set(Record{ID: "resp_c", Response: []byte(`{}`)})
+	if got := reopened.Len(); got != 2 {
+		t.Fatalf("Len() = %d, want 2", got)
+	}
+}
+
+func TestStoreConversationWalksPreviousResponses(t *testing.T) {
+	store := &Store{now: time.Now}
+	store.Configure(NewMemoryBackend(10), time.Hour)
+	store.Save(Record{ID: "resp_1"}, "key-a")
+	store.Save(Record{ID: "resp_2", PreviousResponseID: "resp_1"}, "key-a")
+	store.Save(Record{ID: "resp_3", PreviousResponseID: "resp_2"}, "key-a")
+
+	records, missing := store.Conversation("resp_3", "key-a")
+	if missing != "" || len(records) != 3 || records[0].ID != "resp_1" || records[2].ID != "resp_3" {
+		t.Fatalf("Conversation() = %+v, %q; want resp_1..resp_3", records, missing)
+	}
+	if _, missing = store.Conversation("resp_3", "key-b"); missing != "resp_3" {
+		t.Fatalf("Conversation() for another key missing = %q, want resp_3", missing)
+	}
+	store.Delete("resp_1", "key-a")
+	if records, missing = store.Conversation("resp_3", "key-a"); missing != "resp_1" || len(records) != 2 {
+		t.Fatalf("Conversation() after delete = %+v, %q; want two records and resp_1 missing", records, missing)
+	}
+}
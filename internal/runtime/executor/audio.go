@@ -0,0 +1,327 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	audioTranscriptionsPath = "/audio/transcriptions"
+	audioTranslationsPath   = "/audio/translations"
+	audioSpeechPath         = "/audio/speech"
+
+	// geminiInlineAudioLimit is the largest upload sent as inline data; Gemini rejects
+	// generateContent requests above 20 MB.
+	geminiInlineAudioLimit = 20 << 20
+)
+
+// isAudioRequest reports whether the request originates from an audio handler.
+func isAudioRequest(opts cliproxyexecutor.Options) bool {
+	return opts.SourceFormat.String() == constant.OpenAIAudio
+}
+
+// errAudioUnsupported is returned by executors whose upstream has no audio API.
+var errAudioUnsupported = statusErr{code: http.StatusBadRequest, msg: "audio not supported by provider"}
+
+// audioEndpointPath returns the OpenAI audio endpoint targeted by the inbound request.
+// Requests without a recognised path are classified by payload: speech requests are JSON,
+// transcriptions are multipart uploads.
+func audioEndpointPath(opts cliproxyexecutor.Options, payload []byte) string {
+	path := helps.PayloadRequestPath(opts)
+	switch {
+	case strings.HasSuffix(path, audioTranslationsPath):
+		return audioTranslationsPath
+	case strings.HasSuffix(path, audioSpeechPath):
+		return audioSpeechPath
+	case strings.HasSuffix(path, audioTranscriptionsPath):
+		return audioTranscriptionsPath
+	case gjson.ValidBytes(payload):
+		return audioSpeechPath
+	default:
+		return audioTranscriptionsPath
+	}
+}
+
+// executeAudio forwards audio requests to the provider's OpenAI audio endpoints unchanged
+// apart from the upstream model name.
+func (e *OpenAICompatExecutor) executeAudio(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	baseURL, apiKey := e.resolveCredentials(auth)
+	if baseURL == "" {
+		err = statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+		return resp, err
+	}
+
+	payload, contentType, err := prepareOpenAICompatImagesPayload(req.Payload, baseModel, opts.Headers.Get("Content-Type"), false)
+	if err != nil {
+		err = statusErr{code: http.StatusBadRequest, msg: err.Error()}
+		return resp, err
+	}
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	url := strings.TrimSuffix(baseURL, "/") + audioEndpointPath(opts, req.Payload)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return resp, err
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
+
+	data, headers, err := doBufferedRequest(ctx, e.cfg, auth, e.Identifier(), httpReq, payload)
+	if err != nil {
+		return resp, err
+	}
+	reporter.Publish(ctx, helps.ParseOpenAIUsage(data))
+	reporter.EnsurePublished(ctx)
+	return cliproxyexecutor.Response{Payload: data, Headers: headers}, nil
+}
+
+// geminiAudioRequest is a transcription or translation upload converted for generateContent.
+type geminiAudioRequest struct {
+	body           []byte
+	task           string
+	language       string
+	responseFormat string
+}
+
+// buildGeminiAudioRequest converts an OpenAI transcription or translation upload into a
+// generateContent request carrying the audio as inline data.
+func buildGeminiAudioRequest(payload []byte, contentType, endpoint string) (geminiAudioRequest, error) {
+	var out geminiAudioRequest
+	if endpoint == audioSpeechPath {
+		return out, statusErr{code: http.StatusNotImplemented, msg: "/audio/speech is not supported for Gemini models"}
+	}
+	mediaType, params, errParse := mime.ParseMediaType(contentType)
+	if errParse != nil || !strings.HasPrefix(strings.ToLower(mediaType), "multipart/") || params["boundary"] == "" {
+		return out, statusErr{code: http.StatusBadRequest, msg: "audio upload must be multipart/form-data"}
+	}
+	form, errRead := multipart.NewReader(bytes.NewReader(payload), params["boundary"]).ReadForm(openAICompatMultipartMemory)
+	if errRead != nil {
+		return out, statusErr{code: http.StatusBadRequest, msg: fmt.Sprintf("read multipart form failed: %v", errRead)}
+	}
+	defer func() {
+		if errRemove := form.RemoveAll(); errRemove != nil {
+			log.Errorf("gemini executor: remove multipart form files error: %v", errRemove)
+		}
+	}()
+
+	field := func(key string) string {
+		if values := form.Value[key]; len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+	out.responseFormat = strings.ToLower(field("response_format"))
+	switch out.responseFormat {
+	case "":
+		out.responseFormat = "json"
+	case "json", "text", "verbose_json":
+	default:
+		return out, statusErr{code: http.StatusBadRequest, msg: fmt.Sprintf("response_format %q is not supported for Gemini models", out.responseFormat)}
+	}
+
+	files := form.File["file"]
+	if len(files) == 0 || files[0] == nil {
+		return out, statusErr{code: http.StatusBadRequest, msg: "file is required"}
+	}
+	if files[0].Size > geminiInlineAudioLimit {
+		return out, statusErr{code: http.StatusRequestEntityTooLarge, msg: "audio file exceeds the 20 MB inline limit for Gemini models"}
+	}
+	src, errOpen := files[0].Open()
+	if errOpen != nil {
+		return out, fmt.Errorf("open upload file failed: %w", errOpen)
+	}
+	audio, errAudio := io.ReadAll(src)
+	if errClose := src.Close(); errClose != nil {
+		log.Errorf("gemini executor: close upload file error: %v", errClose)
+	}
+	if errAudio != nil {
+		return out, fmt.Errorf("read upload file failed: %w", errAudio)
+	}
+
+	out.task = "transcribe"
+	instruction := "Transcribe the speech in this audio verbatim. Respond with the transcript only."
+	if endpoint == audioTranslationsPath {
+		out.task = "translate"
+		instruction = "Translate the speech in this audio into English. Respond with the English translation only."
+	} else if out.language = field("language"); out.language != "" {
+		instruction += " The spoken language is " + out.language + "."
+	}
+	if prompt := field("prompt"); prompt != "" {
+		instruction += "\nUse this context for spelling and style: " + prompt
+	}
+
+	body := []byte(`{"contents":[{"role":"user","parts":[{"text":""},{"inlineData":{"mimeType":"","data":""}}]}]}`)
+	body, _ = sjson.SetBytes(body, "contents.0.parts.0.text", instruction)
+	body, _ = sjson.SetBytes(body, "contents.0.parts.1.inlineData.mimeType", audioMimeType(files[0], audio))
+	body, _ = sjson.SetBytes(body, "contents.0.parts.1.inlineData.data", base64.StdEncoding.EncodeToString(audio))
+	if raw := field("temperature"); raw != "" {
+		if temperature, errTemp := strconv.ParseFloat(raw, 64); errTemp == nil {
+			body, _ = sjson.SetBytes(body, "generationConfig.temperature", temperature)
+		}
+	}
+	out.body = body
+	return out, nil
+}
+
+// audioMimeType returns the media type of an uploaded audio file, falling back to the file
+// extension and content sniffing when the client sent a generic type.
+func audioMimeType(fileHeader *multipart.FileHeader, data []byte) string {
+	mediaType := strings.TrimSpace(fileHeader.Header.Get("Content-Type"))
+	if mediaType != "" && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+	case ".mp3", ".mpga", ".mpeg":
+		return "audio/mp3"
+	case ".m4a", ".mp4":
+		return "audio/mp4"
+	case ".wav":
+		return "audio/wav"
+	case ".webm":
+		return "audio/webm"
+	case ".ogg", ".oga":
+		return "audio/ogg"
+	case ".flac":
+		return "audio/flac"
+	}
+	return http.DetectContentType(data)
+}
+
+// convertGeminiAudioResponse renders a generateContent response in the requested OpenAI
+// transcription response format.
+func convertGeminiAudioResponse(data []byte, audioReq geminiAudioRequest) ([]byte, string) {
+	var text strings.Builder
+	gjson.GetBytes(data, "candidates.0.content.parts").ForEach(func(_, part gjson.Result) bool {
+		if !part.Get("thought").Bool() {
+			text.WriteString(part.Get("text").String())
+		}
+		return true
+	})
+	transcript := strings.TrimSpace(text.String())
+
+	switch audioReq.responseFormat {
+	case "text":
+		return []byte(transcript + "\n"), "text/plain; charset=utf-8"
+	case "verbose_json":
+		out := []byte(`{"task":"","language":"","text":""}`)
+		out, _ = sjson.SetBytes(out, "task", audioReq.task)
+		language := audioReq.language
+		if audioReq.task == "translate" {
+			language = "english"
+		}
+		out, _ = sjson.SetBytes(out, "language", language)
+		out, _ = sjson.SetBytes(out, "text", transcript)
+		return out, "application/json"
+	default:
+		out := []byte(`{"text":""}`)
+		out, _ = sjson.SetBytes(out, "text", transcript)
+		if usageNode := gjson.GetBytes(data, "usageMetadata"); usageNode.Exists() {
+			out, _ = sjson.SetBytes(out, "usage.type", "tokens")
+			out, _ = sjson.SetBytes(out, "usage.input_tokens", usageNode.Get("promptTokenCount").Int())
+			out, _ = sjson.SetBytes(out, "usage.output_tokens", usageNode.Get("candidatesTokenCount").Int())
+			out, _ = sjson.SetBytes(out, "usage.total_tokens", usageNode.Get("totalTokenCount").Int())
+		}
+		return out, "application/json"
+	}
+}
+
+// geminiAudioResponse builds the executor response for a converted transcription.
+func geminiAudioResponse(data []byte, headers http.Header, audioReq geminiAudioRequest) cliproxyexecutor.Response {
+	out, contentType := convertGeminiAudioResponse(data, audioReq)
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Set("Content-Type", contentType)
+	headers.Del("Content-Length")
+	return cliproxyexecutor.Response{Payload: out, Headers: headers}
+}
+
+// executeAudio serves transcriptions and translations through generateContent with the
+// audio attached as inline data.
+func (e *GeminiExecutor) executeAudio(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	apiKey, bearer := geminiCreds(auth)
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	audioReq, err := buildGeminiAudioRequest(req.Payload, opts.Headers.Get("Content-Type"), audioEndpointPath(opts, req.Payload))
+	if err != nil {
+		return resp, err
+	}
+
+	url := fmt.Sprintf("%s/%s/models/%s:generateContent", resolveGeminiBaseURL(auth), glAPIVersion, baseModel)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(audioReq.body))
+	if err != nil {
+		return resp, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("x-goog-api-key", apiKey)
+	} else if bearer != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	applyGeminiHeaders(httpReq, auth)
+
+	data, headers, err := doBufferedRequest(ctx, e.cfg, auth, e.Identifier(), httpReq, audioReq.body)
+	if err != nil {
+		return resp, err
+	}
+	reporter.Publish(ctx, helps.ParseGeminiUsage(data))
+	reporter.EnsurePublished(ctx)
+	return geminiAudioResponse(data, headers, audioReq), nil
+}
+
+// executeAudio serves transcriptions and translations through the Vertex AI generateContent
+// action with the audio attached as inline data.
+func (e *GeminiVertexExecutor) executeAudio(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	audioReq, err := buildGeminiAudioRequest(req.Payload, opts.Headers.Get("Content-Type"), audioEndpointPath(opts, req.Payload))
+	if err != nil {
+		return resp, err
+	}
+	httpReq, err := e.newActionRequest(ctx, auth, baseModel, "generateContent", audioReq.body)
+	if err != nil {
+		return resp, err
+	}
+
+	data, headers, err := doBufferedRequest(ctx, e.cfg, auth, e.Identifier(), httpReq, audioReq.body)
+	if err != nil {
+		return resp, err
+	}
+	reporter.Publish(ctx, helps.ParseGeminiUsage(data))
+	reporter.EnsurePublished(ctx)
+	return geminiAudioResponse(data, headers, audioReq), nil
+}
@@ -0,0 +1,147 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func buildAudioUpload(t *testing.T, fields map[string]string, audio []byte) ([]byte, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			t.Fatalf("write field: %v", err)
+		}
+	}
+	part, err := writer.CreateFormFile("file", "clip.mp3")
+	if err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+	_, _ = part.Write(audio)
+	if err = writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+	return body.Bytes(), writer.FormDataContentType()
+}
+
+func audioOptions(path, contentType string, payload []byte) cliproxyexecutor.Options {
+	return cliproxyexecutor.Options{
+		SourceFormat:    sdktranslator.FormatOpenAIAudio,
+		OriginalRequest: payload,
+		Headers:         http.Header{"Content-Type": []string{contentType}},
+		Metadata:        map[string]any{cliproxyexecutor.RequestPathMetadataKey: path},
+	}
+}
+
+func TestGeminiExecutorAudioTranscriptionUsesInlineAudio(t *testing.T) {
+	var upstreamPath string
+	var upstreamBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamPath = r.URL.Path
+		upstreamBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"hello world"}]}}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":3,"totalTokenCount":15}}`))
+	}))
+	defer server.Close()
+
+	audio := []byte("ID3fake-audio")
+	payload, contentType := buildAudioUpload(t, map[string]string{"model": "gemini-2.5-flash", "language": "en", "temperature": "0"}, audio)
+	exec := NewGeminiExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "test-key", "base_url": server.URL}}
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gemini-2.5-flash", Payload: payload}, audioOptions("/v1/audio/transcriptions", contentType, payload))
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.HasSuffix(upstreamPath, "/models/gemini-2.5-flash:generateContent") {
+		t.Fatalf("upstream path = %q", upstreamPath)
+	}
+	inline := gjson.GetBytes(upstreamBody, "contents.0.parts.1.inlineData")
+	if inline.Get("mimeType").String() != "audio/mp3" || inline.Get("data").String() != base64.StdEncoding.EncodeToString(audio) {
+		t.Fatalf("inlineData = %s", inline.Raw)
+	}
+	if !strings.Contains(gjson.GetBytes(upstreamBody, "contents.0.parts.0.text").String(), "The spoken language is en.") {
+		t.Fatalf("instruction = %s", gjson.GetBytes(upstreamBody, "contents.0.parts.0.text").Raw)
+	}
+	if got := gjson.GetBytes(resp.Payload, "text").String(); got != "hello world" {
+		t.Fatalf("text = %q, payload=%s", got, resp.Payload)
+	}
+	if got := gjson.GetBytes(resp.Payload, "usage.total_tokens").Int(); got != 15 {
+		t.Fatalf("usage.total_tokens = %d", got)
+	}
+}
+
+func TestGeminiExecutorAudioRejectsSpeechAndSubtitleFormats(t *testing.T) {
+	exec := NewGeminiExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "test-key", "base_url": "http://127.0.0.1:0"}}
+
+	speech := []byte(`{"model":"gemini-2.5-flash","input":"hi","voice":"alloy"}`)
+	_, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gemini-2.5-flash", Payload: speech}, audioOptions("/v1/audio/speech", "application/json", speech))
+	if se, ok := err.(statusErr); !ok || se.StatusCode() != http.StatusNotImplemented {
+		t.Fatalf("speech error = %v", err)
+	}
+
+	payload, contentType := buildAudioUpload(t, map[string]string{"model": "gemini-2.5-flash", "response_format": "srt"}, []byte("x"))
+	_, err = exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gemini-2.5-flash", Payload: payload}, audioOptions("/v1/audio/transcriptions", contentType, payload))
+	if se, ok := err.(statusErr); !ok || se.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("srt error = %v", err)
+	}
+}
+
+func TestOpenAICompatExecutorAudioForwardsUpload(t *testing.T) {
+	var upstreamPath, upstreamModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamPath = r.URL.Path
+		if err := r.ParseMultipartForm(1 << 20); err == nil {
+			upstreamModel = r.FormValue("model")
+		}
+		_, _ = w.Write([]byte(`{"text":"bonjour"}`))
+	}))
+	defer server.Close()
+
+	payload, contentType := buildAudioUpload(t, map[string]string{"model": "whisper"}, []byte("audio"))
+	exec := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "k", "base_url": server.URL + "/v1"}}
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "whisper-1", Payload: payload}, audioOptions("/v1/audio/translations", contentType, payload))
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if upstreamPath != "/v1/audio/translations" {
+		t.Fatalf("upstream path = %q", upstreamPath)
+	}
+	if upstreamModel != "whisper-1" {
+		t.Fatalf("upstream model = %q", upstreamModel)
+	}
+	if string(resp.Payload) != `{"text":"bonjour"}` {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+}
+
+func TestExecutorsWithoutAudioRejectAudioRequests(t *testing.T) {
+	payload, contentType := buildAudioUpload(t, map[string]string{"model": "m"}, []byte("ID3audio"))
+	executors := map[string]cliproxyauth.ProviderExecutor{
+		"claude": NewClaudeExecutor(&config.Config{}),
+		"codex":  NewCodexExecutor(&config.Config{}),
+		"kimi":   NewKimiExecutor(&config.Config{}),
+	}
+	for name, exec := range executors {
+		_, err := exec.Execute(context.Background(), &cliproxyauth.Auth{Provider: name, Attributes: map[string]string{}}, cliproxyexecutor.Request{Model: "m", Payload: payload}, audioOptions(audioTranscriptionsPath, contentType, payload))
+		se, ok := err.(statusErr)
+		if !ok || se.code != http.StatusBadRequest || se.msg != "audio not supported by provider" {
+			t.Fatalf("%s: expected audio unsupported 400, got %v", name, err)
+		}
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -85,6 +87,12 @@ func NewClaudeExecutor(cfg *config.Config) *ClaudeExecutor { return &ClaudeExecu
 
 func (e *ClaudeExecutor) Identifier() string { return "claude" }
 
+// ReadQuota reads the anthropic-ratelimit-* response headers: request and token counts for API
+// keys, and the utilization of the rolling windows for subscription credentials.
+func (e *ClaudeExecutor) ReadQuota(_ *cliproxyauth.Auth, _ string, _ int, headers http.Header, _ []byte) *cliproxyauth.QuotaSnapshot {
+	return parseClaudeQuota(headers, time.Now())
+}
+
 // PrepareRequest injects Claude credentials into the outgoing HTTP request.
 func (e *ClaudeExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
 	if req == nil {
@@ -131,6 +139,12 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 	if opts.Alt == "responses/compact" {
 		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
 	}
+	if isEmbeddingRequest(opts) {
+		return resp, errEmbeddingsUnsupported
+	}
+	if isAudioRequest(opts) {
+		return resp, errAudioUnsupported
+	}
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
 	apiKey, baseURL := claudeCreds(auth)
@@ -149,8 +163,8 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	originalTranslated := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, originalPayload, stream)
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, stream)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
 	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
@@ -327,8 +341,8 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	originalTranslated := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, originalPayload, true)
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, true)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
 	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
@@ -592,7 +606,7 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 	to := sdktranslator.FromString("claude")
 	// Use streaming translation to preserve function calling, except for claude.
 	stream := from != to
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, stream)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
 	if !strings.HasPrefix(baseModel, "claude-3-5-haiku") {
@@ -2383,3 +2397,53 @@ func ensureModelMaxTokens(body []byte, modelID string) []byte {
 
 	return body
 }
+
+func parseClaudeQuota(headers http.Header, now time.Time) *cliproxyauth.QuotaSnapshot {
+	if len(headers) == 0 {
+		return nil
+	}
+	snapshot := &cliproxyauth.QuotaSnapshot{Source: "anthropic-ratelimit-headers", UpdatedAt: now}
+	for _, name := range []string{"requests", "tokens", "input-tokens", "output-tokens"} {
+		prefix := "anthropic-ratelimit-" + name + "-"
+		limit, errLimit := strconv.ParseInt(strings.TrimSpace(headers.Get(prefix+"limit")), 10, 64)
+		remaining, errRemaining := strconv.ParseInt(strings.TrimSpace(headers.Get(prefix+"remaining")), 10, 64)
+		if errLimit != nil || errRemaining != nil || limit <= 0 {
+			continue
+		}
+		window := cliproxyauth.QuotaWindow{Name: strings.ReplaceAll(name, "-", "_"), Limit: limit, Remaining: &remaining}
+		if resetAt, errReset := time.Parse(time.RFC3339, strings.TrimSpace(headers.Get(prefix+"reset"))); errReset == nil {
+			window.ResetAt = resetAt
+		}
+		snapshot.AddWindow(window)
+		switch name {
+		case "requests":
+			snapshot.RemainingRequests = &remaining
+		case "tokens":
+			snapshot.RemainingTokens = &remaining
+		}
+	}
+	const unifiedPrefix = "anthropic-ratelimit-unified-"
+	var unified []string
+	for key := range headers {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, unifiedPrefix) && strings.HasSuffix(lower, "-utilization") {
+			unified = append(unified, strings.TrimSuffix(strings.TrimPrefix(lower, unifiedPrefix), "-utilization"))
+		}
+	}
+	sort.Strings(unified)
+	for _, name := range unified {
+		utilization, err := strconv.ParseFloat(strings.TrimSpace(headers.Get(unifiedPrefix+name+"-utilization")), 64)
+		if err != nil {
+			continue
+		}
+		window := cliproxyauth.QuotaWindow{Name: name, UsedPercent: utilization * 100}
+		if resetAt, errReset := strconv.ParseInt(strings.TrimSpace(headers.Get(unifiedPrefix+name+"-reset")), 10, 64); errReset == nil && resetAt > 0 {
+			window.ResetAt = time.Unix(resetAt, 0)
+		}
+		snapshot.AddWindow(window)
+	}
+	if len(snapshot.Windows) == 0 {
+		return nil
+	}
+	return snapshot
+}
@@ -2261,3 +2261,42 @@ func TestRestoreClaudeOAuthToolNamesFromStreamLine_MixedCaseWithPrefix(t *testin
 		t.Fatalf("Glob should be restored to glob, got: %s", string(out))
 	}
 }
+
+func TestParseClaudeQuota(t *testing.T) {
+	now := time.Now()
+	resetAt := now.Add(30 * time.Second).UTC().Truncate(time.Second)
+
+	headers := http.Header{}
+	headers.Set("anthropic-ratelimit-requests-limit", "50")
+	headers.Set("anthropic-ratelimit-requests-remaining", "49")
+	headers.Set("anthropic-ratelimit-requests-reset", resetAt.Format(time.RFC3339))
+	headers.Set("anthropic-ratelimit-tokens-limit", "100000")
+	headers.Set("anthropic-ratelimit-tokens-remaining", "2000")
+	headers.Set("anthropic-ratelimit-tokens-reset", resetAt.Format(time.RFC3339))
+	snapshot := parseClaudeQuota(headers, now)
+	if snapshot == nil || len(snapshot.Windows) != 2 {
+		t.Fatalf("snapshot = %+v, want requests and tokens windows", snapshot)
+	}
+	if snapshot.RemainingRequests == nil || *snapshot.RemainingRequests != 49 || snapshot.RemainingTokens == nil || *snapshot.RemainingTokens != 2000 {
+		t.Fatalf("remaining = %v/%v", snapshot.RemainingRequests, snapshot.RemainingTokens)
+	}
+	if used := snapshot.UsedPercent(now); used != 98 {
+		t.Fatalf("UsedPercent() = %v, want 98", used)
+	}
+	if !snapshot.ResetAt.Equal(resetAt) {
+		t.Fatalf("ResetAt = %v, want %v", snapshot.ResetAt, resetAt)
+	}
+
+	unified := http.Header{}
+	unified.Set("anthropic-ratelimit-unified-5h-utilization", "0.42")
+	unified.Set("anthropic-ratelimit-unified-5h-reset", fmt.Sprint(now.Add(time.Hour).Unix()))
+	unified.Set("anthropic-ratelimit-unified-7d-utilization", "0.9")
+	snapshot = parseClaudeQuota(unified, now)
+	if snapshot == nil || len(snapshot.Windows) != 2 || snapshot.Windows[0].Name != "5h" || snapshot.Windows[1].UsedPercent != 90 {
+		t.Fatalf("unified snapshot = %+v", snapshot)
+	}
+
+	if snapshot = parseClaudeQuota(http.Header{"Content-Type": {"application/json"}}, now); snapshot != nil {
+		t.Fatalf("snapshot without rate-limit headers = %+v, want nil", snapshot)
+	}
+}
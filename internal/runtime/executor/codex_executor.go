@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -150,6 +151,12 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 	if isCodexOpenAIImageRequest(opts) {
 		return e.executeOpenAIImage(ctx, auth, req, opts)
 	}
+	if isEmbeddingRequest(opts) {
+		return e.executeEmbeddings(ctx, auth, req, opts)
+	}
+	if isAudioRequest(opts) {
+		return resp, errAudioUnsupported
+	}
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
 	apiKey, baseURL := codexCreds(auth)
@@ -167,8 +174,8 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, false)
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	originalTranslated := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, originalPayload, false)
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, false)
 
 	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -322,8 +329,8 @@ func (e *CodexExecutor) executeCompact(ctx context.Context, auth *cliproxyauth.A
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, false)
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	originalTranslated := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, originalPayload, false)
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, false)
 
 	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -420,8 +427,8 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	originalTranslated := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, originalPayload, true)
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, true)
 
 	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -545,7 +552,7 @@ func (e *CodexExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth
 
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("codex")
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, false)
 
 	body, err := thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -1002,6 +1009,47 @@ func parseCodexRetryAfter(statusCode int, errorBody []byte, now time.Time) *time
 	return nil
 }
 
+// ReadQuota reads the usage windows Codex reports in its x-codex-* response headers and the
+// reset time of a usage_limit_reached error.
+func (e *CodexExecutor) ReadQuota(auth *cliproxyauth.Auth, _ string, statusCode int, headers http.Header, body []byte) *cliproxyauth.QuotaSnapshot {
+	return parseCodexQuota(auth, statusCode, headers, body, time.Now())
+}
+
+func parseCodexQuota(auth *cliproxyauth.Auth, statusCode int, headers http.Header, body []byte, now time.Time) *cliproxyauth.QuotaSnapshot {
+	snapshot := &cliproxyauth.QuotaSnapshot{Source: "codex-headers", UpdatedAt: now}
+	for _, name := range []string{"primary", "secondary"} {
+		prefix := "x-codex-" + name + "-"
+		used, err := strconv.ParseFloat(strings.TrimSpace(headers.Get(prefix+"used-percent")), 64)
+		if err != nil {
+			continue
+		}
+		window := cliproxyauth.QuotaWindow{Name: name, UsedPercent: used}
+		if minutes, errMinutes := strconv.ParseInt(strings.TrimSpace(headers.Get(prefix+"window-minutes")), 10, 64); errMinutes == nil && minutes > 0 {
+			window.WindowMinutes = minutes
+		}
+		if resetAt, errReset := strconv.ParseInt(strings.TrimSpace(headers.Get(prefix+"reset-at")), 10, 64); errReset == nil && resetAt > 0 {
+			window.ResetAt = time.Unix(resetAt, 0)
+		} else if resetAfter, errAfter := strconv.ParseInt(strings.TrimSpace(headers.Get(prefix+"reset-after-seconds")), 10, 64); errAfter == nil && resetAfter > 0 {
+			window.ResetAt = now.Add(time.Duration(resetAfter) * time.Second)
+		}
+		snapshot.AddWindow(window)
+	}
+	if retryAfter := parseCodexRetryAfter(statusCode, body, now); retryAfter != nil {
+		if len(snapshot.Windows) == 0 {
+			snapshot.Source = "codex-usage-limit"
+		}
+		snapshot.AddWindow(cliproxyauth.QuotaWindow{Name: "usage_limit", UsedPercent: 100, ResetAt: now.Add(*retryAfter)})
+	}
+	if len(snapshot.Windows) == 0 {
+		return nil
+	}
+	snapshot.PlanTier = strings.TrimSpace(gjson.GetBytes(body, "error.plan_type").String())
+	if snapshot.PlanTier == "" && auth != nil && auth.Attributes != nil {
+		snapshot.PlanTier = strings.TrimSpace(auth.Attributes["plan_type"])
+	}
+	return snapshot
+}
+
 func codexCreds(a *cliproxyauth.Auth) (apiKey, baseURL string) {
 	if a == nil {
 		return "", ""
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"testing"
 	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 )
 
 func TestParseCodexRetryAfter(t *testing.T) {
@@ -165,3 +167,48 @@ func assertCodexErrorCode(t *testing.T, raw string, wantType string, wantCode st
 func itoa(v int64) string {
 	return strconv.FormatInt(v, 10)
 }
+
+func TestParseCodexQuota(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	auth := &cliproxyauth.Auth{Provider: "codex", Attributes: map[string]string{"plan_type": "plus"}}
+
+	t.Run("headers", func(t *testing.T) {
+		headers := http.Header{}
+		headers.Set("x-codex-primary-used-percent", "82.5")
+		headers.Set("x-codex-primary-window-minutes", "300")
+		headers.Set("x-codex-primary-reset-after-seconds", "600")
+		headers.Set("x-codex-secondary-used-percent", "40")
+		headers.Set("x-codex-secondary-reset-at", strconv.FormatInt(now.Add(72*time.Hour).Unix(), 10))
+		snapshot := parseCodexQuota(auth, http.StatusOK, headers, nil, now)
+		if snapshot == nil || len(snapshot.Windows) != 2 {
+			t.Fatalf("snapshot = %+v, want two windows", snapshot)
+		}
+		primary := snapshot.Windows[0]
+		if primary.Name != "primary" || primary.UsedPercent != 82.5 || primary.WindowMinutes != 300 || !primary.ResetAt.Equal(now.Add(10*time.Minute)) {
+			t.Fatalf("primary window = %+v", primary)
+		}
+		if !snapshot.Windows[1].ResetAt.Equal(now.Add(72 * time.Hour)) {
+			t.Fatalf("secondary reset = %v", snapshot.Windows[1].ResetAt)
+		}
+		if snapshot.PlanTier != "plus" || !snapshot.ResetAt.Equal(primary.ResetAt) {
+			t.Fatalf("snapshot plan=%q reset=%v", snapshot.PlanTier, snapshot.ResetAt)
+		}
+	})
+
+	t.Run("usage limit error", func(t *testing.T) {
+		body := []byte(`{"error":{"type":"usage_limit_reached","plan_type":"pro","resets_in_seconds":120}}`)
+		snapshot := parseCodexQuota(auth, http.StatusTooManyRequests, nil, body, now)
+		if snapshot == nil || snapshot.Source != "codex-usage-limit" || snapshot.PlanTier != "pro" {
+			t.Fatalf("snapshot = %+v", snapshot)
+		}
+		if !snapshot.NearlyExhausted(now, "", 95) || !snapshot.ResetAt.Equal(now.Add(2*time.Minute)) {
+			t.Fatalf("snapshot windows = %+v", snapshot.Windows)
+		}
+	})
+
+	t.Run("no signal", func(t *testing.T) {
+		if snapshot := parseCodexQuota(auth, http.StatusOK, http.Header{}, nil, now); snapshot != nil {
+			t.Fatalf("snapshot = %+v, want nil", snapshot)
+		}
+	})
+}
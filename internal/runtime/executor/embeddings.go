@@ -0,0 +1,292 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// codexEmbeddingsBaseURL is the OpenAI platform endpoint used for Codex API-key embeddings;
+// the ChatGPT Codex backend does not serve embeddings.
+const codexEmbeddingsBaseURL = "https://api.openai.com/v1"
+
+// isEmbeddingRequest reports whether the request originates from an embeddings handler.
+func isEmbeddingRequest(opts cliproxyexecutor.Options) bool {
+	switch opts.SourceFormat.String() {
+	case constant.OpenAIEmbedding, constant.GeminiEmbedding:
+		return true
+	default:
+		return false
+	}
+}
+
+// errEmbeddingsUnsupported is returned by executors whose upstream has no embeddings API.
+var errEmbeddingsUnsupported = statusErr{code: http.StatusBadRequest, msg: "embeddings not supported by provider"}
+
+// doBufferedRequest logs and sends a prepared non-streaming request and returns the upstream
+// body. Non-2xx responses are converted into statusErr values.
+func doBufferedRequest(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, provider string, httpReq *http.Request, body []byte) ([]byte, http.Header, error) {
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, cfg, helps.UpstreamRequestLog{
+		URL:       httpReq.URL.String(),
+		Method:    httpReq.Method,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  provider,
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, cfg, err)
+		return nil, nil, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("%s executor: close response body error: %v", provider, errClose)
+		}
+	}()
+	helps.RecordAPIResponseMetadata(ctx, cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, cfg, err)
+		return nil, nil, err
+	}
+	helps.AppendAPIResponseChunk(ctx, cfg, data)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
+		return nil, nil, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+	return data, httpResp.Header.Clone(), nil
+}
+
+// executeOpenAIEmbeddings posts an OpenAI-schema embeddings request to baseURL/embeddings.
+func executeOpenAIEmbeddings(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, provider, baseURL, apiKey string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, provider, baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FormatOpenAIEmbedding
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, false)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+
+	url := strings.TrimSuffix(baseURL, "/") + "/embeddings"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
+
+	data, headers, err := doBufferedRequest(ctx, cfg, auth, provider, httpReq, body)
+	if err != nil {
+		return resp, err
+	}
+	reporter.Publish(ctx, helps.ParseOpenAIUsage(data))
+	reporter.EnsurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	resp = cliproxyexecutor.Response{Payload: out, Headers: headers}
+	return resp, nil
+}
+
+// executeEmbeddings serves embeddings through the OpenAI-compatible /embeddings endpoint.
+func (e *OpenAICompatExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseURL, apiKey := e.resolveCredentials(auth)
+	if baseURL == "" {
+		return cliproxyexecutor.Response{}, statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+	}
+	return executeOpenAIEmbeddings(ctx, e.cfg, auth, e.Identifier(), baseURL, apiKey, req, opts)
+}
+
+// executeEmbeddings serves embeddings for Codex API-key credentials through the OpenAI platform.
+// OAuth credentials are scoped to the ChatGPT backend and cannot call the embeddings API.
+func (e *CodexExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	var apiKey, baseURL string
+	if auth != nil && auth.Attributes != nil {
+		apiKey = strings.TrimSpace(auth.Attributes["api_key"])
+		baseURL = strings.TrimSpace(auth.Attributes["base_url"])
+	}
+	if apiKey == "" {
+		return cliproxyexecutor.Response{}, errEmbeddingsUnsupported
+	}
+	if baseURL == "" || strings.Contains(baseURL, "chatgpt.com") {
+		baseURL = codexEmbeddingsBaseURL
+	}
+	return executeOpenAIEmbeddings(ctx, e.cfg, auth, e.Identifier(), baseURL, apiKey, req, opts)
+}
+
+// prepareGeminiEmbeddingRequest translates the payload into a batchEmbedContents request and
+// pins every entry to the resolved upstream model.
+func prepareGeminiEmbeddingRequest(ctx context.Context, from sdktranslator.Format, baseModel string, payload []byte) []byte {
+	to := sdktranslator.FormatGeminiEmbedding
+	body := payload
+	if from != to {
+		body = sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, payload, false)
+	}
+	modelPath := "models/" + baseModel
+	count := len(gjson.GetBytes(body, "requests").Array())
+	for i := 0; i < count; i++ {
+		body, _ = sjson.SetBytes(body, fmt.Sprintf("requests.%d.model", i), modelPath)
+	}
+	body, _ = sjson.DeleteBytes(body, "model")
+	return body
+}
+
+// executeEmbeddings serves embeddings through the Gemini batchEmbedContents action.
+func (e *GeminiExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	apiKey, bearer := geminiCreds(auth)
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FormatGeminiEmbedding
+	body := prepareGeminiEmbeddingRequest(ctx, from, baseModel, req.Payload)
+
+	url := fmt.Sprintf("%s/%s/models/%s:batchEmbedContents", resolveGeminiBaseURL(auth), glAPIVersion, baseModel)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("x-goog-api-key", apiKey)
+	} else if bearer != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	applyGeminiHeaders(httpReq, auth)
+
+	data, headers, err := doBufferedRequest(ctx, e.cfg, auth, e.Identifier(), httpReq, body)
+	if err != nil {
+		return resp, err
+	}
+	reporter.Publish(ctx, helps.ParseGeminiUsage(data))
+	reporter.EnsurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	resp = cliproxyexecutor.Response{Payload: out, Headers: headers}
+	return resp, nil
+}
+
+// executeEmbeddings serves embeddings through the Vertex AI predict action, using either the
+// API key or the service account credentials.
+func (e *GeminiVertexExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FormatGeminiEmbedding
+	body := prepareGeminiEmbeddingRequest(ctx, from, baseModel, req.Payload)
+	predictBody := convertGeminiEmbeddingToVertexPredict(body)
+
+	httpReq, err := e.newActionRequest(ctx, auth, baseModel, "predict", predictBody)
+	if err != nil {
+		return resp, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	applyGeminiHeaders(httpReq, auth)
+
+	data, headers, err := doBufferedRequest(ctx, e.cfg, auth, e.Identifier(), httpReq, predictBody)
+	if err != nil {
+		return resp, err
+	}
+	data = convertVertexPredictToGeminiEmbedding(data)
+	reporter.Publish(ctx, helps.ParseGeminiUsage(data))
+	reporter.EnsurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	resp = cliproxyexecutor.Response{Payload: out, Headers: headers}
+	return resp, nil
+}
+
+// convertGeminiEmbeddingToVertexPredict converts a batchEmbedContents request into the Vertex
+// text embedding predict schema.
+func convertGeminiEmbeddingToVertexPredict(body []byte) []byte {
+	out := []byte(`{"instances":[]}`)
+	var dimensions int64
+	gjson.GetBytes(body, "requests").ForEach(func(_, request gjson.Result) bool {
+		var texts []string
+		request.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			if text := part.Get("text"); text.Exists() {
+				texts = append(texts, text.String())
+			}
+			return true
+		})
+		instance := []byte(`{"content":""}`)
+		instance, _ = sjson.SetBytes(instance, "content", strings.Join(texts, "\n"))
+		if taskType := request.Get("taskType"); taskType.Exists() {
+			instance, _ = sjson.SetBytes(instance, "task_type", taskType.String())
+		}
+		if title := request.Get("title"); title.Exists() {
+			instance, _ = sjson.SetBytes(instance, "title", title.String())
+		}
+		out, _ = sjson.SetRawBytes(out, "instances.-1", instance)
+		if dims := request.Get("outputDimensionality"); dims.Exists() && dimensions == 0 {
+			dimensions = dims.Int()
+		}
+		return true
+	})
+	if dimensions > 0 {
+		out, _ = sjson.SetBytes(out, "parameters.outputDimensionality", dimensions)
+	}
+	return out
+}
+
+// convertVertexPredictToGeminiEmbedding converts a Vertex predict response into the
+// batchEmbedContents schema, summing per-instance token statistics into usageMetadata.
+func convertVertexPredictToGeminiEmbedding(data []byte) []byte {
+	out := []byte(`{"embeddings":[]}`)
+	var tokens int64
+	gjson.GetBytes(data, "predictions").ForEach(func(_, prediction gjson.Result) bool {
+		entry := []byte(`{"values":[]}`)
+		if values := prediction.Get("embeddings.values"); values.IsArray() {
+			entry, _ = sjson.SetRawBytes(entry, "values", []byte(values.Raw))
+		}
+		out, _ = sjson.SetRawBytes(out, "embeddings.-1", entry)
+		tokens += prediction.Get("embeddings.statistics.token_count").Int()
+		return true
+	})
+	if tokens > 0 {
+		out, _ = sjson.SetBytes(out, "usageMetadata.promptTokenCount", tokens)
+		out, _ = sjson.SetBytes(out, "usageMetadata.totalTokenCount", tokens)
+	}
+	return out
+}
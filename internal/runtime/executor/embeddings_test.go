@@ -0,0 +1,143 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestGeminiExecutorEmbeddingsTranslatesOpenAIRequest(t *testing.T) {
+	var upstreamPath string
+	var upstreamBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamPath = r.URL.Path
+		upstreamBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}]}`))
+	}))
+	defer server.Close()
+
+	exec := NewGeminiExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "test-key", "base_url": server.URL}}
+	payload := []byte(`{"model":"gemini-embedding-001","input":["a","b"],"dimensions":2}`)
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gemini-embedding-001", Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat:    sdktranslator.FormatOpenAIEmbedding,
+		OriginalRequest: payload,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.HasSuffix(upstreamPath, "/models/gemini-embedding-001:batchEmbedContents") {
+		t.Fatalf("upstream path = %q", upstreamPath)
+	}
+	if got := gjson.GetBytes(upstreamBody, "requests.1.content.parts.0.text").String(); got != "b" {
+		t.Fatalf("second request text = %q, body=%s", got, upstreamBody)
+	}
+	if got := gjson.GetBytes(upstreamBody, "requests.0.outputDimensionality").Int(); got != 2 {
+		t.Fatalf("outputDimensionality = %d", got)
+	}
+	if gjson.GetBytes(upstreamBody, "model").Exists() {
+		t.Fatalf("unexpected top-level model in %s", upstreamBody)
+	}
+	if got := gjson.GetBytes(resp.Payload, "data.1.embedding.1").Float(); got != 0.4 {
+		t.Fatalf("unexpected response payload %s", resp.Payload)
+	}
+	if got := gjson.GetBytes(resp.Payload, "data.1.index").Int(); got != 1 {
+		t.Fatalf("data.1.index = %d", got)
+	}
+}
+
+func TestOpenAICompatExecutorEmbeddingsFromGeminiBatch(t *testing.T) {
+	var upstreamPath string
+	var upstreamBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamPath = r.URL.Path
+		upstreamBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":1,"embedding":[2]},{"object":"embedding","index":0,"embedding":[1]}],"usage":{"prompt_tokens":3,"total_tokens":3}}`))
+	}))
+	defer server.Close()
+
+	exec := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "k", "base_url": server.URL + "/v1"}}
+	payload := []byte(`{"requests":[{"model":"models/embed","content":{"parts":[{"text":"x"}]}},{"model":"models/embed","content":{"parts":[{"text":"y"},{"text":"z"}]}}]}`)
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "embed", Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat:    sdktranslator.FormatGeminiEmbedding,
+		OriginalRequest: payload,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if upstreamPath != "/v1/embeddings" {
+		t.Fatalf("upstream path = %q", upstreamPath)
+	}
+	if got := gjson.GetBytes(upstreamBody, "input.1").String(); got != "y\nz" {
+		t.Fatalf("input[1] = %q", got)
+	}
+	if got := gjson.GetBytes(resp.Payload, "embeddings.0.values.0").Int(); got != 1 {
+		t.Fatalf("embeddings not ordered by index: %s", resp.Payload)
+	}
+}
+
+func TestCodexExecutorEmbeddingsRequiresAPIKey(t *testing.T) {
+	exec := NewCodexExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Metadata: map[string]any{"access_token": "oauth"}}
+	_, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "text-embedding-3-small", Payload: []byte(`{"input":"a"}`)}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FormatOpenAIEmbedding,
+	})
+	se, ok := err.(statusErr)
+	if !ok || se.code != http.StatusBadRequest {
+		t.Fatalf("expected 400 statusErr, got %v", err)
+	}
+}
+
+func TestExecutorsWithoutEmbeddingsRejectEmbeddingRequests(t *testing.T) {
+	executors := map[string]cliproxyauth.ProviderExecutor{
+		"claude":      NewClaudeExecutor(&config.Config{}),
+		"gemini-cli":  NewGeminiCLIExecutor(&config.Config{}),
+		"antigravity": NewAntigravityExecutor(&config.Config{}),
+		"kimi":        NewKimiExecutor(&config.Config{}),
+		"xai":         NewXAIExecutor(&config.Config{}),
+	}
+	for name, exec := range executors {
+		_, err := exec.Execute(context.Background(), &cliproxyauth.Auth{Provider: name, Attributes: map[string]string{}}, cliproxyexecutor.Request{Model: "m", Payload: []byte(`{"input":"a"}`)}, cliproxyexecutor.Options{
+			SourceFormat: sdktranslator.FormatOpenAIEmbedding,
+		})
+		se, ok := err.(statusErr)
+		if !ok || se.code != http.StatusBadRequest || se.msg != "embeddings not supported by provider" {
+			t.Fatalf("%s: expected 400 embeddings not supported, got %v", name, err)
+		}
+	}
+}
+
+func TestVertexEmbeddingPredictConversion(t *testing.T) {
+	batch := []byte(`{"requests":[{"model":"models/text-embedding-005","content":{"parts":[{"text":"hello"}]},"taskType":"RETRIEVAL_QUERY","outputDimensionality":8}]}`)
+	predict := convertGeminiEmbeddingToVertexPredict(batch)
+	if got := gjson.GetBytes(predict, "instances.0.content").String(); got != "hello" {
+		t.Fatalf("instance content = %q", got)
+	}
+	if got := gjson.GetBytes(predict, "instances.0.task_type").String(); got != "RETRIEVAL_QUERY" {
+		t.Fatalf("task_type = %q", got)
+	}
+	if got := gjson.GetBytes(predict, "parameters.outputDimensionality").Int(); got != 8 {
+		t.Fatalf("outputDimensionality = %d", got)
+	}
+
+	out := convertVertexPredictToGeminiEmbedding([]byte(`{"predictions":[{"embeddings":{"values":[0.5],"statistics":{"token_count":2}}},{"embeddings":{"values":[0.6],"statistics":{"token_count":3}}}]}`))
+	if got := gjson.GetBytes(out, "embeddings.1.values.0").Float(); got != 0.6 {
+		t.Fatalf("unexpected embeddings %s", out)
+	}
+	if got := gjson.GetBytes(out, "usageMetadata.promptTokenCount").Int(); got != 5 {
+		t.Fatalf("promptTokenCount = %d", got)
+	}
+}
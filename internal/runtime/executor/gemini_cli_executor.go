@@ -64,6 +64,11 @@ func NewGeminiCLIExecutor(cfg *config.Config) *GeminiCLIExecutor {
 // Identifier returns the executor identifier.
 func (e *GeminiCLIExecutor) Identifier() string { return "gemini-cli" }
 
+// ReadQuota reads the quota named by a Gemini CLI 429 error.
+func (e *GeminiCLIExecutor) ReadQuota(_ *cliproxyauth.Auth, model string, statusCode int, _ http.Header, body []byte) *cliproxyauth.QuotaSnapshot {
+	return parseGoogleQuota(statusCode, body, model, time.Now())
+}
+
 // PrepareRequest injects Gemini CLI credentials into the outgoing HTTP request.
 func (e *GeminiCLIExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
 	if req == nil {
@@ -111,6 +116,12 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 	if opts.Alt == "responses/compact" {
 		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
 	}
+	if isEmbeddingRequest(opts) {
+		return resp, errEmbeddingsUnsupported
+	}
+	if isAudioRequest(opts) {
+		return resp, errAudioUnsupported
+	}
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
 	tokenSource, baseTokenData, err := prepareGeminiCLITokenSource(ctx, e.cfg, auth)
@@ -129,8 +140,8 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, false)
-	basePayload := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	originalTranslated := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, originalPayload, false)
+	basePayload := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, false)
 
 	basePayload, err = thinking.ApplyThinking(basePayload, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -285,8 +296,8 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
-	basePayload := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	originalTranslated := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, originalPayload, true)
+	basePayload := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, true)
 
 	basePayload, err = thinking.ApplyThinking(basePayload, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -519,7 +530,7 @@ func (e *GeminiCLIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.
 	// The loop variable attemptModel is only used as the concrete model id sent to the upstream
 	// Gemini CLI endpoint when iterating fallback variants.
 	for range models {
-		payload := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+		payload := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, false)
 
 		payload, err = thinking.ApplyThinking(payload, req.Model, from.String(), to.String(), e.Identifier())
 		if err != nil {
@@ -911,6 +922,59 @@ func newGeminiStatusErr(statusCode int, body []byte) statusErr {
 	return err
 }
 
+// parseGoogleQuota turns a Google API 429 error into a quota snapshot: one exhausted window
+// per QuotaFailure violation, resetting after the retry delay. Google quotas are per model, so
+// each window is scoped to the model the violation names, or to the requested model when it
+// names none. It returns nil when the error names neither a quota nor a reset time, since a
+// bare 429 may only mean the model is busy.
+func parseGoogleQuota(statusCode int, body []byte, model string, now time.Time) *cliproxyauth.QuotaSnapshot {
+	if statusCode != http.StatusTooManyRequests || len(body) == 0 {
+		return nil
+	}
+	var resetAt time.Time
+	if retryAfter, err := parseRetryDelay(body); err == nil && retryAfter != nil {
+		resetAt = now.Add(*retryAfter)
+	}
+	snapshot := &cliproxyauth.QuotaSnapshot{Source: "google-quota-error", UpdatedAt: now}
+	for _, detail := range gjson.GetBytes(body, "error.details").Array() {
+		if detail.Get("@type").String() != "type.googleapis.com/google.rpc.QuotaFailure" {
+			continue
+		}
+		for _, violation := range detail.Get("violations").Array() {
+			name := strings.TrimSpace(violation.Get("quotaId").String())
+			if name == "" {
+				name = strings.TrimSpace(violation.Get("quotaMetric").String())
+			}
+			if name == "" {
+				name = "quota"
+			}
+			windowModel := strings.TrimSpace(violation.Get("quotaDimensions.model").String())
+			if windowModel == "" {
+				windowModel = model
+			}
+			remaining := int64(0)
+			snapshot.AddWindow(cliproxyauth.QuotaWindow{
+				Name:        name,
+				Model:       windowModel,
+				Limit:       violation.Get("quotaValue").Int(),
+				Remaining:   &remaining,
+				UsedPercent: 100,
+				ResetAt:     resetAt,
+			})
+			if strings.Contains(strings.ToLower(name), "freetier") {
+				snapshot.PlanTier = "free"
+			}
+		}
+	}
+	if len(snapshot.Windows) == 0 {
+		if resetAt.IsZero() {
+			return nil
+		}
+		snapshot.AddWindow(cliproxyauth.QuotaWindow{Name: "quota", Model: model, UsedPercent: 100, ResetAt: resetAt})
+	}
+	return snapshot
+}
+
 // parseRetryDelay extracts the retry delay from a Google API 429 error response.
 // The error response contains a RetryInfo.retryDelay field in the format "0.847655010s".
 // Returns the parsed duration or an error if it cannot be determined.
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
@@ -88,3 +89,35 @@ func TestGeminiExecutorExecuteCapsMaxOutputTokensBeforeUpstream(t *testing.T) {
 		t.Fatalf("upstream maxOutputTokens = %d, want 65536", upstreamMaxOutputTokens)
 	}
 }
+
+func TestParseGoogleQuota(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"error":{"code":429,"status":"RESOURCE_EXHAUSTED","details":[
+		{"@type":"type.googleapis.com/google.rpc.QuotaFailure","violations":[{"quotaMetric":"generativelanguage.googleapis.com/generate_content_free_tier_requests","quotaId":"GenerateRequestsPerDayPerProjectPerModel-FreeTier","quotaValue":"250"}]},
+		{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"42s"}]}}`)
+	snapshot := parseGoogleQuota(http.StatusTooManyRequests, body, "gemini-2.5-pro", now)
+	if snapshot == nil || len(snapshot.Windows) != 1 {
+		t.Fatalf("snapshot = %+v, want one window", snapshot)
+	}
+	window := snapshot.Windows[0]
+	if window.Name != "GenerateRequestsPerDayPerProjectPerModel-FreeTier" || window.Model != "gemini-2.5-pro" || window.Limit != 250 || window.Remaining == nil || *window.Remaining != 0 {
+		t.Fatalf("window = %+v", window)
+	}
+	if snapshot.PlanTier != "free" || !snapshot.ResetAt.Equal(now.Add(42*time.Second)) {
+		t.Fatalf("snapshot plan=%q reset=%v", snapshot.PlanTier, snapshot.ResetAt)
+	}
+
+	dimensioned := []byte(`{"error":{"code":429,"details":[
+		{"@type":"type.googleapis.com/google.rpc.QuotaFailure","violations":[{"quotaId":"GenerateRequestsPerMinutePerProjectPerModel","quotaDimensions":{"location":"global","model":"gemini-2.5-flash"}}]},
+		{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"5s"}]}}`)
+	if snapshot = parseGoogleQuota(http.StatusTooManyRequests, dimensioned, "gemini-2.5-pro", now); snapshot == nil || snapshot.Windows[0].Model != "gemini-2.5-flash" {
+		t.Fatalf("dimensioned snapshot = %+v, want the window scoped to the model the violation names", snapshot)
+	}
+
+	if snapshot = parseGoogleQuota(http.StatusTooManyRequests, []byte(`{"error":{"code":429,"message":"model is overloaded"}}`), "gemini-2.5-pro", now); snapshot != nil {
+		t.Fatalf("bare 429 snapshot = %+v, want nil", snapshot)
+	}
+	if snapshot = parseGoogleQuota(http.StatusBadRequest, body, "gemini-2.5-pro", now); snapshot != nil {
+		t.Fatalf("non-429 snapshot = %+v, want nil", snapshot)
+	}
+}
@@ -55,7 +55,7 @@ type upstreamAttempt struct {
 
 // RecordAPIRequest stores the upstream request metadata in Gin context for request logging.
 func RecordAPIRequest(ctx context.Context, cfg *config.Config, info UpstreamRequestLog) {
-	if cfg == nil || !cfg.RequestLog {
+	if !recordUpstream(ctx, cfg) {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -103,7 +103,7 @@ func RecordAPIRequest(ctx context.Context, cfg *config.Config, info UpstreamRequ
 // RecordAPIResponseMetadata captures upstream response status/header information for the latest attempt.
 func RecordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status int, headers http.Header) {
 	logging.SetResponseHeaders(ctx, headers)
-	if cfg == nil || !cfg.RequestLog {
+	if !recordUpstream(ctx, cfg) {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -129,7 +129,7 @@ func RecordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status i
 
 // RecordAPIResponseError adds an error entry for the latest attempt when no HTTP response is available.
 func RecordAPIResponseError(ctx context.Context, cfg *config.Config, err error) {
-	if cfg == nil || !cfg.RequestLog || err == nil {
+	if !recordUpstream(ctx, cfg) || err == nil {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -154,7 +154,7 @@ func RecordAPIResponseError(ctx context.Context, cfg *config.Config, err error)
 
 // AppendAPIResponseChunk appends an upstream response chunk to Gin context for request logging.
 func AppendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byte) {
-	if cfg == nil || !cfg.RequestLog {
+	if !recordUpstream(ctx, cfg) {
 		return
 	}
 	data := bytes.TrimSpace(chunk)
@@ -196,7 +196,7 @@ func AppendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byt
 
 // RecordAPIWebsocketRequest stores an upstream websocket request event in Gin context.
 func RecordAPIWebsocketRequest(ctx context.Context, cfg *config.Config, info UpstreamRequestLog) {
-	if cfg == nil || !cfg.RequestLog {
+	if !recordUpstream(ctx, cfg) {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -229,7 +229,7 @@ func RecordAPIWebsocketRequest(ctx context.Context, cfg *config.Config, info Ups
 // RecordAPIWebsocketHandshake stores the upstream websocket handshake response metadata.
 func RecordAPIWebsocketHandshake(ctx context.Context, cfg *config.Config, status int, headers http.Header) {
 	logging.SetResponseHeaders(ctx, headers)
-	if cfg == nil || !cfg.RequestLog {
+	if !recordUpstream(ctx, cfg) {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -253,7 +253,7 @@ func RecordAPIWebsocketHandshake(ctx context.Context, cfg *config.Config, status
 // RecordAPIWebsocketUpgradeRejection stores a rejected websocket upgrade as an HTTP attempt.
 func RecordAPIWebsocketUpgradeRejection(ctx context.Context, cfg *config.Config, info UpstreamRequestLog, status int, headers http.Header, body []byte) {
 	logging.SetResponseHeaders(ctx, headers)
-	if cfg == nil || !cfg.RequestLog {
+	if !recordUpstream(ctx, cfg) {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -287,7 +287,7 @@ func WebsocketUpgradeRequestURL(rawURL string) string {
 
 // AppendAPIWebsocketResponse stores an upstream websocket response frame in Gin context.
 func AppendAPIWebsocketResponse(ctx context.Context, cfg *config.Config, payload []byte) {
-	if cfg == nil || !cfg.RequestLog {
+	if !recordUpstream(ctx, cfg) {
 		return
 	}
 	data := bytes.TrimSpace(payload)
@@ -311,7 +311,7 @@ func AppendAPIWebsocketResponse(ctx context.Context, cfg *config.Config, payload
 
 // RecordAPIWebsocketError stores an upstream websocket error event in Gin context.
 func RecordAPIWebsocketError(ctx context.Context, cfg *config.Config, stage string, err error) {
-	if cfg == nil || !cfg.RequestLog || err == nil {
+	if !recordUpstream(ctx, cfg) || err == nil {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -331,6 +331,19 @@ func RecordAPIWebsocketError(ctx context.Context, cfg *config.Config, stage stri
 	appendAPIWebsocketTimeline(ginCtx, []byte(builder.String()))
 }
 
+// recordUpstream reports whether upstream traffic should be recorded: always when request
+// logging is on, and for management replays, which diff it against the original log.
+func recordUpstream(ctx context.Context, cfg *config.Config) bool {
+	if cfg != nil && cfg.RequestLog {
+		return true
+	}
+	if ctx == nil {
+		return false
+	}
+	ginCtx := ginContextFrom(ctx)
+	return ginCtx != nil && ginCtx.Request != nil && logging.ReplayCaptureFrom(ginCtx.Request.Context()) != nil
+}
+
 func ginContextFrom(ctx context.Context) *gin.Context {
 	ginCtx, _ := ctx.Value("gin").(*gin.Context)
 	return ginCtx
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/proxyutil"
 	log "github.com/sirupsen/logrus"
@@ -47,7 +48,7 @@ func NewProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 		transport := buildProxyTransport(proxyURL)
 		if transport != nil {
 			httpClient.Transport = transport
-			return httpClient
+			return TraceHTTPClient(httpClient, auth)
 		}
 		// If proxy setup failed, log and fall through to context RoundTripper
 		log.Debugf("failed to setup proxy from URL: %s, falling back to context transport", proxyutil.Redact(proxyURL))
@@ -58,7 +59,30 @@ func NewProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 		httpClient.Transport = rt
 	}
 
-	return httpClient
+	return TraceHTTPClient(httpClient, auth)
+}
+
+// TraceHTTPClient wraps the client transport so each upstream request is recorded as a
+// client span when tracing is enabled. A nil transport keeps http.DefaultTransport.
+func TraceHTTPClient(client *http.Client, auth *cliproxyauth.Auth) *http.Client {
+	if client == nil || !tracing.Default().Enabled() {
+		return client
+	}
+	if _, ok := client.Transport.(*tracing.Transport); ok {
+		return client
+	}
+	client.Transport = tracing.NewTransport(client.Transport, TracePropagationAllowed(auth))
+	return client
+}
+
+// TracePropagationAllowed reports whether traceparent may be sent upstream for auth.
+// Only API-key credentials qualify: OAuth credentials replay first-party client traffic,
+// and an extra tracing header would make those requests stand out.
+func TracePropagationAllowed(auth *cliproxyauth.Auth) bool {
+	if auth == nil || auth.Attributes == nil {
+		return false
+	}
+	return strings.TrimSpace(auth.Attributes["api_key"]) != ""
 }
 
 // buildProxyTransport creates an HTTP transport configured for the given proxy URL.
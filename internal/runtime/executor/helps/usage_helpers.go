@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	internallogging "github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
@@ -26,6 +27,7 @@ type UsageReporter struct {
 	authType    string
 	apiKey      string
 	source      string
+	fallbackHop int
 	requestedAt time.Time
 	once        sync.Once
 }
@@ -44,6 +46,7 @@ func NewUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 		apiKey:      apiKey,
 		source:      resolveUsageSource(auth, apiKey),
 		authType:    resolveUsageAuthType(auth),
+		fallbackHop: usage.FallbackHopFromContext(ctx),
 	}
 	if auth != nil {
 		reporter.authID = auth.ID
@@ -98,6 +101,7 @@ func (r *UsageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 	}
 	detail = normalizeUsageDetailTotal(detail)
 	r.once.Do(func() {
+		r.observeRequestLog(ctx)
 		r.publishRecord(ctx, r.buildRecord(detail, failed, fail))
 	})
 }
@@ -131,12 +135,39 @@ func (r *UsageReporter) EnsurePublished(ctx context.Context) {
 		return
 	}
 	r.once.Do(func() {
+		r.observeRequestLog(ctx)
 		r.publishRecord(ctx, r.buildRecord(usage.Detail{}, false, usage.Failure{}))
 	})
 }
 
+// observeRequestLog reports this attempt to the structured request log of the request.
+func (r *UsageReporter) observeRequestLog(ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+	ginCtx := ginContextFrom(ctx)
+	if ginCtx == nil {
+		return
+	}
+	sourceFormat := ""
+	if handler, ok := ctx.Value("handler").(interfaces.APIHandler); ok && handler != nil {
+		sourceFormat = handler.HandlerType()
+	}
+	internallogging.ObserveRequestLogAttempt(ginCtx, internallogging.RequestLogMeta{
+		SourceFormat:   sourceFormat,
+		TargetFormat:   r.provider,
+		RequestedModel: r.alias,
+		Model:          r.model,
+		AuthID:         r.authID,
+		AuthIndex:      r.authIndex,
+	})
+}
+
 func (r *UsageReporter) publishRecord(ctx context.Context, record usage.Record) {
 	record.ResponseHeaders = internallogging.GetResponseHeaders(ctx)
+	if firstChunkAt := internallogging.GetFirstChunkAt(ctx); !firstChunkAt.IsZero() && !record.RequestedAt.IsZero() && firstChunkAt.After(record.RequestedAt) {
+		record.TimeToFirstChunk = firstChunkAt.Sub(record.RequestedAt)
+	}
 	usage.PublishRecord(ctx, record)
 }
 
@@ -169,6 +200,7 @@ func (r *UsageReporter) buildRecordForModel(model string, detail usage.Detail, f
 		Failed:      failed,
 		Fail:        fail,
 		Detail:      detail,
+		FallbackHop: r.fallbackHop,
 	}
 }
 
@@ -0,0 +1,205 @@
+// Package sharedstate implements the Redis-protocol backend proxy instances use to share
+// credential cooldowns, quota state, session-affinity bindings and round-robin cursors.
+package sharedstate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultKeyPrefix = "cliproxy:"
+	operationTimeout = 3 * time.Second
+
+	// authStatesKey names both the hash holding the latest state of every auth and the
+	// channel state changes are published on.
+	authStatesKey = "auth-states"
+	sessionKey    = "session:"
+	// sessionAuthKey prefixes the set of session keys ever bound to an auth, so the
+	// bindings of an auth can be removed without scanning the keyspace.
+	sessionAuthKey = "session-auth:"
+	cursorKey      = "cursor:"
+)
+
+// Redis is a coreauth.SharedStateBackend on any server speaking the Redis protocol.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+var _ coreauth.SharedStateBackend = (*Redis)(nil)
+
+// NewRedis returns a backend for cfg. The connection is established lazily, so an
+// unreachable server surfaces as errors from the individual operations.
+func NewRedis(cfg config.SharedStateConfig) (*Redis, error) {
+	options, err := redisOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	prefix := cfg.KeyPrefix
+	if prefix == "" {
+		prefix = defaultKeyPrefix
+	}
+	return &Redis{client: redis.NewClient(options), prefix: prefix}, nil
+}
+
+func redisOptions(cfg config.SharedStateConfig) (*redis.Options, error) {
+	addr := strings.TrimSpace(cfg.Addr)
+	if addr == "" {
+		return nil, errors.New("shared state: address is empty")
+	}
+	options := &redis.Options{Addr: addr}
+	if strings.Contains(addr, "://") {
+		parsed, err := redis.ParseURL(addr)
+		if err != nil {
+			return nil, fmt.Errorf("shared state: parse address: %w", err)
+		}
+		options = parsed
+	}
+	if cfg.Password != "" {
+		options.Password = cfg.Password
+	}
+	// RESP2 without CLIENT SETINFO keeps the backend usable with lightweight stand-ins.
+	options.Protocol = 2
+	options.DisableIdentity = true
+	options.DialTimeout = operationTimeout
+	options.ReadTimeout = operationTimeout
+	options.WriteTimeout = operationTimeout
+	options.ContextTimeoutEnabled = true
+	return options, nil
+}
+
+// PublishAuthState stores state in the auth-states hash and publishes it.
+func (r *Redis) PublishAuthState(ctx context.Context, state coreauth.SharedAuthState) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, r.prefix+authStatesKey, state.AuthID, payload)
+		pipe.Publish(ctx, r.prefix+authStatesKey, payload)
+		return nil
+	})
+	return err
+}
+
+// LoadAuthStates returns every state stored in the auth-states hash.
+func (r *Redis) LoadAuthStates(ctx context.Context) ([]coreauth.SharedAuthState, error) {
+	values, err := r.client.HGetAll(ctx, r.prefix+authStatesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	states := make([]coreauth.SharedAuthState, 0, len(values))
+	for authID, raw := range values {
+		var state coreauth.SharedAuthState
+		if errDecode := json.Unmarshal([]byte(raw), &state); errDecode != nil {
+			log.Debugf("shared state: skip undecodable state for %s: %v", authID, errDecode)
+			continue
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// Subscribe delivers published states to handle until ctx is done or the subscription fails.
+func (r *Redis) Subscribe(ctx context.Context, handle func(coreauth.SharedAuthState)) error {
+	pubsub := r.client.Subscribe(ctx, r.prefix+authStatesKey)
+	defer func() {
+		if errClose := pubsub.Close(); errClose != nil {
+			log.Debugf("shared state: close subscription: %v", errClose)
+		}
+	}()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message, ok := <-messages:
+			if !ok {
+				return errors.New("subscription closed")
+			}
+			var state coreauth.SharedAuthState
+			if errDecode := json.Unmarshal([]byte(message.Payload), &state); errDecode != nil {
+				log.Debugf("shared state: skip undecodable message: %v", errDecode)
+				continue
+			}
+			handle(state)
+		}
+	}
+}
+
+// SetSessionBinding stores the binding with ttl as its expiry and records key in the
+// session index of authID.
+func (r *Redis) SetSessionBinding(ctx context.Context, key, authID string, ttl time.Duration) error {
+	index := r.prefix + sessionAuthKey + authID
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, r.prefix+sessionKey+key, authID, ttl)
+		pipe.SAdd(ctx, index, key)
+		pipe.Expire(ctx, index, ttl)
+		return nil
+	})
+	return err
+}
+
+// SessionBinding returns the auth bound to key, or "" when the binding is missing or expired.
+func (r *Redis) SessionBinding(ctx context.Context, key string) (string, error) {
+	authID, err := r.client.Get(ctx, r.prefix+sessionKey+key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return authID, err
+}
+
+// DeleteSessionBinding removes the binding for key.
+func (r *Redis) DeleteSessionBinding(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+sessionKey+key).Err()
+}
+
+// DeleteAuthSessionBindings removes the bindings in the session index of authID that
+// still point at it, then the index itself. Keys rebound to another auth are left alone.
+func (r *Redis) DeleteAuthSessionBindings(ctx context.Context, authID string) error {
+	index := r.prefix + sessionAuthKey + authID
+	keys, err := r.client.SMembers(ctx, index).Result()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		bound, errGet := r.client.Get(ctx, r.prefix+sessionKey+key).Result()
+		if errors.Is(errGet, redis.Nil) || (errGet == nil && bound != authID) {
+			continue
+		}
+		if errGet != nil {
+			return errGet
+		}
+		if errDel := r.client.Del(ctx, r.prefix+sessionKey+key).Err(); errDel != nil {
+			return errDel
+		}
+	}
+	return r.client.Del(ctx, index).Err()
+}
+
+// ReserveCursor increments the cursor for key by n and returns the first reserved position.
+func (r *Redis) ReserveCursor(ctx context.Context, key string, n int) (int64, error) {
+	end, err := r.client.IncrBy(ctx, r.prefix+cursorKey+key, int64(n)).Result()
+	if err != nil {
+		return 0, err
+	}
+	return end - int64(n), nil
+}
+
+// Close closes the connection pool.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
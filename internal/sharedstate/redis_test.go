@@ -0,0 +1,282 @@
+package sharedstate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// standIn is a minimal RESP2 server implementing the commands the backend uses.
+type standIn struct {
+	mu          sync.Mutex
+	strings     map[string]string
+	hashes      map[string]map[string]string
+	sets        map[string]map[string]struct{}
+	subscribers map[string][]*standInConn
+}
+
+type standInConn struct {
+	mu     sync.Mutex
+	writer *bufio.Writer
+}
+
+func (c *standInConn) write(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _ = fmt.Fprintf(c.writer, format, args...)
+	_ = c.writer.Flush()
+}
+
+func bulk(value string) string { return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n" }
+
+func startStandIn(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	server := &standIn{
+		strings:     make(map[string]string),
+		hashes:      make(map[string]map[string]string),
+		sets:        make(map[string]map[string]struct{}),
+		subscribers: make(map[string][]*standInConn),
+	}
+	go func() {
+		for {
+			conn, errAccept := listener.Accept()
+			if errAccept != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func (s *standIn) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	reader := bufio.NewReader(conn)
+	client := &standInConn{writer: bufio.NewWriter(conn)}
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		s.handle(client, args)
+	}
+}
+
+func (s *standIn) handle(client *standInConn, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		client.write("+PONG\r\n")
+	case "SET":
+		s.strings[args[1]] = args[2]
+		client.write("+OK\r\n")
+	case "GET":
+		value, ok := s.strings[args[1]]
+		if !ok {
+			client.write("$-1\r\n")
+			return
+		}
+		client.write("%s", bulk(value))
+	case "DEL":
+		_, ok := s.strings[args[1]]
+		_, okSet := s.sets[args[1]]
+		delete(s.strings, args[1])
+		delete(s.sets, args[1])
+		if ok || okSet {
+			client.write(":1\r\n")
+		} else {
+			client.write(":0\r\n")
+		}
+	case "EXPIRE":
+		client.write(":1\r\n")
+	case "SADD":
+		if s.sets[args[1]] == nil {
+			s.sets[args[1]] = make(map[string]struct{})
+		}
+		added := 0
+		for _, member := range args[2:] {
+			if _, ok := s.sets[args[1]][member]; !ok {
+				s.sets[args[1]][member] = struct{}{}
+				added++
+			}
+		}
+		client.write(":%d\r\n", added)
+	case "SMEMBERS":
+		set := s.sets[args[1]]
+		var reply strings.Builder
+		reply.WriteString("*" + strconv.Itoa(len(set)) + "\r\n")
+		for member := range set {
+			reply.WriteString(bulk(member))
+		}
+		client.write("%s", reply.String())
+	case "INCRBY":
+		current, _ := strconv.ParseInt(s.strings[args[1]], 10, 64)
+		delta, _ := strconv.ParseInt(args[2], 10, 64)
+		current += delta
+		s.strings[args[1]] = strconv.FormatInt(current, 10)
+		client.write(":%d\r\n", current)
+	case "HSET":
+		if s.hashes[args[1]] == nil {
+			s.hashes[args[1]] = make(map[string]string)
+		}
+		s.hashes[args[1]][args[2]] = args[3]
+		client.write(":1\r\n")
+	case "HGETALL":
+		hash := s.hashes[args[1]]
+		var reply strings.Builder
+		reply.WriteString("*" + strconv.Itoa(len(hash)*2) + "\r\n")
+		for field, value := range hash {
+			reply.WriteString(bulk(field) + bulk(value))
+		}
+		client.write("%s", reply.String())
+	case "PUBLISH":
+		subscribers := s.subscribers[args[1]]
+		for _, subscriber := range subscribers {
+			subscriber.write("*3\r\n%s%s%s", bulk("message"), bulk(args[1]), bulk(args[2]))
+		}
+		client.write(":%d\r\n", len(subscribers))
+	case "SUBSCRIBE":
+		for index, channel := range args[1:] {
+			s.subscribers[channel] = append(s.subscribers[channel], client)
+			client.write("*3\r\n%s%s:%d\r\n", bulk("subscribe"), bulk(channel), index+1)
+		}
+	default:
+		client.write("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, count)
+	for range count {
+		sizeLine, errSize := reader.ReadString('\n')
+		if errSize != nil {
+			return nil, errSize
+		}
+		size, errAtoi := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(sizeLine, "$")))
+		if errAtoi != nil {
+			return nil, errAtoi
+		}
+		buf := make([]byte, size+2)
+		if _, errRead := io.ReadFull(reader, buf); errRead != nil {
+			return nil, errRead
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func TestRedisBackendAgainstStandIn(t *testing.T) {
+	addr := startStandIn(t)
+	backend, err := NewRedis(config.SharedStateConfig{Enable: true, Addr: addr})
+	if err != nil {
+		t.Fatalf("NewRedis() error = %v", err)
+	}
+	t.Cleanup(func() { _ = backend.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan coreauth.SharedAuthState, 1)
+	subscribeCtx, stopSubscribe := context.WithCancel(ctx)
+	defer stopSubscribe()
+	go func() {
+		_ = backend.Subscribe(subscribeCtx, func(state coreauth.SharedAuthState) { received <- state })
+	}()
+
+	// Publish until the subscription is live; publishes before that are only stored.
+	state := coreauth.SharedAuthState{AuthID: "auth-a", Origin: "instance-1", Quota: coreauth.QuotaState{Exceeded: true, Reason: "quota"}}
+	var got coreauth.SharedAuthState
+	for delivered := false; !delivered; {
+		if errPublish := backend.PublishAuthState(ctx, state); errPublish != nil {
+			t.Fatalf("PublishAuthState() error = %v", errPublish)
+		}
+		select {
+		case got = <-received:
+			delivered = true
+		case <-time.After(20 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("published state was never delivered")
+		}
+	}
+	if got.AuthID != "auth-a" || !got.Quota.Exceeded {
+		t.Fatalf("delivered state = %+v", got)
+	}
+	states, err := backend.LoadAuthStates(ctx)
+	if err != nil || len(states) != 1 || states[0].Origin != "instance-1" {
+		t.Fatalf("LoadAuthStates() = %+v, %v", states, err)
+	}
+
+	if err = backend.SetSessionBinding(ctx, "claude:s1::model", "auth-a", time.Hour); err != nil {
+		t.Fatalf("SetSessionBinding() error = %v", err)
+	}
+	if authID, errGet := backend.SessionBinding(ctx, "claude:s1::model"); errGet != nil || authID != "auth-a" {
+		t.Fatalf("SessionBinding() = %q, %v", authID, errGet)
+	}
+	if err = backend.DeleteSessionBinding(ctx, "claude:s1::model"); err != nil {
+		t.Fatalf("DeleteSessionBinding() error = %v", err)
+	}
+	if authID, errGet := backend.SessionBinding(ctx, "claude:s1::model"); errGet != nil || authID != "" {
+		t.Fatalf("SessionBinding() after delete = %q, %v", authID, errGet)
+	}
+
+	for key, authID := range map[string]string{"claude:s2::model": "auth-b", "claude:s3::model": "auth-b", "claude:s4::model": "auth-c"} {
+		if err = backend.SetSessionBinding(ctx, key, authID, time.Hour); err != nil {
+			t.Fatalf("SetSessionBinding(%s) error = %v", key, err)
+		}
+	}
+	// s3 moved to auth-c after being bound to auth-b and must survive the cleanup.
+	if err = backend.SetSessionBinding(ctx, "claude:s3::model", "auth-c", time.Hour); err != nil {
+		t.Fatalf("SetSessionBinding() rebind error = %v", err)
+	}
+	if err = backend.DeleteAuthSessionBindings(ctx, "auth-b"); err != nil {
+		t.Fatalf("DeleteAuthSessionBindings() error = %v", err)
+	}
+	for key, want := range map[string]string{"claude:s2::model": "", "claude:s3::model": "auth-c", "claude:s4::model": "auth-c"} {
+		if authID, errGet := backend.SessionBinding(ctx, key); errGet != nil || authID != want {
+			t.Fatalf("SessionBinding(%s) after auth cleanup = %q, %v; want %q", key, authID, errGet, want)
+		}
+	}
+
+	for _, want := range []int64{0, 16} {
+		start, errReserve := backend.ReserveCursor(ctx, "gemini:m:0", 16)
+		if errReserve != nil || start != want {
+			t.Fatalf("ReserveCursor() = %d, %v; want %d", start, errReserve, want)
+		}
+	}
+}
+
+func TestRedisOptionsAcceptsURLs(t *testing.T) {
+	options, err := redisOptions(config.SharedStateConfig{Addr: "redis://:from-url@cache.internal:6380/2", Password: "override"})
+	if err != nil {
+		t.Fatalf("redisOptions() error = %v", err)
+	}
+	if options.Addr != "cache.internal:6380" || options.DB != 2 || options.Password != "override" || options.Protocol != 2 {
+		t.Fatalf("unexpected options: addr=%s db=%d password=%s protocol=%d", options.Addr, options.DB, options.Password, options.Protocol)
+	}
+	if _, err = redisOptions(config.SharedStateConfig{}); err == nil {
+		t.Fatal("redisOptions() accepted an empty address")
+	}
+}
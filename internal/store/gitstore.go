@@ -18,6 +18,7 @@ import (
 	"github.com/go-git/go-git/v6/plumbing/object"
 	"github.com/go-git/go-git/v6/plumbing/transport"
 	"github.com/go-git/go-git/v6/plumbing/transport/http"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authcrypt"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 )
 
@@ -285,6 +286,7 @@ func (s *GitTokenStore) Save(_ context.Context, auth *cliproxyauth.Auth) (string
 		return "", fmt.Errorf("auth filestore: create dir failed: %w", err)
 	}
 
+	var raw []byte
 	switch {
 	case auth.Storage != nil:
 		if auth.Metadata == nil {
@@ -294,32 +296,25 @@ func (s *GitTokenStore) Save(_ context.Context, auth *cliproxyauth.Auth) (string
 		if setter, ok := auth.Storage.(interface{ SetMetadata(map[string]any) }); ok {
 			setter.SetMetadata(auth.Metadata)
 		}
-		if err = auth.Storage.SaveTokenToFile(path); err != nil {
+		if raw, err = authcrypt.MarshalStorage(auth.Storage); err != nil {
 			return "", err
 		}
 	case auth.Metadata != nil:
 		auth.Metadata["disabled"] = auth.Disabled
-		raw, errMarshal := json.Marshal(auth.Metadata)
-		if errMarshal != nil {
-			return "", fmt.Errorf("auth filestore: marshal metadata failed: %w", errMarshal)
-		}
-		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
-				return path, nil
-			}
-		} else if !os.IsNotExist(errRead) {
-			return "", fmt.Errorf("auth filestore: read existing failed: %w", errRead)
-		}
-		tmp := path + ".tmp"
-		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
-			return "", fmt.Errorf("auth filestore: write temp failed: %w", errWrite)
-		}
-		if errRename := os.Rename(tmp, path); errRename != nil {
-			return "", fmt.Errorf("auth filestore: rename failed: %w", errRename)
+		if raw, err = json.Marshal(auth.Metadata); err != nil {
+			return "", fmt.Errorf("auth filestore: marshal metadata failed: %w", err)
 		}
 	default:
 		return "", fmt.Errorf("auth filestore: nothing to persist for %s", auth.ID)
 	}
+	written, err := authcrypt.WriteFile(path, raw)
+	if err != nil {
+		return "", fmt.Errorf("auth filestore: write file failed: %w", err)
+	}
+	if !written && auth.Storage == nil {
+		// Unchanged metadata needs no further work.
+		return path, nil
+	}
 
 	if auth.Attributes == nil {
 		auth.Attributes = make(map[string]string)
@@ -460,7 +455,7 @@ func (s *GitTokenStore) resolveDeletePath(id string) (string, error) {
 }
 
 func (s *GitTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth, error) {
-	data, err := os.ReadFile(path)
+	data, err := authcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
@@ -1024,3 +1019,19 @@ func deepEqualJSON(a, b any) bool {
 		return false
 	}
 }
+
+// snapshot clones the remote into the local workspace for a dry-run migration. Unlike
+// EnsureRepository it never initializes or pushes to an empty remote.
+func (s *GitTokenStore) snapshot(ctx context.Context) error {
+	cloneOpts := &git.CloneOptions{Auth: s.gitAuth(), URL: s.remote}
+	if s.branch != "" {
+		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(s.branch)
+	}
+	if _, err := git.PlainCloneContext(ctx, s.repoDirSnapshot(), cloneOpts); err != nil && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return fmt.Errorf("git token store: clone: %w", err)
+	}
+	if err := os.MkdirAll(s.baseDirSnapshot(), 0o700); err != nil {
+		return fmt.Errorf("git token store: create auth dir: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// Migration actions recorded for every auth record and the config file.
+const (
+	MigrationCreate    = "create"
+	MigrationUpdate    = "update"
+	MigrationUnchanged = "unchanged"
+)
+
+// MigrationBackend is a token store whose config file moves along with its auth records.
+type MigrationBackend interface {
+	cliproxyauth.Store
+	ConfigPath() string
+	PersistConfig(ctx context.Context) error
+}
+
+// MigrationItem describes what a migration does to one auth record or the config file.
+type MigrationItem struct {
+	ID     string `json:"id"`
+	Action string `json:"action"`
+	// Changes lists the top-level fields that differ; values are never reported.
+	Changes []string `json:"changes,omitempty"`
+}
+
+// MigrationReport is the outcome of a migration or dry run.
+type MigrationReport struct {
+	Source string          `json:"source"`
+	Target string          `json:"target"`
+	DryRun bool            `json:"dry_run"`
+	Config MigrationItem   `json:"config"`
+	Auths  []MigrationItem `json:"auths"`
+	// TargetOnly lists auth records that exist only in the target. They are left untouched.
+	TargetOnly []string `json:"target_only,omitempty"`
+	Verified   bool     `json:"verified"`
+}
+
+// Counts returns the number of auth records per action.
+func (r *MigrationReport) Counts() map[string]int {
+	counts := map[string]int{MigrationCreate: 0, MigrationUpdate: 0, MigrationUnchanged: 0}
+	for _, item := range r.Auths {
+		counts[item.Action]++
+	}
+	return counts
+}
+
+// WriteText prints the report in a diff-like form for the CLI.
+func (r *MigrationReport) WriteText(w io.Writer) {
+	mode := "migration"
+	if r.DryRun {
+		mode = "dry run"
+	}
+	_, _ = fmt.Fprintf(w, "Store %s: %s -> %s\n", mode, r.Source, r.Target)
+	writeItem := func(item MigrationItem) {
+		marker := " "
+		switch item.Action {
+		case MigrationCreate:
+			marker = "+"
+		case MigrationUpdate:
+			marker = "~"
+		}
+		line := fmt.Sprintf("%s %s (%s)", marker, item.ID, item.Action)
+		if len(item.Changes) > 0 {
+			line += ": " + strings.Join(item.Changes, ", ")
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+	writeItem(r.Config)
+	for _, item := range r.Auths {
+		writeItem(item)
+	}
+	for _, id := range r.TargetOnly {
+		_, _ = fmt.Fprintf(w, "? %s (only in target, kept)\n", id)
+	}
+	counts := r.Counts()
+	_, _ = fmt.Fprintf(w, "Auths: %d to create, %d to update, %d unchanged, %d only in target\n",
+		counts[MigrationCreate], counts[MigrationUpdate], counts[MigrationUnchanged], len(r.TargetOnly))
+	if !r.DryRun {
+		if r.Verified {
+			_, _ = fmt.Fprintln(w, "Verification: target matches source")
+		} else {
+			_, _ = fmt.Fprintln(w, "Verification: FAILED")
+		}
+	}
+}
+
+// MigrationSource wraps the registered token store so it can be used as a migration backend.
+// Stores without their own config file, such as the file store, use authDir and configPath.
+func MigrationSource(tokenStore cliproxyauth.Store, authDir, configPath string) MigrationBackend {
+	if backend, ok := tokenStore.(MigrationBackend); ok {
+		return backend
+	}
+	return &fileMigrationBackend{Store: tokenStore, authDir: authDir, configPath: configPath}
+}
+
+// errReadOnlyTarget is returned when a target opened for a dry run is written to.
+var errReadOnlyTarget = errors.New("store migration: target was opened read-only")
+
+// fileMigrationBackend pairs a plain token store with a config file on disk.
+type fileMigrationBackend struct {
+	cliproxyauth.Store
+	authDir    string
+	configPath string
+	// label describes the backend in reports when it holds a snapshot of another target.
+	label    string
+	readOnly bool
+}
+
+func (b *fileMigrationBackend) ConfigPath() string { return b.configPath }
+
+func (b *fileMigrationBackend) PersistConfig(context.Context) error {
+	if b.readOnly {
+		return errReadOnlyTarget
+	}
+	return nil
+}
+
+func (b *fileMigrationBackend) Save(ctx context.Context, auth *cliproxyauth.Auth) (string, error) {
+	if b.readOnly {
+		return "", errReadOnlyTarget
+	}
+	return b.Store.Save(ctx, auth)
+}
+
+func (b *fileMigrationBackend) Delete(ctx context.Context, id string) error {
+	if b.readOnly {
+		return errReadOnlyTarget
+	}
+	return b.Store.Delete(ctx, id)
+}
+
+// Migrate copies every auth record and the config file from src to dst. Records keep their IDs
+// and file names, records already equal in dst are skipped so re-runs are idempotent, and
+// records only present in dst are kept. After writing, dst is listed again and compared.
+func Migrate(ctx context.Context, src, dst MigrationBackend, dryRun bool) (*MigrationReport, error) {
+	if src == nil || dst == nil {
+		return nil, fmt.Errorf("store migration: source and target are required")
+	}
+	report := &MigrationReport{Source: describeBackend(src), Target: describeBackend(dst), DryRun: dryRun}
+
+	srcAuths, err := src.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("store migration: list source: %w", err)
+	}
+	dstAuths, err := dst.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("store migration: list target: %w", err)
+	}
+	srcConfig, err := readMigrationConfig(src.ConfigPath())
+	if err != nil {
+		return nil, fmt.Errorf("store migration: read source config: %w", err)
+	}
+	dstConfig, err := readMigrationConfig(dst.ConfigPath())
+	if err != nil {
+		return nil, fmt.Errorf("store migration: read target config: %w", err)
+	}
+
+	report.Config = MigrationItem{ID: "config.yaml", Action: MigrationUnchanged}
+	switch {
+	case srcConfig == nil:
+		// Nothing to copy; the target keeps its own config.
+	case dstConfig == nil:
+		report.Config.Action = MigrationCreate
+	case normalizeLineEndings(string(srcConfig)) != normalizeLineEndings(string(dstConfig)):
+		report.Config.Action = MigrationUpdate
+	}
+
+	existing := indexAuths(dstAuths)
+	pending := make([]*cliproxyauth.Auth, 0, len(srcAuths))
+	seen := make(map[string]struct{}, len(srcAuths))
+	for _, auth := range sortedAuths(srcAuths) {
+		key := filepath.ToSlash(auth.ID)
+		seen[key] = struct{}{}
+		item := MigrationItem{ID: key, Action: MigrationCreate}
+		if current, ok := existing[key]; ok {
+			item.Changes = metadataChanges(current, auth)
+			item.Action = MigrationUpdate
+			if len(item.Changes) == 0 {
+				item.Action = MigrationUnchanged
+			}
+		}
+		report.Auths = append(report.Auths, item)
+		if item.Action != MigrationUnchanged {
+			pending = append(pending, auth)
+		}
+	}
+	for id := range existing {
+		if _, ok := seen[id]; !ok {
+			report.TargetOnly = append(report.TargetOnly, id)
+		}
+	}
+	sort.Strings(report.TargetOnly)
+	if dryRun {
+		return report, nil
+	}
+
+	if report.Config.Action != MigrationUnchanged {
+		if err = writeMigrationConfig(ctx, dst, srcConfig); err != nil {
+			return report, err
+		}
+	}
+	for _, auth := range pending {
+		if _, err = dst.Save(ctx, migratedAuth(auth)); err != nil {
+			return report, fmt.Errorf("store migration: save %s: %w", auth.ID, err)
+		}
+	}
+
+	if err = verifyMigration(ctx, dst, srcAuths, srcConfig); err != nil {
+		return report, err
+	}
+	report.Verified = true
+	return report, nil
+}
+
+func verifyMigration(ctx context.Context, dst MigrationBackend, srcAuths []*cliproxyauth.Auth, srcConfig []byte) error {
+	dstAuths, err := dst.List(ctx)
+	if err != nil {
+		return fmt.Errorf("store migration: verify: list target: %w", err)
+	}
+	migrated := indexAuths(dstAuths)
+	var problems []string
+	for _, auth := range sortedAuths(srcAuths) {
+		current, ok := migrated[filepath.ToSlash(auth.ID)]
+		switch {
+		case !ok:
+			problems = append(problems, auth.ID+" is missing")
+		case len(metadataChanges(current, auth)) > 0:
+			problems = append(problems, auth.ID+" differs")
+		}
+	}
+	if srcConfig != nil {
+		dstConfig, errRead := readMigrationConfig(dst.ConfigPath())
+		if errRead != nil || normalizeLineEndings(string(dstConfig)) != normalizeLineEndings(string(srcConfig)) {
+			problems = append(problems, "config.yaml differs")
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("store migration: verify failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func writeMigrationConfig(ctx context.Context, dst MigrationBackend, data []byte) error {
+	if b, ok := dst.(*fileMigrationBackend); ok && b.readOnly {
+		return errReadOnlyTarget
+	}
+	path := dst.ConfigPath()
+	if path == "" {
+		return fmt.Errorf("store migration: target has no config path")
+	}
+	// Only ever replace a regular config file; a symlink could point anywhere on the host.
+	if info, err := os.Lstat(path); err == nil && !info.Mode().IsRegular() {
+		return fmt.Errorf("store migration: target config %s is not a regular file", path)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("store migration: inspect target config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("store migration: prepare target config dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("store migration: write target config: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("store migration: write target config: %w", err)
+	}
+	if err := dst.PersistConfig(ctx); err != nil {
+		return fmt.Errorf("store migration: persist target config: %w", err)
+	}
+	return nil
+}
+
+// readMigrationConfig returns nil when the config file does not exist.
+func readMigrationConfig(path string) ([]byte, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// migratedAuth copies auth for the target store. The source path is dropped so the target
+// resolves the file under its own auth dir using the preserved ID.
+func migratedAuth(auth *cliproxyauth.Auth) *cliproxyauth.Auth {
+	clone := auth.Clone()
+	clone.Storage = nil
+	clone.FileName = auth.ID
+	delete(clone.Attributes, "path")
+	clone.Metadata = migrationMetadata(auth)
+	return clone
+}
+
+// migrationMetadata returns the metadata as the stores persist it, including the disabled flag.
+func migrationMetadata(auth *cliproxyauth.Auth) map[string]any {
+	metadata := make(map[string]any, len(auth.Metadata)+1)
+	for key, value := range auth.Metadata {
+		metadata[key] = value
+	}
+	metadata["disabled"] = auth.Disabled
+	return metadata
+}
+
+// metadataChanges lists the top-level metadata fields that differ between the target and source.
+func metadataChanges(current, source *cliproxyauth.Auth) []string {
+	have, want := migrationMetadata(current), migrationMetadata(source)
+	var changes []string
+	for key, value := range want {
+		existing, ok := have[key]
+		if !ok || !jsonValueEqual(existing, value) {
+			changes = append(changes, key)
+		}
+	}
+	for key := range have {
+		if _, ok := want[key]; !ok {
+			changes = append(changes, "-"+key)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+func jsonValueEqual(a, b any) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return jsonEqual(rawA, rawB)
+}
+
+func indexAuths(auths []*cliproxyauth.Auth) map[string]*cliproxyauth.Auth {
+	index := make(map[string]*cliproxyauth.Auth, len(auths))
+	for _, auth := range auths {
+		if auth != nil && auth.ID != "" {
+			index[filepath.ToSlash(auth.ID)] = auth
+		}
+	}
+	return index
+}
+
+func sortedAuths(auths []*cliproxyauth.Auth) []*cliproxyauth.Auth {
+	out := make([]*cliproxyauth.Auth, 0, len(auths))
+	for _, auth := range auths {
+		if auth != nil && auth.ID != "" {
+			out = append(out, auth)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func describeBackend(backend MigrationBackend) string {
+	switch b := backend.(type) {
+	case *GitTokenStore:
+		return "git (" + b.AuthDir() + ")"
+	case *ObjectTokenStore:
+		return "object (" + b.cfg.Bucket + ")"
+	case *PostgresStore:
+		return "postgres (" + b.fullTableName(b.cfg.AuthTable) + ")"
+	case *fileMigrationBackend:
+		if b.label != "" {
+			return b.label
+		}
+		return "file (" + b.authDir + ")"
+	default:
+		return fmt.Sprintf("%T", backend)
+	}
+}
@@ -0,0 +1,244 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// MigrationTarget describes the backend a migration writes to. The fields mirror the
+// PGSTORE_*, GITSTORE_* and OBJECTSTORE_* environment variables used at startup.
+type MigrationTarget struct {
+	// Type is one of "file", "git", "postgres" or "object".
+	Type string `json:"type"`
+	// LocalPath is the workspace for git, postgres and object targets.
+	// When empty a temporary directory is used and removed afterwards.
+	LocalPath string `json:"local-path,omitempty"`
+
+	// AuthDir and ConfigPath locate a file target.
+	AuthDir    string `json:"auth-dir,omitempty"`
+	ConfigPath string `json:"config-path,omitempty"`
+
+	DSN    string `json:"dsn,omitempty"`
+	Schema string `json:"schema,omitempty"`
+
+	GitURL      string `json:"git-url,omitempty"`
+	GitUsername string `json:"git-username,omitempty"`
+	GitToken    string `json:"git-token,omitempty"`
+	GitBranch   string `json:"git-branch,omitempty"`
+
+	ObjectEndpoint  string `json:"object-endpoint,omitempty"`
+	ObjectBucket    string `json:"object-bucket,omitempty"`
+	ObjectAccessKey string `json:"object-access-key,omitempty"`
+	ObjectSecretKey string `json:"object-secret-key,omitempty"`
+}
+
+// MigrationTargetFromEnv builds a migration target of the given type from MIGRATE_-prefixed
+// copies of the store environment variables, e.g. MIGRATE_PGSTORE_DSN or MIGRATE_GITSTORE_GIT_URL.
+// File targets use MIGRATE_AUTH_DIR and MIGRATE_CONFIG_PATH.
+func MigrationTargetFromEnv(targetType string) MigrationTarget {
+	env := func(key string) string {
+		if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+			return value
+		}
+		return strings.TrimSpace(os.Getenv(strings.ToLower(key)))
+	}
+	return MigrationTarget{
+		Type:            targetType,
+		LocalPath:       env("MIGRATE_LOCAL_PATH"),
+		AuthDir:         env("MIGRATE_AUTH_DIR"),
+		ConfigPath:      env("MIGRATE_CONFIG_PATH"),
+		DSN:             env("MIGRATE_PGSTORE_DSN"),
+		Schema:          env("MIGRATE_PGSTORE_SCHEMA"),
+		GitURL:          env("MIGRATE_GITSTORE_GIT_URL"),
+		GitUsername:     env("MIGRATE_GITSTORE_GIT_USERNAME"),
+		GitToken:        env("MIGRATE_GITSTORE_GIT_TOKEN"),
+		GitBranch:       env("MIGRATE_GITSTORE_GIT_BRANCH"),
+		ObjectEndpoint:  env("MIGRATE_OBJECTSTORE_ENDPOINT"),
+		ObjectBucket:    env("MIGRATE_OBJECTSTORE_BUCKET"),
+		ObjectAccessKey: env("MIGRATE_OBJECTSTORE_ACCESS_KEY"),
+		ObjectSecretKey: env("MIGRATE_OBJECTSTORE_SECRET_KEY"),
+	}
+}
+
+// Configured reports whether the fields required by the target type are set.
+func (t MigrationTarget) Configured() bool {
+	switch strings.ToLower(strings.TrimSpace(t.Type)) {
+	case "file":
+		return t.AuthDir != "" && t.ConfigPath != ""
+	case "postgres":
+		return t.DSN != ""
+	case "git":
+		return t.GitURL != ""
+	case "object":
+		return t.ObjectEndpoint != "" && t.ObjectBucket != "" && t.ObjectAccessKey != "" && t.ObjectSecretKey != ""
+	default:
+		return false
+	}
+}
+
+// OpenMigrationTarget prepares the target backend the same way the server does at startup
+// (schema, bucket or clone) and returns a function releasing its resources. With readOnly the
+// target is only read: its content is copied into a temporary workspace and the returned
+// backend rejects writes, so a dry run leaves the target untouched.
+func OpenMigrationTarget(ctx context.Context, target MigrationTarget, readOnly bool) (MigrationBackend, func(), error) {
+	kind := strings.ToLower(strings.TrimSpace(target.Type))
+	if kind == "file" {
+		authDir := strings.TrimSpace(target.AuthDir)
+		configPath := strings.TrimSpace(target.ConfigPath)
+		if authDir == "" || configPath == "" {
+			return nil, nil, fmt.Errorf("store migration: file target requires auth-dir and config-path")
+		}
+		if readOnly {
+			if _, err := os.Stat(authDir); errors.Is(err, fs.ErrNotExist) {
+				return &fileMigrationBackend{Store: emptyStore{}, authDir: authDir, configPath: configPath, readOnly: true}, func() {}, nil
+			}
+		} else if err := os.MkdirAll(authDir, 0o700); err != nil {
+			return nil, nil, fmt.Errorf("store migration: create target auth dir: %w", err)
+		}
+		fileStore := sdkAuth.NewFileTokenStore()
+		fileStore.SetBaseDir(authDir)
+		return &fileMigrationBackend{Store: fileStore, authDir: authDir, configPath: configPath, readOnly: readOnly}, func() {}, nil
+	}
+
+	localPath := strings.TrimSpace(target.LocalPath)
+	cleanup := func() {}
+	if localPath == "" || readOnly {
+		tmp, err := os.MkdirTemp("", "cliproxy-migrate-")
+		if err != nil {
+			return nil, nil, fmt.Errorf("store migration: create workspace: %w", err)
+		}
+		localPath = tmp
+		cleanup = func() { _ = os.RemoveAll(tmp) }
+	}
+
+	switch kind {
+	case "postgres":
+		pgStore, err := NewPostgresStore(ctx, PostgresStoreConfig{
+			DSN:      target.DSN,
+			Schema:   target.Schema,
+			SpoolDir: filepath.Join(localPath, "pgstore"),
+		})
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		if readOnly {
+			err = pgStore.snapshot(ctx)
+			_ = pgStore.Close()
+			if err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			return snapshotBackend("postgres ("+pgStore.fullTableName(pgStore.cfg.AuthTable)+")", pgStore.AuthDir(), pgStore.ConfigPath()), cleanup, nil
+		}
+		if err = pgStore.Bootstrap(ctx, ""); err != nil {
+			_ = pgStore.Close()
+			cleanup()
+			return nil, nil, err
+		}
+		return pgStore, func() { _ = pgStore.Close(); cleanup() }, nil
+	case "object":
+		endpoint, useSSL, err := ParseObjectEndpoint(target.ObjectEndpoint)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		objectStore, err := NewObjectTokenStore(ObjectStoreConfig{
+			Endpoint:  endpoint,
+			Bucket:    target.ObjectBucket,
+			AccessKey: target.ObjectAccessKey,
+			SecretKey: target.ObjectSecretKey,
+			LocalRoot: filepath.Join(localPath, "objectstore"),
+			UseSSL:    useSSL,
+			PathStyle: true,
+		})
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		if readOnly {
+			if err = objectStore.snapshot(ctx); err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			return snapshotBackend("object ("+objectStore.cfg.Bucket+")", objectStore.AuthDir(), objectStore.ConfigPath()), cleanup, nil
+		}
+		if err = objectStore.Bootstrap(ctx, ""); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		return objectStore, cleanup, nil
+	case "git":
+		if strings.TrimSpace(target.GitURL) == "" {
+			cleanup()
+			return nil, nil, fmt.Errorf("store migration: git target requires git-url")
+		}
+		gitStore := NewGitTokenStore(target.GitURL, target.GitUsername, target.GitToken, target.GitBranch)
+		gitStore.SetBaseDir(filepath.Join(localPath, "gitstore", "auths"))
+		if readOnly {
+			if err := gitStore.snapshot(ctx); err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			return snapshotBackend("git ("+target.GitURL+")", gitStore.AuthDir(), gitStore.ConfigPath()), cleanup, nil
+		}
+		if err := gitStore.EnsureRepository(); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		return gitStore, cleanup, nil
+	default:
+		cleanup()
+		return nil, nil, fmt.Errorf("store migration: unsupported target type %q (want file, git, postgres or object)", target.Type)
+	}
+}
+
+// snapshotBackend lists a target copied into authDir and configPath by a read-only open.
+func snapshotBackend(label, authDir, configPath string) MigrationBackend {
+	fileStore := sdkAuth.NewFileTokenStore()
+	fileStore.SetBaseDir(authDir)
+	return &fileMigrationBackend{Store: fileStore, authDir: authDir, configPath: configPath, label: label, readOnly: true}
+}
+
+// emptyStore lists no records; it stands for a file target whose auth dir does not exist yet.
+type emptyStore struct{ cliproxyauth.Store }
+
+func (emptyStore) List(context.Context) ([]*cliproxyauth.Auth, error) { return nil, nil }
+
+// ParseObjectEndpoint splits an OBJECTSTORE_ENDPOINT value into the host (plus optional path)
+// expected by the object store and whether TLS is used. Endpoints without a scheme use TLS.
+func ParseObjectEndpoint(raw string) (string, bool, error) {
+	endpoint := strings.TrimSpace(raw)
+	useSSL := true
+	if strings.Contains(endpoint, "://") {
+		parsed, err := url.Parse(endpoint)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to parse object store endpoint %q: %w", raw, err)
+		}
+		switch strings.ToLower(parsed.Scheme) {
+		case "http":
+			useSSL = false
+		case "https":
+			useSSL = true
+		default:
+			return "", false, fmt.Errorf("unsupported object store scheme %q (only http and https are allowed)", parsed.Scheme)
+		}
+		if parsed.Host == "" {
+			return "", false, fmt.Errorf("object store endpoint %q is missing host information", raw)
+		}
+		endpoint = parsed.Host
+		if parsed.Path != "" && parsed.Path != "/" {
+			endpoint = strings.TrimSuffix(parsed.Host+parsed.Path, "/")
+		}
+	}
+	return strings.TrimRight(endpoint, "/"), useSSL, nil
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
+)
+
+func TestMigrateFileStores(t *testing.T) {
+	ctx := context.Background()
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	srcConfig := filepath.Join(srcDir, "config.yaml")
+	writeTestFile(t, srcConfig, "port: 8317\n")
+	writeTestFile(t, filepath.Join(srcDir, "auths", "codex-a.json"), `{"type":"codex","email":"a@example.com"}`)
+	writeTestFile(t, filepath.Join(srcDir, "auths", "codex-b.json"), `{"type":"codex","email":"b@example.com","disabled":true}`)
+	writeTestFile(t, filepath.Join(dstDir, "auths", "codex-b.json"), `{"type":"codex","email":"old@example.com"}`)
+	writeTestFile(t, filepath.Join(dstDir, "auths", "extra.json"), `{"type":"claude"}`)
+
+	srcStore := sdkAuth.NewFileTokenStore()
+	srcStore.SetBaseDir(filepath.Join(srcDir, "auths"))
+	src := MigrationSource(srcStore, filepath.Join(srcDir, "auths"), srcConfig)
+	target := MigrationTarget{
+		Type:       "file",
+		AuthDir:    filepath.Join(dstDir, "auths"),
+		ConfigPath: filepath.Join(dstDir, "config.yaml"),
+	}
+	readOnly, closeReadOnly, err := OpenMigrationTarget(ctx, target, true)
+	if err != nil {
+		t.Fatalf("OpenMigrationTarget(readOnly) error: %v", err)
+	}
+	defer closeReadOnly()
+
+	report, err := Migrate(ctx, src, readOnly, true)
+	if err != nil {
+		t.Fatalf("dry run error: %v", err)
+	}
+	counts := report.Counts()
+	if counts[MigrationCreate] != 1 || counts[MigrationUpdate] != 1 || report.Config.Action != MigrationCreate {
+		t.Fatalf("unexpected dry run plan: %+v", report)
+	}
+	if got := report.Auths[1].Changes; len(got) != 2 || got[0] != "disabled" || got[1] != "email" {
+		t.Fatalf("changes = %v, want [disabled email]", got)
+	}
+	if len(report.TargetOnly) != 1 || report.TargetOnly[0] != "extra.json" {
+		t.Fatalf("target only = %v", report.TargetOnly)
+	}
+	if _, errStat := os.Stat(filepath.Join(dstDir, "auths", "codex-a.json")); !os.IsNotExist(errStat) {
+		t.Fatalf("dry run wrote to the target: %v", errStat)
+	}
+	if _, err = Migrate(ctx, src, readOnly, false); err == nil {
+		t.Fatal("migrating into a read-only target should fail")
+	}
+	if _, errStat := os.Stat(filepath.Join(dstDir, "config.yaml")); !os.IsNotExist(errStat) {
+		t.Fatalf("read-only target config was written: %v", errStat)
+	}
+
+	dst, closeTarget, err := OpenMigrationTarget(ctx, target, false)
+	if err != nil {
+		t.Fatalf("OpenMigrationTarget() error: %v", err)
+	}
+	defer closeTarget()
+
+	if report, err = Migrate(ctx, src, dst, false); err != nil || !report.Verified {
+		t.Fatalf("migrate error: %v (report %+v)", err, report)
+	}
+	if data, errRead := os.ReadFile(filepath.Join(dstDir, "config.yaml")); errRead != nil || string(data) != "port: 8317\n" {
+		t.Fatalf("target config = %q, %v", data, errRead)
+	}
+
+	if report, err = Migrate(ctx, src, dst, false); err != nil {
+		t.Fatalf("re-run error: %v", err)
+	}
+	if counts = report.Counts(); counts[MigrationUnchanged] != 2 || report.Config.Action != MigrationUnchanged {
+		t.Fatalf("re-run should be a no-op: %+v", report)
+	}
+	if _, errStat := os.Stat(filepath.Join(dstDir, "auths", "extra.json")); errStat != nil {
+		t.Fatalf("target-only auth was removed: %v", errStat)
+	}
+}
+
+func TestMigrateRefusesSymlinkedTargetConfig(t *testing.T) {
+	ctx := context.Background()
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	srcConfig := filepath.Join(srcDir, "config.yaml")
+	writeTestFile(t, srcConfig, "port: 8317\n")
+	victim := filepath.Join(t.TempDir(), "victim")
+	writeTestFile(t, victim, "keep")
+	if err := os.Symlink(victim, filepath.Join(dstDir, "config.yaml")); err != nil {
+		t.Skipf("symlink unsupported: %v", err)
+	}
+
+	srcStore := sdkAuth.NewFileTokenStore()
+	srcStore.SetBaseDir(filepath.Join(srcDir, "auths"))
+	dst, closeTarget, err := OpenMigrationTarget(ctx, MigrationTarget{
+		Type:       "file",
+		AuthDir:    filepath.Join(dstDir, "auths"),
+		ConfigPath: filepath.Join(dstDir, "config.yaml"),
+	}, false)
+	if err != nil {
+		t.Fatalf("OpenMigrationTarget() error: %v", err)
+	}
+	defer closeTarget()
+
+	if _, err = Migrate(ctx, MigrationSource(srcStore, filepath.Join(srcDir, "auths"), srcConfig), dst, false); err == nil {
+		t.Fatal("migrate should refuse a symlinked target config")
+	}
+	if data, _ := os.ReadFile(victim); string(data) != "keep" {
+		t.Fatalf("symlink target was overwritten: %q", data)
+	}
+}
+
+func TestMigrationTargetFromEnv(t *testing.T) {
+	t.Setenv("MIGRATE_PGSTORE_DSN", "")
+	if MigrationTargetFromEnv("postgres").Configured() {
+		t.Fatal("postgres target without DSN should not be configured")
+	}
+	t.Setenv("MIGRATE_PGSTORE_DSN", "postgres://localhost/db")
+	target := MigrationTargetFromEnv("postgres")
+	if !target.Configured() || target.DSN != "postgres://localhost/db" {
+		t.Fatalf("target = %+v, want DSN from env", target)
+	}
+	if MigrationTargetFromEnv("ftp").Configured() {
+		t.Fatal("unknown target type should not be configured")
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
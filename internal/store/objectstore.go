@@ -17,6 +17,7 @@ import (
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
@@ -182,6 +183,7 @@ func (s *ObjectTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (s
 		return "", fmt.Errorf("object store: create auth directory: %w", err)
 	}
 
+	var raw []byte
 	switch {
 	case auth.Storage != nil:
 		if auth.Metadata == nil {
@@ -191,32 +193,25 @@ func (s *ObjectTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (s
 		if setter, ok := auth.Storage.(interface{ SetMetadata(map[string]any) }); ok {
 			setter.SetMetadata(auth.Metadata)
 		}
-		if err = auth.Storage.SaveTokenToFile(path); err != nil {
+		if raw, err = authcrypt.MarshalStorage(auth.Storage); err != nil {
 			return "", err
 		}
 	case auth.Metadata != nil:
 		auth.Metadata["disabled"] = auth.Disabled
-		raw, errMarshal := json.Marshal(auth.Metadata)
-		if errMarshal != nil {
-			return "", fmt.Errorf("object store: marshal metadata: %w", errMarshal)
-		}
-		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
-				return path, nil
-			}
-		} else if errRead != nil && !errors.Is(errRead, fs.ErrNotExist) {
-			return "", fmt.Errorf("object store: read existing metadata: %w", errRead)
-		}
-		tmp := path + ".tmp"
-		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
-			return "", fmt.Errorf("object store: write temp auth file: %w", errWrite)
-		}
-		if errRename := os.Rename(tmp, path); errRename != nil {
-			return "", fmt.Errorf("object store: rename auth file: %w", errRename)
+		if raw, err = json.Marshal(auth.Metadata); err != nil {
+			return "", fmt.Errorf("object store: marshal metadata: %w", err)
 		}
 	default:
 		return "", fmt.Errorf("object store: nothing to persist for %s", auth.ID)
 	}
+	written, err := authcrypt.WriteFile(path, raw)
+	if err != nil {
+		return "", fmt.Errorf("object store: write auth file: %w", err)
+	}
+	if !written && auth.Storage == nil {
+		// Unchanged metadata needs no further work.
+		return path, nil
+	}
 
 	if auth.Attributes == nil {
 		auth.Attributes = make(map[string]string)
@@ -562,7 +557,7 @@ func (s *ObjectTokenStore) resolveDeletePath(id string) (string, error) {
 }
 
 func (s *ObjectTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth, error) {
-	data, err := os.ReadFile(path)
+	data, err := authcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
@@ -630,3 +625,31 @@ func isObjectNotFound(err error) bool {
 	}
 	return false
 }
+
+// snapshot mirrors the bucket into the local workspace for a dry-run migration. Unlike
+// Bootstrap it never creates the bucket or uploads a config.
+func (s *ObjectTokenStore) snapshot(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.cfg.Bucket)
+	if err != nil {
+		return fmt.Errorf("object store: check bucket: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+	object, err := s.client.GetObject(ctx, s.cfg.Bucket, s.prefixedKey(objectStoreConfigKey), minio.GetObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("object store: fetch config: %w", err)
+	}
+	data, errRead := io.ReadAll(object)
+	_ = object.Close()
+	switch {
+	case isObjectNotFound(errRead):
+	case errRead != nil:
+		return fmt.Errorf("object store: read config: %w", errRead)
+	default:
+		if err = os.WriteFile(s.configPath, normalizeLineEndingsBytes(data), 0o600); err != nil {
+			return fmt.Errorf("object store: write config: %w", err)
+		}
+	}
+	return s.syncAuthFromBucket(ctx)
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cachesnapshot"
+)
+
+const objectStoreSnapshotPrefix = "cache-snapshots"
+
+var _ cachesnapshot.Backend = (*ObjectTokenStore)(nil)
+
+// SaveCacheSnapshot uploads the cache snapshot stored under name.
+func (s *ObjectTokenStore) SaveCacheSnapshot(ctx context.Context, name string, data []byte) error {
+	return s.putObject(ctx, objectStoreSnapshotPrefix+"/"+name+".json", data, "application/json")
+}
+
+// LoadCacheSnapshot downloads the cache snapshot stored under name, or returns nil when there is none.
+func (s *ObjectTokenStore) LoadCacheSnapshot(ctx context.Context, name string) ([]byte, error) {
+	key := s.prefixedKey(objectStoreSnapshotPrefix + "/" + name + ".json")
+	object, err := s.client.GetObject(ctx, s.cfg.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("object store: fetch cache snapshot: %w", err)
+	}
+	defer object.Close()
+	data, err := io.ReadAll(object)
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("object store: read cache snapshot: %w", err)
+	}
+	return data, nil
+}
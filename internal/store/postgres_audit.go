@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/audit"
+)
+
+var _ audit.Backend = (*PostgresStore)(nil)
+
+func (s *PostgresStore) ensureAuditSchema(ctx context.Context) error {
+	auditTable := s.fullTableName(s.cfg.AuditTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			ts TIMESTAMPTZ NOT NULL,
+			content JSONB NOT NULL
+		)
+	`, auditTable)); err != nil {
+		return fmt.Errorf("postgres store: create audit table: %w", err)
+	}
+	indexName := quoteIdentifier(s.cfg.AuditTable + "_ts_idx")
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (ts)", indexName, auditTable)); err != nil {
+		return fmt.Errorf("postgres store: create audit index: %w", err)
+	}
+	return nil
+}
+
+// AppendAudit inserts a management audit entry into the audit table.
+func (s *PostgresStore) AppendAudit(ctx context.Context, entry audit.Entry) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("postgres store: encode audit entry: %w", err)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (ts, content) VALUES ($1, $2)", s.fullTableName(s.cfg.AuditTable))
+	if _, err = s.db.ExecContext(ctx, query, entry.Timestamp.UTC(), string(payload)); err != nil {
+		return fmt.Errorf("postgres store: insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// QueryAudit loads management audit entries matching the filter, newest first.
+func (s *PostgresStore) QueryAudit(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres store: not initialized")
+	}
+	var (
+		conditions []string
+		args       []any
+	)
+	if !filter.From.IsZero() {
+		args = append(args, filter.From.UTC())
+		conditions = append(conditions, fmt.Sprintf("ts >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To.UTC())
+		conditions = append(conditions, fmt.Sprintf("ts < $%d", len(args)))
+	}
+	query := fmt.Sprintf("SELECT content FROM %s", s.fullTableName(s.cfg.AuditTable))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY ts DESC, id DESC"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: query audit: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []audit.Entry
+	for rows.Next() {
+		var payload []byte
+		if err = rows.Scan(&payload); err != nil {
+			return nil, fmt.Errorf("postgres store: scan audit row: %w", err)
+		}
+		var entry audit.Entry
+		if err = json.Unmarshal(payload, &entry); err != nil {
+			continue
+		}
+		if filter.Match(entry) {
+			out = append(out, entry)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres store: iterate audit rows: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cachesnapshot"
+)
+
+var _ cachesnapshot.Backend = (*PostgresStore)(nil)
+
+func (s *PostgresStore) ensureCacheSnapshotSchema(ctx context.Context) error {
+	snapshotTable := s.fullTableName(s.cfg.SnapshotTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			name TEXT PRIMARY KEY,
+			content TEXT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`, snapshotTable)); err != nil {
+		return fmt.Errorf("postgres store: create cache snapshot table: %w", err)
+	}
+	return nil
+}
+
+// SaveCacheSnapshot upserts the cache snapshot stored under name.
+func (s *PostgresStore) SaveCacheSnapshot(ctx context.Context, name string, data []byte) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (name, content, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name)
+		DO UPDATE SET content = EXCLUDED.content, updated_at = NOW()
+	`, s.fullTableName(s.cfg.SnapshotTable))
+	if _, err := s.db.ExecContext(ctx, query, name, string(data)); err != nil {
+		return fmt.Errorf("postgres store: upsert cache snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadCacheSnapshot returns the cache snapshot stored under name, or nil when there is none.
+func (s *PostgresStore) LoadCacheSnapshot(ctx context.Context, name string) ([]byte, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres store: not initialized")
+	}
+	query := fmt.Sprintf("SELECT content FROM %s WHERE name = $1", s.fullTableName(s.cfg.SnapshotTable))
+	var content string
+	err := s.db.QueryRowContext(ctx, query, name).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: load cache snapshot: %w", err)
+	}
+	return []byte(content), nil
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/configversion"
+)
+
+var _ configversion.Backend = (*PostgresStore)(nil)
+
+func (s *PostgresStore) ensureConfigVersionSchema(ctx context.Context) error {
+	versionTable := s.fullTableName(s.cfg.VersionTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ NOT NULL,
+			source TEXT NOT NULL,
+			note TEXT NOT NULL DEFAULT '',
+			sha256 TEXT NOT NULL,
+			content TEXT NOT NULL
+		)
+	`, versionTable)); err != nil {
+		return fmt.Errorf("postgres store: create config version table: %w", err)
+	}
+	return nil
+}
+
+// SaveConfigVersion inserts a config version and returns it with its assigned ID.
+func (s *PostgresStore) SaveConfigVersion(ctx context.Context, version configversion.Version) (configversion.Version, error) {
+	if s == nil || s.db == nil {
+		return configversion.Version{}, fmt.Errorf("postgres store: not initialized")
+	}
+	query := fmt.Sprintf("INSERT INTO %s (created_at, source, note, sha256, content) VALUES ($1, $2, $3, $4, $5) RETURNING id", s.fullTableName(s.cfg.VersionTable))
+	if err := s.db.QueryRowContext(ctx, query, version.CreatedAt.UTC(), version.Source, version.Note, version.SHA256, string(version.Content)).Scan(&version.ID); err != nil {
+		return configversion.Version{}, fmt.Errorf("postgres store: insert config version: %w", err)
+	}
+	return version, nil
+}
+
+// ListConfigVersions returns config version metadata, newest first.
+func (s *PostgresStore) ListConfigVersions(ctx context.Context) ([]configversion.Version, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres store: not initialized")
+	}
+	query := fmt.Sprintf("SELECT id, created_at, source, note, sha256, octet_length(content) FROM %s ORDER BY id DESC", s.fullTableName(s.cfg.VersionTable))
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: query config versions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []configversion.Version
+	for rows.Next() {
+		var version configversion.Version
+		if err = rows.Scan(&version.ID, &version.CreatedAt, &version.Source, &version.Note, &version.SHA256, &version.Size); err != nil {
+			return nil, fmt.Errorf("postgres store: scan config version row: %w", err)
+		}
+		out = append(out, version)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres store: iterate config version rows: %w", err)
+	}
+	return out, nil
+}
+
+// GetConfigVersion loads one config version with its content.
+func (s *PostgresStore) GetConfigVersion(ctx context.Context, id int64) (configversion.Version, error) {
+	if s == nil || s.db == nil {
+		return configversion.Version{}, fmt.Errorf("postgres store: not initialized")
+	}
+	query := fmt.Sprintf("SELECT id, created_at, source, note, sha256, content FROM %s WHERE id = $1", s.fullTableName(s.cfg.VersionTable))
+	var (
+		version configversion.Version
+		content string
+	)
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&version.ID, &version.CreatedAt, &version.Source, &version.Note, &version.SHA256, &content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return configversion.Version{}, configversion.ErrNotFound
+	}
+	if err != nil {
+		return configversion.Version{}, fmt.Errorf("postgres store: load config version: %w", err)
+	}
+	version.Content = []byte(content)
+	version.Size = len(content)
+	return version, nil
+}
+
+// PruneConfigVersions deletes all but the newest keep config versions.
+func (s *PostgresStore) PruneConfigVersions(ctx context.Context, keep int) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	if keep < 0 {
+		return nil
+	}
+	table := s.fullTableName(s.cfg.VersionTable)
+	query := fmt.Sprintf("DELETE FROM %s WHERE id NOT IN (SELECT id FROM %s ORDER BY id DESC LIMIT $1)", table, table)
+	if _, err := s.db.ExecContext(ctx, query, keep); err != nil {
+		return fmt.Errorf("postgres store: prune config versions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageledger"
+)
+
+var _ usageledger.Backend = (*PostgresStore)(nil)
+
+// ensureUsageSchema creates the usage table the first time the ledger touches it.
+func (s *PostgresStore) ensureUsageSchema(ctx context.Context) error {
+	if s.usageReady.Load() {
+		return nil
+	}
+	usageTable := s.fullTableName(s.cfg.UsageTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			ts TIMESTAMPTZ NOT NULL,
+			content JSONB NOT NULL
+		)
+	`, usageTable)); err != nil {
+		return fmt.Errorf("postgres store: create usage table: %w", err)
+	}
+	indexName := quoteIdentifier(s.cfg.UsageTable + "_ts_idx")
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (ts)", indexName, usageTable)); err != nil {
+		return fmt.Errorf("postgres store: create usage index: %w", err)
+	}
+	s.usageReady.Store(true)
+	return nil
+}
+
+// AppendUsage inserts a batch of usage ledger entries into the usage table in one transaction.
+func (s *PostgresStore) AppendUsage(ctx context.Context, entries []usageledger.Entry) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := s.ensureUsageSchema(ctx); err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("postgres store: begin usage insert: %w", err)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (ts, content) VALUES ($1, $2)", s.fullTableName(s.cfg.UsageTable))
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("postgres store: prepare usage insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, entry := range entries {
+		payload, errMarshal := json.Marshal(entry)
+		if errMarshal != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("postgres store: encode usage entry: %w", errMarshal)
+		}
+		if _, errExec := stmt.ExecContext(ctx, entry.Timestamp.UTC(), string(payload)); errExec != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("postgres store: insert usage entry: %w", errExec)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("postgres store: commit usage insert: %w", err)
+	}
+	return nil
+}
+
+// QueryUsage loads usage ledger entries within the filter time range.
+func (s *PostgresStore) QueryUsage(ctx context.Context, filter usageledger.Filter) ([]usageledger.Entry, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres store: not initialized")
+	}
+	if err := s.ensureUsageSchema(ctx); err != nil {
+		return nil, err
+	}
+	var (
+		conditions []string
+		args       []any
+	)
+	if !filter.From.IsZero() {
+		args = append(args, filter.From.UTC())
+		conditions = append(conditions, fmt.Sprintf("ts >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To.UTC())
+		conditions = append(conditions, fmt.Sprintf("ts < $%d", len(args)))
+	}
+	query := fmt.Sprintf("SELECT content FROM %s", s.fullTableName(s.cfg.UsageTable))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY ts, id"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: query usage: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []usageledger.Entry
+	for rows.Next() {
+		var payload []byte
+		if err = rows.Scan(&payload); err != nil {
+			return nil, fmt.Errorf("postgres store: scan usage row: %w", err)
+		}
+		var entry usageledger.Entry
+		if err = json.Unmarshal(payload, &entry); err != nil {
+			continue
+		}
+		if filter.Match(entry) {
+			out = append(out, entry)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres store: iterate usage rows: %w", err)
+	}
+	return out, nil
+}
+
+// PruneUsage deletes usage ledger entries recorded before the cutoff.
+func (s *PostgresStore) PruneUsage(ctx context.Context, before time.Time) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	if err := s.ensureUsageSchema(ctx); err != nil {
+		return err
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE ts < $1", s.fullTableName(s.cfg.UsageTable))
+	if _, err := s.db.ExecContext(ctx, query, before.UTC()); err != nil {
+		return fmt.Errorf("postgres store: prune usage: %w", err)
+	}
+	return nil
+}
@@ -11,27 +11,37 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
-	defaultConfigTable = "config_store"
-	defaultAuthTable   = "auth_store"
-	defaultConfigKey   = "config"
+	defaultConfigTable   = "config_store"
+	defaultAuthTable     = "auth_store"
+	defaultUsageTable    = "usage_ledger"
+	defaultAuditTable    = "audit_log"
+	defaultVersionTable  = "config_versions"
+	defaultSnapshotTable = "cache_snapshots"
+	defaultConfigKey     = "config"
 )
 
 // PostgresStoreConfig captures configuration required to initialize a Postgres-backed store.
 type PostgresStoreConfig struct {
-	DSN         string
-	Schema      string
-	ConfigTable string
-	AuthTable   string
-	SpoolDir    string
+	DSN           string
+	Schema        string
+	ConfigTable   string
+	AuthTable     string
+	UsageTable    string
+	AuditTable    string
+	VersionTable  string
+	SnapshotTable string
+	SpoolDir      string
 }
 
 // PostgresStore persists configuration and authentication metadata using PostgreSQL as backend
@@ -43,6 +53,9 @@ type PostgresStore struct {
 	configPath string
 	authDir    string
 	mu         sync.Mutex
+	// usageReady records that the usage table exists. It is created on first use so that
+	// deployments without the usage ledger do not get one.
+	usageReady atomic.Bool
 }
 
 // NewPostgresStore establishes a connection to PostgreSQL and prepares the local workspace.
@@ -58,6 +71,18 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 	if cfg.AuthTable == "" {
 		cfg.AuthTable = defaultAuthTable
 	}
+	if cfg.UsageTable == "" {
+		cfg.UsageTable = defaultUsageTable
+	}
+	if cfg.AuditTable == "" {
+		cfg.AuditTable = defaultAuditTable
+	}
+	if cfg.VersionTable == "" {
+		cfg.VersionTable = defaultVersionTable
+	}
+	if cfg.SnapshotTable == "" {
+		cfg.SnapshotTable = defaultSnapshotTable
+	}
 
 	spoolRoot := strings.TrimSpace(cfg.SpoolDir)
 	if spoolRoot == "" {
@@ -140,6 +165,15 @@ func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	`, authTable)); err != nil {
 		return fmt.Errorf("postgres store: create auth table: %w", err)
 	}
+	if err := s.ensureAuditSchema(ctx); err != nil {
+		return err
+	}
+	if err := s.ensureConfigVersionSchema(ctx); err != nil {
+		return err
+	}
+	if err := s.ensureCacheSnapshotSchema(ctx); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -212,6 +246,7 @@ func (s *PostgresStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (stri
 		return "", fmt.Errorf("postgres store: create auth directory: %w", err)
 	}
 
+	var raw []byte
 	switch {
 	case auth.Storage != nil:
 		if auth.Metadata == nil {
@@ -221,32 +256,25 @@ func (s *PostgresStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (stri
 		if setter, ok := auth.Storage.(interface{ SetMetadata(map[string]any) }); ok {
 			setter.SetMetadata(auth.Metadata)
 		}
-		if err = auth.Storage.SaveTokenToFile(path); err != nil {
+		if raw, err = authcrypt.MarshalStorage(auth.Storage); err != nil {
 			return "", err
 		}
 	case auth.Metadata != nil:
 		auth.Metadata["disabled"] = auth.Disabled
-		raw, errMarshal := json.Marshal(auth.Metadata)
-		if errMarshal != nil {
-			return "", fmt.Errorf("postgres store: marshal metadata: %w", errMarshal)
-		}
-		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
-				return path, nil
-			}
-		} else if errRead != nil && !errors.Is(errRead, fs.ErrNotExist) {
-			return "", fmt.Errorf("postgres store: read existing metadata: %w", errRead)
-		}
-		tmp := path + ".tmp"
-		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
-			return "", fmt.Errorf("postgres store: write temp auth file: %w", errWrite)
-		}
-		if errRename := os.Rename(tmp, path); errRename != nil {
-			return "", fmt.Errorf("postgres store: rename auth file: %w", errRename)
+		if raw, err = json.Marshal(auth.Metadata); err != nil {
+			return "", fmt.Errorf("postgres store: marshal metadata: %w", err)
 		}
 	default:
 		return "", fmt.Errorf("postgres store: nothing to persist for %s", auth.ID)
 	}
+	written, err := authcrypt.WriteFile(path, raw)
+	if err != nil {
+		return "", fmt.Errorf("postgres store: write auth file: %w", err)
+	}
+	if !written && auth.Storage == nil {
+		// Unchanged metadata needs no further work.
+		return path, nil
+	}
 
 	if auth.Attributes == nil {
 		auth.Attributes = make(map[string]string)
@@ -292,8 +320,13 @@ func (s *PostgresStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 			log.WithError(errPath).Warnf("postgres store: skipping auth %s outside spool", id)
 			continue
 		}
+		plain, errOpen := authcrypt.Open([]byte(payload))
+		if errOpen != nil {
+			log.WithError(errOpen).Warnf("postgres store: skipping auth %s that cannot be decrypted", id)
+			continue
+		}
 		metadata := make(map[string]any)
-		if err = json.Unmarshal([]byte(payload), &metadata); err != nil {
+		if err = json.Unmarshal(plain, &metadata); err != nil {
 			log.WithError(err).Warnf("postgres store: skipping auth %s with invalid json", id)
 			continue
 		}
@@ -676,3 +709,38 @@ func normalizeLineEndings(s string) string {
 	s = strings.ReplaceAll(s, "\r", "\n")
 	return s
 }
+
+// snapshot copies the config and auth records into the spool for a dry-run migration. Unlike
+// Bootstrap it never creates tables or writes to the database.
+func (s *PostgresStore) snapshot(ctx context.Context) error {
+	exists, err := s.tableExists(ctx, s.cfg.ConfigTable)
+	if err != nil {
+		return err
+	}
+	if exists {
+		var content string
+		query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", s.fullTableName(s.cfg.ConfigTable))
+		errScan := s.db.QueryRowContext(ctx, query, defaultConfigKey).Scan(&content)
+		switch {
+		case errors.Is(errScan, sql.ErrNoRows):
+		case errScan != nil:
+			return fmt.Errorf("postgres store: load config from database: %w", errScan)
+		default:
+			if err = os.WriteFile(s.configPath, []byte(normalizeLineEndings(content)), 0o600); err != nil {
+				return fmt.Errorf("postgres store: write config to spool: %w", err)
+			}
+		}
+	}
+	if exists, err = s.tableExists(ctx, s.cfg.AuthTable); err != nil || !exists {
+		return err
+	}
+	return s.syncAuthFromDatabase(ctx)
+}
+
+func (s *PostgresStore) tableExists(ctx context.Context, table string) (bool, error) {
+	var name sql.NullString
+	if err := s.db.QueryRowContext(ctx, "SELECT to_regclass($1)::text", s.fullTableName(table)).Scan(&name); err != nil {
+		return false, fmt.Errorf("postgres store: look up table %s: %w", table, err)
+	}
+	return name.Valid, nil
+}
@@ -0,0 +1,31 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// otlpExportTimeout bounds a single export request to the collector.
+const otlpExportTimeout = 10 * time.Second
+
+// NewOTLPExporter returns an exporter sending spans to an OpenTelemetry collector over
+// OTLP/HTTP. An endpoint without a path gets the standard /v1/traces path appended, and
+// headers are sent with every export request.
+func NewOTLPExporter(ctx context.Context, endpoint string, headers map[string]string) (sdktrace.SpanExporter, error) {
+	endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpointURL(endpoint),
+		otlptracehttp.WithTimeout(otlpExportTimeout),
+	}
+	if len(headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(headers))
+	}
+	return otlptracehttp.New(ctx, options...)
+}
@@ -0,0 +1,230 @@
+// Package tracing records request spans across the proxy pipeline with the OpenTelemetry
+// SDK and exports them over OTLP/HTTP. It keeps a process-wide tracer provider that can be
+// swapped on config reload and applies the proxy's span rules on top of the SDK: only server
+// spans start traces, and trace context reaches upstreams only when propagation is allowed.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// DefaultServiceName is reported when no service name is configured.
+const DefaultServiceName = "cli-proxy-api"
+
+const instrumentationName = "github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
+
+// propagator reads and writes the W3C traceparent and tracestate headers.
+var propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// Options configures the tracer.
+type Options struct {
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+	// SampleRatio is the share of new traces that are recorded, in (0, 1].
+	SampleRatio float64
+	// PropagateUpstream allows injecting traceparent into upstream requests.
+	PropagateUpstream bool
+}
+
+// Tracer owns the active tracer provider.
+type Tracer struct {
+	mu       sync.RWMutex
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	sampler  sdktrace.Sampler
+	options  Options
+}
+
+var defaultTracer = &Tracer{}
+
+// Default returns the process-wide tracer.
+func Default() *Tracer { return defaultTracer }
+
+// Configure replaces the tracer provider with one handing finished spans to processor. A nil
+// processor disables tracing. The previous provider is returned so callers can shut it down,
+// which flushes the spans it still holds.
+func (t *Tracer) Configure(processor sdktrace.SpanProcessor, opts Options) *sdktrace.TracerProvider {
+	if t == nil {
+		return nil
+	}
+	opts = normalizeOptions(opts)
+	var provider *sdktrace.TracerProvider
+	if processor != nil {
+		provider = sdktrace.NewTracerProvider(
+			sdktrace.WithSpanProcessor(processor),
+			sdktrace.WithSampler(configuredSampler{tracer: t}),
+			sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(opts.ServiceName))),
+		)
+	}
+	t.mu.Lock()
+	previous := t.provider
+	t.provider = provider
+	t.tracer = nil
+	if provider != nil {
+		t.tracer = provider.Tracer(instrumentationName)
+	}
+	t.setOptionsLocked(opts)
+	t.mu.Unlock()
+	return previous
+}
+
+// SetOptions changes sampling and propagation without replacing the provider, so spans
+// already queued for export are kept. The service name is fixed when the provider is built.
+func (t *Tracer) SetOptions(opts Options) {
+	if t == nil {
+		return
+	}
+	opts = normalizeOptions(opts)
+	t.mu.Lock()
+	opts.ServiceName = t.options.ServiceName
+	t.setOptionsLocked(opts)
+	t.mu.Unlock()
+}
+
+func (t *Tracer) setOptionsLocked(opts Options) {
+	t.options = opts
+	t.sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))
+}
+
+func normalizeOptions(opts Options) Options {
+	if opts.SampleRatio <= 0 || opts.SampleRatio > 1 {
+		opts.SampleRatio = 1
+	}
+	if strings.TrimSpace(opts.ServiceName) == "" {
+		opts.ServiceName = DefaultServiceName
+	}
+	return opts
+}
+
+// configuredSampler defers to the sampler built from the current options: new traces are
+// sampled by ratio and traces continued from a parent follow the parent's decision.
+type configuredSampler struct {
+	tracer *Tracer
+}
+
+func (s configuredSampler) ShouldSample(params sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	s.tracer.mu.RLock()
+	sampler := s.tracer.sampler
+	s.tracer.mu.RUnlock()
+	return sampler.ShouldSample(params)
+}
+
+func (s configuredSampler) Description() string { return "ConfiguredSampler" }
+
+// Enabled reports whether a tracer provider is configured.
+func (t *Tracer) Enabled() bool {
+	if t == nil {
+		return false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.provider != nil
+}
+
+// PropagateUpstream reports whether traceparent may be sent to upstream providers.
+func (t *Tracer) PropagateUpstream() bool {
+	if t == nil {
+		return false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.provider != nil && t.options.PropagateUpstream
+}
+
+// ServiceName returns the configured service name.
+func (t *Tracer) ServiceName() string {
+	if t == nil {
+		return DefaultServiceName
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.options.ServiceName == "" {
+		return DefaultServiceName
+	}
+	return t.options.ServiceName
+}
+
+// Start begins a span as a child of the span in ctx. Server spans may also start a new
+// trace, or continue a remote one extracted with ExtractHeaders; other kinds are only
+// started inside an existing trace so background work does not produce orphan traces.
+// Spans of unsampled traces are not recorded but still carry the trace context, so it is
+// propagated with the sampled flag cleared. The returned span is never nil.
+func (t *Tracer) Start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if t == nil {
+		return ctx, noop.Span{}
+	}
+	t.mu.RLock()
+	tracer := t.tracer
+	t.mu.RUnlock()
+	if tracer == nil {
+		return ctx, noop.Span{}
+	}
+	if kind != trace.SpanKindServer && !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, noop.Span{}
+	}
+	return tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// Start begins a span on the default tracer.
+func Start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return defaultTracer.Start(ctx, name, kind, attrs...)
+}
+
+// RecordError marks span failed with err. Context cancellation is recorded as an event
+// only, since it reflects the client going away rather than a failure.
+func RecordError(span trace.Span, err error) {
+	if span == nil || err == nil {
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		span.AddEvent("cancelled")
+		return
+	}
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// ExtractHeaders returns a copy of ctx carrying the remote trace context found in headers,
+// so the next server span continues the caller's trace.
+func ExtractHeaders(ctx context.Context, headers http.Header) context.Context {
+	if headers == nil {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.HeaderCarrier(headers))
+}
+
+// InjectHeaders writes the trace context of the span in ctx into headers when upstream
+// propagation is enabled.
+func InjectHeaders(ctx context.Context, headers http.Header) {
+	if headers == nil || !defaultTracer.PropagateUpstream() {
+		return
+	}
+	propagator.Inject(ctx, propagation.HeaderCarrier(headers))
+}
+
+// CopySpan copies the active span from src into dst when dst does not carry one. It is
+// used where work continues on a context that is not derived from the request context.
+func CopySpan(dst, src context.Context) context.Context {
+	if trace.SpanContextFromContext(dst).IsValid() {
+		return dst
+	}
+	if span := trace.SpanFromContext(src); span.SpanContext().IsValid() {
+		return trace.ContextWithSpan(dst, span)
+	}
+	return dst
+}
@@ -0,0 +1,210 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const remoteTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func useSpanRecorder(t *testing.T, opts Options) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	Default().Configure(recorder, opts)
+	t.Cleanup(func() { Default().Configure(nil, Options{}) })
+	return recorder
+}
+
+func headersWithTraceParent(value string) http.Header {
+	headers := http.Header{}
+	headers.Set("traceparent", value)
+	return headers
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key string) (attribute.Value, bool) {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracerStart_NestsSpansAndFollowsRemoteParent(t *testing.T) {
+	recorder := useSpanRecorder(t, Options{})
+
+	if _, span := Start(context.Background(), "orphan", trace.SpanKindInternal); span.SpanContext().IsValid() {
+		t.Fatal("internal span without a parent should not start a trace")
+	}
+
+	ctx := ExtractHeaders(context.Background(), headersWithTraceParent(remoteTraceParent))
+	remote := trace.SpanContextFromContext(ctx)
+	ctx, server := Start(ctx, "GET /v1/models", trace.SpanKindServer)
+	_, child := Start(ctx, "auth.select", trace.SpanKindInternal, attribute.String("auth.id", "a1"))
+	child.End()
+	server.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	if spans[1].SpanContext().TraceID() != remote.TraceID() || spans[1].Parent().SpanID() != remote.SpanID() {
+		t.Fatalf("server span did not continue the remote trace: %+v", spans[1].SpanContext())
+	}
+	if spans[0].SpanContext().TraceID() != remote.TraceID() || spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Fatal("child span is not nested under the server span")
+	}
+	if value, _ := attributeValue(spans[0], "auth.id"); value.AsString() != "a1" {
+		t.Fatalf("auth.id = %v, want a1", value)
+	}
+	if got := spans[1].Resource().Attributes(); len(got) == 0 {
+		t.Fatal("spans carry no resource attributes")
+	}
+
+	unsampled := ExtractHeaders(context.Background(), headersWithTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"))
+	if _, span := Start(unsampled, "GET /", trace.SpanKindServer); span.IsRecording() {
+		t.Fatal("unsampled remote parent should not be recorded")
+	}
+	if len(recorder.Ended()) != 2 {
+		t.Fatal("unsampled span was exported")
+	}
+}
+
+func TestTracerStart_SampleRatio(t *testing.T) {
+	recorder := useSpanRecorder(t, Options{SampleRatio: 0.25})
+
+	const total = 4000
+	for i := 0; i < total; i++ {
+		_, span := Start(context.Background(), "request", trace.SpanKindServer)
+		span.End()
+	}
+	got := len(recorder.Ended())
+	if got < total/8 || got > total*3/8 {
+		t.Fatalf("sampled %d of %d traces, want about %d", got, total, total/4)
+	}
+
+	Default().SetOptions(Options{SampleRatio: 1})
+	_, span := Start(context.Background(), "request", trace.SpanKindServer)
+	if !span.IsRecording() {
+		t.Fatal("SetOptions() did not change the sample ratio")
+	}
+}
+
+func TestTransport_PropagatesTraceParent(t *testing.T) {
+	recorder := useSpanRecorder(t, Options{SampleRatio: 1, PropagateUpstream: true})
+
+	var received []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("traceparent"))
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer upstream.Close()
+
+	send := func(ctx context.Context, propagate bool) {
+		t.Helper()
+		client := &http.Client{Transport: NewTransport(nil, propagate)}
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, upstream.URL+"/v1/chat", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	ctx, server := Start(context.Background(), "POST /v1/chat/completions", trace.SpanKindServer)
+	send(ctx, true)
+	send(ctx, false)
+	server.End()
+
+	if len(received) != 2 || received[1] != "" {
+		t.Fatalf("received traceparent headers = %q", received)
+	}
+	upstreamSpan := trace.SpanContextFromContext(ExtractHeaders(context.Background(), headersWithTraceParent(received[0])))
+	if !upstreamSpan.IsSampled() || upstreamSpan.TraceID() != server.SpanContext().TraceID() {
+		t.Fatalf("traceparent %q does not belong to the request trace", received[0])
+	}
+	spans := recorder.Ended()
+	if len(spans) != 3 || spans[0].Name() != "upstream.http" || spans[0].Status().Code != codes.Error {
+		t.Fatalf("spans = %+v", spans)
+	}
+	if spans[0].SpanContext().SpanID() != upstreamSpan.SpanID() {
+		t.Fatal("propagated span ID does not match the client span")
+	}
+
+	// An unsampled trace is not recorded but its context still reaches the upstream.
+	ctx = ExtractHeaders(context.Background(), headersWithTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"))
+	ctx, server = Start(ctx, "POST /v1/chat/completions", trace.SpanKindServer)
+	send(ctx, true)
+	server.End()
+	unsampled := trace.SpanContextFromContext(ExtractHeaders(context.Background(), headersWithTraceParent(received[2])))
+	if !unsampled.IsValid() || unsampled.IsSampled() || unsampled.TraceID() != server.SpanContext().TraceID() {
+		t.Fatalf("unsampled traceparent = %q, want the request trace with flags 00", received[2])
+	}
+	if len(recorder.Ended()) != 3 {
+		t.Fatal("spans of the unsampled trace were recorded")
+	}
+}
+
+func TestOTLPExporter_PostsToCollector(t *testing.T) {
+	var request collectortrace.ExportTraceServiceRequest
+	var auth string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("path = %q, want /v1/traces", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		if err := proto.Unmarshal(data, &request); err != nil {
+			t.Errorf("decode export request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer collector.Close()
+
+	exporter, err := NewOTLPExporter(context.Background(), collector.URL, map[string]string{"Authorization": "Bearer t"})
+	if err != nil {
+		t.Fatalf("NewOTLPExporter() error = %v", err)
+	}
+	tracer := &Tracer{}
+	provider := tracer.Configure(sdktrace.NewBatchSpanProcessor(exporter), Options{ServiceName: "proxy-test"})
+	if provider != nil {
+		t.Fatal("first Configure() returned a previous provider")
+	}
+	_, span := tracer.Start(context.Background(), "GET /healthz", trace.SpanKindServer, attribute.Int("http.response.status_code", 200))
+	span.End()
+	if err = tracer.Configure(nil, Options{}).Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	if auth != "Bearer t" {
+		t.Fatalf("Authorization = %q", auth)
+	}
+	if len(request.ResourceSpans) != 1 {
+		t.Fatalf("resource spans = %d, want 1", len(request.ResourceSpans))
+	}
+	resourceSpans := request.ResourceSpans[0]
+	serviceName := ""
+	for _, attr := range resourceSpans.GetResource().GetAttributes() {
+		if attr.GetKey() == "service.name" {
+			serviceName = attr.GetValue().GetStringValue()
+		}
+	}
+	if serviceName != "proxy-test" {
+		t.Fatalf("service.name = %q, want proxy-test", serviceName)
+	}
+	got := resourceSpans.GetScopeSpans()[0].GetSpans()
+	if len(got) != 1 || got[0].GetName() != "GET /healthz" {
+		t.Fatalf("spans = %+v", got)
+	}
+}
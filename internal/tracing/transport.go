@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Transport wraps an http.RoundTripper with a client span per upstream request.
+type Transport struct {
+	Base http.RoundTripper
+	// Propagate allows injecting traceparent into the outgoing request. The tracer-level
+	// propagate-upstream switch must also be enabled.
+	Propagate bool
+}
+
+// NewTransport wraps base. A nil base uses http.DefaultTransport.
+func NewTransport(base http.RoundTripper, propagate bool) *Transport {
+	return &Transport{Base: base, Propagate: propagate}
+}
+
+// RoundTrip executes the request inside an "upstream.http" client span.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx, span := Start(req.Context(), "upstream.http", trace.SpanKindClient,
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Host),
+		attribute.String("url.path", req.URL.Path),
+	)
+	if !span.SpanContext().IsValid() {
+		return base.RoundTrip(req)
+	}
+	defer span.End()
+
+	req = req.Clone(ctx)
+	if t.Propagate {
+		InjectHeaders(ctx, req.Header)
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		RecordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
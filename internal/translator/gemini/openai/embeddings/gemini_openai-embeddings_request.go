@@ -0,0 +1,69 @@
+// Package embeddings translates OpenAI embeddings requests into Gemini batchEmbedContents
+// requests and converts the resulting embeddings back into the OpenAI schema.
+package embeddings
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIEmbeddingsRequestToGemini converts an OpenAI /v1/embeddings request into a
+// Gemini batchEmbedContents request with one entry per input item.
+//
+// Parameters:
+//   - modelName: The Gemini model that will produce the embeddings
+//   - inputRawJSON: The raw OpenAI embeddings request
+//   - stream: Unused; embeddings are never streamed
+//
+// Returns:
+//   - []byte: The Gemini batchEmbedContents request
+func ConvertOpenAIEmbeddingsRequestToGemini(modelName string, inputRawJSON []byte, _ bool) []byte {
+	modelPath := modelName
+	if !strings.HasPrefix(modelPath, "models/") {
+		modelPath = "models/" + modelPath
+	}
+	dimensions := gjson.GetBytes(inputRawJSON, "dimensions")
+
+	out := []byte(`{"requests":[]}`)
+	for _, text := range openAIEmbeddingInputs(gjson.GetBytes(inputRawJSON, "input")) {
+		entry := []byte(`{"model":"","content":{"parts":[{"text":""}]}}`)
+		entry, _ = sjson.SetBytes(entry, "model", modelPath)
+		entry, _ = sjson.SetBytes(entry, "content.parts.0.text", text)
+		if dimensions.Type == gjson.Number && dimensions.Int() > 0 {
+			entry, _ = sjson.SetBytes(entry, "outputDimensionality", dimensions.Int())
+		}
+		out, _ = sjson.SetRawBytes(out, "requests.-1", entry)
+	}
+	return out
+}
+
+// openAIEmbeddingInputs flattens the OpenAI input field into a list of texts.
+// Token-array inputs cannot be represented in Gemini and are forwarded as empty texts
+// so the upstream error surfaces to the client.
+func openAIEmbeddingInputs(input gjson.Result) []string {
+	if !input.Exists() {
+		return nil
+	}
+	if input.Type == gjson.String {
+		return []string{input.String()}
+	}
+	if !input.IsArray() {
+		return []string{input.String()}
+	}
+	items := input.Array()
+	if len(items) > 0 && items[0].Type == gjson.Number {
+		// A single token array.
+		return []string{""}
+	}
+	texts := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.Type == gjson.String {
+			texts = append(texts, item.String())
+			continue
+		}
+		texts = append(texts, "")
+	}
+	return texts
+}
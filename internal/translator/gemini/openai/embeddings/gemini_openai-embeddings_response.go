@@ -0,0 +1,72 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiEmbeddingsResponseToOpenAINonStream converts a Gemini batchEmbedContents response
+// into an OpenAI embeddings list. When the original request asked for base64 encoding the
+// vectors are packed as little-endian float32 values like the OpenAI API does.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The model name reported back to the client
+//   - originalRequestRawJSON: The original OpenAI embeddings request
+//   - requestRawJSON: The translated Gemini request
+//   - rawJSON: The Gemini batchEmbedContents response
+//   - param: Unused
+//
+// Returns:
+//   - []byte: The OpenAI embeddings response
+func ConvertGeminiEmbeddingsResponseToOpenAINonStream(_ context.Context, modelName string, originalRequestRawJSON, _ []byte, rawJSON []byte, _ *any) []byte {
+	useBase64 := strings.EqualFold(gjson.GetBytes(originalRequestRawJSON, "encoding_format").String(), "base64")
+
+	out := []byte(`{"object":"list","data":[],"model":"","usage":{"prompt_tokens":0,"total_tokens":0}}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+
+	embeddings := gjson.GetBytes(rawJSON, "embeddings")
+	if !embeddings.Exists() {
+		// embedContent responses carry a single embedding object.
+		if single := gjson.GetBytes(rawJSON, "embedding"); single.Exists() {
+			embeddings = gjson.Parse("[" + single.Raw + "]")
+		}
+	}
+	index := 0
+	embeddings.ForEach(func(_, value gjson.Result) bool {
+		entry := []byte(`{"object":"embedding","index":0}`)
+		entry, _ = sjson.SetBytes(entry, "index", index)
+		values := value.Get("values")
+		if useBase64 {
+			entry, _ = sjson.SetBytes(entry, "embedding", encodeFloat32Base64(values))
+		} else if values.IsArray() {
+			entry, _ = sjson.SetRawBytes(entry, "embedding", []byte(values.Raw))
+		} else {
+			entry, _ = sjson.SetRawBytes(entry, "embedding", []byte(`[]`))
+		}
+		out, _ = sjson.SetRawBytes(out, "data.-1", entry)
+		index++
+		return true
+	})
+
+	if promptTokens := gjson.GetBytes(rawJSON, "usageMetadata.promptTokenCount"); promptTokens.Exists() {
+		out, _ = sjson.SetBytes(out, "usage.prompt_tokens", promptTokens.Int())
+		out, _ = sjson.SetBytes(out, "usage.total_tokens", promptTokens.Int())
+	}
+	return out
+}
+
+func encodeFloat32Base64(values gjson.Result) string {
+	items := values.Array()
+	buf := make([]byte, 4*len(items))
+	for i, item := range items {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(item.Float())))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,47 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIEmbeddingsRequestToGeminiStringInput(t *testing.T) {
+	out := ConvertOpenAIEmbeddingsRequestToGemini("gemini-embedding-001", []byte(`{"model":"x","input":"hello"}`), false)
+
+	if got := gjson.GetBytes(out, "requests.#").Int(); got != 1 {
+		t.Fatalf("requests = %d, want 1", got)
+	}
+	if got := gjson.GetBytes(out, "requests.0.model").String(); got != "models/gemini-embedding-001" {
+		t.Fatalf("model = %q", got)
+	}
+	if gjson.GetBytes(out, "requests.0.outputDimensionality").Exists() {
+		t.Fatalf("unexpected outputDimensionality in %s", out)
+	}
+}
+
+func TestConvertGeminiEmbeddingsResponseToOpenAIBase64(t *testing.T) {
+	original := []byte(`{"model":"m","input":"a","encoding_format":"base64"}`)
+	raw := []byte(`{"embeddings":[{"values":[1.5,-2]}],"usageMetadata":{"promptTokenCount":4}}`)
+
+	out := ConvertGeminiEmbeddingsResponseToOpenAINonStream(context.Background(), "m", original, nil, raw, nil)
+
+	encoded := gjson.GetBytes(out, "data.0.embedding").String()
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(decoded) != 8 {
+		t.Fatalf("invalid base64 embedding %q: %v", encoded, err)
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(decoded[4:])); got != -2 {
+		t.Fatalf("second value = %v, want -2", got)
+	}
+	if got := gjson.GetBytes(out, "usage.prompt_tokens").Int(); got != 4 {
+		t.Fatalf("prompt_tokens = %d", got)
+	}
+	if got := gjson.GetBytes(out, "model").String(); got != "m" {
+		t.Fatalf("model = %q", got)
+	}
+}
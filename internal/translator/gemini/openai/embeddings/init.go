@@ -0,0 +1,18 @@
+package embeddings
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		OpenAIEmbedding,
+		GeminiEmbedding,
+		ConvertOpenAIEmbeddingsRequestToGemini,
+		interfaces.TranslateResponse{
+			NonStream: ConvertGeminiEmbeddingsResponseToOpenAINonStream,
+		},
+	)
+}
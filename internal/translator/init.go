@@ -21,11 +21,13 @@ import (
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/gemini"
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/gemini-cli"
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/openai/chat-completions"
+	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/openai/embeddings"
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/openai/responses"
 
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/claude"
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/gemini"
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/gemini-cli"
+	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/gemini/embeddings"
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/openai/chat-completions"
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/openai/responses"
 
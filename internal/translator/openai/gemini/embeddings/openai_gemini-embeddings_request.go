@@ -0,0 +1,45 @@
+// Package embeddings translates Gemini batchEmbedContents requests into OpenAI
+// /v1/embeddings requests and converts the resulting vectors back into the Gemini schema.
+package embeddings
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiEmbeddingsRequestToOpenAI converts a Gemini batchEmbedContents request into an
+// OpenAI embeddings request. The text parts of each entry are joined into a single input item.
+//
+// Parameters:
+//   - modelName: The OpenAI-compatible model that will produce the embeddings
+//   - inputRawJSON: The raw Gemini batchEmbedContents request
+//   - stream: Unused; embeddings are never streamed
+//
+// Returns:
+//   - []byte: The OpenAI embeddings request
+func ConvertGeminiEmbeddingsRequestToOpenAI(modelName string, inputRawJSON []byte, _ bool) []byte {
+	out := []byte(`{"model":"","input":[]}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+
+	var dimensions int64
+	gjson.GetBytes(inputRawJSON, "requests").ForEach(func(_, request gjson.Result) bool {
+		var texts []string
+		request.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			if text := part.Get("text"); text.Exists() {
+				texts = append(texts, text.String())
+			}
+			return true
+		})
+		out, _ = sjson.SetBytes(out, "input.-1", strings.Join(texts, "\n"))
+		if dims := request.Get("outputDimensionality"); dims.Exists() && dimensions == 0 {
+			dimensions = dims.Int()
+		}
+		return true
+	})
+	if dimensions > 0 {
+		out, _ = sjson.SetBytes(out, "dimensions", dimensions)
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package embeddings
+
+import (
+	"context"
+	"sort"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIEmbeddingsResponseToGeminiNonStream converts an OpenAI embeddings list into a
+// Gemini batchEmbedContents response, ordering vectors by their reported index.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: Unused; Gemini embedding responses do not echo the model
+//   - originalRequestRawJSON: The original Gemini request
+//   - requestRawJSON: The translated OpenAI request
+//   - rawJSON: The OpenAI embeddings response
+//   - param: Unused
+//
+// Returns:
+//   - []byte: The Gemini batchEmbedContents response
+func ConvertOpenAIEmbeddingsResponseToGeminiNonStream(_ context.Context, _ string, _, _ []byte, rawJSON []byte, _ *any) []byte {
+	items := gjson.GetBytes(rawJSON, "data").Array()
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Get("index").Int() < items[j].Get("index").Int() })
+
+	out := []byte(`{"embeddings":[]}`)
+	for _, item := range items {
+		entry := []byte(`{"values":[]}`)
+		if values := item.Get("embedding"); values.IsArray() {
+			entry, _ = sjson.SetRawBytes(entry, "values", []byte(values.Raw))
+		}
+		out, _ = sjson.SetRawBytes(out, "embeddings.-1", entry)
+	}
+	if promptTokens := gjson.GetBytes(rawJSON, "usage.prompt_tokens"); promptTokens.Exists() {
+		out, _ = sjson.SetBytes(out, "usageMetadata.promptTokenCount", promptTokens.Int())
+	}
+	return out
+}
@@ -56,6 +56,30 @@ type authConnectMsg struct {
 	err error
 }
 
+// tabScope is the management scope a tab needs. readOnly marks tabs the read-only scope covers.
+type tabScope struct {
+	scope    string
+	readOnly bool
+}
+
+var tabScopes = map[int]tabScope{
+	tabConfig:    {scope: scopeConfig},
+	tabAuthFiles: {scope: scopeAuthFiles, readOnly: true},
+	tabAPIKeys:   {scope: scopeKeys},
+	tabOAuth:     {scope: scopeAuthFiles},
+	tabLogs:      {scope: scopeLogs},
+}
+
+// tabAllowed reports whether the current credential may open tab. Tabs without a scope are
+// always allowed, and so is everything before a session is loaded.
+func (a App) tabAllowed(tab int) bool {
+	required, ok := tabScopes[tab]
+	if !ok {
+		return true
+	}
+	return a.client.Session().Allows(required.scope, required.readOnly)
+}
+
 // NewApp creates the root TUI application model.
 func NewApp(port int, secretKey string, hook *LogHook) App {
 	standalone := hook != nil
@@ -141,7 +165,7 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.initialized = [6]bool{}
 		a.initialized[tabDashboard] = true
 		cmds := []tea.Cmd{a.dashboard.Init()}
-		if a.logsEnabled {
+		if a.logsEnabled && a.tabAllowed(tabLogs) {
 			a.initialized[tabLogs] = true
 			cmds = append(cmds, a.logs.Init())
 		}
@@ -301,7 +325,7 @@ func (a *App) refreshTabs() {
 }
 
 func (a *App) initTabIfNeeded(_ int) tea.Cmd {
-	if a.initialized[a.activeTab] {
+	if a.initialized[a.activeTab] || !a.tabAllowed(a.activeTab) {
 		return nil
 	}
 	a.initialized[a.activeTab] = true
@@ -341,6 +365,12 @@ func (a App) View() string {
 	sb.WriteString("\n")
 
 	// Content
+	if !a.tabAllowed(a.activeTab) {
+		sb.WriteString(a.renderScopeDenied(tabScopes[a.activeTab].scope))
+		sb.WriteString("\n")
+		sb.WriteString(a.renderStatusBar())
+		return sb.String()
+	}
 	switch a.activeTab {
 	case tabDashboard:
 		sb.WriteString(a.dashboard.View())
@@ -386,6 +416,15 @@ func (a App) renderAuthView() string {
 	return sb.String()
 }
 
+func (a App) renderScopeDenied(scope string) string {
+	content := "\n" + warningStyle.Render(fmt.Sprintf(T("scope_tab_denied"), scope))
+	height := a.height - 4
+	if lines := strings.Count(content, "\n") + 1; height > lines {
+		content += strings.Repeat("\n", height-lines)
+	}
+	return content
+}
+
 func (a App) renderTabBar() string {
 	var tabs []string
 	for i, name := range a.tabs {
@@ -401,6 +440,9 @@ func (a App) renderTabBar() string {
 
 func (a App) renderStatusBar() string {
 	left := strings.TrimRight(T("status_left"), " ")
+	if session := a.client.Session(); session.Restricted() {
+		left += " • " + fmt.Sprintf(T("status_scopes"), strings.Join(session.Scopes, ", "))
+	}
 	right := strings.TrimRight(T("status_right"), " ")
 
 	width := a.width
@@ -478,6 +520,15 @@ func (a *App) setAuthInputPrompt() {
 func (a App) connectWithPassword(password string) tea.Cmd {
 	return func() tea.Msg {
 		a.client.SetSecretKey(password)
+		session, errSession := a.client.LoadSession()
+		if errSession != nil {
+			return authConnectMsg{err: errSession}
+		}
+		// Tokens without the config scope cannot read the config; the logs tab then stays
+		// visible and reports the missing scope itself.
+		if !session.Allows(scopeConfig, false) {
+			return authConnectMsg{}
+		}
 		cfg, errGetConfig := a.client.GetConfig()
 		return authConnectMsg{cfg: cfg, err: errGetConfig}
 	}
@@ -2,8 +2,10 @@ package tui
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -219,6 +221,9 @@ func (m authTabModel) renderContent() string {
 
 		row := fmt.Sprintf("%s%s %-24s %-12s %-28s %s",
 			cursor, statusIcon, displayName, channel, displayEmail, statusText)
+		if used, ok := quotaUsedPercent(f); ok {
+			row += "  " + fmt.Sprintf(T("quota_used"), used)
+		}
 		sb.WriteString(rowStyle.Render(row))
 		sb.WriteString("\n")
 
@@ -307,10 +312,62 @@ func (m authTabModel) renderDetail(f map[string]any) string {
 		sb.WriteString("\n")
 	}
 
+	if snapshot, ok := f["quota_snapshot"].(map[string]any); ok {
+		if plan := getString(snapshot, "plan_tier"); plan != "" {
+			sb.WriteString(fmt.Sprintf("    │ %s %s\n", labelStyle.Render(fmt.Sprintf("%-12s:", "Plan")), valueStyle.Render(plan)))
+		}
+		for i, window := range quotaWindowLines(snapshot) {
+			label := ""
+			if i == 0 {
+				label = "Quota"
+			}
+			sb.WriteString(fmt.Sprintf("    │ %s %s\n", labelStyle.Render(fmt.Sprintf("%-12s:", label)), valueStyle.Render(window)))
+		}
+	}
+
 	sb.WriteString("    └─────────────────────────────────────────────\n")
 	return sb.String()
 }
 
+// quotaUsedPercent returns the highest window usage in the quota snapshot of an auth file.
+func quotaUsedPercent(f map[string]any) (float64, bool) {
+	snapshot, ok := f["quota_snapshot"].(map[string]any)
+	if !ok {
+		return 0, false
+	}
+	windows, _ := snapshot["windows"].([]any)
+	used, found := 0.0, false
+	for _, raw := range windows {
+		if window, okWindow := raw.(map[string]any); okWindow {
+			used, found = math.Max(used, getFloat(window, "used_percent")), true
+		}
+	}
+	return used, found
+}
+
+// quotaWindowLines describes each window of a quota snapshot, e.g. "primary 82%, resets 10-17 14:05".
+func quotaWindowLines(snapshot map[string]any) []string {
+	windows, _ := snapshot["windows"].([]any)
+	lines := make([]string, 0, len(windows))
+	for _, raw := range windows {
+		window, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		parts := []string{fmt.Sprintf("%s %.0f%%", getString(window, "name"), getFloat(window, "used_percent"))}
+		if limit := getFloat(window, "limit"); limit > 0 {
+			if _, okRemaining := window["remaining"]; okRemaining {
+				parts = append(parts, fmt.Sprintf(T("quota_left"), strconv.FormatFloat(getFloat(window, "remaining"), 'f', 0, 64), strconv.FormatFloat(limit, 'f', 0, 64)))
+			}
+		}
+		if resetAt, err := time.Parse(time.RFC3339, getString(window, "reset_at")); err == nil {
+			parts = append(parts, fmt.Sprintf(T("quota_resets"), resetAt.Local().Format("01-02 15:04")))
+		}
+		lines = append(lines, strings.Join(parts, ", "))
+	}
+	return lines
+}
+
 // getAnyString converts any value to its string representation.
 func getAnyString(m map[string]any, key string) string {
 	v, ok := m[key]
@@ -6,16 +6,59 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// Management scopes the TUI checks before calling guarded routes.
+const (
+	scopeAdmin     = "admin"
+	scopeReadOnly  = "read-only"
+	scopeAuthFiles = "auth-files"
+	scopeKeys      = "keys"
+	scopeConfig    = "config"
+	scopeLogs      = "logs"
+)
+
 // Client wraps HTTP calls to the management API.
 type Client struct {
 	baseURL   string
 	secretKey string
 	http      *http.Client
+	session   *Session
+}
+
+// Session describes the management credential in use, as reported by /session.
+type Session struct {
+	Credential string   `json:"credential"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expires-at"`
+}
+
+// Restricted reports whether the credential has less than full access.
+func (s *Session) Restricted() bool {
+	return s != nil && !slices.Contains(s.Scopes, scopeAdmin)
+}
+
+// Allows reports whether the credential may use routes guarded by scope. readOnly marks reads
+// that the read-only scope also covers. A nil session has full access.
+func (s *Session) Allows(scope string, readOnly bool) bool {
+	if !s.Restricted() {
+		return true
+	}
+	return slices.Contains(s.Scopes, scope) || (readOnly && slices.Contains(s.Scopes, scopeReadOnly))
+}
+
+// ScopeError is returned when the server rejects a request for a missing scope.
+type ScopeError struct {
+	Scope string
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf(T("scope_denied"), e.Scope)
 }
 
 // NewClient creates a new management API client.
@@ -32,6 +75,47 @@ func NewClient(port int, secretKey string) *Client {
 // SetSecretKey updates management API bearer token used by this client.
 func (c *Client) SetSecretKey(secretKey string) {
 	c.secretKey = strings.TrimSpace(secretKey)
+	c.session = nil
+}
+
+// Session returns the session loaded by LoadSession, or nil for full access.
+func (c *Client) Session() *Session {
+	return c.session
+}
+
+// LoadSession fetches the scopes of the current credential. Servers without the session
+// endpoint only know all-powerful keys, so a 404 is treated as full access.
+func (c *Client) LoadSession() (*Session, error) {
+	data, code, err := c.doRequest("GET", "/v0/management/session", nil)
+	if err != nil {
+		return nil, err
+	}
+	if code == http.StatusNotFound {
+		c.session = nil
+		return nil, nil
+	}
+	if code >= 400 {
+		return nil, responseError(code, data)
+	}
+	var session Session
+	if err = json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	c.session = &session
+	return c.session, nil
+}
+
+// responseError converts an error response, surfacing missing scopes as *ScopeError.
+func responseError(code int, data []byte) error {
+	if code == http.StatusForbidden {
+		var body struct {
+			Scope string `json:"scope"`
+		}
+		if json.Unmarshal(data, &body) == nil && body.Scope != "" {
+			return &ScopeError{Scope: body.Scope}
+		}
+	}
+	return fmt.Errorf("HTTP %d: %s", code, strings.TrimSpace(string(data)))
 }
 
 func (c *Client) doRequest(method, path string, body io.Reader) ([]byte, int, error) {
@@ -64,7 +148,7 @@ func (c *Client) get(path string) ([]byte, error) {
 		return nil, err
 	}
 	if code >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", code, strings.TrimSpace(string(data)))
+		return nil, responseError(code, data)
 	}
 	return data, nil
 }
@@ -75,7 +159,7 @@ func (c *Client) put(path string, body io.Reader) ([]byte, error) {
 		return nil, err
 	}
 	if code >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", code, strings.TrimSpace(string(data)))
+		return nil, responseError(code, data)
 	}
 	return data, nil
 }
@@ -86,7 +170,7 @@ func (c *Client) patch(path string, body io.Reader) ([]byte, error) {
 		return nil, err
 	}
 	if code >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", code, strings.TrimSpace(string(data)))
+		return nil, responseError(code, data)
 	}
 	return data, nil
 }
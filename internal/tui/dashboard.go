@@ -44,9 +44,23 @@ func (m dashboardModel) Init() tea.Cmd {
 }
 
 func (m dashboardModel) fetchData() tea.Msg {
-	cfg, cfgErr := m.client.GetConfig()
-	authFiles, authErr := m.client.GetAuthFiles()
-	apiKeys, keysErr := m.client.GetAPIKeys()
+	// Skip sections the management credential has no scope for instead of failing the page.
+	session := m.client.Session()
+	var (
+		cfg                      map[string]any
+		authFiles                []map[string]any
+		apiKeys                  []string
+		cfgErr, authErr, keysErr error
+	)
+	if session.Allows(scopeConfig, false) {
+		cfg, cfgErr = m.client.GetConfig()
+	}
+	if session.Allows(scopeAuthFiles, true) {
+		authFiles, authErr = m.client.GetAuthFiles()
+	}
+	if session.Allows(scopeKeys, false) {
+		apiKeys, keysErr = m.client.GetAPIKeys()
+	}
 
 	var err error
 	for _, e := range []error{cfgErr, authErr, keysErr} {
@@ -93,6 +93,9 @@ var zhStrings = map[string]string{
 	"auth_gate_connecting":        "正在连接...",
 	"auth_gate_connect_fail":      "连接失败：%s",
 	"auth_gate_password_required": "请输入密码",
+	"status_scopes":               "权限: %s",
+	"scope_denied":                "权限不足：当前管理令牌缺少 %q 权限",
+	"scope_tab_denied":            "  🔒 此页面需要 %q 权限，当前管理令牌没有该权限。",
 
 	// ── Dashboard ──
 	"dashboard_title":  "📊 仪表盘",
@@ -146,6 +149,9 @@ var zhStrings = map[string]string{
 	"updated_field":   "已更新 %s 的 %s",
 	"status_active":   "活跃",
 	"status_disabled": "已停用",
+	"quota_used":      "配额 %.0f%%",
+	"quota_left":      "剩余 %s/%s",
+	"quota_resets":    "%s 重置",
 
 	// ── API Keys ──
 	"keys_title":         "🔐 API 密钥",
@@ -245,6 +251,9 @@ var enStrings = map[string]string{
 	"auth_gate_connecting":        "Connecting...",
 	"auth_gate_connect_fail":      "Connection failed: %s",
 	"auth_gate_password_required": "password is required",
+	"status_scopes":               "scopes: %s",
+	"scope_denied":                "permission denied: this management token lacks the %q scope",
+	"scope_tab_denied":            "  🔒 This tab needs the %q scope, which this management token does not have.",
 
 	// ── Dashboard ──
 	"dashboard_title":  "📊 Dashboard",
@@ -298,6 +307,9 @@ var enStrings = map[string]string{
 	"updated_field":   "Updated %s on %s",
 	"status_active":   "active",
 	"status_disabled": "disabled",
+	"quota_used":      "quota %.0f%%",
+	"quota_left":      "%s/%s left",
+	"quota_resets":    "resets %s",
 
 	// ── API Keys ──
 	"keys_title":         "🔐 API Keys",
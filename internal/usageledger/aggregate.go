@@ -0,0 +1,234 @@
+package usageledger
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interval buckets aggregated rows by time.
+type Interval string
+
+const (
+	IntervalNone Interval = ""
+	IntervalHour Interval = "hour"
+	IntervalDay  Interval = "day"
+)
+
+// Dimension names accepted for grouping.
+const (
+	DimensionProvider  = "provider"
+	DimensionModel     = "model"
+	DimensionAlias     = "alias"
+	DimensionAuth      = "auth"
+	DimensionClientKey = "client_key"
+)
+
+var knownDimensions = map[string]struct{}{
+	DimensionProvider:  {},
+	DimensionModel:     {},
+	DimensionAlias:     {},
+	DimensionAuth:      {},
+	DimensionClientKey: {},
+}
+
+// ParseInterval validates an interval query value.
+func ParseInterval(value string) (Interval, error) {
+	switch Interval(strings.ToLower(strings.TrimSpace(value))) {
+	case IntervalNone:
+		return IntervalNone, nil
+	case IntervalHour:
+		return IntervalHour, nil
+	case IntervalDay:
+		return IntervalDay, nil
+	default:
+		return IntervalNone, fmt.Errorf("interval must be one of: hour, day")
+	}
+}
+
+// ParseDimensions validates a comma separated list of grouping dimensions.
+func ParseDimensions(value string) ([]string, error) {
+	var out []string
+	seen := make(map[string]struct{})
+	for _, part := range strings.Split(value, ",") {
+		dim := strings.ToLower(strings.TrimSpace(part))
+		if dim == "" {
+			continue
+		}
+		if dim == "client-key" {
+			dim = DimensionClientKey
+		}
+		if _, ok := knownDimensions[dim]; !ok {
+			return nil, fmt.Errorf("unknown group_by dimension %q", dim)
+		}
+		if _, dup := seen[dim]; dup {
+			continue
+		}
+		seen[dim] = struct{}{}
+		out = append(out, dim)
+	}
+	return out, nil
+}
+
+// Row is one aggregated bucket of ledger entries.
+type Row struct {
+	Bucket          string  `json:"bucket,omitempty"`
+	Provider        string  `json:"provider,omitempty"`
+	Model           string  `json:"model,omitempty"`
+	Alias           string  `json:"alias,omitempty"`
+	AuthIndex       string  `json:"auth_index,omitempty"`
+	ClientKey       string  `json:"client_key,omitempty"`
+	ClientKeyID     string  `json:"client_key_id,omitempty"`
+	Requests        int64   `json:"requests"`
+	Failures        int64   `json:"failures"`
+	InputTokens     int64   `json:"input_tokens"`
+	OutputTokens    int64   `json:"output_tokens"`
+	ReasoningTokens int64   `json:"reasoning_tokens"`
+	CachedTokens    int64   `json:"cached_tokens"`
+	TotalTokens     int64   `json:"total_tokens"`
+	AvgLatencyMs    float64 `json:"avg_latency_ms"`
+
+	latencySum int64
+}
+
+// Aggregate groups entries by interval bucket and dimensions.
+// Rows are sorted by bucket and then by their dimension values.
+func Aggregate(entries []Entry, interval Interval, dimensions []string) []Row {
+	index := make(map[string]*Row)
+	for _, entry := range entries {
+		row := Row{Bucket: bucketLabel(entry.Timestamp, interval)}
+		for _, dim := range dimensions {
+			switch dim {
+			case DimensionProvider:
+				row.Provider = entry.Provider
+			case DimensionModel:
+				row.Model = entry.Model
+			case DimensionAlias:
+				row.Alias = entry.Alias
+			case DimensionAuth:
+				row.AuthIndex = entry.AuthIndex
+			case DimensionClientKey:
+				row.ClientKey = entry.ClientKey
+				row.ClientKeyID = entry.ClientKeyID
+			}
+		}
+		key := strings.Join([]string{row.Bucket, row.Provider, row.Model, row.Alias, row.AuthIndex, row.ClientKeyID, row.ClientKey}, "\xff")
+		existing, ok := index[key]
+		if !ok {
+			existing = &row
+			index[key] = existing
+		}
+		existing.Requests++
+		if entry.Failed {
+			existing.Failures++
+		}
+		existing.InputTokens += entry.InputTokens
+		existing.OutputTokens += entry.OutputTokens
+		existing.ReasoningTokens += entry.ReasoningTokens
+		existing.CachedTokens += entry.CachedTokens
+		existing.TotalTokens += entry.TotalTokens
+		existing.latencySum += entry.LatencyMs
+	}
+
+	rows := make([]Row, 0, len(index))
+	for _, row := range index {
+		if row.Requests > 0 {
+			row.AvgLatencyMs = float64(row.latencySum) / float64(row.Requests)
+		}
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		for _, pair := range [][2]string{
+			{a.Bucket, b.Bucket},
+			{a.Provider, b.Provider},
+			{a.Model, b.Model},
+			{a.Alias, b.Alias},
+			{a.AuthIndex, b.AuthIndex},
+			{a.ClientKey, b.ClientKey},
+			{a.ClientKeyID, b.ClientKeyID},
+		} {
+			if pair[0] != pair[1] {
+				return pair[0] < pair[1]
+			}
+		}
+		return false
+	})
+	return rows
+}
+
+func bucketLabel(ts time.Time, interval Interval) string {
+	ts = ts.UTC()
+	switch interval {
+	case IntervalHour:
+		return ts.Truncate(time.Hour).Format("2006-01-02T15:00Z")
+	case IntervalDay:
+		return ts.Format(fileDateLayout)
+	default:
+		return ""
+	}
+}
+
+// WriteCSV renders rows as CSV. Only the bucket and requested dimension columns are emitted
+// in addition to the counters.
+func WriteCSV(w io.Writer, rows []Row, interval Interval, dimensions []string) error {
+	writer := csv.NewWriter(w)
+	var header []string
+	if interval != IntervalNone {
+		header = append(header, "bucket")
+	}
+	for _, dim := range dimensions {
+		if dim == DimensionAuth {
+			header = append(header, "auth_index")
+			continue
+		}
+		if dim == DimensionClientKey {
+			header = append(header, "client_key", "client_key_id")
+			continue
+		}
+		header = append(header, dim)
+	}
+	header = append(header, "requests", "failures", "input_tokens", "output_tokens", "reasoning_tokens", "cached_tokens", "total_tokens", "avg_latency_ms")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		var record []string
+		if interval != IntervalNone {
+			record = append(record, row.Bucket)
+		}
+		for _, dim := range dimensions {
+			switch dim {
+			case DimensionProvider:
+				record = append(record, row.Provider)
+			case DimensionModel:
+				record = append(record, row.Model)
+			case DimensionAlias:
+				record = append(record, row.Alias)
+			case DimensionAuth:
+				record = append(record, row.AuthIndex)
+			case DimensionClientKey:
+				record = append(record, row.ClientKey, row.ClientKeyID)
+			}
+		}
+		record = append(record,
+			strconv.FormatInt(row.Requests, 10),
+			strconv.FormatInt(row.Failures, 10),
+			strconv.FormatInt(row.InputTokens, 10),
+			strconv.FormatInt(row.OutputTokens, 10),
+			strconv.FormatInt(row.ReasoningTokens, 10),
+			strconv.FormatInt(row.CachedTokens, 10),
+			strconv.FormatInt(row.TotalTokens, 10),
+			strconv.FormatFloat(row.AvgLatencyMs, 'f', 1, 64),
+		)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
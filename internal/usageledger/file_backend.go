@@ -0,0 +1,241 @@
+package usageledger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+const (
+	fileDateLayout = "2006-01-02"
+	filePrefix     = "usage-"
+	fileSuffix     = ".jsonl"
+	maxLineBytes   = 1 << 20
+)
+
+// FileBackend stores ledger entries as JSON lines in one file per UTC day.
+type FileBackend struct {
+	dir string
+
+	mu      sync.Mutex
+	file    *os.File
+	fileDay string
+}
+
+// NewFileBackend creates dir when needed and returns a backend writing into it.
+func NewFileBackend(dir string) (*FileBackend, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("usage ledger: directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("usage ledger: create directory: %w", err)
+	}
+	return &FileBackend{dir: dir}, nil
+}
+
+// Dir returns the directory holding the ledger files.
+func (b *FileBackend) Dir() string { return b.dir }
+
+// Close releases the currently open day file.
+func (b *FileBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closeLocked()
+}
+
+func (b *FileBackend) closeLocked() error {
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	b.file = nil
+	b.fileDay = ""
+	return err
+}
+
+// AppendUsage writes entries to the day files matching their timestamps.
+func (b *FileBackend) AppendUsage(_ context.Context, entries []Entry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, entry := range entries {
+		day := entry.Timestamp.UTC().Format(fileDateLayout)
+		if b.file == nil || b.fileDay != day {
+			if err := b.closeLocked(); err != nil {
+				return fmt.Errorf("usage ledger: close previous file: %w", err)
+			}
+			f, err := os.OpenFile(b.pathForDay(day), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+			if err != nil {
+				return fmt.Errorf("usage ledger: open file: %w", err)
+			}
+			b.file = f
+			b.fileDay = day
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("usage ledger: encode entry: %w", err)
+		}
+		line = append(line, '\n')
+		if _, err = b.file.Write(line); err != nil {
+			return fmt.Errorf("usage ledger: write entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// QueryUsage scans the day files overlapping the filter range.
+func (b *FileBackend) QueryUsage(ctx context.Context, filter Filter) ([]Entry, error) {
+	days, err := b.listDays()
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	for _, day := range days {
+		if !dayOverlaps(day, filter) {
+			continue
+		}
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err = b.scanDay(day.Format(fileDateLayout), filter, &out); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out, nil
+}
+
+// PruneUsage deletes day files that end before the cutoff.
+func (b *FileBackend) PruneUsage(_ context.Context, before time.Time) error {
+	days, err := b.listDays()
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var errs []error
+	for _, day := range days {
+		if day.AddDate(0, 0, 1).After(before) {
+			continue
+		}
+		name := day.Format(fileDateLayout)
+		if b.fileDay == name {
+			if errClose := b.closeLocked(); errClose != nil {
+				errs = append(errs, errClose)
+			}
+		}
+		if errRemove := os.Remove(b.pathForDay(name)); errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+			errs = append(errs, errRemove)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *FileBackend) pathForDay(day string) string {
+	return filepath.Join(b.dir, filePrefix+day+fileSuffix)
+}
+
+func (b *FileBackend) listDays() ([]time.Time, error) {
+	items, err := os.ReadDir(b.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("usage ledger: read directory: %w", err)
+	}
+	days := make([]time.Time, 0, len(items))
+	for _, item := range items {
+		name := item.Name()
+		if item.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		day, errParse := time.Parse(fileDateLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if errParse != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days, nil
+}
+
+func (b *FileBackend) scanDay(day string, filter Filter, out *[]Entry) error {
+	b.mu.Lock()
+	if b.file != nil && b.fileDay == day {
+		_ = b.file.Sync()
+	}
+	b.mu.Unlock()
+
+	f, err := os.Open(b.pathForDay(day))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("usage ledger: open file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if errDecode := json.Unmarshal(line, &entry); errDecode != nil {
+			// A partially written trailing line is skipped rather than failing the whole query.
+			continue
+		}
+		if filter.Match(entry) {
+			*out = append(*out, entry)
+		}
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		return fmt.Errorf("usage ledger: read file: %w", errScan)
+	}
+	return nil
+}
+
+func dayOverlaps(day time.Time, filter Filter) bool {
+	end := day.AddDate(0, 0, 1)
+	if !filter.From.IsZero() && !end.After(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !day.Before(filter.To) {
+		return false
+	}
+	return true
+}
+
+// ResolveDirectory returns the file-backed ledger directory for cfg.
+func ResolveDirectory(cfg *config.Config) string {
+	if cfg != nil {
+		if dir := strings.TrimSpace(cfg.UsageLedger.Dir); dir != "" {
+			if resolved, err := util.ResolveAuthDir(dir); err == nil && resolved != "" {
+				return resolved
+			}
+			return dir
+		}
+	}
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "usage")
+	}
+	if cfg != nil {
+		if authDir, err := util.ResolveAuthDir(cfg.AuthDir); err == nil && authDir != "" {
+			return filepath.Join(authDir, "usage")
+		}
+	}
+	return "usage"
+}
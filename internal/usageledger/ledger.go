@@ -0,0 +1,340 @@
+// Package usageledger persists usage records emitted by the proxy runtime so that
+// usage history survives restarts and can be aggregated by the management API.
+package usageledger
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	internallogging "github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	writeTimeout  = 5 * time.Second
+	pruneInterval = time.Hour
+	// flushInterval bounds how long a buffered entry waits before it is written.
+	flushInterval = time.Second
+	// flushBatchSize triggers an early flush once this many entries are buffered.
+	flushBatchSize = 256
+	// maxPendingEntries caps the buffer while the backend is failing; the oldest entries
+	// are dropped first.
+	maxPendingEntries = 16384
+)
+
+// Entry is a single persisted usage record.
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Alias     string    `json:"alias,omitempty"`
+	AuthID    string    `json:"auth_id,omitempty"`
+	AuthIndex string    `json:"auth_index,omitempty"`
+	AuthType  string    `json:"auth_type,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	// ClientKey is the masked client key and ClientKeyID a short hash of it, so usage can be
+	// grouped and filtered by key without storing the key itself.
+	ClientKey       string `json:"client_key,omitempty"`
+	ClientKeyID     string `json:"client_key_id,omitempty"`
+	Endpoint        string `json:"endpoint,omitempty"`
+	InputTokens     int64  `json:"input_tokens"`
+	OutputTokens    int64  `json:"output_tokens"`
+	ReasoningTokens int64  `json:"reasoning_tokens"`
+	CachedTokens    int64  `json:"cached_tokens"`
+	TotalTokens     int64  `json:"total_tokens"`
+	LatencyMs       int64  `json:"latency_ms"`
+	Failed          bool   `json:"failed"`
+	StatusCode      int    `json:"status_code,omitempty"`
+	CacheHit        bool   `json:"cache_hit,omitempty"`
+	FallbackHop     int    `json:"fallback_hop,omitempty"`
+}
+
+// Filter narrows a ledger query. Zero values match everything.
+type Filter struct {
+	// From is the inclusive lower bound of the query range.
+	From time.Time
+	// To is the exclusive upper bound of the query range.
+	To        time.Time
+	Provider  string
+	Model     string
+	AuthIndex string
+	// ClientKey matches the raw key, its masked form or its key ID.
+	ClientKey string
+}
+
+// Match reports whether entry satisfies the filter.
+func (f Filter) Match(entry Entry) bool {
+	if !f.From.IsZero() && entry.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !entry.Timestamp.Before(f.To) {
+		return false
+	}
+	if f.Provider != "" && !strings.EqualFold(f.Provider, entry.Provider) {
+		return false
+	}
+	if f.Model != "" && !strings.EqualFold(f.Model, entry.Model) && !strings.EqualFold(f.Model, entry.Alias) {
+		return false
+	}
+	if f.AuthIndex != "" && f.AuthIndex != entry.AuthIndex {
+		return false
+	}
+	if f.ClientKey != "" && f.ClientKey != entry.ClientKey && f.ClientKey != entry.ClientKeyID && internallogging.ClientKeyID(f.ClientKey) != entry.ClientKeyID {
+		return false
+	}
+	return true
+}
+
+// Backend stores and retrieves ledger entries.
+type Backend interface {
+	AppendUsage(ctx context.Context, entries []Entry) error
+	QueryUsage(ctx context.Context, filter Filter) ([]Entry, error)
+	PruneUsage(ctx context.Context, before time.Time) error
+}
+
+// Ledger buffers usage records and writes them to the configured backend in batches from
+// its own goroutine, so the usage dispatcher never waits on storage.
+type Ledger struct {
+	mu            sync.RWMutex
+	backend       Backend
+	retentionDays int
+	lastPrune     time.Time
+	now           func() time.Time
+
+	// flushMu serializes flushes so a batch is never split across backends.
+	flushMu   sync.Mutex
+	pendingMu sync.Mutex
+	pending   []Entry
+	wake      chan struct{}
+	startOnce sync.Once
+}
+
+var defaultLedger = &Ledger{now: time.Now}
+
+func init() {
+	coreusage.RegisterPlugin(defaultLedger)
+}
+
+// Default returns the process-wide ledger.
+func Default() *Ledger { return defaultLedger }
+
+// Configure swaps the ledger backend and retention. A nil backend disables the ledger.
+// Buffered entries are written to the previous backend first, and the previous backend is
+// returned so callers can release it.
+func (l *Ledger) Configure(backend Backend, retentionDays int) Backend {
+	if l == nil {
+		return nil
+	}
+	l.flushMu.Lock()
+	defer l.flushMu.Unlock()
+	l.flushLocked()
+	l.mu.Lock()
+	previous := l.backend
+	l.backend = backend
+	l.retentionDays = retentionDays
+	l.lastPrune = time.Time{}
+	l.mu.Unlock()
+	return previous
+}
+
+// Backend returns the active backend or nil when the ledger is disabled.
+func (l *Ledger) Backend() Backend {
+	if l == nil {
+		return nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.backend
+}
+
+// Enabled reports whether a backend is configured.
+func (l *Ledger) Enabled() bool { return l.Backend() != nil }
+
+// Query returns ledger entries matching filter. Buffered entries are flushed first so the
+// result includes every record handled so far. Entries written before keys were masked are
+// masked on the way out.
+func (l *Ledger) Query(ctx context.Context, filter Filter) ([]Entry, error) {
+	backend := l.Backend()
+	if backend == nil {
+		return nil, nil
+	}
+	l.Flush()
+	entries, err := backend.QueryUsage(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].ClientKeyID == "" && entries[i].ClientKey != "" {
+			entries[i].ClientKeyID = internallogging.ClientKeyID(entries[i].ClientKey)
+			entries[i].ClientKey = util.HideAPIKey(entries[i].ClientKey)
+		}
+	}
+	return entries, nil
+}
+
+// HandleUsage implements coreusage.Plugin. The record is buffered and written by the
+// ledger's flush loop.
+func (l *Ledger) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if l == nil || !l.Enabled() {
+		return
+	}
+	l.startOnce.Do(l.start)
+	entry := EntryFromRecord(ctx, record)
+
+	l.pendingMu.Lock()
+	if len(l.pending) >= maxPendingEntries {
+		dropped := len(l.pending) - maxPendingEntries + 1
+		l.pending = append(l.pending[:0], l.pending[dropped:]...)
+		log.Warnf("usage ledger: buffer full, dropped %d entries", dropped)
+	}
+	l.pending = append(l.pending, entry)
+	full := len(l.pending) >= flushBatchSize
+	l.pendingMu.Unlock()
+
+	if full {
+		select {
+		case l.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (l *Ledger) start() {
+	l.wake = make(chan struct{}, 1)
+	go l.flushLoop()
+}
+
+func (l *Ledger) flushLoop() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.wake:
+		}
+		l.Flush()
+	}
+}
+
+// Flush writes the buffered entries to the backend.
+func (l *Ledger) Flush() {
+	if l == nil {
+		return
+	}
+	l.flushMu.Lock()
+	defer l.flushMu.Unlock()
+	l.flushLocked()
+}
+
+func (l *Ledger) flushLocked() {
+	l.pendingMu.Lock()
+	batch := l.pending
+	l.pending = nil
+	l.pendingMu.Unlock()
+
+	l.mu.RLock()
+	backend := l.backend
+	retentionDays := l.retentionDays
+	l.mu.RUnlock()
+	if backend == nil {
+		return
+	}
+
+	writeCtx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if len(batch) > 0 {
+		if errAppend := backend.AppendUsage(writeCtx, batch); errAppend != nil {
+			log.Warnf("usage ledger: failed to append %d entries: %v", len(batch), errAppend)
+		}
+	}
+	if retentionDays > 0 {
+		l.maybePrune(writeCtx, backend, retentionDays)
+	}
+}
+
+func (l *Ledger) maybePrune(ctx context.Context, backend Backend, retentionDays int) {
+	now := l.now()
+	l.mu.Lock()
+	if !l.lastPrune.IsZero() && now.Sub(l.lastPrune) < pruneInterval {
+		l.mu.Unlock()
+		return
+	}
+	l.lastPrune = now
+	l.mu.Unlock()
+
+	cutoff := now.UTC().AddDate(0, 0, -retentionDays)
+	if errPrune := backend.PruneUsage(ctx, cutoff); errPrune != nil {
+		log.Warnf("usage ledger: failed to prune entries older than %s: %v", cutoff.Format(time.RFC3339), errPrune)
+	}
+}
+
+// EntryFromRecord converts a usage record into a ledger entry.
+func EntryFromRecord(ctx context.Context, record coreusage.Record) Entry {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	model := strings.TrimSpace(record.Model)
+	if model == "" {
+		model = "unknown"
+	}
+	alias := strings.TrimSpace(record.Alias)
+	if alias == "" {
+		alias = model
+	}
+	provider := strings.TrimSpace(record.Provider)
+	if provider == "" {
+		provider = "unknown"
+	}
+
+	detail := record.Detail
+	cached := detail.CachedTokens
+	if cached == 0 {
+		cached = detail.CacheReadTokens
+	}
+	total := detail.TotalTokens
+	if total == 0 {
+		total = detail.InputTokens + detail.OutputTokens + detail.ReasoningTokens
+	}
+
+	status := record.Fail.StatusCode
+	if status <= 0 {
+		status = internallogging.GetResponseStatus(ctx)
+	}
+	failed := record.Failed || status >= http.StatusBadRequest
+
+	var clientKey, clientKeyID string
+	if key := strings.TrimSpace(record.APIKey); key != "" {
+		clientKey = util.HideAPIKey(key)
+		clientKeyID = internallogging.ClientKeyID(key)
+	}
+
+	return Entry{
+		Timestamp:       timestamp.UTC(),
+		Provider:        provider,
+		Model:           model,
+		Alias:           alias,
+		AuthID:          strings.TrimSpace(record.AuthID),
+		AuthIndex:       strings.TrimSpace(record.AuthIndex),
+		AuthType:        strings.TrimSpace(record.AuthType),
+		Source:          record.Source,
+		ClientKey:       clientKey,
+		ClientKeyID:     clientKeyID,
+		Endpoint:        strings.TrimSpace(internallogging.GetEndpoint(ctx)),
+		InputTokens:     detail.InputTokens,
+		OutputTokens:    detail.OutputTokens,
+		ReasoningTokens: detail.ReasoningTokens,
+		CachedTokens:    cached,
+		TotalTokens:     total,
+		LatencyMs:       record.Latency.Milliseconds(),
+		Failed:          failed,
+		StatusCode:      status,
+		CacheHit:        record.CacheHit,
+		FallbackHop:     record.FallbackHop,
+	}
+}
@@ -0,0 +1,181 @@
+package usageledger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	internallogging "github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestFileBackendAppendQueryPrune(t *testing.T) {
+	backend, err := NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileBackend: %v", err)
+	}
+	t.Cleanup(func() { _ = backend.Close() })
+
+	day1 := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	day2 := time.Date(2026, 3, 2, 0, 15, 0, 0, time.UTC)
+	ctx := context.Background()
+	if err = backend.AppendUsage(ctx, []Entry{
+		{Timestamp: day1, Provider: "claude", Model: "claude-sonnet-4-5", InputTokens: 10},
+		{Timestamp: day2, Provider: "gemini", Model: "gemini-2.5-pro", InputTokens: 20},
+	}); err != nil {
+		t.Fatalf("AppendUsage: %v", err)
+	}
+
+	all, err := backend.QueryUsage(ctx, Filter{})
+	if err != nil {
+		t.Fatalf("QueryUsage: %v", err)
+	}
+	if len(all) != 2 || all[0].Provider != "claude" || all[1].Provider != "gemini" {
+		t.Fatalf("unexpected entries: %+v", all)
+	}
+
+	filtered, err := backend.QueryUsage(ctx, Filter{From: day2.Truncate(time.Hour), Provider: "GEMINI"})
+	if err != nil {
+		t.Fatalf("QueryUsage filtered: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].InputTokens != 20 {
+		t.Fatalf("unexpected filtered entries: %+v", filtered)
+	}
+
+	if err = backend.PruneUsage(ctx, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("PruneUsage: %v", err)
+	}
+	if _, errStat := os.Stat(filepath.Join(backend.Dir(), "usage-2026-03-01.jsonl")); !os.IsNotExist(errStat) {
+		t.Fatalf("expected pruned day file to be removed, stat err=%v", errStat)
+	}
+	remaining, err := backend.QueryUsage(ctx, Filter{})
+	if err != nil {
+		t.Fatalf("QueryUsage after prune: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].Provider != "gemini" {
+		t.Fatalf("unexpected entries after prune: %+v", remaining)
+	}
+}
+
+func TestEntryFromRecord(t *testing.T) {
+	entry := EntryFromRecord(context.Background(), coreusage.Record{
+		Provider:    "codex",
+		Model:       "gpt-5",
+		APIKey:      " client ",
+		RequestedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("x", 3600)),
+		Latency:     1500 * time.Millisecond,
+		Fail:        coreusage.Failure{StatusCode: http.StatusBadGateway},
+		Detail:      coreusage.Detail{InputTokens: 3, OutputTokens: 4, CacheReadTokens: 2},
+	})
+	if entry.Alias != "gpt-5" || entry.ClientKey != util.HideAPIKey("client") || entry.ClientKeyID != internallogging.ClientKeyID("client") {
+		t.Fatalf("unexpected identity fields: %+v", entry)
+	}
+	if entry.TotalTokens != 7 || entry.CachedTokens != 2 || entry.LatencyMs != 1500 {
+		t.Fatalf("unexpected counters: %+v", entry)
+	}
+	if !entry.Failed || entry.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected failure with status 502, got %+v", entry)
+	}
+	if entry.Timestamp.Location() != time.UTC || entry.Timestamp.Hour() != 11 {
+		t.Fatalf("expected UTC timestamp, got %v", entry.Timestamp)
+	}
+}
+
+// countingBackend records the size of every batch it receives.
+type countingBackend struct {
+	*FileBackend
+	batches []int
+}
+
+func (b *countingBackend) AppendUsage(ctx context.Context, entries []Entry) error {
+	b.batches = append(b.batches, len(entries))
+	return b.FileBackend.AppendUsage(ctx, entries)
+}
+
+func TestLedgerBuffersRecordsAndMasksKeys(t *testing.T) {
+	fileBackend, err := NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileBackend: %v", err)
+	}
+	t.Cleanup(func() { _ = fileBackend.Close() })
+	ctx := context.Background()
+	legacyAt := time.Now().UTC().Add(-time.Minute)
+	if err = fileBackend.AppendUsage(ctx, []Entry{{Timestamp: legacyAt, Provider: "codex", Model: "gpt-5", ClientKey: "sk-legacy-raw-key"}}); err != nil {
+		t.Fatalf("AppendUsage: %v", err)
+	}
+	backend := &countingBackend{FileBackend: fileBackend}
+	ledger := &Ledger{now: time.Now}
+	ledger.Configure(backend, 0)
+
+	for i := 0; i < 3; i++ {
+		ledger.HandleUsage(ctx, coreusage.Record{Provider: "codex", Model: "gpt-5", APIKey: "sk-client-secret-key", RequestedAt: time.Now()})
+	}
+	if len(backend.batches) != 0 {
+		t.Fatalf("HandleUsage wrote synchronously: batches=%v", backend.batches)
+	}
+
+	entries, err := ledger.Query(ctx, Filter{ClientKey: "sk-client-secret-key"})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(backend.batches) != 1 || backend.batches[0] != 3 {
+		t.Fatalf("batches = %v, want one batch of 3", backend.batches)
+	}
+	if len(entries) != 3 || entries[0].ClientKey == "sk-client-secret-key" || entries[0].ClientKeyID != internallogging.ClientKeyID("sk-client-secret-key") {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	legacy, err := ledger.Query(ctx, Filter{ClientKey: "sk-legacy-raw-key"})
+	if err != nil {
+		t.Fatalf("Query legacy: %v", err)
+	}
+	if len(legacy) != 1 || legacy[0].ClientKey != util.HideAPIKey("sk-legacy-raw-key") || legacy[0].ClientKeyID != internallogging.ClientKeyID("sk-legacy-raw-key") {
+		t.Fatalf("legacy entry not masked: %+v", legacy)
+	}
+}
+
+func TestAggregateAndCSV(t *testing.T) {
+	base := time.Date(2026, 3, 1, 10, 5, 0, 0, time.UTC)
+	entries := []Entry{
+		{Timestamp: base, Provider: "claude", Model: "m1", AuthIndex: "1", InputTokens: 1, TotalTokens: 1, LatencyMs: 100},
+		{Timestamp: base.Add(10 * time.Minute), Provider: "claude", Model: "m1", AuthIndex: "2", InputTokens: 2, TotalTokens: 2, LatencyMs: 300, Failed: true},
+		{Timestamp: base.Add(2 * time.Hour), Provider: "claude", Model: "m2", InputTokens: 4, TotalTokens: 4},
+	}
+
+	rows := Aggregate(entries, IntervalHour, []string{DimensionModel})
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", rows)
+	}
+	first := rows[0]
+	if first.Bucket != "2026-03-01T10:00Z" || first.Model != "m1" || first.Requests != 2 || first.Failures != 1 {
+		t.Fatalf("unexpected first row: %+v", first)
+	}
+	if first.InputTokens != 3 || first.AvgLatencyMs != 200 {
+		t.Fatalf("unexpected first row counters: %+v", first)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, rows, IntervalHour, []string{DimensionModel}); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got %q", buf.String())
+	}
+	if lines[0] != "bucket,model,requests,failures,input_tokens,output_tokens,reasoning_tokens,cached_tokens,total_tokens,avg_latency_ms" {
+		t.Fatalf("unexpected header %q", lines[0])
+	}
+	if lines[1] != "2026-03-01T10:00Z,m1,2,1,3,0,0,0,3,200.0" {
+		t.Fatalf("unexpected row %q", lines[1])
+	}
+
+	if _, err := ParseDimensions("provider,bogus"); err == nil {
+		t.Fatal("expected unknown dimension to be rejected")
+	}
+}
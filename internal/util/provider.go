@@ -258,6 +258,31 @@ func MaskSensitiveQuery(raw string) string {
 	return strings.Join(parts, "&")
 }
 
+// StripSensitiveQuery removes query parameters that MaskSensitiveQuery would mask, so a
+// logged URL can be reissued without carrying a masked credential.
+func StripSensitiveQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	parts := strings.Split(raw, "&")
+	kept := parts[:0]
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		keyPart, _, _ := strings.Cut(part, "=")
+		decodedKey, err := url.QueryUnescape(keyPart)
+		if err != nil {
+			decodedKey = keyPart
+		}
+		if shouldMaskQueryParam(decodedKey) {
+			continue
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(kept, "&")
+}
+
 func shouldMaskQueryParam(key string) bool {
 	key = strings.ToLower(strings.TrimSpace(key))
 	if key == "" {
@@ -33,6 +33,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if strings.TrimSpace(oldCfg.Pprof.Addr) != strings.TrimSpace(newCfg.Pprof.Addr) {
 		changes = append(changes, fmt.Sprintf("pprof.addr: %s -> %s", strings.TrimSpace(oldCfg.Pprof.Addr), strings.TrimSpace(newCfg.Pprof.Addr)))
 	}
+	if oldCfg.Metrics.Enable != newCfg.Metrics.Enable {
+		changes = append(changes, fmt.Sprintf("metrics.enable: %t -> %t", oldCfg.Metrics.Enable, newCfg.Metrics.Enable))
+	}
+	if oldCfg.Metrics.ServeOnPprof != newCfg.Metrics.ServeOnPprof {
+		changes = append(changes, fmt.Sprintf("metrics.serve-on-pprof: %t -> %t", oldCfg.Metrics.ServeOnPprof, newCfg.Metrics.ServeOnPprof))
+	}
 	if oldCfg.LoggingToFile != newCfg.LoggingToFile {
 		changes = append(changes, fmt.Sprintf("logging-to-file: %t -> %t", oldCfg.LoggingToFile, newCfg.LoggingToFile))
 	}
@@ -42,6 +48,138 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.RedisUsageQueueRetentionSeconds != newCfg.RedisUsageQueueRetentionSeconds {
 		changes = append(changes, fmt.Sprintf("redis-usage-queue-retention-seconds: %d -> %d", oldCfg.RedisUsageQueueRetentionSeconds, newCfg.RedisUsageQueueRetentionSeconds))
 	}
+	if oldCfg.UsageLedger.Enable != newCfg.UsageLedger.Enable {
+		changes = append(changes, fmt.Sprintf("usage-ledger.enable: %t -> %t", oldCfg.UsageLedger.Enable, newCfg.UsageLedger.Enable))
+	}
+	if oldCfg.UsageLedger.Dir != newCfg.UsageLedger.Dir {
+		changes = append(changes, fmt.Sprintf("usage-ledger.dir: %s -> %s", oldCfg.UsageLedger.Dir, newCfg.UsageLedger.Dir))
+	}
+	if oldCfg.UsageLedger.RetentionDays != newCfg.UsageLedger.RetentionDays {
+		changes = append(changes, fmt.Sprintf("usage-ledger.retention-days: %d -> %d", oldCfg.UsageLedger.RetentionDays, newCfg.UsageLedger.RetentionDays))
+	}
+	if oldCfg.ResponseCache.Enable != newCfg.ResponseCache.Enable {
+		changes = append(changes, fmt.Sprintf("response-cache.enable: %t -> %t", oldCfg.ResponseCache.Enable, newCfg.ResponseCache.Enable))
+	}
+	if oldCfg.ResponseCache.Backend != newCfg.ResponseCache.Backend {
+		changes = append(changes, fmt.Sprintf("response-cache.backend: %s -> %s", oldCfg.ResponseCache.Backend, newCfg.ResponseCache.Backend))
+	}
+	if oldCfg.ResponseCache.Dir != newCfg.ResponseCache.Dir {
+		changes = append(changes, fmt.Sprintf("response-cache.dir: %s -> %s", oldCfg.ResponseCache.Dir, newCfg.ResponseCache.Dir))
+	}
+	if oldCfg.ResponseCache.MaxEntries != newCfg.ResponseCache.MaxEntries {
+		changes = append(changes, fmt.Sprintf("response-cache.max-entries: %d -> %d", oldCfg.ResponseCache.MaxEntries, newCfg.ResponseCache.MaxEntries))
+	}
+	if oldCfg.ResponseCache.TTLSeconds != newCfg.ResponseCache.TTLSeconds {
+		changes = append(changes, fmt.Sprintf("response-cache.ttl-seconds: %d -> %d", oldCfg.ResponseCache.TTLSeconds, newCfg.ResponseCache.TTLSeconds))
+	}
+	if oldCfg.ResponsesStore.Enable != newCfg.ResponsesStore.Enable {
+		changes = append(changes, fmt.Sprintf("responses-store.enable: %t -> %t", oldCfg.ResponsesStore.Enable, newCfg.ResponsesStore.Enable))
+	}
+	if oldCfg.ResponsesStore.Backend != newCfg.ResponsesStore.Backend {
+		changes = append(changes, fmt.Sprintf("responses-store.backend: %s -> %s", oldCfg.ResponsesStore.Backend, newCfg.ResponsesStore.Backend))
+	}
+	if oldCfg.ResponsesStore.Dir != newCfg.ResponsesStore.Dir {
+		changes = append(changes, fmt.Sprintf("responses-store.dir: %s -> %s", oldCfg.ResponsesStore.Dir, newCfg.ResponsesStore.Dir))
+	}
+	if oldCfg.ResponsesStore.MaxEntries != newCfg.ResponsesStore.MaxEntries {
+		changes = append(changes, fmt.Sprintf("responses-store.max-entries: %d -> %d", oldCfg.ResponsesStore.MaxEntries, newCfg.ResponsesStore.MaxEntries))
+	}
+	if oldCfg.ResponsesStore.TTLSeconds != newCfg.ResponsesStore.TTLSeconds {
+		changes = append(changes, fmt.Sprintf("responses-store.ttl-seconds: %d -> %d", oldCfg.ResponsesStore.TTLSeconds, newCfg.ResponsesStore.TTLSeconds))
+	}
+	if oldCfg.Batch.Enable != newCfg.Batch.Enable {
+		changes = append(changes, fmt.Sprintf("batch.enable: %t -> %t", oldCfg.Batch.Enable, newCfg.Batch.Enable))
+	}
+	if oldCfg.Batch.Dir != newCfg.Batch.Dir {
+		changes = append(changes, fmt.Sprintf("batch.dir: %s -> %s", oldCfg.Batch.Dir, newCfg.Batch.Dir))
+	}
+	if oldCfg.Batch.Concurrency != newCfg.Batch.Concurrency {
+		changes = append(changes, fmt.Sprintf("batch.concurrency: %d -> %d", oldCfg.Batch.Concurrency, newCfg.Batch.Concurrency))
+	}
+	if oldCfg.Batch.MaxFileSizeMB != newCfg.Batch.MaxFileSizeMB {
+		changes = append(changes, fmt.Sprintf("batch.max-file-size-mb: %d -> %d", oldCfg.Batch.MaxFileSizeMB, newCfg.Batch.MaxFileSizeMB))
+	}
+	if oldCfg.AuthEncryption.Enable != newCfg.AuthEncryption.Enable {
+		changes = append(changes, fmt.Sprintf("auth-encryption.enable: %t -> %t", oldCfg.AuthEncryption.Enable, newCfg.AuthEncryption.Enable))
+	}
+	if oldCfg.AuthEncryption.Key != newCfg.AuthEncryption.Key {
+		changes = append(changes, "auth-encryption.key: updated")
+	}
+	if len(oldCfg.AuthEncryption.PreviousKeys) != len(newCfg.AuthEncryption.PreviousKeys) {
+		changes = append(changes, fmt.Sprintf("auth-encryption.previous-keys count: %d -> %d", len(oldCfg.AuthEncryption.PreviousKeys), len(newCfg.AuthEncryption.PreviousKeys)))
+	}
+	if oldCfg.AuditLog.Enable != newCfg.AuditLog.Enable {
+		changes = append(changes, fmt.Sprintf("audit-log.enable: %t -> %t", oldCfg.AuditLog.Enable, newCfg.AuditLog.Enable))
+	}
+	if oldCfg.AuditLog.Dir != newCfg.AuditLog.Dir {
+		changes = append(changes, fmt.Sprintf("audit-log.dir: %s -> %s", oldCfg.AuditLog.Dir, newCfg.AuditLog.Dir))
+	}
+	if oldCfg.AuditLog.Postgres != newCfg.AuditLog.Postgres {
+		changes = append(changes, fmt.Sprintf("audit-log.postgres: %t -> %t", oldCfg.AuditLog.Postgres, newCfg.AuditLog.Postgres))
+	}
+	if oldCfg.ConfigVersions.Disable != newCfg.ConfigVersions.Disable {
+		changes = append(changes, fmt.Sprintf("config-versions.disable: %t -> %t", oldCfg.ConfigVersions.Disable, newCfg.ConfigVersions.Disable))
+	}
+	if oldCfg.ConfigVersions.Dir != newCfg.ConfigVersions.Dir {
+		changes = append(changes, fmt.Sprintf("config-versions.dir: %s -> %s", oldCfg.ConfigVersions.Dir, newCfg.ConfigVersions.Dir))
+	}
+	if oldCfg.ConfigVersions.MaxVersions != newCfg.ConfigVersions.MaxVersions {
+		changes = append(changes, fmt.Sprintf("config-versions.max-versions: %d -> %d", oldCfg.ConfigVersions.MaxVersions, newCfg.ConfigVersions.MaxVersions))
+	}
+	if oldCfg.Tracing.Enable != newCfg.Tracing.Enable {
+		changes = append(changes, fmt.Sprintf("tracing.enable: %t -> %t", oldCfg.Tracing.Enable, newCfg.Tracing.Enable))
+	}
+	if oldCfg.Tracing.Endpoint != newCfg.Tracing.Endpoint {
+		changes = append(changes, fmt.Sprintf("tracing.endpoint: %s -> %s", oldCfg.Tracing.Endpoint, newCfg.Tracing.Endpoint))
+	}
+	if !reflect.DeepEqual(oldCfg.Tracing.Headers, newCfg.Tracing.Headers) {
+		changes = append(changes, fmt.Sprintf("tracing.headers: updated (%d -> %d entries)", len(oldCfg.Tracing.Headers), len(newCfg.Tracing.Headers)))
+	}
+	if oldCfg.Tracing.SampleRatio != newCfg.Tracing.SampleRatio {
+		changes = append(changes, fmt.Sprintf("tracing.sample-ratio: %g -> %g", oldCfg.Tracing.SampleRatio, newCfg.Tracing.SampleRatio))
+	}
+	if oldCfg.Tracing.ServiceName != newCfg.Tracing.ServiceName {
+		changes = append(changes, fmt.Sprintf("tracing.service-name: %s -> %s", oldCfg.Tracing.ServiceName, newCfg.Tracing.ServiceName))
+	}
+	if oldCfg.Tracing.PropagateUpstream != newCfg.Tracing.PropagateUpstream {
+		changes = append(changes, fmt.Sprintf("tracing.propagate-upstream: %t -> %t", oldCfg.Tracing.PropagateUpstream, newCfg.Tracing.PropagateUpstream))
+	}
+	if oldCfg.SharedState.Enable != newCfg.SharedState.Enable {
+		changes = append(changes, fmt.Sprintf("shared-state.enable: %t -> %t", oldCfg.SharedState.Enable, newCfg.SharedState.Enable))
+	}
+	if oldCfg.SharedState.Addr != newCfg.SharedState.Addr {
+		changes = append(changes, fmt.Sprintf("shared-state.addr: %s -> %s", oldCfg.SharedState.Addr, newCfg.SharedState.Addr))
+	}
+	if oldCfg.SharedState.Password != newCfg.SharedState.Password {
+		changes = append(changes, "shared-state.password: updated")
+	}
+	if oldCfg.SharedState.KeyPrefix != newCfg.SharedState.KeyPrefix {
+		changes = append(changes, fmt.Sprintf("shared-state.key-prefix: %s -> %s", oldCfg.SharedState.KeyPrefix, newCfg.SharedState.KeyPrefix))
+	}
+	if oldCfg.CacheSnapshot.Disable != newCfg.CacheSnapshot.Disable {
+		changes = append(changes, fmt.Sprintf("cache-snapshot.disable: %t -> %t", oldCfg.CacheSnapshot.Disable, newCfg.CacheSnapshot.Disable))
+	}
+	if oldCfg.CacheSnapshot.Dir != newCfg.CacheSnapshot.Dir {
+		changes = append(changes, fmt.Sprintf("cache-snapshot.dir: %s -> %s", oldCfg.CacheSnapshot.Dir, newCfg.CacheSnapshot.Dir))
+	}
+	if oldCfg.CacheSnapshot.IntervalSeconds != newCfg.CacheSnapshot.IntervalSeconds {
+		changes = append(changes, fmt.Sprintf("cache-snapshot.interval-seconds: %d -> %d", oldCfg.CacheSnapshot.IntervalSeconds, newCfg.CacheSnapshot.IntervalSeconds))
+	}
+	if oldCfg.HealthProbe.Enable != newCfg.HealthProbe.Enable {
+		changes = append(changes, fmt.Sprintf("health-probe.enable: %t -> %t", oldCfg.HealthProbe.Enable, newCfg.HealthProbe.Enable))
+	}
+	if oldCfg.HealthProbe.IntervalSeconds != newCfg.HealthProbe.IntervalSeconds {
+		changes = append(changes, fmt.Sprintf("health-probe.interval-seconds: %d -> %d", oldCfg.HealthProbe.IntervalSeconds, newCfg.HealthProbe.IntervalSeconds))
+	}
+	if oldCfg.HealthProbe.TimeoutSeconds != newCfg.HealthProbe.TimeoutSeconds {
+		changes = append(changes, fmt.Sprintf("health-probe.timeout-seconds: %d -> %d", oldCfg.HealthProbe.TimeoutSeconds, newCfg.HealthProbe.TimeoutSeconds))
+	}
+	if oldCfg.HealthProbe.Concurrency != newCfg.HealthProbe.Concurrency {
+		changes = append(changes, fmt.Sprintf("health-probe.concurrency: %d -> %d", oldCfg.HealthProbe.Concurrency, newCfg.HealthProbe.Concurrency))
+	}
+	if !reflect.DeepEqual(oldCfg.HealthProbe.Models, newCfg.HealthProbe.Models) {
+		changes = append(changes, "health-probe.models: updated")
+	}
 	if oldCfg.DisableCooling != newCfg.DisableCooling {
 		changes = append(changes, fmt.Sprintf("disable-cooling: %t -> %t", oldCfg.DisableCooling, newCfg.DisableCooling))
 	}
@@ -57,6 +195,15 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ErrorLogsMaxFiles != newCfg.ErrorLogsMaxFiles {
 		changes = append(changes, fmt.Sprintf("error-logs-max-files: %d -> %d", oldCfg.ErrorLogsMaxFiles, newCfg.ErrorLogsMaxFiles))
 	}
+	if !reflect.DeepEqual(oldCfg.RequestLogRedaction, newCfg.RequestLogRedaction) {
+		changes = append(changes, "request-log-redaction: updated")
+	}
+	if oldCfg.RequestLogFormat != newCfg.RequestLogFormat {
+		changes = append(changes, fmt.Sprintf("request-log-format: %s -> %s", oldCfg.RequestLogFormat, newCfg.RequestLogFormat))
+	}
+	if oldCfg.RequestLogRetentionDays != newCfg.RequestLogRetentionDays {
+		changes = append(changes, fmt.Sprintf("request-log-retention-days: %d -> %d", oldCfg.RequestLogRetentionDays, newCfg.RequestLogRetentionDays))
+	}
 	if oldCfg.RequestRetry != newCfg.RequestRetry {
 		changes = append(changes, fmt.Sprintf("request-retry: %d -> %d", oldCfg.RequestRetry, newCfg.RequestRetry))
 	}
@@ -93,6 +240,14 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Routing.Strategy != newCfg.Routing.Strategy {
 		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", oldCfg.Routing.Strategy, newCfg.Routing.Strategy))
 	}
+	if oldQueue, newQueue := oldCfg.Routing.ConcurrencyQueue, newCfg.Routing.ConcurrencyQueue; oldQueue != newQueue {
+		changes = append(changes, fmt.Sprintf("routing.concurrency-queue: disabled=%t max-waiting=%d max-waiting-per-client=%d timeout-seconds=%d -> disabled=%t max-waiting=%d max-waiting-per-client=%d timeout-seconds=%d",
+			oldQueue.Disabled, oldQueue.MaxWaiting, oldQueue.MaxWaitingPerClient, oldQueue.TimeoutSeconds,
+			newQueue.Disabled, newQueue.MaxWaiting, newQueue.MaxWaitingPerClient, newQueue.TimeoutSeconds))
+	}
+	if oldCfg.Routing.QuotaAvoidPercent != newCfg.Routing.QuotaAvoidPercent {
+		changes = append(changes, fmt.Sprintf("routing.quota-avoid-percent: %d -> %d", oldCfg.Routing.QuotaAvoidPercent, newCfg.Routing.QuotaAvoidPercent))
+	}
 	if !reflect.DeepEqual(oldCfg.Payload, newCfg.Payload) {
 		changes = appendPayloadConfigChanges(changes, oldCfg.Payload, newCfg.Payload)
 	}
@@ -103,6 +258,15 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
 		changes = append(changes, "api-keys: values updated (count unchanged, redacted)")
 	}
+	if !reflect.DeepEqual(oldCfg.ModelFallbacks, newCfg.ModelFallbacks) {
+		changes = append(changes, fmt.Sprintf("model-fallbacks: updated (%d -> %d aliases)", len(oldCfg.ModelFallbacks), len(newCfg.ModelFallbacks)))
+	}
+	if !reflect.DeepEqual(oldCfg.APIKeyPolicies, newCfg.APIKeyPolicies) {
+		changes = append(changes, fmt.Sprintf("api-key-policies: updated (%d -> %d entries)", len(oldCfg.APIKeyPolicies), len(newCfg.APIKeyPolicies)))
+	}
+	if !reflect.DeepEqual(oldCfg.Access.Providers, newCfg.Access.Providers) {
+		changes = append(changes, fmt.Sprintf("access.providers: updated (%d -> %d providers)", len(oldCfg.Access.Providers), len(newCfg.Access.Providers)))
+	}
 	if len(oldCfg.GeminiKey) != len(newCfg.GeminiKey) {
 		changes = append(changes, fmt.Sprintf("gemini-api-key count: %d -> %d", len(oldCfg.GeminiKey), len(newCfg.GeminiKey)))
 	} else {
@@ -286,6 +450,11 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			changes = append(changes, "remote-management.secret-key: updated")
 		}
 	}
+	if len(oldCfg.RemoteManagement.Tokens) != len(newCfg.RemoteManagement.Tokens) {
+		changes = append(changes, fmt.Sprintf("remote-management.tokens count: %d -> %d", len(oldCfg.RemoteManagement.Tokens), len(newCfg.RemoteManagement.Tokens)))
+	} else if !reflect.DeepEqual(oldCfg.RemoteManagement.Tokens, newCfg.RemoteManagement.Tokens) {
+		changes = append(changes, "remote-management.tokens: updated")
+	}
 
 	// OpenAI compatibility providers (summarized)
 	if compat := DiffOpenAICompatibility(oldCfg.OpenAICompatibility, newCfg.OpenAICompatibility); len(compat) > 0 {
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authcrypt"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -125,7 +126,7 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 }
 
 func (w *Watcher) authFileUnchanged(path string) (bool, error) {
-	data, errRead := os.ReadFile(path)
+	data, errRead := authcrypt.ReadFile(path)
 	if errRead != nil {
 		return false, errRead
 	}
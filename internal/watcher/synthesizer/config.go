@@ -67,6 +67,7 @@ func (s *ConfigSynthesizer) synthesizeGeminiKeys(ctx *SynthesisContext) []*corea
 		if entry.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(entry.Priority)
 		}
+		setRoutingAttrs(attrs, entry.Weight, entry.MaxConcurrent)
 		if base != "" {
 			attrs["base_url"] = base
 		}
@@ -122,6 +123,7 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 		if ck.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(ck.Priority)
 		}
+		setRoutingAttrs(attrs, ck.Weight, ck.MaxConcurrent)
 		if base != "" {
 			attrs["base_url"] = base
 		}
@@ -177,6 +179,7 @@ func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreau
 		if ck.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(ck.Priority)
 		}
+		setRoutingAttrs(attrs, ck.Weight, ck.MaxConcurrent)
 		if ck.BaseURL != "" {
 			attrs["base_url"] = ck.BaseURL
 		}
@@ -250,6 +253,7 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if compat.Priority != 0 {
 				attrs["priority"] = strconv.Itoa(compat.Priority)
 			}
+			setRoutingAttrs(attrs, compat.Weight, compat.MaxConcurrent)
 			if key != "" {
 				attrs["api_key"] = key
 			}
@@ -292,6 +296,7 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if compat.Priority != 0 {
 				attrs["priority"] = strconv.Itoa(compat.Priority)
 			}
+			setRoutingAttrs(attrs, compat.Weight, compat.MaxConcurrent)
 			if hash := diff.ComputeOpenAICompatModelsHash(compat.Models); hash != "" {
 				attrs["models_hash"] = hash
 			}
@@ -341,6 +346,7 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 		if compat.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(compat.Priority)
 		}
+		setRoutingAttrs(attrs, compat.Weight, compat.MaxConcurrent)
 		if key != "" {
 			attrs["api_key"] = key
 		}
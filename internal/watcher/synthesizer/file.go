@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/codex"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/geminicli"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 )
@@ -46,7 +47,7 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 			continue
 		}
 		full := filepath.Join(ctx.AuthDir, name)
-		data, errRead := os.ReadFile(full)
+		data, errRead := authcrypt.ReadFile(full)
 		if errRead != nil || len(data) == 0 {
 			continue
 		}
@@ -149,6 +150,13 @@ func synthesizeFileAuths(ctx *SynthesisContext, fullPath string, data []byte) []
 			}
 		}
 	}
+	// Read weight and max_concurrent from auth file.
+	if weight, ok := positiveIntMetadata(metadata, "weight"); ok {
+		a.Attributes["weight"] = weight
+	}
+	if maxConcurrent, ok := positiveIntMetadata(metadata, "max_concurrent"); ok {
+		a.Attributes["max_concurrent"] = maxConcurrent
+	}
 	// Read note from auth file.
 	if rawNote, ok := metadata["note"]; ok {
 		if note, isStr := rawNote.(string); isStr {
@@ -230,6 +238,14 @@ func SynthesizeGeminiVirtualAuths(primary *coreauth.Auth, metadata map[string]an
 		if priorityVal, hasPriority := primary.Attributes["priority"]; hasPriority && priorityVal != "" {
 			attrs["priority"] = priorityVal
 		}
+		// Propagate weight from primary auth to virtual auths
+		if weightVal, hasWeight := primary.Attributes["weight"]; hasWeight && weightVal != "" {
+			attrs["weight"] = weightVal
+		}
+		// Propagate max_concurrent from primary auth to virtual auths
+		if maxVal, hasMax := primary.Attributes["max_concurrent"]; hasMax && maxVal != "" {
+			attrs["max_concurrent"] = maxVal
+		}
 		// Propagate note from primary auth to virtual auths
 		if noteVal, hasNote := primary.Attributes["note"]; hasNote && noteVal != "" {
 			attrs["note"] = noteVal
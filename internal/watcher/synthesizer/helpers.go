@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
@@ -118,3 +119,31 @@ func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string)
 		attrs["header:"+key] = val
 	}
 }
+
+// setRoutingAttrs records the weight and max-concurrent limit of a configured credential.
+// Non-positive values are left out so the scheduler defaults apply.
+func setRoutingAttrs(attrs map[string]string, weight, maxConcurrent int) {
+	if weight > 0 {
+		attrs["weight"] = strconv.Itoa(weight)
+	}
+	if maxConcurrent > 0 {
+		attrs["max_concurrent"] = strconv.Itoa(maxConcurrent)
+	}
+}
+
+// positiveIntMetadata returns metadata[key] as a decimal string when it holds a positive
+// integer, either as a JSON number or as a string.
+func positiveIntMetadata(metadata map[string]any, key string) (string, bool) {
+	switch v := metadata[key].(type) {
+	case float64:
+		if v >= 1 {
+			return strconv.Itoa(int(v)), true
+		}
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if parsed, errAtoi := strconv.Atoi(trimmed); errAtoi == nil && parsed > 0 {
+			return trimmed, true
+		}
+	}
+	return "", false
+}
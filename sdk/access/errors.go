@@ -14,6 +14,7 @@ const (
 	AuthErrorCodeInvalidCredential AuthErrorCode = "invalid_credential"
 	AuthErrorCodeNotHandled        AuthErrorCode = "not_handled"
 	AuthErrorCodeInternal          AuthErrorCode = "internal_error"
+	AuthErrorCodeRequestTooLarge   AuthErrorCode = "request_too_large"
 )
 
 // AuthError carries authentication failure details and HTTP status.
@@ -82,6 +83,14 @@ func NewInternalAuthError(message string, cause error) *AuthError {
 	return newAuthError(AuthErrorCodeInternal, normalizedMessage, http.StatusInternalServerError, cause)
 }
 
+func NewRequestTooLargeError(message string) *AuthError {
+	normalizedMessage := strings.TrimSpace(message)
+	if normalizedMessage == "" {
+		normalizedMessage = "Request body too large"
+	}
+	return newAuthError(AuthErrorCodeRequestTooLarge, normalizedMessage, http.StatusRequestEntityTooLarge, nil)
+}
+
 func IsAuthErrorCode(authErr *AuthError, code AuthErrorCode) bool {
 	if authErr == nil {
 		return false
@@ -1,5 +1,10 @@
 package access
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // AccessConfig groups request authentication providers.
 type AccessConfig struct {
 	// Providers lists configured authentication providers.
@@ -28,10 +33,25 @@ const (
 	// AccessProviderTypeConfigAPIKey is the built-in provider validating inline API keys.
 	AccessProviderTypeConfigAPIKey = "config-api-key"
 
+	// AccessProviderTypeJWT is the built-in provider validating JWTs against a JWKS.
+	AccessProviderTypeJWT = "jwt"
+
+	// AccessProviderTypeHMAC is the built-in provider validating HMAC-signed requests.
+	AccessProviderTypeHMAC = "hmac"
+
 	// DefaultAccessProviderName is applied when no provider name is supplied.
 	DefaultAccessProviderName = "config-inline"
 )
 
+const (
+	// MetadataAuthType names the kind of credential that authenticated the request.
+	MetadataAuthType = "auth-type"
+
+	// MetadataAllowedModels carries a comma-separated list of model patterns the principal
+	// may request. It is enforced alongside api-key-policies; absent means unrestricted.
+	MetadataAllowedModels = "allowed-models"
+)
+
 // MakeInlineAPIKeyProvider constructs an inline API key provider configuration.
 // It returns nil when no keys are supplied.
 func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
@@ -45,3 +65,19 @@ func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
 	}
 	return provider
 }
+
+// DecodeConfig decodes the provider-specific Config map into out, a pointer to a struct
+// with json tags matching the YAML keys.
+func (p *AccessProvider) DecodeConfig(out any) error {
+	if p == nil || len(p.Config) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(p.Config)
+	if err != nil {
+		return fmt.Errorf("access provider %s: encode config: %w", p.Name, err)
+	}
+	if err = json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("access provider %s: invalid config: %w", p.Name, err)
+	}
+	return nil
+}
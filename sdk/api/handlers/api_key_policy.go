@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	accesspolicy "github.com/router-for-me/CLIProxyAPI/v7/internal/access/policy"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	"golang.org/x/net/context"
+)
+
+// clientAPIKeyFromContext returns the authenticated client key stored by the access middleware.
+func clientAPIKeyFromContext(ctx context.Context) (string, *gin.Context) {
+	if ctx == nil {
+		return "", nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return "", nil
+	}
+	raw, exists := ginCtx.Get("userApiKey")
+	if !exists {
+		return "", ginCtx
+	}
+	apiKey, _ := raw.(string)
+	return strings.TrimSpace(apiKey), ginCtx
+}
+
+// enforceAPIKeyPolicy applies the client key policy before any credential is selected.
+// When consume is false only the model allowlist is checked.
+func (h *BaseAPIHandler) enforceAPIKeyPolicy(ctx context.Context, modelName string, consume bool) *interfaces.ErrorMessage {
+	baseModel := strings.TrimSpace(thinking.ParseSuffix(modelName).ModelName)
+	limiter := accesspolicy.Default()
+	if errMsg := enforceAccessModelRestriction(ctx, limiter, baseModel); errMsg != nil {
+		return errMsg
+	}
+	if h == nil || h.Cfg == nil || len(h.Cfg.APIKeyPolicies) == 0 {
+		return nil
+	}
+	apiKey, ginCtx := clientAPIKeyFromContext(ctx)
+	policy, ok := h.Cfg.APIKeyPolicyFor(apiKey)
+	if !ok {
+		return nil
+	}
+	var errPolicy *accesspolicy.Error
+	if consume {
+		errPolicy = limiter.Allow(policy, baseModel)
+	} else {
+		errPolicy = limiter.CheckModel(policy, baseModel)
+	}
+	if errPolicy == nil {
+		return nil
+	}
+	if wait := errPolicy.RetryAfter(); wait > 0 && ginCtx != nil {
+		ginCtx.Header("Retry-After", strconv.FormatInt(accesspolicy.RetryAfterSeconds(wait), 10))
+	}
+	status := errPolicy.StatusCode()
+	if status <= 0 {
+		status = http.StatusForbidden
+	}
+	return &interfaces.ErrorMessage{StatusCode: status, Error: errPolicy, Addon: errPolicy.Headers()}
+}
+
+// enforceAccessModelRestriction applies the model restriction granted by the access
+// provider, such as the models claim of a JWT or the allowed models of an HMAC key.
+func enforceAccessModelRestriction(ctx context.Context, limiter *accesspolicy.Limiter, baseModel string) *interfaces.ErrorMessage {
+	_, ginCtx := clientAPIKeyFromContext(ctx)
+	if ginCtx == nil {
+		return nil
+	}
+	raw, exists := ginCtx.Get("accessMetadata")
+	if !exists {
+		return nil
+	}
+	metadata, _ := raw.(map[string]string)
+	allowed := strings.TrimSpace(metadata[sdkaccess.MetadataAllowedModels])
+	if allowed == "" {
+		return nil
+	}
+	restriction := internalconfig.APIKeyPolicy{AllowedModels: internalconfig.NormalizeExcludedModels(strings.Split(allowed, ","))}
+	if errPolicy := limiter.CheckModel(restriction, baseModel); errPolicy != nil {
+		return &interfaces.ErrorMessage{StatusCode: errPolicy.StatusCode(), Error: errPolicy}
+	}
+	return nil
+}
+
+// CheckModelAccess reports whether the client behind ctx may use modelName under its key
+// policy and access provider restrictions, without consuming rate-limit budget. It is used
+// to validate requests that are executed later, such as batch lines.
+func (h *BaseAPIHandler) CheckModelAccess(ctx context.Context, modelName string) *interfaces.ErrorMessage {
+	return h.enforceAPIKeyPolicy(ctx, modelName, false)
+}
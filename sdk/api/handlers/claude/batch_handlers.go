@@ -0,0 +1,183 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/batch"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultMessageBatchListLimit = 20
+	maxMessageBatchListLimit     = 1000
+)
+
+// MessageBatchesCreate handles POST /v1/messages/batches. The inline requests run on the
+// same local runner as OpenAI batches.
+func (h *ClaudeCodeAPIHandler) MessageBatchesCreate(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeClaudeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	requests := gjson.GetBytes(rawJSON, "requests")
+	if !requests.IsArray() || len(requests.Array()) == 0 {
+		writeClaudeBatchError(c, http.StatusBadRequest, "invalid_request_error", "requests: field required")
+		return
+	}
+	var input bytes.Buffer
+	for _, request := range requests.Array() {
+		if errCompact := json.Compact(&input, []byte(request.Raw)); errCompact != nil {
+			writeClaudeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid request: %v", errCompact))
+			return
+		}
+		input.WriteByte('\n')
+	}
+
+	ctx := context.WithValue(context.Background(), "gin", c)
+	job, err := batch.Default().CreateJob(batch.JobOptions{
+		Kind:      batch.KindAnthropic,
+		Owner:     c.GetString("userApiKey"),
+		Principal: batch.PrincipalFromContext(c),
+		Endpoint:  batch.AnthropicEndpoint,
+		Input:     &input,
+		CheckModel: func(model string) error {
+			if errMsg := h.CheckModelAccess(ctx, model); errMsg != nil && errMsg.Error != nil {
+				return errMsg.Error
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		writeClaudeBatchRunnerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job.AnthropicView(messageBatchResultsURL(c, job.ID)))
+}
+
+// MessageBatchesList handles GET /v1/messages/batches.
+func (h *ClaudeCodeAPIHandler) MessageBatchesList(c *gin.Context) {
+	jobs, err := batch.Default().ListJobs(c.GetString("userApiKey"), batch.KindAnthropic)
+	if err != nil {
+		writeClaudeBatchRunnerError(c, err)
+		return
+	}
+	start, end := 0, len(jobs)
+	if afterID := strings.TrimSpace(c.Query("after_id")); afterID != "" {
+		for i, job := range jobs {
+			if job.ID == afterID {
+				start = i + 1
+				break
+			}
+		}
+	}
+	if beforeID := strings.TrimSpace(c.Query("before_id")); beforeID != "" {
+		for i, job := range jobs {
+			if job.ID == beforeID {
+				end = i
+				break
+			}
+		}
+	}
+	start = min(start, end)
+	limit := defaultMessageBatchListLimit
+	if value, errLimit := strconv.Atoi(c.Query("limit")); errLimit == nil && value > 0 {
+		limit = min(value, maxMessageBatchListLimit)
+	}
+	hasMore := end-start > limit
+	if hasMore {
+		end = start + limit
+	}
+
+	data := make([]map[string]any, 0, end-start)
+	var firstID, lastID any
+	for _, job := range jobs[start:end] {
+		data = append(data, job.AnthropicView(messageBatchResultsURL(c, job.ID)))
+	}
+	if end > start {
+		firstID, lastID = jobs[start].ID, jobs[end-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data, "has_more": hasMore, "first_id": firstID, "last_id": lastID})
+}
+
+// MessageBatchesRetrieve handles GET /v1/messages/batches/{id}.
+func (h *ClaudeCodeAPIHandler) MessageBatchesRetrieve(c *gin.Context) {
+	job, err := batch.Default().GetJob(c.Param("id"), c.GetString("userApiKey"), batch.KindAnthropic)
+	if err != nil {
+		writeClaudeBatchRunnerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job.AnthropicView(messageBatchResultsURL(c, job.ID)))
+}
+
+// MessageBatchesCancel handles POST /v1/messages/batches/{id}/cancel.
+func (h *ClaudeCodeAPIHandler) MessageBatchesCancel(c *gin.Context) {
+	job, err := batch.Default().CancelJob(c.Param("id"), c.GetString("userApiKey"), batch.KindAnthropic)
+	if err != nil {
+		writeClaudeBatchRunnerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job.AnthropicView(messageBatchResultsURL(c, job.ID)))
+}
+
+// MessageBatchesDelete handles DELETE /v1/messages/batches/{id}. Only ended batches can be deleted.
+func (h *ClaudeCodeAPIHandler) MessageBatchesDelete(c *gin.Context) {
+	job, err := batch.Default().DeleteJob(c.Param("id"), c.GetString("userApiKey"), batch.KindAnthropic)
+	if err != nil {
+		writeClaudeBatchRunnerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": job.ID, "type": "message_batch_deleted"})
+}
+
+// MessageBatchesResults handles GET /v1/messages/batches/{id}/results and streams the JSONL results.
+func (h *ClaudeCodeAPIHandler) MessageBatchesResults(c *gin.Context) {
+	results, err := batch.Default().OpenResults(c.Param("id"), c.GetString("userApiKey"))
+	if err != nil {
+		writeClaudeBatchRunnerError(c, err)
+		return
+	}
+	defer func() { _ = results.Close() }()
+	size := int64(-1)
+	if info, errStat := results.Stat(); errStat == nil {
+		size = info.Size()
+	}
+	c.DataFromReader(http.StatusOK, size, "application/x-jsonl", results, nil)
+}
+
+func messageBatchResultsURL(c *gin.Context, id string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/v1/messages/batches/%s/results", scheme, c.Request.Host, id)
+}
+
+func writeClaudeBatchError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, claudeErrorResponse{
+		Type:  "error",
+		Error: claudeErrorDetail{Type: errType, Message: message},
+	})
+}
+
+// writeClaudeBatchRunnerError maps batch runner errors onto Anthropic error responses.
+func writeClaudeBatchRunnerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, batch.ErrDisabled), errors.Is(err, batch.ErrNotFound):
+		writeClaudeBatchError(c, http.StatusNotFound, "not_found_error", err.Error())
+	case errors.Is(err, batch.ErrInvalidRequest):
+		writeClaudeBatchError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+	case errors.Is(err, batch.ErrConflict):
+		writeClaudeBatchError(c, http.StatusConflict, "invalid_request_error", err.Error())
+	default:
+		writeClaudeBatchError(c, http.StatusInternalServerError, "api_error", err.Error())
+	}
+}
@@ -17,6 +17,8 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 // GeminiAPIHandler contains the handlers for Gemini API endpoints.
@@ -160,6 +162,10 @@ func (h *GeminiAPIHandler) GeminiHandler(c *gin.Context) {
 		h.handleStreamGenerateContent(c, action[0], rawJSON)
 	case "countTokens":
 		h.handleCountTokens(c, action[0], rawJSON)
+	case "embedContent":
+		h.handleEmbedContent(c, action[0], rawJSON, false)
+	case "batchEmbedContents":
+		h.handleEmbedContent(c, action[0], rawJSON, true)
 	}
 }
 
@@ -339,3 +345,48 @@ func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flus
 		},
 	})
 }
+
+// handleEmbedContent handles embedContent and batchEmbedContents requests for Gemini models.
+// Single requests are wrapped into the batch schema so every executor only has to
+// implement batchEmbedContents; the first embedding is unwrapped again for the response.
+//
+// Parameters:
+//   - c: The Gin context for the request
+//   - modelName: The name of the embedding model
+//   - rawJSON: The raw JSON request body
+//   - batch: Whether the client called batchEmbedContents
+func (h *GeminiAPIHandler) handleEmbedContent(c *gin.Context, modelName string, rawJSON []byte, batch bool) {
+	if !gjson.ValidBytes(rawJSON) {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "Invalid request: body must be valid JSON",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	payload := rawJSON
+	if !batch {
+		entry, _ := sjson.SetBytes(rawJSON, "model", "models/"+modelName)
+		payload, _ = sjson.SetRawBytes([]byte(`{"requests":[]}`), "requests.-1", entry)
+	}
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, GeminiEmbedding, modelName, payload, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	if !batch {
+		single := []byte(`{"embedding":{"values":[]}}`)
+		if embedding := gjson.GetBytes(resp, "embeddings.0"); embedding.Exists() {
+			single, _ = sjson.SetRawBytes(single, "embedding", []byte(embedding.Raw))
+		}
+		resp = single
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
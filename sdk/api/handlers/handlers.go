@@ -17,6 +17,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
@@ -379,6 +380,13 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			parentCtx = logging.WithRequestID(parentCtx, requestID)
 		}
 	}
+	if requestCtx != nil {
+		parentCtx = tracing.CopySpan(parentCtx, requestCtx)
+		// In-process callers such as request replay pin the auth on the request itself.
+		if pinnedAuthIDFromContext(parentCtx) == "" {
+			parentCtx = WithPinnedAuthID(parentCtx, pinnedAuthIDFromContext(requestCtx))
+		}
+	}
 	newCtx, cancel := context.WithCancel(parentCtx)
 
 	endpoint := ""
@@ -401,6 +409,7 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 	}
 	newCtx = logging.WithResponseStatusHolder(newCtx)
 	newCtx = logging.WithResponseHeadersHolder(newCtx)
+	newCtx = logging.WithFirstChunkHolder(newCtx)
 
 	cancelCtx := newCtx
 	if requestCtx != nil && requestCtx != parentCtx {
@@ -548,6 +557,21 @@ func (h *BaseAPIHandler) executeWithAuthManager(ctx context.Context, handlerType
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	if errMsg = h.enforceAPIKeyPolicy(ctx, modelName, true); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	var cacheLookup *responseCacheLookup
+	if !allowImageModel {
+		if lookup, ok := prepareResponseCache(ctx, handlerType, normalizedModel, providers, rawJSON); ok {
+			if cached, cachedHeaders, hit := lookup.serve(ctx, modelName); hit {
+				if !PassthroughHeadersEnabled(h.Cfg) {
+					return cached, nil, nil
+				}
+				return cached, FilterUpstreamHeaders(cachedHeaders), nil
+			}
+			cacheLookup = lookup
+		}
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = modelName
 	payload := rawJSON
@@ -583,6 +607,9 @@ func (h *BaseAPIHandler) executeWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	if cacheLookup != nil {
+		cacheLookup.store(resp.Payload, resp.Headers)
+	}
 	if !PassthroughHeadersEnabled(h.Cfg) {
 		return resp.Payload, nil, nil
 	}
@@ -596,6 +623,9 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	if errMsg = h.enforceAPIKeyPolicy(ctx, modelName, false); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = modelName
 	payload := rawJSON
@@ -651,6 +681,9 @@ func (h *BaseAPIHandler) ExecuteImageStreamWithAuthManager(ctx context.Context,
 
 func (h *BaseAPIHandler) executeStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, allowImageModel bool) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
 	providers, normalizedModel, errMsg := h.getRequestDetailsWithOptions(modelName, allowImageModel)
+	if errMsg == nil {
+		errMsg = h.enforceAPIKeyPolicy(ctx, modelName, true)
+	}
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
@@ -813,6 +846,9 @@ func (h *BaseAPIHandler) executeStreamWithAuthManager(ctx context.Context, handl
 							return
 						}
 					}
+					if !sentPayload {
+						logging.MarkFirstChunk(ctx)
+					}
 					sentPayload = true
 					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
 						return
@@ -905,6 +941,13 @@ func (h *BaseAPIHandler) getRequestDetailsWithOptions(modelName string, allowIma
 		return []string{"home"}, resolvedModelName, nil
 	}
 
+	// Aliases declared under model-fallbacks route across every provider of their chain.
+	if h != nil && h.AuthManager != nil {
+		if fallbackProviders := h.AuthManager.ModelFallbackProviders(resolvedModelName); len(fallbackProviders) > 0 {
+			return fallbackProviders, resolvedModelName, nil
+		}
+	}
+
 	providers = util.GetProviderName(baseModel)
 	// Fallback: if baseModel has no provider but differs from resolvedModelName,
 	// try using the full model name. This handles edge cases where custom models
@@ -0,0 +1,154 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// AudioTranscriptions handles the /v1/audio/transcriptions endpoint.
+// The multipart upload is routed through the auth manager; openai-compatibility providers
+// receive it unchanged while Gemini and Vertex credentials transcribe through generateContent.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) AudioTranscriptions(c *gin.Context) {
+	h.handleAudioUpload(c)
+}
+
+// AudioTranslations handles the /v1/audio/translations endpoint, which transcribes the
+// upload into English.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) AudioTranslations(c *gin.Context) {
+	h.handleAudioUpload(c)
+}
+
+// AudioSpeech handles the /v1/audio/speech endpoint and returns the synthesized audio.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) AudioSpeech(c *gin.Context) {
+	rawJSON, err := handlers.ReadRequestBody(c)
+	if err != nil {
+		writeAudioInvalidRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if errMsg := validateSpeechRequest(rawJSON); errMsg != "" {
+		writeAudioInvalidRequest(c, errMsg)
+		return
+	}
+
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	h.executeAudio(c, modelName, rawJSON, speechContentType(gjson.GetBytes(rawJSON, "response_format").String()))
+}
+
+func (h *OpenAIAPIHandler) handleAudioUpload(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		writeAudioInvalidRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	modelName := strings.TrimSpace(c.PostForm("model"))
+	if modelName == "" {
+		writeAudioInvalidRequest(c, "Invalid request: model is required")
+		return
+	}
+	if files := form.File["file"]; len(files) == 0 || files[0] == nil {
+		writeAudioInvalidRequest(c, "Invalid request: file is required")
+		return
+	}
+	if parseBoolField(c.PostForm("stream"), false) {
+		writeAudioInvalidRequest(c, "Invalid request: streaming transcriptions are not supported")
+		return
+	}
+
+	body, contentType, errBuild := buildAudioMultipartRequest(form, modelName)
+	if errBuild != nil {
+		writeAudioInvalidRequest(c, fmt.Sprintf("Invalid request: %v", errBuild))
+		return
+	}
+	c.Request.Header.Set("Content-Type", contentType)
+	h.executeAudio(c, modelName, body, transcriptionContentType(c.PostForm("response_format")))
+}
+
+// buildAudioMultipartRequest re-encodes a transcription or translation upload with the
+// requested model; the stream flag is dropped since audio uploads are served buffered.
+func buildAudioMultipartRequest(form *multipart.Form, modelName string) ([]byte, string, error) {
+	return rebuildMultipartForm(form, []multipartField{{key: "model", value: modelName}}, "stream")
+}
+
+// executeAudio runs an audio request and writes the upstream body with responseContentType.
+func (h *OpenAIAPIHandler) executeAudio(c *gin.Context, modelName string, payload []byte, responseContentType string) {
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, constant.OpenAIAudio, modelName, payload, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	c.Header("Content-Type", responseContentType)
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+func writeAudioInvalidRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
+
+func validateSpeechRequest(rawJSON []byte) string {
+	if !gjson.ValidBytes(rawJSON) {
+		return "Invalid request: body must be valid JSON"
+	}
+	if strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String()) == "" {
+		return "Invalid request: model is required"
+	}
+	if strings.TrimSpace(gjson.GetBytes(rawJSON, "input").String()) == "" {
+		return "Invalid request: input is required"
+	}
+	return ""
+}
+
+// transcriptionContentType maps an OpenAI transcription response_format to its media type.
+func transcriptionContentType(responseFormat string) string {
+	switch strings.ToLower(strings.TrimSpace(responseFormat)) {
+	case "text", "srt":
+		return "text/plain; charset=utf-8"
+	case "vtt":
+		return "text/vtt; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// speechContentType maps an OpenAI speech response_format to its media type.
+func speechContentType(responseFormat string) string {
+	switch strings.ToLower(strings.TrimSpace(responseFormat)) {
+	case "opus":
+		return "audio/ogg"
+	case "aac":
+		return "audio/aac"
+	case "flac":
+		return "audio/flac"
+	case "wav":
+		return "audio/wav"
+	case "pcm":
+		return "audio/pcm"
+	default:
+		return "audio/mpeg"
+	}
+}
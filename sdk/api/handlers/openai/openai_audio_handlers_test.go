@@ -0,0 +1,112 @@
+package openai
+
+import (
+	"bytes"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestAudioTranscriptionsRequiresModelAndFile(t *testing.T) {
+	handler := &OpenAIAPIHandler{}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("model", "whisper-1"); err != nil {
+		t.Fatalf("write model field: %v", err)
+	}
+	if errClose := writer.Close(); errClose != nil {
+		t.Fatalf("close multipart writer: %v", errClose)
+	}
+
+	resp := performImagesEndpointRequest(t, "/v1/audio/transcriptions", writer.FormDataContentType(), &body, handler.AudioTranscriptions)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+	if message := gjson.GetBytes(resp.Body.Bytes(), "error.message").String(); message != "Invalid request: file is required" {
+		t.Fatalf("error message = %q", message)
+	}
+}
+
+func TestAudioSpeechRequiresInput(t *testing.T) {
+	handler := &OpenAIAPIHandler{}
+	body := strings.NewReader(`{"model":"tts-1","voice":"alloy"}`)
+
+	resp := performImagesEndpointRequest(t, "/v1/audio/speech", "application/json", body, handler.AudioSpeech)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+	if message := gjson.GetBytes(resp.Body.Bytes(), "error.message").String(); message != "Invalid request: input is required" {
+		t.Fatalf("error message = %q", message)
+	}
+}
+
+func TestAudioContentTypes(t *testing.T) {
+	if got := speechContentType("opus"); got != "audio/ogg" {
+		t.Fatalf("speechContentType(opus) = %q", got)
+	}
+	if got := speechContentType(""); got != "audio/mpeg" {
+		t.Fatalf("speechContentType(default) = %q", got)
+	}
+	if got := transcriptionContentType("vtt"); got != "text/vtt; charset=utf-8" {
+		t.Fatalf("transcriptionContentType(vtt) = %q", got)
+	}
+	if got := transcriptionContentType("verbose_json"); got != "application/json" {
+		t.Fatalf("transcriptionContentType(verbose_json) = %q", got)
+	}
+}
+
+func TestBuildAudioMultipartRequestKeepsUploadFields(t *testing.T) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range map[string]string{"model": "whisper-alias", "language": "de", "stream": "false"} {
+		if err := writer.WriteField(key, value); err != nil {
+			t.Fatalf("write %s field: %v", key, err)
+		}
+	}
+	part, errCreate := writer.CreateFormFile("file", "clip.mp3")
+	if errCreate != nil {
+		t.Fatalf("create file field: %v", errCreate)
+	}
+	if _, errWrite := part.Write([]byte("ID3audio")); errWrite != nil {
+		t.Fatalf("write file field: %v", errWrite)
+	}
+	if errClose := writer.Close(); errClose != nil {
+		t.Fatalf("close multipart writer: %v", errClose)
+	}
+	form, errRead := multipart.NewReader(&body, writer.Boundary()).ReadForm(32 << 20)
+	if errRead != nil {
+		t.Fatalf("read source form: %v", errRead)
+	}
+	defer func() { _ = form.RemoveAll() }()
+
+	out, contentType, errBuild := buildAudioMultipartRequest(form, "whisper-1")
+	if errBuild != nil {
+		t.Fatalf("buildAudioMultipartRequest error: %v", errBuild)
+	}
+	_, params, errParse := mime.ParseMediaType(contentType)
+	if errParse != nil {
+		t.Fatalf("parse content type: %v", errParse)
+	}
+	rebuilt, errRead := multipart.NewReader(bytes.NewReader(out), params["boundary"]).ReadForm(32 << 20)
+	if errRead != nil {
+		t.Fatalf("read rebuilt form: %v", errRead)
+	}
+	defer func() { _ = rebuilt.RemoveAll() }()
+	if got := rebuilt.Value["model"]; len(got) != 1 || got[0] != "whisper-1" {
+		t.Fatalf("model values = %#v, want whisper-1", got)
+	}
+	if got := rebuilt.Value["language"]; len(got) != 1 || got[0] != "de" {
+		t.Fatalf("language values = %#v, want de", got)
+	}
+	if _, ok := rebuilt.Value["stream"]; ok {
+		t.Fatal("stream field should be dropped from audio uploads")
+	}
+	if got := rebuilt.File["file"]; len(got) != 1 || got[0].Filename != "clip.mp3" {
+		t.Fatalf("file headers = %#v, want clip.mp3", got)
+	}
+}
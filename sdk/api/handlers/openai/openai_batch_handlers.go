@@ -0,0 +1,261 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+)
+
+const (
+	defaultBatchListLimit = 20
+	maxBatchListLimit     = 100
+	defaultFileListLimit  = 10000
+)
+
+// FilesUpload handles POST /v1/files. Only purpose "batch" uploads are accepted.
+func (h *OpenAIAPIHandler) FilesUpload(c *gin.Context) {
+	purpose := strings.TrimSpace(c.PostForm("purpose"))
+	if purpose != "batch" {
+		writeBatchInvalidRequest(c, `Invalid request: purpose must be "batch"`)
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		writeBatchInvalidRequest(c, "Invalid request: file is required")
+		return
+	}
+	content, err := header.Open()
+	if err != nil {
+		writeBatchInvalidRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	defer func() { _ = content.Close() }()
+
+	file, err := batch.Default().CreateFile(c.GetString("userApiKey"), header.Filename, purpose, content)
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, file.OpenAIView())
+}
+
+// FilesList handles GET /v1/files.
+func (h *OpenAIAPIHandler) FilesList(c *gin.Context) {
+	files, err := batch.Default().ListFiles(c.GetString("userApiKey"), strings.TrimSpace(c.Query("purpose")))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	if strings.EqualFold(c.Query("order"), "asc") {
+		for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+			files[i], files[j] = files[j], files[i]
+		}
+	}
+	ids := make([]string, len(files))
+	for i := range files {
+		ids[i] = files[i].ID
+	}
+	start, end, hasMore := batchListWindow(c, ids, defaultFileListLimit, defaultFileListLimit)
+	data := make([]map[string]any, 0, end-start)
+	for i := start; i < end; i++ {
+		data = append(data, files[i].OpenAIView())
+	}
+	writeBatchList(c, data, ids[start:end], hasMore)
+}
+
+// FilesRetrieve handles GET /v1/files/{id}.
+func (h *OpenAIAPIHandler) FilesRetrieve(c *gin.Context) {
+	file, err := batch.Default().GetFile(c.Param("id"), c.GetString("userApiKey"))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, file.OpenAIView())
+}
+
+// FilesDelete handles DELETE /v1/files/{id}.
+func (h *OpenAIAPIHandler) FilesDelete(c *gin.Context) {
+	id := c.Param("id")
+	if err := batch.Default().DeleteFile(id, c.GetString("userApiKey")); err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "file", "deleted": true})
+}
+
+// FilesContent handles GET /v1/files/{id}/content.
+func (h *OpenAIAPIHandler) FilesContent(c *gin.Context) {
+	content, file, err := batch.Default().OpenFile(c.Param("id"), c.GetString("userApiKey"))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	defer func() { _ = content.Close() }()
+	c.DataFromReader(http.StatusOK, file.Bytes, "application/jsonl", content, nil)
+}
+
+// BatchesCreate handles POST /v1/batches. The input file is validated up front, including
+// the client's model restrictions, and the job is then run in the background.
+func (h *OpenAIAPIHandler) BatchesCreate(c *gin.Context) {
+	rawJSON, err := handlers.ReadRequestBody(c)
+	if err != nil {
+		writeBatchInvalidRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	var body struct {
+		InputFileID      string            `json:"input_file_id"`
+		Endpoint         string            `json:"endpoint"`
+		CompletionWindow string            `json:"completion_window"`
+		Metadata         map[string]string `json:"metadata"`
+	}
+	if err = json.Unmarshal(rawJSON, &body); err != nil {
+		writeBatchInvalidRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if strings.TrimSpace(body.InputFileID) == "" {
+		writeBatchInvalidRequest(c, "Invalid request: input_file_id is required")
+		return
+	}
+	if !batch.SupportedEndpoint(body.Endpoint) {
+		writeBatchInvalidRequest(c, fmt.Sprintf("Invalid request: unsupported endpoint %q", body.Endpoint))
+		return
+	}
+
+	job, err := batch.Default().CreateJob(batch.JobOptions{
+		Kind:             batch.KindOpenAI,
+		Owner:            c.GetString("userApiKey"),
+		Principal:        batch.PrincipalFromContext(c),
+		Endpoint:         body.Endpoint,
+		InputFileID:      body.InputFileID,
+		CompletionWindow: body.CompletionWindow,
+		Metadata:         body.Metadata,
+		CheckModel:       h.batchModelCheck(c),
+	})
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job.OpenAIView())
+}
+
+// BatchesList handles GET /v1/batches.
+func (h *OpenAIAPIHandler) BatchesList(c *gin.Context) {
+	jobs, err := batch.Default().ListJobs(c.GetString("userApiKey"), batch.KindOpenAI)
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	ids := make([]string, len(jobs))
+	for i := range jobs {
+		ids[i] = jobs[i].ID
+	}
+	start, end, hasMore := batchListWindow(c, ids, defaultBatchListLimit, maxBatchListLimit)
+	data := make([]map[string]any, 0, end-start)
+	for i := start; i < end; i++ {
+		data = append(data, jobs[i].OpenAIView())
+	}
+	writeBatchList(c, data, ids[start:end], hasMore)
+}
+
+// BatchesRetrieve handles GET /v1/batches/{id}.
+func (h *OpenAIAPIHandler) BatchesRetrieve(c *gin.Context) {
+	job, err := batch.Default().GetJob(c.Param("id"), c.GetString("userApiKey"), batch.KindOpenAI)
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job.OpenAIView())
+}
+
+// BatchesCancel handles POST /v1/batches/{id}/cancel.
+func (h *OpenAIAPIHandler) BatchesCancel(c *gin.Context) {
+	job, err := batch.Default().CancelJob(c.Param("id"), c.GetString("userApiKey"), batch.KindOpenAI)
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job.OpenAIView())
+}
+
+// batchModelCheck validates batch models against the calling client's policy.
+func (h *OpenAIAPIHandler) batchModelCheck(c *gin.Context) func(string) error {
+	ctx := context.WithValue(context.Background(), "gin", c)
+	return func(model string) error {
+		if errMsg := h.CheckModelAccess(ctx, model); errMsg != nil && errMsg.Error != nil {
+			return errMsg.Error
+		}
+		return nil
+	}
+}
+
+// batchListWindow applies the after and limit query parameters to ids.
+func batchListWindow(c *gin.Context, ids []string, defaultLimit, maxLimit int) (int, int, bool) {
+	start := 0
+	if after := strings.TrimSpace(c.Query("after")); after != "" {
+		for i, id := range ids {
+			if id == after {
+				start = i + 1
+				break
+			}
+		}
+	}
+	limit := defaultLimit
+	if value, err := strconv.Atoi(c.Query("limit")); err == nil && value > 0 {
+		limit = min(value, maxLimit)
+	}
+	end := min(start+limit, len(ids))
+	return start, end, end < len(ids)
+}
+
+func writeBatchList(c *gin.Context, data []map[string]any, ids []string, hasMore bool) {
+	var firstID, lastID any
+	if len(ids) > 0 {
+		firstID, lastID = ids[0], ids[len(ids)-1]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"object":   "list",
+		"data":     data,
+		"first_id": firstID,
+		"last_id":  lastID,
+		"has_more": hasMore,
+	})
+}
+
+func writeBatchInvalidRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
+
+// writeBatchError maps batch runner errors onto OpenAI error responses.
+func writeBatchError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	errType := "server_error"
+	switch {
+	case errors.Is(err, batch.ErrDisabled), errors.Is(err, batch.ErrNotFound):
+		status, errType = http.StatusNotFound, "invalid_request_error"
+	case errors.Is(err, batch.ErrInvalidRequest):
+		status, errType = http.StatusBadRequest, "invalid_request_error"
+	case errors.Is(err, batch.ErrFileTooLarge):
+		status, errType = http.StatusRequestEntityTooLarge, "invalid_request_error"
+	case errors.Is(err, batch.ErrConflict):
+		status, errType = http.StatusConflict, "invalid_request_error"
+	}
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: err.Error(),
+			Type:    errType,
+		},
+	})
+}
@@ -0,0 +1,98 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestOpenAIBatchEndpointsRunUploadedFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executor := &compactCaptureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "batch-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	if err := batch.Default().Configure(t.TempDir(), 1, 1<<20); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	batch.Default().SetExecutor(base)
+	t.Cleanup(batch.Default().Disable)
+
+	h := NewOpenAIAPIHandler(base)
+	router := gin.New()
+	router.POST("/v1/files", h.FilesUpload)
+	router.GET("/v1/files/:id/content", h.FilesContent)
+	router.POST("/v1/batches", h.BatchesCreate)
+	router.GET("/v1/batches/:id", h.BatchesRetrieve)
+	serve := func(method, path, contentType string, body *bytes.Buffer) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, body)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	var upload bytes.Buffer
+	writer := multipart.NewWriter(&upload)
+	_ = writer.WriteField("purpose", "batch")
+	part, _ := writer.CreateFormFile("file", "input.jsonl")
+	_, _ = part.Write([]byte(`{"custom_id":"req-1","method":"POST","url":"/v1/chat/completions","body":{"model":"test-model","messages":[{"role":"user","content":"hi"}]}}` + "\n"))
+	_ = writer.Close()
+	fileResp := serve(http.MethodPost, "/v1/files", writer.FormDataContentType(), &upload)
+	if fileResp.Code != http.StatusOK {
+		t.Fatalf("upload status = %d body=%s", fileResp.Code, fileResp.Body.String())
+	}
+	fileID := gjson.Get(fileResp.Body.String(), "id").String()
+
+	createResp := serve(http.MethodPost, "/v1/batches", "application/json", bytes.NewBufferString(`{"input_file_id":"`+fileID+`","endpoint":"/v1/chat/completions","completion_window":"24h"}`))
+	if createResp.Code != http.StatusOK {
+		t.Fatalf("create status = %d body=%s", createResp.Code, createResp.Body.String())
+	}
+	batchID := gjson.Get(createResp.Body.String(), "id").String()
+
+	var status, outputID string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		body := serve(http.MethodGet, "/v1/batches/"+batchID, "", &bytes.Buffer{}).Body.String()
+		status = gjson.Get(body, "status").String()
+		outputID = gjson.Get(body, "output_file_id").String()
+		if status == batch.StatusCompleted {
+			break
+		}
+	}
+	if status != batch.StatusCompleted {
+		t.Fatalf("batch status = %q, want completed", status)
+	}
+
+	content := serve(http.MethodGet, "/v1/files/"+outputID+"/content", "", &bytes.Buffer{})
+	line := strings.TrimSpace(content.Body.String())
+	if gjson.Get(line, "custom_id").String() != "req-1" || gjson.Get(line, "response.status_code").Int() != http.StatusOK || !gjson.Get(line, "response.body.ok").Bool() {
+		t.Fatalf("unexpected output line: %s", line)
+	}
+
+	bad := serve(http.MethodPost, "/v1/batches", "application/json", bytes.NewBufferString(`{"input_file_id":"`+fileID+`","endpoint":"/v1/moderations"}`))
+	if bad.Code != http.StatusBadRequest {
+		t.Fatalf("unsupported endpoint status = %d, want 400", bad.Code)
+	}
+}
@@ -0,0 +1,74 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// Embeddings handles the /v1/embeddings endpoint.
+// The request is routed through the auth manager like chat completions; the selected
+// provider executor translates it into its native embeddings API.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) Embeddings(c *gin.Context) {
+	rawJSON, err := handlers.ReadRequestBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	if errMsg := validateEmbeddingsRequest(rawJSON); errMsg != "" {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: errMsg,
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, constant.OpenAIEmbedding, modelName, rawJSON, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+func validateEmbeddingsRequest(rawJSON []byte) string {
+	if !gjson.ValidBytes(rawJSON) {
+		return "Invalid request: body must be valid JSON"
+	}
+	if strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String()) == "" {
+		return "Invalid request: model is required"
+	}
+	input := gjson.GetBytes(rawJSON, "input")
+	switch {
+	case !input.Exists():
+		return "Invalid request: input is required"
+	case input.Type == gjson.String:
+		return ""
+	case input.IsArray() && len(input.Array()) > 0:
+		return ""
+	default:
+		return "Invalid request: input must be a string or a non-empty array"
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
-	"net/textproto"
 	"strconv"
 	"strings"
 	"time"
@@ -404,75 +403,12 @@ func buildOpenAICompatImagesJSONRequest(rawJSON []byte, imageModel string, strea
 	return payload
 }
 
-func cloneMIMEHeader(src textproto.MIMEHeader) textproto.MIMEHeader {
-	dst := make(textproto.MIMEHeader, len(src))
-	for key, values := range src {
-		dst[key] = append([]string(nil), values...)
-	}
-	return dst
-}
-
 func buildOpenAICompatImagesMultipartRequest(form *multipart.Form, imageModel string, stream bool) ([]byte, string, error) {
-	if form == nil {
-		return nil, "", fmt.Errorf("multipart form is nil")
-	}
-	var body bytes.Buffer
-	writer := multipart.NewWriter(&body)
-
-	if errWrite := writer.WriteField("model", imageModel); errWrite != nil {
-		return nil, "", fmt.Errorf("write model field failed: %w", errWrite)
-	}
+	fields := []multipartField{{key: "model", value: imageModel}}
 	if stream {
-		if errWrite := writer.WriteField("stream", "true"); errWrite != nil {
-			return nil, "", fmt.Errorf("write stream field failed: %w", errWrite)
-		}
-	}
-	for key, values := range form.Value {
-		if key == "model" || key == "stream" {
-			continue
-		}
-		for _, value := range values {
-			if errWrite := writer.WriteField(key, value); errWrite != nil {
-				return nil, "", fmt.Errorf("write form field %s failed: %w", key, errWrite)
-			}
-		}
-	}
-
-	for key, files := range form.File {
-		for _, fileHeader := range files {
-			if fileHeader == nil {
-				continue
-			}
-			header := cloneMIMEHeader(fileHeader.Header)
-			header.Set("Content-Disposition", multipart.FileContentDisposition(key, fileHeader.Filename))
-			if header.Get("Content-Type") == "" {
-				header.Set("Content-Type", "application/octet-stream")
-			}
-			part, errCreate := writer.CreatePart(header)
-			if errCreate != nil {
-				return nil, "", fmt.Errorf("create file field %s failed: %w", key, errCreate)
-			}
-			src, errOpen := fileHeader.Open()
-			if errOpen != nil {
-				return nil, "", fmt.Errorf("open upload file failed: %w", errOpen)
-			}
-			_, errCopy := io.Copy(part, src)
-			if errClose := src.Close(); errClose != nil {
-				log.Errorf("openai images: close upload file error: %v", errClose)
-				if errCopy == nil {
-					errCopy = errClose
-				}
-			}
-			if errCopy != nil {
-				return nil, "", fmt.Errorf("copy upload file failed: %w", errCopy)
-			}
-		}
-	}
-
-	if errClose := writer.Close(); errClose != nil {
-		return nil, "", fmt.Errorf("close multipart writer failed: %w", errClose)
+		fields = append(fields, multipartField{key: "stream", value: "true"})
 	}
-	return body.Bytes(), writer.FormDataContentType(), nil
+	return rebuildMultipartForm(form, fields, "stream")
 }
 
 func parseIntField(raw string, fallback int64) int64 {
@@ -0,0 +1,93 @@
+package openai
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// multipartField is a form field written ahead of the fields copied from the client upload.
+type multipartField struct {
+	key   string
+	value string
+}
+
+// rebuildMultipartForm re-encodes a parsed client upload for an upstream request. fields are
+// written first and replace the client values of the same keys; keys listed in drop are left
+// out. Every other value and file is copied unchanged.
+func rebuildMultipartForm(form *multipart.Form, fields []multipartField, drop ...string) ([]byte, string, error) {
+	if form == nil {
+		return nil, "", fmt.Errorf("multipart form is nil")
+	}
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	skip := make(map[string]struct{}, len(fields)+len(drop))
+	for _, field := range fields {
+		if errWrite := writer.WriteField(field.key, field.value); errWrite != nil {
+			return nil, "", fmt.Errorf("write %s field failed: %w", field.key, errWrite)
+		}
+		skip[field.key] = struct{}{}
+	}
+	for _, key := range drop {
+		skip[key] = struct{}{}
+	}
+	for key, values := range form.Value {
+		if _, ok := skip[key]; ok {
+			continue
+		}
+		for _, value := range values {
+			if errWrite := writer.WriteField(key, value); errWrite != nil {
+				return nil, "", fmt.Errorf("write form field %s failed: %w", key, errWrite)
+			}
+		}
+	}
+
+	for key, files := range form.File {
+		for _, fileHeader := range files {
+			if fileHeader == nil {
+				continue
+			}
+			header := cloneMIMEHeader(fileHeader.Header)
+			header.Set("Content-Disposition", multipart.FileContentDisposition(key, fileHeader.Filename))
+			if header.Get("Content-Type") == "" {
+				header.Set("Content-Type", "application/octet-stream")
+			}
+			part, errCreate := writer.CreatePart(header)
+			if errCreate != nil {
+				return nil, "", fmt.Errorf("create file field %s failed: %w", key, errCreate)
+			}
+			src, errOpen := fileHeader.Open()
+			if errOpen != nil {
+				return nil, "", fmt.Errorf("open upload file failed: %w", errOpen)
+			}
+			_, errCopy := io.Copy(part, src)
+			if errClose := src.Close(); errClose != nil {
+				log.Errorf("openai multipart: close upload file error: %v", errClose)
+				if errCopy == nil {
+					errCopy = errClose
+				}
+			}
+			if errCopy != nil {
+				return nil, "", fmt.Errorf("copy upload file failed: %w", errCopy)
+			}
+		}
+	}
+
+	if errClose := writer.Close(); errClose != nil {
+		return nil, "", fmt.Errorf("close multipart writer failed: %w", errClose)
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+func cloneMIMEHeader(src textproto.MIMEHeader) textproto.MIMEHeader {
+	dst := make(textproto.MIMEHeader, len(src))
+	for key, values := range src {
+		dst[key] = append([]string(nil), values...)
+	}
+	return dst
+}
@@ -0,0 +1,262 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsestore"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultInputItemsLimit = 20
+	maxInputItemsLimit     = 100
+)
+
+// storedConversation carries what is needed to record a response once it completes.
+type storedConversation struct {
+	owner              string
+	model              string
+	previousResponseID string
+	input              string
+	store              bool
+}
+
+// save records the completed response unless the request opted out with store: false.
+func (s *storedConversation) save(response []byte) {
+	if s == nil || !s.store {
+		return
+	}
+	responseID := strings.TrimSpace(gjson.GetBytes(response, "id").String())
+	if responseID == "" {
+		return
+	}
+	if status := gjson.GetBytes(response, "status").String(); status != "" && status != "completed" && status != "incomplete" {
+		return
+	}
+	model := gjson.GetBytes(response, "model").String()
+	if model == "" {
+		model = s.model
+	}
+	responsestore.Default().Save(responsestore.Record{
+		ID:                 responseID,
+		Model:              model,
+		PreviousResponseID: s.previousResponseID,
+		Input:              json.RawMessage(s.input),
+		Output:             json.RawMessage(normalizeJSONArrayRaw([]byte(gjson.GetBytes(response, "output").Raw))),
+		Response:           json.RawMessage(response),
+	}, s.owner)
+}
+
+// prepareStoredConversation expands previous_response_id into the full input transcript so
+// every backend receives a stateless request, and returns the state needed to store the
+// response. Only the input items of this request are stored; earlier turns are rebuilt from
+// the chain of previous responses. It returns a nil conversation when the store is disabled,
+// leaving the request untouched. Instructions are not carried over, matching the OpenAI
+// semantics.
+func prepareStoredConversation(c *gin.Context, rawJSON []byte) ([]byte, *storedConversation, bool) {
+	store := responsestore.Default()
+	if !store.Enabled() {
+		return rawJSON, nil, true
+	}
+	conversation := &storedConversation{
+		owner: c.GetString("userApiKey"),
+		model: gjson.GetBytes(rawJSON, "model").String(),
+		store: gjson.GetBytes(rawJSON, "store").Type != gjson.False,
+		input: responsesInputItems(gjson.GetBytes(rawJSON, "input")),
+	}
+
+	previousID := strings.TrimSpace(gjson.GetBytes(rawJSON, "previous_response_id").String())
+	if previousID == "" {
+		return rawJSON, conversation, true
+	}
+	chain, missing := store.Conversation(previousID, conversation.owner)
+	if missing != "" {
+		writeResponseNotFound(c, missing, true)
+		return nil, nil, false
+	}
+
+	merged, err := conversationTranscript(chain, conversation.input)
+	if err != nil {
+		writeStoredResponseError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), "")
+		return nil, nil, false
+	}
+
+	expanded, _ := sjson.DeleteBytes(rawJSON, "previous_response_id")
+	expanded, err = sjson.SetRawBytes(expanded, "input", []byte(merged))
+	if err != nil {
+		writeStoredResponseError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), "")
+		return nil, nil, false
+	}
+	if previous := chain[len(chain)-1]; conversation.model == "" && previous.Model != "" {
+		conversation.model = previous.Model
+		expanded, _ = sjson.SetBytes(expanded, "model", previous.Model)
+	}
+	conversation.previousResponseID = previousID
+	return expanded, conversation, true
+}
+
+// conversationTranscript joins the input and output items of records, oldest first, and then
+// input, dropping function calls repeated under the same call ID.
+func conversationTranscript(records []responsestore.Record, input string) (string, error) {
+	merged := "[]"
+	var err error
+	for _, record := range records {
+		if merged, err = mergeJSONArrayRaw(merged, normalizeJSONArrayRaw(record.Input)); err != nil {
+			return "", err
+		}
+		if merged, err = mergeJSONArrayRaw(merged, normalizeJSONArrayRaw(record.Output)); err != nil {
+			return "", err
+		}
+	}
+	if merged, err = mergeJSONArrayRaw(merged, input); err != nil {
+		return "", err
+	}
+	if deduped, errDedupe := dedupeFunctionCallsByCallID(merged); errDedupe == nil {
+		merged = deduped
+	}
+	return merged, nil
+}
+
+// responsesInputItems returns input as an item array, wrapping a plain string as a user message.
+func responsesInputItems(input gjson.Result) string {
+	switch {
+	case !input.Exists() || input.Type == gjson.Null:
+		return "[]"
+	case input.Type == gjson.String:
+		item := []byte(`{"type":"message","role":"user","content":[{"type":"input_text","text":""}]}`)
+		item, _ = sjson.SetBytes(item, "content.0.text", input.String())
+		return "[" + string(item) + "]"
+	default:
+		return normalizeJSONArrayRaw([]byte(input.Raw))
+	}
+}
+
+// GetResponse handles GET /v1/responses/{id} and returns a stored response.
+func (h *OpenAIResponsesAPIHandler) GetResponse(c *gin.Context) {
+	record, ok := h.lookupStoredResponse(c)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, "application/json", record.Response)
+}
+
+// DeleteResponse handles DELETE /v1/responses/{id}.
+func (h *OpenAIResponsesAPIHandler) DeleteResponse(c *gin.Context) {
+	if !responsestore.Default().Enabled() {
+		writeResponsesStoreDisabled(c)
+		return
+	}
+	responseID := strings.TrimSpace(c.Param("id"))
+	if !responsestore.Default().Delete(responseID, c.GetString("userApiKey")) {
+		writeResponseNotFound(c, responseID, false)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": responseID, "object": "response.deleted", "deleted": true})
+}
+
+// ListResponseInputItems handles GET /v1/responses/{id}/input_items. The list covers the full
+// transcript sent upstream, including turns inherited through previous_response_id, and
+// supports the order, limit and after query parameters.
+func (h *OpenAIResponsesAPIHandler) ListResponseInputItems(c *gin.Context) {
+	record, ok := h.lookupStoredResponse(c)
+	if !ok {
+		return
+	}
+	// Earlier turns that are gone from the store are left out of the listing.
+	chain, _ := responsestore.Default().Conversation(record.PreviousResponseID, c.GetString("userApiKey"))
+	transcript, err := conversationTranscript(chain, normalizeJSONArrayRaw(record.Input))
+	if err != nil {
+		transcript = normalizeJSONArrayRaw(record.Input)
+	}
+	items := gjson.Parse(transcript).Array()
+	raw := make([]string, 0, len(items))
+	ids := make([]string, 0, len(items))
+	for i, item := range items {
+		itemRaw := item.Raw
+		id := item.Get("id").String()
+		if id == "" {
+			id = fmt.Sprintf("in_%s_%d", record.ID, i)
+			if updated, err := sjson.Set(itemRaw, "id", id); err == nil {
+				itemRaw = updated
+			}
+		}
+		raw = append(raw, itemRaw)
+		ids = append(ids, id)
+	}
+	if !strings.EqualFold(c.DefaultQuery("order", "desc"), "asc") {
+		for i, j := 0, len(raw)-1; i < j; i, j = i+1, j-1 {
+			raw[i], raw[j] = raw[j], raw[i]
+			ids[i], ids[j] = ids[j], ids[i]
+		}
+	}
+	if after := strings.TrimSpace(c.Query("after")); after != "" {
+		for i, id := range ids {
+			if id == after {
+				raw, ids = raw[i+1:], ids[i+1:]
+				break
+			}
+		}
+	}
+	limit := defaultInputItemsLimit
+	if value, err := strconv.Atoi(c.Query("limit")); err == nil && value > 0 {
+		limit = min(value, maxInputItemsLimit)
+	}
+	hasMore := len(raw) > limit
+	if hasMore {
+		raw, ids = raw[:limit], ids[:limit]
+	}
+
+	out := []byte(`{"object":"list","data":[],"first_id":null,"last_id":null,"has_more":false}`)
+	out, _ = sjson.SetRawBytes(out, "data", []byte("["+strings.Join(raw, ",")+"]"))
+	if len(ids) > 0 {
+		out, _ = sjson.SetBytes(out, "first_id", ids[0])
+		out, _ = sjson.SetBytes(out, "last_id", ids[len(ids)-1])
+	}
+	out, _ = sjson.SetBytes(out, "has_more", hasMore)
+	c.Data(http.StatusOK, "application/json", out)
+}
+
+func (h *OpenAIResponsesAPIHandler) lookupStoredResponse(c *gin.Context) (responsestore.Record, bool) {
+	if !responsestore.Default().Enabled() {
+		writeResponsesStoreDisabled(c)
+		return responsestore.Record{}, false
+	}
+	responseID := strings.TrimSpace(c.Param("id"))
+	record, ok := responsestore.Default().Get(responseID, c.GetString("userApiKey"))
+	if !ok {
+		writeResponseNotFound(c, responseID, false)
+		return responsestore.Record{}, false
+	}
+	return record, true
+}
+
+func writeResponsesStoreDisabled(c *gin.Context) {
+	writeStoredResponseError(c, http.StatusNotFound, "Stored responses are not enabled on this server", "responses_store_disabled")
+}
+
+func writeResponseNotFound(c *gin.Context, responseID string, previous bool) {
+	log.Debugf("responses store: response %s not found", responseID)
+	if previous {
+		writeStoredResponseError(c, http.StatusNotFound, fmt.Sprintf("Previous response with id '%s' not found.", responseID), "previous_response_not_found")
+		return
+	}
+	writeStoredResponseError(c, http.StatusNotFound, fmt.Sprintf("Response with id '%s' not found.", responseID), "response_not_found")
+}
+
+func writeStoredResponseError(c *gin.Context, status int, message, code string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+			Code:    code,
+		},
+	})
+}
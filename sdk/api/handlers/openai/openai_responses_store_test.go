@@ -0,0 +1,163 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsestore"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type storedResponsesExecutor struct {
+	payloads [][]byte
+}
+
+func (e *storedResponsesExecutor) Identifier() string { return "test-provider" }
+
+func (e *storedResponsesExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.payloads = append(e.payloads, req.Payload)
+	id := "resp_" + string(rune('a'+len(e.payloads)-1))
+	return coreexecutor.Response{Payload: []byte(`{"id":"` + id + `","object":"response","status":"completed","model":"test-model","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"reply ` + id + `"}]}]}`)}, nil
+}
+
+func (e *storedResponsesExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *storedResponsesExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *storedResponsesExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *storedResponsesExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newStoredResponsesRouter(t *testing.T, authID string) (*gin.Engine, *storedResponsesExecutor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	executor := &storedResponsesExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: authID, Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-model"}})
+
+	store := responsestore.Default()
+	previous := store.Configure(responsestore.NewMemoryBackend(10), time.Hour)
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+		store.Configure(previous, time.Hour)
+	})
+
+	h := NewOpenAIResponsesAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userApiKey", c.GetHeader("Authorization"))
+	})
+	router.POST("/v1/responses", h.Responses)
+	router.GET("/v1/responses/:id", h.GetResponse)
+	router.DELETE("/v1/responses/:id", h.DeleteResponse)
+	router.GET("/v1/responses/:id/input_items", h.ListResponseInputItems)
+	return router, executor
+}
+
+func serveStoredResponses(router *gin.Engine, method, path, body, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", key)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestResponsesExpandsPreviousResponseID(t *testing.T) {
+	router, executor := newStoredResponsesRouter(t, "stored-auth-1")
+
+	first := serveStoredResponses(router, http.MethodPost, "/v1/responses", `{"model":"test-model","input":"hello"}`, "key-a")
+	if first.Code != http.StatusOK {
+		t.Fatalf("first status = %d body=%s", first.Code, first.Body.String())
+	}
+	second := serveStoredResponses(router, http.MethodPost, "/v1/responses", `{"previous_response_id":"resp_a","input":[{"type":"message","role":"user","content":"again"}]}`, "key-a")
+	if second.Code != http.StatusOK {
+		t.Fatalf("second status = %d body=%s", second.Code, second.Body.String())
+	}
+
+	upstream := executor.payloads[1]
+	if gjson.GetBytes(upstream, "previous_response_id").Exists() {
+		t.Fatalf("previous_response_id should be removed: %s", upstream)
+	}
+	if got := gjson.GetBytes(upstream, "model").String(); got != "test-model" {
+		t.Fatalf("model = %q, want inherited test-model", got)
+	}
+	input := gjson.GetBytes(upstream, "input").Array()
+	if len(input) != 3 {
+		t.Fatalf("input items = %d, want 3: %s", len(input), upstream)
+	}
+	if input[0].Get("content.0.text").String() != "hello" || input[1].Get("role").String() != "assistant" || input[2].Get("content").String() != "again" {
+		t.Fatalf("unexpected expanded input: %s", upstream)
+	}
+
+	items := serveStoredResponses(router, http.MethodGet, "/v1/responses/resp_b/input_items?order=asc&limit=2", "", "key-a")
+	if items.Code != http.StatusOK {
+		t.Fatalf("input_items status = %d body=%s", items.Code, items.Body.String())
+	}
+	if !gjson.Get(items.Body.String(), "has_more").Bool() || len(gjson.Get(items.Body.String(), "data").Array()) != 2 {
+		t.Fatalf("unexpected input_items page: %s", items.Body.String())
+	}
+
+	third := serveStoredResponses(router, http.MethodPost, "/v1/responses", `{"previous_response_id":"resp_b","input":"third"}`, "key-a")
+	if third.Code != http.StatusOK {
+		t.Fatalf("third status = %d body=%s", third.Code, third.Body.String())
+	}
+	if input = gjson.GetBytes(executor.payloads[2], "input").Array(); len(input) != 5 || input[3].Get("content.0.text").String() != "reply resp_b" {
+		t.Fatalf("third turn input = %s, want the whole chain", executor.payloads[2])
+	}
+	record, ok := responsestore.Default().Get("resp_c", "key-a")
+	if !ok || len(gjson.ParseBytes(record.Input).Array()) != 1 || record.PreviousResponseID != "resp_b" {
+		t.Fatalf("stored record = %+v, want only the new input linked to resp_b", record)
+	}
+	items = serveStoredResponses(router, http.MethodGet, "/v1/responses/resp_c/input_items?order=asc", "", "key-a")
+	if data := gjson.Get(items.Body.String(), "data").Array(); len(data) != 5 {
+		t.Fatalf("input_items of resp_c = %s, want the 5 items of the chain", items.Body.String())
+	}
+
+	if resp := serveStoredResponses(router, http.MethodGet, "/v1/responses/resp_a", "", "key-b"); resp.Code != http.StatusNotFound {
+		t.Fatalf("foreign key status = %d, want 404", resp.Code)
+	}
+	if resp := serveStoredResponses(router, http.MethodDelete, "/v1/responses/resp_a", "", "key-a"); resp.Code != http.StatusOK {
+		t.Fatalf("delete status = %d body=%s", resp.Code, resp.Body.String())
+	}
+	missing := serveStoredResponses(router, http.MethodPost, "/v1/responses", `{"previous_response_id":"resp_a","input":"x"}`, "key-a")
+	if missing.Code != http.StatusNotFound || gjson.Get(missing.Body.String(), "error.code").String() != "previous_response_not_found" {
+		t.Fatalf("missing previous status = %d body=%s", missing.Code, missing.Body.String())
+	}
+}
+
+func TestResponsesHonoursStoreFalse(t *testing.T) {
+	router, _ := newStoredResponsesRouter(t, "stored-auth-2")
+
+	resp := serveStoredResponses(router, http.MethodPost, "/v1/responses", `{"model":"test-model","input":"hello","store":false}`, "key-a")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", resp.Code, resp.Body.String())
+	}
+	if got := serveStoredResponses(router, http.MethodGet, "/v1/responses/resp_a", "", "key-a"); got.Code != http.StatusNotFound {
+		t.Fatalf("GET status = %d, want 404 for unstored response", got.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsecache"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// responseCacheHeader reports whether a response was served from the response cache.
+const responseCacheHeader = "X-Cache"
+
+// responseCacheLookup holds the cache key of a cacheable request.
+type responseCacheLookup struct {
+	key         string
+	format      string
+	model       string
+	providers   []string
+	requestedAt time.Time
+}
+
+// prepareResponseCache returns a lookup when the request may be answered from or stored in the
+// response cache. Only deterministic requests are cached, entries are scoped to the client key,
+// and clients can opt out per request with Cache-Control: no-cache or no-store.
+func prepareResponseCache(ctx context.Context, handlerType, model string, providers []string, rawJSON []byte) (*responseCacheLookup, bool) {
+	cache := responsecache.Default()
+	if !cache.Enabled() || !responsecache.Deterministic(rawJSON) {
+		return nil, false
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		directives := strings.ToLower(ginCtx.GetHeader("Cache-Control"))
+		if strings.Contains(directives, "no-cache") || strings.Contains(directives, "no-store") {
+			return nil, false
+		}
+	}
+	var principal string
+	if apiKey, _ := clientAPIKeyFromContext(ctx); apiKey != "" {
+		principal = logging.ClientKeyID(apiKey)
+	}
+	key, ok := responsecache.Key(handlerType, principal, model, rawJSON)
+	if !ok {
+		return nil, false
+	}
+	return &responseCacheLookup{
+		key:         key,
+		format:      handlerType,
+		model:       model,
+		providers:   providers,
+		requestedAt: time.Now(),
+	}, true
+}
+
+// serve returns the cached response and publishes a usage record flagged as a cache hit.
+func (l *responseCacheLookup) serve(ctx context.Context, requestedModel string) ([]byte, http.Header, bool) {
+	entry, ok := responsecache.Default().Lookup(l.key)
+	setResponseCacheHeader(ctx, ok)
+	if !ok {
+		return nil, nil, false
+	}
+	apiKey, _ := clientAPIKeyFromContext(ctx)
+	alias := strings.TrimSpace(requestedModel)
+	if alias == "" {
+		alias = entry.Model
+	}
+	coreusage.PublishRecord(ctx, coreusage.Record{
+		Provider:    entry.Provider,
+		Model:       entry.Model,
+		Alias:       alias,
+		APIKey:      apiKey,
+		RequestedAt: l.requestedAt,
+		Latency:     time.Since(l.requestedAt),
+		Detail:      entry.Usage,
+		CacheHit:    true,
+	})
+	return entry.Payload, entry.Headers, true
+}
+
+// store saves a successful upstream response.
+func (l *responseCacheLookup) store(payload []byte, headers http.Header) {
+	if len(payload) == 0 {
+		return
+	}
+	provider := ""
+	if len(l.providers) > 0 {
+		provider = l.providers[0]
+	}
+	responsecache.Default().Store(responsecache.Entry{
+		Key:      l.key,
+		Format:   l.format,
+		Model:    l.model,
+		Provider: provider,
+		Payload:  append([]byte(nil), payload...),
+		Headers:  headers.Clone(),
+		Usage:    responsecache.ParseUsage(payload),
+	})
+}
+
+func setResponseCacheHeader(ctx context.Context, hit bool) {
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return
+	}
+	if hit {
+		ginCtx.Header(responseCacheHeader, "HIT")
+		return
+	}
+	ginCtx.Header(responseCacheHeader, "MISS")
+}
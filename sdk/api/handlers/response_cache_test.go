@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsecache"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+type countingExecutor struct {
+	calls atomic.Int32
+}
+
+func (e *countingExecutor) Identifier() string { return "cache-test" }
+
+func (e *countingExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	e.calls.Add(1)
+	return coreexecutor.Response{Payload: []byte(`{"id":"x","usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)}, nil
+}
+
+func (e *countingExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *countingExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *countingExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *countingExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestExecuteWithAuthManager_ServesDeterministicRequestsFromResponseCache(t *testing.T) {
+	previous := responsecache.Default().Configure(responsecache.NewMemoryBackend(10), "memory", 0)
+	t.Cleanup(func() { responsecache.Default().Configure(previous, "", 0) })
+
+	executor := &countingExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "cache-auth", Provider: "cache-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "cache-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	deterministic := []byte(`{"model":"cache-model","temperature":0,"messages":[{"role":"user","content":"hi"}]}`)
+	reordered := []byte(`{"messages":[{"content":"hi","role":"user"}],"stream":false,"temperature":0,"model":"cache-model"}`)
+	sampled := []byte(`{"model":"cache-model","temperature":0.7,"messages":[{"role":"user","content":"hi"}]}`)
+
+	for i, payload := range [][]byte{deterministic, reordered, sampled, sampled} {
+		resp, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "cache-model", payload, "")
+		if errMsg != nil {
+			t.Fatalf("request %d: unexpected error %+v", i, errMsg)
+		}
+		if len(resp) == 0 {
+			t.Fatalf("request %d: empty response", i)
+		}
+	}
+	if got := executor.calls.Load(); got != 3 {
+		t.Fatalf("upstream calls = %d, want 3 (one cached hit)", got)
+	}
+	stats := responsecache.Default().Stats()
+	if stats.Hits != 1 || stats.Stores != 1 || stats.Entries != 1 {
+		t.Fatalf("unexpected cache stats %+v", stats)
+	}
+	if entries := responsecache.Default().Entries(); len(entries) != 1 || entries[0].Usage.TotalTokens != 5 || entries[0].Provider != "cache-test" {
+		t.Fatalf("unexpected cache entries %+v", entries)
+	}
+}
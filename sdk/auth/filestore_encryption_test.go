@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestFileTokenStore_EncryptsAuthFiles(t *testing.T) {
+	t.Setenv("TEST_AUTH_KEY", hex.EncodeToString([]byte(strings.Repeat("k", 32))))
+	if err := authcrypt.Configure(config.AuthEncryptionConfig{Enable: true, Key: config.AuthKeySource{Env: "TEST_AUTH_KEY"}}); err != nil {
+		t.Fatalf("configure auth encryption: %v", err)
+	}
+	t.Cleanup(func() { _ = authcrypt.Configure(config.AuthEncryptionConfig{}) })
+
+	ctx := context.Background()
+	baseDir := t.TempDir()
+	legacyPath := filepath.Join(baseDir, "legacy.json")
+	if err := os.WriteFile(legacyPath, []byte(`{"type":"test","email":"legacy@example.com"}`), 0o600); err != nil {
+		t.Fatalf("seed legacy auth file: %v", err)
+	}
+
+	store := NewFileTokenStore()
+	store.SetBaseDir(baseDir)
+	auth := &cliproxyauth.Auth{
+		ID:       "token.json",
+		Provider: "test",
+		FileName: "token.json",
+		Storage:  &testTokenStorage{},
+		Metadata: map[string]any{"type": "test", "refresh_token": "secret"},
+	}
+	path, err := store.Save(ctx, auth)
+	if err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read auth file: %v", err)
+	}
+	if !authcrypt.IsSealed(raw) || strings.Contains(string(raw), "secret") {
+		t.Fatalf("auth file was written in plaintext: %s", raw)
+	}
+
+	auths, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	byID := make(map[string]*cliproxyauth.Auth, len(auths))
+	for _, a := range auths {
+		byID[a.ID] = a
+	}
+	if got := byID["token.json"]; got == nil || got.Metadata["refresh_token"] != "secret" {
+		t.Fatalf("encrypted auth not decrypted on List: %+v", got)
+	}
+	if got := byID["legacy.json"]; got == nil || got.Attributes["email"] != "legacy@example.com" {
+		t.Fatalf("legacy plaintext auth not listed: %+v", got)
+	}
+}
+
+// marshalTokenStorage serializes itself and refuses to write plaintext files.
+type marshalTokenStorage struct {
+	meta map[string]any
+}
+
+func (s *marshalTokenStorage) SetMetadata(meta map[string]any) { s.meta = meta }
+
+func (s *marshalTokenStorage) SaveTokenToFile(path string) error {
+	return fmt.Errorf("unexpected plaintext write to %s", path)
+}
+
+func (s *marshalTokenStorage) MarshalToken() ([]byte, error) { return json.Marshal(s.meta) }
+
+func TestFileTokenStore_SealsTokenStorageBeforeWriting(t *testing.T) {
+	t.Setenv("TEST_AUTH_KEY", hex.EncodeToString([]byte(strings.Repeat("k", 32))))
+	if err := authcrypt.Configure(config.AuthEncryptionConfig{Enable: true, Key: config.AuthKeySource{Env: "TEST_AUTH_KEY"}}); err != nil {
+		t.Fatalf("configure auth encryption: %v", err)
+	}
+	t.Cleanup(func() { _ = authcrypt.Configure(config.AuthEncryptionConfig{}) })
+
+	store := NewFileTokenStore()
+	store.SetBaseDir(t.TempDir())
+	auth := &cliproxyauth.Auth{
+		ID:       "storage.json",
+		Provider: "test",
+		FileName: "storage.json",
+		Storage:  &marshalTokenStorage{},
+		Metadata: map[string]any{"type": "test", "refresh_token": "secret"},
+	}
+	path, err := store.Save(context.Background(), auth)
+	if err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil || !authcrypt.IsSealed(raw) || strings.Contains(string(raw), "secret") {
+		t.Fatalf("auth file = %s, %v; want a sealed envelope", raw, err)
+	}
+}
@@ -0,0 +1,53 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/audit"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyAuditLogConfig points the management audit log at audit.jsonl and, when requested,
+// mirrors entries to the token store when it can persist them (PostgreSQL).
+func (s *Service) applyAuditLogConfig(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	auditLog := audit.Default()
+	if cfg == nil || !cfg.AuditLog.Enable {
+		closeAuditBackend(auditLog.Configure(nil, nil))
+		return
+	}
+
+	var mirror audit.Backend
+	if cfg.AuditLog.Postgres {
+		if backend, ok := sdkAuth.GetTokenStore().(audit.Backend); ok {
+			mirror = backend
+		} else {
+			log.Warn("audit-log.postgres is set but the active token store cannot persist audit entries")
+		}
+	}
+
+	dir := audit.ResolveDirectory(cfg)
+	if current, ok := auditLog.File().(*audit.FileBackend); ok && current.Dir() == dir {
+		auditLog.Configure(current, mirror)
+		return
+	}
+	backend, err := audit.NewFileBackend(dir)
+	if err != nil {
+		log.Errorf("failed to initialize audit log: %v", err)
+		return
+	}
+	closeAuditBackend(auditLog.Configure(backend, mirror))
+	log.Infof("management audit log enabled, writing to %s", backend.Path())
+}
+
+func closeAuditBackend(backend audit.Backend) {
+	fileBackend, ok := backend.(*audit.FileBackend)
+	if !ok {
+		return
+	}
+	if errClose := fileBackend.Close(); errClose != nil {
+		log.Warnf("failed to close audit log file: %v", errClose)
+	}
+}
@@ -0,0 +1,367 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+const (
+	// defaultConcurrencyQueueMaxWaiting bounds the requests waiting for a credential slot.
+	defaultConcurrencyQueueMaxWaiting = 256
+	// defaultConcurrencyQueueTimeout is how long a request waits for a slot before failing.
+	defaultConcurrencyQueueTimeout = 30 * time.Second
+)
+
+// authMaxConcurrent returns the in-flight request limit of auth, or 0 when it is unlimited.
+func authMaxConcurrent(auth *Auth) int {
+	if auth == nil || auth.Attributes == nil {
+		return 0
+	}
+	raw := strings.TrimSpace(auth.Attributes["max_concurrent"])
+	if raw == "" {
+		return 0
+	}
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed <= 0 {
+		return 0
+	}
+	return parsed
+}
+
+// authBusyError reports that every auth matching a request is at its max-concurrent limit.
+// It lists the busy auths so the request can wait for one of them to free a slot.
+type authBusyError struct {
+	authIDs []string
+}
+
+func newAuthBusyError(authIDs []string) *authBusyError {
+	slices.Sort(authIDs)
+	return &authBusyError{authIDs: slices.Compact(authIDs)}
+}
+
+func (e *authBusyError) Error() string {
+	return "auth_busy: all matching credentials are at their max-concurrent limit"
+}
+
+// StatusCode implements optional status accessor for manager decision making.
+func (e *authBusyError) StatusCode() int { return http.StatusTooManyRequests }
+
+// slotWaiter is a request queued for a concurrency slot on any of authIDs.
+type slotWaiter struct {
+	client  string
+	authIDs map[string]struct{}
+	ready   chan struct{}
+	elem    *list.Element
+	// reserved names the auth whose slot was handed to this waiter on release. The slot stays
+	// counted as in flight until the waiter takes it or gives it back.
+	reserved string
+}
+
+type slotWaiterContextKey struct{}
+
+func withSlotWaiter(ctx context.Context, waiter *slotWaiter) context.Context {
+	if waiter == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, slotWaiterContextKey{}, waiter)
+}
+
+func slotWaiterFromContext(ctx context.Context) *slotWaiter {
+	if ctx == nil {
+		return nil
+	}
+	waiter, _ := ctx.Value(slotWaiterContextKey{}).(*slotWaiter)
+	return waiter
+}
+
+// authSlots counts in-flight requests per auth and queues requests that found every matching
+// auth busy. Waiters are FIFO per client and clients are served round-robin, so one client
+// flooding the queue cannot starve the others. A released slot is handed directly to the next
+// eligible waiter instead of being returned to the pool, so new arrivals cannot jump the queue.
+type authSlots struct {
+	mu       sync.Mutex
+	inflight map[string]int
+	queues   map[string]*list.List
+	clients  []string
+	next     int
+	waiting  int
+}
+
+func newAuthSlots() *authSlots {
+	return &authSlots{
+		inflight: make(map[string]int),
+		queues:   make(map[string]*list.List),
+	}
+}
+
+// available reports whether authID can take another request, counting a slot reserved for
+// waiter as free.
+func (s *authSlots) available(authID string, limit int, waiter *slotWaiter) bool {
+	if s == nil || limit <= 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if waiter != nil && waiter.reserved == authID {
+		return true
+	}
+	return s.inflight[authID] < limit
+}
+
+// inFlight returns the number of requests currently running on authID.
+func (s *authSlots) inFlight(authID string) int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inflight[authID]
+}
+
+// tryAcquire takes a slot on authID. A reservation held by waiter on another auth is passed
+// on to the next waiter.
+func (s *authSlots) tryAcquire(authID string, limit int, waiter *slotWaiter) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if waiter != nil && waiter.reserved == authID {
+		waiter.reserved = ""
+		return true
+	}
+	if limit > 0 && s.inflight[authID] >= limit {
+		return false
+	}
+	s.inflight[authID]++
+	if waiter != nil && waiter.reserved != "" {
+		reserved := waiter.reserved
+		waiter.reserved = ""
+		s.releaseLocked(reserved)
+	}
+	return true
+}
+
+// release frees a slot on authID.
+func (s *authSlots) release(authID string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked(authID)
+}
+
+// abandon gives back a reservation waiter did not use.
+func (s *authSlots) abandon(waiter *slotWaiter) {
+	if s == nil || waiter == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dequeueLocked(waiter)
+	if waiter.reserved != "" {
+		reserved := waiter.reserved
+		waiter.reserved = ""
+		s.releaseLocked(reserved)
+	}
+}
+
+func (s *authSlots) releaseLocked(authID string) {
+	for offset := 0; offset < len(s.clients); offset++ {
+		index := (s.next + offset) % len(s.clients)
+		queue := s.queues[s.clients[index]]
+		for elem := queue.Front(); elem != nil; elem = elem.Next() {
+			waiter := elem.Value.(*slotWaiter)
+			if _, ok := waiter.authIDs[authID]; !ok {
+				continue
+			}
+			waiter.reserved = authID
+			s.next = index + 1
+			s.dequeueLocked(waiter)
+			close(waiter.ready)
+			return
+		}
+	}
+	if s.inflight[authID] <= 1 {
+		delete(s.inflight, authID)
+		return
+	}
+	s.inflight[authID]--
+}
+
+func (s *authSlots) dequeueLocked(waiter *slotWaiter) {
+	if waiter.elem == nil {
+		return
+	}
+	queue := s.queues[waiter.client]
+	queue.Remove(waiter.elem)
+	waiter.elem = nil
+	s.waiting--
+	if queue.Len() > 0 {
+		return
+	}
+	delete(s.queues, waiter.client)
+	if index := slices.Index(s.clients, waiter.client); index >= 0 {
+		s.clients = slices.Delete(s.clients, index, index+1)
+		if s.next > index {
+			s.next--
+		}
+	}
+	if len(s.clients) == 0 || s.next >= len(s.clients) {
+		s.next = 0
+	}
+}
+
+// wait queues the caller until a slot on one of authIDs is handed to it or deadline passes.
+func (s *authSlots) wait(ctx context.Context, client string, authIDs []string, deadline time.Time, settings concurrencyQueueSettings) (*slotWaiter, error) {
+	waiter := &slotWaiter{client: client, authIDs: make(map[string]struct{}, len(authIDs)), ready: make(chan struct{})}
+	for _, authID := range authIDs {
+		waiter.authIDs[authID] = struct{}{}
+	}
+
+	s.mu.Lock()
+	if s.waiting >= settings.maxWaiting {
+		s.mu.Unlock()
+		return nil, &Error{Code: "auth_busy", Message: "all matching credentials are busy and the wait queue is full", Retryable: true, HTTPStatus: http.StatusTooManyRequests}
+	}
+	queue := s.queues[client]
+	if queue == nil {
+		queue = list.New()
+		s.queues[client] = queue
+		s.clients = append(s.clients, client)
+	}
+	if settings.maxWaitingPerClient > 0 && queue.Len() >= settings.maxWaitingPerClient {
+		s.mu.Unlock()
+		return nil, &Error{Code: "auth_busy", Message: "all matching credentials are busy and this client has too many queued requests", Retryable: true, HTTPStatus: http.StatusTooManyRequests}
+	}
+	waiter.elem = queue.PushBack(waiter)
+	s.waiting++
+	s.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	var errWait error
+	select {
+	case <-waiter.ready:
+		return waiter, nil
+	case <-timer.C:
+		errWait = &Error{Code: "auth_busy", Message: "timed out waiting for a credential below its max-concurrent limit", Retryable: true, HTTPStatus: http.StatusTooManyRequests}
+	case <-ctx.Done():
+		errWait = ctx.Err()
+	}
+	s.abandon(waiter)
+	return nil, errWait
+}
+
+// concurrencyQueueSettings is the resolved routing.concurrency-queue config.
+type concurrencyQueueSettings struct {
+	disabled            bool
+	maxWaiting          int
+	maxWaitingPerClient int
+	timeout             time.Duration
+}
+
+func (m *Manager) concurrencyQueueSettings() concurrencyQueueSettings {
+	settings := concurrencyQueueSettings{
+		maxWaiting: defaultConcurrencyQueueMaxWaiting,
+		timeout:    defaultConcurrencyQueueTimeout,
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil {
+		return settings
+	}
+	queue := cfg.Routing.ConcurrencyQueue
+	settings.disabled = queue.Disabled
+	if queue.MaxWaiting > 0 {
+		settings.maxWaiting = queue.MaxWaiting
+	}
+	settings.maxWaitingPerClient = queue.MaxWaitingPerClient
+	if queue.TimeoutSeconds > 0 {
+		settings.timeout = time.Duration(queue.TimeoutSeconds) * time.Second
+	}
+	return settings
+}
+
+// slotClientFromContext identifies the downstream client for queue fairness.
+func slotClientFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		return ginCtx.GetString("userApiKey")
+	}
+	return ""
+}
+
+// pickNextWithSlot picks the next auth and takes one of its concurrency slots. While every
+// matching auth is at its max-concurrent limit the request waits in the fair queue, up to the
+// configured timeout. The returned func releases the slot and must be called exactly once.
+func (m *Manager) pickNextWithSlot(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, func(), error) {
+	var waiter *slotWaiter
+	var deadline time.Time
+	for {
+		auth, executor, provider, errPick := m.pickNextMixedTraced(withSlotWaiter(ctx, waiter), providers, model, opts, tried)
+		if errPick == nil {
+			if !m.slots.tryAcquire(auth.ID, authMaxConcurrent(auth), waiter) {
+				// Another request took the last slot between the pick and now; pick again.
+				continue
+			}
+			var once sync.Once
+			return auth, executor, provider, func() { once.Do(func() { m.slots.release(auth.ID) }) }, nil
+		}
+		m.slots.abandon(waiter)
+		waiter = nil
+
+		var busyErr *authBusyError
+		if !errors.As(errPick, &busyErr) || m.HomeEnabled() {
+			return nil, nil, "", nil, errPick
+		}
+		settings := m.concurrencyQueueSettings()
+		if settings.disabled {
+			return nil, nil, "", nil, errPick
+		}
+		if deadline.IsZero() {
+			deadline = time.Now().Add(settings.timeout)
+		}
+		var errWait error
+		waiter, errWait = m.slots.wait(ctx, slotClientFromContext(ctx), busyErr.authIDs, deadline, settings)
+		if errWait != nil {
+			return nil, nil, "", nil, errWait
+		}
+	}
+}
+
+// releaseAfterStream releases a concurrency slot once the stream has been fully forwarded
+// or its consumer has gone away.
+func releaseAfterStream(ctx context.Context, result *cliproxyexecutor.StreamResult, release func()) *cliproxyexecutor.StreamResult {
+	if result == nil || result.Chunks == nil {
+		release()
+		return result
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer release()
+		defer close(out)
+		for chunk := range result.Chunks {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				discardStreamChunks(result.Chunks)
+				return
+			}
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: result.Headers, Chunks: out}
+}
@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func waitForQueued(t *testing.T, slots *authSlots, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		slots.mu.Lock()
+		waiting := slots.waiting
+		slots.mu.Unlock()
+		if waiting == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("queued waiters never reached %d", want)
+}
+
+func TestAuthSlots_HandsReleasedSlotsToClientsRoundRobin(t *testing.T) {
+	t.Parallel()
+
+	slots := newAuthSlots()
+	if !slots.tryAcquire("a", 1, nil) {
+		t.Fatal("tryAcquire() on an idle auth failed")
+	}
+	if slots.tryAcquire("a", 1, nil) {
+		t.Fatal("tryAcquire() over the limit succeeded")
+	}
+
+	settings := concurrencyQueueSettings{maxWaiting: 10}
+	deadline := time.Now().Add(time.Minute)
+	served := make(chan string, 3)
+	for index, waiter := range []struct{ name, client string }{
+		{"first-1", "client-1"},
+		{"first-2", "client-1"},
+		{"second-1", "client-2"},
+	} {
+		go func() {
+			w, errWait := slots.wait(context.Background(), waiter.client, []string{"a"}, deadline, settings)
+			if errWait != nil {
+				served <- "error: " + errWait.Error()
+				return
+			}
+			if !slots.tryAcquire("a", 1, w) {
+				served <- "reservation lost: " + waiter.name
+				return
+			}
+			served <- waiter.name
+		}()
+		waitForQueued(t, slots, index+1)
+	}
+
+	for _, want := range []string{"first-1", "second-1", "first-2"} {
+		slots.release("a")
+		if got := <-served; got != want {
+			t.Fatalf("served %q, want %q", got, want)
+		}
+		if inflight := slots.inFlight("a"); inflight != 1 {
+			t.Fatalf("inFlight() = %d after hand-off, want 1", inflight)
+		}
+	}
+	slots.release("a")
+	if inflight := slots.inFlight("a"); inflight != 0 {
+		t.Fatalf("inFlight() = %d after the last release, want 0", inflight)
+	}
+}
+
+func TestAuthSlots_WaitEnforcesQueueLimits(t *testing.T) {
+	t.Parallel()
+
+	slots := newAuthSlots()
+	slots.tryAcquire("a", 1, nil)
+	settings := concurrencyQueueSettings{maxWaiting: 10, maxWaitingPerClient: 1}
+
+	go func() {
+		_, _ = slots.wait(context.Background(), "client-1", []string{"a"}, time.Now().Add(time.Minute), settings)
+	}()
+	waitForQueued(t, slots, 1)
+
+	_, errWait := slots.wait(context.Background(), "client-1", []string{"a"}, time.Now().Add(time.Minute), settings)
+	var authErr *Error
+	if !errors.As(errWait, &authErr) || authErr.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("wait() over the per-client cap error = %v, want 429", errWait)
+	}
+
+	_, errWait = slots.wait(context.Background(), "client-2", []string{"a"}, time.Now().Add(20*time.Millisecond), settings)
+	if !errors.As(errWait, &authErr) || authErr.Code != "auth_busy" {
+		t.Fatalf("wait() past the deadline error = %v, want auth_busy", errWait)
+	}
+	slots.mu.Lock()
+	waiting := slots.waiting
+	slots.mu.Unlock()
+	if waiting != 1 {
+		t.Fatalf("waiting = %d after a timed-out waiter left, want 1", waiting)
+	}
+}
+
+func TestSchedulerPick_SkipsAuthsAtMaxConcurrent(t *testing.T) {
+	t.Parallel()
+
+	scheduler := newSchedulerForTest(
+		&RoundRobinSelector{},
+		&Auth{ID: "limited", Provider: "gemini", Attributes: map[string]string{"max_concurrent": "1"}},
+	)
+	scheduler.slots = newAuthSlots()
+
+	got, errPick := scheduler.pickSingle(context.Background(), "gemini", "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil || got == nil {
+		t.Fatalf("pickSingle() = %v, %v", got, errPick)
+	}
+	scheduler.slots.tryAcquire(got.ID, 1, nil)
+
+	_, errPick = scheduler.pickSingle(context.Background(), "gemini", "", cliproxyexecutor.Options{}, nil)
+	var busyErr *authBusyError
+	if !errors.As(errPick, &busyErr) {
+		t.Fatalf("pickSingle() at the limit error = %v, want authBusyError", errPick)
+	}
+	if len(busyErr.authIDs) != 1 || busyErr.authIDs[0] != "limited" {
+		t.Fatalf("busy auths = %v, want [limited]", busyErr.authIDs)
+	}
+}
+
+func TestManager_PickNextWithSlot_QueuesUntilReleased(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.executors["gemini"] = schedulerTestExecutor{}
+	auth := &Auth{ID: "limited", Provider: "gemini", Attributes: map[string]string{"max_concurrent": "1"}}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("Register() error = %v", errRegister)
+	}
+
+	_, _, _, release, errPick := manager.pickNextWithSlot(context.Background(), []string{"gemini"}, "", cliproxyexecutor.Options{}, map[string]struct{}{})
+	if errPick != nil {
+		t.Fatalf("pickNextWithSlot() error = %v", errPick)
+	}
+
+	picked := make(chan error, 1)
+	go func() {
+		_, _, _, releaseQueued, errQueued := manager.pickNextWithSlot(context.Background(), []string{"gemini"}, "", cliproxyexecutor.Options{}, map[string]struct{}{})
+		if errQueued == nil {
+			releaseQueued()
+		}
+		picked <- errQueued
+	}()
+	waitForQueued(t, manager.slots, 1)
+	release()
+	if errQueued := <-picked; errQueued != nil {
+		t.Fatalf("queued pickNextWithSlot() error = %v", errQueued)
+	}
+
+	manager.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{
+		ConcurrencyQueue: internalconfig.ConcurrencyQueueConfig{Disabled: true},
+	}})
+	_, _, _, release, _ = manager.pickNextWithSlot(context.Background(), []string{"gemini"}, "", cliproxyexecutor.Options{}, map[string]struct{}{})
+	defer release()
+	_, _, _, _, errPick = manager.pickNextWithSlot(context.Background(), []string{"gemini"}, "", cliproxyexecutor.Options{}, map[string]struct{}{})
+	var busyErr *authBusyError
+	if !errors.As(errPick, &busyErr) || busyErr.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("pickNextWithSlot() with the queue disabled error = %v, want authBusyError", errPick)
+	}
+}
+
+// panicTestExecutor panics on every call, like a buggy executor behind gin's recovery.
+type panicTestExecutor struct{ schedulerTestExecutor }
+
+func (panicTestExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	panic("executor bug")
+}
+
+func (panicTestExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	panic("executor bug")
+}
+
+func TestManagerExecute_ReleasesSlotWhenExecutorPanics(t *testing.T) {
+	model := "panic-slot-model"
+	registerSchedulerModels(t, "gemini", model, "panicky")
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.executors["gemini"] = panicTestExecutor{}
+	auth := &Auth{ID: "panicky", Provider: "gemini", Attributes: map[string]string{"max_concurrent": "1"}}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("Register() error = %v", errRegister)
+	}
+
+	for name, call := range map[string]func(){
+		"Execute": func() {
+			_, _ = manager.Execute(context.Background(), []string{"gemini"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+		},
+		"ExecuteCount": func() {
+			_, _ = manager.ExecuteCount(context.Background(), []string{"gemini"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+		},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%s() did not panic", name)
+				}
+			}()
+			call()
+		}()
+		if inflight := manager.slots.inFlight("panicky"); inflight != 0 {
+			t.Fatalf("inFlight() after a panicking %s = %d, want 0", name, inflight)
+		}
+	}
+}
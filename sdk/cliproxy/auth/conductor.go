@@ -25,6 +25,8 @@ import (
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
 	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ProviderExecutor defines the contract required by Manager to execute provider calls.
@@ -106,6 +108,13 @@ type Result struct {
 	RetryAfter *time.Duration
 	// Error describes the failure when Success is false.
 	Error *Error
+	// Latency is the time the upstream call took, until the last chunk for streams.
+	// Zero means the latency is unknown and only the outcome is recorded.
+	Latency time.Duration
+	// TTFB is the time until the first chunk of a stream; zero for non-streaming calls.
+	TTFB time.Duration
+	// Quota carries the quota reading of the upstream response, when the executor reports one.
+	Quota *QuotaSnapshot
 }
 
 // Selector chooses an auth candidate for execution.
@@ -151,6 +160,9 @@ type Manager struct {
 	mu        sync.RWMutex
 	auths     map[string]*Auth
 	scheduler *authScheduler
+	// slots tracks in-flight requests per auth for max-concurrent limits and queues
+	// requests while every matching auth is busy.
+	slots *authSlots
 	// homeRuntimeAuths caches auths returned by Home so websocket sessions can
 	// reuse an established upstream credential without dispatching every turn.
 	homeRuntimeAuths map[string]map[string]*Auth
@@ -182,6 +194,14 @@ type Manager struct {
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 	refreshLoop   *authAutoRefreshLoop
+
+	// shared is the optional backend replicating routing state to other instances.
+	shared atomic.Pointer[sharedStateHandle]
+	// instanceID tags the state this manager publishes.
+	instanceID string
+
+	// health holds the background credential prober and its latest results.
+	health *healthProber
 }
 
 // NewManager constructs a manager with optional custom selector and hook.
@@ -201,11 +221,16 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		homeRuntimeAuths: make(map[string]map[string]*Auth),
 		providerOffsets:  make(map[string]int),
 		modelPoolOffsets: make(map[string]int),
+		instanceID:       uuid.NewString(),
 	}
 	// atomic.Value requires non-nil initial value.
 	manager.runtimeConfig.Store(&internalconfig.Config{})
 	manager.apiKeyModelAlias.Store(apiKeyModelAliasTable(nil))
+	manager.slots = newAuthSlots()
 	manager.scheduler = newAuthScheduler(selector)
+	manager.scheduler.slots = manager.slots
+	manager.health = newHealthProber(manager)
+	manager.attachSharedSelector(selector)
 	return manager
 }
 
@@ -348,8 +373,17 @@ func (m *Manager) SetSelector(selector Selector) {
 		selector = &RoundRobinSelector{}
 	}
 	m.mu.Lock()
+	previous := m.selector
 	m.selector = selector
 	m.mu.Unlock()
+	// Keep session bindings across strategy changes so sessions stay on their auth.
+	if previousAffinity, ok := previous.(*SessionAffinitySelector); ok && previous != selector {
+		if affinity, okAffinity := selector.(*SessionAffinitySelector); okAffinity {
+			affinity.cache.Restore(previousAffinity.cache.Bindings())
+		}
+		previousAffinity.Stop()
+	}
+	m.attachSharedSelector(selector)
 	if m.scheduler != nil {
 		m.scheduler.setSelector(selector)
 		m.syncScheduler()
@@ -380,6 +414,9 @@ func (m *Manager) SetConfig(cfg *internalconfig.Config) {
 		cfg = &internalconfig.Config{}
 	}
 	m.runtimeConfig.Store(cfg)
+	if m.scheduler != nil {
+		m.scheduler.setQuotaAvoidPercent(quotaAvoidPercent(cfg))
+	}
 	if !cfg.Home.Enabled {
 		m.clearHomeRuntimeAuths()
 	}
@@ -806,7 +843,7 @@ func readStreamBootstrap(ctx context.Context, ch <-chan cliproxyexecutor.StreamC
 	}
 }
 
-func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, resultModel string, headers http.Header, buffered []cliproxyexecutor.StreamChunk, remaining <-chan cliproxyexecutor.StreamChunk) *cliproxyexecutor.StreamResult {
+func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, resultModel string, started time.Time, ttfb time.Duration, headers http.Header, quota *QuotaSnapshot, buffered []cliproxyexecutor.StreamChunk, remaining <-chan cliproxyexecutor.StreamChunk) *cliproxyexecutor.StreamResult {
 	out := make(chan cliproxyexecutor.StreamChunk)
 	go func() {
 		defer close(out)
@@ -819,7 +856,7 @@ func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, re
 				if se, ok := errors.AsType[cliproxyexecutor.StatusError](chunk.Err); ok && se != nil {
 					rerr.HTTPStatus = se.StatusCode()
 				}
-				m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: rerr})
+				m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: rerr, Quota: quota})
 			}
 			if !forward {
 				return false
@@ -849,7 +886,7 @@ func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, re
 			}
 		}
 		if !failed {
-			m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: true})
+			m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: true, Latency: time.Since(started), TTFB: ttfb, Quota: quota})
 		}
 	}()
 	return &cliproxyexecutor.StreamResult{Headers: headers, Chunks: out}
@@ -865,8 +902,11 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 		resultModel := m.stateModelForExecution(auth, routeModel, execModel, pooled)
 		execReq := req
 		execReq.Model = execModel
-		streamResult, errStream := executor.ExecuteStream(ctx, auth, execReq, opts)
+		started := time.Now()
+		spanCtx, span := startExecutorSpan(ctx, "executor.execute_stream", auth, provider, execModel)
+		streamResult, errStream := executor.ExecuteStream(spanCtx, auth, execReq, opts)
 		if errStream != nil {
+			endSpan(span, errStream)
 			if errCtx := ctx.Err(); errCtx != nil {
 				return nil, errCtx
 			}
@@ -876,6 +916,7 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			}
 			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: rerr}
 			result.RetryAfter = retryAfterFromError(errStream)
+			result.Quota = readQuota(executor, auth, execModel, resultModel, nil, errStream)
 			m.MarkResult(ctx, result)
 			if isRequestInvalidError(errStream) {
 				return nil, errStream
@@ -885,6 +926,11 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 		}
 
 		buffered, closed, bootstrapErr := readStreamBootstrap(ctx, streamResult.Chunks)
+		firstChunkLatency := time.Since(started)
+		if bootstrapErr == nil && len(buffered) > 0 {
+			span.AddEvent("stream.first_byte", trace.WithAttributes(attribute.Int64("stream.first_byte_ms", firstChunkLatency.Milliseconds())))
+		}
+		endSpan(span, bootstrapErr)
 		if bootstrapErr != nil {
 			if errCtx := ctx.Err(); errCtx != nil {
 				discardStreamChunks(streamResult.Chunks)
@@ -897,6 +943,7 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 				}
 				result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: rerr}
 				result.RetryAfter = retryAfterFromError(bootstrapErr)
+				result.Quota = readQuota(executor, auth, execModel, resultModel, streamResult.Headers, bootstrapErr)
 				m.MarkResult(ctx, result)
 				discardStreamChunks(streamResult.Chunks)
 				return nil, bootstrapErr
@@ -908,6 +955,7 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 				}
 				result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: rerr}
 				result.RetryAfter = retryAfterFromError(bootstrapErr)
+				result.Quota = readQuota(executor, auth, execModel, resultModel, streamResult.Headers, bootstrapErr)
 				m.MarkResult(ctx, result)
 				discardStreamChunks(streamResult.Chunks)
 				lastErr = bootstrapErr
@@ -919,6 +967,7 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			}
 			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: rerr}
 			result.RetryAfter = retryAfterFromError(bootstrapErr)
+			result.Quota = readQuota(executor, auth, execModel, resultModel, streamResult.Headers, bootstrapErr)
 			m.MarkResult(ctx, result)
 			discardStreamChunks(streamResult.Chunks)
 			return nil, newStreamBootstrapError(bootstrapErr, streamResult.Headers)
@@ -941,7 +990,8 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			close(closedCh)
 			remaining = closedCh
 		}
-		return m.wrapStreamResult(ctx, auth.Clone(), provider, resultModel, streamResult.Headers, buffered, remaining), nil
+		quota := readQuota(executor, auth, execModel, resultModel, streamResult.Headers, nil)
+		return m.wrapStreamResult(ctx, auth.Clone(), provider, resultModel, started, firstChunkLatency, streamResult.Headers, quota, buffered, remaining), nil
 	}
 	if lastErr == nil {
 		lastErr = &Error{Code: "auth_not_found", Message: "no upstream model available"}
@@ -1162,6 +1212,10 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 		auth.Success = existing.Success
 		auth.Failed = existing.Failed
 		auth.recentRequests = existing.recentRequests
+		auth.latencyStats = existing.latencyStats
+		if auth.QuotaSnapshot == nil {
+			auth.QuotaSnapshot = existing.QuotaSnapshot
+		}
 		if !existing.Disabled && existing.Status != StatusDisabled && !auth.Disabled && auth.Status != StatusDisabled {
 			if len(auth.ModelStates) == 0 && len(existing.ModelStates) > 0 {
 				auth.ModelStates = existing.ModelStates
@@ -1224,7 +1278,9 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 
 	var lastErr error
 	for attempt := 0; ; attempt++ {
-		resp, errExec := m.executeMixedOnce(ctx, normalized, req, opts, maxRetryCredentials)
+		attemptCtx, attemptSpan := startAttemptSpan(ctx, attempt, req.Model)
+		resp, errExec := m.executeMixedOnce(attemptCtx, normalized, req, opts, maxRetryCredentials)
+		endSpan(attemptSpan, errExec)
 		if errExec == nil {
 			return resp, nil
 		}
@@ -1259,7 +1315,9 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 
 	var lastErr error
 	for attempt := 0; ; attempt++ {
-		resp, errExec := m.executeCountMixedOnce(ctx, normalized, req, opts, maxRetryCredentials)
+		attemptCtx, attemptSpan := startAttemptSpan(ctx, attempt, req.Model)
+		resp, errExec := m.executeCountMixedOnce(attemptCtx, normalized, req, opts, maxRetryCredentials)
+		endSpan(attemptSpan, errExec)
 		if errExec == nil {
 			return resp, nil
 		}
@@ -1290,7 +1348,9 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 
 	var lastErr error
 	for attempt := 0; ; attempt++ {
-		result, errStream := m.executeStreamMixedOnce(ctx, normalized, req, opts, maxRetryCredentials)
+		attemptCtx, attemptSpan := startAttemptSpan(ctx, attempt, req.Model)
+		result, errStream := m.executeStreamMixedOnce(attemptCtx, normalized, req, opts, maxRetryCredentials)
+		endSpan(attemptSpan, errStream)
 		if errStream == nil {
 			return result, nil
 		}
@@ -1324,6 +1384,11 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
+	if chain := m.modelFallbackChain(routeModel, opts); len(chain) > 0 {
+		return executeModelFallbacks(ctx, chain, req, opts, func(hopCtx context.Context, hopProviders []string, hopReq cliproxyexecutor.Request, hopOpts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+			return m.executeMixedOnce(hopCtx, hopProviders, hopReq, hopOpts, maxRetryCredentials)
+		})
+	}
 	homeMode := m.HomeEnabled()
 	homeAuthCount := 1
 	tried := make(map[string]struct{})
@@ -1340,7 +1405,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		if homeMode {
 			pickOpts = withHomeAuthCount(opts, homeAuthCount)
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, pickOpts, tried)
+		auth, executor, provider, release, errPick := m.pickNextWithSlot(ctx, providers, routeModel, pickOpts, tried)
 		if errPick != nil {
 			if shouldReturnLastErrorOnPickFailure(homeMode, lastErr, errPick) {
 				return cliproxyexecutor.Response{}, lastErr
@@ -1348,62 +1413,80 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			return cliproxyexecutor.Response{}, errPick
 		}
 
-		entry := logEntryWithRequestID(ctx)
-		debugLogAuthSelection(entry, auth, provider, req.Model)
-		publishSelectedAuthMetadata(opts.Metadata, auth.ID)
-
-		tried[auth.ID] = struct{}{}
-		execCtx := ctx
-		if rt := m.roundTripperFor(auth); rt != nil {
-			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
-			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
-		}
-		execCtx = contextWithRequestedModelAlias(execCtx, opts, routeModel)
-
-		models, pooled := m.preparedExecutionModels(auth, routeModel)
-		if len(models) == 0 {
-			continue
-		}
-		attempted[auth.ID] = struct{}{}
-		var authErr error
-		for _, upstreamModel := range models {
-			resultModel := m.stateModelForExecution(auth, routeModel, upstreamModel, pooled)
-			execReq := req
-			execReq.Model = upstreamModel
-			resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
-			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil}
-			if errExec != nil {
-				if errCtx := execCtx.Err(); errCtx != nil {
-					return cliproxyexecutor.Response{}, errCtx
-				}
-				result.Error = &Error{Message: errExec.Error()}
-				if se, ok := errors.AsType[cliproxyexecutor.StatusError](errExec); ok && se != nil {
-					result.Error.HTTPStatus = se.StatusCode()
-				}
-				if ra := retryAfterFromError(errExec); ra != nil {
-					result.RetryAfter = ra
-				}
-				m.MarkResult(execCtx, result)
-				if isRequestInvalidError(errExec) {
-					return cliproxyexecutor.Response{}, errExec
-				}
-				authErr = errExec
-				continue
-			}
-			m.MarkResult(execCtx, result)
-			return resp, nil
+		resp, done, authErr := m.executeMixedAttempt(ctx, auth, executor, provider, release, req, opts, routeModel, tried, attempted)
+		if done {
+			return resp, authErr
 		}
 		if authErr != nil {
-			if isRequestInvalidError(authErr) {
-				return cliproxyexecutor.Response{}, authErr
-			}
 			lastErr = authErr
 			if homeMode {
 				homeAuthCount++
 			}
+		}
+	}
+}
+
+// executeMixedAttempt runs req on one picked auth while it holds the auth's
+// concurrency slot. The slot is released when the attempt ends, even if the executor panics.
+// The bool reports whether the returned response and error are final; otherwise the error,
+// when set, is the auth's last failure.
+func (m *Manager) executeMixedAttempt(ctx context.Context, auth *Auth, executor ProviderExecutor, provider string, release func(), req cliproxyexecutor.Request, opts cliproxyexecutor.Options, routeModel string, tried, attempted map[string]struct{}) (cliproxyexecutor.Response, bool, error) {
+	defer release()
+	if !authMatchesModelFallbackHop(auth, opts.Metadata) {
+		tried[auth.ID] = struct{}{}
+		return cliproxyexecutor.Response{}, false, nil
+	}
+
+	entry := logEntryWithRequestID(ctx)
+	debugLogAuthSelection(entry, auth, provider, req.Model)
+	publishSelectedAuthMetadata(opts.Metadata, auth.ID)
+
+	tried[auth.ID] = struct{}{}
+	execCtx := ctx
+	if rt := m.roundTripperFor(auth); rt != nil {
+		execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+		execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+	}
+	execCtx = contextWithRequestedModelAlias(execCtx, opts, routeModel)
+
+	models, pooled := m.preparedExecutionModels(auth, routeModel)
+	if len(models) == 0 {
+		return cliproxyexecutor.Response{}, false, nil
+	}
+	attempted[auth.ID] = struct{}{}
+	var authErr error
+	for _, upstreamModel := range models {
+		resultModel := m.stateModelForExecution(auth, routeModel, upstreamModel, pooled)
+		execReq := req
+		execReq.Model = upstreamModel
+		started := time.Now()
+		spanCtx, span := startExecutorSpan(execCtx, "executor.execute", auth, provider, upstreamModel)
+		resp, errExec := executor.Execute(spanCtx, auth, execReq, opts)
+		endSpan(span, errExec)
+		result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil, Latency: time.Since(started)}
+		result.Quota = readQuota(executor, auth, upstreamModel, resultModel, resp.Headers, errExec)
+		if errExec != nil {
+			if errCtx := execCtx.Err(); errCtx != nil {
+				return cliproxyexecutor.Response{}, true, errCtx
+			}
+			result.Error = &Error{Message: errExec.Error()}
+			if se, ok := errors.AsType[cliproxyexecutor.StatusError](errExec); ok && se != nil {
+				result.Error.HTTPStatus = se.StatusCode()
+			}
+			if ra := retryAfterFromError(errExec); ra != nil {
+				result.RetryAfter = ra
+			}
+			m.MarkResult(execCtx, result)
+			if isRequestInvalidError(errExec) {
+				return cliproxyexecutor.Response{}, true, errExec
+			}
+			authErr = errExec
 			continue
 		}
+		m.MarkResult(execCtx, result)
+		return resp, true, nil
 	}
+	return cliproxyexecutor.Response{}, false, authErr
 }
 
 func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, maxRetryCredentials int) (cliproxyexecutor.Response, error) {
@@ -1412,6 +1495,11 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
+	if chain := m.modelFallbackChain(routeModel, opts); len(chain) > 0 {
+		return executeModelFallbacks(ctx, chain, req, opts, func(hopCtx context.Context, hopProviders []string, hopReq cliproxyexecutor.Request, hopOpts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+			return m.executeCountMixedOnce(hopCtx, hopProviders, hopReq, hopOpts, maxRetryCredentials)
+		})
+	}
 	homeMode := m.HomeEnabled()
 	homeAuthCount := 1
 	tried := make(map[string]struct{})
@@ -1428,7 +1516,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		if homeMode {
 			pickOpts = withHomeAuthCount(opts, homeAuthCount)
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, pickOpts, tried)
+		auth, executor, provider, release, errPick := m.pickNextWithSlot(ctx, providers, routeModel, pickOpts, tried)
 		if errPick != nil {
 			if shouldReturnLastErrorOnPickFailure(homeMode, lastErr, errPick) {
 				return cliproxyexecutor.Response{}, lastErr
@@ -1436,62 +1524,79 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			return cliproxyexecutor.Response{}, errPick
 		}
 
-		entry := logEntryWithRequestID(ctx)
-		debugLogAuthSelection(entry, auth, provider, req.Model)
-		publishSelectedAuthMetadata(opts.Metadata, auth.ID)
-
-		tried[auth.ID] = struct{}{}
-		execCtx := ctx
-		if rt := m.roundTripperFor(auth); rt != nil {
-			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
-			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
-		}
-		execCtx = contextWithRequestedModelAlias(execCtx, opts, routeModel)
-
-		models, pooled := m.preparedExecutionModels(auth, routeModel)
-		if len(models) == 0 {
-			continue
-		}
-		attempted[auth.ID] = struct{}{}
-		var authErr error
-		for _, upstreamModel := range models {
-			resultModel := m.stateModelForExecution(auth, routeModel, upstreamModel, pooled)
-			execReq := req
-			execReq.Model = upstreamModel
-			resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
-			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil}
-			if errExec != nil {
-				if errCtx := execCtx.Err(); errCtx != nil {
-					return cliproxyexecutor.Response{}, errCtx
-				}
-				result.Error = &Error{Message: errExec.Error()}
-				if se, ok := errors.AsType[cliproxyexecutor.StatusError](errExec); ok && se != nil {
-					result.Error.HTTPStatus = se.StatusCode()
-				}
-				if ra := retryAfterFromError(errExec); ra != nil {
-					result.RetryAfter = ra
-				}
-				m.MarkResult(execCtx, result)
-				if isRequestInvalidError(errExec) {
-					return cliproxyexecutor.Response{}, errExec
-				}
-				authErr = errExec
-				continue
-			}
-			m.MarkResult(execCtx, result)
-			return resp, nil
+		resp, done, authErr := m.executeCountMixedAttempt(ctx, auth, executor, provider, release, req, opts, routeModel, tried, attempted)
+		if done {
+			return resp, authErr
 		}
 		if authErr != nil {
-			if isRequestInvalidError(authErr) {
-				return cliproxyexecutor.Response{}, authErr
-			}
 			lastErr = authErr
 			if homeMode {
 				homeAuthCount++
 			}
+		}
+	}
+}
+
+// executeCountMixedAttempt counts the tokens of req on one picked auth while it holds the
+// auth's concurrency slot. The slot is released when the attempt ends, even if the executor
+// panics. The bool reports whether the returned response and error are final; otherwise the
+// error, when set, is the auth's last failure.
+func (m *Manager) executeCountMixedAttempt(ctx context.Context, auth *Auth, executor ProviderExecutor, provider string, release func(), req cliproxyexecutor.Request, opts cliproxyexecutor.Options, routeModel string, tried, attempted map[string]struct{}) (cliproxyexecutor.Response, bool, error) {
+	defer release()
+	if !authMatchesModelFallbackHop(auth, opts.Metadata) {
+		tried[auth.ID] = struct{}{}
+		return cliproxyexecutor.Response{}, false, nil
+	}
+
+	entry := logEntryWithRequestID(ctx)
+	debugLogAuthSelection(entry, auth, provider, req.Model)
+	publishSelectedAuthMetadata(opts.Metadata, auth.ID)
+
+	tried[auth.ID] = struct{}{}
+	execCtx := ctx
+	if rt := m.roundTripperFor(auth); rt != nil {
+		execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+		execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+	}
+	execCtx = contextWithRequestedModelAlias(execCtx, opts, routeModel)
+
+	models, pooled := m.preparedExecutionModels(auth, routeModel)
+	if len(models) == 0 {
+		return cliproxyexecutor.Response{}, false, nil
+	}
+	attempted[auth.ID] = struct{}{}
+	var authErr error
+	for _, upstreamModel := range models {
+		resultModel := m.stateModelForExecution(auth, routeModel, upstreamModel, pooled)
+		execReq := req
+		execReq.Model = upstreamModel
+		spanCtx, span := startExecutorSpan(execCtx, "executor.count_tokens", auth, provider, upstreamModel)
+		resp, errExec := executor.CountTokens(spanCtx, auth, execReq, opts)
+		endSpan(span, errExec)
+		result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil}
+		result.Quota = readQuota(executor, auth, upstreamModel, resultModel, resp.Headers, errExec)
+		if errExec != nil {
+			if errCtx := execCtx.Err(); errCtx != nil {
+				return cliproxyexecutor.Response{}, true, errCtx
+			}
+			result.Error = &Error{Message: errExec.Error()}
+			if se, ok := errors.AsType[cliproxyexecutor.StatusError](errExec); ok && se != nil {
+				result.Error.HTTPStatus = se.StatusCode()
+			}
+			if ra := retryAfterFromError(errExec); ra != nil {
+				result.RetryAfter = ra
+			}
+			m.MarkResult(execCtx, result)
+			if isRequestInvalidError(errExec) {
+				return cliproxyexecutor.Response{}, true, errExec
+			}
+			authErr = errExec
 			continue
 		}
+		m.MarkResult(execCtx, result)
+		return resp, true, nil
 	}
+	return cliproxyexecutor.Response{}, false, authErr
 }
 
 func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, maxRetryCredentials int) (*cliproxyexecutor.StreamResult, error) {
@@ -1500,6 +1605,11 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
+	if chain := m.modelFallbackChain(routeModel, opts); len(chain) > 0 {
+		return executeModelFallbacks(ctx, chain, req, opts, func(hopCtx context.Context, hopProviders []string, hopReq cliproxyexecutor.Request, hopOpts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+			return m.executeStreamMixedOnce(hopCtx, hopProviders, hopReq, hopOpts, maxRetryCredentials)
+		})
+	}
 	homeMode := m.HomeEnabled()
 	homeAuthCount := 1
 	tried := make(map[string]struct{})
@@ -1516,7 +1626,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		if homeMode {
 			pickOpts = withHomeAuthCount(opts, homeAuthCount)
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, pickOpts, tried)
+		auth, executor, provider, release, errPick := m.pickNextWithSlot(ctx, providers, routeModel, pickOpts, tried)
 		if errPick != nil {
 			if shouldReturnLastErrorOnPickFailure(homeMode, lastErr, errPick) {
 				return nil, lastErr
@@ -1524,6 +1634,12 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			return nil, errPick
 		}
 
+		if !authMatchesModelFallbackHop(auth, opts.Metadata) {
+			release()
+			tried[auth.ID] = struct{}{}
+			continue
+		}
+
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
 		publishSelectedAuthMetadata(opts.Metadata, auth.ID)
@@ -1536,10 +1652,19 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		}
 		models, pooled := m.preparedExecutionModels(auth, routeModel)
 		if len(models) == 0 {
+			release()
 			continue
 		}
 		attempted[auth.ID] = struct{}{}
-		streamResult, errStream := m.executeStreamWithModelPool(execCtx, executor, auth, provider, req, opts, routeModel, models, pooled)
+		streamResult, errStream := func() (result *cliproxyexecutor.StreamResult, err error) {
+			// The stream owns the slot once it starts; release it here on failure or panic.
+			defer func() {
+				if result == nil || err != nil {
+					release()
+				}
+			}()
+			return m.executeStreamWithModelPool(execCtx, executor, auth, provider, req, opts, routeModel, models, pooled)
+		}()
 		if errStream != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
 				return nil, errCtx
@@ -1553,7 +1678,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			}
 			continue
 		}
-		return streamResult, nil
+		return releaseAfterStream(ctx, streamResult, release), nil
 	}
 }
 
@@ -2136,6 +2261,13 @@ func waitForCooldown(ctx context.Context, wait time.Duration) error {
 
 // MarkResult records an execution result and notifies hooks.
 func (m *Manager) MarkResult(ctx context.Context, result Result) {
+	m.markResult(ctx, result, false)
+}
+
+// markResult applies result to the routing state of its auth. Health probe results update
+// availability only: they are kept out of the request counters, latency statistics and hooks
+// that describe user traffic.
+func (m *Manager) markResult(ctx context.Context, result Result, probe bool) {
 	if result.AuthID == "" {
 		return
 	}
@@ -2145,21 +2277,30 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	suspendReason := ""
 	clearModelQuota := false
 	setModelQuota := false
+	// stateChanged reports a cooldown or quota change worth sharing with other instances.
+	stateChanged := false
 	var authSnapshot *Auth
 
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
 		now := time.Now()
-		auth.recordRecentRequest(now, result.Success)
-		if result.Success {
-			auth.Success++
-		} else {
-			auth.Failed++
+		if !probe {
+			auth.recordRecentRequest(now, result.Success)
+			auth.latencyStats.observe(result.Latency, result.TTFB, result.Success)
+			if result.Success {
+				auth.Success++
+			} else {
+				auth.Failed++
+			}
+		}
+		if result.Quota != nil {
+			auth.QuotaSnapshot = mergeQuotaSnapshot(auth.QuotaSnapshot, result.Quota)
 		}
 
 		if result.Success {
 			if result.Model != "" {
 				state := ensureModelState(auth, result.Model)
+				stateChanged = !modelStateIsClean(state)
 				resetModelState(state, now)
 				updateAggregatedAvailability(auth, now)
 				if !hasModelError(auth, now) {
@@ -2171,6 +2312,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 				shouldResumeModel = true
 				clearModelQuota = true
 			} else {
+				stateChanged = auth.Unavailable || auth.Status == StatusError || auth.Quota.Exceeded || !auth.NextRetryAfter.IsZero()
 				clearAuthStateOnSuccess(auth, now)
 			}
 		} else {
@@ -2264,9 +2406,11 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 					auth.Status = StatusError
 					auth.UpdatedAt = now
 					updateAggregatedAvailability(auth, now)
+					stateChanged = true
 				}
 			} else {
 				applyAuthFailureState(auth, result.Error, result.RetryAfter, now)
+				stateChanged = !isRequestScopedNotFoundResultError(result.Error)
 			}
 		}
 
@@ -2277,6 +2421,9 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	if m.scheduler != nil && authSnapshot != nil {
 		m.scheduler.upsertAuth(authSnapshot)
 	}
+	if stateChanged && authSnapshot != nil {
+		m.publishSharedAuthState(authSnapshot)
+	}
 
 	if clearModelQuota && result.Model != "" {
 		registry.GetGlobalRegistry().ClearModelQuotaExceeded(result.AuthID, result.Model)
@@ -2290,7 +2437,9 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		registry.GetGlobalRegistry().SuspendClientModel(result.AuthID, result.Model, suspendReason)
 	}
 
-	m.hook.OnResult(ctx, result)
+	if !probe {
+		m.hook.OnResult(ctx, result)
+	}
 }
 
 func ensureModelState(auth *Auth, model string) *ModelState {
@@ -2865,6 +3014,8 @@ func (m *Manager) pickNextLegacy(ctx context.Context, provider, model string, op
 
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
 	disallowFreeAuth := disallowFreeAuthFromMetadata(opts.Metadata)
+	waiter := slotWaiterFromContext(ctx)
+	var busy []string
 
 	m.mu.RLock()
 	executor, okExecutor := m.executors[provider]
@@ -2898,17 +3049,26 @@ func (m *Manager) pickNextLegacy(ctx context.Context, provider, model string, op
 		if modelKey != "" && !m.authSupportsRouteModel(registryRef, candidate, model) {
 			continue
 		}
+		if !m.slots.available(candidate.ID, authMaxConcurrent(candidate), waiter) {
+			busy = append(busy, candidate.ID)
+			continue
+		}
 		candidates = append(candidates, candidate)
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
+		if len(busy) > 0 {
+			return nil, nil, newAuthBusyError(busy)
+		}
 		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
-	available, errAvailable := m.availableAuthsForRouteModel(candidates, provider, model, time.Now())
+	now := time.Now()
+	available, errAvailable := m.availableAuthsForRouteModel(candidates, provider, model, now)
 	if errAvailable != nil {
 		m.mu.RUnlock()
 		return nil, nil, errAvailable
 	}
+	available = preferQuotaAvailable(available, model, m.quotaAvoidPercent(), now)
 	selected, errPick := m.selector.Pick(ctx, provider, selectionArgForSelector(m.selector, model), opts, available)
 	if errPick != nil {
 		m.mu.RUnlock()
@@ -3000,6 +3160,8 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
 	disallowFreeAuth := disallowFreeAuthFromMetadata(opts.Metadata)
+	waiter := slotWaiterFromContext(ctx)
+	var busy []string
 
 	providerSet := make(map[string]struct{}, len(providers))
 	for _, provider := range providers {
@@ -3050,17 +3212,26 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 		if modelKey != "" && !m.authSupportsRouteModel(registryRef, candidate, model) {
 			continue
 		}
+		if !m.slots.available(candidate.ID, authMaxConcurrent(candidate), waiter) {
+			busy = append(busy, candidate.ID)
+			continue
+		}
 		candidates = append(candidates, candidate)
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
+		if len(busy) > 0 {
+			return nil, nil, "", newAuthBusyError(busy)
+		}
 		return nil, nil, "", &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
-	available, errAvailable := m.availableAuthsForRouteModel(candidates, "mixed", model, time.Now())
+	now := time.Now()
+	available, errAvailable := m.availableAuthsForRouteModel(candidates, "mixed", model, now)
 	if errAvailable != nil {
 		m.mu.RUnlock()
 		return nil, nil, "", errAvailable
 	}
+	available = preferQuotaAvailable(available, model, m.quotaAvoidPercent(), now)
 	selected, errPick := m.selector.Pick(ctx, "mixed", selectionArgForSelector(m.selector, model), opts, available)
 	if errPick != nil {
 		m.mu.RUnlock()
@@ -3656,6 +3827,7 @@ func (m *Manager) tryAntigravityCreditsExecute(ctx context.Context, req cliproxy
 			execReq.Model = upstreamModel
 			resp, errExec := c.executor.Execute(creditsCtx, c.auth, execReq, creditsOpts)
 			result := Result{AuthID: c.auth.ID, Provider: c.provider, Model: resultModel, Success: errExec == nil}
+			result.Quota = readQuota(c.executor, c.auth, upstreamModel, resultModel, resp.Headers, errExec)
 			if errExec != nil {
 				result.Error = &Error{Message: errExec.Error()}
 				if se, ok := errors.AsType[cliproxyexecutor.StatusError](errExec); ok && se != nil {
@@ -0,0 +1,440 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultHealthProbeTimeout     = 30 * time.Second
+	defaultHealthProbeConcurrency = 4
+	// healthProbeMaxTokens keeps probe completions cheap while staying above the minimum
+	// output budget some upstreams enforce.
+	healthProbeMaxTokens = 16
+)
+
+// HealthProbeExecutor is implemented by executors that can check a credential with a cheaper
+// call than a completion, such as a token or quota introspection endpoint. Probes use it
+// instead of the minimal completion when available.
+type HealthProbeExecutor interface {
+	ProbeHealth(ctx context.Context, auth *Auth, model string) error
+}
+
+// HealthProbeOptions configures the background credential prober.
+type HealthProbeOptions struct {
+	// Interval is the pause between probe rounds. Zero stops the prober.
+	Interval time.Duration
+	// Timeout bounds a single probe.
+	Timeout time.Duration
+	// Concurrency caps the probes running at once.
+	Concurrency int
+	// Models pins the models probed per provider. Providers without an entry probe one
+	// registered model per model family.
+	Models map[string][]string
+}
+
+// ProbeResult is the outcome of one probe of an auth and model.
+type ProbeResult struct {
+	Model      string    `json:"model"`
+	Family     string    `json:"family"`
+	Healthy    bool      `json:"healthy"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	LatencyMs  int64     `json:"latency_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+	// Applied reports whether the result was applied to the routing state of the auth.
+	// Only definitive outcomes are: success, auth and payment errors, and rate limits.
+	Applied bool `json:"applied"`
+}
+
+// CredentialHealth is the routing state of an auth together with its latest probe results.
+type CredentialHealth struct {
+	AuthID      string        `json:"auth_id"`
+	Provider    string        `json:"provider"`
+	Label       string        `json:"label,omitempty"`
+	Status      Status        `json:"status"`
+	Unavailable bool          `json:"unavailable"`
+	Disabled    bool          `json:"disabled"`
+	Healthy     *bool         `json:"healthy,omitempty"`
+	LastProbeAt time.Time     `json:"last_probe_at,omitzero"`
+	Probes      []ProbeResult `json:"probes"`
+}
+
+// healthProber runs probe rounds and keeps the latest result per auth and model.
+type healthProber struct {
+	manager *Manager
+
+	mu      sync.Mutex
+	options HealthProbeOptions
+	cancel  context.CancelFunc
+	// results maps auth ID to model to the latest probe of that model.
+	results map[string]map[string]ProbeResult
+	// probing holds the auths with a probe in flight, so rounds and manual probes do not overlap.
+	probing map[string]struct{}
+}
+
+func newHealthProber(manager *Manager) *healthProber {
+	return &healthProber{
+		manager: manager,
+		results: make(map[string]map[string]ProbeResult),
+		probing: make(map[string]struct{}),
+	}
+}
+
+// SetHealthProbe starts, reconfigures or (with a zero interval) stops background probing.
+func (m *Manager) SetHealthProbe(options HealthProbeOptions) {
+	if m == nil || m.health == nil {
+		return
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = defaultHealthProbeTimeout
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = defaultHealthProbeConcurrency
+	}
+	p := m.health
+	p.mu.Lock()
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	p.options = options
+	if options.Interval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		p.cancel = cancel
+		go p.run(ctx, options.Interval)
+	}
+	p.mu.Unlock()
+}
+
+// ProbeAuth probes authID now and returns its updated health.
+func (m *Manager) ProbeAuth(ctx context.Context, authID string) (CredentialHealth, error) {
+	if m == nil || m.health == nil {
+		return CredentialHealth{}, &Error{Code: "auth_not_found", Message: "auth manager unavailable"}
+	}
+	auth, ok := m.GetByID(authID)
+	if !ok {
+		return CredentialHealth{}, &Error{Code: "auth_not_found", Message: "auth not found", HTTPStatus: http.StatusNotFound}
+	}
+	if auth.Disabled {
+		return CredentialHealth{}, &Error{Code: "auth_disabled", Message: "auth is disabled", HTTPStatus: http.StatusConflict}
+	}
+	if !m.health.probeAuth(ctx, auth) {
+		return CredentialHealth{}, &Error{Code: "probe_in_progress", Message: "a probe of this auth is already running", HTTPStatus: http.StatusConflict}
+	}
+	current, _ := m.GetByID(authID)
+	return m.health.report(current), nil
+}
+
+// CredentialHealthReport returns the health of every auth, ordered by provider and ID.
+func (m *Manager) CredentialHealthReport() []CredentialHealth {
+	if m == nil || m.health == nil {
+		return nil
+	}
+	auths := m.List()
+	sort.Slice(auths, func(i, j int) bool {
+		if auths[i].Provider != auths[j].Provider {
+			return auths[i].Provider < auths[j].Provider
+		}
+		return auths[i].ID < auths[j].ID
+	})
+	report := make([]CredentialHealth, 0, len(auths))
+	for _, auth := range auths {
+		report = append(report, m.health.report(auth))
+	}
+	return report
+}
+
+func (p *healthProber) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.round(ctx)
+		}
+	}
+}
+
+// round probes every enabled auth that has an executor, a few at a time.
+func (p *healthProber) round(ctx context.Context) {
+	p.mu.Lock()
+	concurrency := p.options.Concurrency
+	p.mu.Unlock()
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, auth := range p.manager.List() {
+		if auth.Disabled || p.manager.executorFor(auth.Provider) == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(auth *Auth) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			p.probeAuth(ctx, auth)
+		}(auth)
+	}
+	wg.Wait()
+	p.prune()
+}
+
+// probeAuth probes the models planned for auth one after another. It returns false when a
+// probe of auth is already running.
+func (p *healthProber) probeAuth(ctx context.Context, auth *Auth) bool {
+	p.mu.Lock()
+	if _, busy := p.probing[auth.ID]; busy {
+		p.mu.Unlock()
+		return false
+	}
+	p.probing[auth.ID] = struct{}{}
+	options := p.options
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.probing, auth.ID)
+		p.mu.Unlock()
+	}()
+
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = defaultHealthProbeTimeout
+	}
+	for _, model := range p.plan(auth, options.Models) {
+		if ctx.Err() != nil {
+			return true
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, timeout)
+		result := p.manager.probeModel(probeCtx, auth, model)
+		cancel()
+		if ctx.Err() != nil {
+			return true
+		}
+		p.mu.Lock()
+		if p.results[auth.ID] == nil {
+			p.results[auth.ID] = make(map[string]ProbeResult)
+		}
+		p.results[auth.ID][model] = result
+		p.mu.Unlock()
+	}
+	return true
+}
+
+// plan returns the models to probe for auth: the pinned models of its provider, or one
+// registered text model per family, preferring models currently cooling down so recovery
+// is noticed.
+func (p *healthProber) plan(auth *Auth, pinned map[string][]string) []string {
+	if models := pinned[strings.ToLower(auth.Provider)]; len(models) > 0 {
+		return models
+	}
+	now := time.Now()
+	chosen := make(map[string]string)
+	for _, info := range registry.GetGlobalRegistry().GetModelsForClient(auth.ID) {
+		if info == nil || !probeableModel(info) {
+			continue
+		}
+		family := modelFamily(info.ID)
+		current, ok := chosen[family]
+		switch {
+		case !ok:
+			chosen[family] = info.ID
+		case modelCoolingDown(auth, info.ID, now) && !modelCoolingDown(auth, current, now):
+			chosen[family] = info.ID
+		case modelCoolingDown(auth, info.ID, now) == modelCoolingDown(auth, current, now) && info.ID < current:
+			chosen[family] = info.ID
+		}
+	}
+	models := make([]string, 0, len(chosen))
+	for _, model := range chosen {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+func modelCoolingDown(auth *Auth, model string, now time.Time) bool {
+	state := auth.ModelStates[model]
+	return state != nil && state.Unavailable && state.NextRetryAfter.After(now)
+}
+
+// probeableModel skips models a text completion cannot exercise.
+func probeableModel(info *registry.ModelInfo) bool {
+	id := strings.ToLower(info.ID)
+	for _, marker := range []string{"image", "embedding", "tts", "imagen", "veo"} {
+		if strings.Contains(id, marker) {
+			return false
+		}
+	}
+	if len(info.SupportedOutputModalities) == 0 {
+		return true
+	}
+	for _, modality := range info.SupportedOutputModalities {
+		if strings.EqualFold(modality, "text") {
+			return true
+		}
+	}
+	return false
+}
+
+// modelFamily drops the version segments of a model ID, so "claude-sonnet-4-5-20250929" and
+// "claude-sonnet-4" share the family "claude-sonnet" and "gemini-2.5-pro" is "gemini-pro".
+func modelFamily(model string) string {
+	var kept []string
+	for _, segment := range strings.Split(strings.ToLower(model), "-") {
+		if segment == "" || strings.IndexFunc(segment, unicode.IsDigit) >= 0 {
+			continue
+		}
+		kept = append(kept, segment)
+	}
+	if len(kept) == 0 {
+		return strings.ToLower(model)
+	}
+	return strings.Join(kept, "-")
+}
+
+// probeModel sends one probe through the executor of auth and applies definitive outcomes.
+func (m *Manager) probeModel(ctx context.Context, auth *Auth, model string) ProbeResult {
+	result := ProbeResult{Model: model, Family: modelFamily(model), CheckedAt: time.Now()}
+	executor := m.executorFor(auth.Provider)
+	if executor == nil {
+		result.Error = fmt.Sprintf("no executor for provider %s", auth.Provider)
+		return result
+	}
+	if !m.slots.tryAcquire(auth.ID, authMaxConcurrent(auth), nil) {
+		result.Error = "skipped: auth is at its max-concurrent limit"
+		return result
+	}
+	defer m.slots.release(auth.ID)
+
+	candidates := m.executionModelCandidates(auth, model)
+	upstreamModel := model
+	if len(candidates) > 0 {
+		upstreamModel = candidates[0]
+	}
+	// Probes are not client traffic: keep them out of usage statistics, metrics and budgets.
+	execCtx := coreusage.WithHealthProbe(ctx)
+	if rt := m.roundTripperFor(auth); rt != nil {
+		execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+		execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+	}
+
+	started := time.Now()
+	var (
+		errProbe error
+		headers  http.Header
+	)
+	if prober, ok := executor.(HealthProbeExecutor); ok {
+		errProbe = prober.ProbeHealth(execCtx, auth, upstreamModel)
+	} else {
+		payload := fmt.Appendf(nil, `{"model":%q,"messages":[{"role":"user","content":"ping"}],"max_tokens":%d,"stream":false}`, model, healthProbeMaxTokens)
+		var resp cliproxyexecutor.Response
+		resp, errProbe = executor.Execute(execCtx, auth, cliproxyexecutor.Request{Model: upstreamModel, Payload: payload}, cliproxyexecutor.Options{
+			OriginalRequest: payload,
+			SourceFormat:    sdktranslator.FormatOpenAI,
+			Metadata:        map[string]any{cliproxyexecutor.RequestedModelMetadataKey: model},
+		})
+		headers = resp.Headers
+	}
+	result.LatencyMs = time.Since(started).Milliseconds()
+	result.Healthy = errProbe == nil
+
+	markable := Result{AuthID: auth.ID, Provider: auth.Provider, Model: m.stateModelForExecution(auth, model, upstreamModel, len(candidates) > 1), Success: errProbe == nil}
+	markable.Quota = readQuota(executor, auth, upstreamModel, markable.Model, headers, errProbe)
+	if errProbe != nil {
+		if ctx.Err() != nil {
+			result.Error = "probe timed out"
+			return result
+		}
+		result.Error = errProbe.Error()
+		markable.Error = &Error{Message: errProbe.Error()}
+		if se, ok := errors.AsType[cliproxyexecutor.StatusError](errProbe); ok && se != nil {
+			result.StatusCode = se.StatusCode()
+			markable.Error.HTTPStatus = result.StatusCode
+		}
+		markable.RetryAfter = retryAfterFromError(errProbe)
+	}
+	if result.Healthy || definitiveProbeFailure(result.StatusCode) {
+		m.markResult(ctx, markable, true)
+		result.Applied = true
+	}
+	if !result.Healthy {
+		log.Debugf("health probe: auth %s model %s failed: %s", auth.ID, model, result.Error)
+	}
+	return result
+}
+
+// definitiveProbeFailure reports whether a failed probe says something about the credential
+// rather than the probe itself or a transient upstream problem.
+func definitiveProbeFailure(statusCode int) bool {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusPaymentRequired, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	}
+	return false
+}
+
+// report combines the routing state of auth with its latest probes.
+func (p *healthProber) report(auth *Auth) CredentialHealth {
+	health := CredentialHealth{
+		AuthID:      auth.ID,
+		Provider:    auth.Provider,
+		Label:       auth.Label,
+		Status:      auth.Status,
+		Unavailable: auth.Unavailable,
+		Disabled:    auth.Disabled,
+		Probes:      []ProbeResult{},
+	}
+	p.mu.Lock()
+	for _, result := range p.results[auth.ID] {
+		health.Probes = append(health.Probes, result)
+	}
+	p.mu.Unlock()
+	if len(health.Probes) == 0 {
+		return health
+	}
+	sort.Slice(health.Probes, func(i, j int) bool { return health.Probes[i].Model < health.Probes[j].Model })
+	healthy := true
+	for _, result := range health.Probes {
+		healthy = healthy && result.Healthy
+		if result.CheckedAt.After(health.LastProbeAt) {
+			health.LastProbeAt = result.CheckedAt
+		}
+	}
+	health.Healthy = &healthy
+	return health
+}
+
+// prune drops the results of auths that were removed.
+func (p *healthProber) prune() {
+	known := make(map[string]struct{})
+	for _, auth := range p.manager.List() {
+		known[auth.ID] = struct{}{}
+	}
+	p.mu.Lock()
+	for authID := range p.results {
+		if _, ok := known[authID]; !ok {
+			delete(p.results, authID)
+		}
+	}
+	p.mu.Unlock()
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// probeTestExecutor fails with err until it is cleared and records the probed models and
+// the calls whose context would publish usage.
+type probeTestExecutor struct {
+	schedulerTestExecutor
+	mu      sync.Mutex
+	err     error
+	models  []string
+	counted int
+}
+
+func (e *probeTestExecutor) Execute(ctx context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.models = append(e.models, req.Model)
+	if !coreusage.IsHealthProbe(ctx) {
+		e.counted++
+	}
+	return cliproxyexecutor.Response{}, e.err
+}
+
+func (e *probeTestExecutor) setErr(err error) {
+	e.mu.Lock()
+	e.err = err
+	e.mu.Unlock()
+}
+
+type countingHook struct {
+	NoopHook
+	mu      sync.Mutex
+	results int
+}
+
+func (h *countingHook) OnResult(context.Context, Result) {
+	h.mu.Lock()
+	h.results++
+	h.mu.Unlock()
+}
+
+func TestManagerProbeAuth_MarksUnavailableAndRecovered(t *testing.T) {
+	model := "health-probe-model-1"
+	registerSchedulerModels(t, "gemini", model, "probe-a")
+	hook := &countingHook{}
+	manager := NewManager(nil, &RoundRobinSelector{}, hook)
+	executor := &probeTestExecutor{err: &retryAfterStatusError{status: http.StatusUnauthorized, message: "invalid credentials"}}
+	manager.executors["gemini"] = executor
+	if _, err := manager.Register(context.Background(), &Auth{ID: "probe-a", Provider: "gemini"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	health, err := manager.ProbeAuth(context.Background(), "probe-a")
+	if err != nil {
+		t.Fatalf("ProbeAuth() error = %v", err)
+	}
+	if health.Healthy == nil || *health.Healthy || len(health.Probes) != 1 {
+		t.Fatalf("health after failing probe = %+v", health)
+	}
+	if probe := health.Probes[0]; probe.StatusCode != http.StatusUnauthorized || !probe.Applied || probe.Family != "health-probe-model" {
+		t.Fatalf("probe result = %+v", probe)
+	}
+	auth, _ := manager.GetByID("probe-a")
+	if state := auth.ModelStates[model]; state == nil || !state.Unavailable || !state.NextRetryAfter.After(time.Now()) {
+		t.Fatalf("model state after failing probe = %+v", state)
+	}
+	if auth.Failed != 0 || auth.Success != 0 {
+		t.Fatalf("probe counted as user traffic: success=%d failed=%d", auth.Success, auth.Failed)
+	}
+
+	executor.setErr(nil)
+	if health, err = manager.ProbeAuth(context.Background(), "probe-a"); err != nil || health.Healthy == nil || !*health.Healthy {
+		t.Fatalf("health after passing probe = %+v, %v", health, err)
+	}
+	auth, _ = manager.GetByID("probe-a")
+	if state := auth.ModelStates[model]; !modelStateIsClean(state) {
+		t.Fatalf("model state was not recovered: %+v", state)
+	}
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if hook.results != 0 {
+		t.Fatalf("hook saw %d probe results", hook.results)
+	}
+	if executor.counted != 0 {
+		t.Fatalf("%d probes ran with a context that publishes usage", executor.counted)
+	}
+}
+
+func TestManagerProbeAuth_TransientFailureIsNotApplied(t *testing.T) {
+	model := "health-probe-model-2"
+	registerSchedulerModels(t, "gemini", model, "probe-b")
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.executors["gemini"] = &probeTestExecutor{err: &retryAfterStatusError{status: http.StatusBadGateway, message: "bad gateway"}}
+	if _, err := manager.Register(context.Background(), &Auth{ID: "probe-b", Provider: "gemini"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	health, err := manager.ProbeAuth(context.Background(), "probe-b")
+	if err != nil || len(health.Probes) != 1 || health.Probes[0].Applied {
+		t.Fatalf("ProbeAuth() = %+v, %v", health, err)
+	}
+	auth, _ := manager.GetByID("probe-b")
+	if state := auth.ModelStates[model]; state != nil && state.Unavailable {
+		t.Fatalf("transient probe failure cooled the model down: %+v", state)
+	}
+	if report := manager.CredentialHealthReport(); len(report) != 1 || report[0].AuthID != "probe-b" {
+		t.Fatalf("CredentialHealthReport() = %+v", report)
+	}
+}
+
+func TestHealthProberPlan_OneModelPerFamily(t *testing.T) {
+	t.Parallel()
+
+	for model, want := range map[string]string{
+		"claude-sonnet-4-5-20250929": "claude-sonnet",
+		"claude-sonnet-4":            "claude-sonnet",
+		"gemini-2.5-pro":             "gemini-pro",
+		"gpt-5-codex":                "gpt-codex",
+		"o3":                         "o3",
+	} {
+		if got := modelFamily(model); got != want {
+			t.Errorf("modelFamily(%q) = %q, want %q", model, got, want)
+		}
+	}
+
+	prober := newHealthProber(nil)
+	pinned := map[string][]string{"claude": {"claude-haiku-4-5"}}
+	if got := prober.plan(&Auth{ID: "unregistered", Provider: "Claude"}, pinned); len(got) != 1 || got[0] != "claude-haiku-4-5" {
+		t.Fatalf("plan() with pinned models = %v", got)
+	}
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// ModelFallbackHopHeader is the response header reporting which model-fallbacks hop served a request.
+const ModelFallbackHopHeader = "X-Model-Fallback-Hop"
+
+// modelFallbackHopMetadataKey marks Options.Metadata of an execution pinned to one fallback hop.
+// Its presence also stops the hop from expanding the alias chain again.
+const modelFallbackHopMetadataKey = "model_fallback_hop"
+
+// modelFallbackHop is a configured hop together with its 1-based position in the chain.
+type modelFallbackHop struct {
+	Index int
+	internalconfig.ModelFallbackHop
+}
+
+// String renders the hop as reported in ModelFallbackHopHeader.
+func (h modelFallbackHop) String() string {
+	out := fmt.Sprintf("%d; provider=%s; model=%s", h.Index, h.Provider, h.Model)
+	if h.AuthKind != "" {
+		out += "; auth-kind=" + h.AuthKind
+	}
+	return out
+}
+
+// modelFallbackChain returns the hops configured for the requested model, carrying any
+// thinking suffix over to hop models. It returns nil when the model is not a fallback
+// alias or when opts already belong to a hop.
+func (m *Manager) modelFallbackChain(requestedModel string, opts cliproxyexecutor.Options) []modelFallbackHop {
+	if m == nil || m.HomeEnabled() {
+		return nil
+	}
+	if _, ok := modelFallbackHopFromMetadata(opts.Metadata); ok {
+		return nil
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.ModelFallbacks) == 0 {
+		return nil
+	}
+	requested := thinking.ParseSuffix(requestedModel)
+	base := strings.TrimSpace(requested.ModelName)
+	if base == "" {
+		return nil
+	}
+	for _, fallback := range cfg.ModelFallbacks {
+		if !strings.EqualFold(fallback.Alias, base) {
+			continue
+		}
+		chain := make([]modelFallbackHop, 0, len(fallback.Chain))
+		for i, hop := range fallback.Chain {
+			hop.Model = preserveResolvedModelSuffix(hop.Model, requested)
+			chain = append(chain, modelFallbackHop{Index: i + 1, ModelFallbackHop: hop})
+		}
+		return chain
+	}
+	return nil
+}
+
+// ModelFallbackProviders returns the providers referenced by the fallback chain configured
+// for model, in chain order. It returns nil when model is not a fallback alias.
+func (m *Manager) ModelFallbackProviders(model string) []string {
+	chain := m.modelFallbackChain(model, cliproxyexecutor.Options{})
+	if len(chain) == 0 {
+		return nil
+	}
+	providers := make([]string, 0, len(chain))
+	for _, hop := range chain {
+		providers = append(providers, hop.Provider)
+	}
+	return m.normalizeProviders(providers)
+}
+
+// executeModelFallbacks runs exec for each hop in order until one succeeds. Errors that a
+// different upstream cannot fix (invalid requests, cancelled contexts) stop the chain.
+// Streaming callers only get an error before the first byte was produced, so moving to
+// the next hop never duplicates output.
+func executeModelFallbacks[T any](ctx context.Context, chain []modelFallbackHop, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, exec func(context.Context, []string, cliproxyexecutor.Request, cliproxyexecutor.Options) (T, error)) (T, error) {
+	var zero T
+	var lastErr error
+	for _, hop := range chain {
+		hopReq := req
+		hopReq.Model = hop.Model
+		hopOpts := withModelFallbackHop(opts, hop)
+		hopCtx := coreusage.WithFallbackHop(ctx, hop.Index)
+		result, errExec := exec(hopCtx, []string{hop.Provider}, hopReq, hopOpts)
+		if errExec == nil {
+			setModelFallbackHopHeader(ctx, hop)
+			return result, nil
+		}
+		if errCtx := ctx.Err(); errCtx != nil {
+			return zero, errCtx
+		}
+		if isRequestInvalidError(errExec) {
+			return zero, errExec
+		}
+		logEntryWithRequestID(ctx).Debugf("model fallback hop %s failed: %v", hop, errExec)
+		lastErr = errExec
+	}
+	if lastErr == nil {
+		lastErr = &Error{Code: "auth_not_found", Message: "no auth available"}
+	}
+	return zero, lastErr
+}
+
+func withModelFallbackHop(opts cliproxyexecutor.Options, hop modelFallbackHop) cliproxyexecutor.Options {
+	meta := make(map[string]any, len(opts.Metadata)+1)
+	for k, v := range opts.Metadata {
+		meta[k] = v
+	}
+	meta[modelFallbackHopMetadataKey] = hop
+	opts.Metadata = meta
+	return opts
+}
+
+func modelFallbackHopFromMetadata(meta map[string]any) (modelFallbackHop, bool) {
+	if len(meta) == 0 {
+		return modelFallbackHop{}, false
+	}
+	hop, ok := meta[modelFallbackHopMetadataKey].(modelFallbackHop)
+	return hop, ok
+}
+
+// authMatchesModelFallbackHop reports whether auth satisfies the auth-kind restriction of
+// the hop recorded in meta. Executions outside a fallback chain accept every auth.
+func authMatchesModelFallbackHop(auth *Auth, meta map[string]any) bool {
+	hop, ok := modelFallbackHopFromMetadata(meta)
+	if !ok || hop.AuthKind == "" {
+		return true
+	}
+	return authKindOf(auth) == hop.AuthKind
+}
+
+// authKindOf classifies auth as "apikey" or "oauth".
+func authKindOf(auth *Auth) string {
+	if auth == nil {
+		return ""
+	}
+	if auth.Attributes != nil {
+		if kind := strings.ToLower(strings.TrimSpace(auth.Attributes["auth_kind"])); kind != "" {
+			if kind == "apikey" {
+				return "apikey"
+			}
+			return "oauth"
+		}
+	}
+	if kind, _ := auth.AccountInfo(); strings.EqualFold(kind, "api_key") {
+		return "apikey"
+	}
+	return "oauth"
+}
+
+func setModelFallbackHopHeader(ctx context.Context, hop modelFallbackHop) {
+	if ctx == nil {
+		return
+	}
+	ginCtx, ok := ctx.Value("gin").(interface{ Header(string, string) })
+	if !ok || ginCtx == nil {
+		return
+	}
+	ginCtx.Header(ModelFallbackHopHeader, hop.String())
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+type fallbackHeaderSink struct {
+	values map[string]string
+}
+
+func (s *fallbackHeaderSink) Header(key, value string) { s.values[key] = value }
+
+func registerFallbackTestAuth(t *testing.T, m *Manager, id, provider, model string, attrs map[string]string) {
+	t.Helper()
+	auth := &Auth{ID: id, Provider: provider, Status: StatusActive, Attributes: attrs}
+	if _, err := m.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth %s: %v", id, err)
+	}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(id, provider, []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() { reg.UnregisterClient(id) })
+}
+
+func newModelFallbackTestManager(t *testing.T, chain []internalconfig.ModelFallbackHop) (*Manager, *openAICompatPoolExecutor, *openAICompatPoolExecutor) {
+	t.Helper()
+	cfg := &internalconfig.Config{ModelFallbacks: []internalconfig.ModelFallback{{Alias: "smart", Chain: chain}}}
+	cfg.SanitizeModelFallbacks()
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(cfg)
+	primary := &openAICompatPoolExecutor{id: "fb-primary"}
+	secondary := &openAICompatPoolExecutor{id: "fb-secondary"}
+	m.RegisterExecutor(primary)
+	m.RegisterExecutor(secondary)
+	registerFallbackTestAuth(t, m, "fb-primary-"+t.Name(), "fb-primary", "primary-model", map[string]string{"api_key": "k1"})
+	registerFallbackTestAuth(t, m, "fb-secondary-"+t.Name(), "fb-secondary", "secondary-model", map[string]string{"api_key": "k2"})
+	return m, primary, secondary
+}
+
+func TestManagerExecute_ModelFallbackMovesToNextHop(t *testing.T) {
+	m, primary, secondary := newModelFallbackTestManager(t, []internalconfig.ModelFallbackHop{
+		{Provider: "fb-primary", Model: "primary-model"},
+		{Provider: "fb-secondary", Model: "secondary-model"},
+	})
+	primary.executeErrors = map[string]error{"primary-model": &Error{HTTPStatus: http.StatusServiceUnavailable, Message: "overloaded"}}
+
+	if got := m.ModelFallbackProviders("smart"); len(got) != 2 || got[0] != "fb-primary" || got[1] != "fb-secondary" {
+		t.Fatalf("ModelFallbackProviders = %v", got)
+	}
+
+	sink := &fallbackHeaderSink{values: map[string]string{}}
+	ctx := context.WithValue(context.Background(), "gin", sink)
+	resp, err := m.Execute(ctx, m.ModelFallbackProviders("smart"), cliproxyexecutor.Request{Model: "smart"}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("execute error = %v", err)
+	}
+	if string(resp.Payload) != "secondary-model" {
+		t.Fatalf("payload = %q, want secondary-model", resp.Payload)
+	}
+	if got := primary.ExecuteModels(); len(got) != 1 || got[0] != "primary-model" {
+		t.Fatalf("primary calls = %v", got)
+	}
+	if got := secondary.ExecuteModels(); len(got) != 1 || got[0] != "secondary-model" {
+		t.Fatalf("secondary calls = %v", got)
+	}
+	if got := sink.values[ModelFallbackHopHeader]; got != "2; provider=fb-secondary; model=secondary-model" {
+		t.Fatalf("%s = %q", ModelFallbackHopHeader, got)
+	}
+}
+
+func TestManagerExecuteStream_ModelFallbackBeforeFirstByte(t *testing.T) {
+	m, primary, secondary := newModelFallbackTestManager(t, []internalconfig.ModelFallbackHop{
+		{Provider: "fb-primary", Model: "primary-model"},
+		{Provider: "fb-secondary", Model: "secondary-model"},
+	})
+	primary.streamFirstErrors = map[string]error{"primary-model": &Error{HTTPStatus: http.StatusTooManyRequests, Message: "quota"}}
+
+	streamResult, err := m.ExecuteStream(context.Background(), m.ModelFallbackProviders("smart"), cliproxyexecutor.Request{Model: "smart"}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("execute stream error = %v", err)
+	}
+	if got := readOpenAICompatStreamPayload(t, streamResult); got != "secondary-model" {
+		t.Fatalf("payload = %q, want secondary-model", got)
+	}
+	if got := secondary.StreamModels(); len(got) != 1 {
+		t.Fatalf("secondary stream calls = %v", got)
+	}
+}
+
+func TestManagerExecute_ModelFallbackHonorsAuthKind(t *testing.T) {
+	cfg := &internalconfig.Config{ModelFallbacks: []internalconfig.ModelFallback{{
+		Alias: "smart",
+		Chain: []internalconfig.ModelFallbackHop{{Provider: "fb-kind", Model: "kind-model", AuthKind: "api-key"}},
+	}}}
+	cfg.SanitizeModelFallbacks()
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(cfg)
+	executor := &authScopedOpenAICompatPoolExecutor{id: "fb-kind"}
+	m.RegisterExecutor(executor)
+	registerFallbackTestAuth(t, m, "fb-kind-oauth", "fb-kind", "kind-model", map[string]string{"auth_kind": "oauth"})
+	registerFallbackTestAuth(t, m, "fb-kind-apikey", "fb-kind", "kind-model", map[string]string{"auth_kind": "apikey", "api_key": "k"})
+
+	for i := 0; i < 3; i++ {
+		if _, err := m.Execute(context.Background(), []string{"fb-kind"}, cliproxyexecutor.Request{Model: "smart"}, cliproxyexecutor.Options{}); err != nil {
+			t.Fatalf("execute error = %v", err)
+		}
+	}
+	for _, call := range executor.ExecuteCalls() {
+		if call != "fb-kind-apikey|kind-model" {
+			t.Fatalf("unexpected call %q; calls = %v", call, executor.ExecuteCalls())
+		}
+	}
+}
+
+func TestManagerExecute_ModelFallbackStopsOnInvalidRequest(t *testing.T) {
+	m, primary, secondary := newModelFallbackTestManager(t, []internalconfig.ModelFallbackHop{
+		{Provider: "fb-primary", Model: "primary-model"},
+		{Provider: "fb-secondary", Model: "secondary-model"},
+	})
+	primary.executeErrors = map[string]error{"primary-model": &Error{HTTPStatus: http.StatusBadRequest, Message: "invalid_request_error: bad input"}}
+
+	if _, err := m.Execute(context.Background(), m.ModelFallbackProviders("smart"), cliproxyexecutor.Request{Model: "smart"}, cliproxyexecutor.Options{}); err == nil {
+		t.Fatal("expected invalid request error")
+	}
+	if got := secondary.ExecuteModels(); len(got) != 0 {
+		t.Fatalf("secondary should not be called, got %v", got)
+	}
+}
@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+const (
+	// defaultQuotaAvoidPercent is the window usage above which a credential is only picked
+	// when no other credential is available.
+	defaultQuotaAvoidPercent = 95
+	// quotaWindowStaleAfter is how long a window without a reset time is trusted.
+	quotaWindowStaleAfter = 15 * time.Minute
+)
+
+// QuotaReader is implemented by executors that read the quota and rate-limit signals an
+// upstream attaches to its responses. model is the upstream model the request was sent for,
+// statusCode is 0 when the request failed before a response arrived, headers is nil when the
+// failure carried none, and body is the error body of a failed request. It returns nil when
+// the response carried no quota signal.
+type QuotaReader interface {
+	ReadQuota(auth *Auth, model string, statusCode int, headers http.Header, body []byte) *QuotaSnapshot
+}
+
+// headerError is an error carrying the headers of the failed upstream response.
+type headerError interface {
+	error
+	Headers() http.Header
+}
+
+// QuotaWindow is one rate-limit or usage window reported by an upstream.
+type QuotaWindow struct {
+	// Name identifies the window, e.g. "requests", "tokens", "primary" or a Google quota ID.
+	Name string `json:"name"`
+	// Model scopes the window to one model; empty when it covers every model of the credential.
+	Model string `json:"model,omitempty"`
+	// Limit is the window size in requests or tokens; 0 when only a percentage is reported.
+	Limit int64 `json:"limit,omitempty"`
+	// Remaining is what is left in the window; nil when the upstream does not report it.
+	Remaining *int64 `json:"remaining,omitempty"`
+	// UsedPercent is the share of the window already used, from 0 to 100.
+	UsedPercent float64 `json:"used_percent"`
+	// WindowMinutes is the window length when the upstream reports it.
+	WindowMinutes int64 `json:"window_minutes,omitempty"`
+	// ResetAt is when the window resets.
+	ResetAt time.Time `json:"reset_at,omitzero"`
+}
+
+// QuotaSnapshot is the latest quota reading an upstream reported for a credential.
+type QuotaSnapshot struct {
+	// Source names the signal the snapshot was read from, e.g. "codex-headers".
+	Source string `json:"source"`
+	// PlanTier is the subscription or billing tier, when the upstream reveals it.
+	PlanTier string `json:"plan_tier,omitempty"`
+	// RemainingRequests is what is left of the request window, when reported.
+	RemainingRequests *int64 `json:"remaining_requests,omitempty"`
+	// RemainingTokens is what is left of the token window, when reported.
+	RemainingTokens *int64 `json:"remaining_tokens,omitempty"`
+	// ResetAt is when the most used window resets.
+	ResetAt time.Time `json:"reset_at,omitzero"`
+	// Windows lists every window the upstream reported.
+	Windows []QuotaWindow `json:"windows,omitempty"`
+	// UpdatedAt is when the reading was taken.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AddWindow appends window, deriving its used percentage from the limit and remaining
+// values when the upstream reports counts, and tracks the reset time of the most used window.
+func (q *QuotaSnapshot) AddWindow(window QuotaWindow) {
+	if q == nil {
+		return
+	}
+	if window.UsedPercent == 0 && window.Limit > 0 && window.Remaining != nil {
+		window.UsedPercent = float64(window.Limit-*window.Remaining) / float64(window.Limit) * 100
+	}
+	window.UsedPercent = math.Max(0, math.Min(100, window.UsedPercent))
+	mostUsed := -1.0
+	for _, existing := range q.Windows {
+		mostUsed = math.Max(mostUsed, existing.UsedPercent)
+	}
+	if window.UsedPercent > mostUsed || (window.UsedPercent == mostUsed && window.ResetAt.After(q.ResetAt)) {
+		q.ResetAt = window.ResetAt
+	}
+	q.Windows = append(q.Windows, window)
+}
+
+// UsedPercent returns the highest usage among the windows still in effect at now, whatever
+// model they are scoped to.
+func (q *QuotaSnapshot) UsedPercent(now time.Time) float64 {
+	return q.usedPercent(now, func(QuotaWindow) bool { return true })
+}
+
+// UsedPercentFor returns the highest usage among the windows still in effect at now that
+// apply to model: the credential-wide windows and those scoped to model.
+func (q *QuotaSnapshot) UsedPercentFor(now time.Time, model string) float64 {
+	modelKey := canonicalModelKey(model)
+	return q.usedPercent(now, func(window QuotaWindow) bool {
+		return window.Model == "" || canonicalModelKey(window.Model) == modelKey
+	})
+}
+
+func (q *QuotaSnapshot) usedPercent(now time.Time, applies func(QuotaWindow) bool) float64 {
+	if q == nil {
+		return 0
+	}
+	used := 0.0
+	for _, window := range q.Windows {
+		if !q.windowInEffect(window, now) || !applies(window) {
+			continue
+		}
+		used = math.Max(used, window.UsedPercent)
+	}
+	return used
+}
+
+// windowInEffect reports whether window still holds at now. A window without a reset time is
+// trusted for quotaWindowStaleAfter from the reading.
+func (q *QuotaSnapshot) windowInEffect(window QuotaWindow, now time.Time) bool {
+	if window.ResetAt.IsZero() {
+		return now.Sub(q.UpdatedAt) <= quotaWindowStaleAfter
+	}
+	return window.ResetAt.After(now)
+}
+
+// NearlyExhausted reports whether a window applying to model and still in effect at now has
+// used at least percent.
+func (q *QuotaSnapshot) NearlyExhausted(now time.Time, model string, percent float64) bool {
+	if q == nil || percent <= 0 {
+		return false
+	}
+	return q.UsedPercentFor(now, model) >= percent
+}
+
+// Clone returns a deep copy of the snapshot.
+func (q *QuotaSnapshot) Clone() *QuotaSnapshot {
+	if q == nil {
+		return nil
+	}
+	copySnapshot := *q
+	copySnapshot.RemainingRequests = cloneInt64(q.RemainingRequests)
+	copySnapshot.RemainingTokens = cloneInt64(q.RemainingTokens)
+	if len(q.Windows) > 0 {
+		copySnapshot.Windows = make([]QuotaWindow, len(q.Windows))
+		for i, window := range q.Windows {
+			window.Remaining = cloneInt64(window.Remaining)
+			copySnapshot.Windows[i] = window
+		}
+	}
+	return &copySnapshot
+}
+
+func cloneInt64(value *int64) *int64 {
+	if value == nil {
+		return nil
+	}
+	copyValue := *value
+	return &copyValue
+}
+
+// mergeQuotaSnapshot returns next, keeping the plan tier of previous when next lacks one and
+// the windows previous scoped to other models while they have not reset, so a reading for one
+// model does not forget that another is exhausted.
+func mergeQuotaSnapshot(previous, next *QuotaSnapshot) *QuotaSnapshot {
+	if next == nil {
+		return previous
+	}
+	merged := next.Clone()
+	if previous == nil {
+		return merged
+	}
+	if merged.PlanTier == "" {
+		merged.PlanTier = previous.PlanTier
+	}
+	reported := make(map[string]struct{}, len(next.Windows))
+	for _, window := range next.Windows {
+		reported[canonicalModelKey(window.Model)] = struct{}{}
+	}
+	for _, window := range previous.Windows {
+		if window.Model == "" || !window.ResetAt.After(merged.UpdatedAt) {
+			continue
+		}
+		if _, ok := reported[canonicalModelKey(window.Model)]; ok {
+			continue
+		}
+		window.Remaining = cloneInt64(window.Remaining)
+		merged.AddWindow(window)
+	}
+	return merged
+}
+
+// readQuota asks executor for the quota signals of an upstream response sent for
+// upstreamModel. headers are the response headers of a successful call; for a failed call they
+// come from errExec when it carries any. Windows scoped to upstreamModel are relabelled with
+// stateModel, the model the scheduler tracks the credential's state under.
+func readQuota(executor ProviderExecutor, auth *Auth, upstreamModel, stateModel string, headers http.Header, errExec error) *QuotaSnapshot {
+	reader, ok := executor.(QuotaReader)
+	if !ok || auth == nil {
+		return nil
+	}
+	statusCode := http.StatusOK
+	var body []byte
+	if errExec != nil {
+		statusCode = 0
+		if se, okStatus := errors.AsType[cliproxyexecutor.StatusError](errExec); okStatus && se != nil {
+			statusCode = se.StatusCode()
+		}
+		if headers == nil {
+			if he, okHeaders := errors.AsType[headerError](errExec); okHeaders && he != nil {
+				headers = he.Headers()
+			}
+		}
+		body = []byte(errExec.Error())
+	}
+	snapshot := reader.ReadQuota(auth, upstreamModel, statusCode, headers, body)
+	if snapshot == nil {
+		return nil
+	}
+	if upstreamKey := canonicalModelKey(upstreamModel); upstreamKey != "" && strings.TrimSpace(stateModel) != "" {
+		for i := range snapshot.Windows {
+			if canonicalModelKey(snapshot.Windows[i].Model) == upstreamKey {
+				snapshot.Windows[i].Model = strings.TrimSpace(stateModel)
+			}
+		}
+	}
+	if snapshot.UpdatedAt.IsZero() {
+		snapshot.UpdatedAt = time.Now()
+	}
+	return snapshot
+}
+
+// quotaAvoidPercent returns the window usage from which the scheduler avoids a credential;
+// 0 turns the check off.
+func quotaAvoidPercent(cfg *internalconfig.Config) float64 {
+	if cfg == nil || cfg.Routing.QuotaAvoidPercent == 0 {
+		return defaultQuotaAvoidPercent
+	}
+	if cfg.Routing.QuotaAvoidPercent < 0 {
+		return 0
+	}
+	return float64(cfg.Routing.QuotaAvoidPercent)
+}
+
+func (m *Manager) quotaAvoidPercent() float64 {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	return quotaAvoidPercent(cfg)
+}
+
+// preferQuotaAvailable drops auths whose quota for model is nearly exhausted, unless that
+// leaves none. auths are expected to share one priority tier, so avoidance never moves a
+// request to a lower tier.
+func preferQuotaAvailable(auths []*Auth, model string, percent float64, now time.Time) []*Auth {
+	if percent <= 0 || len(auths) < 2 {
+		return auths
+	}
+	preferred := make([]*Auth, 0, len(auths))
+	for _, auth := range auths {
+		if auth != nil && !auth.QuotaSnapshot.NearlyExhausted(now, model, percent) {
+			preferred = append(preferred, auth)
+		}
+	}
+	if len(preferred) == 0 {
+		return auths
+	}
+	return preferred
+}
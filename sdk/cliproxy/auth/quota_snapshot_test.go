@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// quotaTestExecutor reports the used percent configured for each auth in a response header.
+type quotaTestExecutor struct {
+	schedulerTestExecutor
+	used map[string]int
+}
+
+func (e *quotaTestExecutor) Execute(_ context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	headers := http.Header{}
+	headers.Set("X-Test-Used-Percent", strconv.Itoa(e.used[auth.ID]))
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID), Headers: headers}, nil
+}
+
+func (e *quotaTestExecutor) ReadQuota(_ *Auth, _ string, _ int, headers http.Header, _ []byte) *QuotaSnapshot {
+	used, err := strconv.Atoi(headers.Get("X-Test-Used-Percent"))
+	if err != nil {
+		return nil
+	}
+	snapshot := &QuotaSnapshot{Source: "test", PlanTier: "pro"}
+	snapshot.AddWindow(QuotaWindow{Name: "primary", UsedPercent: float64(used), ResetAt: time.Now().Add(time.Hour)})
+	return snapshot
+}
+
+func quotaSnapshotUsed(percent float64, resetAt time.Time) *QuotaSnapshot {
+	snapshot := &QuotaSnapshot{Source: "test", UpdatedAt: time.Now()}
+	snapshot.AddWindow(QuotaWindow{Name: "primary", UsedPercent: percent, ResetAt: resetAt})
+	return snapshot
+}
+
+func TestQuotaSnapshotAddWindow(t *testing.T) {
+	resetAt := time.Now().Add(time.Minute)
+	remaining := int64(10)
+	snapshot := &QuotaSnapshot{}
+	snapshot.AddWindow(QuotaWindow{Name: "tokens", Limit: 1000, Remaining: &remaining, ResetAt: resetAt})
+	snapshot.AddWindow(QuotaWindow{Name: "requests", UsedPercent: 20, ResetAt: resetAt.Add(time.Hour)})
+
+	if got := snapshot.Windows[0].UsedPercent; got != 99 {
+		t.Fatalf("derived used percent = %v, want 99", got)
+	}
+	if !snapshot.ResetAt.Equal(resetAt) {
+		t.Fatalf("ResetAt = %v, want the reset of the most used window %v", snapshot.ResetAt, resetAt)
+	}
+	clone := snapshot.Clone()
+	*clone.Windows[0].Remaining = 500
+	if *snapshot.Windows[0].Remaining != 10 {
+		t.Fatal("Clone() shares the remaining counter with the original")
+	}
+}
+
+func TestQuotaSnapshotNearlyExhaustedIgnoresPastWindows(t *testing.T) {
+	now := time.Now()
+	if !quotaSnapshotUsed(97, now.Add(time.Minute)).NearlyExhausted(now, "", 95) {
+		t.Fatal("97% used window should be nearly exhausted at 95%")
+	}
+	if quotaSnapshotUsed(100, now.Add(-time.Second)).NearlyExhausted(now, "", 95) {
+		t.Fatal("a window that already reset must not count")
+	}
+	stale := quotaSnapshotUsed(100, time.Time{})
+	stale.UpdatedAt = now.Add(-2 * quotaWindowStaleAfter)
+	if stale.NearlyExhausted(now, "", 95) {
+		t.Fatal("a stale window without reset time must not count")
+	}
+	if quotaSnapshotUsed(100, now.Add(time.Minute)).NearlyExhausted(now, "", 0) {
+		t.Fatal("threshold 0 disables the check")
+	}
+}
+
+func TestQuotaSnapshotNearlyExhaustedScopesWindowsToModel(t *testing.T) {
+	now := time.Now()
+	snapshot := &QuotaSnapshot{Source: "test", UpdatedAt: now}
+	snapshot.AddWindow(QuotaWindow{Name: "per-model", Model: "model-a", UsedPercent: 100, ResetAt: now.Add(time.Minute)})
+	if !snapshot.NearlyExhausted(now, "model-a(high)", 95) {
+		t.Fatal("a window scoped to model-a should count for model-a")
+	}
+	if snapshot.NearlyExhausted(now, "model-b", 95) {
+		t.Fatal("a window scoped to model-a must not count for model-b")
+	}
+	if got := snapshot.UsedPercent(now); got != 100 {
+		t.Fatalf("UsedPercent() = %v, want 100 across every model", got)
+	}
+	snapshot.AddWindow(QuotaWindow{Name: "account", UsedPercent: 96, ResetAt: now.Add(time.Minute)})
+	if !snapshot.NearlyExhausted(now, "model-b", 95) {
+		t.Fatal("a window without model should count for every model")
+	}
+}
+
+func TestMergeQuotaSnapshotKeepsOtherModelWindows(t *testing.T) {
+	now := time.Now()
+	previous := &QuotaSnapshot{Source: "test", PlanTier: "free", UpdatedAt: now.Add(-time.Minute)}
+	previous.AddWindow(QuotaWindow{Name: "q", Model: "model-a", UsedPercent: 100, ResetAt: now.Add(time.Hour)})
+	previous.AddWindow(QuotaWindow{Name: "q", Model: "model-b", UsedPercent: 100, ResetAt: now.Add(time.Hour)})
+	previous.AddWindow(QuotaWindow{Name: "q", Model: "model-c", UsedPercent: 100, ResetAt: now.Add(-time.Second)})
+	next := &QuotaSnapshot{Source: "test", UpdatedAt: now}
+	next.AddWindow(QuotaWindow{Name: "q", Model: "model-b", UsedPercent: 50, ResetAt: now.Add(time.Hour)})
+
+	merged := mergeQuotaSnapshot(previous, next)
+	if merged.PlanTier != "free" {
+		t.Fatalf("PlanTier = %q, want the previous tier", merged.PlanTier)
+	}
+	if !merged.NearlyExhausted(now, "model-a", 95) {
+		t.Fatal("model-a window should survive a reading for model-b")
+	}
+	if merged.NearlyExhausted(now, "model-b", 95) {
+		t.Fatal("model-b window should be replaced by the new reading")
+	}
+	if len(merged.Windows) != 2 {
+		t.Fatalf("windows = %+v, want model-b from next and model-a from previous", merged.Windows)
+	}
+}
+
+func TestSchedulerPick_AvoidsNearlyExhaustedQuota(t *testing.T) {
+	t.Parallel()
+
+	exhausted := &Auth{ID: "a", Provider: "codex", QuotaSnapshot: quotaSnapshotUsed(98, time.Now().Add(time.Hour))}
+	scheduler := newSchedulerForTest(&RoundRobinSelector{}, exhausted, &Auth{ID: "b", Provider: "codex"})
+
+	for index := 0; index < 3; index++ {
+		got, errPick := scheduler.pickSingle(context.Background(), "codex", "", cliproxyexecutor.Options{}, nil)
+		if errPick != nil || got == nil || got.ID != "b" {
+			t.Fatalf("pickSingle() #%d = %v, %v; want b", index, got, errPick)
+		}
+	}
+
+	got, errPick := scheduler.pickSingle(context.Background(), "codex", "", cliproxyexecutor.Options{}, map[string]struct{}{"b": {}})
+	if errPick != nil || got == nil || got.ID != "a" {
+		t.Fatalf("pickSingle() with b tried = %v, %v; want the exhausted auth as fallback", got, errPick)
+	}
+
+	got, provider, errPick := scheduler.pickMixed(context.Background(), []string{"codex", "claude"}, "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil || got == nil || got.ID != "b" || provider != "codex" {
+		t.Fatalf("pickMixed() = %v, %q, %v; want b", got, provider, errPick)
+	}
+
+	scheduler.setQuotaAvoidPercent(0)
+	seen := map[string]bool{}
+	for index := 0; index < 2; index++ {
+		got, errPick = scheduler.pickSingle(context.Background(), "codex", "", cliproxyexecutor.Options{}, nil)
+		if errPick != nil || got == nil {
+			t.Fatalf("pickSingle() with check disabled #%d = %v, %v", index, got, errPick)
+		}
+		seen[got.ID] = true
+	}
+	if !seen["a"] || !seen["b"] {
+		t.Fatalf("picks with check disabled = %v, want both auths", seen)
+	}
+}
+
+func TestManagerExecute_StoresQuotaSnapshotAndAvoidsExhaustedAuth(t *testing.T) {
+	model := "quota-snapshot-model"
+	registerSchedulerModels(t, "codex", model, "quota-a", "quota-b")
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{QuotaAvoidPercent: 90}})
+	manager.executors["codex"] = &quotaTestExecutor{used: map[string]int{"quota-a": 92, "quota-b": 30}}
+	for _, id := range []string{"quota-a", "quota-b"} {
+		if _, err := manager.Register(context.Background(), &Auth{ID: id, Provider: "codex"}); err != nil {
+			t.Fatalf("Register(%s) error = %v", id, err)
+		}
+	}
+
+	var picked []string
+	for index := 0; index < 4; index++ {
+		resp, err := manager.Execute(context.Background(), []string{"codex"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+		if err != nil {
+			t.Fatalf("Execute() #%d error = %v", index, err)
+		}
+		picked = append(picked, string(resp.Payload))
+	}
+	seenA := false
+	for index, id := range picked {
+		if id == "quota-a" {
+			if seenA {
+				t.Fatalf("picks = %v, want quota-a avoided after its snapshot (#%d)", picked, index)
+			}
+			seenA = true
+		}
+	}
+
+	auth, ok := manager.GetByID("quota-a")
+	if !ok || auth.QuotaSnapshot == nil {
+		t.Fatalf("quota-a snapshot missing: %+v", auth)
+	}
+	if auth.QuotaSnapshot.PlanTier != "pro" || auth.QuotaSnapshot.UsedPercent(time.Now()) != 92 || auth.QuotaSnapshot.UpdatedAt.IsZero() {
+		t.Fatalf("quota-a snapshot = %+v", auth.QuotaSnapshot)
+	}
+
+	if _, err := manager.Update(context.Background(), &Auth{ID: "quota-a", Provider: "codex"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if auth, _ = manager.GetByID("quota-a"); auth.QuotaSnapshot == nil {
+		t.Fatal("Update() dropped the quota snapshot")
+	}
+}
+
+func TestSchedulerPick_QuotaAvoidanceKeepsPriorityTier(t *testing.T) {
+	t.Parallel()
+
+	exhausted := &Auth{ID: "high", Provider: "gemini", Attributes: map[string]string{"priority": "10"}, QuotaSnapshot: quotaSnapshotUsed(99, time.Now().Add(time.Hour))}
+	scheduler := newSchedulerForTest(&RoundRobinSelector{}, exhausted, &Auth{ID: "low", Provider: "gemini", Attributes: map[string]string{"priority": "0"}})
+
+	got, errPick := scheduler.pickSingle(context.Background(), "gemini", "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil || got == nil || got.ID != "high" {
+		t.Fatalf("pickSingle() = %v, %v; want the higher priority auth despite its quota", got, errPick)
+	}
+	got, _, errPick = scheduler.pickMixed(context.Background(), []string{"gemini", "claude"}, "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil || got == nil || got.ID != "high" {
+		t.Fatalf("pickMixed() = %v, %v; want the higher priority auth despite its quota", got, errPick)
+	}
+}
+
+func TestSchedulerPick_QuotaAvoidanceChecksRequestedModel(t *testing.T) {
+	registerSchedulerModels(t, "gemini", "quota-model-b", "quota-model-a1", "quota-model-a2")
+	snapshot := &QuotaSnapshot{Source: "test", UpdatedAt: time.Now()}
+	snapshot.AddWindow(QuotaWindow{Name: "per-model", Model: "quota-model-a", UsedPercent: 100, ResetAt: time.Now().Add(time.Hour)})
+	scheduler := newSchedulerForTest(&RoundRobinSelector{},
+		&Auth{ID: "quota-model-a1", Provider: "gemini", QuotaSnapshot: snapshot},
+		&Auth{ID: "quota-model-a2", Provider: "gemini"},
+	)
+
+	seen := map[string]bool{}
+	for index := 0; index < 2; index++ {
+		got, errPick := scheduler.pickSingle(context.Background(), "gemini", "quota-model-b", cliproxyexecutor.Options{}, nil)
+		if errPick != nil || got == nil {
+			t.Fatalf("pickSingle() #%d = %v, %v", index, got, errPick)
+		}
+		seen[got.ID] = true
+	}
+	if !seen["quota-model-a1"] || !seen["quota-model-a2"] {
+		t.Fatalf("picks for quota-model-b = %v, want both auths since only quota-model-a is exhausted", seen)
+	}
+}
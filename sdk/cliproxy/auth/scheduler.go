@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -37,6 +38,13 @@ type authScheduler struct {
 	providers     map[string]*providerScheduler
 	authProviders map[string]string
 	mixedCursors  map[string]int
+	// slots reports which auths are at their max-concurrent limit; nil disables the check.
+	slots *authSlots
+	// cursors shares round-robin positions with other instances when a backend is attached.
+	cursors *sharedCursors
+	// quotaAvoidPercent is the quota window usage from which an auth is only picked when no
+	// other auth is ready; 0 disables the check.
+	quotaAvoidPercent float64
 }
 
 // providerScheduler stores auth metadata and model shards for a single provider.
@@ -44,6 +52,7 @@ type providerScheduler struct {
 	providerKey string
 	auths       map[string]*scheduledAuthMeta
 	modelShards map[string]*modelScheduler
+	cursors     *sharedCursors
 }
 
 // scheduledAuthMeta stores the immutable scheduling fields derived from an auth snapshot.
@@ -53,12 +62,15 @@ type scheduledAuthMeta struct {
 	priority          int
 	virtualParent     string
 	websocketEnabled  bool
+	maxConcurrent     int
 	supportedModelSet map[string]struct{}
 }
 
 // modelScheduler tracks ready and blocked auths for one provider/model combination.
 type modelScheduler struct {
+	providerKey     string
 	modelKey        string
+	cursors         *sharedCursors
 	entries         map[string]*scheduledAuth
 	priorityOrder   []int
 	readyByPriority map[int]*readyBucket
@@ -170,6 +182,9 @@ func newAuthScheduler(selector Selector) *authScheduler {
 		providers:     make(map[string]*providerScheduler),
 		authProviders: make(map[string]string),
 		mixedCursors:  make(map[string]int),
+		cursors:       &sharedCursors{},
+
+		quotaAvoidPercent: defaultQuotaAvoidPercent,
 	}
 }
 
@@ -196,6 +211,16 @@ func (s *authScheduler) setSelector(selector Selector) {
 	clear(s.mixedCursors)
 }
 
+// setQuotaAvoidPercent updates the quota usage from which auths are avoided.
+func (s *authScheduler) setQuotaAvoidPercent(percent float64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotaAvoidPercent = percent
+}
+
 // rebuild recreates the complete scheduler state from an auth snapshot.
 func (s *authScheduler) rebuild(auths []*Auth) {
 	if s == nil {
@@ -270,9 +295,24 @@ func (s *authScheduler) pickSingle(ctx context.Context, provider, model string,
 		}
 		return true
 	}
-	if picked := shard.pickReadyLocked(preferWebsocket, s.strategy, predicate); picked != nil {
+	capacity, busy := s.capacityPredicateLocked(ctx, predicate)
+	if preferred := s.quotaPredicateLocked(predicate, modelKey, time.Now()); preferred != nil {
+		// Quota avoidance only reorders auths within the tier that would be picked anyway, so a
+		// nearly exhausted auth still wins over a lower configured priority.
+		shard.promoteExpiredLocked(time.Now())
+		if priority, ok := shard.highestReadyPriorityLocked(preferWebsocket, capacity); ok {
+			preferredCapacity, _ := s.capacityPredicateLocked(ctx, preferred)
+			if picked := shard.pickReadyAtPriorityLocked(preferWebsocket, priority, s.strategy, preferredCapacity); picked != nil {
+				return picked, nil
+			}
+		}
+	}
+	if picked := shard.pickReadyLocked(preferWebsocket, s.strategy, capacity); picked != nil {
 		return picked, nil
 	}
+	if len(*busy) > 0 {
+		return nil, newAuthBusyError(*busy)
+	}
 	return nil, shard.unavailableErrorLocked(provider, model, predicate)
 }
 
@@ -323,37 +363,55 @@ func (s *authScheduler) pickMixed(ctx context.Context, providers []string, model
 			_, ok := tried[pinnedAuthID]
 			return !ok
 		}
-		if picked := shard.pickReadyLocked(false, s.strategy, predicate); picked != nil {
+		capacity, busy := s.capacityPredicateLocked(ctx, predicate)
+		if picked := shard.pickReadyLocked(false, s.strategy, capacity); picked != nil {
 			return picked, providerKey, nil
 		}
+		if len(*busy) > 0 {
+			return nil, "", newAuthBusyError(*busy)
+		}
 		return nil, "", shard.unavailableErrorLocked("mixed", model, predicate)
 	}
 
-	predicate := triedPredicate(tried)
 	candidateShards := make([]*modelScheduler, len(normalized))
-	bestPriority := 0
-	hasCandidate := false
 	now := time.Now()
 	for providerIndex, providerKey := range normalized {
 		providerState := s.providers[providerKey]
 		if providerState == nil {
 			continue
 		}
-		shard := providerState.ensureModelLocked(modelKey, now)
-		candidateShards[providerIndex] = shard
-		if shard == nil {
-			continue
-		}
-		priorityReady, okPriority := shard.highestReadyPriorityLocked(false, predicate)
-		if !okPriority {
-			continue
+		candidateShards[providerIndex] = providerState.ensureModelLocked(modelKey, now)
+	}
+	highestReady := func(predicate func(*scheduledAuth) bool) (int, bool) {
+		bestPriority := 0
+		hasCandidate := false
+		for _, shard := range candidateShards {
+			if shard == nil {
+				continue
+			}
+			priorityReady, okPriority := shard.highestReadyPriorityLocked(false, predicate)
+			if !okPriority {
+				continue
+			}
+			if !hasCandidate || priorityReady > bestPriority {
+				bestPriority = priorityReady
+				hasCandidate = true
+			}
 		}
-		if !hasCandidate || priorityReady > bestPriority {
-			bestPriority = priorityReady
-			hasCandidate = true
+		return bestPriority, hasCandidate
+	}
+	predicate, busy := s.capacityPredicateLocked(ctx, triedPredicate(tried))
+	bestPriority, hasCandidate := highestReady(predicate)
+	if preferred := s.quotaPredicateLocked(triedPredicate(tried), modelKey, now); preferred != nil && hasCandidate {
+		preferredCapacity, _ := s.capacityPredicateLocked(ctx, preferred)
+		if preferredPriority, ok := highestReady(preferredCapacity); ok && preferredPriority == bestPriority {
+			predicate = preferredCapacity
 		}
 	}
 	if !hasCandidate {
+		if len(*busy) > 0 {
+			return nil, "", newAuthBusyError(*busy)
+		}
 		return nil, "", s.mixedUnavailableErrorLocked(normalized, model, tried)
 	}
 
@@ -462,6 +520,41 @@ func (s *authScheduler) mixedUnavailableErrorLocked(providers []string, model st
 	return &Error{Code: "auth_unavailable", Message: "no auth available"}
 }
 
+// capacityPredicateLocked narrows predicate to auths below their max-concurrent limit. Auths
+// rejected only for being full are collected in busy so the caller can wait for a slot.
+func (s *authScheduler) capacityPredicateLocked(ctx context.Context, predicate func(*scheduledAuth) bool) (func(*scheduledAuth) bool, *[]string) {
+	busy := new([]string)
+	if s.slots == nil {
+		return predicate, busy
+	}
+	waiter := slotWaiterFromContext(ctx)
+	return func(entry *scheduledAuth) bool {
+		if !predicate(entry) {
+			return false
+		}
+		if entry.meta == nil || entry.meta.maxConcurrent <= 0 {
+			return true
+		}
+		if s.slots.available(entry.auth.ID, entry.meta.maxConcurrent, waiter) {
+			return true
+		}
+		*busy = append(*busy, entry.auth.ID)
+		return false
+	}, busy
+}
+
+// quotaPredicateLocked narrows predicate to auths whose latest upstream quota reading for
+// model is below the avoid threshold. It returns nil when the check is disabled.
+func (s *authScheduler) quotaPredicateLocked(predicate func(*scheduledAuth) bool, model string, now time.Time) func(*scheduledAuth) bool {
+	if s.quotaAvoidPercent <= 0 {
+		return nil
+	}
+	percent := s.quotaAvoidPercent
+	return func(entry *scheduledAuth) bool {
+		return predicate(entry) && !entry.auth.QuotaSnapshot.NearlyExhausted(now, model, percent)
+	}
+}
+
 // triedPredicate builds a filter that excludes auths already attempted for the current request.
 func triedPredicate(tried map[string]struct{}) func(*scheduledAuth) bool {
 	if len(tried) == 0 {
@@ -549,6 +642,7 @@ func (s *authScheduler) ensureProviderLocked(providerKey string) *providerSchedu
 			providerKey: providerKey,
 			auths:       make(map[string]*scheduledAuthMeta),
 			modelShards: make(map[string]*modelScheduler),
+			cursors:     s.cursors,
 		}
 		s.providers[providerKey] = providerState
 	}
@@ -568,6 +662,7 @@ func buildScheduledAuthMeta(auth *Auth) *scheduledAuthMeta {
 		priority:          authPriority(auth),
 		virtualParent:     virtualParent,
 		websocketEnabled:  authWebsocketsEnabled(auth),
+		maxConcurrent:     authMaxConcurrent(auth),
 		supportedModelSet: supportedModelSetForAuth(auth.ID),
 	}
 }
@@ -638,7 +733,9 @@ func (p *providerScheduler) ensureModelLocked(modelKey string, now time.Time) *m
 		return shard
 	}
 	shard := &modelScheduler{
+		providerKey:     p.providerKey,
 		modelKey:        modelKey,
+		cursors:         p.cursors,
 		entries:         make(map[string]*scheduledAuth),
 		readyByPriority: make(map[int]*readyBucket),
 	}
@@ -818,6 +915,15 @@ func (m *modelScheduler) pickReadyAtPriorityLocked(preferWebsocket bool, priorit
 	if strategy == schedulerStrategyFillFirst {
 		picked = view.pickFirst(predicate)
 	} else {
+		if len(view.parentOrder) <= 1 && m.cursors.active() {
+			cursorKey := m.providerKey + ":" + m.modelKey + ":" + strconv.Itoa(priority)
+			if view == &bucket.ws {
+				cursorKey += ":ws"
+			}
+			if position, ok := m.cursors.next(cursorKey); ok {
+				view.cursor = position
+			}
+		}
 		picked = view.pickRoundRobin(predicate)
 	}
 	if picked == nil || picked.auth == nil {
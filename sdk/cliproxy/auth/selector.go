@@ -28,6 +28,8 @@ type RoundRobinSelector struct {
 	mu      sync.Mutex
 	cursors map[string]int
 	maxKeys int
+	// shared supplies round-robin positions agreed with other instances, when attached.
+	shared *sharedCursors
 }
 
 // FillFirstSelector selects the first available credential (deterministic ordering).
@@ -311,6 +313,9 @@ func (s *RoundRobinSelector) Pick(ctx context.Context, provider, model string, o
 	// Flat round-robin for non-grouped auths (original behavior).
 	s.ensureCursorKey(key, limit)
 	index := s.cursors[key]
+	if position, ok := s.shared.next(key); ok {
+		index = position
+	}
 	if index >= 2_147_483_640 {
 		index = 0
 	}
@@ -319,6 +324,13 @@ func (s *RoundRobinSelector) Pick(ctx context.Context, provider, model string, o
 	return available[index%len(available)], nil
 }
 
+// setSharedCursors makes the flat rotation follow positions shared between instances.
+func (s *RoundRobinSelector) setSharedCursors(shared *sharedCursors) {
+	s.mu.Lock()
+	s.shared = shared
+	s.mu.Unlock()
+}
+
 // ensureCursorKey ensures the cursor map has capacity for the given key.
 // Must be called with s.mu held.
 func (s *RoundRobinSelector) ensureCursorKey(key string, limit int) {
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+const (
+	// latencyEWMAAlpha is the smoothing factor applied to each new latency or outcome sample.
+	latencyEWMAAlpha = 0.2
+	// leastLatencyFailurePenalty scales the latency score by failure rate; a credential that
+	// always fails scores (1 + penalty) times its latency.
+	leastLatencyFailurePenalty = 9.0
+	// leastLatencyExploreRate is the share of picks that go to a random credential so that
+	// estimates for credentials that are not currently preferred stay fresh.
+	leastLatencyExploreRate = 0.05
+	// leastLatencyProbeTimeout is how long a pick of a credential without samples blocks
+	// further picks of it while no result has been recorded.
+	leastLatencyProbeTimeout = time.Minute
+)
+
+// WeightedSelector distributes requests proportionally to each credential's weight using
+// smooth weighted round-robin. Weights come from the "weight" attribute (default 1).
+// Selection happens within the highest available priority bucket.
+type WeightedSelector struct {
+	mu      sync.Mutex
+	current map[string]map[string]int
+	maxKeys int
+}
+
+// LeastLatencySelector prefers the credential with the lowest latency, penalized by its
+// failure rate. Both are rolling EWMAs updated by Manager.MarkResult. Candidates are compared
+// by time to first byte when all of them have streamed, and by total latency otherwise.
+// Credentials without samples are tried first, one request at a time, and a small share of
+// picks is spread randomly to keep the estimates current. Selection happens within the
+// highest available priority bucket.
+type LeastLatencySelector struct {
+	// random returns a value in [0, 1); nil uses math/rand.
+	random func() float64
+
+	mu sync.Mutex
+	// probes records when a credential without samples was last picked, so concurrent
+	// requests do not all pile onto it before its first result arrives.
+	probes map[string]time.Time
+}
+
+// LatencySnapshot reports the rolling latency and failure statistics of a credential.
+type LatencySnapshot struct {
+	// Latency is the EWMA of the total latency of successful calls.
+	Latency time.Duration `json:"latency"`
+	// TTFB is the EWMA of the time to first byte of successful streaming calls.
+	TTFB time.Duration `json:"ttfb"`
+	// FailureRate is the EWMA of failed outcomes in [0, 1].
+	FailureRate float64 `json:"failure_rate"`
+	// Samples counts the outcomes observed.
+	Samples int64 `json:"samples"`
+}
+
+// latencyStats holds the rolling EWMA statistics captured from execution results.
+type latencyStats struct {
+	latencyMs      float64
+	ttfbMs         float64
+	failureRate    float64
+	samples        int64
+	latencySamples int64
+	ttfbSamples    int64
+}
+
+// observe folds one execution outcome into the statistics. Latency and time to first byte
+// are tracked separately, since a stream's first byte arrives long before its last, and are
+// only sampled from successful calls, since failures such as rate limits often return faster
+// than real work. ttfb is zero for non-streaming calls.
+func (s *latencyStats) observe(latency, ttfb time.Duration, success bool) {
+	outcome := 0.0
+	if !success {
+		outcome = 1
+	}
+	if s.samples == 0 {
+		s.failureRate = outcome
+	} else {
+		s.failureRate += latencyEWMAAlpha * (outcome - s.failureRate)
+	}
+	s.samples++
+	if !success {
+		return
+	}
+	if latency > 0 {
+		s.latencyMs = ewma(s.latencyMs, latency, s.latencySamples)
+		s.latencySamples++
+	}
+	if ttfb > 0 {
+		s.ttfbMs = ewma(s.ttfbMs, ttfb, s.ttfbSamples)
+		s.ttfbSamples++
+	}
+}
+
+// ewma folds sample into current, seeding it with the first sample.
+func ewma(current float64, sample time.Duration, samples int64) float64 {
+	ms := float64(sample) / float64(time.Millisecond)
+	if samples == 0 {
+		return ms
+	}
+	return current + latencyEWMAAlpha*(ms-current)
+}
+
+// score returns the latency figure used to rank the credential, or ok=false when it has no
+// sample of that kind yet.
+func (s latencyStats) score(useTTFB bool) (ms float64, ok bool) {
+	if useTTFB {
+		return s.ttfbMs, s.ttfbSamples > 0
+	}
+	return s.latencyMs, s.latencySamples > 0
+}
+
+// LatencySnapshot returns the rolling latency statistics recorded for the auth.
+func (a *Auth) LatencySnapshot() LatencySnapshot {
+	if a == nil {
+		return LatencySnapshot{}
+	}
+	return LatencySnapshot{
+		Latency:     time.Duration(a.latencyStats.latencyMs * float64(time.Millisecond)),
+		TTFB:        time.Duration(a.latencyStats.ttfbMs * float64(time.Millisecond)),
+		FailureRate: a.latencyStats.failureRate,
+		Samples:     a.latencyStats.samples,
+	}
+}
+
+func authWeight(auth *Auth) int {
+	if auth == nil || auth.Attributes == nil {
+		return 1
+	}
+	raw := strings.TrimSpace(auth.Attributes["weight"])
+	if raw == "" {
+		return 1
+	}
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed <= 0 {
+		return 1
+	}
+	return parsed
+}
+
+// Pick selects the next auth by smooth weighted round-robin.
+func (s *WeightedSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = opts
+	available, err := getAvailableAuths(auths, provider, model, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	available = preferCodexWebsocketAuths(ctx, provider, available)
+	if len(available) == 1 {
+		return available[0], nil
+	}
+	key := provider + ":" + canonicalModelKey(model)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	limit := s.maxKeys
+	if limit <= 0 {
+		limit = 4096
+	}
+	if s.current == nil || (s.current[key] == nil && len(s.current) >= limit) {
+		s.current = make(map[string]map[string]int)
+	}
+	current := s.current[key]
+	if current == nil {
+		current = make(map[string]int, len(available))
+		s.current[key] = current
+	}
+	total := 0
+	var best *Auth
+	for _, candidate := range available {
+		weight := authWeight(candidate)
+		current[candidate.ID] += weight
+		total += weight
+		if best == nil || current[candidate.ID] > current[best.ID] {
+			best = candidate
+		}
+	}
+	current[best.ID] -= total
+	return best, nil
+}
+
+// Pick selects the auth with the best latency score.
+func (s *LeastLatencySelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = opts
+	available, err := getAvailableAuths(auths, provider, model, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	available = preferCodexWebsocketAuths(ctx, provider, available)
+	if len(available) == 1 {
+		return available[0], nil
+	}
+	candidates, probe := s.claimProbe(available, time.Now())
+	if probe != nil {
+		return probe, nil
+	}
+	random := rand.Float64
+	if s != nil && s.random != nil {
+		random = s.random
+	}
+	if random() < leastLatencyExploreRate {
+		return candidates[int(random()*float64(len(candidates)))%len(candidates)], nil
+	}
+
+	useTTFB := true
+	for _, candidate := range candidates {
+		if candidate.latencyStats.ttfbSamples == 0 {
+			useTTFB = false
+			break
+		}
+	}
+	// Credentials that never succeeded are scored with the slowest observed latency.
+	slowest := 1.0
+	for _, candidate := range candidates {
+		if ms, ok := candidate.latencyStats.score(useTTFB); ok && ms > slowest {
+			slowest = ms
+		}
+	}
+	var best *Auth
+	bestScore := 0.0
+	for _, candidate := range candidates {
+		stats := candidate.latencyStats
+		latency, ok := stats.score(useTTFB)
+		if !ok {
+			latency = slowest
+		}
+		score := latency * (1 + leastLatencyFailurePenalty*stats.failureRate)
+		if best == nil || score < bestScore {
+			best = candidate
+			bestScore = score
+		}
+	}
+	return best, nil
+}
+
+// claimProbe returns the first credential without samples that has no probe in flight and
+// marks it as probed. Otherwise it returns the candidates to rank: the credentials with
+// samples, or all of them when none has any.
+func (s *LeastLatencySelector) claimProbe(available []*Auth, now time.Time) ([]*Auth, *Auth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.probes == nil {
+		s.probes = make(map[string]time.Time)
+	}
+	sampled := make([]*Auth, 0, len(available))
+	for _, candidate := range available {
+		if candidate.latencyStats.samples > 0 {
+			delete(s.probes, candidate.ID)
+			sampled = append(sampled, candidate)
+			continue
+		}
+		if started, busy := s.probes[candidate.ID]; busy && now.Sub(started) < leastLatencyProbeTimeout {
+			continue
+		}
+		s.probes[candidate.ID] = now
+		return nil, candidate
+	}
+	if len(sampled) == 0 {
+		return available, nil
+	}
+	return sampled, nil
+}
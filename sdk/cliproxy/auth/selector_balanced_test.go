@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestWeightedSelectorPick_DistributesByWeight(t *testing.T) {
+	t.Parallel()
+
+	selector := &WeightedSelector{}
+	auths := []*Auth{
+		{ID: "heavy", Attributes: map[string]string{"weight": "3"}},
+		{ID: "light"},
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		got, err := selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", cliproxyexecutor.Options{}, auths)
+		if err != nil {
+			t.Fatalf("Pick() #%d error = %v", i, err)
+		}
+		counts[got.ID]++
+	}
+	if counts["heavy"] != 6 || counts["light"] != 2 {
+		t.Fatalf("counts = %v, want heavy=6 light=2", counts)
+	}
+}
+
+func TestWeightedSelectorPick_KeepsPriorityBuckets(t *testing.T) {
+	t.Parallel()
+
+	selector := &WeightedSelector{}
+	auths := []*Auth{
+		{ID: "heavy", Attributes: map[string]string{"weight": "10"}},
+		{ID: "preferred", Attributes: map[string]string{"priority": "5"}},
+	}
+	for i := 0; i < 3; i++ {
+		got, err := selector.Pick(context.Background(), "claude", "", cliproxyexecutor.Options{}, auths)
+		if err != nil {
+			t.Fatalf("Pick() error = %v", err)
+		}
+		if got.ID != "preferred" {
+			t.Fatalf("Pick() #%d auth.ID = %q, want preferred", i, got.ID)
+		}
+	}
+}
+
+func TestLeastLatencySelectorPick_PrefersFastAndHealthy(t *testing.T) {
+	t.Parallel()
+
+	selector := &LeastLatencySelector{random: func() float64 { return 0.99 }}
+	fast := &Auth{ID: "fast"}
+	slow := &Auth{ID: "slow"}
+	auths := []*Auth{fast, slow}
+
+	fast.latencyStats.observe(100*time.Millisecond, 0, true)
+	got, err := selector.Pick(context.Background(), "codex", "", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "slow" {
+		t.Fatalf("unsampled auth should be explored first, got %q", got.ID)
+	}
+
+	slow.latencyStats.observe(400*time.Millisecond, 0, true)
+	if got, _ = selector.Pick(context.Background(), "codex", "", cliproxyexecutor.Options{}, auths); got.ID != "fast" {
+		t.Fatalf("Pick() auth.ID = %q, want fast", got.ID)
+	}
+
+	for i := 0; i < 3; i++ {
+		fast.latencyStats.observe(0, 0, false)
+	}
+	if got, _ = selector.Pick(context.Background(), "codex", "", cliproxyexecutor.Options{}, auths); got.ID != "slow" {
+		t.Fatalf("failing auth should be penalized, got %q", got.ID)
+	}
+}
+
+func TestLeastLatencySelectorPick_ProbesUnsampledAuthOnce(t *testing.T) {
+	t.Parallel()
+
+	selector := &LeastLatencySelector{random: func() float64 { return 0.99 }}
+	sampled := &Auth{ID: "sampled"}
+	fresh := &Auth{ID: "fresh"}
+	sampled.latencyStats.observe(300*time.Millisecond, 0, true)
+	auths := []*Auth{sampled, fresh}
+
+	if got, _ := selector.Pick(context.Background(), "codex", "", cliproxyexecutor.Options{}, auths); got.ID != "fresh" {
+		t.Fatalf("first Pick() = %q, want the unsampled auth", got.ID)
+	}
+	for i := 0; i < 3; i++ {
+		if got, _ := selector.Pick(context.Background(), "codex", "", cliproxyexecutor.Options{}, auths); got.ID != "sampled" {
+			t.Fatalf("Pick() #%d while the probe is in flight = %q, want sampled", i, got.ID)
+		}
+	}
+}
+
+func TestLeastLatencySelectorPick_ComparesTTFBOnlyWhenAllStreamed(t *testing.T) {
+	t.Parallel()
+
+	selector := &LeastLatencySelector{random: func() float64 { return 0.99 }}
+	a := &Auth{ID: "a"}
+	b := &Auth{ID: "b"}
+	auths := []*Auth{a, b}
+
+	// a streams: fast first byte, long total. b only served non-streaming calls.
+	a.latencyStats.observe(5*time.Second, 200*time.Millisecond, true)
+	b.latencyStats.observe(1*time.Second, 0, true)
+	if got, _ := selector.Pick(context.Background(), "codex", "", cliproxyexecutor.Options{}, auths); got.ID != "b" {
+		t.Fatalf("Pick() = %q, want b by total latency", got.ID)
+	}
+
+	b.latencyStats.observe(6*time.Second, 400*time.Millisecond, true)
+	if got, _ := selector.Pick(context.Background(), "codex", "", cliproxyexecutor.Options{}, auths); got.ID != "a" {
+		t.Fatalf("Pick() = %q, want a by time to first byte", got.ID)
+	}
+}
+
+func TestManagerMarkResult_RecordsLatencySnapshot(t *testing.T) {
+	m := NewManager(nil, &LeastLatencySelector{}, nil)
+	if _, err := m.Register(context.Background(), &Auth{ID: "latency-auth", Provider: "codex"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	m.MarkResult(context.Background(), Result{AuthID: "latency-auth", Provider: "codex", Success: true, Latency: 200 * time.Millisecond, TTFB: 50 * time.Millisecond})
+	m.MarkResult(context.Background(), Result{AuthID: "latency-auth", Provider: "codex", Success: false})
+
+	auth, ok := m.GetByID("latency-auth")
+	if !ok {
+		t.Fatal("auth not found")
+	}
+	snapshot := auth.LatencySnapshot()
+	if snapshot.Samples != 2 || snapshot.Latency != 200*time.Millisecond || snapshot.TTFB != 50*time.Millisecond {
+		t.Fatalf("snapshot = %+v", snapshot)
+	}
+	if snapshot.FailureRate <= 0 || snapshot.FailureRate >= 1 {
+		t.Fatalf("failure rate = %v, want between 0 and 1", snapshot.FailureRate)
+	}
+}
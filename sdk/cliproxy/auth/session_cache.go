@@ -1,22 +1,34 @@
 package auth
 
 import (
+	"context"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // sessionEntry stores auth binding with expiration.
 type sessionEntry struct {
 	authID    string
 	expiresAt time.Time
+	// sharedAt is when the binding was last written to the shared backend.
+	sharedAt time.Time
 }
 
 // SessionCache provides TTL-based session to auth mapping with automatic cleanup.
+// When a shared backend is attached, bindings are written through to it and local misses
+// are looked up there, so a session keeps its auth across instances.
 type SessionCache struct {
 	mu      sync.RWMutex
 	entries map[string]sessionEntry
 	ttl     time.Duration
 	stopCh  chan struct{}
+	shared  SharedStateBackend
+	// sharedRetryAt is when shared lookups resume after a failed one.
+	sharedRetryAt time.Time
 }
 
 // NewSessionCache creates a cache with the specified TTL.
@@ -44,13 +56,13 @@ func (c *SessionCache) Get(sessionID string) (string, bool) {
 	entry, ok := c.entries[sessionID]
 	c.mu.RUnlock()
 	if !ok {
-		return "", false
+		return c.lookupShared(sessionID)
 	}
 	if time.Now().After(entry.expiresAt) {
 		c.mu.Lock()
 		delete(c.entries, sessionID)
 		c.mu.Unlock()
-		return "", false
+		return c.lookupShared(sessionID)
 	}
 	return entry.authID, true
 }
@@ -66,15 +78,21 @@ func (c *SessionCache) GetAndRefresh(sessionID string) (string, bool) {
 	entry, ok := c.entries[sessionID]
 	if !ok {
 		c.mu.Unlock()
-		return "", false
+		return c.lookupShared(sessionID)
 	}
 	if now.After(entry.expiresAt) {
 		delete(c.entries, sessionID)
 		c.mu.Unlock()
-		return "", false
+		return c.lookupShared(sessionID)
 	}
 	// Refresh TTL on successful access
 	entry.expiresAt = now.Add(c.ttl)
+	shared := c.shared
+	if shared != nil && now.Sub(entry.sharedAt) > c.ttl/2 {
+		// Keep the shared binding alive without writing it on every request.
+		entry.sharedAt = now
+		go c.writeShared(shared, sessionID, entry.authID)
+	}
 	c.entries[sessionID] = entry
 	c.mu.Unlock()
 	return entry.authID, true
@@ -85,12 +103,18 @@ func (c *SessionCache) Set(sessionID, authID string) {
 	if sessionID == "" || authID == "" {
 		return
 	}
+	now := time.Now()
 	c.mu.Lock()
 	c.entries[sessionID] = sessionEntry{
 		authID:    authID,
-		expiresAt: time.Now().Add(c.ttl),
+		expiresAt: now.Add(c.ttl),
+		sharedAt:  now,
 	}
+	shared := c.shared
 	c.mu.Unlock()
+	if shared != nil {
+		go c.writeShared(shared, sessionID, authID)
+	}
 }
 
 // Invalidate removes a specific session binding.
@@ -100,7 +124,17 @@ func (c *SessionCache) Invalidate(sessionID string) {
 	}
 	c.mu.Lock()
 	delete(c.entries, sessionID)
+	shared := c.shared
 	c.mu.Unlock()
+	if shared != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+			defer cancel()
+			if errDelete := shared.DeleteSessionBinding(ctx, sessionID); errDelete != nil {
+				log.Debugf("session cache: delete shared binding: %v", errDelete)
+			}
+		}()
+	}
 }
 
 // InvalidateAuth removes all sessions bound to a specific auth ID.
@@ -115,7 +149,163 @@ func (c *SessionCache) InvalidateAuth(authID string) {
 			delete(c.entries, sid)
 		}
 	}
+	shared := c.shared
+	c.mu.Unlock()
+	if shared != nil {
+		// Other instances may hold bindings this one never saw, so clear them by auth.
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+			defer cancel()
+			if errDelete := shared.DeleteAuthSessionBindings(ctx, authID); errDelete != nil {
+				log.Debugf("session cache: delete shared bindings of auth: %v", errDelete)
+			}
+		}()
+	}
+}
+
+// SessionBinding is a session-affinity binding as exported for snapshots and inspection.
+type SessionBinding struct {
+	// Key is the cache key, "provider::session::model" for bindings made by the selector.
+	Key       string    `json:"key"`
+	AuthID    string    `json:"auth_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Bindings returns the unexpired bindings ordered by key.
+func (c *SessionCache) Bindings() []SessionBinding {
+	if c == nil {
+		return nil
+	}
+	now := time.Now()
+	c.mu.RLock()
+	bindings := make([]SessionBinding, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			continue
+		}
+		bindings = append(bindings, SessionBinding{Key: key, AuthID: entry.authID, ExpiresAt: entry.expiresAt})
+	}
+	c.mu.RUnlock()
+	sort.Slice(bindings, func(i, j int) bool { return bindings[i].Key < bindings[j].Key })
+	return bindings
+}
+
+// Restore adds bindings exported by Bindings and returns how many were added. Each binding
+// keeps its expiry, capped at the cache TTL; expired bindings and keys already bound
+// locally are skipped.
+func (c *SessionCache) Restore(bindings []SessionBinding) int {
+	if c == nil {
+		return 0
+	}
+	now := time.Now()
+	restored := 0
+	c.mu.Lock()
+	for _, binding := range bindings {
+		if binding.Key == "" || binding.AuthID == "" || !binding.ExpiresAt.After(now) {
+			continue
+		}
+		if entry, ok := c.entries[binding.Key]; ok && now.Before(entry.expiresAt) {
+			continue
+		}
+		expiresAt := binding.ExpiresAt
+		if limit := now.Add(c.ttl); expiresAt.After(limit) {
+			expiresAt = limit
+		}
+		c.entries[binding.Key] = sessionEntry{authID: binding.AuthID, expiresAt: expiresAt}
+		restored++
+	}
+	c.mu.Unlock()
+	return restored
+}
+
+// InvalidateSession removes the bindings of sessionID for every provider and model, or all
+// bindings when sessionID is empty, and returns how many were removed.
+func (c *SessionCache) InvalidateSession(sessionID string) int {
+	if c == nil {
+		return 0
+	}
+	var removed []string
+	c.mu.Lock()
+	for key := range c.entries {
+		if sessionID == "" || SessionBindingSessionID(key) == sessionID {
+			delete(c.entries, key)
+			removed = append(removed, key)
+		}
+	}
+	shared := c.shared
 	c.mu.Unlock()
+	if shared != nil && len(removed) > 0 {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+			defer cancel()
+			for _, key := range removed {
+				if errDelete := shared.DeleteSessionBinding(ctx, key); errDelete != nil {
+					log.Debugf("session cache: delete shared binding: %v", errDelete)
+					return
+				}
+			}
+		}()
+	}
+	return len(removed)
+}
+
+// SessionBindingSessionID returns the session ID part of a "provider::session::model" key,
+// or the key itself when it has another shape.
+func SessionBindingSessionID(key string) string {
+	first := strings.Index(key, "::")
+	last := strings.LastIndex(key, "::")
+	if first < 0 || last <= first {
+		return key
+	}
+	return key[first+2 : last]
+}
+
+// setShared attaches the backend bindings are shared through; nil detaches it.
+func (c *SessionCache) setShared(backend SharedStateBackend) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.shared = backend
+	c.mu.Unlock()
+}
+
+// lookupShared fetches a binding missing locally from the shared backend and caches it.
+// After a failed lookup the backend is skipped for sharedSessionLookupBackoff.
+func (c *SessionCache) lookupShared(sessionID string) (string, bool) {
+	c.mu.RLock()
+	shared := c.shared
+	retryAt := c.sharedRetryAt
+	c.mu.RUnlock()
+	if shared == nil || time.Now().Before(retryAt) {
+		return "", false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedSessionLookupTimeout)
+	defer cancel()
+	authID, errLookup := shared.SessionBinding(ctx, sessionID)
+	if errLookup != nil {
+		log.Debugf("session cache: shared lookup: %v", errLookup)
+		c.mu.Lock()
+		c.sharedRetryAt = time.Now().Add(sharedSessionLookupBackoff)
+		c.mu.Unlock()
+		return "", false
+	}
+	if authID == "" {
+		return "", false
+	}
+	now := time.Now()
+	c.mu.Lock()
+	c.entries[sessionID] = sessionEntry{authID: authID, expiresAt: now.Add(c.ttl), sharedAt: now}
+	c.mu.Unlock()
+	return authID, true
+}
+
+func (c *SessionCache) writeShared(shared SharedStateBackend, sessionID, authID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+	defer cancel()
+	if errSet := shared.SetSessionBinding(ctx, sessionID, authID, c.ttl); errSet != nil {
+		log.Debugf("session cache: write shared binding: %v", errSet)
+	}
 }
 
 // Stop terminates the background cleanup goroutine.
@@ -150,3 +340,40 @@ func (c *SessionCache) cleanup() {
 	}
 	c.mu.Unlock()
 }
+
+// sessionCache returns the cache of the session-affinity selector, or nil when the active
+// selector does not keep session bindings.
+func (m *Manager) sessionCache() *SessionCache {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	selector := m.selector
+	m.mu.RUnlock()
+	if affinity, ok := selector.(*SessionAffinitySelector); ok {
+		return affinity.cache
+	}
+	return nil
+}
+
+// SessionAffinityEnabled reports whether the active selector keeps session bindings.
+func (m *Manager) SessionAffinityEnabled() bool {
+	return m.sessionCache() != nil
+}
+
+// SessionBindings returns the unexpired session-affinity bindings ordered by key.
+func (m *Manager) SessionBindings() []SessionBinding {
+	return m.sessionCache().Bindings()
+}
+
+// RestoreSessionBindings adds previously exported bindings to the session-affinity cache
+// and returns how many were added. It is a no-op while session affinity is off.
+func (m *Manager) RestoreSessionBindings(bindings []SessionBinding) int {
+	return m.sessionCache().Restore(bindings)
+}
+
+// InvalidateSession removes the session-affinity bindings of sessionID, or all bindings
+// when sessionID is empty, and returns how many were removed.
+func (m *Manager) InvalidateSession(sessionID string) int {
+	return m.sessionCache().InvalidateSession(sessionID)
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionCacheRestoreHonoursExpiry(t *testing.T) {
+	t.Parallel()
+
+	source := NewSessionCache(time.Hour)
+	t.Cleanup(source.Stop)
+	source.Set("claude::session-1::claude-sonnet-4-5", "auth-a")
+	source.Set("claude::session-1::claude-haiku-4-5", "auth-b")
+	source.Set("gemini::session-2::gemini-2.5-pro", "auth-c")
+	bindings := source.Bindings()
+	if len(bindings) != 3 {
+		t.Fatalf("Bindings() = %+v", bindings)
+	}
+	bindings = append(bindings, SessionBinding{Key: "claude::expired::model", AuthID: "auth-d", ExpiresAt: time.Now().Add(-time.Second)})
+
+	// A shorter TTL caps restored expiries.
+	restored := NewSessionCache(time.Minute)
+	t.Cleanup(restored.Stop)
+	restored.Set("gemini::session-2::gemini-2.5-pro", "auth-local")
+	if got := restored.Restore(bindings); got != 2 {
+		t.Fatalf("Restore() = %d, want 2", got)
+	}
+	if authID, ok := restored.Get("gemini::session-2::gemini-2.5-pro"); !ok || authID != "auth-local" {
+		t.Fatalf("local binding was replaced: %q, %v", authID, ok)
+	}
+	if _, ok := restored.Get("claude::expired::model"); ok {
+		t.Fatal("expired binding was restored")
+	}
+	for _, binding := range restored.Bindings() {
+		if binding.ExpiresAt.After(time.Now().Add(time.Minute)) {
+			t.Fatalf("binding %s expires at %v, beyond the cache TTL", binding.Key, binding.ExpiresAt)
+		}
+	}
+
+	if removed := restored.InvalidateSession("session-1"); removed != 2 {
+		t.Fatalf("InvalidateSession() = %d, want 2", removed)
+	}
+	if remaining := restored.Bindings(); len(remaining) != 1 || SessionBindingSessionID(remaining[0].Key) != "session-2" {
+		t.Fatalf("Bindings() after invalidate = %+v", remaining)
+	}
+}
+
+func TestManagerSetSelector_KeepsSessionBindings(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, NewSessionAffinitySelector(&RoundRobinSelector{}), nil)
+	manager.RestoreSessionBindings([]SessionBinding{{Key: "claude::session-1::model", AuthID: "auth-a", ExpiresAt: time.Now().Add(time.Hour)}})
+	manager.SetSelector(NewSessionAffinitySelector(&FillFirstSelector{}))
+	t.Cleanup(manager.StopAutoRefresh)
+
+	bindings := manager.SessionBindings()
+	if len(bindings) != 1 || bindings[0].AuthID != "auth-a" {
+		t.Fatalf("SessionBindings() after selector change = %+v", bindings)
+	}
+}
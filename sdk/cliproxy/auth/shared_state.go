@@ -0,0 +1,339 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// sharedStateQueueSize bounds the state changes waiting to be published.
+	sharedStateQueueSize = 256
+	// sharedStateTimeout bounds a single backend operation.
+	sharedStateTimeout = 3 * time.Second
+	// sharedStateRetryInterval is the pause before resubscribing after the subscription ends.
+	sharedStateRetryInterval = 2 * time.Second
+	// sharedSessionLookupTimeout bounds the backend lookup made when a session binding is
+	// not cached locally; it sits on the request path.
+	sharedSessionLookupTimeout = 500 * time.Millisecond
+	// sharedSessionLookupBackoff is how long session lookups skip the backend after one
+	// failed, so an unreachable backend does not add the lookup timeout to every request.
+	sharedSessionLookupBackoff = 10 * time.Second
+	// sharedCursorBlockSize is how many round-robin positions are reserved per backend call.
+	sharedCursorBlockSize = 16
+)
+
+// SharedStateBackend replicates routing state between proxy instances that serve the same
+// credentials, so a cooldown discovered by one instance is honoured by all of them. The
+// in-process scheduler stays the fast path: picks never wait on the backend, which only
+// carries changes between instances. Implementations must be safe for concurrent use.
+type SharedStateBackend interface {
+	// PublishAuthState stores state and notifies the other instances.
+	PublishAuthState(ctx context.Context, state SharedAuthState) error
+	// LoadAuthStates returns the latest stored state of every auth.
+	LoadAuthStates(ctx context.Context) ([]SharedAuthState, error)
+	// Subscribe calls handle for every state published by any instance until ctx is done
+	// or the subscription fails.
+	Subscribe(ctx context.Context, handle func(SharedAuthState)) error
+	// SetSessionBinding binds a session-affinity key to authID for ttl.
+	SetSessionBinding(ctx context.Context, key, authID string, ttl time.Duration) error
+	// SessionBinding returns the auth bound to key, or "" when there is none.
+	SessionBinding(ctx context.Context, key string) (string, error)
+	// DeleteSessionBinding removes the binding for key.
+	DeleteSessionBinding(ctx context.Context, key string) error
+	// DeleteAuthSessionBindings removes every binding that still points at authID,
+	// including bindings written by other instances.
+	DeleteAuthSessionBindings(ctx context.Context, authID string) error
+	// ReserveCursor advances the round-robin cursor for key by n and returns the first of
+	// the n positions reserved for the caller.
+	ReserveCursor(ctx context.Context, key string, n int) (int64, error)
+	// Close releases the backend connection.
+	Close() error
+}
+
+// SharedAuthState is the runtime availability of one auth as exchanged between instances.
+type SharedAuthState struct {
+	AuthID string `json:"auth_id"`
+	// Origin identifies the publishing instance so it can ignore its own updates.
+	Origin         string                 `json:"origin"`
+	Status         Status                 `json:"status"`
+	StatusMessage  string                 `json:"status_message,omitempty"`
+	Unavailable    bool                   `json:"unavailable"`
+	NextRetryAfter time.Time              `json:"next_retry_after"`
+	Quota          QuotaState             `json:"quota"`
+	ModelStates    map[string]*ModelState `json:"model_states,omitempty"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+// sharedStateHandle is the backend attached to a Manager and its publish queue.
+type sharedStateHandle struct {
+	backend SharedStateBackend
+	cancel  context.CancelFunc
+	updates chan SharedAuthState
+}
+
+// SetSharedState attaches backend so cooldown and quota changes recorded by MarkResult are
+// published to other instances and theirs are applied here, and so session-affinity
+// bindings and round-robin cursors are shared. A nil backend detaches the current one.
+// The previous backend, if any, is closed.
+func (m *Manager) SetSharedState(backend SharedStateBackend) {
+	if m == nil {
+		return
+	}
+	var handle *sharedStateHandle
+	if backend != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		handle = &sharedStateHandle{backend: backend, cancel: cancel, updates: make(chan SharedAuthState, sharedStateQueueSize)}
+		go m.publishSharedStates(ctx, handle)
+		go m.runSharedStateSync(ctx, backend)
+	}
+	previous := m.shared.Swap(handle)
+	if m.scheduler != nil {
+		m.scheduler.cursors.setBackend(backend)
+	}
+	m.mu.RLock()
+	selector := m.selector
+	m.mu.RUnlock()
+	m.attachSharedSelector(selector)
+	if previous != nil {
+		previous.cancel()
+		if errClose := previous.backend.Close(); errClose != nil {
+			log.Warnf("shared state: close backend: %v", errClose)
+		}
+	}
+}
+
+// sharedStateBackend returns the attached backend, or nil.
+func (m *Manager) sharedStateBackend() SharedStateBackend {
+	if m == nil {
+		return nil
+	}
+	if handle := m.shared.Load(); handle != nil {
+		return handle.backend
+	}
+	return nil
+}
+
+// attachSharedSelector points the session cache and round-robin cursors of selector at
+// the shared backend.
+func (m *Manager) attachSharedSelector(selector Selector) {
+	if affinity, ok := selector.(*SessionAffinitySelector); ok {
+		affinity.cache.setShared(m.sharedStateBackend())
+		selector = affinity.fallback
+	}
+	if roundRobin, ok := selector.(*RoundRobinSelector); ok && m.scheduler != nil {
+		roundRobin.setSharedCursors(m.scheduler.cursors)
+	}
+}
+
+// publishSharedAuthState queues the state of auth for the other instances. It never blocks;
+// changes are dropped while the queue is full.
+func (m *Manager) publishSharedAuthState(auth *Auth) {
+	handle := m.shared.Load()
+	if handle == nil || auth == nil {
+		return
+	}
+	state := SharedAuthState{
+		AuthID:         auth.ID,
+		Origin:         m.instanceID,
+		Status:         auth.Status,
+		StatusMessage:  auth.StatusMessage,
+		Unavailable:    auth.Unavailable,
+		NextRetryAfter: auth.NextRetryAfter,
+		Quota:          auth.Quota,
+		ModelStates:    auth.ModelStates,
+		UpdatedAt:      auth.UpdatedAt,
+	}
+	select {
+	case handle.updates <- state:
+	default:
+		log.Debugf("shared state: publish queue full, dropping update for %s", auth.ID)
+	}
+}
+
+func (m *Manager) publishSharedStates(ctx context.Context, handle *sharedStateHandle) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case state := <-handle.updates:
+			publishCtx, cancel := context.WithTimeout(ctx, sharedStateTimeout)
+			if errPublish := handle.backend.PublishAuthState(publishCtx, state); errPublish != nil && ctx.Err() == nil {
+				log.Debugf("shared state: publish %s: %v", state.AuthID, errPublish)
+			}
+			cancel()
+		}
+	}
+}
+
+// runSharedStateSync catches up with the stored states and then applies published ones,
+// resubscribing until ctx is done.
+func (m *Manager) runSharedStateSync(ctx context.Context, backend SharedStateBackend) {
+	for {
+		loadCtx, cancel := context.WithTimeout(ctx, sharedStateTimeout)
+		states, errLoad := backend.LoadAuthStates(loadCtx)
+		cancel()
+		if errLoad != nil && ctx.Err() == nil {
+			log.Warnf("shared state: load auth states: %v", errLoad)
+		}
+		for _, state := range states {
+			m.applySharedAuthState(state)
+		}
+
+		errSubscribe := backend.Subscribe(ctx, m.applySharedAuthState)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warnf("shared state: subscription ended: %v", errSubscribe)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sharedStateRetryInterval):
+		}
+	}
+}
+
+// applySharedAuthState merges a state published by another instance into the local auth.
+// Model states are merged one by one and the newer side wins; the auth-level fields are
+// taken when the remote state is newer than the local auth.
+func (m *Manager) applySharedAuthState(state SharedAuthState) {
+	if m == nil || state.AuthID == "" || state.Origin == m.instanceID {
+		return
+	}
+	var snapshot *Auth
+	merged := make(map[string]*ModelState)
+	m.mu.Lock()
+	if auth, ok := m.auths[state.AuthID]; ok && auth != nil && !auth.Disabled {
+		now := time.Now()
+		for model, remote := range state.ModelStates {
+			if remote == nil {
+				continue
+			}
+			if local := auth.ModelStates[model]; local != nil && !remote.UpdatedAt.After(local.UpdatedAt) {
+				continue
+			}
+			if auth.ModelStates == nil {
+				auth.ModelStates = make(map[string]*ModelState)
+			}
+			auth.ModelStates[model] = remote.Clone()
+			merged[model] = remote
+		}
+		switch {
+		case state.UpdatedAt.After(auth.UpdatedAt):
+			auth.Status = state.Status
+			auth.StatusMessage = state.StatusMessage
+			auth.Unavailable = state.Unavailable
+			auth.NextRetryAfter = state.NextRetryAfter
+			auth.Quota = state.Quota
+			auth.UpdatedAt = state.UpdatedAt
+			snapshot = auth.Clone()
+		case len(merged) > 0:
+			updateAggregatedAvailability(auth, now)
+			snapshot = auth.Clone()
+		}
+	}
+	m.mu.Unlock()
+	if snapshot == nil {
+		return
+	}
+	if m.scheduler != nil {
+		m.scheduler.upsertAuth(snapshot)
+	}
+
+	reg := registry.GetGlobalRegistry()
+	for model, remote := range merged {
+		switch {
+		case remote.Quota.Exceeded:
+			reg.SetModelQuotaExceeded(state.AuthID, model)
+			reg.SuspendClientModel(state.AuthID, model, "quota")
+		case modelStateIsClean(remote):
+			reg.ClearModelQuotaExceeded(state.AuthID, model)
+			reg.ResumeClientModel(state.AuthID, model)
+		}
+	}
+}
+
+// sharedCursors hands out round-robin positions reserved in blocks from the shared
+// backend, so the instances rotate through credentials together. It never waits on the
+// backend: when the local block is used up the next one is reserved in the background
+// and callers keep their local cursor meanwhile.
+type sharedCursors struct {
+	// enabled lets pickers skip building cursor keys while no backend is attached.
+	enabled atomic.Bool
+	mu      sync.Mutex
+	backend SharedStateBackend
+	blocks  map[string]*cursorBlock
+}
+
+type cursorBlock struct {
+	next     int64
+	end      int64
+	fetching bool
+	// retryAt delays the next reservation after a failed one.
+	retryAt time.Time
+}
+
+func (c *sharedCursors) setBackend(backend SharedStateBackend) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.backend = backend
+	c.blocks = make(map[string]*cursorBlock)
+	c.enabled.Store(backend != nil)
+	c.mu.Unlock()
+}
+
+// active reports whether a backend is attached.
+func (c *sharedCursors) active() bool {
+	return c != nil && c.enabled.Load()
+}
+
+// next returns the next shared position for key, or false when none is reserved yet.
+func (c *sharedCursors) next(key string) (int, bool) {
+	if c == nil {
+		return 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.backend == nil {
+		return 0, false
+	}
+	block := c.blocks[key]
+	if block == nil {
+		block = &cursorBlock{}
+		c.blocks[key] = block
+	}
+	if block.next >= block.end {
+		if !block.fetching && time.Now().After(block.retryAt) {
+			block.fetching = true
+			go c.reserve(c.backend, key, block)
+		}
+		return 0, false
+	}
+	position := block.next
+	block.next++
+	return int(position & 0x7fffffff), true
+}
+
+func (c *sharedCursors) reserve(backend SharedStateBackend, key string, block *cursorBlock) {
+	ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+	start, errReserve := backend.ReserveCursor(ctx, key, sharedCursorBlockSize)
+	cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	block.fetching = false
+	if errReserve != nil {
+		log.Debugf("shared state: reserve cursor %s: %v", key, errReserve)
+		block.retryAt = time.Now().Add(sharedStateRetryInterval)
+		return
+	}
+	if c.backend == backend && c.blocks[key] == block {
+		block.next = start
+		block.end = start + sharedCursorBlockSize
+	}
+}
@@ -0,0 +1,306 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// memorySharedState is an in-process SharedStateBackend shared by the managers of a test.
+type memorySharedState struct {
+	mu          sync.Mutex
+	states      map[string][]byte
+	sessions    map[string]string
+	cursors     map[string]int64
+	subscribers []chan []byte
+}
+
+func newMemorySharedState() *memorySharedState {
+	return &memorySharedState{
+		states:   make(map[string][]byte),
+		sessions: make(map[string]string),
+		cursors:  make(map[string]int64),
+	}
+}
+
+func (b *memorySharedState) PublishAuthState(_ context.Context, state SharedAuthState) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.states[state.AuthID] = payload
+	for _, subscriber := range b.subscribers {
+		subscriber <- payload
+	}
+	return nil
+}
+
+func (b *memorySharedState) LoadAuthStates(context.Context) ([]SharedAuthState, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	states := make([]SharedAuthState, 0, len(b.states))
+	for _, payload := range b.states {
+		var state SharedAuthState
+		if err := json.Unmarshal(payload, &state); err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func (b *memorySharedState) Subscribe(ctx context.Context, handle func(SharedAuthState)) error {
+	messages := make(chan []byte, 16)
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, messages)
+	b.mu.Unlock()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case payload := <-messages:
+			var state SharedAuthState
+			if err := json.Unmarshal(payload, &state); err != nil {
+				return err
+			}
+			handle(state)
+		}
+	}
+}
+
+func (b *memorySharedState) SetSessionBinding(_ context.Context, key, authID string, _ time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sessions[key] = authID
+	return nil
+}
+
+func (b *memorySharedState) SessionBinding(_ context.Context, key string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sessions[key], nil
+}
+
+func (b *memorySharedState) DeleteSessionBinding(_ context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.sessions, key)
+	return nil
+}
+
+func (b *memorySharedState) DeleteAuthSessionBindings(_ context.Context, authID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key, bound := range b.sessions {
+		if bound == authID {
+			delete(b.sessions, key)
+		}
+	}
+	return nil
+}
+
+func (b *memorySharedState) ReserveCursor(_ context.Context, key string, n int) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	start := b.cursors[key]
+	b.cursors[key] = start + int64(n)
+	return start, nil
+}
+
+func (b *memorySharedState) Close() error { return nil }
+
+func TestManagerSharedState_CooldownReachesOtherInstance(t *testing.T) {
+	model := "shared-state-model"
+	registerSchedulerModels(t, "gemini", model, "shared-a")
+	backend := newMemorySharedState()
+
+	newInstance := func() *Manager {
+		manager := NewManager(nil, &RoundRobinSelector{}, nil)
+		manager.executors["gemini"] = schedulerTestExecutor{}
+		if _, errRegister := manager.Register(context.Background(), &Auth{ID: "shared-a", Provider: "gemini"}); errRegister != nil {
+			t.Fatalf("Register() error = %v", errRegister)
+		}
+		manager.SetSharedState(backend)
+		t.Cleanup(func() { manager.SetSharedState(nil) })
+		return manager
+	}
+	first := newInstance()
+	second := newInstance()
+	waitForSubscribers(t, backend, 2)
+
+	retryAfter := time.Minute
+	first.MarkResult(context.Background(), Result{
+		AuthID:     "shared-a",
+		Provider:   "gemini",
+		Model:      model,
+		Success:    false,
+		RetryAfter: &retryAfter,
+		Error:      &Error{Message: "rate limited", HTTPStatus: http.StatusTooManyRequests},
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		auth, _ := second.GetByID("shared-a")
+		if state := auth.ModelStates[model]; state != nil && state.Quota.Exceeded {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cooldown was not applied on the second instance")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, _, errPick := second.pickNext(context.Background(), "gemini", model, cliproxyexecutor.Options{}, nil); errPick == nil {
+		t.Fatal("pickNext() on the second instance succeeded while the auth is cooling down")
+	}
+
+	// A later instance catches up from the stored state.
+	third := newInstance()
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		auth, _ := third.GetByID("shared-a")
+		if state := auth.ModelStates[model]; state != nil && state.Quota.Exceeded {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stored cooldown was not loaded by a new instance")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSessionCacheSharedBindings(t *testing.T) {
+	t.Parallel()
+
+	backend := newMemorySharedState()
+	first := NewSessionCache(time.Hour)
+	second := NewSessionCache(time.Hour)
+	t.Cleanup(first.Stop)
+	t.Cleanup(second.Stop)
+	first.setShared(backend)
+	second.setShared(backend)
+
+	first.Set("session-1", "auth-a")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if authID, ok := second.GetAndRefresh("session-1"); ok {
+			if authID != "auth-a" {
+				t.Fatalf("shared binding = %q, want auth-a", authID)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("binding set on one cache was not visible on the other")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// failingSharedState fails every session lookup and counts the attempts.
+type failingSharedState struct {
+	*memorySharedState
+	lookups atomic.Int32
+}
+
+func (b *failingSharedState) SessionBinding(context.Context, string) (string, error) {
+	b.lookups.Add(1)
+	return "", errors.New("backend unreachable")
+}
+
+func TestSessionCacheBacksOffAfterSharedLookupFailure(t *testing.T) {
+	t.Parallel()
+
+	backend := &failingSharedState{memorySharedState: newMemorySharedState()}
+	cache := NewSessionCache(time.Hour)
+	t.Cleanup(cache.Stop)
+	cache.setShared(backend)
+
+	for index := 0; index < 3; index++ {
+		if _, ok := cache.GetAndRefresh("session-1"); ok {
+			t.Fatal("lookup against a failing backend reported a binding")
+		}
+	}
+	if got := backend.lookups.Load(); got != 1 {
+		t.Fatalf("shared lookups = %d, want 1 while backing off", got)
+	}
+}
+
+func TestSessionCacheInvalidateAuthClearsSharedBindings(t *testing.T) {
+	t.Parallel()
+
+	backend := newMemorySharedState()
+	backend.sessions["remote-session"] = "auth-a"
+	backend.sessions["other-session"] = "auth-b"
+	cache := NewSessionCache(time.Hour)
+	t.Cleanup(cache.Stop)
+	cache.setShared(backend)
+
+	cache.InvalidateAuth("auth-a")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if authID, _ := backend.SessionBinding(context.Background(), "remote-session"); authID == "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("InvalidateAuth() left the shared binding of another instance")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if authID, _ := backend.SessionBinding(context.Background(), "other-session"); authID != "auth-b" {
+		t.Fatalf("binding of another auth = %q, want auth-b", authID)
+	}
+}
+
+func TestSharedCursorsReserveDisjointBlocks(t *testing.T) {
+	t.Parallel()
+
+	backend := newMemorySharedState()
+	first, second := &sharedCursors{}, &sharedCursors{}
+	first.setBackend(backend)
+	second.setBackend(backend)
+
+	take := func(cursors *sharedCursors) int {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			if position, ok := cursors.next("gemini:model:0"); ok {
+				return position
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("no shared cursor block was reserved")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if got := take(first); got != 0 {
+		t.Fatalf("first instance position = %d, want 0", got)
+	}
+	if got := take(second); got != sharedCursorBlockSize {
+		t.Fatalf("second instance position = %d, want %d", got, sharedCursorBlockSize)
+	}
+	if got := take(first); got != 1 {
+		t.Fatalf("first instance next position = %d, want 1", got)
+	}
+}
+
+func waitForSubscribers(t *testing.T, backend *memorySharedState, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		backend.mu.Lock()
+		count := len(backend.subscribers)
+		backend.mu.Unlock()
+		if count >= want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("subscribers never reached %d", want)
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startAttemptSpan opens the span covering one pass over the credential pool. Passes after
+// the first follow a cooldown wait triggered by the previous failure.
+func startAttemptSpan(ctx context.Context, attempt int, model string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "auth.attempt", trace.SpanKindInternal,
+		attribute.Int("retry.attempt", attempt+1),
+		attribute.String("gen_ai.request.model", model),
+	)
+}
+
+// pickNextMixedTraced wraps pickNextMixed in an "auth.select" span.
+func (m *Manager) pickNextMixedTraced(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
+	_, span := tracing.Start(ctx, "auth.select", trace.SpanKindInternal,
+		attribute.Int("auth.tried", len(tried)),
+	)
+	auth, executor, provider, err := m.pickNextMixed(ctx, providers, model, opts, tried)
+	if auth != nil {
+		span.SetAttributes(
+			attribute.String("auth.id", auth.ID),
+			attribute.String("auth.provider", provider),
+			attribute.String("auth.kind", authKindOf(auth)),
+		)
+	}
+	endSpan(span, err)
+	return auth, executor, provider, err
+}
+
+// startExecutorSpan opens the span covering one upstream call made by a provider executor.
+// Translation and HTTP client spans created by the executor nest under it.
+func startExecutorSpan(ctx context.Context, name string, auth *Auth, provider, model string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("auth.provider", provider),
+		attribute.String("gen_ai.request.model", model),
+	}
+	if auth != nil {
+		attrs = append(attrs, attribute.String("auth.id", auth.ID))
+	}
+	return tracing.Start(ctx, name, trace.SpanKindInternal, attrs...)
+}
+
+// endSpan records err, if any, and finishes span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		tracing.RecordError(span, err)
+		if se, ok := errors.AsType[cliproxyexecutor.StatusError](err); ok && se != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", se.StatusCode()))
+		}
+	}
+	span.End()
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestManagerExecute_RecordsTracingSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracing.Default().Configure(recorder, tracing.Options{})
+	t.Cleanup(func() { tracing.Default().Configure(nil, tracing.Options{}) })
+
+	m := NewManager(nil, nil, nil)
+	executor := &openAICompatPoolExecutor{
+		id:            "trace-provider",
+		executeErrors: map[string]error{"trace-model": &Error{HTTPStatus: http.StatusServiceUnavailable, Message: "overloaded"}},
+	}
+	m.RegisterExecutor(executor)
+	registerFallbackTestAuth(t, m, "trace-auth", "trace-provider", "trace-model", nil)
+
+	ctx, server := tracing.Start(context.Background(), "POST /v1/chat/completions", trace.SpanKindServer)
+	_, err := m.Execute(ctx, []string{"trace-provider"}, cliproxyexecutor.Request{Model: "trace-model"}, cliproxyexecutor.Options{})
+	server.End()
+	if err == nil {
+		t.Fatal("expected upstream error")
+	}
+
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID() != server.SpanContext().TraceID() {
+			t.Fatalf("span %q belongs to another trace", span.Name())
+		}
+		if _, seen := byName[span.Name()]; !seen {
+			byName[span.Name()] = span
+		}
+	}
+	attempt, okAttempt := byName["auth.attempt"]
+	selectSpan, okSelect := byName["auth.select"]
+	execSpan, okExec := byName["executor.execute"]
+	if !okAttempt || !okSelect || !okExec {
+		t.Fatalf("missing spans, got %v", byName)
+	}
+	if attempt.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Fatal("attempt span is not a child of the server span")
+	}
+	if selectSpan.Parent().SpanID() != attempt.SpanContext().SpanID() || execSpan.Parent().SpanID() != attempt.SpanContext().SpanID() {
+		t.Fatal("selection and executor spans are not children of the attempt span")
+	}
+	if value := spanAttribute(selectSpan, "auth.id"); value.AsString() != "trace-auth" {
+		t.Fatalf("auth.select auth.id = %v", value)
+	}
+	if execSpan.Status().Code != codes.Error {
+		t.Fatalf("executor span status = %v, want error", execSpan.Status())
+	}
+	if value := spanAttribute(execSpan, "http.response.status_code"); value.AsInt64() != http.StatusServiceUnavailable {
+		t.Fatalf("executor span status code = %v", value)
+	}
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
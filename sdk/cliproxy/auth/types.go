@@ -75,6 +75,8 @@ type Auth struct {
 	Metadata map[string]any `json:"metadata,omitempty"`
 	// Quota captures recent quota information for load balancers.
 	Quota QuotaState `json:"quota"`
+	// QuotaSnapshot holds the latest quota and rate-limit reading reported by the upstream.
+	QuotaSnapshot *QuotaSnapshot `json:"quota_snapshot,omitempty"`
 	// LastError stores the last failure encountered while executing or refreshing.
 	LastError *Error `json:"last_error,omitempty"`
 	// CreatedAt is the creation timestamp in UTC.
@@ -97,6 +99,7 @@ type Auth struct {
 	Failed  int64 `json:"-"`
 
 	recentRequests recentRequestRing `json:"-"`
+	latencyStats   latencyStats      `json:"-"`
 	indexAssigned  bool              `json:"-"`
 }
 
@@ -239,6 +242,7 @@ func (a *Auth) Clone() *Auth {
 			copyAuth.ModelStates[key] = state.Clone()
 		}
 	}
+	copyAuth.QuotaSnapshot = a.QuotaSnapshot.Clone()
 	copyAuth.Runtime = a.Runtime
 	return &copyAuth
 }
@@ -0,0 +1,27 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyAuthEncryptionConfig loads the auth encryption keys. Unchanged settings keep the loaded
+// keys, and a failed reload keeps the previous ones so stored auth files stay readable.
+func (s *Service) applyAuthEncryptionConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	wasEnabled := authcrypt.Enabled()
+	if err := authcrypt.Configure(cfg.AuthEncryption); err != nil {
+		log.Errorf("failed to configure auth encryption, keeping previous keys: %v", err)
+		return
+	}
+	if enabled := authcrypt.Enabled(); enabled != wasEnabled {
+		if enabled {
+			log.Infof("auth encryption enabled with key %s", authcrypt.KeyID())
+		} else {
+			log.Info("auth encryption disabled")
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyBatchConfig opens the batch store and resumes unfinished jobs, or stops the runner
+// when batches are disabled.
+func (s *Service) applyBatchConfig(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	manager := batch.Default()
+	if cfg == nil || !cfg.Batch.Enable {
+		if manager.Enabled() {
+			manager.Disable()
+			log.Info("batch API disabled")
+		}
+		return
+	}
+	dir := batch.ResolveDirectory(cfg)
+	wasEnabled := manager.Enabled()
+	if err := manager.Configure(dir, cfg.Batch.Concurrency, int64(cfg.Batch.MaxFileSizeMB)<<20); err != nil {
+		log.Errorf("failed to initialize batch API: %v", err)
+		return
+	}
+	if !wasEnabled {
+		log.Infof("batch API enabled, writing to %s (concurrency %d)", dir, cfg.Batch.Concurrency)
+	}
+}
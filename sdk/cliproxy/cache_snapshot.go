@@ -0,0 +1,153 @@
+package cliproxy
+
+import (
+	"context"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cachesnapshot"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// cacheSnapshotTimeout bounds a single save or restore.
+	cacheSnapshotTimeout = 30 * time.Second
+	// defaultCacheSnapshotInterval applies when the config leaves the interval unset, as SDK
+	// callers that skip config sanitization do.
+	defaultCacheSnapshotInterval = 5 * time.Minute
+)
+
+// cacheSnapshotState is the active snapshotter and its periodic save loop.
+type cacheSnapshotState struct {
+	snapshotter *cachesnapshot.Snapshotter
+	// location names the backend ("token store" or a directory) so reloads keep it.
+	location string
+	interval time.Duration
+	cancel   context.CancelFunc
+	done     chan struct{}
+	// restored is set once the snapshots were loaded, so reloads do not restore again.
+	restored bool
+}
+
+// applyCacheSnapshotConfig saves the session-affinity bindings and the signature cache to the
+// active token store when it can keep snapshots (PostgreSQL, object storage), and to a local
+// directory otherwise. The first call restores the saved snapshots.
+func (s *Service) applyCacheSnapshotConfig(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	if cfg == nil || cfg.CacheSnapshot.Disable {
+		s.disableCacheSnapshots("cache snapshots disabled")
+		return
+	}
+	interval := time.Duration(cfg.CacheSnapshot.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultCacheSnapshotInterval
+	}
+
+	var (
+		backend  cachesnapshot.Backend
+		location string
+	)
+	if storeBackend, ok := sdkAuth.GetTokenStore().(cachesnapshot.Backend); ok {
+		backend, location = storeBackend, "the token store"
+	} else {
+		dir := cachesnapshot.ResolveDirectory(cfg, s.configPath)
+		if dir == "" {
+			s.disableCacheSnapshots("cache snapshots disabled: no config path or writable directory is known")
+			return
+		}
+		if s.cacheSnapshot.snapshotter != nil && s.cacheSnapshot.location == dir {
+			backend = s.cacheSnapshot.snapshotter.Backend()
+		} else {
+			fileBackend, err := cachesnapshot.NewFileBackend(dir)
+			if err != nil {
+				log.Errorf("failed to initialize cache snapshots: %v", err)
+				return
+			}
+			backend = fileBackend
+		}
+		location = dir
+	}
+	if s.cacheSnapshot.snapshotter != nil && s.cacheSnapshot.location == location && s.cacheSnapshot.interval == interval {
+		return
+	}
+
+	s.stopCacheSnapshots()
+	snapshotter := cachesnapshot.New(backend, s.coreManager)
+	if !s.cacheSnapshot.restored {
+		ctx, cancel := context.WithTimeout(context.Background(), cacheSnapshotTimeout)
+		restored, err := snapshotter.Restore(ctx)
+		cancel()
+		if err != nil {
+			log.Warnf("failed to restore cache snapshots: %v", err)
+		} else {
+			log.Infof("restored %d session binding(s) and %d thinking signature(s) from cache snapshots", restored.SessionBindings, restored.Signatures)
+		}
+		s.cacheSnapshot.restored = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.cacheSnapshot.snapshotter = snapshotter
+	s.cacheSnapshot.location = location
+	s.cacheSnapshot.interval = interval
+	s.cacheSnapshot.cancel = cancel
+	s.cacheSnapshot.done = done
+	go runCacheSnapshots(ctx, snapshotter, interval, done)
+	log.Infof("cache snapshots enabled, saving to %s every %s", location, interval)
+}
+
+func runCacheSnapshots(ctx context.Context, snapshotter *cachesnapshot.Snapshotter, interval time.Duration, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			saveCacheSnapshots(ctx, snapshotter)
+		}
+	}
+}
+
+func saveCacheSnapshots(ctx context.Context, snapshotter *cachesnapshot.Snapshotter) {
+	ctx, cancel := context.WithTimeout(ctx, cacheSnapshotTimeout)
+	defer cancel()
+	if err := snapshotter.Save(ctx); err != nil {
+		log.Warnf("failed to save cache snapshots: %v", err)
+	}
+}
+
+// disableCacheSnapshots stops the active snapshotter, logging message when one was running.
+func (s *Service) disableCacheSnapshots(message string) {
+	if s.cacheSnapshot.snapshotter == nil {
+		return
+	}
+	s.stopCacheSnapshots()
+	s.cacheSnapshot.snapshotter = nil
+	s.cacheSnapshot.location = ""
+	log.Info(message)
+}
+
+// stopCacheSnapshots stops the periodic save loop and waits for a save in progress.
+func (s *Service) stopCacheSnapshots() {
+	if s.cacheSnapshot.cancel == nil {
+		return
+	}
+	s.cacheSnapshot.cancel()
+	<-s.cacheSnapshot.done
+	s.cacheSnapshot.cancel = nil
+	s.cacheSnapshot.done = nil
+}
+
+// shutdownCacheSnapshots stops the save loop and writes a final snapshot.
+func (s *Service) shutdownCacheSnapshots(ctx context.Context) {
+	if s == nil || s.cacheSnapshot.snapshotter == nil {
+		return
+	}
+	s.stopCacheSnapshots()
+	saveCacheSnapshots(context.WithoutCancel(ctx), s.cacheSnapshot.snapshotter)
+}
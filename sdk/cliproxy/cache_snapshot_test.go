@@ -0,0 +1,51 @@
+package cliproxy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestApplyCacheSnapshotConfigDefaultsZeroInterval(t *testing.T) {
+	unsetWritablePath(t)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	service := &Service{configPath: configPath, coreManager: coreauth.NewManager(nil, nil, nil)}
+
+	service.applyCacheSnapshotConfig(&config.Config{})
+	t.Cleanup(service.stopCacheSnapshots)
+
+	if service.cacheSnapshot.snapshotter == nil {
+		t.Fatal("expected cache snapshots to be enabled by a zero-value config")
+	}
+	if service.cacheSnapshot.interval != defaultCacheSnapshotInterval {
+		t.Fatalf("interval = %s, want %s", service.cacheSnapshot.interval, defaultCacheSnapshotInterval)
+	}
+	if want := filepath.Join(filepath.Dir(configPath), "cache-snapshots"); service.cacheSnapshot.location != want {
+		t.Fatalf("location = %q, want %q", service.cacheSnapshot.location, want)
+	}
+}
+
+func TestApplyCacheSnapshotConfigWithoutDirectoryStaysDisabled(t *testing.T) {
+	unsetWritablePath(t)
+	t.Chdir(t.TempDir())
+	service := &Service{coreManager: coreauth.NewManager(nil, nil, nil)}
+
+	service.applyCacheSnapshotConfig(&config.Config{})
+	t.Cleanup(service.stopCacheSnapshots)
+
+	if service.cacheSnapshot.snapshotter != nil {
+		t.Fatalf("expected cache snapshots to stay disabled, got location %q", service.cacheSnapshot.location)
+	}
+	if _, err := os.Stat("cache-snapshots"); !os.IsNotExist(err) {
+		t.Fatalf("cache-snapshots was created in the working directory: %v", err)
+	}
+}
+
+func unsetWritablePath(t *testing.T) {
+	t.Helper()
+	t.Setenv("WRITABLE_PATH", "")
+	t.Setenv("writable_path", "")
+}
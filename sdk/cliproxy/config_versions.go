@@ -0,0 +1,48 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/configversion"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyConfigVersionsConfig points the config history at the active token store when it can keep
+// versions (PostgreSQL), and at a local directory otherwise.
+func (s *Service) applyConfigVersionsConfig(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	history := configversion.Default()
+	if cfg == nil || cfg.ConfigVersions.Disable {
+		history.Configure(nil, 0)
+		return
+	}
+	maxVersions := cfg.ConfigVersions.MaxVersions
+
+	if backend, ok := sdkAuth.GetTokenStore().(configversion.Backend); ok {
+		if previous := history.Configure(backend, maxVersions); previous != backend {
+			log.Info("config version history enabled using the token store backend")
+		}
+		return
+	}
+
+	dir := configversion.ResolveDirectory(cfg, s.configPath)
+	if dir == "" {
+		if history.Configure(nil, 0) != nil {
+			log.Info("config version history disabled: no config path or writable directory is known")
+		}
+		return
+	}
+	if current, ok := history.Backend().(*configversion.FileBackend); ok && current.Dir() == dir {
+		history.Configure(current, maxVersions)
+		return
+	}
+	backend, err := configversion.NewFileBackend(dir)
+	if err != nil {
+		log.Errorf("failed to initialize config version history: %v", err)
+		return
+	}
+	history.Configure(backend, maxVersions)
+	log.Infof("config version history enabled, writing to %s", dir)
+}
@@ -0,0 +1,26 @@
+package cliproxy
+
+import (
+	"os"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/configversion"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestApplyConfigVersionsConfigWithoutDirectoryStaysDisabled(t *testing.T) {
+	unsetWritablePath(t)
+	t.Chdir(t.TempDir())
+	history := configversion.Default()
+	previous := history.Configure(nil, 0)
+	t.Cleanup(func() { history.Configure(previous, 0) })
+
+	(&Service{}).applyConfigVersionsConfig(&config.Config{})
+
+	if backend := history.Backend(); backend != nil {
+		t.Fatalf("history backend = %T, want none without a config path", backend)
+	}
+	if _, err := os.Stat("config-versions"); !os.IsNotExist(err) {
+		t.Fatalf("config-versions was created in the working directory: %v", err)
+	}
+}
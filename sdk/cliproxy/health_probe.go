@@ -0,0 +1,36 @@
+package cliproxy
+
+import (
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyHealthProbeConfig starts, reconfigures or stops the background credential prober.
+// Home mode owns credential state centrally, so probing stays off there.
+func (s *Service) applyHealthProbeConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil {
+		return
+	}
+	if cfg == nil || !cfg.HealthProbe.Enable || cfg.Home.Enabled {
+		if s.healthProbe {
+			s.coreManager.SetHealthProbe(coreauth.HealthProbeOptions{})
+			s.healthProbe = false
+			log.Info("credential health probing disabled")
+		}
+		return
+	}
+	interval := time.Duration(cfg.HealthProbe.IntervalSeconds) * time.Second
+	s.coreManager.SetHealthProbe(coreauth.HealthProbeOptions{
+		Interval:    interval,
+		Timeout:     time.Duration(cfg.HealthProbe.TimeoutSeconds) * time.Second,
+		Concurrency: cfg.HealthProbe.Concurrency,
+		Models:      cfg.HealthProbe.Models,
+	})
+	if !s.healthProbe {
+		log.Infof("credential health probing enabled every %s", interval)
+	}
+	s.healthProbe = true
+}
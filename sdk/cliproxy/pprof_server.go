@@ -7,9 +7,11 @@ import (
 	"net/http/pprof"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -18,6 +20,8 @@ type pprofServer struct {
 	server  *http.Server
 	addr    string
 	enabled bool
+	// serveMetrics exposes /metrics on the pprof listener when set.
+	serveMetrics atomic.Bool
 }
 
 func newPprofServer() *pprofServer {
@@ -50,6 +54,7 @@ func (p *pprofServer) Apply(cfg *config.Config) {
 		addr = config.DefaultPprofAddr
 	}
 	enabled := cfg.Pprof.Enable
+	p.serveMetrics.Store(cfg.Metrics.Enable && cfg.Metrics.ServeOnPprof)
 
 	p.mu.Lock()
 	currentServer := p.server
@@ -97,6 +102,14 @@ func (p *pprofServer) Shutdown(ctx context.Context) error {
 
 func (p *pprofServer) startServer(addr string) {
 	mux := newPprofMux()
+	metricsHandler := metrics.Handler()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		if !p.serveMetrics.Load() {
+			http.NotFound(w, r)
+			return
+		}
+		metricsHandler.ServeHTTP(w, r)
+	})
 	server := &http.Server{
 		Addr:              addr,
 		Handler:           mux,
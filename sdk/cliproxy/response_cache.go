@@ -0,0 +1,60 @@
+package cliproxy
+
+import (
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsecache"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// responseCacheSettings remembers the options the active backend was built with so reloads
+// that do not touch the cache keep its contents.
+type responseCacheSettings struct {
+	backend    string
+	dir        string
+	maxEntries int
+}
+
+// applyResponseCacheConfig configures the response cache with an in-memory LRU or an on-disk store.
+func (s *Service) applyResponseCacheConfig(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	cache := responsecache.Default()
+	if cfg == nil || !cfg.ResponseCache.Enable {
+		if cache.Configure(nil, "", 0) != nil {
+			log.Info("response cache disabled")
+		}
+		s.responseCache = responseCacheSettings{}
+		return
+	}
+	ttl := time.Duration(cfg.ResponseCache.TTLSeconds) * time.Second
+	settings := responseCacheSettings{
+		backend:    cfg.ResponseCache.Backend,
+		maxEntries: cfg.ResponseCache.MaxEntries,
+	}
+	if settings.backend == "disk" {
+		settings.dir = responsecache.ResolveDirectory(cfg)
+	}
+	if current := cache.Backend(); current != nil && settings == s.responseCache {
+		cache.Configure(current, settings.backend, ttl)
+		return
+	}
+
+	var backend responsecache.Backend
+	if settings.backend == "disk" {
+		diskBackend, err := responsecache.NewDiskBackend(settings.dir, settings.maxEntries)
+		if err != nil {
+			log.Errorf("failed to initialize response cache: %v", err)
+			return
+		}
+		backend = diskBackend
+		log.Infof("response cache enabled, writing to %s", settings.dir)
+	} else {
+		backend = responsecache.NewMemoryBackend(settings.maxEntries)
+		log.Infof("response cache enabled in memory (max %d entries)", settings.maxEntries)
+	}
+	cache.Configure(backend, settings.backend, ttl)
+	s.responseCache = settings
+}
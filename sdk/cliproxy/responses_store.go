@@ -0,0 +1,60 @@
+package cliproxy
+
+import (
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsestore"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// responsesStoreSettings remembers the options the active backend was built with so reloads
+// that do not touch the store keep stored conversations.
+type responsesStoreSettings struct {
+	backend    string
+	dir        string
+	maxEntries int
+}
+
+// applyResponsesStoreConfig configures the Responses API store with an in-memory LRU or an on-disk store.
+func (s *Service) applyResponsesStoreConfig(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	store := responsestore.Default()
+	if cfg == nil || !cfg.ResponsesStore.Enable {
+		if store.Configure(nil, 0) != nil {
+			log.Info("responses store disabled")
+		}
+		s.responsesStore = responsesStoreSettings{}
+		return
+	}
+	ttl := time.Duration(cfg.ResponsesStore.TTLSeconds) * time.Second
+	settings := responsesStoreSettings{
+		backend:    cfg.ResponsesStore.Backend,
+		maxEntries: cfg.ResponsesStore.MaxEntries,
+	}
+	if settings.backend == "disk" {
+		settings.dir = responsestore.ResolveDirectory(cfg)
+	}
+	if current := store.Backend(); current != nil && settings == s.responsesStore {
+		store.Configure(current, ttl)
+		return
+	}
+
+	var backend responsestore.Backend
+	if settings.backend == "disk" {
+		diskBackend, err := responsestore.NewDiskBackend(settings.dir, settings.maxEntries)
+		if err != nil {
+			log.Errorf("failed to initialize responses store: %v", err)
+			return
+		}
+		backend = diskBackend
+		log.Infof("responses store enabled, writing to %s", settings.dir)
+	} else {
+		backend = responsestore.NewMemoryBackend(settings.maxEntries)
+		log.Infof("responses store enabled in memory (max %d entries)", settings.maxEntries)
+	}
+	store.Configure(backend, ttl)
+	s.responsesStore = settings
+}
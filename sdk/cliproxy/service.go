@@ -17,6 +17,8 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisqueue"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageledger"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher/diff"
@@ -66,6 +68,24 @@ type Service struct {
 	// pprofServer manages the optional pprof HTTP debug server.
 	pprofServer *pprofServer
 
+	// responseCache records the settings of the active response cache backend.
+	responseCache responseCacheSettings
+
+	// responsesStore records the settings of the active Responses API store backend.
+	responsesStore responsesStoreSettings
+
+	// tracing records the settings of the active span exporter.
+	tracing tracingSettings
+
+	// sharedState records the settings of the attached shared-state backend.
+	sharedState config.SharedStateConfig
+
+	// cacheSnapshot holds the snapshotter saving session bindings and thinking signatures.
+	cacheSnapshot cacheSnapshotState
+
+	// healthProbe reports whether the credential prober is running.
+	healthProbe bool
+
 	// serverErr channel for server startup/shutdown errors.
 	serverErr chan error
 
@@ -507,6 +527,10 @@ func (s *Service) applyConfigUpdate(newCfg *config.Config) {
 		switch strategy {
 		case "fill-first", "fillfirst", "ff":
 			return "fill-first"
+		case "weighted", "weighted-round-robin", "wrr":
+			return "weighted"
+		case "least-latency", "leastlatency", "latency":
+			return "least-latency"
 		default:
 			return "round-robin"
 		}
@@ -526,6 +550,10 @@ func (s *Service) applyConfigUpdate(newCfg *config.Config) {
 		switch nextStrategy {
 		case "fill-first":
 			selector = &coreauth.FillFirstSelector{}
+		case "weighted":
+			selector = &coreauth.WeightedSelector{}
+		case "least-latency":
+			selector = &coreauth.LeastLatencySelector{}
 		default:
 			selector = &coreauth.RoundRobinSelector{}
 		}
@@ -546,8 +574,19 @@ func (s *Service) applyConfigUpdate(newCfg *config.Config) {
 		s.coreManager.SetSelector(selector)
 	}
 
+	s.applyAuthEncryptionConfig(newCfg)
 	s.applyRetryConfig(newCfg)
 	s.applyPprofConfig(newCfg)
+	s.applyUsageLedgerConfig(newCfg)
+	s.applyAuditLogConfig(newCfg)
+	s.applyConfigVersionsConfig(newCfg)
+	s.applyResponseCacheConfig(newCfg)
+	s.applyResponsesStoreConfig(newCfg)
+	s.applyBatchConfig(newCfg)
+	s.applyTracingConfig(newCfg)
+	s.applySharedStateConfig(newCfg)
+	s.applyCacheSnapshotConfig(newCfg)
+	s.applyHealthProbeConfig(newCfg)
 	if s.server != nil {
 		s.server.UpdateClients(newCfg)
 	}
@@ -779,6 +818,7 @@ func (s *Service) Run(ctx context.Context) error {
 		}
 	}
 
+	s.applyAuthEncryptionConfig(s.cfg)
 	s.applyRetryConfig(s.cfg)
 
 	if s.coreManager != nil && !homeEnabled {
@@ -900,6 +940,16 @@ func (s *Service) Run(ctx context.Context) error {
 	fmt.Printf("API server started successfully on: %s:%d\n", s.cfg.Host, s.cfg.Port)
 
 	s.applyPprofConfig(s.cfg)
+	s.applyUsageLedgerConfig(s.cfg)
+	s.applyAuditLogConfig(s.cfg)
+	s.applyConfigVersionsConfig(s.cfg)
+	s.applyResponseCacheConfig(s.cfg)
+	s.applyResponsesStoreConfig(s.cfg)
+	s.applyBatchConfig(s.cfg)
+	s.applyTracingConfig(s.cfg)
+	s.applySharedStateConfig(s.cfg)
+	s.applyCacheSnapshotConfig(s.cfg)
+	s.applyHealthProbeConfig(s.cfg)
 
 	if s.hooks.OnAfterStart != nil {
 		s.hooks.OnAfterStart(s)
@@ -1007,6 +1057,8 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			}
 		}
 
+		closeUsageLedgerBackend(usageledger.Default().Configure(nil, 0))
+
 		// no legacy clients to persist
 
 		if s.server != nil {
@@ -1020,6 +1072,12 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			}
 		}
 
+		s.shutdownCacheSnapshots(ctx)
+		shutdownTracingProvider(ctx, tracing.Default().Configure(nil, tracing.Options{}))
+		if s.coreManager != nil {
+			s.coreManager.SetHealthProbe(coreauth.HealthProbeOptions{})
+			s.coreManager.SetSharedState(nil)
+		}
 		usage.StopDefault()
 	})
 	return shutdownErr
@@ -1147,6 +1205,10 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 		default:
 			models = registry.GetCodexProModels()
 		}
+		if authKind == "apikey" {
+			// API keys can reach the OpenAI embeddings API; OAuth tokens cannot.
+			models = registry.WithCodexEmbeddingBuiltins(models)
+		}
 		if entry := s.resolveConfigCodexKey(a); entry != nil {
 			if len(entry.Models) > 0 {
 				models = buildCodexConfigModels(entry)
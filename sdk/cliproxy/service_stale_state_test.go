@@ -2,6 +2,7 @@ package cliproxy
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -115,11 +116,14 @@ func TestForceHomeRuntimeConfigEnablesUsageStatistics(t *testing.T) {
 func TestApplyHomeOverlayForcesUsageStatisticsEnabled(t *testing.T) {
 	baseCfg := &config.Config{}
 	baseCfg.Home.Enabled = true
-	service := &Service{cfg: baseCfg}
+	service := &Service{cfg: baseCfg, configPath: filepath.Join(t.TempDir(), "config.yaml")}
 
-	service.applyHomeOverlay(&config.Config{
+	remoteCfg := &config.Config{
 		UsageStatisticsEnabled: false,
-	})
+	}
+	remoteCfg.ConfigVersions.Disable = true
+	remoteCfg.CacheSnapshot.Disable = true
+	service.applyHomeOverlay(remoteCfg)
 
 	if service.cfg == nil || !service.cfg.UsageStatisticsEnabled {
 		t.Fatal("expected home overlay to force usage statistics enabled")
@@ -0,0 +1,36 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sharedstate"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// applySharedStateConfig attaches the configured shared-state backend to the core manager.
+// Home mode already centralizes auth state, so the backend stays detached there.
+func (s *Service) applySharedStateConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil {
+		return
+	}
+	var settings config.SharedStateConfig
+	if cfg != nil && cfg.SharedState.Enable && !cfg.Home.Enabled {
+		settings = cfg.SharedState
+	}
+	if settings == s.sharedState {
+		return
+	}
+	if !settings.Enable {
+		s.coreManager.SetSharedState(nil)
+		s.sharedState = settings
+		log.Info("shared state disabled")
+		return
+	}
+	backend, err := sharedstate.NewRedis(settings)
+	if err != nil {
+		log.Errorf("shared state: %v", err)
+		return
+	}
+	s.coreManager.SetSharedState(backend)
+	s.sharedState = settings
+	log.Infof("shared state enabled via %s", settings.Addr)
+}
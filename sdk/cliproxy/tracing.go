@@ -0,0 +1,77 @@
+package cliproxy
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// tracingSettings remembers the options the active provider was built with so reloads that
+// only change sampling or propagation keep the exporter and its queued spans.
+type tracingSettings struct {
+	endpoint    string
+	serviceName string
+	headers     map[string]string
+}
+
+func (a tracingSettings) equal(b tracingSettings) bool {
+	return a.endpoint == b.endpoint && a.serviceName == b.serviceName && maps.Equal(a.headers, b.headers)
+}
+
+// applyTracingConfig enables span export to the configured OTLP/HTTP collector.
+func (s *Service) applyTracingConfig(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	tracer := tracing.Default()
+	if cfg == nil || !cfg.Tracing.Enable {
+		if previous := tracer.Configure(nil, tracing.Options{}); previous != nil {
+			log.Info("tracing disabled")
+			go shutdownTracingProvider(context.Background(), previous)
+		}
+		s.tracing = tracingSettings{}
+		return
+	}
+	options := tracing.Options{
+		ServiceName:       cfg.Tracing.ServiceName,
+		SampleRatio:       cfg.Tracing.SampleRatio,
+		PropagateUpstream: cfg.Tracing.PropagateUpstream,
+	}
+	settings := tracingSettings{
+		endpoint:    cfg.Tracing.Endpoint,
+		serviceName: cfg.Tracing.ServiceName,
+		headers:     maps.Clone(cfg.Tracing.Headers),
+	}
+	if tracer.Enabled() && settings.equal(s.tracing) {
+		tracer.SetOptions(options)
+		return
+	}
+
+	exporter, err := tracing.NewOTLPExporter(context.Background(), settings.endpoint, settings.headers)
+	if err != nil {
+		log.Errorf("tracing: create exporter for %s: %v", settings.endpoint, err)
+		return
+	}
+	if previous := tracer.Configure(sdktrace.NewBatchSpanProcessor(exporter), options); previous != nil {
+		go shutdownTracingProvider(context.Background(), previous)
+	}
+	s.tracing = settings
+	log.Infof("tracing enabled, exporting to %s (sample ratio %g)", settings.endpoint, options.SampleRatio)
+}
+
+// shutdownTracingProvider flushes the spans provider still holds and stops its exporter.
+func shutdownTracingProvider(ctx context.Context, provider *sdktrace.TracerProvider) {
+	if provider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := provider.Shutdown(ctx); err != nil {
+		log.Warnf("tracing: shutdown provider: %v", err)
+	}
+}
@@ -22,11 +22,17 @@ type Record struct {
 	Source      string
 	RequestedAt time.Time
 	Latency     time.Duration
-	Failed      bool
-	Fail        Failure
-	Detail      Detail
+	// TimeToFirstChunk is the delay until the first streamed chunk reached the client (zero when unknown).
+	TimeToFirstChunk time.Duration
+	Failed           bool
+	Fail             Failure
+	Detail           Detail
 	// ResponseHeaders stores a snapshot of upstream response headers for usage sinks.
 	ResponseHeaders http.Header
+	// CacheHit marks records answered from the proxy response cache without contacting a provider.
+	CacheHit bool
+	// FallbackHop is the 1-based model-fallbacks hop that served the request (zero when no chain applied).
+	FallbackHop int
 }
 
 // Failure holds HTTP failure metadata for an upstream request attempt.
@@ -76,6 +82,48 @@ func RequestedModelAliasFromContext(ctx context.Context) string {
 	}
 }
 
+type fallbackHopContextKey struct{}
+
+// WithFallbackHop stores the 1-based model fallback hop executing the request.
+func WithFallbackHop(ctx context.Context, hop int) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if hop <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, fallbackHopContextKey{}, hop)
+}
+
+// FallbackHopFromContext returns the model fallback hop stored in ctx, or zero.
+func FallbackHopFromContext(ctx context.Context) int {
+	if ctx == nil {
+		return 0
+	}
+	hop, _ := ctx.Value(fallbackHopContextKey{}).(int)
+	return hop
+}
+
+type healthProbeContextKey struct{}
+
+// WithHealthProbe marks ctx as a background credential health probe. Records published with
+// such a context are dropped, so probe traffic never counts as client usage.
+func WithHealthProbe(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, healthProbeContextKey{}, true)
+}
+
+// IsHealthProbe reports whether ctx was marked by WithHealthProbe.
+func IsHealthProbe(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	probe, _ := ctx.Value(healthProbeContextKey{}).(bool)
+	return probe
+}
+
 // Plugin consumes usage records emitted by the proxy runtime.
 type Plugin interface {
 	HandleUsage(ctx context.Context, record Record)
@@ -150,9 +198,9 @@ func (m *Manager) Register(plugin Plugin) {
 }
 
 // Publish enqueues a usage record for processing. If no plugin is registered
-// the record will be discarded downstream.
+// the record will be discarded downstream. Records of health probes are dropped.
 func (m *Manager) Publish(ctx context.Context, record Record) {
-	if m == nil {
+	if m == nil || IsHealthProbe(ctx) {
 		return
 	}
 	// ensure worker is running even if Start was not called explicitly
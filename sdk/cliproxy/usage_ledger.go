@@ -0,0 +1,76 @@
+package cliproxy
+
+import (
+	"context"
+	"time"
+
+	accesspolicy "github.com/router-for-me/CLIProxyAPI/v7/internal/access/policy"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageledger"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// tokenBudgetSeedTimeout bounds the ledger scan that restores API key token budgets.
+const tokenBudgetSeedTimeout = time.Minute
+
+// applyUsageLedgerConfig points the usage ledger at the active token store when it can persist
+// usage (PostgreSQL), and at daily JSONL files otherwise.
+func (s *Service) applyUsageLedgerConfig(cfg *config.Config) {
+	if s == nil {
+		return
+	}
+	ledger := usageledger.Default()
+	if cfg == nil || !cfg.UsageLedger.Enable {
+		closeUsageLedgerBackend(ledger.Configure(nil, 0))
+		return
+	}
+	retention := cfg.UsageLedger.RetentionDays
+	defer seedTokenBudgets(ledger, cfg.APIKeyPolicies)
+
+	if backend, ok := sdkAuth.GetTokenStore().(usageledger.Backend); ok {
+		if previous := ledger.Configure(backend, retention); previous != backend {
+			closeUsageLedgerBackend(previous)
+			log.Info("usage ledger enabled using the token store backend")
+		}
+		return
+	}
+
+	dir := usageledger.ResolveDirectory(cfg)
+	if current, ok := ledger.Backend().(*usageledger.FileBackend); ok && current.Dir() == dir {
+		ledger.Configure(current, retention)
+		return
+	}
+	backend, err := usageledger.NewFileBackend(dir)
+	if err != nil {
+		log.Errorf("failed to initialize usage ledger: %v", err)
+		return
+	}
+	closeUsageLedgerBackend(ledger.Configure(backend, retention))
+	log.Infof("usage ledger enabled, writing to %s", dir)
+}
+
+// seedTokenBudgets restores the API key token budgets from the ledger in the background, so
+// a month of ledger history does not hold up startup or a config reload.
+func seedTokenBudgets(ledger *usageledger.Ledger, policies []config.APIKeyPolicy) {
+	if !ledger.Enabled() || len(policies) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tokenBudgetSeedTimeout)
+		defer cancel()
+		if errSeed := accesspolicy.Default().SeedFromLedger(ctx, ledger, policies); errSeed != nil {
+			log.Warnf("failed to restore API key token budgets from the usage ledger: %v", errSeed)
+		}
+	}()
+}
+
+func closeUsageLedgerBackend(backend usageledger.Backend) {
+	fileBackend, ok := backend.(*usageledger.FileBackend)
+	if !ok {
+		return
+	}
+	if errClose := fileBackend.Close(); errClose != nil {
+		log.Warnf("failed to close usage ledger file: %v", errClose)
+	}
+}
@@ -2,11 +2,14 @@ package translator
 
 // Common format identifiers exposed for SDK users.
 const (
-	FormatOpenAI         Format = "openai"
-	FormatOpenAIResponse Format = "openai-response"
-	FormatClaude         Format = "claude"
-	FormatGemini         Format = "gemini"
-	FormatGeminiCLI      Format = "gemini-cli"
-	FormatCodex          Format = "codex"
-	FormatAntigravity    Format = "antigravity"
+	FormatOpenAI          Format = "openai"
+	FormatOpenAIResponse  Format = "openai-response"
+	FormatOpenAIEmbedding Format = "openai-embedding"
+	FormatOpenAIAudio     Format = "openai-audio"
+	FormatClaude          Format = "claude"
+	FormatGemini          Format = "gemini"
+	FormatGeminiCLI       Format = "gemini-cli"
+	FormatGeminiEmbedding Format = "gemini-embedding"
+	FormatCodex           Format = "codex"
+	FormatAntigravity     Format = "antigravity"
 )
@@ -7,6 +7,12 @@ func TranslateRequestByFormatName(from, to Format, model string, rawJSON []byte,
 	return TranslateRequest(from, to, model, rawJSON, stream)
 }
 
+// TranslateRequestWithContextByFormatName converts a request payload between schemas by their
+// string identifiers, tracing the conversion under the span carried by ctx.
+func TranslateRequestWithContextByFormatName(ctx context.Context, from, to Format, model string, rawJSON []byte, stream bool) []byte {
+	return TranslateRequestWithContext(ctx, from, to, model, rawJSON, stream)
+}
+
 // HasResponseTransformerByFormatName reports whether a response translator exists between two schemas.
 func HasResponseTransformerByFormatName(from, to Format) bool {
 	return HasResponseTransformer(from, to)
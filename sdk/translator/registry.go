@@ -4,9 +4,12 @@ import (
 	"context"
 	"sync"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tracing"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Registry manages translation functions across schemas.
@@ -65,6 +68,15 @@ func (r *Registry) TranslateRequest(from, to Format, model string, rawJSON []byt
 	return rawJSON
 }
 
+// TranslateRequestWithContext is TranslateRequest recorded as a "translator.request" span
+// under the span carried by ctx.
+func (r *Registry) TranslateRequestWithContext(ctx context.Context, from, to Format, model string, rawJSON []byte, stream bool) []byte {
+	_, span := startTranslateSpan(ctx, "translator.request", from, to)
+	out := r.TranslateRequest(from, to, model, rawJSON, stream)
+	span.End()
+	return out
+}
+
 // HasResponseTransformer indicates whether a response translator exists.
 func (r *Registry) HasResponseTransformer(from, to Format) bool {
 	r.mu.RLock()
@@ -98,6 +110,8 @@ func (r *Registry) TranslateNonStream(ctx context.Context, from, to Format, mode
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.NonStream != nil {
+			_, span := startTranslateSpan(ctx, "translator.response", from, to)
+			defer span.End()
 			return fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 		}
 	}
@@ -134,6 +148,11 @@ func TranslateRequest(from, to Format, model string, rawJSON []byte, stream bool
 	return defaultRegistry.TranslateRequest(from, to, model, rawJSON, stream)
 }
 
+// TranslateRequestWithContext is a helper on the default registry.
+func TranslateRequestWithContext(ctx context.Context, from, to Format, model string, rawJSON []byte, stream bool) []byte {
+	return defaultRegistry.TranslateRequestWithContext(ctx, from, to, model, rawJSON, stream)
+}
+
 // HasResponseTransformer inspects the default registry.
 func HasResponseTransformer(from, to Format) bool {
 	return defaultRegistry.HasResponseTransformer(from, to)
@@ -153,3 +172,12 @@ func TranslateNonStream(ctx context.Context, from, to Format, model string, orig
 func TranslateTokenCount(ctx context.Context, from, to Format, count int64, rawJSON []byte) []byte {
 	return defaultRegistry.TranslateTokenCount(ctx, from, to, count, rawJSON)
 }
+
+// startTranslateSpan opens a translator span. Streaming chunks are not traced individually;
+// the executor span and its first-byte event cover them.
+func startTranslateSpan(ctx context.Context, name string, from, to Format) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name, trace.SpanKindInternal,
+		attribute.String("translator.from", from.String()),
+		attribute.String("translator.to", to.String()),
+	)
+}